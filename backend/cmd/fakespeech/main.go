@@ -0,0 +1,25 @@
+// Command fakespeech stands in for the speech sidecar in CI. Point the
+// backend at it with TTS_URL=http://host:8000/generate and
+// STT_URL=http://host:8000/transcribe.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"medical-ai-agent/internal/platform/fakespeech"
+)
+
+func main() {
+	addr := flag.String("addr", ":8000", "listen address")
+	dir := flag.String("fixtures", "fixtures/speech", "directory with canned audio and transcripts")
+	flag.Parse()
+
+	srv, err := fakespeech.New(*dir)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+	log.Printf("Fake speech service on %s: %s", *addr, srv)
+	log.Fatal(http.ListenAndServe(*addr, srv))
+}
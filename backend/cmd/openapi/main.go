@@ -0,0 +1,45 @@
+// Command openapi writes the OpenAPI spec of the public and admin APIs to a file.
+// Run it through go generate after changing handler request/response types.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"slices"
+
+	"medical-ai-agent/internal/admin"
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/branding"
+	"medical-ai-agent/internal/configversions"
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/devices"
+	"medical-ai-agent/internal/keys"
+	"medical-ai-agent/internal/openapi"
+	"medical-ai-agent/internal/profiles"
+	"medical-ai-agent/internal/station"
+	"medical-ai-agent/internal/version"
+)
+
+func main() {
+	out := flag.String("o", "api/openapi.json", "output file")
+	flag.Parse()
+
+	doc := openapi.Build(slices.Concat(
+		consultation.Routes(), version.Routes(), station.Routes(),
+		admin.Routes(), auth.Routes(), profiles.Routes(), configversions.Routes(),
+		branding.Routes(), devices.Routes(), keys.Routes(),
+	))
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal spec: %v", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Failed to write spec: %v", err)
+	}
+	log.Printf("OpenAPI spec written to %s", *out)
+}
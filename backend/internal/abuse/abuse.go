@@ -0,0 +1,135 @@
+// Package abuse recognizes abusive language aimed at the assistant and
+// decides how the assistant responds to repeated incidents.
+package abuse
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+// Config is the detection vocabulary and the response policy
+type Config struct {
+	Direct       []string `json:"direct"`
+	Insults      []string `json:"insults"`
+	Address      []string `json:"address"`
+	Deescalation []string `json:"deescalation"`
+	Warning      string   `json:"warning"`
+	WarnAfter    int      `json:"warn_after"`
+	NotifyAfter  int      `json:"notify_after"`
+	StaffCalled  string   `json:"staff_called"`
+}
+
+// Parse reads a policy written in YAML
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yamlconf.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid abuse policy: %w", err)
+	}
+	if len(cfg.Deescalation) == 0 {
+		return nil, fmt.Errorf("abuse policy: at least one deescalation phrase is required")
+	}
+	if cfg.WarnAfter < 0 || cfg.NotifyAfter < 0 {
+		return nil, fmt.Errorf("abuse policy: warn_after and notify_after must not be negative")
+	}
+	if cfg.WarnAfter > 0 && cfg.Warning == "" {
+		return nil, fmt.Errorf("abuse policy: warn_after is set but warning is empty")
+	}
+	if cfg.NotifyAfter > 0 && cfg.StaffCalled == "" {
+		return nil, fmt.Errorf("abuse policy: notify_after is set but staff_called is empty")
+	}
+	return &cfg, nil
+}
+
+// Default returns the built-in policy
+func Default() *Config {
+	cfg, err := Parse(defaultConfig)
+	if err != nil {
+		panic(fmt.Sprintf("built-in abuse policy: %v", err))
+	}
+	return cfg
+}
+
+// Load reads the policy from path, falling back to the built-in one when path is empty
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+type Policy struct {
+	cfg     *Config
+	address map[string]bool
+}
+
+func NewPolicy(cfg *Config) *Policy {
+	p := &Policy{cfg: cfg, address: make(map[string]bool)}
+	for _, a := range cfg.Address {
+		p.address[normalize(a)] = true
+	}
+	return p
+}
+
+// Detect returns the phrase that makes text abuse aimed at the assistant
+func (p *Policy) Detect(text string) (string, bool) {
+	words := strings.Fields(normalize(text))
+	padded := " " + strings.Join(words, " ")
+	for _, phrase := range p.cfg.Direct {
+		if strings.Contains(padded, " "+normalize(phrase)) {
+			return phrase, true
+		}
+	}
+
+	addressed := false
+	for _, w := range words {
+		if p.address[w] {
+			addressed = true
+			break
+		}
+	}
+	if !addressed {
+		return "", false
+	}
+	for _, insult := range p.cfg.Insults {
+		if strings.Contains(padded, " "+normalize(insult)) {
+			return insult, true
+		}
+	}
+	return "", false
+}
+
+// Respond decides the response to the incident-th incident of a consultation, counting from 1
+func (p *Policy) Respond(incident int) consultation.AbuseAction {
+	switch {
+	case p.cfg.NotifyAfter > 0 && incident >= p.cfg.NotifyAfter:
+		return consultation.AbuseAction{Kind: consultation.AbuseStaffCalled, Response: p.cfg.StaffCalled, Notify: incident == p.cfg.NotifyAfter}
+	case p.cfg.WarnAfter > 0 && incident >= p.cfg.WarnAfter:
+		return consultation.AbuseAction{Kind: consultation.AbuseWarning, Response: p.cfg.Warning}
+	}
+	script := p.cfg.Deescalation
+	return consultation.AbuseAction{Kind: consultation.AbuseDeescalation, Response: script[min(max(incident, 1), len(script))-1]}
+}
+
+// normalize lowercases text, folds "ё" and keeps only letters and digits
+func normalize(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+}
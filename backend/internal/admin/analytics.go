@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Without from, analytics cover the last 30 days
+const defaultAnalyticsDays = 30
+
+// MoodAnalytics shows how patients' moods changed over their consultations,
+// per day, department and persona, so management can see whether the
+// assistant calms patients down. to is exclusive; ?building, ?floor and
+// ?department narrow it to one waiting area, as for the other analytics.
+func (h *Handler) MoodAnalytics(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := analyticsPeriod(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.store.MoodRows(r.Context(), from, to, consultation.LocationFromQuery(r.URL.Query()))
+	if err != nil {
+		http.Error(w, "Failed to get mood analytics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(consultation.BuildMoodAnalytics(rows, from, to))
+}
+
+// ResponseAnalytics shows how long reports dispatched in the period waited
+// for a doctor's acknowledgment and for the visit to be closed, per doctor
+// and department. to is exclusive.
+func (h *Handler) ResponseAnalytics(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := analyticsPeriod(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.store.ResponseRows(r.Context(), from, to, consultation.LocationFromQuery(r.URL.Query()))
+	if err != nil {
+		http.Error(w, "Failed to get response-time analytics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(consultation.BuildResponseAnalytics(rows, from, to))
+}
+
+// analyticsPeriod reads ?from and ?to, answering 400 for invalid dates
+func analyticsPeriod(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	to = time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		var err error
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "to must be a date (YYYY-MM-DD)", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	from = to.AddDate(0, 0, -defaultAnalyticsDays)
+	if v := r.URL.Query().Get("from"); v != "" {
+		var err error
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "from must be a date (YYYY-MM-DD)", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return from, to, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+)
+
+type AssignmentRequest struct {
+	Room string `json:"room"`          // e.g. "12"
+	Bed  string `json:"bed,omitempty"` // e.g. "3", in the emergency department
+}
+
+// AssignRoom puts a waiting patient in a room and bed. The assignment shows
+// on the nurse station, goes to the doctor chat and is announced at the kiosk.
+func (h *Handler) AssignRoom(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		author = u.Name
+	}
+
+	c, err := h.svc.AssignRoom(r.Context(), id, req.Room, req.Bed, author)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultation.ErrInvalidAssignment):
+			http.Error(w, "room is required, room and bed up to 50 characters", http.StatusBadRequest)
+		case errors.Is(err, consultation.ErrNotWaiting):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			consultation.WriteError(w, "Failed to assign room: "+err.Error(), err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(c.Assignment)
+}
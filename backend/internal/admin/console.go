@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+)
+
+type InjectTurnRequest struct {
+	Text string `json:"text"` // what the patient would have said
+}
+
+type InjectTurnResponse struct {
+	Reply        string                     `json:"reply"`
+	Consultation *consultation.Consultation `json:"consultation"` // state right after the turn; background agents may still be running
+}
+
+// InjectTurn sends a synthetic patient turn into a consultation and returns
+// the assistant's reply, so support can reproduce an issue without a kiosk
+func (h *Handler) InjectTurn(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req InjectTurnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var authorID uuid.UUID
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		authorID, author = u.ID, u.Name
+	}
+
+	reply, err := h.svc.InjectTurn(r.Context(), id, authorID, author, req.Text)
+	if err != nil {
+		if errors.Is(err, consultation.ErrInvalidTurn) {
+			http.Error(w, "text must be 1-2000 characters", http.StatusBadRequest)
+			return
+		}
+		consultation.WriteError(w, "Turn failed: "+err.Error(), err)
+		return
+	}
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Failed to load consultation: "+err.Error(), err)
+		return
+	}
+	json.NewEncoder(w).Encode(InjectTurnResponse{Reply: reply.Text, Consultation: c})
+}
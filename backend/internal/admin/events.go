@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"medical-ai-agent/internal/consultation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// EventLog is implemented by the store when EVENT_SOURCING is enabled
+type EventLog interface {
+	Events(ctx context.Context, id uuid.UUID) ([]consultation.Event, error)
+}
+
+// ListEvents returns the consultation's immutable change log
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	log, ok := h.store.(EventLog)
+	if !ok {
+		http.Error(w, "Event log is not enabled", http.StatusNotFound)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	events, err := log.Events(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Failed to load events: "+err.Error(), err)
+		return
+	}
+	if events == nil {
+		events = []consultation.Event{}
+	}
+
+	json.NewEncoder(w).Encode(events)
+}
@@ -0,0 +1,488 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/flags"
+	"medical-ai-agent/internal/openapi"
+	"medical-ai-agent/internal/report"
+)
+
+// ConsultationStore is the subset of the consultation repository used by operators
+type ConsultationStore interface {
+	Stats(ctx context.Context, loc consultation.Location) (*consultation.Stats, error)
+	MoodRows(ctx context.Context, from, to time.Time, loc consultation.Location) ([]consultation.MoodRow, error)
+	ResponseRows(ctx context.Context, from, to time.Time, loc consultation.Location) ([]consultation.ResponseRow, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+	Search(ctx context.Context, filter consultation.SearchFilter) ([]uuid.UUID, error)
+	CompletedBetween(ctx context.Context, from, to time.Time) ([]uuid.UUID, error)
+	ReasoningTraces(ctx context.Context, consultationID uuid.UUID) ([]consultation.ReasoningTrace, error)
+}
+
+// Anonymizer strips identifiers from consultations for research export
+type Anonymizer interface {
+	Anonymize(c consultation.Consultation) consultation.Consultation
+}
+
+// DeliveryTracker exposes reports that failed to reach the doctor
+type DeliveryTracker interface {
+	FailedDeliveries() []report.FailedDelivery
+	RetryDelivery(ctx context.Context, consultationID uuid.UUID) error
+}
+
+// ReportRenderer builds the doctor's PDF on demand
+type ReportRenderer interface {
+	RenderReport(c consultation.Consultation, internal bool) ([]byte, error)
+}
+
+// FlagLister exposes the effective feature flag rules
+type FlagLister interface {
+	Rules() []flags.Rule
+}
+
+type Handler struct {
+	svc     consultation.Service
+	store   ConsultationStore
+	reports DeliveryTracker
+	render  ReportRenderer
+	flags   FlagLister
+	anon    Anonymizer
+	config  map[string]any
+	// encrypted tells Purge whether the event log is erased with the patient keys
+	encrypted bool
+}
+
+// NewHandler creates the admin handler. config is returned as-is by GET /config,
+// so it must not contain secrets; its "encryption" entry also tells Purge
+// whether patient data is encrypted.
+func NewHandler(svc consultation.Service, store ConsultationStore, reports DeliveryTracker, render ReportRenderer, flags FlagLister, anon Anonymizer, config map[string]any) *Handler {
+	encrypted, _ := config["encryption"].(bool)
+	return &Handler{
+		svc:       svc,
+		store:     store,
+		reports:   reports,
+		render:    render,
+		flags:     flags,
+		anon:      anon,
+		config:    config,
+		encrypted: encrypted,
+	}
+}
+
+type PurgeRequest struct {
+	OlderThanDays int `json:"older_than_days"`
+}
+
+type PurgeResponse struct {
+	Deleted int64 `json:"deleted"`
+	// The immutable event log still holds the purged consultations, readable
+	// only with the patient keys; see /admin/patients/{patientID}/erase
+	EventsKept bool `json:"events_kept,omitempty"`
+}
+
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.Stats(r.Context(), consultation.LocationFromQuery(r.URL.Query()))
+	if err != nil {
+		consultation.WriteError(w, "Failed to load stats: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.config)
+}
+
+func (h *Handler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.flags.Rules())
+}
+
+// SearchConsultations lists consultations mentioning a normalized symptom code
+// and/or carrying a staff tag
+func (h *Handler) SearchConsultations(w http.ResponseWriter, r *http.Request) {
+	filter := consultation.SearchFilter{Code: r.URL.Query().Get("code")}
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		var err error
+		if filter.Tag, err = consultation.NormalizeTag(tag); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.Code == "" && filter.Tag == "" {
+		http.Error(w, "code or tag is required", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := h.store.Search(r.Context(), filter)
+	if err != nil {
+		consultation.WriteError(w, "Search failed: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ids)
+}
+
+func (h *Handler) Reanalyze(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.Reanalyze(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Reanalysis failed: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c)
+}
+
+type LinkRequest struct {
+	LinkedID uuid.UUID                 `json:"linked_id"`
+	Relation consultation.LinkRelation `json:"relation"` // follow_up_of, transferred_from
+}
+
+// LinkConsultation records that a consultation continues an earlier one
+func (h *Handler) LinkConsultation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req LinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.LinkConsultation(r.Context(), id, req.LinkedID, req.Relation)
+	if err != nil {
+		if errors.Is(err, consultation.ErrInvalidLink) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		consultation.WriteError(w, "Failed to link consultations: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c.Links)
+}
+
+type VisitRequest struct {
+	State consultation.VisitState `json:"state"` // "called", "in_room" or "done"
+	Room  string                  `json:"room,omitempty"`
+}
+
+// UpdateVisit moves a patient through the waiting-room queue shown on the board
+func (h *Handler) UpdateVisit(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req VisitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.SetVisitState(r.Context(), id, req.State, req.Room)
+	if err != nil {
+		if errors.Is(err, consultation.ErrInvalidVisitState) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		consultation.WriteError(w, "Failed to update visit: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c.Visit)
+}
+
+type UpdateFactsRequest struct {
+	Facts []consultation.MedicalFact `json:"facts"`
+}
+
+// ListReviews returns completed consultations waiting for nurse approval,
+// optionally in one waiting area (?building, ?floor, ?department)
+func (h *Handler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.ListPendingReviews(r.Context(), consultation.LocationFromQuery(r.URL.Query()))
+	if err != nil {
+		consultation.WriteError(w, "Failed to load review queue: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *Handler) GetReview(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Consultation not found", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *Handler) UpdateReviewFacts(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateFactsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.UpdateFacts(r.Context(), id, req.Facts)
+	if err != nil {
+		if errors.Is(err, consultation.ErrNotPendingReview) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		consultation.WriteError(w, "Failed to update facts: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c)
+}
+
+// ApproveReview signs off a consultation and dispatches its report
+func (h *Handler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+	reviewer, _ := auth.UserFromContext(r.Context())
+
+	c, err := h.svc.ApproveReview(r.Context(), id, reviewer.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultation.ErrNotPendingReview):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case c != nil:
+			// Approved, but the report is now in the failed deliveries
+			http.Error(w, "Approved, "+err.Error(), http.StatusBadGateway)
+		default:
+			consultation.WriteError(w, "Approval failed: "+err.Error(), err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *Handler) ListFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.reports.FailedDeliveries())
+}
+
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reports.RetryDelivery(r.Context(), id); err != nil {
+		if errors.Is(err, report.ErrNoFailedDelivery) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Retry failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportResearch streams anonymized completed consultations as JSON Lines.
+// from/to are dates (YYYY-MM-DD) of consultation creation, to is exclusive.
+func (h *Handler) ExportResearch(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(time.DateOnly, r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "from must be a date (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "to must be a date (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ids, err := h.store.CompletedBetween(r.Context(), from, to)
+	if err != nil {
+		consultation.WriteError(w, "Export failed: "+err.Error(), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="consultations-research.jsonl"`)
+	enc := json.NewEncoder(w)
+	for _, id := range ids {
+		c, err := h.svc.GetConsultation(r.Context(), id)
+		if err != nil {
+			// Headers are already sent; a partial file is still research-safe
+			log.Printf("Research export: skipping consultation: %v", err)
+			continue
+		}
+		if err := enc.Encode(h.anon.Anonymize(*c)); err != nil {
+			return
+		}
+	}
+}
+
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	var req PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.OlderThanDays <= 0 {
+		http.Error(w, "older_than_days must be positive", http.StatusBadRequest)
+		return
+	}
+
+	// The event log cannot be deleted from; without encryption a purge would
+	// leave the whole history readable while looking like it removed it
+	_, eventSourced := h.store.(EventLog)
+	if eventSourced && !h.encrypted {
+		http.Error(w, "Purge would only remove the projection: EVENT_SOURCING keeps every consultation in the append-only event log in plaintext. Enable ENCRYPTION_MASTER_KEY and erase patients through /admin/patients/{patientID}/erase", http.StatusConflict)
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -req.OlderThanDays)
+	deleted, err := h.store.DeleteOlderThan(r.Context(), before)
+	if err != nil {
+		consultation.WriteError(w, "Purge failed: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(PurgeResponse{Deleted: deleted, EventsKept: eventSourced})
+}
+
+// RegisterRoutes mounts the admin endpoints. auth.Authenticate must already be
+// applied; each endpoint checks the permission it needs.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.With(auth.Require(auth.PermViewStats)).Get("/stats", h.GetStats)
+	r.With(auth.Require(auth.PermViewStats)).Get("/analytics/mood", h.MoodAnalytics)
+	r.With(auth.Require(auth.PermViewStats)).Get("/analytics/response-times", h.ResponseAnalytics)
+	r.With(auth.Require(auth.PermViewConfig)).Get("/config", h.GetConfig)
+	r.With(auth.Require(auth.PermViewConfig)).Get("/flags", h.ListFlags)
+	r.With(auth.Require(auth.PermViewStats)).Get("/consultations", h.SearchConsultations)
+	r.With(auth.Require(auth.PermReanalyze)).Post("/consultations/{id}/reanalyze", h.Reanalyze)
+	r.With(auth.Require(auth.PermAnnotateFacts)).Post("/consultations/{id}/links", h.LinkConsultation)
+	r.With(auth.Require(auth.PermManageQueue)).Post("/consultations/{id}/visit", h.UpdateVisit)
+	r.With(auth.Require(auth.PermManageQueue)).Put("/consultations/{id}/assignment", h.AssignRoom)
+	r.With(auth.Require(auth.PermAnnotateFacts)).Put("/consultations/{id}/tags", h.SetTags)
+	r.With(auth.Require(auth.PermAnnotateFacts)).Get("/consultations/{id}/notes", h.ListNotes)
+	r.With(auth.Require(auth.PermAnnotateFacts)).Post("/consultations/{id}/notes", h.AddNote)
+	r.With(auth.Require(auth.PermViewStats)).Get("/consultations/{id}/report", h.GetReport)
+	r.With(auth.Require(auth.PermViewStats)).Get("/consultations/{id}/events", h.ListEvents)
+	r.With(auth.Require(auth.PermViewStats)).Get("/consultations/{id}/reasoning", h.ListReasoning)
+	r.With(auth.Require(auth.PermInjectTurns)).Post("/consultations/{id}/inject", h.InjectTurn)
+	r.With(auth.Require(auth.PermAskPatient)).Post("/consultations/{id}/questions", h.AskQuestion)
+	r.With(auth.Require(auth.PermAcknowledge)).Post("/consultations/{id}/acknowledge", h.AcknowledgeReport)
+	r.With(auth.Require(auth.PermRelay)).Post("/consultations/{id}/relay", h.RelayReply)
+	r.With(auth.Require(auth.PermRelay)).Delete("/consultations/{id}/relay", h.EndBridge)
+	r.With(auth.Require(auth.PermReview)).Get("/reviews", h.ListReviews)
+	r.With(auth.Require(auth.PermReview)).Get("/reviews/{id}", h.GetReview)
+	r.With(auth.Require(auth.PermReview), auth.Require(auth.PermAnnotateFacts)).Put("/reviews/{id}/facts", h.UpdateReviewFacts)
+	r.With(auth.Require(auth.PermReview)).Post("/reviews/{id}/approve", h.ApproveReview)
+	r.With(auth.Require(auth.PermManageDelivery)).Get("/deliveries/failed", h.ListFailedDeliveries)
+	r.With(auth.Require(auth.PermManageDelivery)).Post("/deliveries/failed/{id}/retry", h.RetryDelivery)
+	r.With(auth.Require(auth.PermExportResearch)).Get("/export/research", h.ExportResearch)
+	r.With(auth.Require(auth.PermPurge)).Post("/purge", h.Purge)
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// All of them need a staff login; the permission is named in the summary.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/stats", Summary: "Consultation statistics, ?building, ?floor and ?department for one waiting area (view_stats)", Tags: tags,
+			Response: consultation.Stats{}},
+		{Method: http.MethodGet, Path: "/admin/analytics/mood", Summary: "Patient mood by day, ?from and ?to as dates, ?building, ?floor and ?department for one waiting area (view_stats)", Tags: tags,
+			Response: consultation.MoodAnalytics{}},
+		{Method: http.MethodGet, Path: "/admin/analytics/response-times", Summary: "Report acknowledgment and visit times by day, ?from and ?to as dates, ?building, ?floor and ?department for one waiting area (view_stats)", Tags: tags,
+			Response: consultation.ResponseAnalytics{}},
+		{Method: http.MethodGet, Path: "/admin/config", Summary: "Effective server configuration (view_config)", Tags: tags,
+			Response: map[string]any{}},
+		{Method: http.MethodGet, Path: "/admin/flags", Summary: "Effective feature flag rules (view_config)", Tags: tags,
+			Response: []flags.Rule{}},
+		{Method: http.MethodGet, Path: "/admin/consultations", Summary: "IDs of consultations with a normalized symptom ?code and/or a staff ?tag (view_stats)", Tags: tags,
+			Response: []uuid.UUID{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/reanalyze", Summary: "Run the Analyst and recommendations again on the stored transcript (reanalyze)", Tags: tags,
+			Response: consultation.Consultation{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/links", Summary: "Link the consultation to an earlier one it continues (annotate_facts)", Tags: tags,
+			Request: LinkRequest{}, Response: []consultation.LinkedConsultation{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/visit", Summary: "Move the patient through the waiting-room queue (manage_queue)", Tags: tags,
+			Request: VisitRequest{}, Response: consultation.Visit{}},
+		{Method: http.MethodPut, Path: "/admin/consultations/{id}/assignment", Summary: "Assign a room and bed to the waiting patient (manage_queue)", Tags: tags,
+			Request: AssignmentRequest{}, Response: consultation.Assignment{}},
+		{Method: http.MethodPut, Path: "/admin/consultations/{id}/tags", Summary: "Replace the staff tags (annotate_facts)", Tags: tags,
+			Request: TagsRequest{}, Response: TagsResponse{}},
+		{Method: http.MethodGet, Path: "/admin/consultations/{id}/notes", Summary: "Internal staff notes (annotate_facts)", Tags: tags,
+			Response: []consultation.Note{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/notes", Summary: "Add an internal note signed by the current user (annotate_facts)", Tags: tags,
+			Request: NoteRequest{}, Response: consultation.Note{}},
+		{Method: http.MethodGet, Path: "/admin/consultations/{id}/report", Summary: "Render the doctor's PDF, ?internal=true adds tags and notes (view_stats, annotate_facts for internal)", Tags: tags,
+			ResponseType: "application/pdf"},
+		{Method: http.MethodGet, Path: "/admin/consultations/{id}/events", Summary: "Event log of the consultation, only with EVENT_SOURCING (view_stats)", Tags: tags,
+			Response: []consultation.Event{}},
+		{Method: http.MethodGet, Path: "/admin/consultations/{id}/reasoning", Summary: "Recorded agent reasoning traces (view_stats)", Tags: tags,
+			Response: []consultation.ReasoningTrace{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/inject", Summary: "Send a test patient turn from the support console; it raises no alerts and sends no report (inject_turns)", Tags: tags,
+			Request: InjectTurnRequest{}, Response: InjectTurnResponse{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/questions", Summary: "Have the assistant ask the patient a doctor's question on its next turn (ask_patient)", Tags: tags,
+			Request: AskQuestionRequest{}, Response: consultation.DoctorQuestion{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/acknowledge", Summary: "Mark the sent report as read (acknowledge)", Tags: tags,
+			Response: consultation.Acknowledgment{}},
+		{Method: http.MethodPost, Path: "/admin/consultations/{id}/relay", Summary: "Answer a patient relayed to staff during an LLM outage (relay)", Tags: tags,
+			Request: RelayReplyRequest{}, Response: consultation.Message{}},
+		{Method: http.MethodDelete, Path: "/admin/consultations/{id}/relay", Summary: "Hand the relayed patient back to the assistant (relay)", Tags: tags,
+			Response: consultation.Bridge{}},
+		{Method: http.MethodGet, Path: "/admin/reviews", Summary: "Completed consultations waiting for nurse approval, ?building, ?floor and ?department for one waiting area (review)", Tags: tags,
+			Response: []consultation.ReviewQueueItem{}},
+		{Method: http.MethodGet, Path: "/admin/reviews/{id}", Summary: "Consultation under review (review)", Tags: tags,
+			Response: consultation.Consultation{}},
+		{Method: http.MethodPut, Path: "/admin/reviews/{id}/facts", Summary: "Correct the facts before approval (review, annotate_facts)", Tags: tags,
+			Request: UpdateFactsRequest{}, Response: consultation.Consultation{}},
+		{Method: http.MethodPost, Path: "/admin/reviews/{id}/approve", Summary: "Approve the report and send it to the doctor (review)", Tags: tags,
+			Response: consultation.Consultation{}},
+		{Method: http.MethodGet, Path: "/admin/deliveries/failed", Summary: "Reports that could not be delivered, kept across restarts (manage_delivery)", Tags: tags,
+			Response: []report.FailedDelivery{}},
+		{Method: http.MethodPost, Path: "/admin/deliveries/failed/{id}/retry", Summary: "Send a failed report again to the recipients that did not get it (manage_delivery)", Tags: tags},
+		{Method: http.MethodGet, Path: "/admin/export/research", Summary: "Anonymized consultations completed since ?from, up to ?to, as JSON Lines (export_research)", Tags: tags,
+			ResponseType: "application/x-ndjson", Response: consultation.Consultation{}},
+		{Method: http.MethodPost, Path: "/admin/purge", Summary: "Delete consultations older than a date; refused while the event log keeps unencrypted history (purge)", Tags: tags,
+			Request: PurgeRequest{}, Response: PurgeResponse{}},
+	}
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+)
+
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type TagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+type NoteRequest struct {
+	Text string `json:"text"`
+}
+
+// SetTags replaces a consultation's staff tags
+func (h *Handler) SetTags(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req TagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.SetTags(r.Context(), id, req.Tags)
+	if err != nil {
+		if errors.Is(err, consultation.ErrInvalidTag) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		consultation.WriteError(w, "Failed to set tags: "+err.Error(), err)
+		return
+	}
+
+	tags := c.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	json.NewEncoder(w).Encode(TagsResponse{Tags: tags})
+}
+
+// ListNotes returns a consultation's internal notes, oldest first
+func (h *Handler) ListNotes(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Consultation not found", err)
+		return
+	}
+
+	notes := c.Notes
+	if notes == nil {
+		notes = []consultation.Note{}
+	}
+	json.NewEncoder(w).Encode(notes)
+}
+
+// AddNote attaches an internal note signed by the current user
+func (h *Handler) AddNote(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var authorID uuid.UUID
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		authorID, author = u.ID, u.Name
+	}
+
+	n, err := h.svc.AddNote(r.Context(), id, authorID, author, req.Text)
+	if err != nil {
+		if errors.Is(err, consultation.ErrInvalidNote) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		consultation.WriteError(w, "Failed to add note: "+err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(n)
+}
+
+// GetReport renders the doctor's PDF. ?internal=true adds tags and notes and
+// needs the annotate permission.
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	internal := r.URL.Query().Get("internal") == "true"
+	if internal {
+		if u, ok := auth.UserFromContext(r.Context()); !ok || !u.Can(auth.PermAnnotateFacts) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Consultation not found", err)
+		return
+	}
+
+	data, err := h.render.RenderReport(*c, internal)
+	if err != nil {
+		consultation.WriteError(w, "Failed to render report: "+err.Error(), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="report_%s.pdf"`, c.ID))
+	w.Write(data)
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+)
+
+type AskQuestionRequest struct {
+	Text string `json:"text"` // e.g. "спросите, принимал ли он сегодня инсулин"
+}
+
+// AskQuestion lets a doctor following the interview have the assistant ask
+// the patient a question on its next turn
+func (h *Handler) AskQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AskQuestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var authorID uuid.UUID
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		authorID, author = u.ID, u.Name
+	}
+
+	q, err := h.svc.AskDoctorQuestion(r.Context(), id, authorID, author, consultation.QuestionViaAPI, req.Text)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultation.ErrInvalidQuestion):
+			http.Error(w, "text must be 1-500 characters", http.StatusBadRequest)
+		case errors.Is(err, consultation.ErrConsultationComplete):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			consultation.WriteError(w, "Failed to add question: "+err.Error(), err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(q)
+}
+
+// AcknowledgeReport records that the doctor has read the consultation's report
+func (h *Handler) AcknowledgeReport(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var doctorID uuid.UUID
+	doctor := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		doctorID, doctor = u.ID, u.Name
+	}
+
+	c, err := h.svc.AcknowledgeReport(r.Context(), id, doctorID, doctor, consultation.QuestionViaAPI)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultation.ErrReportNotDispatched), errors.Is(err, consultation.ErrAlreadyAcknowledged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			consultation.WriteError(w, "Failed to acknowledge report: "+err.Error(), err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(c.Acknowledgment)
+}
+
+type RelayReplyRequest struct {
+	Text string `json:"text"` // e.g. "медсестра подойдёт через пять минут"
+}
+
+// RelayReply answers the patient of a consultation relayed to staff while the
+// assistant is unavailable
+func (h *Handler) RelayReply(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req RelayReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		author = u.Name
+	}
+
+	m, err := h.svc.RelayReply(r.Context(), id, author, req.Text)
+	if err != nil {
+		switch {
+		case errors.Is(err, consultation.ErrInvalidRelayReply):
+			http.Error(w, "text must be 1-500 characters", http.StatusBadRequest)
+		case errors.Is(err, consultation.ErrConsultationComplete), errors.Is(err, consultation.ErrNoBridge):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			consultation.WriteError(w, "Failed to relay reply: "+err.Error(), err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+// EndBridge hands the patient of a relayed consultation back to the assistant
+func (h *Handler) EndBridge(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	author := "unknown"
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		author = u.Name
+	}
+
+	c, err := h.svc.EndBridge(r.Context(), id, author)
+	if err != nil {
+		if errors.Is(err, consultation.ErrNoBridge) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		consultation.WriteError(w, "Failed to end relay: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(c.Bridge)
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"medical-ai-agent/internal/consultation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ListReasoning returns what a reasoning model thought before each
+// Communicator reply, empty unless LLM_REASONING_AUDIT is on
+func (h *Handler) ListReasoning(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	traces, err := h.store.ReasoningTraces(r.Context(), id)
+	if err != nil {
+		consultation.WriteError(w, "Failed to load reasoning: "+err.Error(), err)
+		return
+	}
+	if traces == nil {
+		traces = []consultation.ReasoningTrace{}
+	}
+
+	json.NewEncoder(w).Encode(traces)
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// analystOutput is the Analyst's reply: the schema it is constrained to and
+// the checks it has to pass. Codes are linked by the service afterwards.
+type analystOutput struct {
+	Facts           []analystFact       `json:"facts"`
+	Negatives       []analystNegative   `json:"negatives"`
+	Medications     []analystMedication `json:"medications"`
+	PriorConditions []analystCondition  `json:"prior_conditions"`
+}
+
+// Confidence is one of the Russian grades the prompt asks for; the English
+// ones of older replies are still accepted
+type analystFact struct {
+	Category    string `json:"category" schema:"nonempty"`
+	Description string `json:"description" schema:"nonempty"`
+	Confidence  string `json:"confidence" enum:"Высокая|Средняя|Низкая|High|Medium|Low"`
+}
+
+type analystNegative struct {
+	Symptom    string `json:"symptom" schema:"nonempty"`
+	Context    string `json:"context"`
+	Confidence string `json:"confidence" enum:"Высокая|Средняя|Низкая|High|Medium|Low"`
+}
+
+type analystMedication struct {
+	Name      string `json:"name" schema:"nonempty"`
+	Dose      string `json:"dose"`
+	Schedule  string `json:"schedule"`
+	Adherence string `json:"adherence"`
+}
+
+type analystCondition struct {
+	Name      string `json:"name" schema:"nonempty"`
+	Since     string `json:"since"`
+	Treatment string `json:"treatment"`
+}
+
+var analystSchema = schemaOf("analysis", analystOutput{})
+
+// repairPrompt goes back to the model with the reason its reply was rejected
+const repairPrompt = "Ответ не прошёл проверку: %v. Верни исправленный ответ целиком: только JSON-объект в описанном формате, без пояснений и Markdown."
+
+func (o analystOutput) result() *consultation.AnalysisResult {
+	r := &consultation.AnalysisResult{}
+	for _, f := range o.Facts {
+		r.Facts = append(r.Facts, consultation.MedicalFact{Category: f.Category, Description: f.Description, Confidence: f.Confidence})
+	}
+	for _, n := range o.Negatives {
+		r.Negatives = append(r.Negatives, consultation.PertinentNegative{Symptom: n.Symptom, Context: n.Context, Confidence: n.Confidence})
+	}
+	for _, m := range o.Medications {
+		r.Medications = append(r.Medications, consultation.Medication{Name: m.Name, Dose: m.Dose, Schedule: m.Schedule, Adherence: m.Adherence})
+	}
+	for _, p := range o.PriorConditions {
+		r.PriorConditions = append(r.PriorConditions, consultation.PriorCondition{Name: p.Name, Since: p.Since, Treatment: p.Treatment})
+	}
+	return r
+}
+
+// extractAnalysis asks for the analysis and checks it. A reply that does not
+// decode or fails the checks is sent back once with the error for the model
+// to repair; if the repair fails too, the pass returns the error.
+func (c *client) extractAnalysis(ctx context.Context, messages []chatMessage) (*consultation.AnalysisResult, error) {
+	resp, err := c.makeSchemaRequest(ctx, RoleAnalyst, c.timeouts.Analyst, messages, 0.1, analystSchema)
+	if err != nil {
+		return nil, err
+	}
+	var out analystOutput
+	invalid := decodeStrict(resp, &out)
+	if invalid == nil {
+		return out.result(), nil
+	}
+	fmt.Printf("Analyst reply rejected, asking for a repair: %v\n", invalid)
+
+	messages = append(messages,
+		chatMessage{Role: "assistant", Content: resp},
+		chatMessage{Role: "user", Content: fmt.Sprintf(repairPrompt, invalid)},
+	)
+	resp, err = c.makeSchemaRequest(ctx, RoleAnalyst, c.timeouts.Analyst, messages, 0.1, analystSchema)
+	if err != nil {
+		return nil, err
+	}
+	out = analystOutput{}
+	if err := decodeStrict(resp, &out); err != nil {
+		return nil, fmt.Errorf("analyst reply invalid after repair: %w", err)
+	}
+	return out.result(), nil
+}
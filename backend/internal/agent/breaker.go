@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// BreakerConfig trips a provider's circuit after Failures consecutive
+// failures. Calls then skip it for Cooldown, after which a single trial call
+// decides whether it closes again. Zero Failures disables the breaker.
+type BreakerConfig struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	Failures: 5,
+	Cooldown: 30 * time.Second,
+}
+
+// holdingMessage is what the patient hears when no provider could answer.
+// The patient's message is already in the History, so nothing is lost.
+const holdingMessage = "Извините, у меня небольшая техническая заминка. Вы в очереди, медсестра о вас знает. Пожалуйста, повторите ещё раз, что вы сказали."
+
+// breaker keeps the circuit state of each provider
+type breaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time // zero while closed
+	trial     bool      // a call is testing the provider after the cooldown
+}
+
+func newBreaker(cfg BreakerConfig) *breaker {
+	return &breaker{cfg: cfg, now: time.Now, circuits: map[string]*circuit{}}
+}
+
+// allow reports whether a call may go to the provider. Once the cooldown is
+// over, one call at a time is let through to test it.
+func (b *breaker) allow(provider string) bool {
+	if b.cfg.Failures <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[provider]
+	if c == nil || c.openUntil.IsZero() {
+		return true
+	}
+	if b.now().Before(c.openUntil) || c.trial {
+		return false
+	}
+	c.trial = true
+	return true
+}
+
+func (b *breaker) success(provider string) {
+	if b.cfg.Failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[provider]
+	if c == nil {
+		return
+	}
+	if !c.openUntil.IsZero() {
+		fmt.Printf("LLM provider %s recovered, circuit closed\n", provider)
+	}
+	delete(b.circuits, provider)
+}
+
+// failure counts a failed call; a failed trial opens the circuit again
+func (b *breaker) failure(provider string) {
+	if b.cfg.Failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[provider]
+	if c == nil {
+		c = &circuit{}
+		b.circuits[provider] = c
+	}
+	c.failures++
+	if c.trial || (c.openUntil.IsZero() && c.failures >= b.cfg.Failures) {
+		c.openUntil = b.now().Add(b.cfg.Cooldown)
+		c.trial = false
+		fmt.Printf("LLM provider %s failed %d times in a row, circuit open for %s\n", provider, c.failures, b.cfg.Cooldown)
+	}
+}
+
+// record updates the provider's circuit after an attempt. Attempts cut short
+// by the caller, whose context is caller, say nothing about the provider and
+// are not counted; running out of the agent timeout is.
+func (b *breaker) record(caller context.Context, provider string, err error) {
+	switch {
+	case err == nil:
+		b.success(provider)
+	case caller.Err() == nil:
+		b.failure(provider)
+	default:
+		// A cancelled trial leaves the provider to the next call
+		b.mu.Lock()
+		if c := b.circuits[provider]; c != nil {
+			c.trial = false
+		}
+		b.mu.Unlock()
+	}
+}
+
+// holding answers the patient when the Communicator failed while the turn
+// itself still has time, so the kiosk gets a reply instead of an error
+func holding(ctx context.Context, err error) (string, bool) {
+	if ctx.Err() != nil {
+		return "", false
+	}
+	fmt.Printf("Communicator unavailable, answering with the holding message: %v\n", err)
+	consultation.RecordProvider(ctx, consultation.HoldingProvider)
+	return holdingMessage, true
+}
+
+// withHolding passes a Communicator stream through, replacing a failure
+// before the first token with the holding message
+func withHolding(ctx context.Context, tokens <-chan string, errs <-chan error) (<-chan string, <-chan error) {
+	tokenChan := make(chan string)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(tokenChan)
+		defer close(errChan)
+
+		started := false
+		for token := range tokens {
+			started = true
+			select {
+			case tokenChan <- token:
+			case <-ctx.Done():
+			}
+		}
+		err := <-errs
+		if err == nil {
+			return
+		}
+		if !started {
+			if text, ok := holding(ctx, err); ok {
+				select {
+				case tokenChan <- text:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+		errChan <- err
+	}()
+
+	return tokenChan, errChan
+}
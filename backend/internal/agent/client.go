@@ -2,39 +2,134 @@ package agent
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"medical-ai-agent/internal/consultation"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const deepSeekAPIURL = "https://api.deepseek.com/chat/completions"
 
+const (
+	defaultModel            = "deepseek-chat"
+	communicatorTemperature = 0.7
+)
+
+// PromptVersions identifies the system prompts in use. Bump an entry whenever
+// the corresponding prompt changes so deployments can be told apart.
+var PromptVersions = map[string]string{
+	"communicator":    "16",
+	"analyst":         "9",
+	"supervisor":      "3",
+	"recommendations": "3",
+	"screener":        "1",
+	"quality":         "1",
+	"complaint":       "1",
+	"red_flag":        "1",
+	"wearables":       "1",
+	"fact_summary":    "1",
+	"translation":     "1",
+	"recap":           "1",
+}
+
 type DeepSeekClient interface {
-	RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState) (string, consultation.EmotionalState, error)
-	RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState) (<-chan string, <-chan error)
-	RunAnalyst(ctx context.Context, history []consultation.Message) ([]consultation.MedicalFact, error)
-	RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (bool, error)
-	GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (string, error)
+	RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (string, consultation.EmotionalState, error)
+	RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (<-chan string, <-chan error)
+	RunAnalyst(ctx context.Context, history []consultation.Message, required []consultation.RequiredField) (*consultation.AnalysisResult, error)
+	RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact, negatives []consultation.PertinentNegative, pending []consultation.RequiredField) (bool, error)
+	GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (*consultation.RecommendationResult, error)
+	RunScreener(ctx context.Context, question string, answer string) (bool, error)
+	RunQualityReview(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (*consultation.QualityReview, error)
+	DetectChiefComplaint(ctx context.Context, message string) (*consultation.ChiefComplaint, error)
+	RunRedFlagScreen(ctx context.Context, question, message string) ([]consultation.RedFlag, error)
+	SummarizeWearables(ctx context.Context, signals []string, facts []consultation.MedicalFact) ([]consultation.MedicalFact, error)
+	SummarizeFacts(ctx context.Context, facts []consultation.MedicalFact, limit int) ([]consultation.MedicalFact, error)
+	Translate(ctx context.Context, texts []string, from, to, model string) ([]string, error)
+	CheckRecap(ctx context.Context, recap, answer string, facts []consultation.MedicalFact) (*consultation.RecapCheck, error)
+	Prewarm(ctx context.Context)
+}
+
+// Timeouts bounds each agent's LLM call. Local models are much slower than
+// the hosted API, so these are configurable per deployment.
+type Timeouts struct {
+	Communicator    time.Duration
+	Analyst         time.Duration
+	Supervisor      time.Duration
+	Recommendations time.Duration
+	Screener        time.Duration
+	Quality         time.Duration
+	Complaint       time.Duration
+	RedFlag         time.Duration
+	Wearables       time.Duration
+	Translation     time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Communicator:    30 * time.Second,
+	Analyst:         30 * time.Second,
+	Supervisor:      30 * time.Second,
+	Recommendations: 60 * time.Second,
+	Screener:        15 * time.Second,
+	Quality:         60 * time.Second,
+	Complaint:       15 * time.Second,
+	RedFlag:         15 * time.Second,
+	Wearables:       30 * time.Second,
+	Translation:     60 * time.Second,
 }
 
 type client struct {
-	apiKey     string
-	httpClient *http.Client
+	providers     []Provider
+	httpClients   map[string]*http.Client // by provider name
+	timeouts      Timeouts
+	models        AgentModels
+	breaker       *breaker
+	retry         RetryConfig
+	queue         *Queue
+	keepReasoning bool
+	prompts       *Prompts
 }
 
-func NewDeepSeekClient(apiKey string) DeepSeekClient {
-	return &client{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// NewDeepSeekClient sends every call through queue; nil means no limits.
+// providers is the failover chain, see Provider; breaker decides when a
+// failing provider is skipped, retry how often a failed call is tried again
+// before moving on. models overrides the model and temperature
+// per agent role.
+// transport gives a provider's HTTP transport, e.g. to observe its health;
+// nil uses the default one.
+// The thinking of reasoning models is dropped unless keepReasoning is set,
+// then the Communicator's goes to consultation.RecordReasoning for audit.
+// prompts holds the system prompt templates; nil uses the built-in ones.
+func NewDeepSeekClient(providers []Provider, timeouts Timeouts, models AgentModels, breaker BreakerConfig, retry RetryConfig, queue *Queue, transport func(provider string) http.RoundTripper, keepReasoning bool, prompts *Prompts) DeepSeekClient {
+	if prompts == nil {
+		prompts = DefaultPrompts()
+	}
+	c := &client{
+		providers:     providers,
+		httpClients:   make(map[string]*http.Client, len(providers)),
+		timeouts:      timeouts,
+		models:        models,
+		breaker:       newBreaker(breaker),
+		retry:         retry,
+		queue:         queue,
+		keepReasoning: keepReasoning,
+		prompts:       prompts,
 	}
+	for _, p := range providers {
+		// Deadlines come from the per-agent timeouts via the request context,
+		// a client-wide timeout would also cut long streaming responses
+		httpClient := &http.Client{}
+		if transport != nil {
+			httpClient.Transport = transport(p.Name)
+		}
+		c.httpClients[p.Name] = httpClient
+	}
+	return c
 }
 
 // --- API Structures ---
@@ -45,57 +140,116 @@ type chatRequest struct {
 	Temperature float64       `json:"temperature"`
 	Format      *jsonFormat   `json:"response_format,omitempty"`
 	Stream      bool          `json:"stream,omitempty"`
+	Tools       []tool        `json:"tools,omitempty"`
+	ToolChoice  string        `json:"tool_choice,omitempty"` // "none" asks for text only
 }
 
 type jsonFormat struct {
-	Type string `json:"type"`
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
 }
 
+// jsonObject asks for any JSON object
+var jsonObject = &jsonFormat{Type: "json_object"}
+
 type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`   // an assistant message that called tools
+	ToolCallID string     `json:"tool_call_id,omitempty"` // a tool result
 }
 
 type chatResponse struct {
 	Choices []struct {
-		Message chatMessage `json:"message"`
-		Delta   chatMessage `json:"delta"`
+		Message chatReply `json:"message"`
+		Delta   chatReply `json:"delta"`
 	} `json:"choices"`
 }
 
-// --- Implementations ---
-
-func (c *client) RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState) (<-chan string, <-chan error) {
-	systemPrompt := fmt.Sprintf(`Ты — заботливый и чуткий медицинский ассистент в приемном отделении.
-Твоя главная цель: успокоить пациента и мягко выяснить причину обращения, пока он ожидает врача.
-Текущее настроение пациента (по твоей оценке): %s.
+// chatReply is a message or a streaming delta; reasoning models add their
+// thinking, which is never sent back to the API
+type chatReply struct {
+	Content          string     `json:"content"`
+	ReasoningContent string     `json:"reasoning_content"`
+	ToolCalls        []toolCall `json:"tool_calls"`
+}
 
-ПРИНЦИПЫ ОБЩЕНИЯ:
-1. **Эмпатия и Теплота**: Используй фразы "Я понимаю, как это неприятно", "Мне очень жаль, что вам больно", "Мы обязательно вам поможем". Твой тон должен быть мягким, человечным, не роботизированным.
-2. **Активное слушание**: Подтверждай, что ты услышал пациента (например, "Хорошо, значит боль в животе...").
-3. **Поддержка**: Если пациент тревожится, обязательно успокой его перед тем, как задать следующий вопрос.
+// --- Implementations ---
 
-ИНСТРУКЦИЯ ПО ФОРМАТУ ОТВЕТА:
-1. Сначала оцени настроение пациента: "Спокойное", "Тревожное", "Критическое".
-2. Напиши ответ пациенту.
-3. Формат вывода: "[MOOD: <настроение>] <Текст ответа>"
+// communicatorPrompt builds the Communicator's system prompt for the interview
+func (c *client) communicatorPrompt(mood consultation.EmotionalState, interview consultation.Interview) (string, error) {
+	data := communicatorData{
+		Mood:                     mood,
+		Pediatric:                interview.Pediatric,
+		Required:                 interview.Required,
+		MedicationReconciliation: interview.Mode == consultation.ModeMedicationReconciliation,
+		ScreenTag:                consultation.ScreenTag,
+		KeysTag:                  consultation.KeysTag,
+		DoctorQuestion:           interview.DoctorQuestion,
+		NextQuestion:             interview.NextQuestion,
+		Variant:                  interview.Variant.Prompt,
+		AfterHours:               interview.AfterHours,
+		Tools:                    interview.Tools,
+		Continue:                 interview.Continue,
+	}
+	for _, l := range interview.Links {
+		data.Visits = append(data.Visits, l.Describe(time.Now()))
+	}
+	for _, q := range interview.Questionnaires {
+		data.Questionnaires = append(data.Questionnaires, q.Summary())
+	}
+	for _, t := range interview.EpidTopics {
+		data.EpidTopics = append(data.EpidTopics, t.Question)
+	}
+	for _, p := range interview.Conditions {
+		data.Conditions = append(data.Conditions, p.Name)
+	}
+	if p := interview.Pacing; p != nil {
+		data.MaxSentenceWords, data.ScreenText, data.Keypad = p.MaxSentenceWords, p.ScreenText, p.Keypad
+	}
+	return c.prompts.render("communicator", data)
+}
 
-Пример: "[MOOD: Тревожное] Я вижу, что вы очень переживаете. Пожалуйста, постарайтесь дышать глубже, вы уже в больнице и в безопасности. Скажите, как давно началась эта боль?"
+// communicatorModel applies an experiment arm's overrides to the Communicator's
+// configured model; an empty model means the provider's own
+func (c *client) communicatorModel(v consultation.Variant) (string, float64) {
+	model, temp := c.models.pick(RoleCommunicator, communicatorTemperature)
+	if v.Model != "" {
+		model = v.Model
+	}
+	if v.Temperature > 0 {
+		temp = v.Temperature
+	}
+	return model, temp
+}
 
-ВАЖНО:
-- Не ставь диагнозы.
-- Задавай только ОДИН вопрос за раз, чтобы не перегружать пациента.
-- Если ты собрал достаточно информации (основные жалобы, длительность, характер боли) или пациент сказал, что больше жалоб нет, ОБЯЗАТЕЛЬНО заверши диалог фразой: "Спасибо, врач скоро подойдет". Это сигнал для системы отправить отчет.`, mood)
+func (c *client) RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (<-chan string, <-chan error) {
+	systemPrompt, err := c.communicatorPrompt(mood, interview)
+	if err != nil {
+		errs := make(chan error, 1)
+		errs <- err
+		close(errs)
+		tokens := make(chan string)
+		close(tokens)
+		return tokens, errs
+	}
 
 	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
 	for _, msg := range history {
 		messages = append(messages, chatMessage{Role: msg.Role, Content: msg.Content})
 	}
 
-	return c.makeStreamRequest(ctx, messages, 0.7)
+	// The patient gets the holding message rather than an error when every provider fails
+	model, temp := c.communicatorModel(interview.Variant)
+	call := chatRequest{Messages: messages, Temperature: temp}
+	if interview.Tools {
+		call.Tools = communicatorTools
+	}
+	tokens, errs := c.makeStreamRequest(ctx, RoleCommunicator, c.timeouts.Communicator, model, call)
+	return withHolding(ctx, tokens, errs)
 }
 
-func (c *client) makeStreamRequest(ctx context.Context, messages []chatMessage, temp float64) (<-chan string, <-chan error) {
+func (c *client) makeStreamRequest(ctx context.Context, role Role, timeout time.Duration, model string, call chatRequest) (<-chan string, <-chan error) {
 	tokenChan := make(chan string)
 	errChan := make(chan error, 1)
 
@@ -103,104 +257,198 @@ func (c *client) makeStreamRequest(ctx context.Context, messages []chatMessage,
 		defer close(tokenChan)
 		defer close(errChan)
 
-		reqBody := chatRequest{
-			Model:       "deepseek-chat",
-			Messages:    messages,
-			Temperature: temp,
-			Stream:      true,
-		}
+		// The agent timeout counts against a provider, the caller going away does not
+		caller := ctx
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
 
-		jsonBody, _ := json.Marshal(reqBody)
-		req, err := http.NewRequestWithContext(ctx, "POST", deepSeekAPIURL, bytes.NewBuffer(jsonBody))
+		// The slot is held until the stream ends
+		release, err := c.queue.Acquire(ctx, role)
 		if err != nil {
-			errChan <- err
+			errChan <- timeoutError(err, timeout)
 			return
 		}
+		defer release()
+
+		// Tokens already sent cannot be taken back, so only a provider that
+		// has not produced the first token yet is failed over
+		var lastErr error
+		for i, p := range c.providers {
+			if !c.breaker.allow(p.Name) {
+				lastErr = fmt.Errorf("provider %s: circuit open", p.Name)
+				continue
+			}
+			started, calls, err := c.streamProvider(ctx, p, providerModel(i, p, model), call, tokenChan)
+			if err == nil && !started && len(calls) > 0 {
+				// Only tool calls came back: the same provider writes the reply
+				// once it has their results
+				started, _, err = c.streamProvider(ctx, p, providerModel(i, p, model), withToolResults(call, calls), tokenChan)
+			}
+			c.breaker.record(caller, p.Name, err)
+			if err == nil {
+				if call.Tools != nil && p.tools() {
+					c.recordToolCalls(ctx, calls)
+				}
+				return
+			}
+			if started || ctx.Err() != nil {
+				errChan <- timeoutError(err, timeout)
+				return
+			}
+			fmt.Printf("LLM provider %s failed for %s: %v\n", p.Name, role, err)
+			lastErr = err
+		}
+		errChan <- fmt.Errorf("%w: %v", consultation.ErrLLMUnavailable, lastErr)
+	}()
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return tokenChan, errChan
+}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			errChan <- err
-			return
+// streamProvider streams one provider's answer into tokenChan. started
+// reports whether any token was sent; calls are the tools the reply called.
+func (c *client) streamProvider(ctx context.Context, p Provider, model string, call chatRequest, tokenChan chan<- string) (started bool, calls []toolCall, err error) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The budget covers the wait for the first token only
+	var budget *time.Timer
+	if p.budget > 0 {
+		budget = time.AfterFunc(p.budget, cancel)
+		defer budget.Stop()
+	}
+	overBudget := func(err error) error {
+		if !started && ctx.Err() == nil && attemptCtx.Err() != nil {
+			return fmt.Errorf("no answer within latency budget %s", p.budget)
 		}
-		defer resp.Body.Close()
+		return err
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			errChan <- fmt.Errorf("API error: %s - %s", resp.Status, string(body))
-			return
-		}
+	api := p.api()
+	call.Model, call.Messages, call.Stream = model, p.adjust(call.Messages), true
+	call = p.offerTools(call)
+	// Retries happen before the first token, inside the latency budget
+	resp, err := c.do(attemptCtx, p, func() (*http.Request, error) {
+		return api.Request(attemptCtx, p, call)
+	})
+	if err != nil {
+		return false, nil, overBudget(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	}
 
-		reader := bufio.NewReader(resp.Body)
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil {
-				if err != io.EOF {
-					errChan <- err
+	// meetBudget stops the latency budget, false when it already ran out
+	budgetMet := budget == nil
+	meetBudget := func() bool {
+		if !budgetMet {
+			budgetMet = budget.Stop()
+		}
+		return budgetMet
+	}
+	var think thinkFilter
+	var thought strings.Builder
+	send := func(content string) error {
+		if !started {
+			if !meetBudget() {
+				return overBudget(attemptCtx.Err())
+			}
+			started = true
+			consultation.RecordProvider(ctx, p.Name)
+		}
+		select {
+		case tokenChan <- content:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	finish := func() (bool, []toolCall, error) {
+		if content, reasoning := think.flush(); content != "" || reasoning != "" {
+			thought.WriteString(reasoning)
+			if strings.TrimSpace(content) != "" {
+				if err := send(content); err != nil {
+					return started, nil, err
 				}
-				return
 			}
+		}
+		if !started && len(calls) == 0 {
+			return false, nil, fmt.Errorf("empty response from AI")
+		}
+		c.recordReasoning(ctx, thought.String())
+		return started, calls, nil
+	}
 
-			lineStr := strings.TrimSpace(string(line))
-			if !strings.HasPrefix(lineStr, "data: ") {
-				continue
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if err != io.EOF {
+				return started, nil, overBudget(err)
 			}
+			return finish()
+		}
 
-			data := strings.TrimPrefix(lineStr, "data: ")
-			if data == "[DONE]" {
-				return
+		delta, done, err := api.Chunk(line)
+		if err != nil {
+			return started, nil, err
+		}
+		if len(delta.ToolCalls) > 0 {
+			// A provider calling tools is alive, like a thinking one
+			if !meetBudget() {
+				return false, nil, overBudget(attemptCtx.Err())
 			}
-
-			var chatResp chatResponse
-			if err := json.Unmarshal([]byte(data), &chatResp); err != nil {
-				continue
+			calls = mergeToolCalls(calls, delta.ToolCalls)
+		}
+		content, reasoning := think.feed(delta.Content)
+		if reasoning = delta.ReasoningContent + reasoning; reasoning != "" {
+			// A thinking provider is alive, so the latency budget is met,
+			// but nothing reached the patient yet and it can still fail over
+			if !meetBudget() {
+				return false, nil, overBudget(attemptCtx.Err())
 			}
-
-			if len(chatResp.Choices) > 0 {
-				content := chatResp.Choices[0].Delta.Content
-				if content != "" {
-					tokenChan <- content
-				}
+			thought.WriteString(reasoning)
+		}
+		if content != "" && (started || strings.TrimSpace(content) != "") {
+			if err := send(content); err != nil {
+				return started, nil, err
 			}
 		}
-	}()
-
-	return tokenChan, errChan
+		if done {
+			return finish()
+		}
+	}
 }
 
-func (c *client) RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState) (string, consultation.EmotionalState, error) {
-	systemPrompt := fmt.Sprintf(`Ты — заботливый и чуткий медицинский ассистент в приемном отделении.
-Твоя главная цель: успокоить пациента и мягко выяснить причину обращения, пока он ожидает врача.
-Текущее настроение пациента (по твоей оценке): %s.
-
-ПРИНЦИПЫ ОБЩЕНИЯ:
-1. **Эмпатия и Теплота**: Используй фразы "Я понимаю, как это неприятно", "Мне очень жаль, что вам больно", "Мы обязательно вам поможем". Твой тон должен быть мягким, человечным, не роботизированным.
-2. **Активное слушание**: Подтверждай, что ты услышал пациента (например, "Хорошо, значит боль в животе...").
-3. **Поддержка**: Если пациент тревожится, обязательно успокой его перед тем, как задать следующий вопрос.
-
-ИНСТРУКЦИЯ ПО ФОРМАТУ ОТВЕТА:
-1. Сначала оцени настроение пациента: "Спокойное", "Тревожное", "Критическое".
-2. Напиши ответ пациенту.
-3. Формат вывода: "[MOOD: <настроение>] <Текст ответа>"
-
-Пример: "[MOOD: Тревожное] Я вижу, что вы очень переживаете. Пожалуйста, постарайтесь дышать глубже, вы уже в больнице и в безопасности. Скажите, как давно началась эта боль?"
-
-ВАЖНО:
-- Не ставь диагнозы.
-- Задавай только ОДИН вопрос за раз, чтобы не перегружать пациента.
-- Если ты собрал достаточно информации (основные жалобы, длительность, характер боли) или пациент сказал, что больше жалоб нет, ОБЯЗАТЕЛЬНО заверши диалог фразой: "Спасибо, врач скоро подойдет". Это сигнал для системы отправить отчет.`, mood)
+func (c *client) RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (string, consultation.EmotionalState, error) {
+	systemPrompt, err := c.communicatorPrompt(mood, interview)
+	if err != nil {
+		return "", mood, err
+	}
 
 	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
 	for _, msg := range history {
 		messages = append(messages, chatMessage{Role: msg.Role, Content: msg.Content})
 	}
 
-	resp, err := c.makeRequest(ctx, messages, 0.7, false)
+	model, temp := c.communicatorModel(interview.Variant)
+	call := chatRequest{Messages: messages, Temperature: temp}
+	if interview.Tools {
+		call.Tools = communicatorTools
+	}
+	reply, err := c.makeCall(ctx, RoleCommunicator, c.timeouts.Communicator, model, call)
+	if err == nil && strings.TrimSpace(reply.Content) == "" && len(reply.ToolCalls) > 0 {
+		// Only tool calls came back: the reply follows their results
+		reply, err = c.makeCall(ctx, RoleCommunicator, c.timeouts.Communicator, model, withToolResults(call, reply.ToolCalls))
+	}
 	if err != nil {
+		if text, ok := holding(ctx, err); ok {
+			return text, mood, nil
+		}
 		return "", consultation.StateNeutral, err
 	}
+	resp := reply.Content
 
 	// Parse Mood and Content
 	newMood := mood
@@ -211,40 +459,30 @@ func (c *client) RunCommunicator(ctx context.Context, history []consultation.Mes
 		if endIdx != -1 {
 			moodStr := resp[7:endIdx]
 			content = strings.TrimSpace(resp[endIdx+1:])
-			
-			// Map string to EmotionalState
-			switch strings.ToLower(strings.TrimSpace(moodStr)) {
-			case "тревожное", "anxious":
-				newMood = consultation.StateAnxious
-			case "критическое", "critical":
-				newMood = consultation.StateCritical
-			case "спокойное", "calm", "neutral", "нейтральное":
-				newMood = consultation.StateCalm
-			default:
-				newMood = consultation.StateCalm
-			}
+			newMood = moodFromText(moodStr)
 		}
 	}
 
 	return content, newMood, nil
 }
 
-func (c *client) RunAnalyst(ctx context.Context, history []consultation.Message) ([]consultation.MedicalFact, error) {
-	systemPrompt := `Ты — медицинский аналитик. Твоя задача — извлекать факты из диалога.
-Верни ТОЛЬКО валидный JSON массив объектов. Не пиши ничего кроме JSON.
-Формат: [{"category": "Симптом/Лекарство/Хронология", "description": "...", "confidence": "Высокая/Средняя/Низкая"}]
-
-КРИТЕРИИ УВЕРЕННОСТИ:
-- "Высокая": Пациент сказал четко и прямо (напр. "Болит голова 3 дня").
-- "Средняя": Пациент выразился неточно или использовал слова "вроде", "наверное" (напр. "Кажется, температура была").
-- "Низкая": Информацию пришлось додумывать или пациент путается в показаниях.
-
-ВАЖНО:
-- Анализируй каждое сообщение внимательно.
-- Если пациент упоминает боль, обязательно фиксируй её характер, локализацию и длительность как отдельные факты или один подробный.
-- Если пациент отрицает симптомы (напр. "температуры нет"), это тоже важный факт (category: "Отсутствие симптома").
+// moodFromText maps the Communicator's judgement of the mood to an EmotionalState
+func moodFromText(mood string) consultation.EmotionalState {
+	switch strings.ToLower(strings.TrimSpace(mood)) {
+	case "тревожное", "anxious":
+		return consultation.StateAnxious
+	case "критическое", "critical":
+		return consultation.StateCritical
+	default:
+		return consultation.StateCalm
+	}
+}
 
-Если новых фактов нет, верни пустой массив [].`
+func (c *client) RunAnalyst(ctx context.Context, history []consultation.Message, required []consultation.RequiredField) (*consultation.AnalysisResult, error) {
+	systemPrompt, err := c.prompts.render("analyst", analystData{Required: required})
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
 	// Only analyze last few messages to save tokens and focus on recent context
@@ -256,129 +494,480 @@ func (c *client) RunAnalyst(ctx context.Context, history []consultation.Message)
 		messages = append(messages, chatMessage{Role: msg.Role, Content: msg.Content})
 	}
 
-	resp, err := c.makeRequest(ctx, messages, 0.1, true)
+	return c.extractAnalysis(ctx, messages)
+}
+
+func (c *client) RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact, negatives []consultation.PertinentNegative, pending []consultation.RequiredField) (bool, error) {
+	// Don't even bother the AI if we have very little history
+	if len(history) < 4 { // Reduced minimum history check to allow quicker completion if needed
+		return false, nil
+	}
+
+	systemPrompt, err := c.prompts.render("supervisor", supervisorData{Facts: facts, Negatives: negatives, Pending: pending})
+	if err != nil {
+		return false, err
+	}
+
+	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
+	
+	resp, err := c.makeRequest(ctx, RoleSupervisor, c.timeouts.Supervisor, messages, 0.1, false)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Printf("Supervisor Response: %s\n", resp)
+
+	return strings.Contains(strings.ToUpper(resp), "ДА"), nil
+}
+
+func (c *client) GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (*consultation.RecommendationResult, error) {
+	systemPrompt, err := c.prompts.render("recommendations", factsData{Facts: facts})
 	if err != nil {
 		return nil, err
 	}
 
-	// Clean up response if it contains markdown code blocks
-	resp = strings.TrimPrefix(resp, "```json")
-	resp = strings.TrimPrefix(resp, "```")
-	resp = strings.TrimSuffix(resp, "```")
-	resp = strings.TrimSpace(resp)
+	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
 
-	var facts []consultation.MedicalFact
-	if err := json.Unmarshal([]byte(resp), &facts); err != nil {
-		// If JSON fails, just return empty to not break flow
-		fmt.Printf("Analyst JSON error: %v. Response: %s\n", err, resp)
-		return []consultation.MedicalFact{}, nil
+	resp, err := c.makeRequest(ctx, RoleRecommendations, c.timeouts.Recommendations, messages, 0.3, false)
+	if err != nil {
+		return nil, err
 	}
+	text, confidence := parseSelfConfidence(resp)
+	text, followUp := parseFollowUp(text)
+	return &consultation.RecommendationResult{Text: text, Confidence: confidence, FollowUp: followUp}, nil
+}
+
+var selfConfidenceRe = regexp.MustCompile(`(?i)\**УВЕРЕННОСТЬ\**:\s*\**(\d{1,3})\s*%?\**\s*$`)
 
-	return facts, nil
+// parseSelfConfidence strips the trailing "УВЕРЕННОСТЬ: N" line, returning -1 if it's missing
+func parseSelfConfidence(resp string) (string, int) {
+	resp = strings.TrimSpace(resp)
+	m := selfConfidenceRe.FindStringSubmatchIndex(resp)
+	if m == nil {
+		return resp, -1
+	}
+	confidence, err := strconv.Atoi(resp[m[2]:m[3]])
+	if err != nil || confidence > 100 {
+		return resp, -1
+	}
+	return strings.TrimSpace(resp[:m[0]]), confidence
 }
 
-func (c *client) RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (bool, error) {
-	// Don't even bother the AI if we have very little history
-	if len(history) < 4 { // Reduced minimum history check to allow quicker completion if needed
-		return false, nil
+var followUpRe = regexp.MustCompile(`(?i)\**ПОВТОРНЫЙ ПРИ[ЁЕ]М\**:\s*\**([^\n]*?)\**\s*$`)
+var followUpDaysRe = regexp.MustCompile(`(?i),?\s*через\s+(\d{1,3})\s*(?:дн[а-я]*|сут[а-я]*)?\.?$`)
+
+// parseFollowUp strips the trailing "ПОВТОРНЫЙ ПРИЁМ: ..." line, returning nil
+// if it's missing or says no follow-up is needed
+func parseFollowUp(resp string) (string, *consultation.FollowUp) {
+	resp = strings.TrimSpace(resp)
+	m := followUpRe.FindStringSubmatchIndex(resp)
+	if m == nil {
+		return resp, nil
+	}
+	text := strings.TrimSpace(resp[:m[0]])
+	value := strings.TrimSpace(resp[m[2]:m[3]])
+	if value == "" || strings.EqualFold(strings.Trim(value, "."), "нет") {
+		return text, nil
+	}
+	f := &consultation.FollowUp{Specialty: value}
+	if d := followUpDaysRe.FindStringSubmatchIndex(value); d != nil {
+		f.WithinDays, _ = strconv.Atoi(value[d[2]:d[3]])
+		f.Specialty = strings.TrimSpace(value[:d[0]])
+	}
+	f.Specialty = strings.Trim(f.Specialty, " .,")
+	if f.Specialty == "" {
+		return text, nil
+	}
+	return text, f
+}
+
+// RunScreener classifies the patient's answer to a risk screening question.
+// Ambiguous answers count as positive so that staff are alerted rather than not.
+func (c *client) RunScreener(ctx context.Context, question string, answer string) (bool, error) {
+	systemPrompt, err := c.prompts.render("screener", screenerData{Question: question})
+	if err != nil {
+		return false, err
 	}
 
-	factsSummary := ""
-	for _, f := range facts {
-		factsSummary += fmt.Sprintf("- %s: %s\n", f.Category, f.Description)
+	messages := []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: answer},
 	}
 
-	systemPrompt := fmt.Sprintf(`Ты — супервайзер медицинского опроса.
-Собранные факты:
-%s
-Твоя задача — решить, можно ли ЗАВЕРШАТЬ опрос и отправлять отчет врачу.
+	resp, err := c.makeRequest(ctx, RoleScreener, c.timeouts.Screener, messages, 0, false)
+	if err != nil {
+		return false, err
+	}
 
-КРИТЕРИИ ЗАВЕРШЕНИЯ (Достаточно выполнения ЛЮБОГО из пунктов):
-1. Мы знаем основную жалобу пациента, её длительность и характер.
-2. Пациент явно сказал "это всё", "больше ничего", "нет" на вопрос о других жалобах.
-3. Собрано достаточно фактов для первичной сортировки (триажа).
+	return !strings.Contains(strings.ToUpper(resp), "НЕТ"), nil
+}
 
-Если пациент только поздоровался или мы знаем только "болит живот" без подробностей — отвечай "НЕТ".
-Во всех остальных случаях, если картина ясна — отвечай "ДА".
+// RunQualityReview scores a finished interview for prompt regression monitoring
+func (c *client) RunQualityReview(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (*consultation.QualityReview, error) {
+	transcript := make([]transcriptLine, len(history))
+	for i, msg := range history {
+		transcript[i] = transcriptLine{Speaker: "Пациент", Text: msg.Content}
+		if msg.Role == "assistant" {
+			transcript[i].Speaker = "Ассистент"
+		}
+	}
 
-Ответь ТОЛЬКО словом "ДА" или "НЕТ".`, factsSummary)
+	systemPrompt, err := c.prompts.render("quality", qualityData{Facts: facts, Transcript: transcript})
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
-	
-	resp, err := c.makeRequest(ctx, messages, 0.1, false)
+
+	resp, err := c.makeRequest(ctx, RoleQuality, c.timeouts.Quality, messages, 0.1, true)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	fmt.Printf("Supervisor Response: %s\n", resp)
+	var review consultation.QualityReview
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &review); err != nil {
+		return nil, fmt.Errorf("invalid quality review: %w", err)
+	}
+	review.PromptVersion = c.prompts.Version("communicator")
+	review.ReviewedAt = time.Now()
+	return &review, nil
+}
 
-	return strings.Contains(strings.ToUpper(resp), "ДА"), nil
+// DetectChiefComplaint classifies a single patient message. It is short and
+// cheap so dashboards get the reason for the visit from the first messages.
+func (c *client) DetectChiefComplaint(ctx context.Context, message string) (*consultation.ChiefComplaint, error) {
+	categories := make([]string, len(consultation.ComplaintCategories))
+	for i, cat := range consultation.ComplaintCategories {
+		categories[i] = string(cat)
+	}
+
+	systemPrompt, err := c.prompts.render("complaint", complaintData{Categories: categories})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: message},
+	}
+
+	resp, err := c.makeRequest(ctx, RoleComplaint, c.timeouts.Complaint, messages, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Complaint string `json:"complaint"`
+		Category  string `json:"category"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid chief complaint: %w", err)
+	}
+	if strings.TrimSpace(result.Complaint) == "" {
+		return nil, nil
+	}
+
+	category := consultation.ComplaintOther
+	for _, cat := range consultation.ComplaintCategories {
+		if string(cat) == strings.ToLower(strings.TrimSpace(result.Category)) {
+			category = cat
+		}
+	}
+	return &consultation.ChiefComplaint{Text: strings.TrimSpace(result.Complaint), Category: category}, nil
 }
 
-func (c *client) GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (string, error) {
-	factsSummary := ""
-	for _, f := range facts {
-		factsSummary += fmt.Sprintf("- %s: %s (Уверенность: %s)\n", f.Category, f.Description, f.Confidence)
+// RunRedFlagScreen looks for emergency indicators in a single patient
+// message, reading it as the answer to question. It runs on every message,
+// so it is as short as the complaint call.
+func (c *client) RunRedFlagScreen(ctx context.Context, question, message string) ([]consultation.RedFlag, error) {
+	categories := make([]string, len(consultation.RedFlagCategories))
+	for i, cat := range consultation.RedFlagCategories {
+		categories[i] = string(cat)
 	}
 
-	systemPrompt := fmt.Sprintf(`Ты — старший врач-консультант.
-На основе собранных фактов составь краткие рекомендации для дежурного врача.
-Факты:
-%s
+	systemPrompt, err := c.prompts.render("red_flag", redFlagData{Categories: categories, Question: question})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: message},
+	}
+
+	resp, err := c.makeRequest(ctx, RoleRedFlag, c.timeouts.RedFlag, messages, 0, true)
+	if err != nil {
+		return nil, err
+	}
 
-Твоя задача:
-1. Предположить возможную срочность (Триаж: Зеленый/Желтый/Красный).
-2. Предложить список необходимых обследований (анализы, рентген и т.д.).
-3. Дать краткое резюме случая.
+	var result struct {
+		Flags []struct {
+			Category  string `json:"category"`
+			Indicator string `json:"indicator"`
+		} `json:"flags"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid red flags: %w", err)
+	}
 
-Ответ должен быть кратким, структурированным текстом (не JSON).`, factsSummary)
+	var flags []consultation.RedFlag
+	for _, f := range result.Flags {
+		category := consultation.RedFlagCategory(strings.ToLower(strings.TrimSpace(f.Category)))
+		known := false
+		for _, cat := range consultation.RedFlagCategories {
+			known = known || cat == category
+		}
+		if !known {
+			fmt.Printf("Red-flag screen returned unknown category %q, skipped\n", f.Category)
+			continue
+		}
+		flags = append(flags, consultation.RedFlag{Category: category, Indicator: strings.TrimSpace(f.Indicator)})
+	}
+	return flags, nil
+}
+
+// SummarizeWearables turns device statistics into a few facts for the doctor,
+// keeping only what matters given the complaints already collected.
+func (c *client) SummarizeWearables(ctx context.Context, signals []string, facts []consultation.MedicalFact) ([]consultation.MedicalFact, error) {
+	systemPrompt, err := c.prompts.render("wearables", wearablesData{Facts: facts, Signals: signals})
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
 
-	return c.makeRequest(ctx, messages, 0.3, false)
+	resp, err := c.makeRequest(ctx, RoleWearables, c.timeouts.Wearables, messages, 0.1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Facts []consultation.MedicalFact `json:"facts"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid wearables summary: %w", err)
+	}
+	return result.Facts, nil
 }
 
-// --- Helper ---
+// SummarizeFacts condenses a long fact list into at most limit facts for the
+// report. It runs in the recommendations slot: both are part of building the report.
+func (c *client) SummarizeFacts(ctx context.Context, facts []consultation.MedicalFact, limit int) ([]consultation.MedicalFact, error) {
+	systemPrompt, err := c.prompts.render("fact_summary", factSummaryData{Facts: facts, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
+
+	resp, err := c.makeRequest(ctx, RoleRecommendations, c.timeouts.Recommendations, messages, 0.1, true)
+	if err != nil {
+		return nil, err
+	}
 
-func (c *client) makeRequest(ctx context.Context, messages []chatMessage, temp float64, jsonMode bool) (string, error) {
-	reqBody := chatRequest{
-		Model:       "deepseek-chat", // Or "deepseek-coder" depending on availability
-		Messages:    messages,
-		Temperature: temp,
+	var result struct {
+		Facts []consultation.MedicalFact `json:"facts"`
 	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid fact summary: %w", err)
+	}
+	if len(result.Facts) == 0 || len(result.Facts) > limit {
+		return nil, fmt.Errorf("fact summary has %d facts, want 1-%d", len(result.Facts), limit)
+	}
+	return result.Facts, nil
+}
+
+// Translate translates report texts for a doctor who does not read the
+// interview language. The texts go in one call as a numbered JSON list, so
+// terms stay consistent across facts.
+func (c *client) Translate(ctx context.Context, texts []string, from, to, model string) ([]string, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt, err := c.prompts.render("translation", translationData{From: from, To: to, Texts: string(input)})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []chatMessage{{Role: "system", Content: systemPrompt}}
+
+	// The translation config's model wins over the role's
+	configured, temp := c.models.pick(RoleTranslation, 0.1)
+	if model == "" {
+		model = configured
+	}
+	resp, err := c.makeModelRequest(ctx, RoleTranslation, c.timeouts.Translation, model, messages, temp, jsonObject)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Translations []string `json:"translations"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid translation: %w", err)
+	}
+	if len(result.Translations) != len(texts) {
+		return nil, fmt.Errorf("translation has %d texts, want %d", len(result.Translations), len(texts))
+	}
+	return result.Translations, nil
+}
+
+// CheckRecap decides whether the patient agreed with the read-back of the
+// facts and, if not, returns the fact list with the patient's corrections
+func (c *client) CheckRecap(ctx context.Context, recap, answer string, facts []consultation.MedicalFact) (*consultation.RecapCheck, error) {
+	input, err := json.Marshal(facts)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt, err := c.prompts.render("recap", recapData{Recap: recap, Facts: string(input)})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: answer},
+	}
+
+	resp, err := c.makeRequest(ctx, RoleAnalyst, c.timeouts.Analyst, messages, 0.1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result consultation.RecapCheck
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resp)), &result); err != nil {
+		return nil, fmt.Errorf("invalid recap check: %w", err)
+	}
+	return &result, nil
+}
+
+// --- Helper ---
+
+// makeRequest calls the role's configured model; temp is the agent's own
+// temperature, used unless the role overrides it
+func (c *client) makeRequest(ctx context.Context, role Role, timeout time.Duration, messages []chatMessage, temp float64, jsonMode bool) (string, error) {
+	var format *jsonFormat
 	if jsonMode {
-		reqBody.Format = &jsonFormat{Type: "json_object"}
+		format = jsonObject
 	}
+	model, temp := c.models.pick(role, temp)
+	return c.makeModelRequest(ctx, role, timeout, model, messages, temp, format)
+}
 
-	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", deepSeekAPIURL, bytes.NewBuffer(jsonBody))
+// makeSchemaRequest asks for a reply that follows schema on providers with
+// structured output and for a JSON object on the others
+func (c *client) makeSchemaRequest(ctx context.Context, role Role, timeout time.Duration, messages []chatMessage, temp float64, schema *jsonSchema) (string, error) {
+	model, temp := c.models.pick(role, temp)
+	return c.makeModelRequest(ctx, role, timeout, model, messages, temp, &jsonFormat{Type: "json_schema", JSONSchema: schema})
+}
+
+// makeModelRequest tries the providers in order; format nil asks for plain text
+func (c *client) makeModelRequest(ctx context.Context, role Role, timeout time.Duration, model string, messages []chatMessage, temp float64, format *jsonFormat) (string, error) {
+	reply, err := c.makeCall(ctx, role, timeout, model, chatRequest{Messages: messages, Temperature: temp, Format: format})
+	return reply.Content, err
+}
+
+// makeCall is makeModelRequest for a whole request, e.g. one with tools
+func (c *client) makeCall(ctx context.Context, role Role, timeout time.Duration, model string, call chatRequest) (chatReply, error) {
+	caller := ctx
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	release, err := c.queue.Acquire(ctx, role)
 	if err != nil {
-		return "", err
+		return chatReply{}, timeoutError(err, timeout)
 	}
+	defer release()
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	var lastErr error
+	for i, p := range c.providers {
+		if !c.breaker.allow(p.Name) {
+			lastErr = fmt.Errorf("provider %s: circuit open", p.Name)
+			continue
+		}
+		reply, err := c.callProvider(ctx, p, providerModel(i, p, model), call)
+		c.breaker.record(caller, p.Name, err)
+		if err == nil {
+			if i > 0 {
+				fmt.Printf("LLM %s call served by fallback provider %s\n", role, p.Name)
+			}
+			consultation.RecordProvider(ctx, p.Name)
+			return reply, nil
+		}
+		if ctx.Err() != nil {
+			return chatReply{}, timeoutError(err, timeout)
+		}
+		fmt.Printf("LLM provider %s failed for %s: %v\n", p.Name, role, err)
+		lastErr = err
+	}
+	return chatReply{}, fmt.Errorf("%w: %v", consultation.ErrLLMUnavailable, lastErr)
+}
+
+// providerModel is the model to ask provider i for: the caller's choice on
+// the primary, the provider's own model otherwise
+func providerModel(i int, p Provider, model string) string {
+	if i == 0 && model != "" {
+		return model
+	}
+	return p.Model
+}
 
-	resp, err := c.httpClient.Do(req)
+// callProvider makes one attempt within the provider's latency budget
+func (c *client) callProvider(ctx context.Context, p Provider, model string, call chatRequest) (chatReply, error) {
+	attemptCtx, cancel := p.withBudget(ctx)
+	defer cancel()
+	overBudget := func(err error) error {
+		if ctx.Err() == nil && attemptCtx.Err() != nil {
+			return fmt.Errorf("no answer within latency budget %s", p.budget)
+		}
+		return err
+	}
+
+	format := call.Format
+	call.Model, call.Messages, call.Format = model, p.adjust(call.Messages), nil
+	if format != nil {
+		switch {
+		case format.JSONSchema != nil && p.jsonSchema():
+			call.Format = format
+		case p.jsonMode():
+			call.Format = jsonObject
+		}
+	}
+	call = p.offerTools(call)
+
+	api := p.api()
+	resp, err := c.do(attemptCtx, p, func() (*http.Request, error) {
+		return api.Request(attemptCtx, p, call)
+	})
 	if err != nil {
-		return "", err
+		return chatReply{}, overBudget(err)
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return chatReply{}, overBudget(err)
+	}
 	
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+		return chatReply{}, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
 	}
 
-	var chatResp chatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", err
+	msg, err := api.Reply(body)
+	if err != nil {
+		return chatReply{}, err
 	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("empty response from AI")
+	content, reasoning := splitThink(msg.Content)
+	c.recordReasoning(ctx, strings.TrimSpace(msg.ReasoningContent+"\n"+reasoning))
+	if format != nil && call.Format == nil {
+		content = extractJSON(content)
 	}
-
-	return chatResp.Choices[0].Message.Content, nil
+	if call.Tools != nil {
+		c.recordToolCalls(ctx, msg.ToolCalls)
+	}
+	return chatReply{Content: content, ToolCalls: msg.ToolCalls}, nil
 }
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// LLMProvider speaks one chat API. The client takes care of failover,
+// latency budgets and hiding the thinking of reasoning models; a provider
+// only encodes the call and decodes the answer.
+type LLMProvider interface {
+	// Request encodes one chat call for the endpoint p
+	Request(ctx context.Context, p Provider, call chatRequest) (*http.Request, error)
+	// Reply decodes a complete answer
+	Reply(body []byte) (chatReply, error)
+	// Chunk decodes one line of a streamed answer. Lines without content
+	// give an empty chunk; done is set once the answer is complete.
+	Chunk(line []byte) (chunk chatReply, done bool, err error)
+}
+
+// providerKind is an API the agents can run on, with the endpoint and model
+// used when nothing else is configured
+type providerKind struct {
+	api       LLMProvider
+	url       string
+	model     string
+	apiKeyEnv string
+}
+
+// ProviderKinds lists the APIs selectable by LLM_PROVIDER or a provider's kind
+var ProviderKinds = []string{"deepseek", "openai", "ollama", "openai-compatible"}
+
+var providerKinds = map[string]providerKind{
+	"deepseek": {deepSeekChat{}, deepSeekAPIURL, defaultModel, "DEEPSEEK_API_KEY"},
+	"openai":   {openAIChat{}, "https://api.openai.com/v1/chat/completions", "gpt-4o-mini", "OPENAI_API_KEY"},
+	"ollama":   {ollamaChat{}, "http://localhost:11434/api/chat", "qwen2.5:7b", ""},
+	// vLLM, LM Studio, Azure OpenAI and the like; the endpoint and model must be given
+	"openai-compatible": {chatCompletions{}, "", "", "LLM_API_KEY"},
+}
+
+// api returns the provider's wire format; an empty kind is the
+// OpenAI-compatible format of DeepSeek
+func (p Provider) api() LLMProvider {
+	if k, ok := providerKinds[p.Kind]; ok {
+		return k.api
+	}
+	return deepSeekChat{}
+}
+
+// chatCompletions is the OpenAI chat completions format, which DeepSeek,
+// vLLM and most local servers also accept
+type chatCompletions struct{}
+
+func (chatCompletions) Request(ctx context.Context, p Provider, call chatRequest) (*http.Request, error) {
+	return newJSONRequest(ctx, p, call)
+}
+
+func (chatCompletions) Reply(body []byte) (chatReply, error) {
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return chatReply{}, err
+	}
+	if len(chatResp.Choices) == 0 {
+		return chatReply{}, fmt.Errorf("empty response from AI")
+	}
+	return chatResp.Choices[0].Message, nil
+}
+
+// Chunk reads a server-sent event line
+func (chatCompletions) Chunk(line []byte) (chatReply, bool, error) {
+	data, ok := strings.CutPrefix(strings.TrimSpace(string(line)), "data: ")
+	if !ok {
+		return chatReply{}, false, nil
+	}
+	if data == "[DONE]" {
+		return chatReply{}, true, nil
+	}
+	var chatResp chatResponse
+	if err := json.Unmarshal([]byte(data), &chatResp); err != nil || len(chatResp.Choices) == 0 {
+		return chatReply{}, false, nil
+	}
+	return chatResp.Choices[0].Delta, false, nil
+}
+
+// deepSeekChat is the hosted DeepSeek API. deepseek-reasoner sends its
+// thinking as reasoning_content, which chatReply already reads.
+type deepSeekChat struct{ chatCompletions }
+
+// openAIChat is the OpenAI API. Its reasoning models (o1, o3, ...) only
+// take the default temperature.
+type openAIChat struct{ chatCompletions }
+
+func (openAIChat) Request(ctx context.Context, p Provider, call chatRequest) (*http.Request, error) {
+	if !openAIReasoning(call.Model) {
+		return newJSONRequest(ctx, p, call)
+	}
+	// The outer field wins over the embedded one when encoding
+	return newJSONRequest(ctx, p, struct {
+		chatRequest
+		Temperature *float64 `json:"temperature,omitempty"`
+	}{chatRequest: call})
+}
+
+func openAIReasoning(model string) bool {
+	return len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9'
+}
+
+// ollamaChat is Ollama's native /api/chat, which streams JSON lines instead
+// of server-sent events
+type ollamaChat struct{}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`           // Ollama streams unless told not to
+	Format   any             `json:"format,omitempty"` // "json" or a JSON schema
+	Tools    []tool          `json:"tools,omitempty"`
+	Options  struct {
+		Temperature float64 `json:"temperature"`
+	} `json:"options"`
+}
+
+// ollamaMessage differs from chatMessage in the tool calls, whose arguments
+// are an object rather than a string
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+// reply converts the message; Ollama sends whole calls without ids, so each
+// gets one to be kept apart from the others
+func (m ollamaMessage) reply() chatReply {
+	r := chatReply{Content: m.Content, ReasoningContent: m.Thinking}
+	for _, oc := range m.ToolCalls {
+		var tc toolCall
+		tc.ID = fmt.Sprintf("call_%d", len(r.ToolCalls))
+		tc.Function.Name, tc.Function.Arguments = oc.Function.Name, string(oc.Function.Arguments)
+		r.ToolCalls = append(r.ToolCalls, tc)
+	}
+	return r
+}
+
+func (ollamaChat) Request(ctx context.Context, p Provider, call chatRequest) (*http.Request, error) {
+	req := ollamaRequest{Model: call.Model, Stream: call.Stream}
+	for _, m := range call.Messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, tc := range m.ToolCalls {
+			var oc ollamaToolCall
+			oc.Function.Name, oc.Function.Arguments = tc.Function.Name, json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(oc.Function.Arguments) {
+				oc.Function.Arguments = json.RawMessage("{}")
+			}
+			om.ToolCalls = append(om.ToolCalls, oc)
+		}
+		req.Messages = append(req.Messages, om)
+	}
+	// Ollama has no tool_choice; without the tools it can only answer in text
+	if call.ToolChoice != "none" {
+		req.Tools = call.Tools
+	}
+	req.Options.Temperature = call.Temperature
+	if call.Format != nil {
+		req.Format = "json"
+		if call.Format.JSONSchema != nil {
+			req.Format = call.Format.JSONSchema.Schema
+		}
+	}
+	return newJSONRequest(ctx, p, req)
+}
+
+func (ollamaChat) Reply(body []byte) (chatReply, error) {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return chatReply{}, err
+	}
+	if resp.Error != "" {
+		return chatReply{}, fmt.Errorf("API error: %s", resp.Error)
+	}
+	return resp.Message.reply(), nil
+}
+
+func (ollamaChat) Chunk(line []byte) (chatReply, bool, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return chatReply{}, false, nil
+	}
+	var resp ollamaResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return chatReply{}, false, nil
+	}
+	if resp.Error != "" {
+		return chatReply{}, false, fmt.Errorf("API error: %s", resp.Error)
+	}
+	return resp.Message.reply(), resp.Done, nil
+}
+
+func newJSONRequest(ctx context.Context, p Provider, body any) (*http.Request, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.URL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
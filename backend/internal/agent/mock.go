@@ -0,0 +1,317 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// mockQuestions is the scripted interview, one question per patient turn
+// after the complaint
+var mockQuestions = []struct {
+	question string
+	category string // fact category the answer is recorded under
+}{
+	{"Понимаю. Когда это началось и сколько уже длится?", "Хронология"},
+	{"Насколько это беспокоит по шкале от 0 до 10?", "Симптом"},
+	{"Есть ли температура, тошнота или другие жалобы?", "Симптом"},
+	{"Принимаете ли вы сейчас какие-нибудь лекарства?", "Лекарство"},
+}
+
+const (
+	mockGreeting = "Здравствуйте! Я медицинский ассистент. Расскажите, пожалуйста, что вас беспокоит?"
+	mockFarewell = "Спасибо, я всё записал. Врач скоро подойдет."
+)
+
+// mockClient answers every agent call by fixed rules, without an LLM, so the
+// whole consultation flow down to the report runs offline. The Communicator
+// follows mockQuestions, then the pending epidemiological and required
+// questions, then says goodbye; the Analyst records each answer under the
+// category of the question it answers.
+type mockClient struct {
+	mu   sync.Mutex
+	epid map[string]string // epidemiological question -> its fact category, for the Analyst
+}
+
+// NewMockClient returns a deterministic client for development without API
+// keys, selected by AI_PROVIDER=mock. Replies depend only on the history.
+func NewMockClient() DeepSeekClient {
+	return &mockClient{epid: map[string]string{}}
+}
+
+// nextQuestion picks the Communicator's next line for the history
+func (m *mockClient) nextQuestion(history []consultation.Message, interview consultation.Interview) (text string, ends bool) {
+	turns := 0
+	for _, msg := range history {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	switch {
+	case turns == 0:
+		return mockGreeting, false
+	case len(interview.Continue) > 0:
+		return "Простите, я поторопился. Уточните, пожалуйста: " + interview.Continue[0], false
+	case interview.DoctorQuestion != "":
+		return "Врач просит уточнить: " + interview.DoctorQuestion, false
+	case turns <= len(mockQuestions):
+		return mockQuestions[turns-1].question, false
+	case len(interview.EpidTopics) > 0:
+		t := interview.EpidTopics[0]
+		m.mu.Lock()
+		m.epid[t.Question] = t.Category
+		m.mu.Unlock()
+		return t.Question, false
+	case len(interview.Required) > 0:
+		return mockRequiredQuestion(interview.Required[0]), false
+	}
+	return mockFarewell, true
+}
+
+func mockRequiredQuestion(f consultation.RequiredField) string {
+	return "Уточните, пожалуйста: " + strings.ToLower(f.Label) + "?"
+}
+
+// category is the fact category of the answer to question
+func (m *mockClient) category(question string, required []consultation.RequiredField) string {
+	for _, q := range mockQuestions {
+		if q.question == question {
+			return q.category
+		}
+	}
+	for _, f := range required {
+		if mockRequiredQuestion(f) == question {
+			return f.Label
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if category, ok := m.epid[question]; ok {
+		return category
+	}
+	return "Симптом"
+}
+
+// mockMood judges the mood from the patient's last message
+func mockMood(history []consultation.Message) consultation.EmotionalState {
+	text := strings.ToLower(lastUserMessage(history))
+	switch {
+	case strings.Contains(text, "не могу дышать") || strings.Contains(text, "боль в груди"):
+		return consultation.StateCritical
+	case strings.Contains(text, "боюсь") || strings.Contains(text, "страшно") || strings.Contains(text, "волнуюсь"):
+		return consultation.StateAnxious
+	}
+	return consultation.StateCalm
+}
+
+func lastUserMessage(history []consultation.Message) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			return history[i].Content
+		}
+	}
+	return ""
+}
+
+func (m *mockClient) RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (string, consultation.EmotionalState, error) {
+	text, ends := m.nextQuestion(history, interview)
+	newMood := mockMood(history)
+	if interview.Tools {
+		m.recordTools(ctx, newMood, ends)
+	}
+	return text, newMood, nil
+}
+
+// RunCommunicatorStream sends the reply word by word with the mood marker,
+// as a model would
+func (m *mockClient) RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (<-chan string, <-chan error) {
+	out := make(chan string)
+	errc := make(chan error, 1)
+	text, ends := m.nextQuestion(history, interview)
+	newMood := mockMood(history)
+	if interview.Tools {
+		m.recordTools(ctx, newMood, ends)
+	} else {
+		text = "[MOOD: " + mockMoodText[newMood] + "] " + text
+	}
+	go func() {
+		defer close(out)
+		defer close(errc)
+		for i, word := range strings.Fields(text) {
+			if i > 0 {
+				word = " " + word
+			}
+			select {
+			case out <- word:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return out, errc
+}
+
+var mockMoodText = map[consultation.EmotionalState]string{
+	consultation.StateCalm:     "Спокойное",
+	consultation.StateAnxious:  "Тревожное",
+	consultation.StateCritical: "Критическое",
+}
+
+func (m *mockClient) recordTools(ctx context.Context, mood consultation.EmotionalState, ends bool) {
+	calls := []consultation.ToolCall{{Tool: consultation.ToolSetMood, Mood: mood}}
+	if ends {
+		calls = append(calls, consultation.ToolCall{Tool: consultation.ToolEndConsultation})
+	}
+	consultation.RecordToolCalls(ctx, calls)
+}
+
+// RunAnalyst records the patient's last answer as a fact under the category
+// of the question it answers; the first answer is the complaint
+func (m *mockClient) RunAnalyst(ctx context.Context, history []consultation.Message, required []consultation.RequiredField) (*consultation.AnalysisResult, error) {
+	result := &consultation.AnalysisResult{}
+	last := len(history) - 1
+	for last >= 0 && history[last].Role != "user" {
+		last--
+	}
+	if last < 0 {
+		return result, nil
+	}
+	answer := strings.TrimSpace(history[last].Content)
+	if answer == "" {
+		return result, nil
+	}
+	category := "Симптом"
+	for i := last - 1; i >= 0; i-- {
+		if history[i].Role == "assistant" {
+			category = m.category(history[i].Content, required)
+			break
+		}
+	}
+	lower := strings.ToLower(answer)
+	if lower == "нет" || strings.HasPrefix(lower, "нет,") || strings.HasPrefix(lower, "нет ") {
+		if category == "Симптом" {
+			result.Negatives = append(result.Negatives, consultation.PertinentNegative{Symptom: "другие жалобы", Context: answer, Confidence: "Высокая"})
+			return result, nil
+		}
+	}
+	result.Facts = append(result.Facts, consultation.MedicalFact{Category: category, Description: answer, Confidence: "Высокая"})
+	if category == "Лекарство" && !strings.HasPrefix(lower, "нет") {
+		result.Medications = append(result.Medications, consultation.Medication{Name: answer})
+	}
+	return result, nil
+}
+
+// RunSupervisor agrees once the script and the pending questions are through
+func (m *mockClient) RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact, negatives []consultation.PertinentNegative, pending []consultation.RequiredField) (bool, error) {
+	turns := 0
+	for _, msg := range history {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	return len(pending) == 0 && turns > len(mockQuestions), nil
+}
+
+func (m *mockClient) GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (*consultation.RecommendationResult, error) {
+	text := fmt.Sprintf("Тестовый режим без LLM, рекомендации не составлялись. Собрано фактов: %d.", len(facts))
+	return &consultation.RecommendationResult{Text: text, Confidence: -1}, nil
+}
+
+// RunScreener takes any answer without a "нет" as positive
+func (m *mockClient) RunScreener(ctx context.Context, question string, answer string) (bool, error) {
+	return !strings.Contains(strings.ToLower(answer), "нет"), nil
+}
+
+func (m *mockClient) RunQualityReview(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (*consultation.QualityReview, error) {
+	return &consultation.QualityReview{
+		Score: 100, Coverage: 100, Empathy: 100,
+		Comment: "Тестовый режим без LLM", PromptVersion: "mock", ReviewedAt: time.Now(),
+	}, nil
+}
+
+// mockComplaints maps words of a complaint to its category
+var mockComplaints = []struct {
+	word     string
+	category consultation.ComplaintCategory
+}{
+	{"кашель", consultation.ComplaintRespiratory},
+	{"дыш", consultation.ComplaintRespiratory},
+	{"сердц", consultation.ComplaintCardiovascular},
+	{"давлени", consultation.ComplaintCardiovascular},
+	{"температур", consultation.ComplaintFever},
+	{"травм", consultation.ComplaintInjury},
+	{"голов", consultation.ComplaintNeurological},
+	{"живот", consultation.ComplaintDigestive},
+	{"тошн", consultation.ComplaintDigestive},
+	{"сып", consultation.ComplaintSkin},
+	{"бол", consultation.ComplaintPain},
+}
+
+func (m *mockClient) DetectChiefComplaint(ctx context.Context, message string) (*consultation.ChiefComplaint, error) {
+	lower := strings.ToLower(message)
+	for _, c := range mockComplaints {
+		if strings.Contains(lower, c.word) {
+			return &consultation.ChiefComplaint{Text: strings.TrimSpace(message), Category: c.category}, nil
+		}
+	}
+	return nil, nil
+}
+
+// mockRedFlags maps word stems that together signal an emergency to its category
+var mockRedFlags = []struct {
+	stems    []string
+	category consultation.RedFlagCategory
+}{
+	{[]string{"груд", "отда"}, consultation.RedFlagCardiac},
+	{[]string{"перекос"}, consultation.RedFlagStroke},
+	{[]string{"онемел"}, consultation.RedFlagStroke},
+	{[]string{"не хочу жить"}, consultation.RedFlagSuicidal},
+}
+
+// RunRedFlagScreen flags a message that has all stems of a rule
+func (m *mockClient) RunRedFlagScreen(ctx context.Context, question, message string) ([]consultation.RedFlag, error) {
+	lower := strings.ToLower(message)
+	var flags []consultation.RedFlag
+	for _, rule := range mockRedFlags {
+		matched := true
+		for _, stem := range rule.stems {
+			matched = matched && strings.Contains(lower, stem)
+		}
+		if matched {
+			flags = append(flags, consultation.RedFlag{Category: rule.category, Indicator: strings.TrimSpace(message)})
+		}
+	}
+	return flags, nil
+}
+
+func (m *mockClient) SummarizeWearables(ctx context.Context, signals []string, facts []consultation.MedicalFact) ([]consultation.MedicalFact, error) {
+	var summary []consultation.MedicalFact
+	for _, s := range signals {
+		summary = append(summary, consultation.MedicalFact{Category: consultation.WearablesCategory, Description: s, Confidence: "Средняя"})
+	}
+	return summary, nil
+}
+
+func (m *mockClient) SummarizeFacts(ctx context.Context, facts []consultation.MedicalFact, limit int) ([]consultation.MedicalFact, error) {
+	if len(facts) > limit {
+		facts = facts[:limit]
+	}
+	return facts, nil
+}
+
+// Translate returns the texts as they are
+func (m *mockClient) Translate(ctx context.Context, texts []string, from, to, model string) ([]string, error) {
+	return append([]string(nil), texts...), nil
+}
+
+func (m *mockClient) CheckRecap(ctx context.Context, recap, answer string, facts []consultation.MedicalFact) (*consultation.RecapCheck, error) {
+	confirmed := !strings.Contains(strings.ToLower(answer), "нет")
+	return &consultation.RecapCheck{Confirmed: confirmed, Facts: facts}, nil
+}
+
+func (m *mockClient) Prewarm(ctx context.Context) {}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+// AgentModel overrides the model and temperature of one agent role, e.g. a
+// cheap model for the Supervisor and a stronger one for Recommendations
+type AgentModel struct {
+	// Asked of the primary provider; fallbacks keep their own model. Empty
+	// means the provider's model.
+	Model string `json:"model,omitempty"`
+	// nil keeps the agent's built-in temperature
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// AgentModels holds the overrides by role. Agents without a queue role of
+// their own share one: the fact summary runs as recommendations and the
+// recap check as analyst. An experiment arm's model and temperature still
+// win for the Communicator, as does the report translation model.
+type AgentModels map[Role]AgentModel
+
+// LoadAgentModels reads overrides written in YAML, e.g.
+// {"supervisor": {"model": "deepseek-chat", "temperature": 0}} or the same in block style.
+// Without a path there are none.
+func LoadAgentModels(path string) (AgentModels, error) {
+	if path == "" {
+		return AgentModels{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAgentModels(data)
+}
+
+// ParseAgentModels reads the overrides file format, see LoadAgentModels
+func ParseAgentModels(data []byte) (AgentModels, error) {
+	models := AgentModels{}
+	if err := yamlconf.Unmarshal(data, &models); err != nil {
+		return nil, fmt.Errorf("invalid agent models file: %w", err)
+	}
+	for role, m := range models {
+		if err := m.validate(role); err != nil {
+			return nil, err
+		}
+	}
+	return models, nil
+}
+
+// Set overrides one role, as the LLM_MODEL_<ROLE> and LLM_TEMPERATURE_<ROLE>
+// variables do on top of the file
+func (m AgentModels) Set(role Role, override AgentModel) error {
+	if err := override.validate(role); err != nil {
+		return err
+	}
+	current := m[role]
+	if override.Model != "" {
+		current.Model = override.Model
+	}
+	if override.Temperature != nil {
+		current.Temperature = override.Temperature
+	}
+	m[role] = current
+	return nil
+}
+
+func (m AgentModel) validate(role Role) error {
+	if !slices.Contains(Roles, role) {
+		return fmt.Errorf("unknown agent role %q", role)
+	}
+	if t := m.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("agent %s: temperature must be 0-2", role)
+	}
+	return nil
+}
+
+// pick returns the role's model, "" for the provider's own, and its
+// temperature, temp unless overridden
+func (m AgentModels) pick(role Role, temp float64) (string, float64) {
+	override := m[role]
+	if override.Temperature != nil {
+		temp = *override.Temperature
+	}
+	return override.Model, temp
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// PoolConfig tunes the connections to the LLM providers. Opening a TLS
+// connection costs a few round trips, which the first Communicator call of a
+// session would otherwise pay.
+type PoolConfig struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration // idle connections are closed after this
+	KeepAlive           time.Duration // TCP keepalive probe interval
+	HTTP2               bool
+	DNSCacheTTL         time.Duration // 0 resolves on every dial
+	// Prewarm opens a connection to every provider at startup and again
+	// before idle ones would be closed
+	Prewarm bool
+}
+
+var DefaultPoolConfig = PoolConfig{
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     5 * time.Minute,
+	KeepAlive:           30 * time.Second,
+	HTTP2:               true,
+	DNSCacheTTL:         time.Minute,
+}
+
+// NewTransport returns the transport shared by all LLM provider clients
+func NewTransport(cfg PoolConfig) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: cfg.KeepAlive}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = dialer.DialContext
+	if cfg.DNSCacheTTL > 0 {
+		t.DialContext = (&dnsCache{ttl: cfg.DNSCacheTTL, entries: map[string]dnsEntry{}}).dial(dialer)
+	}
+	t.ForceAttemptHTTP2 = cfg.HTTP2
+	t.MaxIdleConns = 0 // no overall limit, per host only
+	t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	t.IdleConnTimeout = cfg.IdleConnTimeout
+	return t
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache keeps resolved provider addresses for a while, so a connection
+// opened on a busy turn does not wait for DNS
+type dnsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	e, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+	return addrs, nil
+}
+
+func (d *dnsCache) dial(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		addrs, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, a := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(a, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		// The host may have moved; resolve again on the next dial
+		d.mu.Lock()
+		delete(d.entries, host)
+		d.mu.Unlock()
+		return nil, lastErr
+	}
+}
+
+// Prewarm opens a connection to every provider with a HEAD request to its
+// host, leaving it in the pool for the next call. The answer itself does not
+// matter, so errors are only logged.
+func (c *client) Prewarm(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range c.providers {
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(p Provider, origin string) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, origin, nil)
+			if err != nil {
+				return
+			}
+			resp, err := c.httpClients[p.Name].Do(req)
+			if err != nil {
+				fmt.Printf("Failed to prewarm LLM provider %s: %v\n", p.Name, err)
+				return
+			}
+			// Drained so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}(p, u.Scheme+"://"+u.Host+"/")
+	}
+	wg.Wait()
+}
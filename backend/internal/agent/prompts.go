@@ -0,0 +1,415 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Built-in system prompts, one text/template per entry of PromptVersions
+//
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+var promptFuncs = template.FuncMap{"join": strings.Join}
+
+// Prompts holds the system prompt templates. A file <name>.tmpl in the
+// override directory replaces the built-in prompt of that name, so clinical
+// staff can tune the wording without a rebuild; Watch picks up edits.
+type Prompts struct {
+	dir string
+
+	mu        sync.RWMutex
+	builtin   map[string]*template.Template
+	overrides map[string]*template.Template
+	texts     map[string]string // sources of the overrides, kept in config versions
+	hashes    map[string]string // overridden prompts, for their versions
+	modTimes  map[string]time.Time
+}
+
+// NewPrompts loads the built-in prompts and the overrides in dir, if set.
+// A broken override fails here, later reloads keep the previous template.
+func NewPrompts(dir string) (*Prompts, error) {
+	p := &Prompts{dir: dir, builtin: make(map[string]*template.Template)}
+	for name := range PromptVersions {
+		data, err := builtinPrompts.ReadFile("prompts/" + name + ".tmpl")
+		if err != nil {
+			return nil, err
+		}
+		t, err := parsePrompt(name, data)
+		if err != nil {
+			return nil, err
+		}
+		p.builtin[name] = t
+	}
+	if _, err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DefaultPrompts has only the built-in prompts
+func DefaultPrompts() *Prompts {
+	p, err := NewPrompts("")
+	if err != nil {
+		panic(err) // the embedded templates are part of the build
+	}
+	return p
+}
+
+func parsePrompt(name string, data []byte) (*template.Template, error) {
+	t, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// parseOverride also runs the template on empty data, which catches
+// misspelled placeholders outside of conditions before the prompt is used
+func parseOverride(name string, data []byte) (*template.Template, error) {
+	t, err := parsePrompt(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(io.Discard, promptData[name]); err != nil {
+		return nil, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// Reload reads the override directory again if any template changed. It
+// reports whether the prompts in use changed; an override that does not
+// parse is skipped and keeps the one loaded before.
+func (p *Prompts) Reload() (bool, error) {
+	if p.dir == "" {
+		return false, nil
+	}
+	modTimes := make(map[string]time.Time)
+	for name := range PromptVersions {
+		if info, err := os.Stat(filepath.Join(p.dir, name+".tmpl")); err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+	if p.unchanged(modTimes) {
+		return false, nil
+	}
+
+	p.mu.RLock()
+	overrides, texts, hashes := make(map[string]*template.Template), make(map[string]string), make(map[string]string)
+	var errs []string
+	for name := range modTimes {
+		data, err := os.ReadFile(filepath.Join(p.dir, name+".tmpl"))
+		if err == nil {
+			var t *template.Template
+			if t, err = parseOverride(name, data); err == nil {
+				overrides[name], texts[name], hashes[name] = t, string(data), promptHash(data)
+				continue
+			}
+		}
+		errs = append(errs, err.Error())
+		if old := p.overrides[name]; old != nil {
+			overrides[name], texts[name], hashes[name] = old, p.texts[name], p.hashes[name]
+		}
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	p.overrides, p.texts, p.hashes, p.modTimes = overrides, texts, hashes, modTimes
+	p.mu.Unlock()
+	if len(errs) > 0 {
+		return true, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return true, nil
+}
+
+func promptHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:4])
+}
+
+func (p *Prompts) unchanged(modTimes map[string]time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.modTimes == nil || len(modTimes) != len(p.modTimes) {
+		return false
+	}
+	for name, t := range modTimes {
+		if !t.Equal(p.modTimes[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Watch reloads the overrides every interval until ctx is cancelled and calls
+// changed with the new versions after each change
+func (p *Prompts) Watch(ctx context.Context, interval time.Duration, changed func(versions map[string]string)) {
+	if p.dir == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ok, err := p.Reload()
+				if err != nil {
+					log.Printf("Prompt templates: %v", err)
+				}
+				if ok {
+					log.Printf("Reloaded prompt templates from %s", p.dir)
+					if changed != nil {
+						changed(p.Versions())
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Versions is PromptVersions with the content hash appended for every
+// overridden prompt, e.g. "13-custom-1a2b3c4d"
+func (p *Prompts) Versions() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	versions := make(map[string]string, len(PromptVersions))
+	for name, v := range PromptVersions {
+		if h, ok := p.hashes[name]; ok {
+			v += "-custom-" + h
+		}
+		versions[name] = v
+	}
+	return versions
+}
+
+// PromptConfig is the content of a prompts config version: the versions in
+// use and the source of every overridden template, so a rollback can bring
+// the wording back
+type PromptConfig struct {
+	Versions  map[string]string `json:"versions"`
+	Overrides map[string]string `json:"overrides,omitempty"`
+}
+
+// Config returns the prompts in use as a config version
+func (p *Prompts) Config() PromptConfig {
+	cfg := PromptConfig{Versions: p.Versions()}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.texts) > 0 {
+		cfg.Overrides = make(map[string]string, len(p.texts))
+		for name, text := range p.texts {
+			cfg.Overrides[name] = text
+		}
+	}
+	return cfg
+}
+
+// Validate checks a prompts config version before it is stored
+func (p *Prompts) Validate(content json.RawMessage) error {
+	_, _, err := decodePromptConfig(content)
+	return err
+}
+
+func decodePromptConfig(content json.RawMessage) (PromptConfig, map[string]*template.Template, error) {
+	var cfg PromptConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return cfg, nil, err
+	}
+	if cfg.Versions == nil {
+		// Versions recorded before the templates were kept cannot be restored
+		return cfg, nil, fmt.Errorf("no prompt versions; this version holds no template texts")
+	}
+	overrides := make(map[string]*template.Template, len(cfg.Overrides))
+	for name, text := range cfg.Overrides {
+		if _, ok := PromptVersions[name]; !ok {
+			return cfg, nil, fmt.Errorf("unknown prompt %q", name)
+		}
+		t, err := parseOverride(name, []byte(text))
+		if err != nil {
+			return cfg, nil, err
+		}
+		overrides[name] = t
+	}
+	return cfg, overrides, nil
+}
+
+// Apply puts the overrides of a prompts config version in effect; the other
+// prompts go back to the built-in ones. With an override directory the files
+// are rewritten, so the watcher and a restart keep them.
+func (p *Prompts) Apply(ctx context.Context, content json.RawMessage) error {
+	cfg, overrides, err := decodePromptConfig(content)
+	if err != nil {
+		return err
+	}
+
+	if p.dir != "" {
+		for name := range PromptVersions {
+			path := filepath.Join(p.dir, name+".tmpl")
+			if text, ok := cfg.Overrides[name]; ok {
+				err = os.WriteFile(path, []byte(text), 0o644)
+			} else if err = os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+				err = nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write prompt %s: %w", name, err)
+			}
+		}
+		_, err := p.Reload()
+		return err
+	}
+
+	texts, hashes := make(map[string]string), make(map[string]string)
+	for name, text := range cfg.Overrides {
+		texts[name], hashes[name] = text, promptHash([]byte(text))
+	}
+	p.mu.Lock()
+	p.overrides, p.texts, p.hashes = overrides, texts, hashes
+	p.mu.Unlock()
+	return nil
+}
+
+// Version of one prompt, see Versions
+func (p *Prompts) Version(name string) string {
+	return p.Versions()[name]
+}
+
+// render executes the prompt's template. An override that fails on this data
+// falls back to the built-in prompt so the interview goes on.
+func (p *Prompts) render(name string, data any) (string, error) {
+	p.mu.RLock()
+	override, builtin := p.overrides[name], p.builtin[name]
+	p.mu.RUnlock()
+	if builtin == nil {
+		return "", fmt.Errorf("unknown prompt %s", name)
+	}
+
+	var buf bytes.Buffer
+	if override != nil {
+		err := override.Execute(&buf, data)
+		if err == nil {
+			return strings.TrimSpace(buf.String()), nil
+		}
+		log.Printf("Prompt %s from %s failed, using the built-in one: %v", name, p.dir, err)
+		buf.Reset()
+	}
+	if err := builtin.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Template data. Field names are the placeholders available to overrides.
+
+var promptData = map[string]any{
+	"communicator":    communicatorData{},
+	"analyst":         analystData{},
+	"supervisor":      supervisorData{},
+	"recommendations": factsData{},
+	"screener":        screenerData{},
+	"quality":         qualityData{},
+	"complaint":       complaintData{},
+	"red_flag":        redFlagData{},
+	"wearables":       wearablesData{},
+	"fact_summary":    factSummaryData{},
+	"translation":     translationData{},
+	"recap":           recapData{},
+}
+
+type communicatorData struct {
+	Mood                     consultation.EmotionalState
+	Pediatric                bool
+	Visits                   []string // earlier visits this one follows up
+	Questionnaires           []string // summaries of questionnaires filled in before the visit
+	EpidTopics               []string
+	Required                 []consultation.RequiredField
+	Conditions               []string // conditions with unknown treatment
+	MedicationReconciliation bool
+	MaxSentenceWords         int
+	ScreenText               bool
+	Keypad                   bool
+	ScreenTag                string
+	KeysTag                  string
+	DoctorQuestion           string
+	NextQuestion             string
+	Variant                  string   // the experiment arm's extra instructions
+	AfterHours               string   // the clinic's after-hours script, "" while open
+	Tools                    bool     // actions go through tool calls instead of text markers
+	Continue                 []string // the last farewell came too early, this is still missing
+}
+
+type analystData struct {
+	Required []consultation.RequiredField
+}
+
+type supervisorData struct {
+	Facts     []consultation.MedicalFact
+	Negatives []consultation.PertinentNegative
+	Pending   []consultation.RequiredField
+}
+
+type factsData struct {
+	Facts []consultation.MedicalFact
+}
+
+type screenerData struct {
+	Question string
+}
+
+type transcriptLine struct {
+	Speaker string
+	Text    string
+}
+
+type qualityData struct {
+	Facts      []consultation.MedicalFact
+	Transcript []transcriptLine
+}
+
+type complaintData struct {
+	Categories []string
+}
+
+type redFlagData struct {
+	Categories []string
+	Question   string // the assistant's line the message answers, "" before the first one
+}
+
+type wearablesData struct {
+	Facts   []consultation.MedicalFact
+	Signals []string
+}
+
+type factSummaryData struct {
+	Facts []consultation.MedicalFact
+	Limit int
+}
+
+type translationData struct {
+	From, To string
+	Texts    string // JSON array
+}
+
+type recapData struct {
+	Recap string
+	Facts string // JSON array
+}
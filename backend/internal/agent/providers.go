@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Provider is one chat endpoint. Calls go to the first provider of the chain
+// and fail over to the next one on an error or when the latency budget runs
+// out.
+type Provider struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind,omitempty"` // API of the endpoint, one of ProviderKinds; empty is OpenAI-compatible
+	URL       string `json:"url"`
+	BaseURL   string `json:"base_url,omitempty"` // instead of url: the API root, e.g. "http://vllm:8000/v1", /chat/completions is appended
+	APIKeyEnv string `json:"api_key_env"`        // environment variable holding the key; keys stay out of the file
+	Model     string `json:"model"`              // experiment arm models apply to the first provider only
+	// Sent with every request, e.g. {"api-key": "${AZURE_OPENAI_KEY}"} for
+	// Azure OpenAI; ${VAR} is taken from the environment
+	Headers map[string]string `json:"headers,omitempty"`
+	// Time to a complete answer, or to the first token when streaming,
+	// before the next provider is tried, e.g. "20s". Empty means only the
+	// agent timeout applies.
+	LatencyBudget string `json:"latency_budget,omitempty"`
+	// Appended to the system prompt, e.g. to remind a smaller model to answer in Russian
+	PromptSuffix string `json:"prompt_suffix,omitempty"`
+	// false for endpoints without response_format; JSON is then cut out of the reply
+	JSONMode *bool `json:"json_mode,omitempty"`
+	// Structured output: the Analyst's reply is constrained to its JSON
+	// schema. On by default for openai and ollama, which support it; off
+	// falls back to json_mode.
+	JSONSchema *bool `json:"json_schema,omitempty"`
+	// Function calling for the Communicator's tools; false for endpoints
+	// without it. On by default except for deepseek-reasoner.
+	Tools *bool `json:"tools,omitempty"`
+
+	apiKey  string
+	headers map[string]string // Headers with the environment filled in
+	budget  time.Duration
+}
+
+// DefaultProvider completes p, the only provider unless LLM_PROVIDERS_FILE
+// is set: the API of its kind ("" is DeepSeek) at the kind's usual endpoint
+// and model unless p names others, e.g. for an Ollama on another host.
+func DefaultProvider(p Provider) (Provider, error) {
+	if p.Kind == "" {
+		p.Kind = "deepseek"
+	}
+	if p.Name == "" {
+		p.Name = p.Kind
+	}
+	if err := p.resolve(); err != nil {
+		return Provider{}, err
+	}
+	// deepseek-reasoner does not take response_format
+	if p.Kind == "deepseek" && p.JSONMode == nil && strings.Contains(p.Model, "reasoner") {
+		off := false
+		p.JSONMode = &off
+	}
+	return p, nil
+}
+
+// LoadProviders reads the failover chain from a JSON list, falling back to
+// DefaultProvider(fallback) when path is empty
+func LoadProviders(path string, fallback Provider) ([]Provider, error) {
+	if path == "" {
+		p, err := DefaultProvider(fallback)
+		if err != nil {
+			return nil, err
+		}
+		return []Provider{p}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var providers []Provider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, fmt.Errorf("invalid provider list: %w", err)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider list is empty")
+	}
+	names := map[string]bool{}
+	for i := range providers {
+		p := &providers[i]
+		if p.Name == "" {
+			return nil, fmt.Errorf("provider %d: name is required", i+1)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("provider %q is listed twice", p.Name)
+		}
+		names[p.Name] = true
+		if err := p.resolve(); err != nil {
+			return nil, err
+		}
+	}
+	return providers, nil
+}
+
+// resolve fills in what the kind brings and reads the secrets from the environment
+func (p *Provider) resolve() error {
+	// A known kind brings its endpoint, model and key variable
+	if k, ok := providerKinds[p.Kind]; ok {
+		if p.URL == "" && p.BaseURL == "" {
+			p.URL = k.url
+		}
+		if p.Model == "" {
+			p.Model = k.model
+		}
+		if p.APIKeyEnv == "" {
+			p.APIKeyEnv = k.apiKeyEnv
+		}
+	} else if p.Kind != "" {
+		return fmt.Errorf("provider %q: unknown kind %q, expected one of %s", p.Name, p.Kind, strings.Join(ProviderKinds, ", "))
+	}
+	if p.URL == "" && p.BaseURL != "" {
+		u, err := url.Parse(p.BaseURL)
+		if err != nil {
+			return fmt.Errorf("provider %q: invalid base_url: %w", p.Name, err)
+		}
+		// Keeps a query such as Azure's api-version
+		p.URL = u.JoinPath("chat", "completions").String()
+	}
+	if p.URL == "" || p.Model == "" {
+		return fmt.Errorf("provider %q: url (or base_url) and model are required", p.Name)
+	}
+	if p.LatencyBudget != "" {
+		var err error
+		if p.budget, err = time.ParseDuration(p.LatencyBudget); err != nil || p.budget <= 0 {
+			return fmt.Errorf("provider %q: invalid latency_budget %q", p.Name, p.LatencyBudget)
+		}
+	}
+	if p.APIKeyEnv != "" {
+		p.apiKey = os.Getenv(p.APIKeyEnv)
+	}
+	p.headers = make(map[string]string, len(p.Headers))
+	for name, value := range p.Headers {
+		p.headers[name] = os.ExpandEnv(value)
+	}
+	return nil
+}
+
+// MarshalJSON hides header values for the admin config unless they only name
+// an environment variable
+func (p Provider) MarshalJSON() ([]byte, error) {
+	type plain Provider
+	if len(p.Headers) > 0 {
+		masked := make(map[string]string, len(p.Headers))
+		for name, value := range p.Headers {
+			if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+				value = "***"
+			}
+			masked[name] = value
+		}
+		p.Headers = masked
+	}
+	return json.Marshal(plain(p))
+}
+
+// ParseHeaders reads headers given as "Name=value" pairs separated by commas,
+// as in LLM_HEADERS
+func ParseHeaders(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q, expected Name=value", strings.TrimSpace(pair))
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+func (p Provider) jsonMode() bool {
+	return p.JSONMode == nil || *p.JSONMode
+}
+
+func (p Provider) tools() bool {
+	if p.Tools != nil {
+		return *p.Tools
+	}
+	return !(p.Kind == "deepseek" && strings.Contains(p.Model, "reasoner"))
+}
+
+func (p Provider) jsonSchema() bool {
+	if p.JSONSchema != nil {
+		return *p.JSONSchema
+	}
+	return p.jsonMode() && (p.Kind == "openai" || p.Kind == "ollama")
+}
+
+// adjust applies the provider's prompt suffix to the system message
+func (p Provider) adjust(messages []chatMessage) []chatMessage {
+	if p.PromptSuffix == "" || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	adjusted := append([]chatMessage(nil), messages...)
+	adjusted[0].Content += "\n\n" + p.PromptSuffix
+	return adjusted
+}
+
+// withBudget bounds one attempt by the provider's latency budget
+func (p Provider) withBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, p.budget)
+}
+
+// extractJSON cuts the JSON object out of a reply from an endpoint without
+// JSON mode, which tends to wrap it in a Markdown fence or add a sentence
+func extractJSON(reply string) string {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return reply
+	}
+	return reply[start : end+1]
+}
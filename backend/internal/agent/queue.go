@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// Role identifies the agent behind an LLM call for scheduling
+type Role string
+
+const (
+	RoleCommunicator    Role = "communicator"
+	RoleAnalyst         Role = "analyst"
+	RoleSupervisor      Role = "supervisor"
+	RoleRecommendations Role = "recommendations"
+	RoleScreener        Role = "screener"
+	RoleQuality         Role = "quality"
+	RoleComplaint       Role = "complaint"
+	RoleRedFlag         Role = "red_flag"
+	RoleWearables       Role = "wearables"
+	RoleTranslation     Role = "translation"
+)
+
+var Roles = []Role{RoleCommunicator, RoleAnalyst, RoleSupervisor, RoleRecommendations, RoleScreener, RoleQuality, RoleComplaint, RoleRedFlag, RoleWearables, RoleTranslation}
+
+// QueueConfig bounds concurrent LLM calls. When all slots are busy, a freed
+// slot goes to the waiting role that got the smallest share relative to its
+// weight, so the patient-facing Communicator overtakes background work
+// without starving it.
+type QueueConfig struct {
+	Concurrency int          `json:"concurrency"` // calls in flight across all roles
+	Limits      map[Role]int `json:"limits"`      // per-role cap; missing means only the total applies
+	Weights     map[Role]int `json:"weights"`     // missing means 1
+}
+
+// The caps keep background agents from filling every slot, so a patient's
+// turn at most waits for one call to finish
+var DefaultQueueConfig = QueueConfig{
+	Concurrency: 8,
+	Limits: map[Role]int{
+		RoleAnalyst:         3,
+		RoleSupervisor:      2,
+		RoleRecommendations: 2,
+		RoleQuality:         1,
+		RoleWearables:       1,
+		RoleTranslation:     1,
+	},
+	Weights: map[Role]int{
+		RoleCommunicator:    10,
+		RoleScreener:        5,
+		RoleComplaint:       5,
+		RoleRedFlag:         5,
+		RoleAnalyst:         2,
+		RoleSupervisor:      2,
+		RoleRecommendations: 1,
+		RoleQuality:         1,
+		RoleWearables:       1,
+		RoleTranslation:     1,
+	},
+}
+
+// Queue is a weighted work queue in front of the LLM API. A nil *Queue
+// lets every call through.
+type Queue struct {
+	cfg QueueConfig
+
+	mu      sync.Mutex
+	running int
+	byRole  map[Role]int
+	waiting map[Role][]*queued
+	// pass is each role's virtual time: it advances by 1/weight per call, and
+	// the waiting role with the lowest pass goes next
+	pass  map[Role]float64
+	clock float64
+}
+
+type queued struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewQueue returns nil, i.e. no queueing, when cfg.Concurrency is not positive
+func NewQueue(cfg QueueConfig) *Queue {
+	if cfg.Concurrency <= 0 {
+		return nil
+	}
+	return &Queue{
+		cfg:     cfg,
+		byRole:  make(map[Role]int),
+		waiting: make(map[Role][]*queued),
+		pass:    make(map[Role]float64),
+	}
+}
+
+// Acquire waits for a slot for role; call the returned func when the call is
+// done. Waiting counts against ctx, so the agent's timeout covers the queue.
+func (q *Queue) Acquire(ctx context.Context, role Role) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	w := &queued{ready: make(chan struct{})}
+	q.mu.Lock()
+	if len(q.waiting[role]) == 0 && q.pass[role] < q.clock {
+		// An idle role does not bank credit while it has nothing to run
+		q.pass[role] = q.clock
+	}
+	q.waiting[role] = append(q.waiting[role], w)
+	q.dispatch()
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		var once sync.Once
+		return func() { once.Do(func() { q.release(role) }) }, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if w.granted {
+			// Granted while we were giving up
+			q.running--
+			q.byRole[role]--
+			q.dispatch()
+		} else {
+			q.remove(role, w)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *Queue) release(role Role) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.byRole[role]--
+	q.dispatch()
+}
+
+// dispatch hands free slots to waiting calls; q.mu must be held
+func (q *Queue) dispatch() {
+	for q.running < q.cfg.Concurrency {
+		next, found := Role(""), false
+		for _, role := range Roles {
+			if len(q.waiting[role]) == 0 {
+				continue
+			}
+			if limit := q.cfg.Limits[role]; limit > 0 && q.byRole[role] >= limit {
+				continue
+			}
+			if !found || q.pass[role] < q.pass[next] {
+				next, found = role, true
+			}
+		}
+		if !found {
+			return
+		}
+
+		w := q.waiting[next][0]
+		q.waiting[next] = q.waiting[next][1:]
+		w.granted = true
+		close(w.ready)
+		q.running++
+		q.byRole[next]++
+		q.clock = q.pass[next]
+		q.pass[next] += 1 / float64(q.weight(next))
+	}
+}
+
+func (q *Queue) remove(role Role, w *queued) {
+	waiting := q.waiting[role]
+	for i := range waiting {
+		if waiting[i] == w {
+			q.waiting[role] = append(waiting[:i], waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *Queue) weight(role Role) int {
+	if w := q.cfg.Weights[role]; w > 0 {
+		return w
+	}
+	return 1
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Reasoning models (deepseek-reasoner and the like) think before answering.
+// The hosted API sends the thinking as reasoning_content next to the
+// content; R1-style local models put it inline in <think> tags. Either way it
+// must not reach the patient.
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkFilter separates inline <think> blocks from the answer as tokens
+// stream in. A tag split across tokens is held back until it is complete.
+type thinkFilter struct {
+	inThink bool
+	pending string
+}
+
+func (f *thinkFilter) feed(token string) (content, reasoning string) {
+	s := f.pending + token
+	f.pending = ""
+	var out, think strings.Builder
+	emit := func(text string) {
+		if f.inThink {
+			think.WriteString(text)
+		} else {
+			out.WriteString(text)
+		}
+	}
+	for s != "" {
+		tag := thinkOpen
+		if f.inThink {
+			tag = thinkClose
+		}
+		if i := strings.Index(s, tag); i >= 0 {
+			emit(s[:i])
+			s = s[i+len(tag):]
+			f.inThink = !f.inThink
+			continue
+		}
+		keep := partialTag(s, tag)
+		emit(s[:len(s)-keep])
+		f.pending = s[len(s)-keep:]
+		break
+	}
+	return out.String(), think.String()
+}
+
+// flush returns what was held back once the stream ended
+func (f *thinkFilter) flush() (content, reasoning string) {
+	s := f.pending
+	f.pending = ""
+	if f.inThink {
+		return "", s
+	}
+	return s, ""
+}
+
+// partialTag is the length of the longest suffix of s that starts tag
+func partialTag(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// splitThink cuts inline <think> blocks out of a complete reply
+func splitThink(reply string) (content, reasoning string) {
+	var f thinkFilter
+	content, reasoning = f.feed(reply)
+	c, r := f.flush()
+	return strings.TrimSpace(content + c), strings.TrimSpace(reasoning + r)
+}
+
+// recordReasoning hands a reply's thinking over for audit when configured.
+// Only the Communicator's is kept, see consultation.RecordReasoning.
+func (c *client) recordReasoning(ctx context.Context, reasoning string) {
+	if c.keepReasoning && strings.TrimSpace(reasoning) != "" {
+		consultation.RecordReasoning(ctx, strings.TrimSpace(reasoning))
+	}
+}
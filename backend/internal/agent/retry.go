@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig retries a provider call that failed on the network or with
+// 429 or 5xx before the failover moves on. Waits double from Base up to Max
+// with full jitter; a Retry-After header is followed instead. Retries stay
+// within the provider's latency budget and the agent timeout: when the wait
+// would not fit, the provider fails at once. Zero Attempts disables retries.
+type RetryConfig struct {
+	Attempts int // retries after the first call
+	Base     time.Duration
+	Max      time.Duration
+}
+
+var DefaultRetryConfig = RetryConfig{
+	Attempts: 2,
+	Base:     500 * time.Millisecond,
+	Max:      8 * time.Second,
+}
+
+// do sends the request built by newRequest, which is called again for
+// every attempt since a request body can only be read once. Once the
+// retries are spent, the last response or error is returned as is.
+func (c *client) do(ctx context.Context, p Provider, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClients[p.Name].Do(req)
+		if attempt >= c.retry.Attempts || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := c.retry.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait = after
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+		if resp != nil {
+			fmt.Printf("LLM provider %s answered %s, retrying in %s\n", p.Name, resp.Status, wait.Round(time.Millisecond))
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			fmt.Printf("LLM provider %s failed: %v, retrying in %s\n", p.Name, err, wait.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether another attempt may succeed: the network
+// failed, the provider is rate limiting or it had a transient server error
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is the wait before retry attempt+1: a random duration up to
+// Base doubled attempt times, capped at Max
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	limit := r.Base
+	for i := 0; i < attempt && limit < r.Max; i++ {
+		limit *= 2
+	}
+	if r.Max > 0 && limit > r.Max {
+		limit = r.Max
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// retryAfter reads a Retry-After header, given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonSchema constrains a reply to a JSON schema, sent as response_format
+// json_schema (or as Ollama's format) to providers that support it
+type jsonSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+// schemaOf derives a strict schema from a struct type. Every field with a
+// json tag is required, as strict mode wants, `enum:"a|b"` restricts a
+// string and `desc` describes the field to the model. `schema:"nonempty"` is
+// only checked by decodeStrict: strict mode does not take minLength.
+func schemaOf(name string, v any) *jsonSchema {
+	return &jsonSchema{Name: name, Strict: true, Schema: typeSchema(reflect.TypeOf(v))}
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		props := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			s := typeSchema(f.Type)
+			if enum := f.Tag.Get("enum"); enum != "" {
+				s["enum"] = strings.Split(enum, "|")
+			}
+			if desc := f.Tag.Get("desc"); desc != "" {
+				s["description"] = desc
+			}
+			props[name] = s
+			required = append(required, name)
+		}
+		return map[string]any{"type": "object", "properties": props, "required": required, "additionalProperties": false}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// decodeStrict decodes a reply into v and checks it against the schema tags.
+// Models that ignore the schema wrap the JSON in a Markdown fence, which is
+// cut off first.
+func decodeStrict(reply string, v any) error {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+	reply = strings.TrimSpace(reply)
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(reply)))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("not valid JSON: %w", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("text after the JSON object")
+	}
+	return validate(reflect.ValueOf(v), "")
+}
+
+func validate(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return validate(v.Elem(), path)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			field := path + name
+			fv := v.Field(i)
+			if fv.Kind() == reflect.String {
+				s := strings.TrimSpace(fv.String())
+				if f.Tag.Get("schema") == "nonempty" && s == "" {
+					return fmt.Errorf("%s is empty", field)
+				}
+				if enum := f.Tag.Get("enum"); enum != "" && !oneOf(s, strings.Split(enum, "|")) {
+					return fmt.Errorf("%s is %q, want one of %s", field, s, strings.ReplaceAll(enum, "|", ", "))
+				}
+				continue
+			}
+			if err := validate(fv, field+"."); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		prefix := strings.TrimSuffix(path, ".")
+		for i := 0; i < v.Len(); i++ {
+			if err := validate(v.Index(i), fmt.Sprintf("%s[%d].", prefix, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,11 @@
 package agent
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"medical-ai-agent/internal/consultation"
 	"mime/multipart"
 	"net/http"
 	"time"
@@ -15,66 +15,84 @@ import (
 const sttServiceURL = "http://tts:8000/transcribe"
 
 type STTClient interface {
-	Transcribe(ctx context.Context, audioData []byte) (string, error)
+	Transcribe(ctx context.Context, audio io.Reader, hints consultation.STTHints) (consultation.Transcript, error)
 }
 
 type whisperClient struct {
 	httpClient *http.Client
+	url        string
 }
 
-func NewWhisperClient() STTClient {
+// transport may be nil for the default one, url empty for the sidecar's
+func NewWhisperClient(timeout time.Duration, transport http.RoundTripper, url string) STTClient {
+	if url == "" {
+		url = sttServiceURL
+	}
 	return &whisperClient{
+		url: url,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   timeout,
+			Transport: transport,
 		},
 	}
 }
 
 type sttResponse struct {
-	Text     string `json:"text"`
-	Language string `json:"language"`
+	Text     string                        `json:"text"`
+	Language string                        `json:"language"`
+	Words    []consultation.TranscriptWord `json:"words"` // with Whisper's probability as the confidence
 }
 
-func (c *whisperClient) Transcribe(ctx context.Context, audioData []byte) (string, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	
-	// Create form file
-	part, err := writer.CreateFormFile("file", "audio.wav")
-	if err != nil {
-		return "", err
-	}
-	
-	if _, err := part.Write(audioData); err != nil {
-		return "", err
-	}
-	
-	if err := writer.Close(); err != nil {
-		return "", err
-	}
+// Transcribe sends the hints as "language" and "prompt" form fields ahead of the audio
+func (c *whisperClient) Transcribe(ctx context.Context, audio io.Reader, hints consultation.STTHints) (consultation.Transcript, error) {
+	// Stream the upload through a pipe so the audio is never fully buffered
+	body, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		if err := writer.WriteField("language", hints.Language); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if err := writer.WriteField("prompt", hints.Prompt); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		part, err := writer.CreateFormFile("file", "audio.wav")
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, audio); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", sttServiceURL, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, body)
 	if err != nil {
-		return "", err
+		body.Close()
+		return consultation.Transcript{}, err
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return consultation.Transcript{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("STT API error: %s - %s", resp.Status, string(respBody))
+		return consultation.Transcript{}, fmt.Errorf("STT API error: %s - %s", resp.Status, string(respBody))
 	}
 
 	var result sttResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+		return consultation.Transcript{}, err
 	}
 
-	return result.Text, nil
+	return consultation.Transcript{Text: result.Text, Words: result.Words}, nil
 }
@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// withTimeout applies timeout to ctx; zero means no limit beyond the caller's deadline
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError makes deadline errors say which limit was hit, keeping
+// context.DeadlineExceeded in the chain for callers
+func timeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("request timed out (limit %s): %w", timeout, err)
+	}
+	return err
+}
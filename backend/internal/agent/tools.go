@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// tool is a function offered to the model in the OpenAI tools format, which
+// DeepSeek and Ollama take as well
+type tool struct {
+	Type     string       `json:"type"` // "function"
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// toolCall is a call in a reply, or a piece of one in a streamed reply
+type toolCall struct {
+	Index    int    `json:"index,omitempty"` // the call a streamed piece belongs to
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON object
+	} `json:"function"`
+}
+
+func newTool(name, description string, args any) tool {
+	return tool{Type: "function", Function: toolFunction{Name: name, Description: description, Parameters: schemaOf(name, args).Schema}}
+}
+
+// Tool arguments; the tags make their schema and checks, see schemaOf
+
+type moodArgs struct {
+	Mood string `json:"mood" enum:"Спокойное|Тревожное|Критическое"`
+}
+
+type vitalArgs struct {
+	Kind      string  `json:"kind" enum:"blood_pressure|pulse|temperature|spo2|resp_rate|height|weight"`
+	Value     float64 `json:"value" desc:"значение; для давления — систолическое"`
+	Diastolic float64 `json:"diastolic" desc:"диастолическое давление, для остальных показателей 0"`
+}
+
+type emergencyArgs struct {
+	Reason string `json:"reason" schema:"nonempty" desc:"что угрожает жизни, словами пациента"`
+}
+
+type endArgs struct{}
+
+// communicatorTools are the backend actions of consultation.ToolCall
+var communicatorTools = []tool{
+	newTool(consultation.ToolSetMood, "Настроение пациента по твоей оценке. Вызывай с каждым ответом.", moodArgs{}),
+	newTool(consultation.ToolRecordVital, "Записать показатель, который пациент измерил и назвал сам: давление, пульс, температуру, сатурацию, частоту дыхания, рост или вес.", vitalArgs{}),
+	newTool(consultation.ToolFlagEmergency, "Срочно позвать персонал: состояние пациента угрожает жизни (боль в груди, удушье, потеря сознания, сильное кровотечение, признаки инсульта).", emergencyArgs{}),
+	newTool(consultation.ToolEndConsultation, "Завершить опрос и отправить отчёт врачу. Вызывай вместе с прощанием.", endArgs{}),
+}
+
+// decodeToolCall checks a call's arguments against its tool
+func decodeToolCall(tc toolCall) (consultation.ToolCall, error) {
+	name, args := tc.Function.Name, tc.Function.Arguments
+	if strings.TrimSpace(args) == "" {
+		args = "{}"
+	}
+	call := consultation.ToolCall{Tool: name}
+	switch name {
+	case consultation.ToolSetMood:
+		var a moodArgs
+		if err := decodeStrict(args, &a); err != nil {
+			return call, err
+		}
+		call.Mood = moodFromText(a.Mood)
+	case consultation.ToolRecordVital:
+		var a vitalArgs
+		if err := decodeStrict(args, &a); err != nil {
+			return call, err
+		}
+		m := consultation.Measurement{Kind: consultation.VitalKind(a.Kind), Value: a.Value}
+		if m.Kind == consultation.VitalBloodPressure {
+			m.Diastolic = a.Diastolic
+		}
+		if err := m.Validate(); err != nil {
+			return call, err
+		}
+		call.Vital = &m
+	case consultation.ToolFlagEmergency:
+		var a emergencyArgs
+		if err := decodeStrict(args, &a); err != nil {
+			return call, err
+		}
+		call.Reason = strings.TrimSpace(a.Reason)
+	case consultation.ToolEndConsultation:
+	default:
+		return call, fmt.Errorf("unknown tool %q", name)
+	}
+	return call, nil
+}
+
+// recordToolCalls hands the valid calls to the consultation service
+func (c *client) recordToolCalls(ctx context.Context, calls []toolCall) {
+	var decoded []consultation.ToolCall
+	for _, tc := range calls {
+		call, err := decodeToolCall(tc)
+		if err != nil {
+			fmt.Printf("Communicator tool call %s rejected: %v\n", tc.Function.Name, err)
+			continue
+		}
+		decoded = append(decoded, call)
+	}
+	consultation.RecordToolCalls(ctx, decoded)
+}
+
+// withToolResults continues call after a reply that only called tools: the
+// calls and their results are added and text is asked for. The service
+// carries the calls out after the turn, so a valid call is reported done.
+func withToolResults(call chatRequest, calls []toolCall) chatRequest {
+	messages := append([]chatMessage(nil), call.Messages...)
+	sent := make([]toolCall, len(calls))
+	for i, tc := range calls {
+		tc.Index = 0
+		if tc.ID == "" {
+			tc.ID = fmt.Sprintf("call_%d", i)
+		}
+		if strings.TrimSpace(tc.Function.Arguments) == "" {
+			tc.Function.Arguments = "{}"
+		}
+		tc.Type = "function"
+		sent[i] = tc
+	}
+	messages = append(messages, chatMessage{Role: "assistant", ToolCalls: sent})
+	for _, tc := range sent {
+		result := "ok"
+		if _, err := decodeToolCall(tc); err != nil {
+			result = "error: " + err.Error()
+		}
+		messages = append(messages, chatMessage{Role: "tool", Content: result, ToolCallID: tc.ID})
+	}
+	call.Messages, call.ToolChoice = messages, "none"
+	return call
+}
+
+// mergeToolCalls adds the pieces of a streamed reply: a piece with an id
+// starts a call, one without continues the call at its index
+func mergeToolCalls(calls, pieces []toolCall) []toolCall {
+	for _, p := range pieces {
+		if p.ID != "" || p.Index >= len(calls) {
+			calls = append(calls, p)
+			continue
+		}
+		calls[p.Index].Function.Name += p.Function.Name
+		calls[p.Index].Function.Arguments += p.Function.Arguments
+	}
+	return calls
+}
+
+// offerTools drops the tools for a provider without function calling; the
+// Communicator's text markers then apply
+func (p Provider) offerTools(call chatRequest) chatRequest {
+	if !p.tools() {
+		call.Tools, call.ToolChoice = nil, ""
+	}
+	return call
+}
@@ -8,46 +8,69 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"medical-ai-agent/internal/consultation"
 )
 
 // Local Silero TTS Service URL (from docker-compose)
 const ttsServiceURL = "http://tts:8000/generate"
 
 type TTSClient interface {
-	Synthesize(ctx context.Context, text string, voiceID string) ([]byte, error)
+	Synthesize(ctx context.Context, text string, opts consultation.SpeechOptions) ([]byte, error)
+	Ping(ctx context.Context) error
 }
 
 type sileroClient struct {
 	httpClient *http.Client
+	url        string
 }
 
-func NewSileroClient() TTSClient {
+// transport may be nil for the default one, url empty for the sidecar's
+func NewSileroClient(timeout time.Duration, transport http.RoundTripper, url string) TTSClient {
+	if url == "" {
+		url = ttsServiceURL
+	}
 	return &sileroClient{
+		url: url,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   timeout,
+			Transport: transport,
 		},
 	}
 }
 
 type ttsRequest struct {
-	Text    string `json:"text"`
-	Speaker string `json:"speaker"` // xenia, kseniya, aidar, baya, eugene
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker"` // xenia, kseniya, aidar, baya, eugene
+	Rate    float64 `json:"rate"`    // tempo, 1.0 = normal
+	PauseMs int     `json:"pause_ms"`
+	Volume  float64 `json:"volume"` // gain, 1.0 = normal
 }
 
-func (c *sileroClient) Synthesize(ctx context.Context, text string, voiceID string) ([]byte, error) {
-	// Map "voiceID" to Silero speakers if needed, or use default
+func (c *sileroClient) Synthesize(ctx context.Context, text string, opts consultation.SpeechOptions) ([]byte, error) {
+	// Map the voice to Silero speakers if needed, or use default
 	speaker := "kseniya" // Default female voice
-	if voiceID != "" {
-		speaker = voiceID
+	if opts.Voice != "" {
+		speaker = opts.Voice
+	}
+	rate, volume := opts.Rate, opts.Volume
+	if rate == 0 {
+		rate = 1.0
+	}
+	if volume == 0 {
+		volume = 1.0
 	}
 
 	reqBody := ttsRequest{
 		Text:    text,
 		Speaker: speaker,
+		Rate:    rate,
+		PauseMs: opts.PauseMs,
+		Volume:  volume,
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", ttsServiceURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +90,19 @@ func (c *sileroClient) Synthesize(ctx context.Context, text string, voiceID stri
 
 	return io.ReadAll(resp.Body)
 }
+
+// Ping checks that the speech service is reachable. Any HTTP response counts,
+// the service has no dedicated health endpoint.
+func (c *sileroClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
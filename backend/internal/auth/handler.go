@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/openapi"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type CreateUserRequest struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+}
+
+type CreateUserResponse struct {
+	User  *User  `json:"user"`
+	Token string `json:"token"` // shown only once
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.svc.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list users: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(users)
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if !ValidRole(req.Role) {
+		http.Error(w, "Unknown role", http.StatusBadRequest)
+		return
+	}
+
+	u, token, err := h.svc.CreateUser(r.Context(), req.Name, req.Role)
+	if err != nil {
+		http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateUserResponse{User: u, Token: token})
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.DeleteUser(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete user: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me returns the authenticated user, handy for checking a token
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	json.NewEncoder(w).Encode(u)
+}
+
+// RegisterRoutes mounts user management. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/me", h.Me)
+	r.Group(func(r chi.Router) {
+		r.Use(Require(PermManageUsers))
+		r.Get("/users", h.ListUsers)
+		r.Post("/users", h.CreateUser)
+		r.Delete("/users/{id}", h.DeleteUser)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/me", Summary: "The user of the bearer token", Tags: tags,
+			Response: User{}},
+		{Method: http.MethodGet, Path: "/admin/users", Summary: "Staff accounts (manage_users)", Tags: tags,
+			Response: []User{}},
+		{Method: http.MethodPost, Path: "/admin/users", Summary: "Create a staff account; its token is shown only once (manage_users)", Tags: tags,
+			Request: CreateUserRequest{}, Response: CreateUserResponse{}},
+		{Method: http.MethodDelete, Path: "/admin/users/{id}", Summary: "Delete a staff account and revoke its token (manage_users)", Tags: tags},
+	}
+}
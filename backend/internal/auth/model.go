@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleDoctor Role = "doctor"
+	RoleNurse  Role = "nurse"
+	RoleKiosk  Role = "kiosk"
+)
+
+type Permission string
+
+const (
+	PermConsult        Permission = "consult"         // run patient consultations
+	PermViewStats      Permission = "view_stats"      // clinic statistics
+	PermViewConfig     Permission = "view_config"     // runtime config and flags
+	PermAnnotateFacts  Permission = "annotate_facts"  // edit or annotate extracted facts
+	PermReanalyze      Permission = "reanalyze"       // re-run agents on a consultation
+	PermReview         Permission = "review"          // approve reports held for nurse review
+	PermManageQueue    Permission = "manage_queue"    // call patients from the waiting room
+	PermManageDelivery Permission = "manage_delivery" // inspect and retry failed reports
+	PermPurge          Permission = "purge"           // delete consultation data
+	PermExportResearch Permission = "export_research" // anonymized dataset export
+	PermManageProfiles Permission = "manage_profiles" // department required-information profiles
+	PermManageDevices  Permission = "manage_devices"  // register and configure kiosks
+	PermInjectTurns    Permission = "inject_turns"    // send synthetic patient turns from the support console
+	PermAskPatient     Permission = "ask_patient"     // put a question to the patient during the interview
+	PermAcknowledge    Permission = "acknowledge"     // confirm having read a report
+	PermRelay          Permission = "relay"           // answer patients relayed to staff during an LLM outage
+	PermManageConfig   Permission = "manage_config"   // publish and roll back rule and checklist versions, edit the branding
+	PermManageUsers    Permission = "manage_users"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermViewStats, PermViewConfig, PermReanalyze, PermManageDelivery, PermPurge, PermExportResearch, PermManageUsers,
+		PermManageProfiles, PermManageDevices, PermInjectTurns, PermManageConfig,
+	},
+	RoleDoctor: {PermViewStats, PermAnnotateFacts, PermReanalyze, PermReview, PermManageQueue, PermManageProfiles, PermAskPatient, PermAcknowledge, PermRelay},
+	RoleNurse:  {PermViewStats, PermManageDelivery, PermReview, PermAnnotateFacts, PermManageQueue, PermRelay},
+	RoleKiosk:  {PermConsult},
+}
+
+// ValidRole reports whether r is one of the known roles
+func ValidRole(r Role) bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Can reports whether the user's role grants perm
+func (u *User) Can(perm Permission) bool {
+	for _, p := range rolePermissions[u.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
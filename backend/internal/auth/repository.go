@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, u *User, tokenHash string) error
+	List(ctx context.Context) ([]User, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*User, error)
+}
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+func (r *postgresRepo) Create(ctx context.Context, u *User, tokenHash string) error {
+	query := `INSERT INTO users (id, name, role, token_hash, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, u.ID, u.Name, u.Role, tokenHash, u.CreatedAt)
+	return err
+}
+
+func (r *postgresRepo) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, role, created_at FROM users ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Role, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *postgresRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (r *postgresRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*User, error) {
+	query := `SELECT id, name, role, created_at FROM users WHERE token_hash = $1`
+
+	var u User
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&u.ID, &u.Name, &u.Role, &u.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo           Repository
+	bootstrapToken string
+}
+
+// NewService creates the auth service. bootstrapToken (ADMIN_TOKEN) always
+// authenticates as an admin so the first users can be created.
+func NewService(repo Repository, bootstrapToken string) *Service {
+	return &Service{repo: repo, bootstrapToken: bootstrapToken}
+}
+
+// CreateUser stores a new user and returns its API token. Only a hash of the
+// token is persisted, so it cannot be shown again.
+func (s *Service) CreateUser(ctx context.Context, name string, role Role) (*User, string, error) {
+	if !ValidRole(role) {
+		return nil, "", fmt.Errorf("unknown role %q", role)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	u := &User{
+		ID:        uuid.New(),
+		Name:      name,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, u, hashToken(token)); err != nil {
+		return nil, "", err
+	}
+	return u, token, nil
+}
+
+func (s *Service) ListUsers(ctx context.Context) ([]User, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Authenticate resolves a bearer token to a user
+func (s *Service) Authenticate(ctx context.Context, token string) (*User, error) {
+	if token == "" {
+		return nil, fmt.Errorf("missing token")
+	}
+	if s.bootstrapToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.bootstrapToken)) == 1 {
+		return &User{Name: "bootstrap-admin", Role: RoleAdmin}, nil
+	}
+	return s.repo.GetByTokenHash(ctx, hashToken(token))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package branding
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/openapi"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetBranding returns the texts of the branding and whether it has a logo
+func (h *Handler) GetBranding(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.svc.Current())
+}
+
+// UpdateBranding replaces the display name, header and footer
+func (h *Handler) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+	var req Update
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	b, err := h.svc.Update(r.Context(), req, author(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(b)
+}
+
+// GetLogo returns the logo image as uploaded
+func (h *Handler) GetLogo(w http.ResponseWriter, r *http.Request) {
+	b := h.svc.Current()
+	if len(b.Logo) == 0 {
+		http.Error(w, "No logo", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", b.LogoType)
+	w.Write(b.Logo)
+}
+
+// UploadLogo takes the PNG or JPEG image as the request body
+func (h *Handler) UploadLogo(w http.ResponseWriter, r *http.Request) {
+	// One byte over the limit so an oversized logo is rejected, not cut
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxLogoBytes+1))
+	if err != nil {
+		http.Error(w, "Failed to read logo", http.StatusBadRequest)
+		return
+	}
+	b, err := h.svc.SetLogo(r.Context(), data, author(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *Handler) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+	b, err := h.svc.DeleteLogo(r.Context(), author(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(b)
+}
+
+func author(r *http.Request) string {
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		return u.Name
+	}
+	return "unknown"
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNoStore):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Branding update failed: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RegisterRoutes mounts the report branding. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermViewConfig))
+		r.Get("/branding", h.GetBranding)
+		r.Get("/branding/logo", h.GetLogo)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermManageConfig))
+		r.Put("/branding", h.UpdateBranding)
+		r.Put("/branding/logo", h.UploadLogo)
+		r.Delete("/branding/logo", h.DeleteLogo)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/branding", Summary: "Clinic name, greeting and report texts (view_config)", Tags: tags,
+			Response: Branding{}},
+		{Method: http.MethodPut, Path: "/admin/branding", Summary: "Change the branding texts (manage_config)", Tags: tags,
+			Request: Update{}, Response: Branding{}},
+		{Method: http.MethodGet, Path: "/admin/branding/logo", Summary: "The logo image as uploaded (view_config)", Tags: tags,
+			ResponseType: "image/*"},
+		{Method: http.MethodPut, Path: "/admin/branding/logo", Summary: "Upload a PNG or JPEG logo as the request body (manage_config)", Tags: tags,
+			RequestType: "application/octet-stream", Response: Branding{}},
+		{Method: http.MethodDelete, Path: "/admin/branding/logo", Summary: "Remove the logo (manage_config)", Tags: tags,
+			Response: Branding{}},
+	}
+}
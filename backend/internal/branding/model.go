@@ -0,0 +1,42 @@
+package branding
+
+import (
+	"errors"
+	"time"
+)
+
+// Branding is how a tenant's reports and Telegram messages present the
+// clinic. The zero value is the unbranded default.
+type Branding struct {
+	Tenant      string `json:"tenant"`
+	DisplayName string `json:"display_name"` // heads Telegram messages and names report files
+	Header      string `json:"header"`       // above the report title, e.g. the clinic's address
+	Footer      string `json:"footer"`       // at the bottom of every report page
+	Logo        []byte `json:"-"`
+	LogoType    string `json:"logo_type,omitempty"` // image/png or image/jpeg, "" without a logo
+	UpdatedBy   string `json:"updated_by,omitempty"`
+	// Zero until the tenant's branding was first set
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Update replaces the texts of the branding; the logo is uploaded on its own
+type Update struct {
+	DisplayName string `json:"display_name"`
+	Header      string `json:"header"`
+	Footer      string `json:"footer"`
+}
+
+// Limits keep the texts on one report line and the logo small enough to
+// embed in every report
+const (
+	MaxDisplayName = 80
+	MaxHeader      = 300
+	MaxFooter      = 150
+	MaxLogoBytes   = 512 << 10
+)
+
+var (
+	ErrInvalid = errors.New("invalid branding")
+	// ErrNoStore is returned for changes when the database is not connected
+	ErrNoStore = errors.New("branding is kept in the database, which is not connected")
+)
@@ -0,0 +1,48 @@
+package branding
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type Store interface {
+	// Get returns the tenant's branding, nil if it was never set
+	Get(ctx context.Context, tenant string) (*Branding, error)
+	Save(ctx context.Context, b Branding) error
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) Get(ctx context.Context, tenant string) (*Branding, error) {
+	var b Branding
+	err := s.db.QueryRowContext(ctx, `
+		SELECT tenant, display_name, header, footer, logo, logo_type, updated_by, updated_at
+		FROM tenant_branding WHERE tenant = $1`, tenant).
+		Scan(&b.Tenant, &b.DisplayName, &b.Header, &b.Footer, &b.Logo, &b.LogoType, &b.UpdatedBy, &b.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *postgresStore) Save(ctx context.Context, b Branding) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO tenant_branding (tenant, display_name, header, footer, logo, logo_type, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (tenant) DO UPDATE SET
+			display_name = EXCLUDED.display_name, header = EXCLUDED.header, footer = EXCLUDED.footer,
+			logo = EXCLUDED.logo, logo_type = EXCLUDED.logo_type,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		b.Tenant, b.DisplayName, b.Header, b.Footer, b.Logo, b.LogoType, b.UpdatedBy, b.UpdatedAt)
+	return err
+}
@@ -0,0 +1,153 @@
+package branding
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // decoders for the logo check
+	_ "image/png"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Service keeps this deployment's tenant's branding in memory, since every
+// report and Telegram message reads it
+type Service struct {
+	tenant string
+	store  Store
+
+	mu      sync.RWMutex
+	current Branding
+}
+
+// NewService serves the branding of tenant; a nil store leaves it unbranded
+func NewService(tenant string, store Store) *Service {
+	return &Service{tenant: tenant, store: store, current: Branding{Tenant: tenant}}
+}
+
+// Current returns the branding in effect
+func (s *Service) Current() Branding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Refresh reloads the branding from the store
+func (s *Service) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	b, err := s.store.Get(ctx, s.tenant)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		b = &Branding{Tenant: s.tenant}
+	}
+	s.set(*b)
+	return nil
+}
+
+// StartRefresh picks up changes made through other instances until ctx is cancelled
+func (s *Service) StartRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					log.Printf("Branding refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Update replaces the display name, header and footer, keeping the logo
+func (s *Service) Update(ctx context.Context, u Update, author string) (Branding, error) {
+	u.DisplayName = strings.TrimSpace(u.DisplayName)
+	u.Header = strings.TrimSpace(u.Header)
+	u.Footer = strings.TrimSpace(u.Footer)
+	if err := u.validate(); err != nil {
+		return Branding{}, err
+	}
+	b := s.Current()
+	b.DisplayName, b.Header, b.Footer = u.DisplayName, u.Header, u.Footer
+	return s.save(ctx, b, author)
+}
+
+// SetLogo replaces the logo with a PNG or JPEG image
+func (s *Service) SetLogo(ctx context.Context, data []byte, author string) (Branding, error) {
+	logoType, err := checkLogo(data)
+	if err != nil {
+		return Branding{}, err
+	}
+	b := s.Current()
+	b.Logo, b.LogoType = data, logoType
+	return s.save(ctx, b, author)
+}
+
+// DeleteLogo removes the logo; reports go out without one
+func (s *Service) DeleteLogo(ctx context.Context, author string) (Branding, error) {
+	b := s.Current()
+	b.Logo, b.LogoType = nil, ""
+	return s.save(ctx, b, author)
+}
+
+func (s *Service) save(ctx context.Context, b Branding, author string) (Branding, error) {
+	if s.store == nil {
+		return Branding{}, ErrNoStore
+	}
+	b.Tenant, b.UpdatedBy, b.UpdatedAt = s.tenant, author, time.Now()
+	if err := s.store.Save(ctx, b); err != nil {
+		return Branding{}, err
+	}
+	s.set(b)
+	return b, nil
+}
+
+func (s *Service) set(b Branding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = b
+}
+
+func (u Update) validate() error {
+	switch {
+	case utf8.RuneCountInString(u.DisplayName) > MaxDisplayName:
+		return fmt.Errorf("%w: display_name is longer than %d characters", ErrInvalid, MaxDisplayName)
+	case strings.ContainsAny(u.DisplayName, "\r\n"):
+		return fmt.Errorf("%w: display_name must be one line", ErrInvalid)
+	case utf8.RuneCountInString(u.Header) > MaxHeader:
+		return fmt.Errorf("%w: header is longer than %d characters", ErrInvalid, MaxHeader)
+	case utf8.RuneCountInString(u.Footer) > MaxFooter:
+		return fmt.Errorf("%w: footer is longer than %d characters", ErrInvalid, MaxFooter)
+	}
+	return nil
+}
+
+// checkLogo returns the image type of a logo that can go into the PDF report
+func checkLogo(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("%w: empty logo", ErrInvalid)
+	}
+	if len(data) > MaxLogoBytes {
+		return "", fmt.Errorf("%w: logo is larger than %d KB", ErrInvalid, MaxLogoBytes>>10)
+	}
+	logoType := http.DetectContentType(data)
+	if logoType != "image/png" && logoType != "image/jpeg" {
+		return "", fmt.Errorf("%w: logo must be PNG or JPEG, got %s", ErrInvalid, logoType)
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("%w: unreadable logo: %v", ErrInvalid, err)
+	}
+	return logoType, nil
+}
@@ -0,0 +1,121 @@
+// Package chaos injects latency and errors into external calls for resilience
+// testing. Faults are requested per HTTP request via the X-Chaos header and are
+// only honoured when the middleware is mounted, which main does outside production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Header = "X-Chaos"
+
+// Target is an external dependency faults can be injected into
+type Target string
+
+const (
+	LLM Target = "llm"
+	TTS Target = "tts"
+	STT Target = "stt"
+	DB  Target = "db"
+)
+
+// ErrInjected is returned by injected error faults
+var ErrInjected = errors.New("chaos: injected fault")
+
+type fault struct {
+	latency     time.Duration
+	probability float64 // of an injected error
+}
+
+// Faults maps targets to the faults requested for them
+type Faults map[Target]fault
+
+type ctxKey struct{}
+
+// Parse reads a header value like "llm=latency:2s,llm=error:0.5,db=error".
+// "error" without a probability always fails.
+func Parse(value string) (Faults, error) {
+	faults := Faults{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		target, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid fault %q: expected target=kind[:param]", item)
+		}
+		kind, param, _ := strings.Cut(spec, ":")
+
+		f := faults[Target(target)]
+		switch kind {
+		case "latency":
+			d, err := time.ParseDuration(param)
+			if err != nil {
+				return nil, fmt.Errorf("invalid latency in %q: %w", item, err)
+			}
+			f.latency = d
+		case "error":
+			f.probability = 1
+			if param != "" {
+				p, err := strconv.ParseFloat(param, 64)
+				if err != nil || p < 0 || p > 1 {
+					return nil, fmt.Errorf("invalid error probability in %q", item)
+				}
+				f.probability = p
+			}
+		default:
+			return nil, fmt.Errorf("unknown fault kind %q", kind)
+		}
+		faults[Target(target)] = f
+	}
+	return faults, nil
+}
+
+// Middleware stores the faults requested in the X-Chaos header in the request context
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(Header)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		faults, err := Parse(value)
+		if err != nil {
+			http.Error(w, "Invalid "+Header+" header: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, faults)))
+	})
+}
+
+// Inject applies the faults requested for target, if any: it sleeps for the
+// configured latency (respecting ctx) and then possibly returns ErrInjected.
+func Inject(ctx context.Context, target Target) error {
+	faults, _ := ctx.Value(ctxKey{}).(Faults)
+	f, ok := faults[target]
+	if !ok {
+		return nil
+	}
+
+	if f.latency > 0 {
+		select {
+		case <-time.After(f.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.probability > 0 && rand.Float64() < f.probability {
+		return fmt.Errorf("%s: %w", target, ErrInjected)
+	}
+	return nil
+}
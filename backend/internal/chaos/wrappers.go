@@ -0,0 +1,156 @@
+package chaos
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// WrapAgent injects LLM faults before every agent call
+func WrapAgent(next consultation.AgentClient) consultation.AgentClient {
+	return &agentClient{next}
+}
+
+type agentClient struct {
+	consultation.AgentClient
+}
+
+func (c *agentClient) RunCommunicator(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (string, consultation.EmotionalState, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return "", mood, err
+	}
+	return c.AgentClient.RunCommunicator(ctx, history, mood, interview)
+}
+
+func (c *agentClient) RunCommunicatorStream(ctx context.Context, history []consultation.Message, mood consultation.EmotionalState, interview consultation.Interview) (<-chan string, <-chan error) {
+	if err := Inject(ctx, LLM); err != nil {
+		tokenChan := make(chan string)
+		errChan := make(chan error, 1)
+		errChan <- err
+		close(tokenChan)
+		close(errChan)
+		return tokenChan, errChan
+	}
+	return c.AgentClient.RunCommunicatorStream(ctx, history, mood, interview)
+}
+
+func (c *agentClient) RunAnalyst(ctx context.Context, history []consultation.Message, required []consultation.RequiredField) (*consultation.AnalysisResult, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.RunAnalyst(ctx, history, required)
+}
+
+func (c *agentClient) RunScreener(ctx context.Context, question string, answer string) (bool, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return false, err
+	}
+	return c.AgentClient.RunScreener(ctx, question, answer)
+}
+
+func (c *agentClient) RunQualityReview(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact) (*consultation.QualityReview, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.RunQualityReview(ctx, history, facts)
+}
+
+func (c *agentClient) DetectChiefComplaint(ctx context.Context, message string) (*consultation.ChiefComplaint, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.DetectChiefComplaint(ctx, message)
+}
+
+func (c *agentClient) RunRedFlagScreen(ctx context.Context, question, message string) ([]consultation.RedFlag, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.RunRedFlagScreen(ctx, question, message)
+}
+
+func (c *agentClient) SummarizeWearables(ctx context.Context, signals []string, facts []consultation.MedicalFact) ([]consultation.MedicalFact, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.SummarizeWearables(ctx, signals, facts)
+}
+
+func (c *agentClient) SummarizeFacts(ctx context.Context, facts []consultation.MedicalFact, limit int) ([]consultation.MedicalFact, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.SummarizeFacts(ctx, facts, limit)
+}
+
+func (c *agentClient) RunSupervisor(ctx context.Context, history []consultation.Message, facts []consultation.MedicalFact, negatives []consultation.PertinentNegative, pending []consultation.RequiredField) (bool, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return false, err
+	}
+	return c.AgentClient.RunSupervisor(ctx, history, facts, negatives, pending)
+}
+
+func (c *agentClient) GenerateRecommendations(ctx context.Context, facts []consultation.MedicalFact) (*consultation.RecommendationResult, error) {
+	if err := Inject(ctx, LLM); err != nil {
+		return nil, err
+	}
+	return c.AgentClient.GenerateRecommendations(ctx, facts)
+}
+
+// WrapTTS injects TTS faults before synthesis
+func WrapTTS(next consultation.TTSClient) consultation.TTSClient {
+	return &ttsClient{next}
+}
+
+type ttsClient struct {
+	consultation.TTSClient
+}
+
+func (c *ttsClient) Synthesize(ctx context.Context, text string, opts consultation.SpeechOptions) ([]byte, error) {
+	if err := Inject(ctx, TTS); err != nil {
+		return nil, err
+	}
+	return c.TTSClient.Synthesize(ctx, text, opts)
+}
+
+// WrapSTT injects STT faults before transcription
+func WrapSTT(next consultation.STTClient) consultation.STTClient {
+	return &sttClient{next}
+}
+
+type sttClient struct {
+	consultation.STTClient
+}
+
+func (c *sttClient) Transcribe(ctx context.Context, audio io.Reader, hints consultation.STTHints) (consultation.Transcript, error) {
+	if err := Inject(ctx, STT); err != nil {
+		return consultation.Transcript{}, err
+	}
+	return c.STTClient.Transcribe(ctx, audio, hints)
+}
+
+// WrapRepository injects DB faults into consultation reads and writes
+func WrapRepository(next consultation.Repository) consultation.Repository {
+	return &repository{next}
+}
+
+type repository struct {
+	consultation.Repository
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*consultation.Consultation, error) {
+	if err := Inject(ctx, DB); err != nil {
+		return nil, err
+	}
+	return r.Repository.GetByID(ctx, id)
+}
+
+func (r *repository) Save(ctx context.Context, c *consultation.Consultation) error {
+	if err := Inject(ctx, DB); err != nil {
+		return err
+	}
+	return r.Repository.Save(ctx, c)
+}
@@ -0,0 +1,131 @@
+package configversions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/openapi"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ListVersions returns the versions of every kind with their effective ranges
+func (h *Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.svc.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list config versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(versions)
+}
+
+// GetVersion returns one version with its content
+func (h *Handler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+	v, err := h.svc.Get(r.Context(), Kind(chi.URLParam(r, "kind")), version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// PublishVersion adds a version, effective now or at effective_from
+func (h *Handler) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	var req PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Content) == 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	v, err := h.svc.Publish(r.Context(), Kind(chi.URLParam(r, "kind")), req, author(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+// RollbackVersion brings back an earlier version as a new one
+func (h *Handler) RollbackVersion(w http.ResponseWriter, r *http.Request) {
+	var req RollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version <= 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	v, err := h.svc.Rollback(r.Context(), Kind(chi.URLParam(r, "kind")), req, author(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+func author(r *http.Request) string {
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		return u.Name
+	}
+	return "unknown"
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var invalid *InvalidError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrReadOnly):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &invalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Config version failed: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RegisterRoutes mounts config versioning. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermViewConfig))
+		r.Get("/config/versions", h.ListVersions)
+		r.Get("/config/versions/{kind}/{version}", h.GetVersion)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermManageConfig))
+		r.Post("/config/versions/{kind}", h.PublishVersion)
+		r.Post("/config/versions/{kind}/rollback", h.RollbackVersion)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/config/versions", Summary: "Published versions of prompts, rules and checklists (view_config)", Tags: tags,
+			Response: []Version{}},
+		{Method: http.MethodGet, Path: "/admin/config/versions/{kind}/{version}", Summary: "One version with its content; kind is prompts, rules or checklists (view_config)", Tags: tags,
+			Response: Version{}},
+		{Method: http.MethodPost, Path: "/admin/config/versions/{kind}", Summary: "Publish a new version and apply it (manage_config)", Tags: tags,
+			Request: PublishRequest{}, Response: Version{}},
+		{Method: http.MethodPost, Path: "/admin/config/versions/{kind}/rollback", Summary: "Publish an earlier version again (manage_config)", Tags: tags,
+			Request: RollbackRequest{}, Response: Version{}},
+	}
+}
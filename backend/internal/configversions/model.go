@@ -0,0 +1,83 @@
+package configversions
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Kind is a part of the configuration that is versioned as a whole
+type Kind string
+
+const (
+	KindPrompts    Kind = "prompts"    // prompt versions and override templates of the agents
+	KindRules      Kind = "rules"      // decision support rules, in the RULES_FILE format
+	KindChecklists Kind = "checklists" // department required-information profiles
+)
+
+var (
+	ErrNotFound = errors.New("config version not found")
+	// ErrReadOnly is returned for publishing or rolling back a kind that has
+	// no Applier and is only recorded
+	ErrReadOnly = errors.New("config kind cannot be changed through the API")
+)
+
+// Version is one configuration of a kind for a tenant. A version is in
+// effect from EffectiveFrom until the next one starts; versions are never
+// edited, a rollback adds a new version with the old content.
+type Version struct {
+	Tenant        string          `json:"tenant"`
+	Kind          Kind            `json:"kind"`
+	Version       int             `json:"version"`
+	Content       json.RawMessage `json:"content,omitempty"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+	// Start of the next version, nil while this one is the last
+	EffectiveTo *time.Time `json:"effective_to,omitempty"`
+	Active      bool       `json:"active"`
+	RollbackOf  int        `json:"rollback_of,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PublishRequest adds a version; without effective_from it takes effect at once
+type PublishRequest struct {
+	Content       json.RawMessage `json:"content"`
+	EffectiveFrom *time.Time      `json:"effective_from,omitempty"`
+	Note          string          `json:"note,omitempty"`
+}
+
+// RollbackRequest brings back the content of an earlier version
+type RollbackRequest struct {
+	Version       int        `json:"version"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	Note          string     `json:"note,omitempty"`
+}
+
+// timeline orders the versions of one kind by start and fills in the ranges.
+// It returns the version in effect at now, nil before the first one starts.
+func timeline(versions []Version, now time.Time) *Version {
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].EffectiveFrom.Equal(versions[j].EffectiveFrom) {
+			return versions[i].EffectiveFrom.Before(versions[j].EffectiveFrom)
+		}
+		return versions[i].Version < versions[j].Version
+	})
+
+	var active *Version
+	for i := range versions {
+		versions[i].EffectiveTo, versions[i].Active = nil, false
+		if i+1 < len(versions) {
+			to := versions[i+1].EffectiveFrom
+			versions[i].EffectiveTo = &to
+		}
+		if !versions[i].EffectiveFrom.After(now) {
+			active = &versions[i]
+		}
+	}
+	if active != nil {
+		active.Active = true
+	}
+	return active
+}
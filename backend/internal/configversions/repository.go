@@ -0,0 +1,79 @@
+package configversions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type Store interface {
+	// List returns every version of the tenant
+	List(ctx context.Context, tenant string) ([]Version, error)
+	Get(ctx context.Context, tenant string, kind Kind, version int) (*Version, error)
+	// Add stores v as the next version of its kind and sets v.Version
+	Add(ctx context.Context, v *Version) error
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+const versionColumns = `tenant, kind, version, content, effective_from, COALESCE(rollback_of, 0), note, created_by, created_at`
+
+func scanVersion(row interface{ Scan(...any) error }) (Version, error) {
+	var v Version
+	var content []byte
+	err := row.Scan(&v.Tenant, &v.Kind, &v.Version, &content, &v.EffectiveFrom, &v.RollbackOf, &v.Note, &v.CreatedBy, &v.CreatedAt)
+	v.Content = content
+	return v, err
+}
+
+func (s *postgresStore) List(ctx context.Context, tenant string) ([]Version, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+versionColumns+` FROM config_versions WHERE tenant = $1 ORDER BY kind, version`, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []Version
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (s *postgresStore) Get(ctx context.Context, tenant string, kind Kind, version int) (*Version, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+versionColumns+` FROM config_versions WHERE tenant = $1 AND kind = $2 AND version = $3`, tenant, kind, version)
+	v, err := scanVersion(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Add numbers the version in the insert itself; two writers racing for the
+// same number fail on the primary key instead of overwriting each other
+func (s *postgresStore) Add(ctx context.Context, v *Version) error {
+	var rollbackOf any
+	if v.RollbackOf > 0 {
+		rollbackOf = v.RollbackOf
+	}
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO config_versions (tenant, kind, version, content, effective_from, rollback_of, note, created_by, created_at)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, $6, $7, $8
+		FROM config_versions WHERE tenant = $1 AND kind = $2
+		RETURNING version`,
+		v.Tenant, v.Kind, []byte(v.Content), v.EffectiveFrom, rollbackOf, v.Note, v.CreatedBy, v.CreatedAt,
+	).Scan(&v.Version)
+}
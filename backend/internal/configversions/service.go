@@ -0,0 +1,290 @@
+package configversions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Applier puts the content of a version into effect. Kinds without one are
+// only recorded.
+type Applier interface {
+	// Validate checks content before it is stored
+	Validate(content json.RawMessage) error
+	Apply(ctx context.Context, content json.RawMessage) error
+}
+
+// Service tracks the configuration versions of this deployment's tenant and
+// applies the one in effect for each kind, including versions scheduled for
+// later, once their time comes.
+type Service struct {
+	tenant   string
+	store    Store
+	appliers map[Kind]Applier
+
+	mu     sync.Mutex
+	active map[Kind]int // versions in effect on this instance
+}
+
+// NewService works without a store too, then nothing is versioned
+func NewService(tenant string, store Store, appliers map[Kind]Applier) *Service {
+	return &Service{tenant: tenant, store: store, appliers: appliers, active: make(map[Kind]int)}
+}
+
+// List returns every version of the tenant with their ranges, without content
+func (s *Service) List(ctx context.Context) ([]Version, error) {
+	if s.store == nil {
+		return []Version{}, nil
+	}
+	versions, err := s.store.List(ctx, s.tenant)
+	if err != nil {
+		return nil, err
+	}
+	list := []Version{}
+	for _, vs := range byKind(versions) {
+		timeline(vs, time.Now())
+		for _, v := range vs {
+			v.Content = nil
+			list = append(list, v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// Get returns one version with its content
+func (s *Service) Get(ctx context.Context, kind Kind, version int) (*Version, error) {
+	if s.store == nil {
+		return nil, ErrNotFound
+	}
+	return s.store.Get(ctx, s.tenant, kind, version)
+}
+
+// Record stores content that is already in effect, e.g. the prompts of the
+// running code or profiles just edited, unless the current version has it.
+func (s *Service) Record(ctx context.Context, kind Kind, content any, author, note string) (*Version, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.current(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && jsonEqual(current.Content, data) {
+		return current, nil
+	}
+	v, err := s.add(ctx, kind, data, time.Now(), 0, author, note)
+	if err != nil {
+		return nil, err
+	}
+	s.setActive(kind, v.Version)
+	return v, nil
+}
+
+// Seed records content as the first version of a kind that has none yet.
+// It returns the version in effect, which may hold other content.
+func (s *Service) Seed(ctx context.Context, kind Kind, content any, author string) (*Version, error) {
+	current, err := s.current(ctx, kind)
+	if err != nil || current != nil {
+		return current, err
+	}
+	return s.Record(ctx, kind, content, author, "initial version")
+}
+
+// Publish validates and stores a new version; one effective now is applied
+// at once, a later one by the refresh once its time comes
+func (s *Service) Publish(ctx context.Context, kind Kind, req PublishRequest, author string) (*Version, error) {
+	return s.publish(ctx, kind, req.Content, req.EffectiveFrom, 0, author, req.Note)
+}
+
+// Rollback publishes the content of an earlier version as a new version, so
+// the history stays complete
+func (s *Service) Rollback(ctx context.Context, kind Kind, req RollbackRequest, author string) (*Version, error) {
+	if s.appliers[kind] == nil {
+		return nil, ErrReadOnly
+	}
+	if s.store == nil {
+		return nil, ErrNotFound
+	}
+	old, err := s.store.Get(ctx, s.tenant, kind, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	note := req.Note
+	if note == "" {
+		note = fmt.Sprintf("rollback to version %d", old.Version)
+	}
+	return s.publish(ctx, kind, old.Content, req.EffectiveFrom, old.Version, author, note)
+}
+
+func (s *Service) publish(ctx context.Context, kind Kind, content json.RawMessage, from *time.Time, rollbackOf int, author, note string) (*Version, error) {
+	applier := s.appliers[kind]
+	if applier == nil {
+		return nil, ErrReadOnly
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("config versions need the database")
+	}
+	if err := applier.Validate(content); err != nil {
+		return nil, &InvalidError{Err: err}
+	}
+
+	now := time.Now()
+	effective := now
+	if from != nil && from.After(now) {
+		effective = *from
+	}
+	v, err := s.add(ctx, kind, content, effective, rollbackOf, author, note)
+	if err != nil {
+		return nil, err
+	}
+	if !effective.After(now) {
+		if err := applier.Apply(ctx, content); err != nil {
+			return nil, fmt.Errorf("version %d stored but not applied: %w", v.Version, err)
+		}
+		s.setActive(kind, v.Version)
+		log.Printf("Config %s version %d in effect (by %s)", kind, v.Version, author)
+	}
+	return v, nil
+}
+
+func (s *Service) add(ctx context.Context, kind Kind, content json.RawMessage, from time.Time, rollbackOf int, author, note string) (*Version, error) {
+	v := &Version{
+		Tenant:        s.tenant,
+		Kind:          kind,
+		Content:       content,
+		EffectiveFrom: from,
+		RollbackOf:    rollbackOf,
+		Note:          note,
+		CreatedBy:     author,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.store.Add(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// current is the version of kind in effect now, nil when there is none
+func (s *Service) current(ctx context.Context, kind Kind) (*Version, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	versions, err := s.store.List(ctx, s.tenant)
+	if err != nil {
+		return nil, err
+	}
+	return timeline(byKind(versions)[kind], time.Now()), nil
+}
+
+// Refresh applies the versions that came into effect since the last call,
+// whether scheduled or published by another instance
+func (s *Service) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	versions, err := s.store.List(ctx, s.tenant)
+	if err != nil {
+		return err
+	}
+	for kind, vs := range byKind(versions) {
+		v := timeline(vs, time.Now())
+		if v == nil || s.activeVersion(kind) == v.Version {
+			continue
+		}
+		if applier := s.appliers[kind]; applier != nil {
+			if err := applier.Apply(ctx, v.Content); err != nil {
+				log.Printf("Failed to apply config %s version %d: %v", kind, v.Version, err)
+				continue
+			}
+			log.Printf("Config %s version %d in effect", kind, v.Version)
+		}
+		s.setActive(kind, v.Version)
+	}
+	return nil
+}
+
+// StartRefresh keeps the versions in effect until ctx is cancelled
+func (s *Service) StartRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					log.Printf("Failed to refresh config versions: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stamp implements consultation.ConfigVersions
+func (s *Service) Stamp() consultation.ConfigStamp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := make(map[string]int, len(s.active))
+	for kind, v := range s.active {
+		versions[string(kind)] = v
+	}
+	return consultation.ConfigStamp{Tenant: s.tenant, Versions: versions, StampedAt: time.Now()}
+}
+
+func (s *Service) activeVersion(kind Kind) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active[kind]
+}
+
+func (s *Service) setActive(kind Kind, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[kind] = version
+}
+
+// InvalidError wraps content an Applier rejected
+type InvalidError struct {
+	Err error
+}
+
+func (e *InvalidError) Error() string { return "invalid content: " + e.Err.Error() }
+func (e *InvalidError) Unwrap() error { return e.Err }
+
+func byKind(versions []Version) map[Kind][]Version {
+	kinds := make(map[Kind][]Version)
+	for _, v := range versions {
+		kinds[v.Kind] = append(kinds[v.Kind], v)
+	}
+	return kinds
+}
+
+// jsonEqual compares two JSON documents regardless of formatting; JSONB
+// does not keep the key order
+func jsonEqual(a, b []byte) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return bytes.Equal(xs, ys)
+}
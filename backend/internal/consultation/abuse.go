@@ -0,0 +1,90 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AbuseResponse is how the assistant answered an incident
+type AbuseResponse string
+
+const (
+	AbuseDeescalation AbuseResponse = "deescalation"
+	AbuseWarning      AbuseResponse = "warning"
+	AbuseStaffCalled  AbuseResponse = "staff_called"
+)
+
+// AbuseAction is the policy's decision for one incident
+type AbuseAction struct {
+	Kind     AbuseResponse
+	Response string // said instead of the Communicator's reply
+	Notify   bool   // notify staff now
+}
+
+// AbusePolicy recognizes abuse aimed at the assistant and decides the response
+type AbusePolicy interface {
+	Detect(text string) (trigger string, ok bool)
+	Respond(incident int) AbuseAction // incident counts from 1
+}
+
+// AbuseNotifier alerts staff about a patient who keeps abusing the assistant
+type AbuseNotifier interface {
+	NotifyAbuse(ctx context.Context, c Consultation) error
+}
+
+// AbuseIncident records abusive language aimed at the assistant. The words
+// themselves stay in the History at MessageIndex.
+type AbuseIncident struct {
+	MessageIndex int           `json:"message_index"`
+	Trigger      string        `json:"trigger"`
+	Response     AbuseResponse `json:"response"`
+	Notified     bool          `json:"notified"`
+	At           time.Time     `json:"at"`
+}
+
+// localTurn answers the turn without the Communicator when risk screening,
+// the recap of the facts or the abuse policy takes over. Screening comes
+// first: distress often comes with swearing.
+func (s *service) localTurn(ctx context.Context, c *Consultation, text string) (string, bool) {
+	if response, ok := s.screeningTurn(ctx, c, text); ok {
+		return c.withKeypadPrompt(response), true
+	}
+	if response, ok := s.recapTurn(ctx, c, text); ok {
+		return response, true
+	}
+	return s.abuseTurn(ctx, c, text)
+}
+
+// abuseTurn answers abuse aimed at the assistant per the policy instead of
+// the Communicator. The patient's message must already be in the History.
+// It returns false when text is not abusive.
+func (s *service) abuseTurn(ctx context.Context, c *Consultation, text string) (string, bool) {
+	trigger, ok := s.abuse.Detect(text)
+	if !ok {
+		return "", false
+	}
+
+	action := s.abuse.Respond(len(c.AbuseIncidents) + 1)
+	c.AbuseIncidents = append(c.AbuseIncidents, AbuseIncident{
+		MessageIndex: len(c.History) - 1,
+		Trigger:      trigger,
+		Response:     action.Kind,
+		At:           time.Now(),
+	})
+	incident := &c.AbuseIncidents[len(c.AbuseIncidents)-1]
+	fmt.Printf("Abusive language in consultation %s (incident %d, %s)\n", c.ID, len(c.AbuseIncidents), action.Kind)
+
+	if action.Notify && !alertSuppressed(ctx, c, "abuse alert") {
+		if err := s.abuseAlerts.NotifyAbuse(ctx, *c); err != nil {
+			fmt.Printf("Failed to notify staff about abuse: %v\n", err)
+		} else {
+			incident.Notified = true
+		}
+	}
+	// Kept in their own table so a stale background save cannot drop one
+	if err := s.repo.AddAbuseIncident(ctx, c.ID, *incident); err != nil {
+		fmt.Printf("Failed to record abuse incident: %v\n", err)
+	}
+	return action.Response, true
+}
@@ -0,0 +1,65 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrReportNotDispatched is returned for acknowledging a report that has not gone out yet
+	ErrReportNotDispatched = errors.New("report has not been dispatched")
+	// ErrAlreadyAcknowledged is returned when another doctor acknowledged the report first
+	ErrAlreadyAcknowledged = errors.New("report is already acknowledged")
+)
+
+// Acknowledgment is a doctor confirming they have read the report. Only the
+// first one counts; it ends the report's wait in the response-time analytics.
+type Acknowledgment struct {
+	DoctorID uuid.UUID `json:"doctor_id"`
+	Doctor   string    `json:"doctor"`
+	Via      string    `json:"via"` // QuestionViaAPI or QuestionViaTelegram
+	At       time.Time `json:"at"`
+}
+
+// dispatchReport sends the first revision of the report and records when it
+// went out. The time of the first attempt is kept, so a report stuck in the
+// failed deliveries shows up as a slow acknowledgment.
+func (s *service) dispatchReport(ctx context.Context, c *Consultation) error {
+	now := time.Now()
+	if err := s.repo.MarkReportDispatched(ctx, c.ID, now); err != nil {
+		fmt.Printf("Failed to record report dispatch for consultation %s: %v\n", c.ID, err)
+	} else if c.ReportDispatchedAt == nil {
+		c.ReportDispatchedAt = &now
+	}
+	return s.reportSvc.SendDoctorReport(ctx, *c)
+}
+
+// AcknowledgeReport records that a doctor has read the consultation's report
+func (s *service) AcknowledgeReport(ctx context.Context, consultationID uuid.UUID, doctorID uuid.UUID, doctor, via string) (*Consultation, error) {
+	if doctor == "" {
+		doctor = "unknown"
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.ReportDispatchedAt == nil {
+		return nil, ErrReportNotDispatched
+	}
+	if c.Acknowledgment != nil {
+		return nil, ErrAlreadyAcknowledged
+	}
+
+	a := Acknowledgment{DoctorID: doctorID, Doctor: doctor, Via: via, At: time.Now()}
+	if err := s.repo.SetAcknowledgment(ctx, c.ID, a); err != nil {
+		return nil, err
+	}
+	c.Acknowledgment = &a
+	fmt.Printf("Doctor %s acknowledged the report of consultation %s via %s\n", doctor, c.ID, via)
+	return c, nil
+}
@@ -0,0 +1,89 @@
+package consultation
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAssignment is returned for an assignment without a room or with overlong fields
+	ErrInvalidAssignment = errors.New("invalid assignment")
+	// ErrNotWaiting is returned while the interview is running or once the visit is over
+	ErrNotWaiting = errors.New("consultation is not waiting")
+)
+
+const maxAssignmentLength = 50
+
+// Assignment is the room, and in the emergency department the bed, staff
+// gave a waiting patient. It is kept apart from the Visit: a patient can be
+// put in a room long before the doctor calls them.
+type Assignment struct {
+	Room       string    `json:"room"`
+	Bed        string    `json:"bed,omitempty"`
+	AssignedBy string    `json:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// Announcement is what the kiosk tells the patient
+func (a Assignment) Announcement() string {
+	if a.Bed != "" {
+		return fmt.Sprintf("Пройдите, пожалуйста, в кабинет %s, место %s.", a.Room, a.Bed)
+	}
+	return fmt.Sprintf("Пройдите, пожалуйста, в кабинет %s.", a.Room)
+}
+
+// AssignRoom gives a waiting patient a room and an optional bed, replacing
+// an earlier assignment. The doctor chat is told; a failure there does not
+// undo the assignment.
+func (s *service) AssignRoom(ctx context.Context, consultationID uuid.UUID, room, bed, author string) (*Consultation, error) {
+	room, bed = strings.TrimSpace(room), strings.TrimSpace(bed)
+	if room == "" || len([]rune(room)) > maxAssignmentLength || len([]rune(bed)) > maxAssignmentLength {
+		return nil, ErrInvalidAssignment
+	}
+	if author == "" {
+		author = "unknown"
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsComplete || (c.Visit != nil && c.Visit.State == VisitDone) {
+		return nil, ErrNotWaiting
+	}
+
+	a := Assignment{Room: room, Bed: bed, AssignedBy: author, AssignedAt: time.Now()}
+	if err := s.repo.SetAssignment(ctx, c.ID, a); err != nil {
+		return nil, err
+	}
+	c.Assignment = &a
+	fmt.Printf("%s assigned consultation %s to room %s\n", author, c.ID, room)
+
+	if err := s.reportSvc.SendAssignment(ctx, *c); err != nil {
+		fmt.Printf("Failed to notify the doctor of the assignment in consultation %s: %v\n", c.ID, err)
+	}
+	return c, nil
+}
+
+// announceAssignment tells a kiosk watcher where to go, in text and voice
+func (h *Handler) announceAssignment(ctx context.Context, sse *sseWriter, c *Consultation) error {
+	text := c.Assignment.Announcement()
+	if err := sse.Send(StreamEvent{Type: "assignment", Data: text}); err != nil {
+		return err
+	}
+	if c.Pacing.textOnly() {
+		return nil
+	}
+	audio, err := h.svc.Speak(ctx, c.ID, text, c.Pacing)
+	if err != nil {
+		fmt.Printf("Failed to voice the assignment in consultation %s: %v\n", c.ID, err)
+		return nil
+	}
+	return sse.Send(StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(audio)})
+}
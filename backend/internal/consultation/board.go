@@ -0,0 +1,114 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidVisitState is returned for unknown states or a call without a room
+var ErrInvalidVisitState = errors.New("invalid visit state")
+
+// VisitState is set by staff once the interview is over. A completed
+// consultation without a Visit is waiting to be called.
+type VisitState string
+
+const (
+	VisitCalled VisitState = "called"  // the patient is invited to a room
+	VisitInRoom VisitState = "in_room" // the doctor is seeing the patient
+	VisitDone   VisitState = "done"    // the visit is over, the ticket leaves the board
+)
+
+func ValidVisitState(s VisitState) bool {
+	switch s {
+	case VisitCalled, VisitInRoom, VisitDone:
+		return true
+	}
+	return false
+}
+
+type Visit struct {
+	State     VisitState `json:"state"`
+	Room      string     `json:"room,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// BoardState is what the waiting-room display shows for a ticket
+type BoardState string
+
+const (
+	BoardInterview BoardState = "interview"  // still talking to the assistant
+	BoardWaiting   BoardState = "waiting"    // interview done, waiting to be called
+	BoardCalled    BoardState = "called"     // please go to Room
+	BoardBeingSeen BoardState = "being_seen" // with the doctor
+)
+
+// BoardEntry is one line of the display. It carries no identifiers, only the
+// ticket number the patient got at the kiosk.
+type BoardEntry struct {
+	Ticket string     `json:"ticket"`
+	State  BoardState `json:"state"`
+	Room   string     `json:"room,omitempty"`
+}
+
+type Board struct {
+	Entries []BoardEntry `json:"entries"`
+	// Outside of working hours the display shows the clinic closed
+	Clinic ClinicStatus `json:"clinic"`
+}
+
+// Tickets on the board are created within this window, so yesterday's
+// forgotten consultations do not stay on screen
+const boardWindow = 12 * time.Hour
+
+// FormatTicket renders the number shown to the patient and on the board
+func FormatTicket(n int) string {
+	return fmt.Sprintf("%03d", n%1000)
+}
+
+func boardEntry(ticket int, isComplete bool, visit *Visit) BoardEntry {
+	e := BoardEntry{Ticket: FormatTicket(ticket), State: BoardInterview}
+	switch {
+	case visit != nil && visit.State == VisitCalled:
+		e.State, e.Room = BoardCalled, visit.Room
+	case visit != nil && visit.State == VisitInRoom:
+		e.State, e.Room = BoardBeingSeen, visit.Room
+	case isComplete:
+		e.State = BoardWaiting
+	}
+	return e
+}
+
+// Board returns the current waiting-room queue at loc, the whole hospital's
+// for a zero Location
+func (s *service) Board(ctx context.Context, loc Location) (*Board, error) {
+	entries, err := s.repo.BoardEntries(ctx, time.Now().Add(-boardWindow), loc)
+	if err != nil {
+		return nil, err
+	}
+	return &Board{Entries: entries, Clinic: s.hours.Status(time.Now())}, nil
+}
+
+// SetVisitState moves a completed consultation through the queue; calling a
+// patient requires a room
+func (s *service) SetVisitState(ctx context.Context, consultationID uuid.UUID, state VisitState, room string) (*Consultation, error) {
+	if !ValidVisitState(state) || (state == VisitCalled && room == "") {
+		return nil, ErrInvalidVisitState
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if room == "" && c.Visit != nil {
+		room = c.Visit.Room
+	}
+	c.Visit = &Visit{State: state, Room: room, UpdatedAt: time.Now()}
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,101 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// FollowUp is a follow-up visit the Recommendations agent suggests
+type FollowUp struct {
+	Specialty  string `json:"specialty"`             // e.g. "кардиолог"
+	WithinDays int    `json:"within_days,omitempty"` // 0 leaves the date to the clinic
+}
+
+type BookingStatus string
+
+const (
+	BookingBooked    BookingStatus = "booked"    // the scheduling API returned a slot
+	BookingRequested BookingStatus = "requested" // the request was accepted without a slot, e.g. by a webhook
+	BookingFailed    BookingStatus = "failed"
+)
+
+// Booking is the follow-up visit requested from the scheduling system once
+// the interview is complete
+type Booking struct {
+	FollowUp
+	Status    BookingStatus `json:"status"`
+	Slot      *time.Time    `json:"slot,omitempty"`
+	Location  string        `json:"location,omitempty"`  // e.g. "каб. 214"
+	Reference string        `json:"reference,omitempty"` // the scheduling system's id for the appointment
+	Error     string        `json:"error,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// Appointment is a slot the scheduling system booked
+type Appointment struct {
+	Slot      time.Time
+	Location  string
+	Reference string
+}
+
+// Scheduler books follow-up visits. A nil Appointment without an error means
+// the request was accepted and the slot is assigned later.
+type Scheduler interface {
+	Book(ctx context.Context, c Consultation, f FollowUp) (*Appointment, error)
+}
+
+// bookFollowUp asks the scheduling system for the suggested follow-up and
+// tells the patient the outcome in the dialogue
+func (s *service) bookFollowUp(ctx context.Context, c *Consultation, f *FollowUp) {
+	if s.scheduler == nil || f == nil || c.Booking != nil {
+		return
+	}
+	b := &Booking{FollowUp: *f, UpdatedAt: time.Now()}
+	appointment, err := s.scheduler.Book(ctx, *c, *f)
+	switch {
+	case err != nil:
+		fmt.Printf("Failed to book follow-up for consultation %s: %v\n", c.ID, err)
+		b.Status, b.Error = BookingFailed, err.Error()
+	case appointment == nil:
+		b.Status = BookingRequested
+	default:
+		slot := appointment.Slot
+		b.Status, b.Slot, b.Location, b.Reference = BookingBooked, &slot, appointment.Location, appointment.Reference
+	}
+	c.Booking = b
+	if notice := b.patientNotice(); notice != "" {
+		c.History = append(c.History, Message{Role: "assistant", Content: notice, Timestamp: time.Now()})
+	}
+}
+
+// patientNotice is what the patient is told about the booking
+func (b *Booking) patientNotice() string {
+	switch b.Status {
+	case BookingBooked:
+		notice := fmt.Sprintf("Вы записаны на повторный приём: %s, %s в %s", b.Specialty, b.Slot.Format("02.01"), b.Slot.Format("15:04"))
+		if b.Location != "" {
+			notice += ", " + b.Location
+		}
+		return notice + "."
+	case BookingRequested:
+		return fmt.Sprintf("Врач рекомендует повторный приём: %s. Регистратура свяжется с вами, чтобы выбрать время.", b.Specialty)
+	}
+	// A failed booking is left to the doctor, who sees it in the report
+	return ""
+}
+
+// ReportLine describes the booking for the doctor's report
+func (b *Booking) ReportLine() string {
+	switch b.Status {
+	case BookingBooked:
+		line := fmt.Sprintf("Повторный приём: %s, записан на %s", b.Specialty, b.Slot.Format("02.01.2006 15:04"))
+		if b.Location != "" {
+			line += ", " + b.Location
+		}
+		return line
+	case BookingRequested:
+		return fmt.Sprintf("Повторный приём: %s, запрос передан в регистратуру", b.Specialty)
+	}
+	return fmt.Sprintf("Повторный приём: %s, записать не удалось (%s)", b.Specialty, b.Error)
+}
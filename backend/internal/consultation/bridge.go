@@ -0,0 +1,207 @@
+package consultation
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNoBridge is returned for relaying a reply to, or ending the bridge of,
+	// a consultation whose messages are not being relayed
+	ErrNoBridge = errors.New("consultation is not relayed to staff")
+	// ErrInvalidRelayReply is returned for an empty or overlong staff reply
+	ErrInvalidRelayReply = errors.New("invalid relay reply")
+)
+
+// bridgeAfter consecutive holding messages give up on the LLM for the
+// consultation and relay the patient's messages to the nurse station
+const bridgeAfter = 2
+
+const maxRelayReplyLength = 500
+
+const (
+	// BridgeNotice follows the holding message that opens the bridge
+	BridgeNotice = "Пока ассистент недоступен, я передаю ваши сообщения медсестре напрямую. Говорите, она ответит здесь же."
+	// bridgeAck answers each relayed message
+	bridgeAck = "Ваше сообщение передано медсестре."
+	// bridgeFailed answers a message that could not be relayed
+	bridgeFailed = "Не получилось передать сообщение. Пожалуйста, обратитесь к медсестре на посту."
+)
+
+// Bridge keeps the channel useful during an LLM outage: once the failover
+// chain is exhausted, the patient's messages skip the Communicator and go to
+// the nurse station chat, and staff replies come back to the kiosk. Background
+// agents do not run on relayed turns. Staff end the bridge to hand the
+// patient back to the assistant.
+type Bridge struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	EndedBy   string     `json:"ended_by,omitempty"`
+}
+
+// Active reports whether patient messages are being relayed
+func (b *Bridge) Active() bool {
+	return b != nil && b.EndedAt == nil
+}
+
+// holdingStreak counts the holding messages answering the patient since the
+// last real reply
+func (c *Consultation) holdingStreak() int {
+	n := 0
+	for i := len(c.History) - 1; i >= 0; i-- {
+		m := c.History[i]
+		if m.Role != "assistant" {
+			continue
+		}
+		if m.Provider != HoldingProvider {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// startBridge opens the bridge when the turn's reply, by provider, is another
+// holding message. The patient's unanswered messages are relayed at once. It
+// returns the notice to add to the reply.
+func (s *service) startBridge(ctx context.Context, c *Consultation, provider string) (string, bool) {
+	if provider != HoldingProvider || c.Bridge.Active() || c.holdingStreak()+1 < bridgeAfter {
+		return "", false
+	}
+
+	var unanswered []string
+	for i := len(c.History) - 1; i >= 0; i-- {
+		m := c.History[i]
+		if m.Role == "assistant" && m.Provider != HoldingProvider {
+			break
+		}
+		if m.Role == "user" {
+			unanswered = append([]string{m.Content}, unanswered...)
+		}
+	}
+	if err := s.reportSvc.RelayToStaff(ctx, *c, unanswered); err != nil {
+		fmt.Printf("Failed to open the staff bridge for consultation %s: %v\n", c.ID, err)
+		return "", false
+	}
+
+	b := Bridge{StartedAt: time.Now()}
+	if err := s.repo.SetBridge(ctx, c.ID, b); err != nil {
+		fmt.Printf("Failed to record the staff bridge for consultation %s: %v\n", c.ID, err)
+		return "", false
+	}
+	c.Bridge = &b
+	fmt.Printf("LLM unavailable for consultation %s, relaying the patient's messages to staff\n", c.ID)
+	return BridgeNotice, true
+}
+
+// bridgeTurn relays the patient's message to staff instead of running the
+// Communicator. Both messages are appended in place, so a staff reply
+// arriving meanwhile is kept. It returns false when there is no bridge.
+func (s *service) bridgeTurn(ctx context.Context, c *Consultation, text string) (string, bool, error) {
+	if !c.Bridge.Active() {
+		return "", false, nil
+	}
+
+	response := bridgeAck
+	if err := s.reportSvc.RelayToStaff(ctx, *c, []string{text}); err != nil {
+		fmt.Printf("Failed to relay a message of consultation %s: %v\n", c.ID, err)
+		response = bridgeFailed
+	}
+	now := time.Now()
+	for _, m := range []Message{
+		{Role: "user", Content: text, Timestamp: now, InjectedBy: injectedBy(ctx), Key: keyPressed(ctx)},
+		{Role: "assistant", Content: response, Timestamp: now},
+	} {
+		if err := s.repo.AppendMessage(ctx, c.ID, m); err != nil {
+			return "", true, err
+		}
+		c.History = append(c.History, m)
+	}
+	return response, true, nil
+}
+
+// RelayReply passes a staff reply to the patient of a bridged consultation.
+// Kiosks watching the consultation voice it.
+func (s *service) RelayReply(ctx context.Context, consultationID uuid.UUID, author, text string) (*Message, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxRelayReplyLength {
+		return nil, ErrInvalidRelayReply
+	}
+	if author == "" {
+		author = "unknown"
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsComplete {
+		return nil, ErrConsultationComplete
+	}
+	if !c.Bridge.Active() {
+		return nil, ErrNoBridge
+	}
+
+	m := Message{Role: "assistant", Content: text, Timestamp: time.Now(), RelayedBy: author}
+	if err := s.repo.AppendMessage(ctx, c.ID, m); err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s replied to the patient of consultation %s over the staff bridge\n", author, c.ID)
+	return &m, nil
+}
+
+// EndBridge hands the patient back to the assistant; the next message goes
+// to the Communicator again
+func (s *service) EndBridge(ctx context.Context, consultationID uuid.UUID, author string) (*Consultation, error) {
+	if author == "" {
+		author = "unknown"
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Bridge.Active() {
+		return nil, ErrNoBridge
+	}
+
+	b := *c.Bridge
+	now := time.Now()
+	b.EndedAt, b.EndedBy = &now, author
+	if err := s.repo.SetBridge(ctx, c.ID, b); err != nil {
+		return nil, err
+	}
+	c.Bridge = &b
+	fmt.Printf("%s ended the staff bridge of consultation %s\n", author, c.ID)
+	return c, nil
+}
+
+// relayReplies sends a watcher the staff replies among messages, in text and voice
+func (h *Handler) relayReplies(ctx context.Context, sse *sseWriter, c *Consultation, messages []Message) error {
+	for _, m := range messages {
+		if m.RelayedBy == "" {
+			continue
+		}
+		if err := sse.Send(StreamEvent{Type: "relay", Data: m.Content}); err != nil {
+			return err
+		}
+		if c.Pacing.textOnly() {
+			continue
+		}
+		audio, err := h.svc.Speak(ctx, c.ID, m.Content, c.Pacing)
+		if err != nil {
+			fmt.Printf("Failed to voice a staff reply in consultation %s: %v\n", c.ID, err)
+			continue
+		}
+		if err := sse.Send(StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(audio)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package consultation
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/flags"
+)
+
+// A certificate is the patient's own summary of a completed interview: what
+// they reported and what happens next, unlike the doctor's report. The kiosk
+// shows its download link as a QR code for the patient's phone, or a link to
+// the clinic bot, which sends the PDF to the patient's Telegram. The PDF
+// carries a code signed with the session secret, so staff can check that a
+// certificate shown to them was issued by the clinic.
+
+// ErrNoCertificate is returned while the interview is running or when the
+// patient_certificate flag is off for the consultation
+var ErrNoCertificate = errors.New("no certificate for this consultation")
+
+var errInvalidCertificate = errors.New("invalid or expired certificate link")
+
+const (
+	// certificateTTL is how long a download link works, long enough to open
+	// it at home
+	certificateTTL = 24 * time.Hour
+	// telegramCodeTTL is how long the bot link works; it is meant to be
+	// tapped right at the kiosk
+	telegramCodeTTL = 15 * time.Minute
+)
+
+type pendingCertificate struct {
+	consultationID uuid.UUID
+	expires        time.Time
+}
+
+type CertificateResponse struct {
+	Path      string    `json:"path"` // download link, encode in a QR for the patient's phone
+	ExpiresAt time.Time `json:"expires_at"`
+	// Opens the clinic bot, which sends the PDF; empty without a patient bot
+	TelegramURL string `json:"telegram_url,omitempty"`
+}
+
+type CertificateVerification struct {
+	Valid  bool   `json:"valid"`
+	Ticket string `json:"ticket,omitempty"`
+}
+
+// Certificate returns the consultation if the patient may get a certificate for it
+func (s *service) Certificate(ctx context.Context, consultationID uuid.UUID) (*Consultation, error) {
+	if !s.flags.Enabled(flags.PatientCertificate, consultationID) {
+		return nil, ErrNoCertificate
+	}
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsComplete {
+		return nil, ErrNoCertificate
+	}
+	return c, nil
+}
+
+// RenderCertificate builds the certificate PDF with the signed code
+func (s *service) RenderCertificate(ctx context.Context, consultationID uuid.UUID, code string) ([]byte, error) {
+	c, err := s.Certificate(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reportSvc.RenderCertificate(*c, code)
+}
+
+// certificateMAC signs certificate links and codes. The "certificate" prefix
+// keeps them apart from session tokens, which start with the expiry.
+func (s *SessionSigner) certificateMAC(consultationID uuid.UUID, data string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(consultationID[:])
+	mac.Write([]byte("certificate:" + data))
+	return mac.Sum(nil)
+}
+
+// CertificateCode is the code printed on the certificate, e.g. "K7QW-2MXA-9PDF"
+func (s *SessionSigner) CertificateCode(consultationID uuid.UUID) string {
+	sum := base32.StdEncoding.EncodeToString(s.certificateMAC(consultationID, "code"))
+	return sum[0:4] + "-" + sum[4:8] + "-" + sum[8:12]
+}
+
+// signCertificate returns a download token of the form "<expiry>.<signature>"
+func (s *SessionSigner) signCertificate(consultationID uuid.UUID) (string, time.Time) {
+	expires := time.Now().Add(certificateTTL).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	sig := base64.RawURLEncoding.EncodeToString(s.certificateMAC(consultationID, exp))
+	return exp + "." + sig, expires
+}
+
+// verifyCertificate checks a download token. Unlike session tokens it
+// survives handoffs: the patient keeps the link after leaving the kiosk.
+func (s *SessionSigner) verifyCertificate(token string, consultationID uuid.UUID) error {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return errInvalidCertificate
+	}
+	want := base64.RawURLEncoding.EncodeToString(s.certificateMAC(consultationID, exp))
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return errInvalidCertificate
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return errInvalidCertificate
+	}
+	return nil
+}
+
+// startTelegramCode issues a code for the bot link, replacing the
+// consultation's earlier one. It can be redeemed until it expires, in case
+// the patient taps the link twice.
+func (s *SessionSigner) startTelegramCode(consultationID uuid.UUID) (string, error) {
+	code, err := newHandoffCode()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, p := range s.certificates {
+		if p.consultationID == consultationID || time.Now().After(p.expires) {
+			delete(s.certificates, k)
+		}
+	}
+	s.certificates[code] = pendingCertificate{consultationID: consultationID, expires: time.Now().Add(telegramCodeTTL)}
+	return code, nil
+}
+
+func (s *SessionSigner) claimTelegramCode(code string) (uuid.UUID, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.certificates[code]
+	if !ok || time.Now().After(p.expires) {
+		return uuid.Nil, errInvalidCertificate
+	}
+	return p.consultationID, nil
+}
+
+func certificateFileName(consultationID uuid.UUID) string {
+	return fmt.Sprintf("certificate_%s.pdf", consultationID)
+}
+
+// IssueCertificate gives the kiosk the links to the patient's certificate
+func (h *Handler) IssueCertificate(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	if _, err := h.svc.Certificate(r.Context(), id); err != nil {
+		if errors.Is(err, ErrNoCertificate) {
+			http.Error(w, "No certificate for this consultation", http.StatusNotFound)
+			return
+		}
+		writeServiceError(w, "Consultation not found", err)
+		return
+	}
+
+	token, expires := h.sessions.signCertificate(id)
+	resp := CertificateResponse{
+		Path:      fmt.Sprintf("/api/certificate/%s?token=%s", id, token),
+		ExpiresAt: expires,
+	}
+	if h.patientBot != "" {
+		code, err := h.sessions.startTelegramCode(id)
+		if err != nil {
+			http.Error(w, "Failed to create certificate code", http.StatusInternalServerError)
+			return
+		}
+		resp.TelegramURL = fmt.Sprintf("https://t.me/%s?start=%s", h.patientBot, code)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetCertificate serves the certificate PDF for a signed download link. No
+// session is needed, the link is opened on the patient's phone.
+func (h *Handler) GetCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.sessions.verifyCertificate(r.URL.Query().Get("token"), id); err != nil {
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	data, err := h.svc.RenderCertificate(r.Context(), id, h.sessions.CertificateCode(id))
+	if err != nil {
+		if errors.Is(err, ErrNoCertificate) {
+			http.Error(w, "No certificate for this consultation", http.StatusNotFound)
+			return
+		}
+		writeServiceError(w, "Failed to build certificate", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", certificateFileName(id)))
+	w.Write(data)
+}
+
+// VerifyCertificate checks the code printed on a certificate
+func (h *Handler) VerifyCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	resp := CertificateVerification{Valid: hmac.Equal([]byte(code), []byte(h.sessions.CertificateCode(id)))}
+	if resp.Valid {
+		if c, err := h.svc.GetConsultation(r.Context(), id); err == nil {
+			resp.Ticket = FormatTicket(c.Ticket)
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CertificateByCode renders the certificate for a code from the bot link; the
+// Telegram webhook sends it to the chat that opened the link
+func (h *Handler) CertificateByCode(ctx context.Context, code string) ([]byte, string, error) {
+	id, err := h.sessions.claimTelegramCode(code)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := h.svc.RenderCertificate(ctx, id, h.sessions.CertificateCode(id))
+	if err != nil {
+		return nil, "", err
+	}
+	return data, certificateFileName(id), nil
+}
+
+// RegisterCertificateRoutes adds the endpoints opened on the patient's phone.
+// They are mounted apart from the kiosk routes, which may require a kiosk token.
+func RegisterCertificateRoutes(r chi.Router, h *Handler) {
+	r.With(withDeadline(h.timeouts.Request)).Get("/certificate/{id}", h.GetCertificate)
+	r.With(withDeadline(h.timeouts.Request)).Get("/certificate/{id}/verify", h.VerifyCertificate)
+}
@@ -0,0 +1,129 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TurnStage is how far a patient turn got. The user message and the reply
+// are saved together, and the background agents run after the response, so a
+// crash in between leaves the turn half done; the checkpoint tells a restart
+// which half.
+type TurnStage string
+
+const (
+	TurnStarted   TurnStage = "started"   // the patient's text was accepted, no reply saved yet
+	TurnAnswered  TurnStage = "answered"  // the reply is saved, background agents are running
+	TurnDone      TurnStage = "done"      // background agents saved their results
+	TurnAbandoned TurnStage = "abandoned" // the turn ended early while the server was up, e.g. the client left
+	TurnFailed    TurnStage = "failed"    // the server died before the reply was saved
+)
+
+// TurnCheckpoint is one patient turn. Text is the patient's message, kept
+// until the turn is answered so a lost turn can be put back into the History.
+type TurnCheckpoint struct {
+	ID             int64     `json:"id"`
+	ConsultationID uuid.UUID `json:"consultation_id"`
+	MessageIndex   int       `json:"message_index"` // index of the user message in the History
+	Text           string    `json:"-"`
+	Stage          TurnStage `json:"stage"`
+	ForceComplete  bool      `json:"force_complete"` // the reply ended the interview
+	StartedAt      time.Time `json:"started_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// turnRecoveryMessage tells the patient their last answer was lost
+const turnRecoveryMessage = "Извините, произошёл технический сбой, и я не успел ответить. Повторите, пожалуйста, ваш последний ответ."
+
+type turnCheckpoint struct {
+	s        *service
+	t        TurnCheckpoint
+	answered bool
+}
+
+// beginTurn records that a turn started. The turn goes on even when the
+// checkpoint can't be written, it just can't be recovered.
+func (s *service) beginTurn(ctx context.Context, c *Consultation, text string) *turnCheckpoint {
+	cp := &turnCheckpoint{s: s, t: TurnCheckpoint{
+		ConsultationID: c.ID, MessageIndex: len(c.History), Text: text, Stage: TurnStarted, StartedAt: time.Now(),
+	}}
+	if err := s.repo.BeginTurn(ctx, &cp.t); err != nil {
+		fmt.Printf("Failed to checkpoint turn of consultation %s: %v\n", c.ID, err)
+	}
+	return cp
+}
+
+func (cp *turnCheckpoint) set(stage TurnStage, forceComplete bool) {
+	if cp.t.ID == 0 {
+		return
+	}
+	// The request context may be gone by now
+	if err := cp.s.repo.SetTurnStage(context.Background(), cp.t.ConsultationID, cp.t.ID, stage, forceComplete); err != nil {
+		fmt.Printf("Failed to checkpoint turn of consultation %s: %v\n", cp.t.ConsultationID, err)
+	}
+}
+
+// answer is called once the reply is saved
+func (cp *turnCheckpoint) answer(forceComplete bool) {
+	cp.answered = true
+	cp.set(TurnAnswered, forceComplete)
+}
+
+// abandon closes a turn that returned before its reply was saved; deferred
+// by the turn handlers
+func (cp *turnCheckpoint) abandon() {
+	if !cp.answered {
+		cp.set(TurnAbandoned, false)
+	}
+}
+
+// runTurnAgents runs the background agents of an answered turn and closes it
+func (s *service) runTurnAgents(cp *turnCheckpoint, c Consultation, forceComplete bool) {
+	s.runBackgroundAgents(c, forceComplete)
+	cp.set(TurnDone, forceComplete)
+}
+
+// RecoverTurns settles the turns a previous run left unfinished. Answered
+// turns get their background agents run again on the saved consultation;
+// turns without a saved reply get the patient's message back and an apology
+// asking them to repeat it. Meant to run once at startup, before any turn of
+// this process could be in flight.
+func (s *service) RecoverTurns(ctx context.Context) error {
+	turns, err := s.repo.UnfinishedTurns(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range turns {
+		cp := &turnCheckpoint{s: s, t: t}
+		c, err := s.repo.GetByID(ctx, t.ConsultationID)
+		if err != nil {
+			fmt.Printf("Failed to recover turn %d of consultation %s: %v\n", t.ID, t.ConsultationID, err)
+			cp.set(TurnFailed, false)
+			continue
+		}
+
+		if t.Stage == TurnAnswered {
+			fmt.Printf("Replaying background agents for consultation %s\n", c.ID)
+			s.runTurnAgents(cp, *c, t.ForceComplete)
+			continue
+		}
+
+		// The reply may have been saved just before the checkpoint would have been updated
+		if len(c.History) == t.MessageIndex && !c.IsComplete {
+			if t.Text != "" {
+				c.History = append(c.History, Message{Role: "user", Content: t.Text, Timestamp: t.StartedAt})
+			}
+			c.History = append(c.History, Message{Role: "assistant", Content: turnRecoveryMessage, Timestamp: time.Now()})
+			if err := s.repo.Save(ctx, c); err != nil {
+				fmt.Printf("Failed to recover turn %d of consultation %s: %v\n", t.ID, c.ID, err)
+				continue
+			}
+			fmt.Printf("Marked the interrupted turn of consultation %s as failed\n", c.ID)
+		}
+		cp.set(TurnFailed, false)
+	}
+	return nil
+}
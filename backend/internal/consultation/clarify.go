@@ -0,0 +1,189 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Transcript is what STT heard. Words carry the recognizer's confidence and
+// are empty when it gives none, as for long audio transcribed in chunks.
+type Transcript struct {
+	Text  string
+	Words []TranscriptWord
+}
+
+// TranscriptWord is a recognized word with its confidence, 0 to 1
+type TranscriptWord struct {
+	Word       string  `json:"word"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Clarification is an utterance held out of the History until the patient
+// confirms it was heard right. Text is empty once the patient said it was
+// not; the next utterance is then taken as heard.
+type Clarification struct {
+	Text    string    `json:"text"`
+	Words   []string  `json:"words,omitempty"` // content words heard with low confidence
+	AskedAt time.Time `json:"asked_at"`
+}
+
+type transcriptKey struct{}
+
+// withTranscript passes the word confidences of a spoken turn to the service
+func withTranscript(ctx context.Context, t Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+func transcriptFrom(ctx context.Context) Transcript {
+	t, _ := ctx.Value(transcriptKey{}).(Transcript)
+	return t
+}
+
+// Short and filler words are not worth a question even when misheard
+const minContentWordLength = 4
+
+var fillerWords = map[string]bool{
+	"когда": true, "очень": true, "тоже": true, "только": true, "сейчас": true, "потом": true,
+	"этот": true, "этого": true, "такой": true, "такая": true, "может": true, "будто": true,
+	"вроде": true, "просто": true, "ещё": true, "еще": true, "меня": true, "была": true, "было": true,
+}
+
+// uncertainWords returns the content words heard below the confidence threshold
+func (t Transcript) uncertainWords(threshold float64) []string {
+	if threshold <= 0 {
+		return nil
+	}
+	var words []string
+	for _, w := range t.Words {
+		word := normalizeWord(w.Word)
+		if w.Confidence >= threshold || len([]rune(word)) < minContentWordLength || fillerWords[word] {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+var clauseRe = regexp.MustCompile(`[.!?;,]+`)
+
+// clarifyQuestion reads back the parts of text with the uncertain words
+func clarifyQuestion(text string, words []string) string {
+	var parts []string
+	for _, clause := range clauseRe.Split(text, -1) {
+		clause = strings.TrimSpace(clause)
+		for _, field := range strings.Fields(clause) {
+			if containsWord(words, normalizeWord(field)) {
+				parts = append(parts, clause)
+				break
+			}
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{strings.TrimSpace(clauseRe.ReplaceAllString(text, " "))}
+	}
+	readBack := []rune(strings.Join(parts, ", "))
+	if len(readBack) > 0 {
+		readBack[0] = unicode.ToLower(readBack[0])
+	}
+	// No gendered verb: the reply is spoken by either voice
+	return "Уточню: " + string(readBack) + ", верно?"
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+const clarifyRepeat = "Простите, не удалось разобрать. Повторите, пожалуйста."
+
+var (
+	confirmWords = []string{"да", "верно", "правильно", "точно", "именно", "ага", "угу", "конечно"}
+	// "нет, не так" only says the read-back was wrong
+	denyWords = []string{"нет", "неверно", "неправильно", "не", "так", "совсем", "это"}
+)
+
+// clarify decides what of a spoken turn enters the History. A turn with
+// misheard content words is held and read back; the patient's answer
+// releases it, replaces it or asks for it to be said again. question is
+// set when the turn ends with it; otherwise turn is the text to run.
+func (s *service) clarify(ctx context.Context, c *Consultation, text string) (turn, question string, err error) {
+	if pending := c.Clarification; pending != nil {
+		c.Clarification = nil
+		turn, question = pending.resolve(text)
+		if question != "" {
+			c.Clarification = &Clarification{AskedAt: time.Now()}
+		}
+	} else if words := transcriptFrom(ctx).uncertainWords(s.inputLimits.ClarifyConfidence); len(words) > 0 {
+		fmt.Printf("Clarifying a turn of consultation %s, uncertain: %s\n", c.ID, strings.Join(words, ", "))
+		question = clarifyQuestion(text, words)
+		c.Clarification = &Clarification{Text: text, Words: words, AskedAt: time.Now()}
+	} else {
+		return text, "", nil
+	}
+	// Only the clarification changes; History is saved as it was loaded
+	if err := s.repo.Save(ctx, c); err != nil {
+		return "", "", err
+	}
+	return turn, question, nil
+}
+
+// resolve reads the patient's answer to the read-back. A confirmation runs
+// the held turn, with anything said after it; a bare denial asks to repeat;
+// any other answer is run as the corrected turn.
+func (p *Clarification) resolve(answer string) (turn, question string) {
+	if p.Text == "" {
+		// Said again after a denial, taken as heard
+		return answer, ""
+	}
+	fields := strings.Fields(answer)
+	if len(fields) == 0 {
+		return p.Text, ""
+	}
+	if n := agreement(fields); n > 0 {
+		return strings.TrimSpace(p.Text + " " + strings.Join(fields[n:], " ")), ""
+	}
+	denial := true
+	for _, f := range fields {
+		if !containsWord(denyWords, normalizeWord(f)) {
+			denial = false
+			break
+		}
+	}
+	switch {
+	case denial:
+		return "", clarifyRepeat
+	case normalizeWord(fields[0]) == "нет":
+		// "нет, в колене" is the correction itself
+		return strings.Join(fields[1:], " "), ""
+	}
+	return answer, ""
+}
+
+// agreement counts the leading words of an answer that confirm the
+// read-back, as in "да" or "всё верно"; 0 if it does not start with one
+func agreement(fields []string) int {
+	n, agreed := 0, false
+	for _, f := range fields {
+		w := normalizeWord(f)
+		if w == "всё" || w == "все" {
+			n++
+			continue
+		}
+		if !containsWord(confirmWords, w) {
+			break
+		}
+		n, agreed = n+1, true
+	}
+	if !agreed {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,262 @@
+package consultation
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VoiceCommand is a meta-request about the conversation itself ("повторите",
+// "говорите медленнее"). Commands are handled without the Communicator and
+// never enter the History.
+type VoiceCommand string
+
+const (
+	CommandRepeat   VoiceCommand = "repeat"
+	CommandSlower   VoiceCommand = "slower"
+	CommandLouder   VoiceCommand = "louder"
+	CommandTextOnly VoiceCommand = "text_only"
+	CommandMale     VoiceCommand = "male_voice"
+	CommandFemale   VoiceCommand = "female_voice"
+)
+
+// Phrases per command; earlier commands win, so "повторите помедленнее" slows down
+var commandPhrases = []struct {
+	command VoiceCommand
+	phrases []string
+}{
+	{CommandTextOnly, []string{"текстом", "напишите", "пишите", "на текст", "без звука"}},
+	{CommandMale, []string{"мужской голос", "мужским голосом", "мужчина говорил"}},
+	{CommandFemale, []string{"женский голос", "женским голосом", "женщина говорила"}},
+	{CommandSlower, []string{"медленнее", "не так быстро", "слишком быстро"}},
+	{CommandLouder, []string{"громче", "плохо слышу", "не слышу"}},
+	{CommandRepeat, []string{"повтори", "не расслышал", "еще раз", "ещё раз", "что вы сказали", "не понял"}},
+}
+
+// Utterances longer than this carry content of their own and go to the Communicator
+const maxCommandWords = 6
+
+// detectCommand recognizes short utterances that only control the conversation
+func detectCommand(text string) (VoiceCommand, bool) {
+	lower := strings.ToLower(text)
+	if len(strings.Fields(lower)) > maxCommandWords {
+		return "", false
+	}
+	for _, c := range commandPhrases {
+		for _, phrase := range c.phrases {
+			if strings.Contains(lower, phrase) {
+				return c.command, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Speech adjustments per command, bounded by what Pacing.Validate accepts
+const (
+	slowerStep = 0.15
+	louderStep = 0.5
+	maxVolume  = 2.0
+)
+
+// Reply is the assistant's answer to one patient utterance
+type Reply struct {
+	Text    string
+	Command VoiceCommand   // set when the utterance was a voice command handled locally
+	Audio   [][]byte       // speech rendered in advance, e.g. a cached replay, one chunk per phrase
+	Pacing  *Pacing        // speech settings to voice Text with
+	Screen  string         // short on-screen version of Text, see Pacing.ScreenText
+	Keys    []KeypadOption // keypad answers to the question in Text, see Pacing.Keypad
+}
+
+// commandTurn handles a voice command: it adjusts the speech settings and
+// replays the last reply. ok is false when text is not a command.
+func (s *service) commandTurn(ctx context.Context, c *Consultation, text string) (reply *Reply, ok bool, err error) {
+	command, ok := detectCommand(text)
+	if !ok {
+		return nil, false, nil
+	}
+	last, hasLast := c.lastAssistantMessage()
+	settingOnly := command == CommandTextOnly || command == CommandMale || command == CommandFemale
+	if !hasLast && !settingOnly {
+		// Nothing to repeat yet, let the Communicator answer
+		return nil, false, nil
+	}
+
+	reply = &Reply{Command: command}
+	if command != CommandRepeat {
+		if c.Pacing == nil {
+			c.Pacing = &Pacing{}
+		}
+		switch command {
+		case CommandSlower:
+			rate := c.Pacing.Speech().Rate - slowerStep
+			c.Pacing.SpeechRate = max(rate, 0.5)
+			reply.Text = "Хорошо, буду говорить медленнее. " + last
+		case CommandLouder:
+			c.Pacing.Volume = min(c.Pacing.Speech().Volume+louderStep, maxVolume)
+			reply.Text = "Хорошо, буду говорить громче. " + last
+		case CommandMale:
+			c.Pacing.Voice = MaleVoice
+			reply.Text = strings.TrimSpace("Хорошо, теперь с вами будет говорить мужской голос. " + last)
+		case CommandFemale:
+			c.Pacing.Voice = FemaleVoice
+			reply.Text = strings.TrimSpace("Хорошо, теперь с вами будет говорить женский голос. " + last)
+		case CommandTextOnly:
+			c.Pacing.TextOnly = true
+			reply.Text = "Хорошо, дальше общаемся текстом."
+			if hasLast {
+				reply.Text += " " + last
+			}
+		}
+		// Only the settings change; History is saved as it was loaded
+		if err := s.repo.Save(ctx, c); err != nil {
+			return nil, true, err
+		}
+	} else {
+		reply.Text = last
+		reply.Audio = s.speech.get(c.ID)
+	}
+	reply.Pacing = c.Pacing
+	return reply, true, nil
+}
+
+// SetVoice switches the TTS speaker for the rest of the consultation
+func (s *service) SetVoice(ctx context.Context, consultationID uuid.UUID, voice string) (*Consultation, error) {
+	speaker, err := ResolveVoice(voice)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Pacing == nil {
+		c.Pacing = &Pacing{}
+	}
+	c.Pacing.Voice = speaker
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	// The cached reply was spoken by the previous voice
+	s.speech.reset(consultationID)
+	return c, nil
+}
+
+// SwitchToText stops voicing replies, as the "напишите" command does, for a
+// patient who continues on a device that only shows text
+func (s *service) SwitchToText(ctx context.Context, consultationID uuid.UUID) (*Consultation, error) {
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Pacing == nil {
+		c.Pacing = &Pacing{}
+	}
+	c.Pacing.TextOnly = true
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Speak voices a reply of the consultation and remembers the audio so a
+// "повторите" can replay it without another TTS call
+func (s *service) Speak(ctx context.Context, consultationID uuid.UUID, text string, pacing *Pacing) ([]byte, error) {
+	audio, err := s.SynthesizeSpeech(ctx, text, pacing)
+	if err != nil {
+		return nil, err
+	}
+	s.speech.reset(consultationID)
+	s.speech.add(consultationID, audio)
+	s.RecordAudio(ctx, consultationID, "assistant", audio)
+	return audio, nil
+}
+
+// streamCommand sends a command reply as stream events; a replay reuses the cached chunks
+func (s *service) streamCommand(ctx context.Context, consultationID uuid.UUID, reply *Reply, eventChan chan<- StreamEvent) error {
+	events := []StreamEvent{{Type: "command", Data: string(reply.Command)}, {Type: "text", Data: reply.Text}}
+	for _, e := range events {
+		if !sendEvent(ctx, eventChan, e) {
+			return ctx.Err()
+		}
+	}
+
+	audio := reply.Audio
+	if len(audio) == 0 && !reply.Pacing.textOnly() {
+		if chunk, err := s.Speak(ctx, consultationID, reply.Text, reply.Pacing); err == nil {
+			audio = [][]byte{chunk}
+		}
+	}
+	for _, chunk := range audio {
+		sendEvent(ctx, eventChan, StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(chunk)})
+	}
+	sendEvent(ctx, eventChan, StreamEvent{Type: "done", Data: ""})
+	return nil
+}
+
+// lastAssistantMessage returns the most recent reply, if any
+func (c *Consultation) lastAssistantMessage() (string, bool) {
+	for i := len(c.History) - 1; i >= 0; i-- {
+		if c.History[i].Role == "assistant" {
+			return c.History[i].Content, true
+		}
+	}
+	return "", false
+}
+
+// speechCache keeps the last spoken reply per consultation; streamed replies
+// are voiced in several chunks. Entries of abandoned consultations are swept
+// once the cache grows.
+type speechCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]cachedSpeech
+}
+
+type cachedSpeech struct {
+	chunks [][]byte
+	at     time.Time
+}
+
+const (
+	speechCacheSweepAt = 500
+	speechCacheTTL     = time.Hour
+)
+
+func newSpeechCache() *speechCache {
+	return &speechCache{entries: make(map[uuid.UUID]cachedSpeech)}
+}
+
+// reset starts a new reply
+func (c *speechCache) reset(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= speechCacheSweepAt {
+		for k, e := range c.entries {
+			if time.Since(e.at) > speechCacheTTL {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[id] = cachedSpeech{at: time.Now()}
+}
+
+// add appends a chunk to the current reply
+func (c *speechCache) add(id uuid.UUID, audio []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[id]
+	e.chunks = append(e.chunks, audio)
+	e.at = time.Now()
+	c.entries[id] = e
+}
+
+func (c *speechCache) get(id uuid.UUID) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[id].chunks
+}
@@ -0,0 +1,79 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComplaintCategory is a coarse grouping of the chief complaint for dashboards
+type ComplaintCategory string
+
+const (
+	ComplaintPain           ComplaintCategory = "pain"
+	ComplaintRespiratory    ComplaintCategory = "respiratory"
+	ComplaintCardiovascular ComplaintCategory = "cardiovascular"
+	ComplaintFever          ComplaintCategory = "fever"
+	ComplaintInjury         ComplaintCategory = "injury"
+	ComplaintNeurological   ComplaintCategory = "neurological"
+	ComplaintDigestive      ComplaintCategory = "digestive"
+	ComplaintUrinary        ComplaintCategory = "urinary"
+	ComplaintSkin           ComplaintCategory = "skin"
+	ComplaintMental         ComplaintCategory = "mental"
+	ComplaintOther          ComplaintCategory = "other"
+)
+
+var ComplaintCategories = []ComplaintCategory{
+	ComplaintPain, ComplaintRespiratory, ComplaintCardiovascular, ComplaintFever, ComplaintInjury,
+	ComplaintNeurological, ComplaintDigestive, ComplaintUrinary, ComplaintSkin, ComplaintMental, ComplaintOther,
+}
+
+// ChiefComplaint is the reason for the visit, classified from the patient's
+// first messages so staff see it long before the Analyst's facts
+type ChiefComplaint struct {
+	Text       string            `json:"text"` // a few words, e.g. "боль в животе"
+	Category   ComplaintCategory `json:"category"`
+	Code       *Coding           `json:"code,omitempty"`
+	DetectedAt time.Time         `json:"detected_at"`
+}
+
+// Classification is tried on this many patient messages at most; greetings
+// and small talk before the complaint come back empty
+const maxComplaintMessages = 3
+
+// detectComplaint classifies the latest patient message in the background
+// until a chief complaint is found. It never delays the turn.
+func (s *service) detectComplaint(c *Consultation, text string) {
+	if c.ChiefComplaint != nil || len(strings.Fields(text)) < 2 {
+		return
+	}
+	patientMessages := 0
+	for _, m := range c.History {
+		if m.Role == "user" {
+			patientMessages++
+		}
+	}
+	if patientMessages > maxComplaintMessages {
+		return
+	}
+
+	go func(id uuid.UUID, text string) {
+		ctx := context.Background()
+		complaint, err := s.aiClient.DetectChiefComplaint(ctx, text)
+		if err != nil {
+			fmt.Printf("Chief complaint detection failed for consultation %s: %v\n", id, err)
+			return
+		}
+		if complaint == nil {
+			return
+		}
+		complaint.Code = s.normalizer.Normalize(complaint.Text)
+		complaint.DetectedAt = time.Now()
+		if err := s.repo.SetChiefComplaint(ctx, id, *complaint); err != nil {
+			fmt.Printf("Failed to save chief complaint: %v\n", err)
+		}
+	}(c.ID, text)
+}
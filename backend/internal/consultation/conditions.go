@@ -0,0 +1,84 @@
+package consultation
+
+import "strings"
+
+// ConditionLinker maps a diagnosis named by the patient to a coded concept
+type ConditionLinker interface {
+	Normalize(text string) *Coding
+}
+
+// PriorCondition is a diagnosis the patient was given before this visit,
+// e.g. "у меня гипертония"
+type PriorCondition struct {
+	Name      string  `json:"name"`            // as the patient put it, e.g. "гипертония"
+	Code      *Coding `json:"code,omitempty"`  // linked diagnosis, if recognized
+	Since     string  `json:"since,omitempty"` // e.g. "с 2015 года"
+	Treatment string  `json:"treatment"`       // e.g. "принимает эналаприл", "не лечится"; empty until asked
+}
+
+// same reports whether two mentions name one diagnosis: the same code, or
+// the same words when either is not linked
+func (p PriorCondition) same(other PriorCondition) bool {
+	if p.Code != nil && other.Code != nil {
+		return p.Code.System == other.Code.System && p.Code.Code == other.Code.Code
+	}
+	return strings.EqualFold(strings.TrimSpace(p.Name), strings.TrimSpace(other.Name))
+}
+
+// AddPriorConditions merges diagnoses, filling in details learned later in the dialogue
+func (c *Consultation) AddPriorConditions(conditions []PriorCondition) {
+	for _, p := range conditions {
+		if strings.TrimSpace(p.Name) == "" {
+			continue
+		}
+		merged := false
+		for i := range c.PriorConditions {
+			existing := &c.PriorConditions[i]
+			if !existing.same(p) {
+				continue
+			}
+			if existing.Code == nil {
+				existing.Code = p.Code
+			}
+			if p.Since != "" {
+				existing.Since = p.Since
+			}
+			if p.Treatment != "" {
+				existing.Treatment = p.Treatment
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			c.PriorConditions = append(c.PriorConditions, p)
+		}
+	}
+}
+
+// UnclearConditions returns the diagnoses whose treatment status is not known yet
+func (c *Consultation) UnclearConditions() []PriorCondition {
+	var unclear []PriorCondition
+	for _, p := range c.PriorConditions {
+		if p.Treatment == "" {
+			unclear = append(unclear, p)
+		}
+	}
+	return unclear
+}
+
+// Describe is the diagnosis as the doctor reads it, e.g.
+// "гипертония (Hypertensive disorder, 38341003), с 2015 года; лечение: эналаприл"
+func (p PriorCondition) Describe() string {
+	line := p.Name
+	if p.Code != nil {
+		line += " (" + p.Code.Display + ", " + p.Code.Code + ")"
+	}
+	if p.Since != "" {
+		line += ", " + p.Since
+	}
+	treatment := p.Treatment
+	if treatment == "" {
+		treatment = "не уточнено"
+	}
+	return line + "; лечение: " + treatment
+}
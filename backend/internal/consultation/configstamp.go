@@ -0,0 +1,54 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigStamp names the configuration a consultation ran on: the tenant and
+// the version of every versioned kind in effect, e.g. {"rules": 3}. It is
+// taken at creation and again at completion, when the rules produce the
+// report, so a historical report can be traced to its exact configuration.
+type ConfigStamp struct {
+	Tenant    string         `json:"tenant,omitempty"`
+	Versions  map[string]int `json:"versions"`
+	StampedAt time.Time      `json:"stamped_at"`
+}
+
+// String reads like "checklists v2, prompts v7, rules v3"
+func (s *ConfigStamp) String() string {
+	kinds := make([]string, 0, len(s.Versions))
+	for k := range s.Versions {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%s v%d", k, s.Versions[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ConfigVersions gives the configuration versions in effect for the tenant
+type ConfigVersions interface {
+	Stamp() ConfigStamp
+}
+
+// stampConfig records the configuration versions in effect now
+func (s *service) stampConfig(ctx context.Context, c *Consultation) {
+	if s.configs == nil {
+		return
+	}
+	stamp := s.configs.Stamp()
+	if len(stamp.Versions) == 0 {
+		return
+	}
+	if err := s.repo.SetConfigStamp(ctx, c.ID, stamp); err != nil {
+		fmt.Printf("Failed to stamp the config versions of consultation %s: %v\n", c.ID, err)
+		return
+	}
+	c.Config = &stamp
+}
@@ -0,0 +1,96 @@
+package consultation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// onsetRe matches a fact that dates the complaint: "3 дня", "с утра", "неделю назад"
+var onsetRe = regexp.MustCompile(`\d+\s*(мин|час|дн|день|сут|недел|мес|год|лет)|вчера|сегодня|позавчера|с утра|утром|вечером|ночью|неделю|месяц|давно|недавно|назад`)
+
+// coverageGaps lists what the interview has not covered yet by fixed rules:
+// the complaint and when it began, the child's age and weight, the
+// epidemiological block, the department's required information and, when
+// reconciling medications, every entry in full. The Communicator's farewell
+// does not end an interview with gaps, see SupervisorSchedule.Veto.
+func (c *Consultation) coverageGaps() []string {
+	var gaps []string
+	if c.Mode == ModeMedicationReconciliation {
+		if !c.MedicationsComplete() {
+			gaps = append(gaps, "дозировка, схема и регулярность приёма каждого препарата")
+		}
+	} else {
+		if c.ChiefComplaint == nil && !c.hasFact(isSymptomFact) {
+			gaps = append(gaps, "основная жалоба")
+		}
+		if !c.hasFact(isOnsetFact) {
+			gaps = append(gaps, "когда появились жалобы и как долго длятся")
+		}
+	}
+	if c.Pediatric {
+		if _, ok := PatientAgeMonths(c.ExtractedFacts); !ok {
+			gaps = append(gaps, "возраст ребёнка")
+		}
+		if c.Child == nil || c.Child.WeightKg == 0 {
+			gaps = append(gaps, "вес ребёнка")
+		}
+	}
+	for _, t := range c.PendingEpidTopics() {
+		gaps = append(gaps, t.Question)
+	}
+	for _, f := range c.PendingRequired() {
+		gaps = append(gaps, f.Label)
+	}
+	return gaps
+}
+
+func (c *Consultation) hasFact(match func(MedicalFact) bool) bool {
+	for _, f := range c.ExtractedFacts {
+		if match(f) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSymptomFact(f MedicalFact) bool {
+	category := strings.ToLower(f.Category)
+	return strings.Contains(category, "симптом") || strings.Contains(category, "жалоб") || strings.Contains(category, "symptom")
+}
+
+func isOnsetFact(f MedicalFact) bool {
+	category := strings.ToLower(f.Category)
+	if strings.Contains(category, "хронолог") || strings.Contains(category, "длительн") || strings.Contains(category, "duration") || strings.Contains(category, "onset") {
+		return true
+	}
+	return isSymptomFact(f) && onsetRe.MatchString(strings.ToLower(f.Description))
+}
+
+// vetoedFarewell reports the gaps that kept the Communicator's last farewell
+// from ending the interview, nil if it did not say goodbye or nothing is missing
+func (c *Consultation) vetoedFarewell() []string {
+	if c.IsComplete || (c.Recap != nil && c.Recap.Active) {
+		return nil
+	}
+	for i := len(c.History) - 1; i >= 0; i-- {
+		if c.History[i].Role != "assistant" {
+			continue
+		}
+		if !c.History[i].endsInterview() {
+			return nil
+		}
+		return c.coverageGaps()
+	}
+	return nil
+}
+
+// endsInterview reports whether the reply said goodbye, by the tool when the
+// Communicator called one and by the farewell phrase otherwise
+func (m Message) endsInterview() bool {
+	for _, call := range m.ToolCalls {
+		if call.Tool == ToolEndConsultation {
+			return true
+		}
+	}
+	return len(m.ToolCalls) == 0 && isCompletionPhrase(m.Content)
+}
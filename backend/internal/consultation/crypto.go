@@ -0,0 +1,662 @@
+package consultation
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrErased is returned for content of a patient whose data key was destroyed
+var ErrErased = errors.New("patient data was erased")
+
+// Keyring hands out the per-patient data keys that encrypt stored content
+type Keyring interface {
+	// PatientKey returns the patient's current key, creating one if needed
+	PatientKey(ctx context.Context, patientID uuid.UUID) (uuid.UUID, []byte, error)
+	// Key returns a key by ID, or ErrErased once it has been destroyed
+	Key(ctx context.Context, keyID uuid.UUID) ([]byte, error)
+}
+
+// Encrypted text is "enc:v1:<key id>:<base64 nonce+ciphertext>"; encrypted
+// audio starts with audioMagic and the raw key ID
+const encPrefix = "enc:v1:"
+
+var audioMagic = []byte("ENC1")
+
+// encryptedRepo encrypts the patient's words before they are stored: message
+// text, fact descriptions and the fact summary, pertinent negatives,
+// medications, prior conditions, the chief complaint, recommendations and
+// their translation, the risk screening trigger and answers, the recap,
+// queued questions, answers to doctor questions, red-flag indicators, staff
+// notes and session audio. The rest (codes, tickets, states) stays readable
+// for search, stats and the board.
+type encryptedRepo struct {
+	Repository
+	keys Keyring
+
+	mu       sync.Mutex
+	patients map[uuid.UUID]uuid.UUID // consultation -> patient, for AddAudio
+}
+
+// eventLog matches the event-sourced repository, whose events are kept
+// encrypted as stored
+type eventLog interface {
+	Events(ctx context.Context, id uuid.UUID) ([]Event, error)
+}
+
+type encryptedEventRepo struct {
+	*encryptedRepo
+	log eventLog
+}
+
+func (r *encryptedEventRepo) Events(ctx context.Context, id uuid.UUID) ([]Event, error) {
+	return r.log.Events(ctx, id)
+}
+
+// NewEncryptedRepository wraps a repository so content is stored encrypted
+// with the patient's data key. Destroying the key erases the content,
+// including copies in the event log and in backups.
+func NewEncryptedRepository(inner Repository, keys Keyring) Repository {
+	r := &encryptedRepo{Repository: inner, keys: keys, patients: make(map[uuid.UUID]uuid.UUID)}
+	if log, ok := inner.(eventLog); ok {
+		return &encryptedEventRepo{encryptedRepo: r, log: log}
+	}
+	return r
+}
+
+func (r *encryptedRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation, error) {
+	c, err := r.Repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.open(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *encryptedRepo) OpenConsultation(ctx context.Context, patientID uuid.UUID, since time.Time) (*Consultation, error) {
+	c, err := r.Repository.OpenConsultation(ctx, patientID, since)
+	if err != nil || c == nil {
+		return c, err
+	}
+	if err := r.open(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save stores an encrypted copy and hands back what the inner Save assigned,
+// such as the ticket and timestamps
+func (r *encryptedRepo) Save(ctx context.Context, c *Consultation) error {
+	s, err := r.sealer(ctx, c.PatientID)
+	if err != nil {
+		return err
+	}
+	stored := *c
+	stored.History = make([]Message, len(c.History))
+	for i, m := range c.History {
+		m.Content = s.sealText(m.Content)
+		stored.History[i] = m
+	}
+	stored.ExtractedFacts = make([]MedicalFact, len(c.ExtractedFacts))
+	for i, f := range c.ExtractedFacts {
+		f.Description = s.sealText(f.Description)
+		stored.ExtractedFacts[i] = f
+	}
+	stored.PertinentNegatives = make([]PertinentNegative, len(c.PertinentNegatives))
+	for i, n := range c.PertinentNegatives {
+		n.Context = s.sealText(n.Context)
+		stored.PertinentNegatives[i] = n
+	}
+	if c.Clarification != nil {
+		held := *c.Clarification
+		held.Text = s.sealText(held.Text)
+		stored.Clarification = &held
+	}
+	if c.Medications != nil {
+		stored.Medications = make([]Medication, len(c.Medications))
+		for i, m := range c.Medications {
+			stored.Medications[i] = s.sealMedication(m)
+		}
+	}
+	if c.PriorConditions != nil {
+		stored.PriorConditions = make([]PriorCondition, len(c.PriorConditions))
+		for i, p := range c.PriorConditions {
+			p.Name, p.Since, p.Treatment = s.sealText(p.Name), s.sealText(p.Since), s.sealText(p.Treatment)
+			stored.PriorConditions[i] = p
+		}
+	}
+	stored.Recommendations = s.sealText(c.Recommendations)
+	if c.FactSummary != nil {
+		stored.FactSummary = make([]MedicalFact, len(c.FactSummary))
+		for i, f := range c.FactSummary {
+			f.Description = s.sealText(f.Description)
+			stored.FactSummary[i] = f
+		}
+	}
+	if c.RiskScreening != nil {
+		screening := *c.RiskScreening
+		screening.Trigger = s.sealText(screening.Trigger)
+		if screening.Answers != nil {
+			screening.Answers = make([]ScreeningAnswer, len(c.RiskScreening.Answers))
+			for i, a := range c.RiskScreening.Answers {
+				a.Answer = s.sealText(a.Answer)
+				screening.Answers[i] = a
+			}
+		}
+		stored.RiskScreening = &screening
+	}
+	if c.Translation != nil {
+		translation := *c.Translation
+		if translation.Facts != nil {
+			translation.Facts = make([]TranslatedText, len(c.Translation.Facts))
+			for i, t := range c.Translation.Facts {
+				translation.Facts[i] = TranslatedText{Original: s.sealText(t.Original), Text: s.sealText(t.Text)}
+			}
+		}
+		translation.Recommendations = s.sealText(translation.Recommendations)
+		stored.Translation = &translation
+	}
+	if c.Recap != nil {
+		recap := *c.Recap
+		recap.Text = s.sealText(recap.Text)
+		if recap.Corrections != nil {
+			recap.Corrections = make([]string, len(c.Recap.Corrections))
+			for i, text := range c.Recap.Corrections {
+				recap.Corrections[i] = s.sealText(text)
+			}
+		}
+		stored.Recap = &recap
+	}
+	if err := r.Repository.Save(ctx, &stored); err != nil {
+		return err
+	}
+
+	stored.History, stored.ExtractedFacts, stored.PertinentNegatives, stored.Clarification = c.History, c.ExtractedFacts, c.PertinentNegatives, c.Clarification
+	stored.Medications, stored.PriorConditions, stored.Recommendations = c.Medications, c.PriorConditions, c.Recommendations
+	stored.FactSummary, stored.RiskScreening, stored.Translation, stored.Recap = c.FactSummary, c.RiskScreening, c.Translation, c.Recap
+	*c = stored
+	r.remember(c.ID, c.PatientID)
+	return nil
+}
+
+func (r *encryptedRepo) AddAudio(ctx context.Context, consultationID uuid.UUID, segment AudioSegment) error {
+	patientID, err := r.patientOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	s, err := r.sealer(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if segment.Data, err = s.sealBytes(segment.Data); err != nil {
+		return err
+	}
+	return r.Repository.AddAudio(ctx, consultationID, segment)
+}
+
+// AppendMessage encrypts a message appended outside Save, such as a turn
+// relayed through the doctor bridge
+func (r *encryptedRepo) AppendMessage(ctx context.Context, consultationID uuid.UUID, m Message) error {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	m.Content = s.sealText(m.Content)
+	return r.Repository.AppendMessage(ctx, consultationID, m)
+}
+
+// SetChiefComplaint encrypts the complaint's text, which quotes the patient
+func (r *encryptedRepo) SetChiefComplaint(ctx context.Context, consultationID uuid.UUID, complaint ChiefComplaint) error {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	complaint.Text = s.sealText(complaint.Text)
+	return r.Repository.SetChiefComplaint(ctx, consultationID, complaint)
+}
+
+// SetQueuedQuestions encrypts the questions, which often restate the patient's answers
+func (r *encryptedRepo) SetQueuedQuestions(ctx context.Context, consultationID uuid.UUID, questions []string) error {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	sealed := make([]string, len(questions))
+	for i, q := range questions {
+		sealed[i] = s.sealText(q)
+	}
+	return r.Repository.SetQueuedQuestions(ctx, consultationID, sealed)
+}
+
+// UpdateDoctorQuestion encrypts the answer, which is the patient's message
+func (r *encryptedRepo) UpdateDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) error {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	q.Answer = s.sealText(q.Answer)
+	return r.Repository.UpdateDoctorQuestion(ctx, consultationID, q)
+}
+
+// AddNote encrypts the staff note, which is about the patient
+func (r *encryptedRepo) AddNote(ctx context.Context, consultationID uuid.UUID, note Note) error {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	note.Text = s.sealText(note.Text)
+	return r.Repository.AddNote(ctx, consultationID, note)
+}
+
+// AddRedFlag encrypts the indicator, which quotes the patient
+func (r *encryptedRepo) AddRedFlag(ctx context.Context, consultationID uuid.UUID, flag RedFlag) (bool, error) {
+	s, err := r.sealerOf(ctx, consultationID)
+	if err != nil {
+		return false, err
+	}
+	flag.Indicator = s.sealText(flag.Indicator)
+	return r.Repository.AddRedFlag(ctx, consultationID, flag)
+}
+
+// PendingReviews decrypts the chief complaints; an erased one is left out
+func (r *encryptedRepo) PendingReviews(ctx context.Context, loc Location) ([]ReviewQueueItem, error) {
+	items, err := r.Repository.PendingReviews(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	o := r.opener(ctx)
+	for i := range items {
+		if items[i].ChiefComplaint, err = o.openText(items[i].ChiefComplaint); err != nil {
+			items[i].ChiefComplaint = ""
+		}
+	}
+	return items, nil
+}
+
+// Summaries decrypts the chief complaints; an erased one is left out
+func (r *encryptedRepo) Summaries(ctx context.Context, since time.Time) ([]Summary, error) {
+	summaries, err := r.Repository.Summaries(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	o := r.opener(ctx)
+	for i := range summaries {
+		if summaries[i].ChiefComplaint, err = o.openText(summaries[i].ChiefComplaint); err != nil {
+			summaries[i].ChiefComplaint = ""
+		}
+	}
+	return summaries, nil
+}
+
+// AudioSegments decrypts the recording; audio stored before encryption was
+// enabled is returned as is
+func (r *encryptedRepo) AudioSegments(ctx context.Context, consultationID uuid.UUID) ([]AudioSegment, error) {
+	segments, err := r.Repository.AudioSegments(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	o := r.opener(ctx)
+	for i := range segments {
+		if segments[i].Data, err = o.openBytes(segments[i].Data); err != nil {
+			return nil, err
+		}
+	}
+	return segments, nil
+}
+
+// AddReasoning encrypts the thinking, which restates what the patient said
+func (r *encryptedRepo) AddReasoning(ctx context.Context, consultationID uuid.UUID, t ReasoningTrace) error {
+	patientID, err := r.patientOf(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	s, err := r.sealer(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	t.Text = s.sealText(t.Text)
+	return r.Repository.AddReasoning(ctx, consultationID, t)
+}
+
+func (r *encryptedRepo) ReasoningTraces(ctx context.Context, consultationID uuid.UUID) ([]ReasoningTrace, error) {
+	traces, err := r.Repository.ReasoningTraces(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	o := r.opener(ctx)
+	for i := range traces {
+		if traces[i].Text, err = o.openText(traces[i].Text); err != nil {
+			return nil, err
+		}
+	}
+	return traces, nil
+}
+
+// BeginTurn encrypts the patient's message kept for recovery
+func (r *encryptedRepo) BeginTurn(ctx context.Context, t *TurnCheckpoint) error {
+	patientID, err := r.patientOf(ctx, t.ConsultationID)
+	if err != nil {
+		return err
+	}
+	s, err := r.sealer(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	sealed := *t
+	sealed.Text = s.sealText(t.Text)
+	if err := r.Repository.BeginTurn(ctx, &sealed); err != nil {
+		return err
+	}
+	t.ID = sealed.ID
+	return nil
+}
+
+func (r *encryptedRepo) UnfinishedTurns(ctx context.Context) ([]TurnCheckpoint, error) {
+	turns, err := r.Repository.UnfinishedTurns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o := r.opener(ctx)
+	for i := range turns {
+		// An erased patient's turn must not hold up the others
+		if turns[i].Text, err = o.openText(turns[i].Text); err != nil {
+			turns[i].Text = ""
+		}
+	}
+	return turns, nil
+}
+
+func (r *encryptedRepo) open(ctx context.Context, c *Consultation) error {
+	o := r.opener(ctx)
+	var err error
+	for i := range c.History {
+		if c.History[i].Content, err = o.openText(c.History[i].Content); err != nil {
+			return err
+		}
+	}
+	for i := range c.ExtractedFacts {
+		if c.ExtractedFacts[i].Description, err = o.openText(c.ExtractedFacts[i].Description); err != nil {
+			return err
+		}
+	}
+	for i := range c.PertinentNegatives {
+		if c.PertinentNegatives[i].Context, err = o.openText(c.PertinentNegatives[i].Context); err != nil {
+			return err
+		}
+	}
+	if c.Clarification != nil {
+		if c.Clarification.Text, err = o.openText(c.Clarification.Text); err != nil {
+			return err
+		}
+	}
+	for i := range c.Medications {
+		if c.Medications[i], err = o.openMedication(c.Medications[i]); err != nil {
+			return err
+		}
+	}
+	for i := range c.PriorConditions {
+		p := &c.PriorConditions[i]
+		for _, field := range []*string{&p.Name, &p.Since, &p.Treatment} {
+			if *field, err = o.openText(*field); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Recommendations, err = o.openText(c.Recommendations); err != nil {
+		return err
+	}
+	if c.ChiefComplaint != nil {
+		if c.ChiefComplaint.Text, err = o.openText(c.ChiefComplaint.Text); err != nil {
+			return err
+		}
+	}
+	for i := range c.QueuedQuestions {
+		if c.QueuedQuestions[i], err = o.openText(c.QueuedQuestions[i]); err != nil {
+			return err
+		}
+	}
+	for i := range c.RedFlags {
+		if c.RedFlags[i].Indicator, err = o.openText(c.RedFlags[i].Indicator); err != nil {
+			return err
+		}
+	}
+	for i := range c.Notes {
+		if c.Notes[i].Text, err = o.openText(c.Notes[i].Text); err != nil {
+			return err
+		}
+	}
+	for i := range c.FactSummary {
+		if c.FactSummary[i].Description, err = o.openText(c.FactSummary[i].Description); err != nil {
+			return err
+		}
+	}
+	if rs := c.RiskScreening; rs != nil {
+		if rs.Trigger, err = o.openText(rs.Trigger); err != nil {
+			return err
+		}
+		for i := range rs.Answers {
+			if rs.Answers[i].Answer, err = o.openText(rs.Answers[i].Answer); err != nil {
+				return err
+			}
+		}
+	}
+	if t := c.Translation; t != nil {
+		for i := range t.Facts {
+			for _, field := range []*string{&t.Facts[i].Original, &t.Facts[i].Text} {
+				if *field, err = o.openText(*field); err != nil {
+					return err
+				}
+			}
+		}
+		if t.Recommendations, err = o.openText(t.Recommendations); err != nil {
+			return err
+		}
+	}
+	if rc := c.Recap; rc != nil {
+		if rc.Text, err = o.openText(rc.Text); err != nil {
+			return err
+		}
+		for i := range rc.Corrections {
+			if rc.Corrections[i], err = o.openText(rc.Corrections[i]); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range c.DoctorQuestions {
+		if c.DoctorQuestions[i].Answer, err = o.openText(c.DoctorQuestions[i].Answer); err != nil {
+			return err
+		}
+	}
+	r.remember(c.ID, c.PatientID)
+	return nil
+}
+
+func (r *encryptedRepo) remember(consultationID, patientID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patients[consultationID] = patientID
+}
+
+func (r *encryptedRepo) patientOf(ctx context.Context, consultationID uuid.UUID) (uuid.UUID, error) {
+	r.mu.Lock()
+	patientID, ok := r.patients[consultationID]
+	r.mu.Unlock()
+	if ok {
+		return patientID, nil
+	}
+	c, err := r.Repository.GetByID(ctx, consultationID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	r.remember(c.ID, c.PatientID)
+	return c.PatientID, nil
+}
+
+// sealerOf returns the sealer of the consultation's patient
+func (r *encryptedRepo) sealerOf(ctx context.Context, consultationID uuid.UUID) (*sealer, error) {
+	patientID, err := r.patientOf(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	return r.sealer(ctx, patientID)
+}
+
+// sealer encrypts with the patient's current key. Text gets a nonce derived
+// from the plaintext, so saving unchanged content stores the same ciphertext
+// and the event log still sees appends as appends. Equal texts of one
+// patient are the only thing that leaks.
+type sealer struct {
+	keyID    uuid.UUID
+	aead     cipher.AEAD
+	nonceKey []byte
+}
+
+func (r *encryptedRepo) sealer(ctx context.Context, patientID uuid.UUID) (*sealer, error) {
+	keyID, key, err := r.keys.PatientKey(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("consultation nonce"))
+	return &sealer{keyID: keyID, aead: aead, nonceKey: mac.Sum(nil)}, nil
+}
+
+func (s *sealer) sealText(text string) string {
+	if text == "" {
+		return text
+	}
+	mac := hmac.New(sha256.New, s.nonceKey)
+	mac.Write([]byte(text))
+	nonce := mac.Sum(nil)[:s.aead.NonceSize()]
+	sealed := s.aead.Seal(nonce, nonce, []byte(text), s.keyID[:])
+	return encPrefix + s.keyID.String() + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+func (s *sealer) sealMedication(m Medication) Medication {
+	m.Name, m.Dose, m.Schedule, m.Adherence = s.sealText(m.Name), s.sealText(m.Dose), s.sealText(m.Schedule), s.sealText(m.Adherence)
+	return m
+}
+
+func (s *sealer) sealBytes(data []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append(append([]byte{}, audioMagic...), s.keyID[:]...), nonce...)
+	return s.aead.Seal(out, nonce, data, s.keyID[:]), nil
+}
+
+// opener decrypts content that may use several keys, e.g. audio recorded
+// before a key was replaced, and looks each key up once
+type opener struct {
+	ctx   context.Context
+	keys  Keyring
+	aeads map[uuid.UUID]cipher.AEAD
+}
+
+func (r *encryptedRepo) opener(ctx context.Context) *opener {
+	return &opener{ctx: ctx, keys: r.keys, aeads: make(map[uuid.UUID]cipher.AEAD)}
+}
+
+func (o *opener) aead(keyID uuid.UUID) (cipher.AEAD, error) {
+	if aead, ok := o.aeads[keyID]; ok {
+		return aead, nil
+	}
+	key, err := o.keys.Key(o.ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	o.aeads[keyID] = aead
+	return aead, nil
+}
+
+// openText decrypts encrypted text; plain text from before encryption was
+// enabled is returned unchanged
+func (o *opener) openText(text string) (string, error) {
+	rest, ok := strings.CutPrefix(text, encPrefix)
+	if !ok {
+		return text, nil
+	}
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted text")
+	}
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted text: %w", err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted text: %w", err)
+	}
+	plain, err := o.open(keyID, sealed)
+	return string(plain), err
+}
+
+func (o *opener) openMedication(m Medication) (Medication, error) {
+	var err error
+	for _, field := range []*string{&m.Name, &m.Dose, &m.Schedule, &m.Adherence} {
+		if *field, err = o.openText(*field); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func (o *opener) openBytes(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, audioMagic) {
+		return data, nil
+	}
+	rest := data[len(audioMagic):]
+	if len(rest) < 16 {
+		return nil, fmt.Errorf("malformed encrypted audio")
+	}
+	keyID, _ := uuid.FromBytes(rest[:16])
+	return o.open(keyID, rest[16:])
+}
+
+func (o *opener) open(keyID uuid.UUID, sealed []byte) ([]byte, error) {
+	aead, err := o.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("malformed ciphertext")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, keyID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %s: %w", keyID, err)
+	}
+	return plain, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
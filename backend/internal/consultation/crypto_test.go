@@ -0,0 +1,344 @@
+package consultation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// memKeyring keeps one key per patient; deleting it erases the patient
+type memKeyring struct {
+	byPatient map[uuid.UUID]uuid.UUID
+	keys      map[uuid.UUID][]byte
+}
+
+func newMemKeyring() *memKeyring {
+	return &memKeyring{byPatient: map[uuid.UUID]uuid.UUID{}, keys: map[uuid.UUID][]byte{}}
+}
+
+func (k *memKeyring) PatientKey(ctx context.Context, patientID uuid.UUID) (uuid.UUID, []byte, error) {
+	if keyID, ok := k.byPatient[patientID]; ok {
+		return keyID, k.keys[keyID], nil
+	}
+	keyID := uuid.New()
+	key := []byte(strings.Repeat("k", 32))
+	copy(key, keyID[:])
+	k.byPatient[patientID], k.keys[keyID] = keyID, key
+	return keyID, key, nil
+}
+
+func (k *memKeyring) Key(ctx context.Context, keyID uuid.UUID) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrErased
+	}
+	return key, nil
+}
+
+func (k *memKeyring) erase(patientID uuid.UUID) {
+	delete(k.keys, k.byPatient[patientID])
+}
+
+// memRepo stores consultations as JSON, so a test sees exactly what would
+// reach the database
+type memRepo struct {
+	Repository
+	stored    map[uuid.UUID][]byte
+	notes     map[uuid.UUID][]Note
+	questions map[uuid.UUID][]DoctorQuestion
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{stored: map[uuid.UUID][]byte{}, notes: map[uuid.UUID][]Note{}, questions: map[uuid.UUID][]DoctorQuestion{}}
+}
+
+func (r *memRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation, error) {
+	data, ok := r.stored[id]
+	if !ok {
+		return nil, ErrConsultationNotFound
+	}
+	var c Consultation
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	c.Notes = append([]Note(nil), r.notes[id]...)
+	c.DoctorQuestions = append([]DoctorQuestion(nil), r.questions[id]...)
+	return &c, nil
+}
+
+func (r *memRepo) Save(ctx context.Context, c *Consultation) error {
+	return r.update(c.ID, func(stored *Consultation) {
+		complaint, queued := stored.ChiefComplaint, stored.QueuedQuestions
+		*stored = *c
+		stored.ChiefComplaint, stored.QueuedQuestions = complaint, queued
+	})
+}
+
+func (r *memRepo) SetChiefComplaint(ctx context.Context, id uuid.UUID, complaint ChiefComplaint) error {
+	return r.update(id, func(stored *Consultation) { stored.ChiefComplaint = &complaint })
+}
+
+func (r *memRepo) SetQueuedQuestions(ctx context.Context, id uuid.UUID, questions []string) error {
+	return r.update(id, func(stored *Consultation) { stored.QueuedQuestions = questions })
+}
+
+func (r *memRepo) AppendMessage(ctx context.Context, id uuid.UUID, m Message) error {
+	return r.update(id, func(stored *Consultation) { stored.History = append(stored.History, m) })
+}
+
+func (r *memRepo) AddNote(ctx context.Context, id uuid.UUID, note Note) error {
+	r.notes[id] = append(r.notes[id], note)
+	return nil
+}
+
+func (r *memRepo) AddDoctorQuestion(ctx context.Context, id uuid.UUID, q DoctorQuestion) error {
+	r.questions[id] = append(r.questions[id], q)
+	return nil
+}
+
+func (r *memRepo) UpdateDoctorQuestion(ctx context.Context, id uuid.UUID, q DoctorQuestion) error {
+	for i := range r.questions[id] {
+		if r.questions[id][i].ID == q.ID {
+			r.questions[id][i] = q
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) AddRedFlag(ctx context.Context, id uuid.UUID, flag RedFlag) (bool, error) {
+	return true, r.update(id, func(stored *Consultation) { stored.RedFlags = append(stored.RedFlags, flag) })
+}
+
+func (r *memRepo) update(id uuid.UUID, change func(stored *Consultation)) error {
+	var c Consultation
+	if data, ok := r.stored[id]; ok {
+		if err := json.Unmarshal(data, &c); err != nil {
+			return err
+		}
+	}
+	change(&c)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	r.stored[id] = data
+	return nil
+}
+
+// raw is everything stored for the consultation, as the database holds it
+func (r *memRepo) raw(id uuid.UUID) string {
+	notes, _ := json.Marshal(r.notes[id])
+	questions, _ := json.Marshal(r.questions[id])
+	return string(r.stored[id]) + string(notes) + string(questions)
+}
+
+func TestEncryptedRepository(t *testing.T) {
+	const secret = "боль в груди отдаёт в левую руку"
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, repo Repository, c *Consultation) error
+		read  func(c *Consultation) string
+	}{
+		{
+			name: "history",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.History = append(c.History, Message{Role: "user", Content: secret})
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.History[0].Content },
+		},
+		{
+			name: "appended message",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				return repo.AppendMessage(ctx, c.ID, Message{Role: "user", Content: secret})
+			},
+			read: func(c *Consultation) string { return c.History[0].Content },
+		},
+		{
+			name: "fact",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.ExtractedFacts = []MedicalFact{{Category: "Symptom", Description: secret}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.ExtractedFacts[0].Description },
+		},
+		{
+			name: "medication",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Medications = []Medication{{Name: "Эналаприл", Dose: "10 мг", Schedule: "утром", Adherence: secret}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Medications[0].Adherence },
+		},
+		{
+			name: "prior condition",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.PriorConditions = []PriorCondition{{Name: secret, Since: "с 2015 года"}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.PriorConditions[0].Name },
+		},
+		{
+			name: "recommendations",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Recommendations = secret
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Recommendations },
+		},
+		{
+			name: "chief complaint",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				return repo.SetChiefComplaint(ctx, c.ID, ChiefComplaint{Text: secret, Category: "pain"})
+			},
+			read: func(c *Consultation) string { return c.ChiefComplaint.Text },
+		},
+		{
+			name: "queued question",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				return repo.SetQueuedQuestions(ctx, c.ID, []string{secret})
+			},
+			read: func(c *Consultation) string { return c.QueuedQuestions[0] },
+		},
+		{
+			name: "note",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				return repo.AddNote(ctx, c.ID, Note{ID: uuid.New(), Author: "Медсестра", Text: secret})
+			},
+			read: func(c *Consultation) string { return c.Notes[0].Text },
+		},
+		{
+			name: "red flag",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				_, err := repo.AddRedFlag(ctx, c.ID, RedFlag{Category: RedFlagCardiac, Indicator: secret})
+				return err
+			},
+			read: func(c *Consultation) string { return c.RedFlags[0].Indicator },
+		},
+		{
+			name: "fact summary",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.FactSummary = []MedicalFact{{Category: "Symptom", Description: secret}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.FactSummary[0].Description },
+		},
+		{
+			name: "risk screening trigger",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.RiskScreening = &RiskScreening{Active: true, Trigger: secret}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.RiskScreening.Trigger },
+		},
+		{
+			name: "risk screening answer",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.RiskScreening = &RiskScreening{Answers: []ScreeningAnswer{{Question: "Вопрос", Answer: secret, Positive: true}}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.RiskScreening.Answers[0].Answer },
+		},
+		{
+			name: "translated fact",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Translation = &Translation{From: "ru", To: "en", Facts: []TranslatedText{{Original: secret, Text: secret}}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Translation.Of(secret) },
+		},
+		{
+			name: "translated recommendations",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Translation = &Translation{From: "ru", To: "en", Recommendations: secret}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Translation.Recommendations },
+		},
+		{
+			name: "recap",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Recap = &Recap{Active: true, Text: secret}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Recap.Text },
+		},
+		{
+			name: "recap correction",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				c.Recap = &Recap{Corrections: []string{secret}}
+				return repo.Save(ctx, c)
+			},
+			read: func(c *Consultation) string { return c.Recap.Corrections[0] },
+		},
+		{
+			name: "doctor question answer",
+			write: func(ctx context.Context, repo Repository, c *Consultation) error {
+				q := DoctorQuestion{ID: uuid.New(), Text: "Давно ли болит?", Author: "Врач"}
+				if err := repo.AddDoctorQuestion(ctx, c.ID, q); err != nil {
+					return err
+				}
+				q.Answer = secret
+				return repo.UpdateDoctorQuestion(ctx, c.ID, q)
+			},
+			read: func(c *Consultation) string { return c.DoctorQuestions[0].Answer },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			inner, keys := newMemRepo(), newMemKeyring()
+			repo := NewEncryptedRepository(inner, keys)
+
+			c := &Consultation{ID: uuid.New(), PatientID: uuid.New()}
+			if err := repo.Save(ctx, c); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if err := tt.write(ctx, repo, c); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			if raw := inner.raw(c.ID); strings.Contains(raw, secret) {
+				t.Fatalf("stored in plaintext: %s", raw)
+			} else if !strings.Contains(raw, encPrefix) {
+				t.Fatalf("nothing was encrypted: %s", raw)
+			}
+
+			got, err := repo.GetByID(ctx, c.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if text := tt.read(got); text != secret {
+				t.Errorf("read back %q, want %q", text, secret)
+			}
+
+			keys.erase(c.PatientID)
+			if _, err := NewEncryptedRepository(inner, keys).GetByID(ctx, c.ID); !errors.Is(err, ErrErased) {
+				t.Errorf("GetByID after erasure: got %v, want ErrErased", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedRepositoryKeepsPlaintextInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEncryptedRepository(newMemRepo(), newMemKeyring())
+	c := &Consultation{
+		ID:              uuid.New(),
+		PatientID:       uuid.New(),
+		History:         []Message{{Role: "user", Content: "голова болит"}},
+		Medications:     []Medication{{Name: "Ибупрофен"}},
+		Recommendations: "Осмотр невролога",
+	}
+	if err := repo.Save(ctx, c); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if c.History[0].Content != "голова болит" || c.Medications[0].Name != "Ибупрофен" || c.Recommendations != "Осмотр невролога" {
+		t.Errorf("Save left encrypted content in the caller's consultation: %+v", c)
+	}
+}
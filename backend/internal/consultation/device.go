@@ -0,0 +1,74 @@
+package consultation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Device is the kiosk a consultation was started on, identified by its
+// device key. The placement is stamped onto the consultation so reports
+// state where the patient physically is.
+type Device struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Location string    `json:"location,omitempty"` // e.g. "Приёмное отделение, 1 этаж"
+	Room     string    `json:"room,omitempty"`
+	// Waiting area the kiosk serves, see Location. The department is used
+	// when the interview names none.
+	Building   string  `json:"building,omitempty"`
+	Floor      string  `json:"floor,omitempty"`
+	Department string  `json:"department,omitempty"`
+	Persona    string  `json:"persona,omitempty"`  // default TTS speaker
+	Language   string  `json:"language,omitempty"` // default UI language, e.g. "ru"
+	Volume     float64 `json:"volume,omitempty"`   // default TTS gain, 0 = normal
+}
+
+// Place describes where the device stands, e.g. "корп. А, 2 этаж, Приёмное отделение, каб. 3"
+func (d *Device) Place() string {
+	var parts []string
+	if d.Building != "" {
+		parts = append(parts, "корп. "+d.Building)
+	}
+	if d.Floor != "" {
+		parts = append(parts, d.Floor+" этаж")
+	}
+	if d.Location != "" {
+		parts = append(parts, d.Location)
+	}
+	if d.Room != "" {
+		parts = append(parts, "каб. "+d.Room)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pacing fills the speech settings the request left open with the device defaults
+func (d *Device) pacing(p *Pacing) *Pacing {
+	if d.Persona == "" && d.Volume == 0 {
+		return p
+	}
+	if p == nil {
+		p = &Pacing{}
+	}
+	if p.Voice == "" {
+		p.Voice = d.Persona
+	}
+	if p.Volume == 0 {
+		p.Volume = d.Volume
+	}
+	return p
+}
+
+type deviceKey struct{}
+
+// WithDevice attaches the authenticated kiosk to the request context
+func WithDevice(ctx context.Context, d *Device) context.Context {
+	return context.WithValue(ctx, deviceKey{}, d)
+}
+
+// DeviceFromContext returns the kiosk the request came from, if it sent a device key
+func DeviceFromContext(ctx context.Context) (*Device, bool) {
+	d, ok := ctx.Value(deviceKey{}).(*Device)
+	return d, ok
+}
@@ -0,0 +1,116 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidQuestion = errors.New("invalid question")
+
+const maxDoctorQuestionLength = 500
+
+// Where a doctor's question came from
+const (
+	QuestionViaAPI      = "api"
+	QuestionViaTelegram = "telegram"
+)
+
+// DoctorQuestion is a question a doctor following the interview asked the
+// assistant to put to the patient. It is stored in its own table, so turn
+// and background saves cannot drop it.
+type DoctorQuestion struct {
+	ID         uuid.UUID  `json:"id"`
+	Text       string     `json:"text"`
+	AuthorID   uuid.UUID  `json:"author_id"`
+	Author     string     `json:"author"`
+	Via        string     `json:"via"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AskedAt    *time.Time `json:"asked_at,omitempty"` // when the Communicator put it to the patient
+	Answer     string     `json:"answer,omitempty"`   // the patient's next message
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
+
+// AskDoctorQuestion queues a question for the Communicator's next turn.
+// Questions are asked one per turn, in the order they came in.
+func (s *service) AskDoctorQuestion(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author, via, text string) (*DoctorQuestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxDoctorQuestionLength {
+		return nil, ErrInvalidQuestion
+	}
+	if author == "" {
+		author = "unknown"
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsComplete {
+		return nil, ErrConsultationComplete
+	}
+
+	q := &DoctorQuestion{ID: uuid.New(), Text: text, AuthorID: authorID, Author: author, Via: via, CreatedAt: time.Now()}
+	if err := s.repo.AddDoctorQuestion(ctx, c.ID, *q); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Doctor %s asked a question in consultation %s via %s\n", author, c.ID, via)
+	return q, nil
+}
+
+// pendingDoctorQuestion is the oldest question not put to the patient yet
+func (c *Consultation) pendingDoctorQuestion() *DoctorQuestion {
+	for i := range c.DoctorQuestions {
+		if c.DoctorQuestions[i].AskedAt == nil {
+			return &c.DoctorQuestions[i]
+		}
+	}
+	return nil
+}
+
+// awaitingDoctorAnswer is the question asked in the assistant's last message, if any
+func (c *Consultation) awaitingDoctorAnswer() *DoctorQuestion {
+	for i := range c.DoctorQuestions {
+		q := &c.DoctorQuestions[i]
+		if q.AskedAt != nil && q.AnsweredAt == nil {
+			return q
+		}
+	}
+	return nil
+}
+
+// answerDoctorQuestion takes the patient's message just added to the History
+// as the answer to the doctor's question from the previous turn
+func (s *service) answerDoctorQuestion(ctx context.Context, c *Consultation) {
+	q := c.awaitingDoctorAnswer()
+	if q == nil || len(c.History) == 0 {
+		return
+	}
+	msg := &c.History[len(c.History)-1]
+	now := time.Now()
+	q.Answer, q.AnsweredAt = msg.Content, &now
+	msg.RequestedBy = q.Author
+	if err := s.repo.UpdateDoctorQuestion(ctx, c.ID, *q); err != nil {
+		fmt.Printf("Failed to save answer to doctor question %s: %v\n", q.ID, err)
+	}
+}
+
+// doctorQuestionAsked marks the question passed to the Communicator this turn
+// as asked by the assistant's reply just added to the History
+func (s *service) doctorQuestionAsked(ctx context.Context, c *Consultation) {
+	q := c.pendingDoctorQuestion()
+	if q == nil || len(c.History) == 0 {
+		return
+	}
+	msg := &c.History[len(c.History)-1]
+	now := time.Now()
+	q.AskedAt = &now
+	msg.RequestedBy = q.Author
+	if err := s.repo.UpdateDoctorQuestion(ctx, c.ID, *q); err != nil {
+		fmt.Printf("Failed to mark doctor question %s as asked: %v\n", q.ID, err)
+	}
+}
@@ -0,0 +1,35 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// An unfinished consultation counts as open for the same window as the board
+const duplicateWindow = boardWindow
+
+// DuplicateError is returned when the patient already has an open consultation
+// and the caller asked for neither resume nor force
+type DuplicateError struct {
+	Existing *Consultation
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("patient already has an open consultation %s", e.Existing.ID)
+}
+
+// DuplicateConsultationResponse is the 409 body of a duplicate create
+type DuplicateConsultationResponse struct {
+	Error          string    `json:"error"` // always "duplicate_consultation"
+	ConsultationID string    `json:"consultation_id"`
+	Ticket         string    `json:"ticket,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// openConsultation returns the patient's latest unfinished consultation, or nil
+func (s *service) openConsultation(ctx context.Context, patientID uuid.UUID) (*Consultation, error) {
+	return s.repo.OpenConsultation(ctx, patientID, time.Now().Add(-duplicateWindow))
+}
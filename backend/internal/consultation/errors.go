@@ -0,0 +1,53 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// The service layer wraps these so handlers answer with a specific status
+// instead of a 500 and clients can tell the cases apart by X-Error-Code
+var (
+	ErrConsultationNotFound = errors.New("consultation not found")
+	// ErrConsultationComplete is returned for actions that need the interview still running
+	ErrConsultationComplete = errors.New("consultation is already complete")
+	// ErrLLMUnavailable is returned when every LLM provider failed
+	ErrLLMUnavailable = errors.New("language model unavailable")
+	// ErrSTTEmpty is returned when no speech was recognized in the audio
+	ErrSTTEmpty = errors.New("no speech recognized")
+)
+
+// ErrorStatus maps a service error to its HTTP status and error code, 500
+// and "internal" for anything unrecognized
+func ErrorStatus(err error) (int, string) {
+	var reqErr *requestError
+	var inputErr *InputError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, ErrErased):
+		return http.StatusGone, "erased"
+	case errors.Is(err, ErrConsultationNotFound):
+		return http.StatusNotFound, "consultation_not_found"
+	case errors.Is(err, ErrConsultationComplete):
+		return http.StatusConflict, "consultation_complete"
+	case errors.Is(err, ErrLLMUnavailable):
+		return http.StatusServiceUnavailable, "llm_unavailable"
+	case errors.Is(err, ErrSTTEmpty):
+		return http.StatusUnprocessableEntity, "stt_empty"
+	case errors.As(err, &inputErr):
+		return http.StatusUnprocessableEntity, inputErr.Code
+	case errors.As(err, &reqErr):
+		return reqErr.status, "bad_request"
+	}
+	return http.StatusInternalServerError, "internal"
+}
+
+// WriteError responds with the status ErrorStatus picks for err. The body
+// stays plain text; the code goes in the X-Error-Code header.
+func WriteError(w http.ResponseWriter, msg string, err error) {
+	status, code := ErrorStatus(err)
+	w.Header().Set("X-Error-Code", code)
+	http.Error(w, msg, status)
+}
@@ -0,0 +1,449 @@
+package consultation
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a change recorded in the consultation's event log
+type EventType string
+
+const (
+	EventCreated         EventType = "created"          // full initial state
+	EventMessageAppended EventType = "message_appended" // one Message
+	EventFactAdded       EventType = "fact_added"       // one MedicalFact
+	EventNegativeAdded   EventType = "negative_added"   // one PertinentNegative
+	EventMoodChanged     EventType = "mood_changed"     // the new EmotionalState
+	EventStateChanged    EventType = "state_changed"    // the new is_complete
+	EventFieldChanged    EventType = "field_changed"    // FieldChange, any other change
+)
+
+// Event is an immutable record of one change to a consultation
+type Event struct {
+	Seq            int64           `json:"seq"`
+	ConsultationID uuid.UUID       `json:"consultation_id"`
+	Type           EventType       `json:"type"`
+	Data           json.RawMessage `json:"data"`
+	At             time.Time       `json:"at"`
+}
+
+// FieldChange replaces one field, named by its JSON key; a nil Value clears it
+type FieldChange struct {
+	Field string          `json:"field"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Keys appended to one element at a time when the new value extends the old
+var appendEvents = map[string]EventType{
+	"history":   EventMessageAppended,
+	"facts":     EventFactAdded,
+	"negatives": EventNegativeAdded,
+}
+
+// Keys never recorded: kept in their own tables or implied by the events
+var eventSkipped = map[string]bool{
+	"links":            true,
+	"slow_turns":       true,
+	"abuse_incidents":  true,
+	"red_flags":        true,
+	"vitals":           true,
+	"doctor_questions": true,
+	"updated_at":       true,
+}
+
+// Keys recorded only by their dedicated setters, since Save does not write them
+var setterOnly = map[string]bool{
+	"chief_complaint":      true,
+	"queued_questions":     true,
+	"assignment":           true,
+	"report_dispatched_at": true,
+	"acknowledgment":       true,
+	"bridge":               true,
+	"config":               true,
+}
+
+// eventState is a consultation as JSON fields, the form events apply to
+type eventState map[string]json.RawMessage
+
+func stateOf(c *Consultation) (eventState, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var state eventState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	for key := range eventSkipped {
+		delete(state, key)
+	}
+	return state, nil
+}
+
+func (s eventState) apply(e Event) error {
+	switch e.Type {
+	case EventCreated:
+		var created eventState
+		if err := json.Unmarshal(e.Data, &created); err != nil {
+			return err
+		}
+		for key, value := range created {
+			s[key] = value
+		}
+	case EventMessageAppended, EventFactAdded, EventNegativeAdded:
+		for key, t := range appendEvents {
+			if t != e.Type {
+				continue
+			}
+			var items []json.RawMessage
+			if raw := s[key]; len(raw) > 0 {
+				if err := json.Unmarshal(raw, &items); err != nil {
+					return err
+				}
+			}
+			data, err := json.Marshal(append(items, e.Data))
+			if err != nil {
+				return err
+			}
+			s[key] = data
+		}
+	case EventMoodChanged:
+		s["mood"] = e.Data
+	case EventStateChanged:
+		s["is_complete"] = e.Data
+	case EventFieldChanged:
+		var change FieldChange
+		if err := json.Unmarshal(e.Data, &change); err != nil {
+			return err
+		}
+		if change.Value == nil || string(change.Value) == "null" {
+			delete(s, change.Field)
+		} else {
+			s[change.Field] = change.Value
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// diff returns the events that turn s into next, in key order so the same
+// change is always recorded the same way
+func (s eventState) diff(next eventState) []Event {
+	var events []Event
+	for _, key := range slices.Sorted(maps.Keys(next)) {
+		value := next[key]
+		old, existed := s[key]
+		if existed && bytes.Equal(old, value) {
+			continue
+		}
+		switch {
+		case key == "mood":
+			events = append(events, Event{Type: EventMoodChanged, Data: value})
+		case key == "is_complete":
+			events = append(events, Event{Type: EventStateChanged, Data: value})
+		case appendEvents[key] != "":
+			added, ok := appended(old, value)
+			if !ok {
+				events = append(events, fieldChanged(key, value))
+				break
+			}
+			for _, item := range added {
+				events = append(events, Event{Type: appendEvents[key], Data: item})
+			}
+		default:
+			events = append(events, fieldChanged(key, value))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(s)) {
+		if _, ok := next[key]; !ok {
+			events = append(events, fieldChanged(key, nil))
+		}
+	}
+	return events
+}
+
+// appended returns the items next adds to the end of old; ok is false when
+// next does not keep old as its prefix
+func appended(old, next json.RawMessage) ([]json.RawMessage, bool) {
+	var oldItems, nextItems []json.RawMessage
+	if len(old) > 0 {
+		if err := json.Unmarshal(old, &oldItems); err != nil {
+			return nil, false
+		}
+	}
+	if err := json.Unmarshal(next, &nextItems); err != nil || len(nextItems) < len(oldItems) {
+		return nil, false
+	}
+	for i := range oldItems {
+		if !bytes.Equal(oldItems[i], nextItems[i]) {
+			return nil, false
+		}
+	}
+	return nextItems[len(oldItems):], true
+}
+
+// Rebuild replays a consultation's events in order
+func Rebuild(events []Event) (*Consultation, error) {
+	state := eventState{}
+	for _, e := range events {
+		if err := state.apply(e); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.Seq, err)
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var c Consultation
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		c.UpdatedAt = events[len(events)-1].At
+	}
+	return &c, nil
+}
+
+// eventSourcedRepo records every change as an event in consultation_events
+// and rebuilds consultations from them on read. The consultations table is
+// still written as a projection for lists, stats and search.
+type eventSourcedRepo struct {
+	Repository
+	db *sql.DB
+}
+
+// NewEventSourcedRepository wraps the projection repository with an
+// append-only event log
+func NewEventSourcedRepository(db *sql.DB, projection Repository) Repository {
+	return &eventSourcedRepo{Repository: projection, db: db}
+}
+
+// GetByID rebuilds the consultation from its events. Data kept in separate
+// tables comes from the projection. A consultation created before the event
+// log was enabled has no events until its next save and is read as is.
+func (r *eventSourcedRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation, error) {
+	projected, err := r.Repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := r.Events(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return projected, nil
+	}
+
+	c, err := Rebuild(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild consultation %s: %w", id, err)
+	}
+	c.Links, c.Tags, c.Notes, c.SlowTurns = projected.Links, projected.Tags, projected.Notes, projected.SlowTurns
+	c.AbuseIncidents, c.Vitals, c.DoctorQuestions = projected.AbuseIncidents, projected.Vitals, projected.DoctorQuestions
+	c.RedFlags = projected.RedFlags
+	return c, nil
+}
+
+// Events returns the consultation's event log in order
+func (r *eventSourcedRepo) Events(ctx context.Context, id uuid.UUID) ([]Event, error) {
+	return r.events(ctx, r.db, id)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (r *eventSourcedRepo) events(ctx context.Context, q queryer, id uuid.UUID) ([]Event, error) {
+	rows, err := q.QueryContext(ctx, `SELECT seq, consultation_id, type, data, created_at FROM consultation_events WHERE consultation_id = $1 ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var data []byte
+		if err := rows.Scan(&e.Seq, &e.ConsultationID, &e.Type, &data, &e.At); err != nil {
+			return nil, err
+		}
+		e.Data = data
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Save writes the projection first, so the ticket and timestamps it assigns
+// are part of the record, then appends the difference from the replayed state
+func (r *eventSourcedRepo) Save(ctx context.Context, c *Consultation) error {
+	if err := r.Repository.Save(ctx, c); err != nil {
+		return err
+	}
+	next, err := stateOf(c)
+	if err != nil {
+		return err
+	}
+	return r.append(ctx, c.ID, func(state eventState, empty bool) ([]Event, error) {
+		// The first event captures everything, including the state of a
+		// consultation created before the event log was enabled
+		if empty {
+			return []Event{{Type: EventCreated, Data: mustMarshal(next)}}, nil
+		}
+		for key := range setterOnly {
+			delete(next, key)
+			if value, ok := state[key]; ok {
+				next[key] = value
+			}
+		}
+		return state.diff(next), nil
+	})
+}
+
+func (r *eventSourcedRepo) SetQueuedQuestions(ctx context.Context, consultationID uuid.UUID, questions []string) error {
+	if err := r.Repository.SetQueuedQuestions(ctx, consultationID, questions); err != nil {
+		return err
+	}
+	value := json.RawMessage(mustMarshal(questions))
+	if len(questions) == 0 {
+		value = nil
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		if bytes.Equal(state["queued_questions"], value) {
+			return nil, nil
+		}
+		return []Event{fieldChanged("queued_questions", value)}, nil
+	})
+}
+
+func (r *eventSourcedRepo) SetAssignment(ctx context.Context, consultationID uuid.UUID, a Assignment) error {
+	if err := r.Repository.SetAssignment(ctx, consultationID, a); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		return []Event{fieldChanged("assignment", mustMarshal(a))}, nil
+	})
+}
+
+// MarkReportDispatched keeps the first-wins rule of the projection
+func (r *eventSourcedRepo) MarkReportDispatched(ctx context.Context, consultationID uuid.UUID, at time.Time) error {
+	if err := r.Repository.MarkReportDispatched(ctx, consultationID, at); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		if _, ok := state["report_dispatched_at"]; ok {
+			return nil, nil
+		}
+		return []Event{fieldChanged("report_dispatched_at", mustMarshal(at))}, nil
+	})
+}
+
+func (r *eventSourcedRepo) SetAcknowledgment(ctx context.Context, consultationID uuid.UUID, a Acknowledgment) error {
+	if err := r.Repository.SetAcknowledgment(ctx, consultationID, a); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		return []Event{fieldChanged("acknowledgment", mustMarshal(a))}, nil
+	})
+}
+
+func (r *eventSourcedRepo) SetBridge(ctx context.Context, consultationID uuid.UUID, b Bridge) error {
+	if err := r.Repository.SetBridge(ctx, consultationID, b); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		return []Event{fieldChanged("bridge", mustMarshal(b))}, nil
+	})
+}
+
+func (r *eventSourcedRepo) SetConfigStamp(ctx context.Context, consultationID uuid.UUID, stamp ConfigStamp) error {
+	if err := r.Repository.SetConfigStamp(ctx, consultationID, stamp); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		return []Event{fieldChanged("config", mustMarshal(stamp))}, nil
+	})
+}
+
+func (r *eventSourcedRepo) AppendMessage(ctx context.Context, consultationID uuid.UUID, m Message) error {
+	if err := r.Repository.AppendMessage(ctx, consultationID, m); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		return []Event{{Type: EventMessageAppended, Data: mustMarshal(m)}}, nil
+	})
+}
+
+// SetChiefComplaint keeps the first-wins rule of the projection
+func (r *eventSourcedRepo) SetChiefComplaint(ctx context.Context, consultationID uuid.UUID, complaint ChiefComplaint) error {
+	if err := r.Repository.SetChiefComplaint(ctx, consultationID, complaint); err != nil {
+		return err
+	}
+	return r.append(ctx, consultationID, func(state eventState, empty bool) ([]Event, error) {
+		if _, ok := state["chief_complaint"]; ok {
+			return nil, nil
+		}
+		return []Event{fieldChanged("chief_complaint", mustMarshal(complaint))}, nil
+	})
+}
+
+// append replays the log and stores the events change returns. Appends to one
+// consultation are serialized with an advisory lock so each diff sees the
+// events before it.
+func (r *eventSourcedRepo) append(ctx context.Context, id uuid.UUID, change func(state eventState, empty bool) ([]Event, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, id.String()); err != nil {
+		return err
+	}
+	past, err := r.events(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	state := eventState{}
+	for _, e := range past {
+		if err := state.apply(e); err != nil {
+			return fmt.Errorf("event %d: %w", e.Seq, err)
+		}
+	}
+
+	events, err := change(state, len(past) == 0)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	for _, e := range events {
+		_, err := tx.ExecContext(ctx, `INSERT INTO consultation_events (consultation_id, type, data) VALUES ($1, $2, $3)`, id, e.Type, []byte(e.Data))
+		if err != nil {
+			return fmt.Errorf("failed to append %s event: %w", e.Type, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func fieldChanged(field string, value json.RawMessage) Event {
+	return Event{Type: EventFieldChanged, Data: mustMarshal(FieldChange{Field: field, Value: value})}
+}
+
+// mustMarshal is for values built from already-marshalled JSON
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
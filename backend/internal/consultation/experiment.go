@@ -0,0 +1,56 @@
+package consultation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/flags"
+)
+
+// Experiments splits consultations between Communicator variants for A/B tests
+type Experiments interface {
+	Assign(consultationID uuid.UUID) (experiment, arm string)
+	Variant(experiment, arm string) (Variant, bool)
+}
+
+// Variant overrides the Communicator's model settings for an experiment arm.
+// Zero values keep the defaults.
+type Variant struct {
+	Model       string
+	Temperature float64
+	Prompt      string // extra system prompt instructions
+}
+
+// ArmStats are the outcome metrics of one arm. Turns and supervisor rounds
+// are averaged over completed consultations, the score over QA-reviewed ones.
+type ArmStats struct {
+	Arm                 string  `json:"arm"`
+	Consultations       int     `json:"consultations"`
+	Completed           int     `json:"completed"`
+	AvgTurns            float64 `json:"avg_turns_to_completion"`
+	AvgSupervisorRounds float64 `json:"avg_supervisor_rounds"`
+	Reviewed            int     `json:"reviewed"`
+	AvgQualityScore     float64 `json:"avg_quality_score"`
+}
+
+// ExperimentStats compares the arms of one experiment side by side
+type ExperimentStats struct {
+	Experiment string     `json:"experiment"`
+	Arms       []ArmStats `json:"arms"`
+}
+
+// interview returns the Communicator settings, including the experiment arm's
+// variant and the after-hours script while the clinic is closed
+func (s *service) interview(c *Consultation) Interview {
+	iv := c.Interview()
+	if c.Experiment != "" && s.experiments != nil {
+		iv.Variant, _ = s.experiments.Variant(c.Experiment, c.Arm)
+	}
+	iv.AfterHours = s.hours.afterHoursScript(time.Now())
+	iv.Tools = s.flags.Enabled(flags.CommunicatorTools, c.ID)
+	if s.supervisor.Veto {
+		iv.Continue = c.vetoedFarewell()
+	}
+	return iv
+}
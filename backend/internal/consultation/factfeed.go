@@ -0,0 +1,67 @@
+package consultation
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// factFeed pushes facts to open streams as soon as the background Analyst
+// extracts them, so live views build the fact sheet during the interview
+type factFeed struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan MedicalFact]struct{}
+}
+
+// Facts are dropped for subscribers that fall this far behind; they still get
+// them from the saved consultation
+const factFeedBuffer = 32
+
+func newFactFeed() *factFeed {
+	return &factFeed{subs: make(map[uuid.UUID]map[chan MedicalFact]struct{})}
+}
+
+// SubscribeFacts returns new facts of the consultation until the returned func is called
+func (s *service) SubscribeFacts(consultationID uuid.UUID) (<-chan MedicalFact, func()) {
+	return s.facts.subscribe(consultationID)
+}
+
+func (f *factFeed) subscribe(id uuid.UUID) (<-chan MedicalFact, func()) {
+	ch := make(chan MedicalFact, factFeedBuffer)
+	f.mu.Lock()
+	if f.subs[id] == nil {
+		f.subs[id] = make(map[chan MedicalFact]struct{})
+	}
+	f.subs[id][ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subs[id], ch)
+		if len(f.subs[id]) == 0 {
+			delete(f.subs, id)
+		}
+	}
+}
+
+// publish never blocks the Analyst on a slow stream
+func (f *factFeed) publish(id uuid.UUID, facts []MedicalFact) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs[id] {
+		for _, fact := range facts {
+			select {
+			case ch <- fact:
+			default:
+			}
+		}
+	}
+}
+
+// factEvent is the "fact" stream event; Data holds the fact as JSON
+func factEvent(fact MedicalFact) StreamEvent {
+	data, _ := json.Marshal(fact)
+	return StreamEvent{Type: "fact", Data: string(data)}
+}
@@ -0,0 +1,57 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReportSize bounds the fact list the doctor gets. Above MaxFacts the report
+// carries an AI summary of at most SummaryFacts facts instead of the full list.
+type ReportSize struct {
+	MaxFacts     int // 0 turns the summary off
+	SummaryFacts int
+}
+
+var DefaultReportSize = ReportSize{
+	MaxFacts:     40,
+	SummaryFacts: 20,
+}
+
+// GeneralFacts are the facts listed under "Собранные факты": those not
+// reported in the epidemiological, checklist or vitals sections
+func (c *Consultation) GeneralFacts() []MedicalFact {
+	var facts []MedicalFact
+	for _, f := range c.ExtractedFacts {
+		if c.isEpidFact(f) || c.RequiredFieldOf(f) != nil || f.Category == VitalsCategory {
+			continue
+		}
+		facts = append(facts, f)
+	}
+	return facts
+}
+
+func (c *Consultation) isEpidFact(f MedicalFact) bool {
+	for _, t := range c.EpidTopics {
+		if strings.EqualFold(t.Category, f.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeFacts refreshes the report's fact summary. Below the threshold, or
+// when the summary fails, the report falls back to the full list.
+func (s *service) summarizeFacts(ctx context.Context, c *Consultation) {
+	c.FactSummary = nil
+	facts := c.GeneralFacts()
+	if s.reportSize.MaxFacts <= 0 || len(facts) <= s.reportSize.MaxFacts {
+		return
+	}
+	summary, err := s.aiClient.SummarizeFacts(ctx, facts, s.reportSize.SummaryFacts)
+	if err != nil {
+		fmt.Printf("Failed to summarize %d facts for consultation %s: %v\n", len(facts), c.ID, err)
+		return
+	}
+	c.FactSummary = summary
+}
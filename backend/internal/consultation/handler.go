@@ -1,23 +1,57 @@
 package consultation
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/openapi"
 )
 
+// Timeouts bounds how long each kind of endpoint may run. The deadline is
+// propagated through the request context down to the LLM, STT and TTS calls.
+type Timeouts struct {
+	Request time.Duration // consultation creation, TTS
+	Turn    time.Duration // a full chat/audio turn: STT + LLM + TTS
+	Stream  time.Duration // the whole SSE response of a streamed turn
+	// Turn-latency target, not a deadline: slower turns get a diagnostic
+	// breakdown attached to the consultation. Zero disables it.
+	SLO time.Duration
+	// Patient silence after which /watch receives a re-engagement prompt.
+	// Zero disables it.
+	Silence time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Request: 30 * time.Second,
+	Turn:    90 * time.Second,
+	Stream:  3 * time.Minute,
+	SLO:     8 * time.Second,
+	Silence: 2 * time.Minute,
+}
+
 type Handler struct {
-	svc Service
+	svc        Service
+	limits     Limits
+	timeouts   Timeouts
+	sessions   *SessionSigner
+	patientBot string
+	activity   *patientActivity
+	turns      *turnLogs
 }
 
-func NewHandler(svc Service) *Handler {
-	return &Handler{svc: svc}
+// NewHandler takes the username of the Telegram bot that sends patients
+// their certificate; empty leaves only the download link
+func NewHandler(svc Service, limits Limits, timeouts Timeouts, sessions *SessionSigner, patientBot string) *Handler {
+	return &Handler{svc: svc, limits: limits, timeouts: timeouts, sessions: sessions, patientBot: patientBot, activity: newPatientActivity(), turns: newTurnLogs()}
 }
 
 type AudioInputRequest struct {
@@ -26,7 +60,79 @@ type AudioInputRequest struct {
 }
 
 type CreateConsultationRequest struct {
-	PatientID string `json:"patient_id"`
+	PatientID  string        `json:"patient_id"`
+	Mode       InterviewMode `json:"mode,omitempty"` // "standard" (default) or "medication_reconciliation"
+	Pediatric  bool          `json:"pediatric,omitempty"`
+	Pacing     *Pacing       `json:"pacing,omitempty"`     // "elderly" or a settings object
+	Department string        `json:"department,omitempty"` // selects the required-information profile, e.g. "surgery"
+	// Doctors from the registry who also get the report, e.g. the referring GP
+	Recipients []ReportRecipient `json:"recipients,omitempty"`
+	// With an open consultation for the patient the request fails with 409
+	// unless Resume (continue the open one) or Force (start another) is set
+	Resume bool `json:"resume,omitempty"`
+	Force  bool `json:"force,omitempty"`
+}
+
+type CreateConsultationResponse struct {
+	ConsultationID string    `json:"consultation_id"`
+	SessionToken   string    `json:"session_token"`
+	ExpiresAt      time.Time `json:"session_expires_at"`
+	Ticket         string    `json:"ticket,omitempty"`   // queue number shown on the waiting-room board
+	Resumed        bool      `json:"resumed,omitempty"`  // an open consultation was returned instead of a new one
+	Language       string    `json:"language,omitempty"` // the kiosk's default UI language
+	// The assistant's introduction, which a new consultation starts with; the
+	// audio is a base64 WAV, absent in text-only mode
+	Opening      string `json:"opening,omitempty"`
+	OpeningAudio string `json:"opening_audio,omitempty"`
+}
+
+type TranscriptResponse struct {
+	ConsultationID string    `json:"consultation_id"`
+	Messages       []Message `json:"messages"`
+	IsComplete     bool      `json:"is_complete"`
+}
+
+// WatchEvent is pushed by the watch stream whenever the consultation changes
+type WatchEvent struct {
+	Messages   int            `json:"messages"`
+	Facts      int            `json:"facts"`
+	Mood       EmotionalState `json:"mood"`
+	IsComplete bool           `json:"is_complete"`
+}
+
+type QuestionnaireRequest struct {
+	Instrument  string    `json:"instrument"` // "phq9", "gad7" or "pain_nrs"
+	Answers     []int     `json:"answers"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+}
+
+type VoiceRequest struct {
+	Voice string `json:"voice"` // "male", "female" or a Silero speaker (kseniya, xenia, baya, aidar, eugene)
+}
+
+type VoiceResponse struct {
+	Voice string `json:"voice"` // the speaker now in use
+}
+
+type ChatResponse struct {
+	Response string       `json:"response"`
+	Screen   string         `json:"screen,omitempty"` // short on-screen version, see Pacing.ScreenText
+	Keys     []KeypadOption `json:"keys,omitempty"`   // keypad answers to the question, see Pacing.Keypad
+	Command  VoiceCommand `json:"command,omitempty"` // voice command handled instead of a normal turn, e.g. "text_only"
+}
+
+// AudioUploadForm documents the multipart fields of the audio endpoints
+type AudioUploadForm struct {
+	ConsultationID string `json:"consultation_id"`
+	Audio          []byte `json:"audio" format:"binary"`
+}
+
+type AudioResponse struct {
+	Response    string       `json:"response"`
+	Text        string       `json:"text"`
+	AudioBase64 string       `json:"audio_base64,omitempty"`
+	Command     VoiceCommand `json:"command,omitempty"`
+	Screen      string       `json:"screen,omitempty"`
 }
 
 func (h *Handler) CreateConsultation(w http.ResponseWriter, r *http.Request) {
@@ -42,212 +148,610 @@ func (h *Handler) CreateConsultation(w http.ResponseWriter, r *http.Request) {
 		pid = uuid.New()
 	}
 
-	c, err := h.svc.CreateConsultation(r.Context(), pid)
+	if req.Mode == "" {
+		req.Mode = ModeStandard
+	}
+	if !ValidMode(req.Mode) {
+		http.Error(w, "Invalid mode", http.StatusBadRequest)
+		return
+	}
+	if req.Pacing != nil {
+		if err := req.Pacing.Validate(); err != nil {
+			http.Error(w, "Invalid pacing", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Department != "" && !ValidDepartment(req.Department) {
+		http.Error(w, "Invalid department", http.StatusBadRequest)
+		return
+	}
+
+	if err := ValidateRecipients(req.Recipients); err != nil {
+		http.Error(w, "Invalid recipients: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Resume && req.Force {
+		http.Error(w, "resume and force are mutually exclusive", http.StatusBadRequest)
+		return
+	}
+
+	interview := Interview{Mode: req.Mode, Pediatric: req.Pediatric, Pacing: req.Pacing, Department: req.Department, Recipients: req.Recipients}
+	var language string
+	if device, ok := DeviceFromContext(r.Context()); ok {
+		interview.Device, interview.Pacing, language = device, device.pacing(req.Pacing), device.Language
+	}
+
+	resumed := false
+	c, err := h.svc.CreateConsultation(r.Context(), pid, interview, req.Force)
+	var dup *DuplicateError
+	if errors.As(err, &dup) {
+		if !req.Resume {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(DuplicateConsultationResponse{
+				Error:          "duplicate_consultation",
+				ConsultationID: dup.Existing.ID.String(),
+				Ticket:         FormatTicket(dup.Existing.Ticket),
+				CreatedAt:      dup.Existing.CreatedAt,
+			})
+			return
+		}
+		c, err, resumed = dup.Existing, nil, true
+	}
+	if errors.Is(err, ErrUnknownRecipient) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to create consultation", http.StatusInternalServerError)
+		writeServiceError(w, "Failed to create consultation", err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"consultation_id": c.ID.String(),
-	})
+	token, expires := h.sessions.Sign(c.ID)
+	if resumed {
+		// Resuming moves the session like a handoff, so the other device cannot keep talking
+		if token, expires, err = h.sessions.moveSession(r.Context(), c.ID); err != nil {
+			writeServiceError(w, "Failed to resume consultation", err)
+			return
+		}
+	}
+	resp := CreateConsultationResponse{
+		ConsultationID: c.ID.String(),
+		SessionToken:   token,
+		ExpiresAt:      expires,
+		Ticket:         FormatTicket(c.Ticket),
+		Resumed:        resumed,
+		Language:       language,
+	}
+	if !resumed {
+		var audio []byte
+		if resp.Opening, audio = h.svc.Opening(r.Context(), c); len(audio) > 0 {
+			resp.OpeningAudio = base64.StdEncoding.EncodeToString(audio)
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
-func (h *Handler) HandleVoiceInput(w http.ResponseWriter, r *http.Request) {
-	var req AudioInputRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+// GetBoard returns the waiting-room queue. Clients that accept text/event-stream
+// (e.g. EventSource on the TV display) get the board again whenever it changes.
+// ?building, ?floor and ?department limit it to one waiting area.
+func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	loc := LocationFromQuery(r.URL.Query())
+	if r.Header.Get("Accept") != "text/event-stream" {
+		board, err := h.svc.Board(r.Context(), loc)
+		if err != nil {
+			writeServiceError(w, "Failed to load board", err)
+			return
+		}
+		json.NewEncoder(w).Encode(board)
 		return
 	}
 
-	id, err := uuid.Parse(req.ConsultationID)
-	if err != nil {
-		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
-	
-	response, err := h.svc.ProcessUserAudio(r.Context(), id, req.Text)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		board, err := h.svc.Board(r.Context(), loc)
+		if err != nil {
+			sse.Send(StreamEvent{Type: "error", Data: err.Error()})
+			return
+		}
+		data, _ := json.Marshal(board)
+		if string(data) != last {
+			if err := sse.Send(StreamEvent{Type: "board", Data: string(data)}); err != nil {
+				return
+			}
+			last = string(data)
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if err := sse.keepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// The handlers below serve patient-facing resources and sit behind requireSession
+
+func (h *Handler) GetTranscript(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Processing failed: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, "Consultation not found", err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"response": response,
+	json.NewEncoder(w).Encode(TranscriptResponse{
+		ConsultationID: c.ID.String(),
+		Messages:       c.History,
+		IsComplete:     c.IsComplete,
 	})
 }
 
-type TTSRequest struct {
-	Text string `json:"text"`
-}
+// ImportQuestionnaire attaches a pre-visit questionnaire to the consultation
+func (h *Handler) ImportQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
 
-func (h *Handler) HandleTTS(w http.ResponseWriter, r *http.Request) {
-	var req TTSRequest
+	var req QuestionnaireRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	audioData, err := h.svc.SynthesizeSpeech(r.Context(), req.Text)
+	q, err := h.svc.ImportQuestionnaire(r.Context(), id, req.Instrument, req.Answers, req.CompletedAt)
 	if err != nil {
-		http.Error(w, "TTS failed: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrInvalidQuestionnaire) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeServiceError(w, "Failed to import questionnaire", err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "audio/mpeg")
-	w.Write(audioData)
+	json.NewEncoder(w).Encode(q)
 }
 
-func (h *Handler) HandleAudioUpload(w http.ResponseWriter, r *http.Request) {
-	// Limit upload size (e.g. 10MB)
-	r.ParseMultipartForm(10 << 20)
+// GetMessageAudio synthesizes an assistant message on demand, so replies can be replayed
+func (h *Handler) GetMessageAudio(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil {
+		http.Error(w, "Invalid message index", http.StatusBadRequest)
+		return
+	}
 
-	consultationIDStr := r.FormValue("consultation_id")
-	if consultationIDStr == "" {
-		http.Error(w, "Missing consultation_id", http.StatusBadRequest)
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, "Consultation not found", err)
+		return
+	}
+	if index < 0 || index >= len(c.History) || c.History[index].Role != "assistant" {
+		http.Error(w, "No assistant message at this index", http.StatusNotFound)
 		return
 	}
 
-	id, err := uuid.Parse(consultationIDStr)
+	audioData, err := h.svc.SynthesizeSpeech(r.Context(), c.History[index].Content, c.Pacing)
 	if err != nil {
-		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		writeServiceError(w, "TTS failed: "+err.Error(), err)
 		return
 	}
 
-	file, _, err := r.FormFile("audio")
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Write(audioData)
+}
+
+// GetRecording returns the session audio as one WAV file for clinical review
+func (h *Handler) GetRecording(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	audioData, skipped, err := h.svc.Recording(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Error retrieving audio file", http.StatusBadRequest)
+		if errors.Is(err, ErrNoRecording) {
+			http.Error(w, "No recording for this consultation", http.StatusNotFound)
+			return
+		}
+		writeServiceError(w, "Failed to build recording: "+err.Error(), err)
 		return
 	}
-	defer file.Close()
 
-	// Read audio bytes
-	// In a real app, we might stream this, but for now read into memory
-	// or pass the reader if the client supports it. 
-	// Our STTClient.Transcribe takes []byte currently.
-	// Let's read it.
-	// Note: For large files, this is bad practice, but for voice commands it's fine.
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, file); err != nil {
-		http.Error(w, "Failed to read audio file", http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "audio/wav")
+	// Uploads in formats other than PCM WAV are left out
+	w.Header().Set("X-Recording-Skipped", strconv.Itoa(skipped))
+	w.Write(audioData)
+}
+
+// SetVoice changes the assistant's voice for all following replies
+func (h *Handler) SetVoice(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	var req VoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	// 1. Transcribe
-	text, err := h.svc.TranscribeAudio(r.Context(), buf.Bytes())
+	c, err := h.svc.SetVoice(r.Context(), id, req.Voice)
 	if err != nil {
-		http.Error(w, "Transcription failed: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrInvalidVoice) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeServiceError(w, "Failed to set voice: "+err.Error(), err)
 		return
 	}
 
-	if text == "" {
-		// If silence or no speech detected
-		json.NewEncoder(w).Encode(map[string]string{
-			"response": "", 
-			"text": "",
-		})
+	json.NewEncoder(w).Encode(VoiceResponse{Voice: c.Pacing.Voice})
+}
+
+// Watch streams consultation progress as SSE until it completes or the client leaves
+func (h *Handler) Watch(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
-	// 2. Process as if it was text input
-	response, err := h.svc.ProcessUserAudio(r.Context(), id, text)
-	if err != nil {
-		http.Error(w, "Processing failed: "+err.Error(), http.StatusInternalServerError)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	moved, unsubscribe := h.sessions.subscribe(id)
+	defer unsubscribe()
+	facts, unsubscribeFacts := h.svc.SubscribeFacts(id)
+	defer unsubscribeFacts()
+
+	// Facts already extracted are replayed first; seen drops a fact that was
+	// published while the consultation was loading
+	seen := make(map[MedicalFact]bool)
+	sendFact := func(f MedicalFact) error {
+		key := MedicalFact{Category: f.Category, Description: f.Description}
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		return sse.Send(factEvent(f))
+	}
+
+	// With ?until=assignment the kiosk keeps the stream after the interview to
+	// hear the room staff assign
+	untilAssignment := r.URL.Query().Get("until") == "assignment"
+
+	var last WatchEvent
+	first := true
+	// Staff replies of a bridged consultation are voiced once they reach the History
+	relayed := -1
+	for {
+		c, err := h.svc.GetConsultation(r.Context(), id)
+		if err != nil {
+			sse.Send(StreamEvent{Type: "error", Data: err.Error()})
+			return
+		}
+		if first {
+			for _, f := range c.ExtractedFacts {
+				if err := sendFact(f); err != nil {
+					return
+				}
+			}
+		}
+
+		current := WatchEvent{
+			Messages:   len(c.History),
+			Facts:      len(c.ExtractedFacts),
+			Mood:       c.CurrentMood,
+			IsComplete: c.IsComplete,
+		}
+		if first || current != last {
+			data, _ := json.Marshal(current)
+			if err := sse.Send(StreamEvent{Type: "status", Data: string(data)}); err != nil {
+				return
+			}
+			first, last = false, current
+		}
+		if relayed >= 0 && relayed < len(c.History) {
+			if err := h.relayReplies(r.Context(), sse, c, c.History[relayed:]); err != nil {
+				return
+			}
+		}
+		relayed = len(c.History)
+		if c.IsComplete && untilAssignment && c.Assignment != nil {
+			if err := h.announceAssignment(r.Context(), sse, c); err != nil {
+				return
+			}
+			sse.Send(StreamEvent{Type: "done"})
+			return
+		}
+		if c.IsComplete && (!untilAssignment || c.Visit != nil) {
+			sse.Send(StreamEvent{Type: "done"})
+			return
+		}
+		if h.silent(c) {
+			if err := h.reEngage(r.Context(), sse, id); err != nil {
+				return
+			}
+		}
+
+	wait:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-moved:
+				sse.Send(StreamEvent{Type: "handoff"})
+				return
+			case f := <-facts:
+				if err := sendFact(f); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := sse.keepAlive(); err != nil {
+					return
+				}
+				break wait
+			}
+		}
+	}
+}
+
+func (h *Handler) HandleVoiceInput(w http.ResponseWriter, r *http.Request) {
+	var req AudioInputRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	// 3. Generate TTS immediately to save roundtrip time
-	var audioBase64 string
-	audioData, err := h.svc.SynthesizeSpeech(r.Context(), response)
-	if err == nil {
-		audioBase64 = base64.StdEncoding.EncodeToString(audioData)
+	id, err := uuid.Parse(req.ConsultationID)
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.sessions.checkChannel(r, id); err != nil {
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	h.activity.touch(id)
+	
+	ctx, timer := withTurnTimer(r.Context())
+	reply, err := h.svc.ProcessUserAudio(ctx, id, req.Text)
+	if err != nil {
+		writeServiceError(w, "Processing failed: "+err.Error(), err)
+		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"response":     response,
-		"text":         text,
-		"audio_base64": audioBase64,
+	json.NewEncoder(w).Encode(ChatResponse{
+		Response: reply.Text,
+		Command:  reply.Command,
+		Screen:   reply.Screen,
+		Keys:     reply.Keys,
 	})
+	h.recordTurn(ctx, id, timer)
 }
 
-func (h *Handler) HandleAudioUploadStream(w http.ResponseWriter, r *http.Request) {
-	// Limit upload size (e.g. 10MB)
-	r.ParseMultipartForm(10 << 20)
+type TTSRequest struct {
+	Text string `json:"text"`
+}
 
-	consultationIDStr := r.FormValue("consultation_id")
-	if consultationIDStr == "" {
-		http.Error(w, "Missing consultation_id", http.StatusBadRequest)
+func (h *Handler) HandleTTS(w http.ResponseWriter, r *http.Request) {
+	var req TTSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	id, err := uuid.Parse(consultationIDStr)
+	audioData, err := h.svc.SynthesizeSpeech(r.Context(), req.Text, nil)
 	if err != nil {
-		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		writeServiceError(w, "TTS failed: "+err.Error(), err)
 		return
 	}
 
-	file, _, err := r.FormFile("audio")
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Write(audioData)
+}
+
+func (h *Handler) HandleAudioUpload(w http.ResponseWriter, r *http.Request) {
+	ctx, timer := withTurnTimer(r.Context())
+
+	// 1. Transcribe (streams the multipart body straight into STT)
+	sttStart := time.Now()
+	id, transcript, err := h.transcribeUpload(r, nil)
+	timer.addSTT(sttStart)
 	if err != nil {
-		http.Error(w, "Error retrieving audio file", http.StatusBadRequest)
+		writeRequestError(w, err)
 		return
 	}
-	defer file.Close()
+	text := transcript.Text
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, file); err != nil {
-		http.Error(w, "Failed to read audio file", http.StatusInternalServerError)
+	if text == "" {
+		// If silence or no speech detected
+		json.NewEncoder(w).Encode(AudioResponse{})
 		return
 	}
 
-	// 1. Transcribe (Blocking)
-	text, err := h.svc.TranscribeAudio(r.Context(), buf.Bytes())
+	// 2. Process as if it was text input
+	reply, err := h.svc.ProcessUserAudio(withTranscript(ctx, transcript), id, text)
 	if err != nil {
-		http.Error(w, "Transcription failed: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, "Processing failed: "+err.Error(), err)
 		return
 	}
 
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	// 3. Generate TTS immediately to save roundtrip time
+	var audioBase64 string
+	switch {
+	case reply.Pacing.textOnly():
+	case len(reply.Audio) == 1:
+		// Replay of the cached reply
+		audioBase64 = base64.StdEncoding.EncodeToString(reply.Audio[0])
+	default:
+		if audioData, err := h.svc.Speak(ctx, id, reply.Text, reply.Pacing); err == nil {
+			audioBase64 = base64.StdEncoding.EncodeToString(audioData)
+		}
+	}
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(AudioResponse{
+		Response:    reply.Text,
+		Text:        text,
+		AudioBase64: audioBase64,
+		Command:     reply.Command,
+		Screen:      reply.Screen,
+	})
+	h.recordTurn(ctx, id, timer)
+}
+
+func (h *Handler) HandleAudioUploadStream(w http.ResponseWriter, r *http.Request) {
+	// 1. Transcribe (Blocking). Long audio opens the stream early to report progress.
+	var sse *sseWriter
+	progress := func(p STTProgress) {
+		if sse == nil {
+			if sse, _ = newSSEWriter(w); sse == nil {
+				return
+			}
+		}
+		data, _ := json.Marshal(p)
+		sse.Send(StreamEvent{Type: "stt_progress", Data: string(data)})
+	}
+	turnCtx, timer := withTurnTimer(r.Context())
+	sttStart := time.Now()
+	id, transcript, err := h.transcribeUpload(r, progress)
+	timer.addSTT(sttStart)
+	text := transcript.Text
+	if err != nil {
+		if sse != nil {
+			sse.Send(StreamEvent{Type: "error", Data: err.Error()})
+			return
+		}
+		writeRequestError(w, err)
 		return
 	}
 
-	// Send initial event with transcribed text
-	initData, _ := json.Marshal(StreamEvent{Type: "user_text", Data: text})
-	fmt.Fprintf(w, "data: %s\n\n", initData)
-	flusher.Flush()
+	if sse == nil {
+		var ok bool
+		if sse, ok = newSSEWriter(w); !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+	}
 
+	// The turn is logged with event IDs so a client that loses the connection
+	// can pick it up again from /consultation/{id}/stream
+	turn := h.turns.start(id)
+	turn.append(StreamEvent{Type: "user_text", Data: text})
 	if text == "" {
-		return
+		turn.finish()
+	} else {
+		go h.runTurn(withTranscript(turnCtx, transcript), id, text, timer, turn)
 	}
+	h.follow(r.Context(), sse, id, turn, 0)
+}
 
-	eventChan := make(chan StreamEvent)
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequestSize(h.limits.JSON))
+		r.With(withDeadline(h.timeouts.Request)).Post("/consultation", h.CreateConsultation)
+		r.With(withDeadline(h.timeouts.Turn)).Post("/consultation/chat", h.HandleVoiceInput)
+		r.With(withDeadline(h.timeouts.Turn)).Post("/consultation/keypad", h.HandleKeypad)
+		r.With(withDeadline(h.timeouts.Request)).Post("/tts", h.HandleTTS)
+		r.With(withDeadline(h.timeouts.Request)).Post("/consultation/handoff", h.ClaimHandoff)
+		r.With(withDeadline(h.timeouts.Request)).Post("/consultation/vitals", h.RecordVitals)
+	})
 
-	go func() {
-		defer close(eventChan)
-		err := h.svc.ProcessUserAudioStream(r.Context(), id, text, eventChan)
-		if err != nil {
-			eventChan <- StreamEvent{Type: "error", Data: err.Error()}
-		}
-	}()
+	r.Get("/board", h.GetBoard)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.sessions.requireSession)
+		r.With(withDeadline(h.timeouts.Request)).Get("/consultation/{id}/transcript", h.GetTranscript)
+		r.With(withDeadline(h.timeouts.Request)).Get("/consultation/{id}/messages/{index}/audio", h.GetMessageAudio)
+		r.With(withDeadline(h.timeouts.Request)).Get("/consultation/{id}/recording", h.GetRecording)
+		r.Get("/consultation/{id}/watch", h.Watch)
+		r.Get("/consultation/{id}/stream", h.ResumeStream)
+		r.With(withDeadline(h.timeouts.Request)).Post("/consultation/{id}/handoff", h.StartHandoff)
+		r.With(withDeadline(h.timeouts.Request)).Post("/consultation/{id}/certificate", h.IssueCertificate)
+		r.With(middleware.RequestSize(h.limits.JSON), withDeadline(h.timeouts.Request)).Post("/consultation/{id}/questionnaire", h.ImportQuestionnaire)
+		r.With(middleware.RequestSize(h.limits.JSON), withDeadline(h.timeouts.Request)).Put("/consultation/{id}/voice", h.SetVoice)
+		r.With(middleware.RequestSize(h.limits.Wearables), withDeadline(h.timeouts.Turn)).Post("/consultation/{id}/wearables", h.ImportWearables)
+	})
 
-	for event := range eventChan {
-		data, _ := json.Marshal(event)
-		fmt.Fprintf(w, "data: %s\n\n", data)
-		flusher.Flush()
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequestSize(h.limits.Audio))
+		r.With(withDeadline(h.timeouts.Turn)).Post("/consultation/audio", h.HandleAudioUpload)
+		r.With(withDeadline(h.timeouts.Stream)).Post("/consultation/audio/stream", h.HandleAudioUploadStream)
+	})
+}
+
+// withDeadline sets a deadline on the request context. Unlike middleware.Timeout
+// it never writes a response itself, which would break already started SSE streams.
+func withDeadline(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
 	}
 }
 
-func RegisterRoutes(r chi.Router, h *Handler) {
-	r.Post("/consultation", h.CreateConsultation)
-	r.Post("/consultation/chat", h.HandleVoiceInput)
-	r.Post("/consultation/audio", h.HandleAudioUpload)
-	r.Post("/consultation/audio/stream", h.HandleAudioUploadStream)
-	r.Post("/tts", h.HandleTTS)
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"consultation"}
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/api/consultation", Summary: "Start a new consultation", Tags: tags,
+			Request: CreateConsultationRequest{}, Response: CreateConsultationResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/chat", Summary: "Send a text message to the assistant", Tags: tags,
+			Request: AudioInputRequest{}, Response: ChatResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/keypad", Summary: "Answer the last question with a keypad key (DTMF digit, or * to repeat)", Tags: tags,
+			Request: KeypadRequest{}, Response: ChatResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/audio", Summary: "Upload a voice message and get the reply with synthesized audio", Tags: tags,
+			RequestType: "multipart/form-data", Request: AudioUploadForm{}, Response: AudioResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/audio/stream", Summary: "Upload a voice message and stream the reply as server-sent events", Tags: tags,
+			RequestType: "multipart/form-data", Request: AudioUploadForm{}, ResponseType: "text/event-stream", Response: StreamEvent{}},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/transcript", Summary: "Get the transcript (requires session token)", Tags: tags,
+			Response: TranscriptResponse{}},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/messages/{index}/audio", Summary: "Download an assistant message as audio (requires session token)", Tags: tags,
+			ResponseType: "audio/mpeg"},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/recording", Summary: "Download the session audio, patient and assistant merged in order (requires session token)", Tags: tags,
+			ResponseType: "audio/wav"},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/stream", Summary: "Resume a streamed turn after Last-Event-ID (requires session token)", Tags: tags,
+			ResponseType: "text/event-stream", Response: StreamEvent{}},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/watch", Summary: "Watch consultation progress as server-sent events; ?until=assignment keeps it open after the interview to announce the assigned room (requires session token)", Tags: tags,
+			ResponseType: "text/event-stream", Response: StreamEvent{}},
+		{Method: http.MethodPost, Path: "/api/consultation/vitals", Summary: "Record vitals from a waiting-room device by consultation ID or ticket (requires device key)", Tags: tags,
+			Request: VitalsRequest{}, Response: VitalsResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/{id}/wearables", Summary: "Import an Apple Health export (xml or zip) or a Google Fit response; ?format=apple_health|google_fit (requires session token)", Tags: tags,
+			RequestType: "application/octet-stream", Response: WearablesResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/{id}/questionnaire", Summary: "Import a pre-visit questionnaire such as PHQ-9 (requires session token)", Tags: tags,
+			Request: QuestionnaireRequest{}, Response: Questionnaire{}},
+		{Method: http.MethodPut, Path: "/api/consultation/{id}/voice", Summary: "Switch the assistant's voice for the rest of the consultation (requires session token)", Tags: tags,
+			Request: VoiceRequest{}, Response: VoiceResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/{id}/handoff", Summary: "Get a one-time code to continue the consultation on another device (requires session token)", Tags: tags,
+			Response: HandoffResponse{}},
+		{Method: http.MethodPost, Path: "/api/consultation/{id}/certificate", Summary: "Get the download link of the patient's certificate for a completed consultation, and a Telegram bot link when a patient bot is set (requires session token)", Tags: tags,
+			Response: CertificateResponse{}},
+		{Method: http.MethodGet, Path: "/api/certificate/{id}", Summary: "Download the patient's certificate with the signed ?token from the certificate link", Tags: []string{"certificate"},
+			ResponseType: "application/pdf"},
+		{Method: http.MethodGet, Path: "/api/certificate/{id}/verify", Summary: "Check the ?code printed on a patient's certificate", Tags: []string{"certificate"},
+			Response: CertificateVerification{}},
+		{Method: http.MethodPost, Path: "/api/consultation/handoff", Summary: "Claim a handoff code and take over the consultation session", Tags: tags,
+			Request: ClaimHandoffRequest{}, Response: CreateConsultationResponse{}},
+		{Method: http.MethodGet, Path: "/api/board", Summary: "Get the anonymized waiting-room queue and whether the clinic is open, of one waiting area with ?building, ?floor and ?department; with Accept: text/event-stream, stream it as server-sent events", Tags: []string{"board"},
+			Response: Board{}},
+		{Method: http.MethodPost, Path: "/api/tts", Summary: "Synthesize speech from text", Tags: []string{"speech"},
+			Request: TTSRequest{}, ResponseType: "audio/mpeg"},
+	}
 }
@@ -0,0 +1,199 @@
+package consultation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// A handoff moves a running consultation to another device, e.g. from a kiosk
+// to the patient's phone. The old device asks for a one-time code, the new one
+// claims it and gets a session token for the next channel; tokens and streams
+// of the old device stop working. The consultation itself is not touched.
+
+var errInvalidHandoff = errors.New("invalid or expired handoff code")
+
+const (
+	handoffTTL = 5 * time.Minute
+	// No 0/O and 1/I, the code is typed in by hand when the QR cannot be scanned
+	handoffAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	handoffCodeLen  = 8
+)
+
+type pendingHandoff struct {
+	consultationID uuid.UUID
+	expires        time.Time
+}
+
+type HandoffResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Path      string    `json:"path"` // open on the new device (or encode in a QR) to continue
+	// Continues the consultation in the patient bot's chat instead; only
+	// when the bot is configured
+	TelegramURL string `json:"telegram_url,omitempty"`
+}
+
+type ClaimHandoffRequest struct {
+	Code string `json:"code"`
+}
+
+func newHandoffCode() (string, error) {
+	buf := make([]byte, handoffCodeLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = handoffAlphabet[int(b)%len(handoffAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// startHandoff issues a code for consultationID, replacing earlier unclaimed ones
+func (s *SessionSigner) startHandoff(consultationID uuid.UUID) (string, time.Time, error) {
+	code, err := newHandoffCode()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(handoffTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, p := range s.handoffs {
+		if p.consultationID == consultationID || time.Now().After(p.expires) {
+			delete(s.handoffs, k)
+		}
+	}
+	s.handoffs[code] = pendingHandoff{consultationID: consultationID, expires: expires}
+	return code, expires, nil
+}
+
+// claimHandoff redeems a code once: the session moves to the next channel and
+// the old device's streams are closed
+func (s *SessionSigner) claimHandoff(ctx context.Context, code string) (uuid.UUID, string, time.Time, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	s.mu.Lock()
+	p, ok := s.handoffs[code]
+	delete(s.handoffs, code)
+	s.mu.Unlock()
+	if !ok || time.Now().After(p.expires) {
+		return uuid.Nil, "", time.Time{}, errInvalidHandoff
+	}
+
+	token, expires, err := s.moveSession(ctx, p.consultationID)
+	if err != nil {
+		return uuid.Nil, "", time.Time{}, err
+	}
+	return p.consultationID, token, expires, nil
+}
+
+// moveSession signs a token for a new device; earlier tokens stop working
+func (s *SessionSigner) moveSession(ctx context.Context, consultationID uuid.UUID) (string, time.Time, error) {
+	channel, err := s.channels.AdvanceSessionChannel(ctx, consultationID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	s.closeStreams(consultationID)
+
+	token, expires := s.signChannel(consultationID, channel)
+	return token, expires, nil
+}
+
+// subscribe returns a channel that is closed when the session is handed off;
+// call the returned func once the stream ends
+func (s *SessionSigner) subscribe(consultationID uuid.UUID) (<-chan struct{}, func()) {
+	ch := make(chan struct{})
+	s.mu.Lock()
+	if s.streams[consultationID] == nil {
+		s.streams[consultationID] = make(map[chan struct{}]struct{})
+	}
+	s.streams[consultationID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.streams[consultationID][ch]; ok {
+			delete(s.streams[consultationID], ch)
+			if len(s.streams[consultationID]) == 0 {
+				delete(s.streams, consultationID)
+			}
+		}
+	}
+}
+
+func (s *SessionSigner) closeStreams(consultationID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.streams[consultationID] {
+		close(ch)
+	}
+	delete(s.streams, consultationID)
+}
+
+// StartHandoff issues a one-time code to continue the consultation on another device
+func (h *Handler) StartHandoff(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	c, err := h.svc.GetConsultation(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, "Consultation not found", err)
+		return
+	}
+	if c.IsComplete {
+		writeServiceError(w, "Consultation is already complete", ErrConsultationComplete)
+		return
+	}
+
+	code, expires, err := h.sessions.startHandoff(id)
+	if err != nil {
+		http.Error(w, "Failed to create handoff code", http.StatusInternalServerError)
+		return
+	}
+	resp := HandoffResponse{
+		Code:      code,
+		ExpiresAt: expires,
+		Path:      "/?handoff=" + code,
+	}
+	if h.patientBot != "" {
+		resp.TelegramURL = fmt.Sprintf("https://t.me/%s?start=%s%s", h.patientBot, telegramHandoffPrefix, code)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ClaimHandoff binds the consultation to the calling device
+func (h *Handler) ClaimHandoff(w http.ResponseWriter, r *http.Request) {
+	var req ClaimHandoffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	id, token, expires, err := h.sessions.claimHandoff(r.Context(), req.Code)
+	if errors.Is(err, errInvalidHandoff) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, "Handoff failed", err)
+		return
+	}
+	resp := CreateConsultationResponse{
+		ConsultationID: id.String(),
+		SessionToken:   token,
+		ExpiresAt:      expires,
+	}
+	if c, err := h.svc.GetConsultation(r.Context(), id); err == nil {
+		resp.Ticket = FormatTicket(c.Ticket)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
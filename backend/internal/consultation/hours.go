@@ -0,0 +1,199 @@
+package consultation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkingHours is the clinic's schedule. Outside of it the Communicator
+// follows the after-hours script, critical alerts go to the on-call chain
+// instead of the day roster and the queue endpoints report the clinic closed.
+// A nil schedule is always open.
+type WorkingHours struct {
+	Timezone string `json:"timezone"` // IANA name, e.g. "Europe/Moscow"; empty is the server's zone
+	// Opening intervals by weekday ("mon" to "sun"), e.g. ["08:00-13:00", "14:00-20:00"].
+	// "20:00-08:00" runs into the next day; a day that is not listed is closed.
+	Week     map[string][]string `json:"week"`
+	Holidays []string            `json:"holidays,omitempty"` // closed dates, "2026-01-01"
+	// Told to the patient after hours: who sees them now and what to expect.
+	// Empty uses a generic script.
+	AfterHoursScript string `json:"after_hours_script,omitempty"`
+	// Telegram chats that take critical alerts after hours, tried in order
+	// until one accepts, e.g. the on-call doctor and then the deputy
+	OnCallChatIDs []int64 `json:"on_call_chat_ids"`
+
+	loc      *time.Location
+	week     map[time.Weekday][]hoursSpan
+	holidays map[string]bool
+}
+
+const defaultAfterHoursScript = "Сейчас клиника закрыта, пациента примет дежурный врач, ожидание может быть дольше обычного. Если состояние резко ухудшается (сильная боль, трудно дышать, спутанность сознания), пациент должен сразу сказать об этом сотруднику или позвонить 103."
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// hoursSpan is an opening interval in minutes since midnight; to may pass
+// 24 hours for an overnight interval
+type hoursSpan struct{ from, to int }
+
+// ClinicStatus tells the queue displays whether the clinic is open and when
+// that changes. Both times are nil without a schedule.
+type ClinicStatus struct {
+	Open     bool       `json:"open"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+	OpensAt  *time.Time `json:"opens_at,omitempty"`
+}
+
+// LoadWorkingHours reads the schedule from a JSON file; path "" means the
+// clinic is always open
+func LoadWorkingHours(path string) (*WorkingHours, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var h WorkingHours
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid working hours: %w", err)
+	}
+	if err := h.parse(); err != nil {
+		return nil, fmt.Errorf("invalid working hours: %w", err)
+	}
+	return &h, nil
+}
+
+func (h *WorkingHours) parse() error {
+	h.loc = time.Local
+	if h.Timezone != "" {
+		loc, err := time.LoadLocation(h.Timezone)
+		if err != nil {
+			return err
+		}
+		h.loc = loc
+	}
+	h.week = make(map[time.Weekday][]hoursSpan)
+	for day, intervals := range h.Week {
+		wd, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		for _, iv := range intervals {
+			span, err := parseSpan(iv)
+			if err != nil {
+				return err
+			}
+			h.week[wd] = append(h.week[wd], span)
+		}
+	}
+	h.holidays = make(map[string]bool, len(h.Holidays))
+	for _, d := range h.Holidays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid holiday %q", d)
+		}
+		h.holidays[d] = true
+	}
+	if h.AfterHoursScript == "" {
+		h.AfterHoursScript = defaultAfterHoursScript
+	}
+	return nil
+}
+
+func parseSpan(s string) (hoursSpan, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return hoursSpan{}, fmt.Errorf("invalid interval %q, want \"08:00-20:00\"", s)
+	}
+	f, err := parseClock(from)
+	if err != nil {
+		return hoursSpan{}, fmt.Errorf("invalid interval %q: %w", s, err)
+	}
+	t, err := parseClock(to)
+	if err != nil {
+		return hoursSpan{}, fmt.Errorf("invalid interval %q: %w", s, err)
+	}
+	if t <= f {
+		t += 24 * 60
+	}
+	return hoursSpan{from: f, to: t}, nil
+}
+
+// parseClock reads "HH:MM" as minutes since midnight; "24:00" is the end of the day
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if !ok || err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Open reports whether the clinic works at t
+func (h *WorkingHours) Open(t time.Time) bool {
+	return h.Status(t).Open
+}
+
+// Status is the clinic's state at now with the next change within a week
+func (h *WorkingHours) Status(now time.Time) ClinicStatus {
+	if h == nil {
+		return ClinicStatus{Open: true}
+	}
+	now = now.In(h.loc)
+	for _, iv := range h.intervals(now) {
+		if now.Before(iv[0]) {
+			opens := iv[0]
+			return ClinicStatus{Open: false, OpensAt: &opens}
+		}
+		if now.Before(iv[1]) {
+			closes := iv[1]
+			return ClinicStatus{Open: true, ClosesAt: &closes}
+		}
+	}
+	return ClinicStatus{Open: false}
+}
+
+// intervals returns the opening intervals from yesterday, which may run
+// overnight, to a week ahead, in order and with adjoining ones merged
+func (h *WorkingHours) intervals(now time.Time) [][2]time.Time {
+	y, m, d := now.Date()
+	var out [][2]time.Time
+	for i := -1; i <= 8; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, h.loc)
+		if h.holidays[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, s := range h.week[day.Weekday()] {
+			out = append(out, [2]time.Time{day.Add(time.Duration(s.from) * time.Minute), day.Add(time.Duration(s.to) * time.Minute)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0].Before(out[j][0]) })
+
+	merged := out[:0]
+	for _, iv := range out {
+		if n := len(merged); n > 0 && !iv[0].After(merged[n-1][1]) {
+			if iv[1].After(merged[n-1][1]) {
+				merged[n-1][1] = iv[1]
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// afterHoursScript is the script for the Communicator at now, "" while open
+func (h *WorkingHours) afterHoursScript(now time.Time) string {
+	if h == nil || h.Open(now) {
+		return ""
+	}
+	return h.AfterHoursScript
+}
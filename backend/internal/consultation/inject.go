@@ -0,0 +1,70 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTurn is returned for an empty or oversized synthetic turn
+var ErrInvalidTurn = errors.New("invalid turn")
+
+const maxInjectedLength = 2000
+
+type injectorKey struct{}
+
+// injectedBy returns the staff member running the turn from the support
+// console, or "" for a real patient turn
+func injectedBy(ctx context.Context) string {
+	author, _ := ctx.Value(injectorKey{}).(string)
+	return author
+}
+
+// lastTurnInjectedBy returns the staff member who sent the latest patient
+// message from the support console, or "" if the patient sent it
+func (c *Consultation) lastTurnInjectedBy() string {
+	for i := len(c.History) - 1; i >= 0; i-- {
+		if c.History[i].Role == "user" {
+			return c.History[i].InjectedBy
+		}
+	}
+	return ""
+}
+
+// alertSuppressed reports whether an alert about the current turn must not
+// go out because the turn came from the support console: staff on duty
+// should not run to a patient over a test message
+func alertSuppressed(ctx context.Context, c *Consultation, alert string) bool {
+	author := injectedBy(ctx)
+	if author == "" {
+		return false
+	}
+	fmt.Printf("Support console: %s not sent for the turn %s injected into consultation %s\n", alert, author, c.ID)
+	return true
+}
+
+// InjectTurn runs a synthetic patient turn from the support console, to
+// reproduce a reported issue without a kiosk. The turn and the assistant's
+// reply are marked with the author in the History, and a note records it.
+// The turn raises no alerts, facts drawn from it are marked, and a report the
+// turn completes is not sent.
+func (s *service) InjectTurn(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author string, text string) (*Reply, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxInjectedLength {
+		return nil, ErrInvalidTurn
+	}
+	if author == "" {
+		author = "unknown"
+	}
+
+	// The note is written first so the audit trail exists even if the turn fails
+	if _, err := s.AddNote(ctx, consultationID, authorID, author, fmt.Sprintf("Тестовая реплика через консоль поддержки: «%s»", text)); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Support console: %s injected a turn into consultation %s\n", author, consultationID)
+
+	return s.ProcessUserAudio(context.WithValue(ctx, injectorKey{}, author), consultationID, text)
+}
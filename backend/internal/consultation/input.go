@@ -0,0 +1,61 @@
+package consultation
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InputLimits bounds what a patient turn may carry before it reaches the LLM.
+// Lengths are in characters; 0 turns a limit off.
+type InputLimits struct {
+	TurnText    int // one turn, typed or transcribed
+	HistoryText int // the whole dialogue including the new turn
+	// ValidateText rejects invalid UTF-8 and control characters other than line breaks and tabs
+	ValidateText bool
+	// A spoken turn with content words heard below this confidence is read back
+	// to the patient before it enters the History, see Clarification; 0 never asks
+	ClarifyConfidence float64
+}
+
+// 10MB of WAV is about five minutes of speech, some 5000 characters
+var DefaultInputLimits = InputLimits{TurnText: 5000, HistoryText: 100000, ValidateText: true, ClarifyConfidence: 0.5}
+
+// InputError is a rejected turn. Code is the X-Error-Code the API answers with.
+type InputError struct {
+	Code string
+	msg  string
+}
+
+func (e *InputError) Error() string {
+	return e.msg
+}
+
+// checkInput rejects a turn that breaks the limits, before it joins the History
+func (s *service) checkInput(c *Consultation, text string) error {
+	l := s.inputLimits
+	if l.ValidateText {
+		if !utf8.ValidString(text) {
+			return &InputError{"invalid_text", "text is not valid UTF-8"}
+		}
+		for _, r := range text {
+			if r == utf8.RuneError || (unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t') {
+				return &InputError{"invalid_text", fmt.Sprintf("text contains invalid character %U", r)}
+			}
+		}
+	}
+
+	n := utf8.RuneCountInString(text)
+	if l.TurnText > 0 && n > l.TurnText {
+		return &InputError{"text_too_long", fmt.Sprintf("text is %d characters, at most %d allowed", n, l.TurnText)}
+	}
+	if l.HistoryText > 0 {
+		for _, m := range c.History {
+			n += utf8.RuneCountInString(m.Content)
+		}
+		if n > l.HistoryText {
+			return &InputError{"history_too_long", fmt.Sprintf("dialogue would be %d characters, at most %d allowed", n, l.HistoryText)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package consultation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoKeypadOptions = errors.New("the last question has no keypad answers")
+	ErrUnknownKey      = errors.New("no answer for this key")
+)
+
+// KeysTag opens the keypad answers the Communicator appends to a question in
+// the keypad mode: "[KEYS: 1=сильная | 2=умеренная | 3=слабая]"
+const KeysTag = "[KEYS:"
+
+// keyRepeat asks for the last reply again, like the "повторите" voice command
+const keyRepeat = "*"
+
+// KeypadOption is an answer the patient can give by pressing a key, e.g. on a phone
+type KeypadOption struct {
+	Key    string `json:"key"`    // "1"-"9" or "0"
+	Answer string `json:"answer"` // recorded as the patient's message, e.g. "Сильная боль"
+}
+
+// Screening questions are answered yes or no
+var yesNoKeys = []KeypadOption{{Key: "1", Answer: "Да"}, {Key: "2", Answer: "Нет"}}
+
+const yesNoPrompt = " Нажмите 1, если да, или 2, если нет."
+
+// keypad reports whether the patient may answer with keys
+func (p *Pacing) keypad() bool {
+	return p != nil && p.Keypad
+}
+
+// parseKeys reads the options of a "[KEYS: ...]" block body
+func parseKeys(body string) []KeypadOption {
+	var keys []KeypadOption
+	seen := map[string]bool{}
+	for _, item := range strings.Split(body, "|") {
+		key, answer, ok := strings.Cut(item, "=")
+		key, answer = strings.TrimSpace(key), strings.TrimSpace(answer)
+		if !ok || len(key) != 1 || key[0] < '0' || key[0] > '9' || answer == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, KeypadOption{Key: key, Answer: answer})
+	}
+	return keys
+}
+
+// screeningKeys are the keys for the screening question just asked, if any
+func (c *Consultation) screeningKeys() []KeypadOption {
+	if !c.Pacing.keypad() || c.RiskScreening == nil || !c.RiskScreening.Active || c.RiskScreening.Pending {
+		return nil
+	}
+	return yesNoKeys
+}
+
+// withKeypadPrompt tells the patient which keys answer a screening question
+func (c *Consultation) withKeypadPrompt(response string) string {
+	if c.screeningKeys() == nil {
+		return response
+	}
+	return response + yesNoPrompt
+}
+
+type keypadKey struct{}
+
+// keyPressed returns the key the current turn was answered with, or ""
+func keyPressed(ctx context.Context) string {
+	key, _ := ctx.Value(keypadKey{}).(string)
+	return key
+}
+
+// KeypadAnswer runs the answer the last assistant message offered for key as
+// the patient's turn. The message records the key that was pressed.
+func (s *service) KeypadAnswer(ctx context.Context, consultationID uuid.UUID, key string) (*Reply, error) {
+	key = strings.TrimSpace(key)
+	ctx = context.WithValue(ctx, keypadKey{}, key)
+	if key == keyRepeat {
+		return s.ProcessUserAudio(ctx, consultationID, "повторите")
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	var keys []KeypadOption
+	for i := len(c.History) - 1; i >= 0; i-- {
+		if c.History[i].Role == "assistant" {
+			keys = c.History[i].Keys
+			break
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKeypadOptions
+	}
+	for _, k := range keys {
+		if k.Key == key {
+			return s.ProcessUserAudio(ctx, consultationID, k.Answer)
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+type KeypadRequest struct {
+	ConsultationID string `json:"consultation_id"`
+	Key            string `json:"key"` // a DTMF digit, or "*" to repeat the last reply
+}
+
+// HandleKeypad takes a key pressed on a phone in answer to a question that
+// offered keypad answers
+func (h *Handler) HandleKeypad(w http.ResponseWriter, r *http.Request) {
+	var req KeypadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(req.ConsultationID)
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.sessions.checkChannel(r, id); err != nil {
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	h.activity.touch(id)
+
+	ctx, timer := withTurnTimer(r.Context())
+	reply, err := h.svc.KeypadAnswer(ctx, id, req.Key)
+	if errors.Is(err, ErrNoKeypadOptions) || errors.Is(err, ErrUnknownKey) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, "Processing failed: "+err.Error(), err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ChatResponse{
+		Response: reply.Text,
+		Command:  reply.Command,
+		Screen:   reply.Screen,
+		Keys:     reply.Keys,
+	})
+	h.recordTurn(ctx, id, timer)
+}
@@ -0,0 +1,170 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Percentiles in the admin stats cover recent turns only
+const latencyWindow = 7 * 24 * time.Hour
+
+// TurnTimings is the stage breakdown of one turn in milliseconds. A stage that
+// did not run is zero: STT for typed text, first token for a non-streamed
+// reply, TTS in text-only mode.
+type TurnTimings struct {
+	STTMs           int64 `json:"stt_ms"`
+	LLMFirstTokenMs int64 `json:"llm_first_token_ms"`
+	LLMMs           int64 `json:"llm_ms"` // Communicator call, without TTS done while streaming
+	TTSMs           int64 `json:"tts_ms"`
+	TotalMs         int64 `json:"total_ms"` // request start to the last byte of the response
+}
+
+// Slowest names the stage that took most of the turn: "stt", "llm", "tts" or
+// "other" (database, LLM queue, network to the client)
+func (t TurnTimings) Slowest() string {
+	stage, longest := "other", t.TotalMs-t.STTMs-t.LLMMs-t.TTSMs
+	for _, s := range []struct {
+		name string
+		ms   int64
+	}{{"stt", t.STTMs}, {"llm", t.LLMMs}, {"tts", t.TTSMs}} {
+		if s.ms > longest {
+			stage, longest = s.name, s.ms
+		}
+	}
+	return stage
+}
+
+// SlowTurn is the diagnostic attached to a consultation when a turn misses the SLO
+type SlowTurn struct {
+	TurnTimings
+	SLOMs   int64     `json:"slo_ms"`
+	Slowest string    `json:"slowest"`
+	At      time.Time `json:"at"`
+}
+
+// LatencyStats are turn-time percentiles over the last week
+type LatencyStats struct {
+	Turns int         `json:"turns"`
+	Slow  int         `json:"slow"` // turns over the SLO
+	P50   TurnTimings `json:"p50"`
+	P95   TurnTimings `json:"p95"`
+}
+
+// RecordTurn stores a turn's timings; with a positive slo a slower turn is
+// attached to the consultation as a SlowTurn
+func (s *service) RecordTurn(ctx context.Context, consultationID uuid.UUID, t TurnTimings, slo time.Duration) error {
+	slow := slo > 0 && t.TotalMs > slo.Milliseconds()
+	if slow {
+		fmt.Printf("Slow turn in consultation %s: %dms (SLO %s), stt=%dms llm=%dms first_token=%dms tts=%dms, mostly %s\n",
+			consultationID, t.TotalMs, slo, t.STTMs, t.LLMMs, t.LLMFirstTokenMs, t.TTSMs, t.Slowest())
+	}
+	return s.repo.SaveTurnTimings(ctx, consultationID, t, slo.Milliseconds(), slow)
+}
+
+type turnTimerKey struct{}
+
+// turnTimer collects stage timings of one turn as it passes through the
+// handler and the service. Methods are safe on a nil timer, so code outside a
+// timed request (background agents, /api/tts) records nothing.
+type turnTimer struct {
+	mu         sync.Mutex
+	start      time.Time
+	stt        time.Duration
+	firstToken time.Duration
+	llm        time.Duration
+	tts        time.Duration
+	llmStart   time.Time
+	llmTTS     time.Duration // tts when the Communicator started
+	llmDone    bool
+}
+
+func withTurnTimer(ctx context.Context) (context.Context, *turnTimer) {
+	t := &turnTimer{start: time.Now()}
+	return context.WithValue(ctx, turnTimerKey{}, t), t
+}
+
+func turnTimerFrom(ctx context.Context) *turnTimer {
+	t, _ := ctx.Value(turnTimerKey{}).(*turnTimer)
+	return t
+}
+
+func (t *turnTimer) addSTT(start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stt += time.Since(start)
+}
+
+func (t *turnTimer) addTTS(start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tts += time.Since(start)
+}
+
+func (t *turnTimer) startLLM() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.llmStart, t.llmTTS = time.Now(), t.tts
+}
+
+// gotToken marks the first streamed token; later calls are ignored
+func (t *turnTimer) gotToken() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.firstToken == 0 && !t.llmStart.IsZero() {
+		t.firstToken = time.Since(t.llmStart)
+	}
+}
+
+// endLLM closes the Communicator stage. Sentences voiced while the stream was
+// still running are counted as TTS, not LLM time.
+func (t *turnTimer) endLLM() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.llm = max(time.Since(t.llmStart)-(t.tts-t.llmTTS), 0)
+	t.llmDone = true
+}
+
+// timings returns the breakdown so far; ok is false when the Communicator did
+// not finish (voice commands, screening questions, failed or abandoned turns)
+func (t *turnTimer) timings() (TurnTimings, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TurnTimings{
+		STTMs:           t.stt.Milliseconds(),
+		LLMFirstTokenMs: t.firstToken.Milliseconds(),
+		LLMMs:           t.llm.Milliseconds(),
+		TTSMs:           t.tts.Milliseconds(),
+		TotalMs:         time.Since(t.start).Milliseconds(),
+	}, t.llmDone
+}
+
+// recordTurn stores the timings once the response has been written. It runs
+// after the client may have gone, so the write does not inherit cancellation.
+func (h *Handler) recordTurn(ctx context.Context, consultationID uuid.UUID, t *turnTimer) {
+	timings, ok := t.timings()
+	if !ok {
+		return
+	}
+	if err := h.svc.RecordTurn(context.WithoutCancel(ctx), consultationID, timings, h.timeouts.SLO); err != nil {
+		fmt.Printf("Failed to record turn timings for consultation %s: %v\n", consultationID, err)
+	}
+}
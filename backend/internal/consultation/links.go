@@ -0,0 +1,93 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidLink is returned for unknown relations or a consultation linked to itself
+var ErrInvalidLink = errors.New("invalid consultation link")
+
+// LinkRelation describes how a consultation continues an earlier one
+type LinkRelation string
+
+const (
+	RelationFollowUpOf      LinkRelation = "follow_up_of"
+	RelationTransferredFrom LinkRelation = "transferred_from"
+)
+
+// ValidRelation reports whether r is a known link relation
+func ValidRelation(r LinkRelation) bool {
+	return r == RelationFollowUpOf || r == RelationTransferredFrom
+}
+
+// LinkedConsultation is an earlier consultation this one refers to, with just
+// enough context for the Communicator and the report
+type LinkedConsultation struct {
+	ID             uuid.UUID    `json:"id"`
+	Relation       LinkRelation `json:"relation"`
+	VisitedAt      time.Time    `json:"visited_at"`
+	ChiefComplaint string       `json:"chief_complaint,omitempty"`
+	LinkedAt       time.Time    `json:"linked_at"`
+}
+
+// Describe renders the link for prompts and reports,
+// e.g. "продолжение визита вчера по поводу боли в животе"
+func (l LinkedConsultation) Describe(now time.Time) string {
+	kind := "продолжение визита"
+	if l.Relation == RelationTransferredFrom {
+		kind = "передан после визита"
+	}
+	text := kind + " " + relativeDay(l.VisitedAt, now)
+	if l.ChiefComplaint != "" {
+		text += " по поводу: " + l.ChiefComplaint
+	}
+	return text
+}
+
+func relativeDay(t, now time.Time) string {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	days := int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	switch {
+	case days <= 0:
+		return "сегодня"
+	case days == 1:
+		return "вчера"
+	case days < 7:
+		return fmt.Sprintf("%d дн. назад", days)
+	default:
+		return t.Format("02.01.2006")
+	}
+}
+
+// chiefComplaint picks the first symptom fact, falling back to the first fact
+func chiefComplaint(facts []MedicalFact) string {
+	for _, f := range facts {
+		if strings.Contains(strings.ToLower(f.Category), "симптом") {
+			return f.Description
+		}
+	}
+	if len(facts) > 0 {
+		return facts[0].Description
+	}
+	return ""
+}
+
+func (s *service) LinkConsultation(ctx context.Context, consultationID, linkedID uuid.UUID, relation LinkRelation) (*Consultation, error) {
+	if !ValidRelation(relation) || consultationID == linkedID {
+		return nil, ErrInvalidLink
+	}
+	if _, err := s.repo.GetByID(ctx, linkedID); err != nil {
+		return nil, fmt.Errorf("linked consultation: %w", err)
+	}
+	if err := s.repo.AddLink(ctx, consultationID, linkedID, relation); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, consultationID)
+}
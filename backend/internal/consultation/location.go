@@ -0,0 +1,74 @@
+package consultation
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Location is where a consultation takes place. Large hospitals run many
+// waiting areas at once, so queues, routing and statistics can be narrowed
+// to one of them. Building and floor come from the kiosk the consultation
+// was started on, the department from the interview or else the kiosk.
+type Location struct {
+	Building   string `json:"building,omitempty"`
+	Floor      string `json:"floor,omitempty"`
+	Department string `json:"department,omitempty"`
+}
+
+// Location returns where the consultation takes place; fields are empty when
+// the kiosk did not say
+func (c *Consultation) Location() Location {
+	l := Location{Department: c.Department}
+	if c.Device != nil {
+		l.Building, l.Floor = c.Device.Building, c.Device.Floor
+	}
+	return l
+}
+
+// LocationFromQuery reads ?building, ?floor and ?department
+func LocationFromQuery(q url.Values) Location {
+	return Location{
+		Building:   strings.TrimSpace(q.Get("building")),
+		Floor:      strings.TrimSpace(q.Get("floor")),
+		Department: strings.TrimSpace(q.Get("department")),
+	}
+}
+
+// IsZero reports whether no field is set; as a filter it matches everything
+func (l Location) IsZero() bool {
+	return l == Location{}
+}
+
+// Matches reports whether other lies within l used as a filter: every field
+// set in l must be equal in other
+func (l Location) Matches(other Location) bool {
+	return (l.Building == "" || l.Building == other.Building) &&
+		(l.Floor == "" || l.Floor == other.Floor) &&
+		(l.Department == "" || l.Department == other.Department)
+}
+
+// String reads like "корп. А, 2 этаж, cardiology"
+func (l Location) String() string {
+	var parts []string
+	if l.Building != "" {
+		parts = append(parts, "корп. "+l.Building)
+	}
+	if l.Floor != "" {
+		parts = append(parts, l.Floor+" этаж")
+	}
+	if l.Department != "" {
+		parts = append(parts, l.Department)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// locationClause restricts a query on consultations to a Location filter
+// passed as three parameters starting at $n, see Location.args
+func locationClause(n int) string {
+	return fmt.Sprintf(`($%[1]d::text = '' OR device->>'building' = $%[1]d::text) AND ($%[2]d::text = '' OR device->>'floor' = $%[2]d::text) AND ($%[3]d::text = '' OR COALESCE(department, '') = $%[3]d::text)`, n, n+1, n+2)
+}
+
+func (l Location) args() []any {
+	return []any{l.Building, l.Floor, l.Department}
+}
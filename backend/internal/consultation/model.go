@@ -1,11 +1,59 @@
 package consultation
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// InterviewMode selects what the Communicator is trying to collect
+type InterviewMode string
+
+const (
+	ModeStandard                 InterviewMode = "standard"
+	ModeMedicationReconciliation InterviewMode = "medication_reconciliation"
+)
+
+// ValidMode reports whether mode is a known interview mode
+func ValidMode(mode InterviewMode) bool {
+	return mode == ModeStandard || mode == ModeMedicationReconciliation
+}
+
+// Interview describes who the Communicator is talking to and what it collects
+type Interview struct {
+	Mode           InterviewMode
+	Pediatric      bool                 // the patient is a child, answers come from a guardian
+	Questionnaires []Questionnaire      // pre-visit questionnaires the Communicator may refer to
+	EpidTopics     []EpidTopic          // epidemiological questions still to be asked
+	Links          []LinkedConsultation // earlier visits this one continues
+	Pacing         *Pacing              // slower, simpler speech; nil = normal pace
+	Variant        Variant              // experiment arm overrides, zero = defaults
+	NextQuestion   string               // cut from an earlier reply, asked if still relevant
+	Department     string               // selects the required-information profile
+	Required       []RequiredField      // department's required fields not collected yet
+	Device         *Device              // kiosk the consultation is started on
+	Conditions     []PriorCondition     // mentioned diagnoses with treatment not yet clarified
+	DoctorQuestion string               // a doctor's question to put to the patient this turn
+	Recipients     []ReportRecipient    // doctors who get the report besides the doctor on duty
+	AfterHours     string               // the clinic is closed, the after-hours script to follow
+	Tools          bool                 // offer the Communicator the backend tools, see ToolCall
+	Continue       []string             // the last farewell was vetoed, these are still missing
+}
+
+// EpidTopic is one question of the epidemiological screening block
+type EpidTopic struct {
+	ID       string `json:"id"`       // e.g., "travel"
+	Category string `json:"category"` // fact category the Analyst records the answer under
+	Question string `json:"question"`
+}
+
+// ChildInfo holds the vitals a pediatric interview must collect
+type ChildInfo struct {
+	AgeMonths int     `json:"age_months,omitempty"`
+	WeightKg  float64 `json:"weight_kg,omitempty"`
+}
+
 // BICA Components
 type EmotionalState string
 
@@ -20,33 +68,352 @@ type Message struct {
 	Role      string    `json:"role"` // "user" or "assistant"
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Staff member who sent this turn from the support console; set on the
+	// synthetic patient message and on the reply to it
+	InjectedBy string `json:"injected_by,omitempty"`
+
+	// Doctor whose question this turn asks or answers
+	RequestedBy string `json:"requested_by,omitempty"`
+
+	// Staff member whose reply to a relayed message this is, see Bridge
+	RelayedBy string `json:"relayed_by,omitempty"`
+
+	// LLM provider that wrote this reply, see agent.Provider
+	Provider string `json:"provider,omitempty"`
+
+	// Short on-screen version of an assistant reply, see Pacing.ScreenText
+	Screen string `json:"screen,omitempty"`
+
+	// Keypad answers offered by an assistant reply, see Pacing.Keypad
+	Keys []KeypadOption `json:"keys,omitempty"`
+	// Key the patient pressed for this message, which holds the matching answer
+	Key string `json:"key,omitempty"`
+
+	// Patient's mood as the Communicator judged it on this reply, for analytics
+	Mood EmotionalState `json:"mood,omitempty"`
+
+	// Backend tools the Communicator called with this reply
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// Coding is a controlled vocabulary code (e.g. SNOMED CT) in FHIR Coding form
+type Coding struct {
+	System  string `json:"system"`  // e.g., "http://snomed.info/sct"
+	Code    string `json:"code"`    // e.g., "25064002"
+	Display string `json:"display"` // e.g., "Headache"
 }
 
 type MedicalFact struct {
-	Category    string `json:"category"`    // e.g., "Symptom", "Duration", "Medication"
-	Description string `json:"description"` // e.g., "Headache for 3 days"
-	Confidence  string `json:"confidence"`  // "High", "Medium", "Low"
+	Category    string  `json:"category"`       // e.g., "Symptom", "Duration", "Medication"
+	Description string  `json:"description"`    // e.g., "Headache for 3 days"
+	Confidence  string  `json:"confidence"`     // "High", "Medium", "Low"
+	Code        *Coding `json:"code,omitempty"` // normalized symptom, if recognized
+
+	// Staff member whose support console turn the fact was drawn from
+	InjectedBy string `json:"injected_by,omitempty"`
+}
+
+// PertinentNegative is a symptom the patient explicitly denied. Doctors read these
+// separately from positive findings, e.g. "no fever" for an abdominal pain complaint.
+type PertinentNegative struct {
+	Symptom    string  `json:"symptom"`        // e.g., "Температура"
+	Context    string  `json:"context"`        // e.g., "Отрицает повышение температуры за последние 3 дня"
+	Confidence string  `json:"confidence"`     // "High", "Medium", "Low"
+	Code       *Coding `json:"code,omitempty"` // normalized symptom, if recognized
+}
+
+// Medication is one entry of the patient's current medication list
+type Medication struct {
+	Name      string `json:"name"`      // e.g., "Эналаприл"
+	Dose      string `json:"dose"`      // e.g., "10 мг"
+	Schedule  string `json:"schedule"`  // e.g., "утром"
+	Adherence string `json:"adherence"` // e.g., "пропускает 1-2 раза в неделю"
+}
+
+// Complete reports whether every field of the entry is known
+func (m Medication) Complete() bool {
+	return m.Name != "" && m.Dose != "" && m.Schedule != "" && m.Adherence != ""
+}
+
+// AnalysisResult is the Analyst's output for one pass over the dialogue
+type AnalysisResult struct {
+	Facts       []MedicalFact       `json:"facts"`
+	Negatives   []PertinentNegative `json:"negatives"`
+	Medications []Medication        `json:"medications"`
+	// Diagnoses the patient was given before, linked to codes by the service
+	PriorConditions []PriorCondition `json:"prior_conditions"`
+}
+
+// RuleFinding is a firing of a deterministic clinical decision support rule.
+// These are reported separately from the LLM's conclusions.
+type RuleFinding struct {
+	RuleID         string `json:"rule_id"`
+	Title          string `json:"title"`
+	Triage         string `json:"triage,omitempty"` // "red", "yellow", "green"
+	Recommendation string `json:"recommendation"`
+	// Conflict marks a clash between the recommendations and the patient's allergies or conditions
+	Conflict bool `json:"conflict,omitempty"`
 }
 
 // Consultation represents the aggregate root
 type Consultation struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	PatientID uuid.UUID `json:"patient_id" db:"patient_id"`
+	ID        uuid.UUID     `json:"id" db:"id"`
+	PatientID uuid.UUID     `json:"patient_id" db:"patient_id"`
+	Mode      InterviewMode `json:"mode" db:"mode"`
+	Pediatric bool          `json:"pediatric" db:"pediatric"`
+
+	// Speech pacing, e.g. the "elderly" preset
+	Pacing *Pacing `json:"pacing,omitempty" db:"pacing"`
+
+	// Child's age and weight as reported by the guardian (pediatric only)
+	Child *ChildInfo `json:"child,omitempty" db:"child"`
 	
 	// Episodic Memory
 	History []Message `json:"history" db:"history"`
 
 	// Semantic Memory (The Analyst's Output)
-	ExtractedFacts []MedicalFact `json:"facts" db:"facts"`
+	ExtractedFacts     []MedicalFact       `json:"facts" db:"facts"`
+	PertinentNegatives []PertinentNegative `json:"negatives" db:"negatives"`
+
+	// Pre-visit questionnaires (PROMs) imported before or during the interview
+	Questionnaires []Questionnaire `json:"questionnaires" db:"questionnaires"`
+
+	// Epidemiological screening topics, set once the chief complaint requires them
+	EpidTopics []EpidTopic `json:"epid_topics,omitempty" db:"epid_topics"`
+
+	// Current medications, filled in by the Analyst in every mode
+	Medications []Medication `json:"medications" db:"medications"`
+	// Past and chronic diagnoses the patient mentioned
+	PriorConditions []PriorCondition `json:"prior_conditions" db:"prior_conditions"`
+	// AI summary of the facts for the report, set when there are too many to list
+	FactSummary []MedicalFact `json:"fact_summary,omitempty" db:"fact_summary"`
+
+	// Deterministic rule firings over the facts above
+	RuleFindings []RuleFinding `json:"rule_findings" db:"rule_findings"`
+
+	// Suicide/self-harm screening, nil unless risk language was detected
+	RiskScreening *RiskScreening `json:"risk_screening,omitempty" db:"risk_screening"`
 
 	// Emotional Module State
 	CurrentMood EmotionalState `json:"mood" db:"mood"`
 
 	// Output
-	Recommendations string `json:"recommendations" db:"recommendations"`
+	Recommendations string       `json:"recommendations" db:"recommendations"`
+	Reliability     *Reliability `json:"reliability,omitempty" db:"reliability"`
+
+	// QA agent's review, filled in after completion
+	Quality *QualityReview `json:"quality,omitempty" db:"quality"`
+
+	// Nurse sign-off, set only when the clinic requires review before dispatch
+	Review *Review `json:"review,omitempty" db:"review"`
+
+	// Earlier consultations this one continues, stored in consultation_links
+	Links []LinkedConsultation `json:"links,omitempty" db:"-"`
+
+	// Staff-only tags and notes, stored in their own tables and never serialized
+	// to the patient; the admin API returns them explicitly
+	Tags  []string `json:"-" db:"-"`
+	Notes []Note   `json:"-" db:"-"`
+
+	// Abuse aimed at the assistant, stored in consultation_abuse_incidents
+	AbuseIncidents []AbuseIncident `json:"abuse_incidents,omitempty" db:"-"`
+
+	// Emergencies found by the red-flag screen, stored in consultation_red_flags
+	RedFlags []RedFlag `json:"red_flags,omitempty" db:"-"`
+
+	// The patient's own wearable data, summarized on import
+	Wearables *WearableSignals `json:"wearables,omitempty" db:"wearables"`
+
+	// Readings from waiting-room devices, stored in consultation_vitals
+	Vitals []Measurement `json:"vitals,omitempty" db:"-"`
+
+	// Questions doctors asked to put to the patient, stored in consultation_doctor_questions
+	DoctorQuestions []DoctorQuestion `json:"doctor_questions,omitempty" db:"-"`
+
+	// Department the patient is seen in and its required fields, copied from
+	// the department's profile at creation so later edits do not affect it
+	Department     string          `json:"department,omitempty" db:"department"`
+	RequiredFields []RequiredField `json:"required_fields,omitempty" db:"required_fields"`
+
+	// Kiosk the consultation was started on, nil for requests without a device key
+	Device *Device `json:"device,omitempty" db:"device"`
+
+	// Doctors from the registry who get the report besides the doctor on
+	// duty, set at creation
+	Recipients []ReportRecipient `json:"recipients,omitempty" db:"report_recipients"`
+
+	// Report text in the doctor's language when the interview was held in another
+	Translation *Translation `json:"translation,omitempty" db:"translation"`
+
+	// Read-back of the key facts before completion, nil when none was made
+	Recap *Recap `json:"recap,omitempty" db:"recap"`
+
+	// Spoken turn held back until the patient confirms it was heard right
+	Clarification *Clarification `json:"clarification,omitempty" db:"clarification"`
+
+	// Follow-up visit booked after completion, nil when none was suggested
+	Booking *Booking `json:"booking,omitempty" db:"booking"`
+
+	// Reason for the visit, classified from the first messages. Written only
+	// by SetChiefComplaint.
+	ChiefComplaint *ChiefComplaint `json:"chief_complaint,omitempty" db:"chief_complaint"`
+
+	// Questions cut from multi-question replies, asked one per turn. Written
+	// only by SetQueuedQuestions.
+	QueuedQuestions []string `json:"queued_questions,omitempty" db:"queued_questions"`
+
+	// Turns that missed the latency SLO, stored in turn_timings
+	SlowTurns []SlowTurn `json:"slow_turns,omitempty" db:"-"`
+
+	// Waiting-room queue: ticket number assigned by the database, visit state set by staff
+	Ticket int    `json:"ticket" db:"ticket"`
+	Visit  *Visit `json:"visit,omitempty" db:"visit"`
+	// Room and bed given to the waiting patient. Written only by SetAssignment.
+	Assignment *Assignment `json:"assignment,omitempty" db:"assignment"`
+
+	// A/B experiment arm this consultation was assigned to, if any
+	Experiment string `json:"experiment,omitempty" db:"experiment"`
+	Arm        string `json:"arm,omitempty" db:"arm"`
+	// How many times the Supervisor judged the interview, an experiment outcome metric
+	SupervisorRounds int `json:"supervisor_rounds" db:"supervisor_rounds"`
+	// Patient turn of the Supervisor's last decision, for its schedule
+	SupervisorTurn int `json:"supervisor_turn,omitempty" db:"supervisor_turn"`
+	// Revision of the report last dispatched to the doctor, 0 before the first
+	ReportRevision int `json:"report_revision,omitempty" db:"report_revision"`
+	// When the first report went out and which doctor confirmed reading it,
+	// for response-time analytics. Written only by MarkReportDispatched and
+	// SetAcknowledgment.
+	ReportDispatchedAt *time.Time      `json:"report_dispatched_at,omitempty" db:"report_dispatched_at"`
+	Acknowledgment     *Acknowledgment `json:"acknowledgment,omitempty" db:"acknowledgment"`
+
+	// Patient's messages go straight to the nurse station while the LLM is
+	// down, see Bridge. Written only by SetBridge.
+	Bridge *Bridge `json:"bridge,omitempty" db:"bridge"`
+
+	// Prompt, rule and checklist versions the consultation ran on. Written
+	// only by SetConfigStamp.
+	Config *ConfigStamp `json:"config,omitempty" db:"config_versions"`
 
 	// Metacognition Status
 	IsComplete bool      `json:"is_complete" db:"is_complete"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// Interview returns the Communicator settings for this consultation
+func (c *Consultation) Interview() Interview {
+	iv := Interview{
+		Mode:           c.Mode,
+		Pediatric:      c.Pediatric,
+		Questionnaires: c.Questionnaires,
+		EpidTopics:     c.PendingEpidTopics(),
+		Links:          c.Links,
+		Pacing:         c.Pacing,
+		NextQuestion:   c.nextQuestion(),
+		Department:     c.Department,
+		Required:       c.PendingRequired(),
+		Conditions:     c.UnclearConditions(),
+	}
+	// The doctor's question takes this turn's question slot
+	if q := c.pendingDoctorQuestion(); q != nil {
+		iv.DoctorQuestion, iv.NextQuestion = q.Text, ""
+	}
+	return iv
+}
+
+func (c *Consultation) nextQuestion() string {
+	if len(c.QueuedQuestions) == 0 {
+		return ""
+	}
+	return c.QueuedQuestions[0]
+}
+
+// PendingEpidTopics returns the required epidemiological topics without an answer yet
+func (c *Consultation) PendingEpidTopics() []EpidTopic {
+	var pending []EpidTopic
+	for _, t := range c.EpidTopics {
+		answered := false
+		for _, f := range c.ExtractedFacts {
+			if strings.EqualFold(f.Category, t.Category) {
+				answered = true
+				break
+			}
+		}
+		if !answered {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+// AddNegatives merges newly found negatives, skipping symptoms already recorded
+func (c *Consultation) AddNegatives(negatives []PertinentNegative) {
+	for _, n := range negatives {
+		known := false
+		for _, existing := range c.PertinentNegatives {
+			if strings.EqualFold(strings.TrimSpace(existing.Symptom), strings.TrimSpace(n.Symptom)) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			c.PertinentNegatives = append(c.PertinentNegatives, n)
+		}
+	}
+}
+
+// AddMedications merges medications by name, filling in fields learned later in the dialogue
+func (c *Consultation) AddMedications(meds []Medication) {
+	for _, m := range meds {
+		if strings.TrimSpace(m.Name) == "" {
+			continue
+		}
+		merged := false
+		for i := range c.Medications {
+			existing := &c.Medications[i]
+			if !strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(m.Name)) {
+				continue
+			}
+			if m.Dose != "" {
+				existing.Dose = m.Dose
+			}
+			if m.Schedule != "" {
+				existing.Schedule = m.Schedule
+			}
+			if m.Adherence != "" {
+				existing.Adherence = m.Adherence
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			c.Medications = append(c.Medications, m)
+		}
+	}
+}
+
+// MedicationsComplete reports whether every listed medication is fully described
+func (c *Consultation) MedicationsComplete() bool {
+	for _, m := range c.Medications {
+		if !m.Complete() {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats is an aggregated snapshot of consultations for operators
+type Stats struct {
+	Total     int                    `json:"total"`
+	Completed int                    `json:"completed"`
+	ByMood    map[EmotionalState]int `json:"by_mood"`
+	// Consultations per normalized symptom code
+	BySymptom map[string]int `json:"by_symptom"`
+	// Interview quality as scored by the QA agent
+	Quality QualityStats `json:"quality"`
+	// Outcome metrics per A/B experiment arm
+	Experiments []ExperimentStats `json:"experiments"`
+	// Turn-time percentiles per stage over the last week
+	TurnLatency LatencyStats `json:"turn_latency"`
+}
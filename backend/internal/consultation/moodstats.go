@@ -0,0 +1,112 @@
+package consultation
+
+import (
+	"sort"
+	"time"
+)
+
+// MoodRow counts consultations of one day, department and persona that
+// started and ended in the given moods. Initial is the mood the Communicator
+// judged on its first reply, "" for consultations recorded before moods
+// were kept per message.
+type MoodRow struct {
+	Day        string // YYYY-MM-DD
+	Department string
+	Persona    string // the TTS voice, "" for the service default
+	Initial    EmotionalState
+	Final      EmotionalState
+	Count      int
+}
+
+// MoodGroup shows whether the assistant calmed patients down within a group
+type MoodGroup struct {
+	Key           string                 `json:"key"` // "" for the default department or persona
+	Consultations int                    `json:"consultations"`
+	Initial       map[EmotionalState]int `json:"initial"`
+	Final         map[EmotionalState]int `json:"final"`
+	// Started anxious or critical and ended calm or neutral, and the reverse
+	Calmed   int `json:"calmed"`
+	Worsened int `json:"worsened"`
+	// Calmed out of those who started distressed, -1 when nobody did
+	CalmingRate float64 `json:"calming_rate"`
+}
+
+type MoodAnalytics struct {
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	Overall      MoodGroup   `json:"overall"`
+	ByDay        []MoodGroup `json:"by_day"`
+	ByDepartment []MoodGroup `json:"by_department"`
+	ByPersona    []MoodGroup `json:"by_persona"`
+}
+
+func distressed(m EmotionalState) bool {
+	return m == StateAnxious || m == StateCritical
+}
+
+func settled(m EmotionalState) bool {
+	return m == StateCalm || m == StateNeutral
+}
+
+// BuildMoodAnalytics aggregates the rows overall and per day, department and persona
+func BuildMoodAnalytics(rows []MoodRow, from, to time.Time) MoodAnalytics {
+	a := MoodAnalytics{From: from, To: to}
+	overall := newMoodGroup("")
+	days, departments, personas := map[string]*MoodGroup{}, map[string]*MoodGroup{}, map[string]*MoodGroup{}
+	for _, r := range rows {
+		overall.add(r)
+		for _, g := range []struct {
+			groups map[string]*MoodGroup
+			key    string
+		}{{days, r.Day}, {departments, r.Department}, {personas, r.Persona}} {
+			if g.groups[g.key] == nil {
+				g.groups[g.key] = newMoodGroup(g.key)
+			}
+			g.groups[g.key].add(r)
+		}
+	}
+	a.Overall = overall.finish()
+	a.ByDay = sortedMoodGroups(days)
+	a.ByDepartment = sortedMoodGroups(departments)
+	a.ByPersona = sortedMoodGroups(personas)
+	return a
+}
+
+func newMoodGroup(key string) *MoodGroup {
+	return &MoodGroup{Key: key, Initial: map[EmotionalState]int{}, Final: map[EmotionalState]int{}}
+}
+
+func (g *MoodGroup) add(r MoodRow) {
+	g.Consultations += r.Count
+	if r.Initial != "" {
+		g.Initial[r.Initial] += r.Count
+	}
+	if r.Final != "" {
+		g.Final[r.Final] += r.Count
+	}
+	switch {
+	case distressed(r.Initial) && settled(r.Final):
+		g.Calmed += r.Count
+	case settled(r.Initial) && distressed(r.Final):
+		g.Worsened += r.Count
+	}
+}
+
+func (g *MoodGroup) finish() MoodGroup {
+	started := g.Initial[StateAnxious] + g.Initial[StateCritical]
+	g.CalmingRate = -1
+	if started > 0 {
+		g.CalmingRate = float64(g.Calmed) / float64(started)
+	}
+	return *g
+}
+
+// sortedMoodGroups orders groups by key, so days come out in order
+func sortedMoodGroups(groups map[string]*MoodGroup) []MoodGroup {
+	list := make([]MoodGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g.finish())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
@@ -0,0 +1,92 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTag is returned for empty, too long or malformed tags
+var ErrInvalidTag = errors.New("invalid tag")
+
+// ErrInvalidNote is returned for empty or oversized notes
+var ErrInvalidNote = errors.New("invalid note")
+
+const (
+	maxTagLength  = 40
+	maxTags       = 20
+	maxNoteLength = 4000
+)
+
+// Note is an internal staff note. Notes and tags are never shown to the
+// patient, passed to the agents or included in the doctor's report.
+type Note struct {
+	ID        uuid.UUID `json:"id"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeTag lowercases a tag and checks it is a single word of letters,
+// digits, "-" and "_", e.g. "повторный-визит"
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len([]rune(tag)) > maxTagLength {
+		return "", ErrInvalidTag
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
+
+// SetTags replaces the consultation's tags
+func (s *service) SetTags(ctx context.Context, consultationID uuid.UUID, tags []string) (*Consultation, error) {
+	if len(tags) > maxTags {
+		return nil, ErrInvalidTag
+	}
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, t := range tags {
+		tag, err := NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	if _, err := s.repo.GetByID(ctx, consultationID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTags(ctx, consultationID, normalized); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, consultationID)
+}
+
+// AddNote appends an internal note by a staff member
+func (s *service) AddNote(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author string, text string) (*Note, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxNoteLength {
+		return nil, ErrInvalidNote
+	}
+	if _, err := s.repo.GetByID(ctx, consultationID); err != nil {
+		return nil, err
+	}
+
+	n := &Note{ID: uuid.New(), AuthorID: authorID, Author: author, Text: text, CreatedAt: time.Now()}
+	if err := s.repo.AddNote(ctx, consultationID, *n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
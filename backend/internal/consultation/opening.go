@@ -0,0 +1,131 @@
+package consultation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpeningTemplates is the first assistant turn of every consultation by kiosk
+// language: who the assistant is, that it is not a doctor and what happens
+// to the answers. It is fixed text, so it is said the same way every time
+// and costs no LLM call. "{place}" is replaced with the kiosk's location.
+// An empty set turns the opening turn off.
+type OpeningTemplates map[string]string
+
+var DefaultOpeningTemplates = OpeningTemplates{
+	"ru": "Здравствуйте! Я виртуальный ассистент{place}. Я не врач и не ставлю диагнозы: я задам несколько вопросов о вашем самочувствии, а ответы передам врачу, который вас примет. Разговор записывается и используется только для вашего лечения. Расскажите, пожалуйста, что вас беспокоит?",
+	"en": "Hello! I am a virtual assistant{place}. I am not a doctor and I do not make diagnoses: I will ask a few questions about how you feel and pass your answers on to the doctor who will see you. The conversation is recorded and used only for your care. Please tell me what is bothering you.",
+}
+
+const defaultOpeningLanguage = "ru"
+
+// LoadOpeningTemplates reads templates by language from a JSON object,
+// replacing or adding to the built-in ones; path "" keeps the built-in set
+func LoadOpeningTemplates(path string) (OpeningTemplates, error) {
+	t := OpeningTemplates{}
+	for lang, text := range DefaultOpeningTemplates {
+		t[lang] = text
+	}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var custom OpeningTemplates
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("invalid opening templates: %w", err)
+	}
+	for lang, text := range custom {
+		t[lang] = text
+	}
+	return t, nil
+}
+
+// text fills in the template for the consultation's kiosk language, falling
+// back to Russian
+func (t OpeningTemplates) text(c *Consultation) string {
+	lang, place := defaultOpeningLanguage, ""
+	if c.Device != nil {
+		if _, ok := t[c.Device.Language]; ok {
+			lang = c.Device.Language
+		}
+		if p := c.Device.Place(); p != "" {
+			place = " (" + p + ")"
+		}
+	}
+	return strings.TrimSpace(strings.ReplaceAll(t[lang], "{place}", place))
+}
+
+// addOpening puts the opening turn at the start of a new consultation's History
+func (s *service) addOpening(c *Consultation) {
+	text := s.opening.text(c)
+	if text == "" {
+		return
+	}
+	c.History = append(c.History, Message{Role: "assistant", Content: text, Timestamp: time.Now()})
+}
+
+// Opening returns the consultation's opening turn and its audio, nil in
+// text-only mode or when speech failed. The audio is shared by every
+// consultation with the same text and voice.
+func (s *service) Opening(ctx context.Context, c *Consultation) (string, []byte) {
+	if len(c.History) == 0 || c.History[0].Role != "assistant" {
+		return "", nil
+	}
+	text := c.History[0].Content
+	if c.Pacing.textOnly() {
+		return text, nil
+	}
+
+	key := openingKey{text: text, speech: c.Pacing.Speech()}
+	audio, ok := s.openings.get(key)
+	if !ok {
+		var err error
+		if audio, err = s.SynthesizeSpeech(ctx, text, c.Pacing); err != nil {
+			fmt.Printf("Failed to voice the opening of consultation %s: %v\n", c.ID, err)
+			return text, nil
+		}
+		s.openings.put(key, audio)
+	}
+	// "Repeat" right after the opening plays it again
+	s.speech.reset(c.ID)
+	s.speech.add(c.ID, audio)
+	s.RecordAudio(ctx, c.ID, "assistant", audio)
+	return text, audio
+}
+
+type openingKey struct {
+	text   string
+	speech SpeechOptions
+}
+
+// openingCache holds the voiced openings; there are only as many as
+// languages, kiosk locations and voices
+type openingCache struct {
+	mu    sync.Mutex
+	audio map[openingKey][]byte
+}
+
+func newOpeningCache() *openingCache {
+	return &openingCache{audio: make(map[openingKey][]byte)}
+}
+
+func (c *openingCache) get(key openingKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	audio, ok := c.audio[key]
+	return audio, ok
+}
+
+func (c *openingCache) put(key openingKey, audio []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.audio[key] = audio
+}
@@ -0,0 +1,225 @@
+package consultation
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidPacing is returned for unknown presets or out-of-range settings
+var ErrInvalidPacing = errors.New("invalid pacing")
+
+// ErrInvalidVoice is returned for voices the TTS service does not have
+var ErrInvalidVoice = errors.New("invalid voice")
+
+// Silero speakers; "female" and "male" are accepted as aliases
+const (
+	FemaleVoice = "kseniya"
+	MaleVoice   = "aidar"
+)
+
+var speakers = []string{"kseniya", "xenia", "baya", "aidar", "eugene"}
+
+// ResolveVoice maps an alias or speaker name to a speaker
+func ResolveVoice(voice string) (string, error) {
+	switch voice {
+	case "female":
+		return FemaleVoice, nil
+	case "male":
+		return MaleVoice, nil
+	}
+	for _, s := range speakers {
+		if voice == s {
+			return s, nil
+		}
+	}
+	return "", ErrInvalidVoice
+}
+
+// Pacing slows the interview down for patients who need it, e.g. the elderly.
+// Voice commands adjust it during the interview. The zero value keeps the normal pace.
+type Pacing struct {
+	MaxSentenceWords int     `json:"max_sentence_words,omitempty"` // longer sentences are split, 0 = no limit
+	SpeechRate       float64 `json:"speech_rate,omitempty"`        // TTS tempo, 1.0 (or 0) = normal
+	PauseMs          int     `json:"pause_ms,omitempty"`           // silence after each spoken phrase
+	Volume           float64 `json:"volume,omitempty"`             // TTS gain, 1.0 (or 0) = normal
+	TextOnly         bool    `json:"text_only,omitempty"`          // the patient asked to continue in text, replies are not voiced
+	Voice            string  `json:"voice,omitempty"`              // TTS speaker, empty = service default
+	ScreenText       bool    `json:"screen_text,omitempty"`        // a short simplified version of each reply is shown in large print
+	Keypad           bool    `json:"keypad,omitempty"`             // questions with a few answers offer keys to press, e.g. on a phone
+}
+
+// ElderlyPacing is the "elderly" preset
+var ElderlyPacing = Pacing{MaxSentenceWords: 12, SpeechRate: 0.85, PauseMs: 800}
+
+// HearingPacing is the "hearing" preset for hearing-impaired patients: replies
+// are voiced louder and shown in a short on-screen version
+var HearingPacing = Pacing{Volume: 1.5, ScreenText: true}
+
+// PhonePacing is the "phone" preset: answers can be given with the keypad
+var PhonePacing = Pacing{Keypad: true}
+
+// UnmarshalJSON accepts either a settings object or a preset name ("elderly")
+func (p *Pacing) UnmarshalJSON(data []byte) error {
+	var preset string
+	if err := json.Unmarshal(data, &preset); err == nil {
+		switch preset {
+		case "", "standard":
+			*p = Pacing{}
+		case "elderly":
+			*p = ElderlyPacing
+		case "hearing":
+			*p = HearingPacing
+		case "phone":
+			*p = PhonePacing
+		default:
+			return ErrInvalidPacing
+		}
+		return nil
+	}
+
+	type settings Pacing // drops the method to avoid recursion
+	return json.Unmarshal(data, (*settings)(p))
+}
+
+// Validate checks that the settings are within what the TTS service supports.
+// A voice alias is replaced by its speaker.
+func (p *Pacing) Validate() error {
+	if p.MaxSentenceWords < 0 || p.PauseMs < 0 || p.PauseMs > 3000 {
+		return ErrInvalidPacing
+	}
+	if p.SpeechRate != 0 && (p.SpeechRate < 0.5 || p.SpeechRate > 1.5) {
+		return ErrInvalidPacing
+	}
+	if p.Volume != 0 && (p.Volume < 0.5 || p.Volume > maxVolume) {
+		return ErrInvalidPacing
+	}
+	if p.Voice != "" {
+		voice, err := ResolveVoice(p.Voice)
+		if err != nil {
+			return err
+		}
+		p.Voice = voice
+	}
+	return nil
+}
+
+// SpeechOptions tunes a single TTS request
+type SpeechOptions struct {
+	Voice   string  // empty = service default
+	Rate    float64 // 1.0 = normal tempo
+	PauseMs int     // silence appended after the phrase
+	Volume  float64 // 1.0 = normal gain
+}
+
+// Speech returns the TTS options for p; a nil p means normal pace
+func (p *Pacing) Speech() SpeechOptions {
+	opts := SpeechOptions{Rate: 1.0, Volume: 1.0}
+	if p == nil {
+		return opts
+	}
+	if p.SpeechRate > 0 {
+		opts.Rate = p.SpeechRate
+	}
+	if p.Volume > 0 {
+		opts.Volume = p.Volume
+	}
+	opts.PauseMs = p.PauseMs
+	opts.Voice = p.Voice
+	return opts
+}
+
+// textOnly reports whether replies should not be voiced
+func (p *Pacing) textOnly() bool {
+	return p != nil && p.TextOnly
+}
+
+// Words that can start the second half when a long sentence is split
+var clauseStarts = []string{"и", "а", "но", "или", "чтобы", "потому", "если", "когда", "который", "которая", "которые"}
+
+// Apply enforces the maximum sentence length on a Communicator reply: long
+// sentences are split at the comma or conjunction closest to their middle.
+// Sentences without a natural break are left as they are.
+func (p *Pacing) Apply(text string) string {
+	if p == nil || p.MaxSentenceWords <= 0 {
+		return text
+	}
+
+	var out []string
+	for _, sentence := range splitSentences(text) {
+		out = append(out, p.splitLong(sentence)...)
+	}
+	return strings.Join(out, " ")
+}
+
+func (p *Pacing) splitLong(sentence string) []string {
+	words := strings.Fields(sentence)
+	if len(words) <= p.MaxSentenceWords {
+		return []string{sentence}
+	}
+
+	// Pick the break closest to the middle; breaks right at the edges would leave a stub
+	best := -1
+	for i := 2; i < len(words)-1; i++ {
+		if !strings.HasSuffix(words[i-1], ",") && !isClauseStart(words[i]) {
+			continue
+		}
+		if best < 0 || abs(i-len(words)/2) < abs(best-len(words)/2) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return []string{sentence}
+	}
+
+	first := strings.TrimRight(strings.Join(words[:best], " "), ",;") + "."
+	second := strings.Join(words[best:], " ")
+	second = capitalize(second)
+	return append(p.splitLong(first), p.splitLong(second)...)
+}
+
+func isClauseStart(word string) bool {
+	w := strings.ToLower(strings.Trim(word, ",;:"))
+	for _, c := range clauseStarts {
+		if w == c {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSentences splits text after ".", "!" or "?" followed by a space
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func capitalize(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
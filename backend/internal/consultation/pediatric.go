@@ -0,0 +1,88 @@
+package consultation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ageYearsRe  = regexp.MustCompile(`(\d{1,3})\s*(лет|год)`)
+	ageMonthsRe = regexp.MustCompile(`(\d{1,2})\s*(мес)`)
+	weightRe    = regexp.MustCompile(`(\d{1,3}(?:[.,]\d+)?)\s*(кг|килограмм)`)
+	// An age outside a "Возраст" fact counts only when phrased as one, e.g.
+	// "мне 45 лет" or "возраст 3 года"; "кашель 2 года" is a duration
+	agePhraseRe = regexp.MustCompile(`(?:мне|ему|ей|ребёнку|ребенку|исполнилось|возраст[а-яё]*)\s*:?\s*(\d{1,3}\s*(?:лет|год[а-яё]*)(?:\s*(?:и\s*)?\d{1,2}\s*мес[а-яё]*)?|\d{1,2}\s*мес[а-яё]*)`)
+)
+
+// ParseAgeMonths reads an age like "3 года", "1 год 6 месяцев" or "8 месяцев"
+func ParseAgeMonths(text string) (int, bool) {
+	text = strings.ToLower(text)
+	months, found := 0, false
+	if m := ageYearsRe.FindStringSubmatch(text); m != nil {
+		years, _ := strconv.Atoi(m[1])
+		months += years * 12
+		found = true
+	}
+	if m := ageMonthsRe.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		months += n
+		found = true
+	}
+	return months, found
+}
+
+// ParseWeightKg reads a weight like "14 кг" or "7,5 килограмм"
+func ParseWeightKg(text string) (float64, bool) {
+	m := weightRe.FindStringSubmatch(strings.ToLower(text))
+	if m == nil {
+		return 0, false
+	}
+	kg, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	return kg, err == nil
+}
+
+// PatientAgeMonths finds the age among the Analyst's "Возраст" facts, or
+// else in a fact phrased as an age
+func PatientAgeMonths(facts []MedicalFact) (int, bool) {
+	for _, f := range facts {
+		if !strings.EqualFold(f.Category, "Возраст") {
+			continue
+		}
+		if months, ok := ParseAgeMonths(f.Description); ok {
+			return months, true
+		}
+		if years, err := strconv.Atoi(strings.TrimSpace(f.Description)); err == nil {
+			return years * 12, true
+		}
+	}
+	// Fall back to a fact stating the age in other words
+	for _, f := range facts {
+		if m := agePhraseRe.FindStringSubmatch(strings.ToLower(f.Description)); m != nil {
+			if months, ok := ParseAgeMonths(m[1]); ok {
+				return months, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// childInfo collects the child's age and weight from the facts, nil if neither is known yet
+func childInfo(facts []MedicalFact) *ChildInfo {
+	info := &ChildInfo{}
+	if months, ok := PatientAgeMonths(facts); ok {
+		info.AgeMonths = months
+	}
+	for _, f := range facts {
+		if !strings.EqualFold(f.Category, "Вес") {
+			continue
+		}
+		if kg, ok := ParseWeightKg(f.Description); ok {
+			info.WeightKg = kg
+		}
+	}
+	if info.AgeMonths == 0 && info.WeightKg == 0 {
+		return nil
+	}
+	return info
+}
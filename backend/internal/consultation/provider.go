@@ -0,0 +1,63 @@
+package consultation
+
+import (
+	"context"
+	"sync"
+)
+
+type providerKey struct{}
+
+// HoldingProvider is recorded by the agent client when no provider could
+// answer and the patient got the holding message instead
+const HoldingProvider = "holding"
+
+// servedBy records the LLM provider that answered the Communicator in one
+// turn, and the thinking of a reasoning model when it is kept for audit
+type servedBy struct {
+	mu        sync.Mutex
+	name      string
+	reasoning string
+	tools     bool       // the answering provider was offered the tools
+	calls     []ToolCall // tool calls of the reply, see RecordToolCalls
+}
+
+func withServedBy(ctx context.Context) (context.Context, *servedBy) {
+	s := &servedBy{}
+	return context.WithValue(ctx, providerKey{}, s), s
+}
+
+// RecordProvider is called by the agent client with the provider that
+// answered. Outside a Communicator call it does nothing.
+func RecordProvider(ctx context.Context, provider string) {
+	s, _ := ctx.Value(providerKey{}).(*servedBy)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = provider
+}
+
+// RecordReasoning is called by the agent client with the Communicator's
+// thinking. Outside a Communicator call it does nothing.
+func RecordReasoning(ctx context.Context, reasoning string) {
+	s, _ := ctx.Value(providerKey{}).(*servedBy)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reasoning = reasoning
+}
+
+func (s *servedBy) thinking() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reasoning
+}
+
+func (s *servedBy) provider() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
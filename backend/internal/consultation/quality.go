@@ -0,0 +1,35 @@
+package consultation
+
+import "time"
+
+// QualityReview is the QA agent's assessment of a finished interview
+type QualityReview struct {
+	Score               int       `json:"score"`    // 0-100 overall
+	Coverage            int       `json:"coverage"` // 0-100, how much of the intake checklist was covered
+	Empathy             int       `json:"empathy"`  // 0-100
+	UnansweredQuestions []string  `json:"unanswered_questions"`
+	Comment             string    `json:"comment"`
+	PromptVersion       string    `json:"prompt_version"` // Communicator prompt the interview ran on
+	ReviewedAt          time.Time `json:"reviewed_at"`
+}
+
+// QualityAggregate averages quality reviews over a group of consultations
+type QualityAggregate struct {
+	Reviewed    int     `json:"reviewed"`
+	AvgScore    float64 `json:"avg_score"`
+	AvgCoverage float64 `json:"avg_coverage"`
+	AvgEmpathy  float64 `json:"avg_empathy"`
+}
+
+// WeeklyQuality is the quality aggregate of consultations created in one week
+type WeeklyQuality struct {
+	Week time.Time `json:"week"`
+	QualityAggregate
+}
+
+// QualityStats lets operators spot prompt regressions over time
+type QualityStats struct {
+	QualityAggregate
+	ByPromptVersion map[string]QualityAggregate `json:"by_prompt_version"`
+	ByWeek          []WeeklyQuality             `json:"by_week"`
+}
@@ -0,0 +1,266 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSlowQuery is the repository call duration logged as slow
+const DefaultSlowQuery = 200 * time.Millisecond
+
+// QueryMetrics records how long repository calls take
+type QueryMetrics interface {
+	ObserveQuery(query string, d time.Duration, slow bool, err error)
+}
+
+// timedRepo times every call of the wrapped repository. Calls over the slow
+// threshold are logged with the consultation and the size of what it holds,
+// since a history or fact list growing in its JSONB column shows up here first.
+type timedRepo struct {
+	next    Repository
+	metrics QueryMetrics
+	slow    time.Duration
+}
+
+// NewTimedRepository wraps next with duration metrics and a slow-query log;
+// slow <= 0 turns the log off
+func NewTimedRepository(next Repository, metrics QueryMetrics, slow time.Duration) Repository {
+	r := &timedRepo{next: next, metrics: metrics, slow: slow}
+	if log, ok := next.(eventLog); ok {
+		return &timedEventRepo{timedRepo: r, log: log}
+	}
+	return r
+}
+
+// timedEventRepo keeps the event log of an event-sourced repository reachable
+type timedEventRepo struct {
+	*timedRepo
+	log eventLog
+}
+
+func (r *timedEventRepo) Events(ctx context.Context, id uuid.UUID) (events []Event, err error) {
+	defer r.observe("Events", id, time.Now(), nil, &err)
+	return r.log.Events(ctx, id)
+}
+
+// observe is deferred by every call; err points at the call's named result
+func (r *timedRepo) observe(query string, id uuid.UUID, start time.Time, c *Consultation, err *error) {
+	d := time.Since(start)
+	slow := r.slow > 0 && d >= r.slow
+	r.metrics.ObserveQuery(query, d, slow, *err)
+	if !slow {
+		return
+	}
+	if id == uuid.Nil {
+		fmt.Printf("Slow repository call %s: %v\n", query, d.Round(time.Millisecond))
+		return
+	}
+	size := ""
+	if c != nil {
+		size = fmt.Sprintf(" (%d messages, %d facts)", len(c.History), len(c.ExtractedFacts))
+	}
+	fmt.Printf("Slow repository call %s for consultation %s: %v%s\n", query, id, d.Round(time.Millisecond), size)
+}
+
+func (r *timedRepo) GetByID(ctx context.Context, id uuid.UUID) (c *Consultation, err error) {
+	defer func(start time.Time) { r.observe("GetByID", id, start, c, &err) }(time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *timedRepo) Save(ctx context.Context, c *Consultation) (err error) {
+	defer r.observe("Save", c.ID, time.Now(), c, &err)
+	return r.next.Save(ctx, c)
+}
+
+func (r *timedRepo) Stats(ctx context.Context, loc Location) (stats *Stats, err error) {
+	defer r.observe("Stats", uuid.Nil, time.Now(), nil, &err)
+	return r.next.Stats(ctx, loc)
+}
+
+func (r *timedRepo) MoodRows(ctx context.Context, from, to time.Time, loc Location) (rows []MoodRow, err error) {
+	defer r.observe("MoodRows", uuid.Nil, time.Now(), nil, &err)
+	return r.next.MoodRows(ctx, from, to, loc)
+}
+
+func (r *timedRepo) ResponseRows(ctx context.Context, from, to time.Time, loc Location) (rows []ResponseRow, err error) {
+	defer r.observe("ResponseRows", uuid.Nil, time.Now(), nil, &err)
+	return r.next.ResponseRows(ctx, from, to, loc)
+}
+
+func (r *timedRepo) DeleteOlderThan(ctx context.Context, before time.Time) (n int64, err error) {
+	defer r.observe("DeleteOlderThan", uuid.Nil, time.Now(), nil, &err)
+	return r.next.DeleteOlderThan(ctx, before)
+}
+
+func (r *timedRepo) Search(ctx context.Context, filter SearchFilter) (ids []uuid.UUID, err error) {
+	defer r.observe("Search", uuid.Nil, time.Now(), nil, &err)
+	return r.next.Search(ctx, filter)
+}
+
+func (r *timedRepo) PendingReviews(ctx context.Context, loc Location) (items []ReviewQueueItem, err error) {
+	defer r.observe("PendingReviews", uuid.Nil, time.Now(), nil, &err)
+	return r.next.PendingReviews(ctx, loc)
+}
+
+func (r *timedRepo) CompletedBetween(ctx context.Context, from, to time.Time) (ids []uuid.UUID, err error) {
+	defer r.observe("CompletedBetween", uuid.Nil, time.Now(), nil, &err)
+	return r.next.CompletedBetween(ctx, from, to)
+}
+
+func (r *timedRepo) AddLink(ctx context.Context, consultationID, linkedID uuid.UUID, relation LinkRelation) (err error) {
+	defer r.observe("AddLink", consultationID, time.Now(), nil, &err)
+	return r.next.AddLink(ctx, consultationID, linkedID, relation)
+}
+
+func (r *timedRepo) BoardEntries(ctx context.Context, since time.Time, loc Location) (entries []BoardEntry, err error) {
+	defer r.observe("BoardEntries", uuid.Nil, time.Now(), nil, &err)
+	return r.next.BoardEntries(ctx, since, loc)
+}
+
+func (r *timedRepo) Summaries(ctx context.Context, since time.Time) (summaries []Summary, err error) {
+	defer r.observe("Summaries", uuid.Nil, time.Now(), nil, &err)
+	return r.next.Summaries(ctx, since)
+}
+
+func (r *timedRepo) OpenConsultation(ctx context.Context, patientID uuid.UUID, since time.Time) (c *Consultation, err error) {
+	defer r.observe("OpenConsultation", uuid.Nil, time.Now(), nil, &err)
+	return r.next.OpenConsultation(ctx, patientID, since)
+}
+
+func (r *timedRepo) SetTags(ctx context.Context, consultationID uuid.UUID, tags []string) (err error) {
+	defer r.observe("SetTags", consultationID, time.Now(), nil, &err)
+	return r.next.SetTags(ctx, consultationID, tags)
+}
+
+func (r *timedRepo) AddNote(ctx context.Context, consultationID uuid.UUID, note Note) (err error) {
+	defer r.observe("AddNote", consultationID, time.Now(), nil, &err)
+	return r.next.AddNote(ctx, consultationID, note)
+}
+
+func (r *timedRepo) AddAbuseIncident(ctx context.Context, consultationID uuid.UUID, incident AbuseIncident) (err error) {
+	defer r.observe("AddAbuseIncident", consultationID, time.Now(), nil, &err)
+	return r.next.AddAbuseIncident(ctx, consultationID, incident)
+}
+
+func (r *timedRepo) AddRedFlag(ctx context.Context, consultationID uuid.UUID, flag RedFlag) (added bool, err error) {
+	defer r.observe("AddRedFlag", consultationID, time.Now(), nil, &err)
+	return r.next.AddRedFlag(ctx, consultationID, flag)
+}
+
+func (r *timedRepo) MarkRedFlagNotified(ctx context.Context, consultationID uuid.UUID, category RedFlagCategory) (err error) {
+	defer r.observe("MarkRedFlagNotified", consultationID, time.Now(), nil, &err)
+	return r.next.MarkRedFlagNotified(ctx, consultationID, category)
+}
+
+func (r *timedRepo) AddVital(ctx context.Context, consultationID uuid.UUID, m Measurement) (err error) {
+	defer r.observe("AddVital", consultationID, time.Now(), nil, &err)
+	return r.next.AddVital(ctx, consultationID, m)
+}
+
+func (r *timedRepo) AddDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) (err error) {
+	defer r.observe("AddDoctorQuestion", consultationID, time.Now(), nil, &err)
+	return r.next.AddDoctorQuestion(ctx, consultationID, q)
+}
+
+func (r *timedRepo) UpdateDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) (err error) {
+	defer r.observe("UpdateDoctorQuestion", consultationID, time.Now(), nil, &err)
+	return r.next.UpdateDoctorQuestion(ctx, consultationID, q)
+}
+
+func (r *timedRepo) FindByTicket(ctx context.Context, ticket int, since time.Time) (id uuid.UUID, err error) {
+	defer r.observe("FindByTicket", uuid.Nil, time.Now(), nil, &err)
+	return r.next.FindByTicket(ctx, ticket, since)
+}
+
+func (r *timedRepo) SetQueuedQuestions(ctx context.Context, consultationID uuid.UUID, questions []string) (err error) {
+	defer r.observe("SetQueuedQuestions", consultationID, time.Now(), nil, &err)
+	return r.next.SetQueuedQuestions(ctx, consultationID, questions)
+}
+
+func (r *timedRepo) SetChiefComplaint(ctx context.Context, consultationID uuid.UUID, complaint ChiefComplaint) (err error) {
+	defer r.observe("SetChiefComplaint", consultationID, time.Now(), nil, &err)
+	return r.next.SetChiefComplaint(ctx, consultationID, complaint)
+}
+
+func (r *timedRepo) SetAssignment(ctx context.Context, consultationID uuid.UUID, a Assignment) (err error) {
+	defer r.observe("SetAssignment", consultationID, time.Now(), nil, &err)
+	return r.next.SetAssignment(ctx, consultationID, a)
+}
+
+func (r *timedRepo) MarkReportDispatched(ctx context.Context, consultationID uuid.UUID, at time.Time) (err error) {
+	defer r.observe("MarkReportDispatched", consultationID, time.Now(), nil, &err)
+	return r.next.MarkReportDispatched(ctx, consultationID, at)
+}
+
+func (r *timedRepo) SetAcknowledgment(ctx context.Context, consultationID uuid.UUID, a Acknowledgment) (err error) {
+	defer r.observe("SetAcknowledgment", consultationID, time.Now(), nil, &err)
+	return r.next.SetAcknowledgment(ctx, consultationID, a)
+}
+
+func (r *timedRepo) SetBridge(ctx context.Context, consultationID uuid.UUID, b Bridge) (err error) {
+	defer r.observe("SetBridge", consultationID, time.Now(), nil, &err)
+	return r.next.SetBridge(ctx, consultationID, b)
+}
+
+func (r *timedRepo) SetConfigStamp(ctx context.Context, consultationID uuid.UUID, stamp ConfigStamp) (err error) {
+	defer r.observe("SetConfigStamp", consultationID, time.Now(), nil, &err)
+	return r.next.SetConfigStamp(ctx, consultationID, stamp)
+}
+
+func (r *timedRepo) AppendMessage(ctx context.Context, consultationID uuid.UUID, m Message) (err error) {
+	defer r.observe("AppendMessage", consultationID, time.Now(), nil, &err)
+	return r.next.AppendMessage(ctx, consultationID, m)
+}
+
+func (r *timedRepo) SaveTurnTimings(ctx context.Context, consultationID uuid.UUID, t TurnTimings, sloMs int64, slow bool) (err error) {
+	defer r.observe("SaveTurnTimings", consultationID, time.Now(), nil, &err)
+	return r.next.SaveTurnTimings(ctx, consultationID, t, sloMs, slow)
+}
+
+func (r *timedRepo) AddAudio(ctx context.Context, consultationID uuid.UUID, segment AudioSegment) (err error) {
+	defer r.observe("AddAudio", consultationID, time.Now(), nil, &err)
+	return r.next.AddAudio(ctx, consultationID, segment)
+}
+
+func (r *timedRepo) AudioSegments(ctx context.Context, consultationID uuid.UUID) (segments []AudioSegment, err error) {
+	defer r.observe("AudioSegments", consultationID, time.Now(), nil, &err)
+	return r.next.AudioSegments(ctx, consultationID)
+}
+
+func (r *timedRepo) AddReasoning(ctx context.Context, consultationID uuid.UUID, t ReasoningTrace) (err error) {
+	defer r.observe("AddReasoning", consultationID, time.Now(), nil, &err)
+	return r.next.AddReasoning(ctx, consultationID, t)
+}
+
+func (r *timedRepo) ReasoningTraces(ctx context.Context, consultationID uuid.UUID) (traces []ReasoningTrace, err error) {
+	defer r.observe("ReasoningTraces", consultationID, time.Now(), nil, &err)
+	return r.next.ReasoningTraces(ctx, consultationID)
+}
+
+func (r *timedRepo) BeginTurn(ctx context.Context, t *TurnCheckpoint) (err error) {
+	defer r.observe("BeginTurn", t.ConsultationID, time.Now(), nil, &err)
+	return r.next.BeginTurn(ctx, t)
+}
+
+func (r *timedRepo) SetTurnStage(ctx context.Context, consultationID uuid.UUID, id int64, stage TurnStage, forceComplete bool) (err error) {
+	defer r.observe("SetTurnStage", consultationID, time.Now(), nil, &err)
+	return r.next.SetTurnStage(ctx, consultationID, id, stage, forceComplete)
+}
+
+func (r *timedRepo) UnfinishedTurns(ctx context.Context) (turns []TurnCheckpoint, err error) {
+	defer r.observe("UnfinishedTurns", uuid.Nil, time.Now(), nil, &err)
+	return r.next.UnfinishedTurns(ctx)
+}
+
+func (r *timedRepo) SessionChannel(ctx context.Context, consultationID uuid.UUID) (channel int, err error) {
+	defer r.observe("SessionChannel", consultationID, time.Now(), nil, &err)
+	return r.next.SessionChannel(ctx, consultationID)
+}
+
+func (r *timedRepo) AdvanceSessionChannel(ctx context.Context, consultationID uuid.UUID) (channel int, err error) {
+	defer r.observe("AdvanceSessionChannel", consultationID, time.Now(), nil, &err)
+	return r.next.AdvanceSessionChannel(ctx, consultationID)
+}
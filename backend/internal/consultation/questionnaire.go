@@ -0,0 +1,121 @@
+package consultation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidQuestionnaire is returned for unknown instruments or malformed answers
+var ErrInvalidQuestionnaire = errors.New("invalid questionnaire")
+
+// Questionnaire is a scored patient-reported outcome measure (PROM) filled in before the visit
+type Questionnaire struct {
+	Instrument     string    `json:"instrument"` // e.g., "phq9"
+	Title          string    `json:"title"`
+	Answers        []int     `json:"answers"`
+	Score          int       `json:"score"`
+	MaxScore       int       `json:"max_score"`
+	Interpretation string    `json:"interpretation"`
+	CompletedAt    time.Time `json:"completed_at"`
+	ImportedAt     time.Time `json:"imported_at"`
+}
+
+// severityBand maps a total score to its interpretation, bands are ordered by Min
+type severityBand struct {
+	Min   int
+	Label string
+}
+
+type instrument struct {
+	Title        string
+	Items        int
+	MaxItem      int
+	Bands        []severityBand
+	Annotate     func(answers []int) string // optional item-level warning
+	SelfHarmItem int                        // 1-based item about self-harm, 0 when there is none
+}
+
+var instruments = map[string]instrument{
+	"phq9": {
+		Title: "PHQ-9 (депрессия)", Items: 9, MaxItem: 3, SelfHarmItem: 9,
+		Bands: []severityBand{
+			{0, "минимальная"}, {5, "лёгкая"}, {10, "умеренная"}, {15, "умеренно тяжёлая"}, {20, "тяжёлая"},
+		},
+		Annotate: func(answers []int) string {
+			// Item 9 asks about thoughts of self-harm
+			if answers[8] > 0 {
+				return "положительный ответ на пункт 9 (мысли о самоповреждении)"
+			}
+			return ""
+		},
+	},
+	"gad7": {
+		Title: "GAD-7 (тревога)", Items: 7, MaxItem: 3,
+		Bands: []severityBand{{0, "минимальная"}, {5, "лёгкая"}, {10, "умеренная"}, {15, "тяжёлая"}},
+	},
+	"pain_nrs": {
+		Title: "Числовая шкала боли (NRS)", Items: 1, MaxItem: 10,
+		Bands: []severityBand{{0, "нет боли"}, {1, "слабая"}, {4, "умеренная"}, {7, "сильная"}},
+	},
+}
+
+// ScoreQuestionnaire validates the answers and computes the total score and its interpretation
+func ScoreQuestionnaire(name string, answers []int, completedAt time.Time) (*Questionnaire, error) {
+	inst, ok := instruments[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown instrument %q", ErrInvalidQuestionnaire, name)
+	}
+	if len(answers) != inst.Items {
+		return nil, fmt.Errorf("%w: %s expects %d answers, got %d", ErrInvalidQuestionnaire, name, inst.Items, len(answers))
+	}
+
+	score := 0
+	for i, a := range answers {
+		if a < 0 || a > inst.MaxItem {
+			return nil, fmt.Errorf("%w: answer %d must be between 0 and %d", ErrInvalidQuestionnaire, i+1, inst.MaxItem)
+		}
+		score += a
+	}
+
+	interpretation := ""
+	for _, b := range inst.Bands {
+		if score >= b.Min {
+			interpretation = b.Label
+		}
+	}
+	if inst.Annotate != nil {
+		if note := inst.Annotate(answers); note != "" {
+			interpretation += "; " + note
+		}
+	}
+
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+	return &Questionnaire{
+		Instrument:     name,
+		Title:          inst.Title,
+		Answers:        answers,
+		Score:          score,
+		MaxScore:       inst.Items * inst.MaxItem,
+		Interpretation: interpretation,
+		CompletedAt:    completedAt,
+		ImportedAt:     time.Now(),
+	}, nil
+}
+
+// Summary is a one-line description used by the Communicator and the report
+func (q Questionnaire) Summary() string {
+	return fmt.Sprintf("%s: %d из %d — %s", q.Title, q.Score, q.MaxScore, q.Interpretation)
+}
+
+// selfHarmItem returns the number of the self-harm item and the answer to
+// it, 0 when the instrument has no such item
+func (q Questionnaire) selfHarmItem() (int, int) {
+	item := instruments[q.Instrument].SelfHarmItem
+	if item == 0 || len(q.Answers) < item {
+		return 0, 0
+	}
+	return item, q.Answers[item-1]
+}
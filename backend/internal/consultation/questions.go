@@ -0,0 +1,106 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// QuestionMode controls what happens when the Communicator breaks the
+// "one question at a time" rule
+type QuestionMode string
+
+const (
+	QuestionsOff      QuestionMode = "off"      // replies are left as is
+	QuestionsTruncate QuestionMode = "truncate" // the reply is cut after its first question
+	QuestionsQueue    QuestionMode = "queue"    // as truncate, the cut questions are asked on later turns
+)
+
+// At most this many cut questions wait for later turns
+const maxQueuedQuestions = 3
+
+// ParseQuestionMode accepts "off", "truncate" and "queue"; empty means queue
+func ParseQuestionMode(s string) (QuestionMode, error) {
+	switch mode := QuestionMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return QuestionsQueue, nil
+	case QuestionsOff, QuestionsTruncate, QuestionsQueue:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown question mode %q", s)
+}
+
+// splitQuestions cuts text after its first question. rest holds the later
+// questions; ok is false when text asks at most one.
+func splitQuestions(text string) (first string, rest []string, ok bool) {
+	sentences := splitSentences(text)
+	for i, s := range sentences {
+		if !isQuestion(s) {
+			continue
+		}
+		rest = questionsIn(strings.Join(sentences[i+1:], " "))
+		if len(rest) == 0 {
+			return text, nil, false
+		}
+		return strings.Join(sentences[:i+1], " "), rest, true
+	}
+	return text, nil, false
+}
+
+// questionsIn returns the sentences of text that are questions
+func questionsIn(text string) []string {
+	var questions []string
+	for _, s := range splitSentences(text) {
+		if isQuestion(s) {
+			questions = append(questions, s)
+		}
+	}
+	return questions
+}
+
+func isQuestion(sentence string) bool {
+	return strings.HasSuffix(strings.TrimRight(sentence, `"»)`), "?")
+}
+
+// limitQuestions applies the question mode to a full Communicator reply
+func (s *service) limitQuestions(response string) (string, []string) {
+	if s.questions == QuestionsOff {
+		return response, nil
+	}
+	first, rest, ok := splitQuestions(response)
+	if !ok {
+		return response, nil
+	}
+	return first, rest
+}
+
+// advanceQuestions updates the queue after a Communicator turn: the head was
+// offered to the Communicator this turn and is consumed (unless a doctor's
+// question took its place), and newly cut questions replace the rest because
+// they follow from the latest answer.
+// The queue has its own column, so stale background saves cannot restore it.
+func (s *service) advanceQuestions(ctx context.Context, c *Consultation, cut []string) {
+	if len(cut) > 0 {
+		fmt.Printf("Communicator asked %d questions at once in consultation %s, keeping the first\n", len(cut)+1, c.ID)
+	}
+
+	queue := c.QueuedQuestions
+	if len(queue) > 0 && c.pendingDoctorQuestion() == nil {
+		queue = queue[1:]
+	}
+	if s.questions == QuestionsQueue && len(cut) > 0 {
+		queue = cut
+	}
+	if len(queue) > maxQueuedQuestions {
+		queue = queue[:maxQueuedQuestions]
+	}
+	if slices.Equal(queue, c.QueuedQuestions) {
+		return
+	}
+
+	c.QueuedQuestions = queue
+	if err := s.repo.SetQueuedQuestions(ctx, c.ID, queue); err != nil {
+		fmt.Printf("Failed to save queued questions: %v\n", err)
+	}
+}
@@ -0,0 +1,29 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReasoningTrace is what a reasoning model thought before a Communicator
+// reply. It is kept apart from the History, which the patient's device
+// reads, and only when the agent client is configured to keep it.
+type ReasoningTrace struct {
+	MessageIndex int       `json:"message_index"` // the reply in the History
+	Provider     string    `json:"provider"`
+	Text         string    `json:"text"`
+	At           time.Time `json:"at"`
+}
+
+// keepReasoning stores the thinking behind the reply just added to the History
+func (s *service) keepReasoning(ctx context.Context, c *Consultation, served *servedBy) {
+	text := served.thinking()
+	if text == "" {
+		return
+	}
+	t := ReasoningTrace{MessageIndex: len(c.History) - 1, Provider: served.provider(), Text: text, At: time.Now()}
+	if err := s.repo.AddReasoning(context.WithoutCancel(ctx), c.ID, t); err != nil {
+		fmt.Printf("Failed to keep the reasoning of consultation %s: %v\n", c.ID, err)
+	}
+}
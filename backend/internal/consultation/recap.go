@@ -0,0 +1,148 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"medical-ai-agent/internal/flags"
+)
+
+// Recap is the read-back of the key facts before the interview ends. The
+// patient's "yes" completes the consultation; a correction updates the facts
+// and the recap is read again, at most maxRecapRounds times.
+type Recap struct {
+	Active      bool       `json:"active"`
+	Text        string     `json:"text"`
+	Rounds      int        `json:"rounds"`
+	Corrections []string   `json:"corrections,omitempty"` // the patient's answers that changed the facts
+	Confirmed   bool       `json:"confirmed"`
+	AskedAt     time.Time  `json:"asked_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// RecapCheck is the patient's answer to a recap. Facts is the corrected fact
+// list when the patient did not confirm, nil when nothing needs to change.
+type RecapCheck struct {
+	Confirmed bool          `json:"confirmed"`
+	Facts     []MedicalFact `json:"facts"`
+}
+
+const (
+	maxRecapRounds = 2
+	recapFacts     = 5
+
+	recapIntro     = "Прежде чем закончить, проверю, правильно ли я вас понял. "
+	recapQuestion  = " Всё верно?"
+	recapCorrected = "Спасибо, поправил. "
+	recapConfirmed = "Спасибо, я всё записал. Врач скоро подойдет."
+	recapGaveUp    = "Спасибо, я передам врачу ваши уточнения. Врач скоро подойдет."
+)
+
+// ReportLine tells the doctor whether the patient agreed with the read-back
+func (r *Recap) ReportLine() string {
+	if r.Confirmed && len(r.Corrections) == 0 {
+		return "Пациент подтвердил пересказ собранных фактов."
+	}
+	line := fmt.Sprintf("Пациент поправил пересказ фактов: «%s».", strings.Join(r.Corrections, "», «"))
+	if !r.Confirmed {
+		line += " Исправленный пересказ пациент не подтвердил."
+	}
+	return line
+}
+
+// Answered reports whether the patient answered the recap and the interview may end
+func (r *Recap) Answered() bool {
+	return r != nil && !r.Active && r.FinishedAt != nil
+}
+
+// recapText reads back the most certain facts, in the order they came up
+func recapText(facts []MedicalFact) string {
+	var picked []string
+	seen := map[string]bool{}
+	for _, confident := range []bool{true, false} {
+		for _, f := range facts {
+			d := strings.TrimRight(strings.TrimSpace(f.Description), ".")
+			if d == "" || seen[d] || isConfident(f) != confident || len(picked) == recapFacts {
+				continue
+			}
+			seen[d] = true
+			picked = append(picked, lowerFirst(d))
+		}
+	}
+	if len(picked) == 0 {
+		return ""
+	}
+	return "Итак: " + strings.Join(picked, ", ") + "." + recapQuestion
+}
+
+func isConfident(f MedicalFact) bool {
+	return f.Confidence == "High" || f.Confidence == "Высокая"
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	// Keep abbreviations like "ОРВИ" as they are
+	if next, _ := utf8.DecodeRuneInString(s[n:]); unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// startRecap replaces the forced completion of a Communicator farewell with
+// the read-back of the facts. It returns false when the recap is off, was
+// already answered or there is nothing to read back.
+func (s *service) startRecap(c *Consultation) (string, bool) {
+	if c.Recap != nil || c.IsComplete || !s.flags.Enabled(flags.FactRecap, c.ID) {
+		return "", false
+	}
+	text := recapText(c.ExtractedFacts)
+	if text == "" {
+		return "", false
+	}
+	c.Recap = &Recap{Active: true, Text: text, Rounds: 1, AskedAt: time.Now()}
+	fmt.Printf("Reading the facts back to the patient in consultation %s\n", c.ID)
+	return recapIntro + text, true
+}
+
+// recapTurn takes the patient's answer to the recap instead of the
+// Communicator. A correction replaces the facts with the updated list and
+// the recap is read again. It returns false when no recap is waiting.
+func (s *service) recapTurn(ctx context.Context, c *Consultation, text string) (string, bool) {
+	r := c.Recap
+	if r == nil || !r.Active {
+		return "", false
+	}
+
+	check, err := s.aiClient.CheckRecap(ctx, r.Text, text, c.ExtractedFacts)
+	if err != nil {
+		// The patient must not be stuck in the recap; the doctor still sees the whole dialogue
+		fmt.Printf("Recap check failed in consultation %s: %v\n", c.ID, err)
+		check = &RecapCheck{Confirmed: true}
+	}
+
+	now := time.Now()
+	if check.Confirmed {
+		r.Active, r.Confirmed, r.FinishedAt = false, true, &now
+		return recapConfirmed, true
+	}
+
+	r.Corrections = append(r.Corrections, text)
+	if len(check.Facts) > 0 {
+		c.ExtractedFacts = check.Facts
+		for i := range c.ExtractedFacts {
+			c.ExtractedFacts[i].Code = s.normalizer.Normalize(c.ExtractedFacts[i].Description)
+		}
+	}
+	if r.Rounds >= maxRecapRounds {
+		r.Active, r.FinishedAt = false, &now
+		return recapGaveUp, true
+	}
+	r.Rounds++
+	r.Text = recapText(c.ExtractedFacts)
+	r.AskedAt = now
+	return recapCorrected + r.Text, true
+}
@@ -0,0 +1,66 @@
+package consultation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownRecipient = errors.New("unknown report recipient")
+
+// More recipients than this is more likely a mistake than a care team
+const maxRecipients = 5
+
+// Redaction is what a report recipient may see
+type Redaction string
+
+const (
+	RedactionFull       Redaction = "full"       // the report the doctor on duty gets
+	RedactionAnonymized Redaction = "anonymized" // identifiers pseudonymized and free text redacted, as in the research export
+)
+
+// ReportRecipient is a doctor from the registry who gets the report besides
+// the doctor on duty, e.g. the referring GP
+type ReportRecipient struct {
+	DoctorID  string    `json:"doctor_id"`
+	Redaction Redaction `json:"redaction,omitempty"` // "full" (default) or "anonymized"
+}
+
+// ValidateRecipients trims the doctor ids, fills in the default redaction and
+// checks that no doctor is listed twice. Whether the doctors exist is checked
+// against the registry at consultation creation.
+func ValidateRecipients(recipients []ReportRecipient) error {
+	if len(recipients) > maxRecipients {
+		return fmt.Errorf("more than %d report recipients", maxRecipients)
+	}
+	seen := map[string]bool{}
+	for i := range recipients {
+		r := &recipients[i]
+		r.DoctorID = strings.TrimSpace(r.DoctorID)
+		if r.DoctorID == "" {
+			return fmt.Errorf("report recipient %d has no doctor_id", i+1)
+		}
+		if seen[r.DoctorID] {
+			return fmt.Errorf("report recipient %q is listed twice", r.DoctorID)
+		}
+		seen[r.DoctorID] = true
+		switch r.Redaction {
+		case "":
+			r.Redaction = RedactionFull
+		case RedactionFull, RedactionAnonymized:
+		default:
+			return fmt.Errorf("invalid redaction %q", r.Redaction)
+		}
+	}
+	return nil
+}
+
+// checkRecipients rejects doctors the report service does not know
+func (s *service) checkRecipients(recipients []ReportRecipient) error {
+	for _, r := range recipients {
+		if !s.reportSvc.KnownDoctor(r.DoctorID) {
+			return fmt.Errorf("%w: %s", ErrUnknownRecipient, r.DoctorID)
+		}
+	}
+	return nil
+}
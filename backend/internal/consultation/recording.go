@@ -0,0 +1,156 @@
+package consultation
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"medical-ai-agent/internal/flags"
+
+	"github.com/google/uuid"
+)
+
+var ErrNoRecording = errors.New("no recorded audio")
+
+// Silence inserted where the speaker changes, and between the sentences of
+// a streamed reply, in the merged recording
+const (
+	speakerGap  = 700 * time.Millisecond
+	sentenceGap = 150 * time.Millisecond
+)
+
+// AudioSegment is one piece of session audio: a patient upload or a
+// synthesized reply (a whole reply or one streamed sentence)
+type AudioSegment struct {
+	Role string // "user" or "assistant", as in Message
+	Data []byte
+	At   time.Time
+}
+
+// RecordingEnabled reports whether the consultation's audio is kept for review
+func (s *service) RecordingEnabled(consultationID uuid.UUID) bool {
+	return s.flags.Enabled(flags.SessionRecording, consultationID)
+}
+
+// RecordAudio stores a segment of session audio if recording is enabled. The
+// write happens in the background so it never delays the turn.
+func (s *service) RecordAudio(ctx context.Context, consultationID uuid.UUID, role string, data []byte) {
+	if len(data) == 0 || !s.RecordingEnabled(consultationID) {
+		return
+	}
+	segment := AudioSegment{Role: role, Data: data, At: time.Now()}
+	go func() {
+		if err := s.repo.AddAudio(context.WithoutCancel(ctx), consultationID, segment); err != nil {
+			fmt.Printf("Failed to record %s audio for consultation %s: %v\n", role, consultationID, err)
+		}
+	}()
+}
+
+// Recording merges the stored audio into one chronological WAV file. Segments
+// that are not PCM WAV cannot be joined without decoding and are skipped.
+func (s *service) Recording(ctx context.Context, consultationID uuid.UUID) ([]byte, int, error) {
+	segments, err := s.repo.AudioSegments(ctx, consultationID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(segments) == 0 {
+		return nil, 0, ErrNoRecording
+	}
+	return mergeRecording(segments)
+}
+
+// mergeRecording converts the segments to the mono 16-bit format of the first
+// usable one and joins them with silence. It returns the number of skipped segments.
+func mergeRecording(segments []AudioSegment) ([]byte, int, error) {
+	var out []int16
+	rate, skipped := 0, 0
+	prevRole := ""
+	for _, seg := range segments {
+		samples, segRate, err := decodePCM16(seg.Data)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if rate == 0 {
+			rate = segRate
+		} else {
+			gap := sentenceGap
+			if seg.Role != prevRole {
+				gap = speakerGap
+			}
+			out = append(out, make([]int16, int(gap.Seconds()*float64(rate)))...)
+		}
+		out = append(out, resample(samples, segRate, rate)...)
+		prevRole = seg.Role
+	}
+	if rate == 0 {
+		return nil, skipped, ErrNoRecording
+	}
+	return encodePCM16(out, rate), skipped, nil
+}
+
+// decodePCM16 reads a 16-bit PCM WAV file as mono samples
+func decodePCM16(b []byte) ([]int16, int, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, 0, fmt.Errorf("not a WAV file")
+	}
+	w, err := parseWAV(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	channels := int(binary.LittleEndian.Uint16(w.format[2:4]))
+	rate := int(binary.LittleEndian.Uint32(w.format[4:8]))
+	bits := binary.LittleEndian.Uint16(w.format[14:16])
+	if bits != 16 || channels == 0 || rate == 0 {
+		return nil, 0, fmt.Errorf("unsupported WAV format: %d-bit, %d channels", bits, channels)
+	}
+
+	frames := len(w.data) / (2 * channels)
+	samples := make([]int16, frames)
+	for i := range samples {
+		sum := 0
+		for ch := 0; ch < channels; ch++ {
+			off := (i*channels + ch) * 2
+			sum += int(int16(binary.LittleEndian.Uint16(w.data[off : off+2])))
+		}
+		samples[i] = int16(sum / channels)
+	}
+	return samples, rate, nil
+}
+
+// resample converts between sample rates by linear interpolation, which is
+// enough for speech review
+func resample(samples []int16, from, to int) []int16 {
+	if from == to || len(samples) == 0 {
+		return samples
+	}
+	out := make([]int16, int(int64(len(samples))*int64(to)/int64(from)))
+	for i := range out {
+		pos := float64(i) * float64(from) / float64(to)
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = int16(float64(samples[j])*(1-frac) + float64(samples[j+1])*frac)
+	}
+	return out
+}
+
+func encodePCM16(samples []int16, rate int) []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:2], 1) // PCM
+	binary.LittleEndian.PutUint16(format[2:4], 1) // mono
+	binary.LittleEndian.PutUint32(format[4:8], uint32(rate))
+	binary.LittleEndian.PutUint32(format[8:12], uint32(rate*2))
+	binary.LittleEndian.PutUint16(format[12:14], 2)
+	binary.LittleEndian.PutUint16(format[14:16], 16)
+
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+	return (&wav{format: format}).file(data.Bytes())
+}
@@ -0,0 +1,137 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RedFlagCategory is a kind of emergency the red-flag screen looks for
+type RedFlagCategory string
+
+const (
+	RedFlagCardiac  RedFlagCategory = "cardiac"  // chest pain spreading to the arm, jaw or back
+	RedFlagStroke   RedFlagCategory = "stroke"   // facial droop, one-sided weakness, slurred speech
+	RedFlagSuicidal RedFlagCategory = "suicidal" // thoughts of suicide or self-harm
+)
+
+var RedFlagCategories = []RedFlagCategory{RedFlagCardiac, RedFlagStroke, RedFlagSuicidal}
+
+// RedFlag is an emergency indicator the red-flag screen found in a patient
+// message. The message itself stays in the History at MessageIndex.
+type RedFlag struct {
+	MessageIndex int             `json:"message_index"`
+	Category     RedFlagCategory `json:"category"`
+	Indicator    string          `json:"indicator"` // what was found, e.g. "боль в груди отдаёт в левую руку"
+	Notified     bool            `json:"notified"`
+	At           time.Time       `json:"at"`
+}
+
+// RedFlagNotifier alerts the doctor about an emergency as soon as it is found
+type RedFlagNotifier interface {
+	NotifyRedFlag(ctx context.Context, c Consultation, flag RedFlag) error
+}
+
+// A failed red-flag alert is retried with exponential backoff; one still not
+// delivered after the last attempt is sent again when a later message shows
+// the same emergency
+const (
+	redFlagAlertAttempts = 6
+	redFlagAlertDelay    = 2 * time.Second
+	redFlagAlertMaxDelay = time.Minute
+)
+
+// screenRedFlags checks the latest patient message for emergency indicators
+// in the background and alerts the doctor right away, without waiting for
+// the Supervisor or the report. A category is alerted once per consultation;
+// suicidal ideation is left to risk screening when its phrases start it.
+// Support console turns are not screened.
+func (s *service) screenRedFlags(c *Consultation, text string) {
+	if author := c.lastTurnInjectedBy(); author != "" {
+		fmt.Printf("Support console: red-flag screen skipped for the turn %s injected into consultation %s\n", author, c.ID)
+		return
+	}
+	question, _ := c.lastAssistantMessage()
+	found := map[RedFlagCategory]bool{}
+	unnotified := map[RedFlagCategory]RedFlag{}
+	for _, f := range c.RedFlags {
+		if f.Notified {
+			found[f.Category] = true
+		} else {
+			unnotified[f.Category] = f
+		}
+	}
+	if _, ok := detectRiskLanguage(text); ok || c.RiskScreening != nil {
+		found[RedFlagSuicidal] = true
+	}
+	if len(found) == len(RedFlagCategories) {
+		return
+	}
+
+	// The turn goes on with c while the screen runs
+	snapshot := *c
+	snapshot.RedFlags = append([]RedFlag(nil), c.RedFlags...)
+	go func(id uuid.UUID, index int) {
+		ctx := context.Background()
+		flags, err := s.aiClient.RunRedFlagScreen(ctx, question, text)
+		if err != nil {
+			fmt.Printf("Red-flag screen failed for consultation %s: %v\n", id, err)
+			return
+		}
+		for _, flag := range flags {
+			if found[flag.Category] {
+				continue
+			}
+			found[flag.Category] = true
+			if earlier, ok := unnotified[flag.Category]; ok {
+				// Recorded before, but the doctor never got the alert
+				fmt.Printf("Red flag %s in consultation %s was not alerted, alerting again\n", flag.Category, id)
+				s.alertRedFlag(ctx, id, snapshot, earlier)
+				continue
+			}
+			flag.MessageIndex, flag.At = index, time.Now()
+
+			// Kept in their own table so the turn's save cannot drop one. The
+			// screen of an overlapping turn may have recorded it first.
+			added, err := s.repo.AddRedFlag(ctx, id, flag)
+			if err != nil {
+				fmt.Printf("Failed to record red flag: %v\n", err)
+				continue
+			}
+			if !added {
+				continue
+			}
+			fmt.Printf("Red flag in consultation %s: %s (%s)\n", id, flag.Category, flag.Indicator)
+
+			snapshot.RedFlags = append(snapshot.RedFlags, flag)
+			s.alertRedFlag(ctx, id, snapshot, flag)
+		}
+	}(c.ID, len(c.History)-1)
+}
+
+// alertRedFlag notifies the doctor, retrying with backoff, and marks the
+// flag notified once the alert is delivered
+func (s *service) alertRedFlag(ctx context.Context, id uuid.UUID, c Consultation, flag RedFlag) {
+	delay := redFlagAlertDelay
+	for attempt := 1; ; attempt++ {
+		err := s.redFlagAlerts.NotifyRedFlag(ctx, c, flag)
+		if err == nil {
+			break
+		}
+		if attempt == redFlagAlertAttempts {
+			fmt.Printf("Failed to alert the doctor about red flag %s in consultation %s after %d attempts: %v\n", flag.Category, id, attempt, err)
+			return
+		}
+		fmt.Printf("Failed to alert the doctor about a red flag (%d/%d), retrying in %v: %v\n", attempt, redFlagAlertAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > redFlagAlertMaxDelay {
+			delay = redFlagAlertMaxDelay
+		}
+	}
+	if err := s.repo.MarkRedFlagNotified(ctx, id, flag.Category); err != nil {
+		fmt.Printf("Failed to mark red flag notified: %v\n", err)
+	}
+}
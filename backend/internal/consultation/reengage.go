@@ -0,0 +1,120 @@
+package consultation
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReEngagePrompt is said once when the patient has not answered for a while
+const ReEngagePrompt = "Вы ещё здесь? Если устали, можно продолжить позже."
+
+// ReEngage adds the re-engagement prompt to the dialogue and voices it. It
+// returns nil when the prompt no longer applies: the consultation finished,
+// the patient spoke meanwhile, or the prompt was already the last message.
+func (s *service) ReEngage(ctx context.Context, consultationID uuid.UUID) (*Reply, error) {
+	// Several watchers of one consultation must not prompt twice
+	s.reengaging.Lock()
+	defer s.reengaging.Unlock()
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.awaitingPatient() {
+		return nil, nil
+	}
+
+	c.History = append(c.History, Message{Role: "assistant", Content: ReEngagePrompt, Timestamp: time.Now()})
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Patient silent in consultation %s, sent re-engagement prompt\n", c.ID)
+
+	reply := &Reply{Text: ReEngagePrompt, Pacing: c.Pacing}
+	if !c.Pacing.textOnly() {
+		if audio, err := s.Speak(ctx, c.ID, ReEngagePrompt, c.Pacing); err == nil {
+			reply.Audio = [][]byte{audio}
+		}
+	}
+	return reply, nil
+}
+
+// awaitingPatient reports whether the assistant spoke last and the patient
+// has not been prompted for silence since
+func (c *Consultation) awaitingPatient() bool {
+	if c.IsComplete || len(c.History) == 0 {
+		return false
+	}
+	last := c.History[len(c.History)-1]
+	return last.Role == "assistant" && last.Content != ReEngagePrompt
+}
+
+// patientActivity remembers when a patient's input last reached the handler.
+// The message is saved only once the turn is over, so a long recording or a
+// slow turn would otherwise look like silence.
+type patientActivity struct {
+	mu   sync.Mutex
+	seen map[uuid.UUID]time.Time
+}
+
+func newPatientActivity() *patientActivity {
+	return &patientActivity{seen: make(map[uuid.UUID]time.Time)}
+}
+
+func (a *patientActivity) touch(id uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Entries only matter for the silence interval, so old ones are swept
+	if len(a.seen) >= 500 {
+		for k, at := range a.seen {
+			if time.Since(at) > time.Hour {
+				delete(a.seen, k)
+			}
+		}
+	}
+	a.seen[id] = time.Now()
+}
+
+func (a *patientActivity) last(id uuid.UUID) time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.seen[id]
+}
+
+// silent reports whether the patient has been quiet for the configured interval
+func (h *Handler) silent(c *Consultation) bool {
+	if h.timeouts.Silence <= 0 || !c.awaitingPatient() {
+		return false
+	}
+	quietSince := c.History[len(c.History)-1].Timestamp
+	if at := h.activity.last(c.ID); at.After(quietSince) {
+		quietSince = at
+	}
+	return time.Since(quietSince) >= h.timeouts.Silence
+}
+
+// reEngage sends the re-engagement prompt to a watcher
+func (h *Handler) reEngage(ctx context.Context, sse *sseWriter, id uuid.UUID) error {
+	reply, err := h.svc.ReEngage(ctx, id)
+	if err != nil {
+		fmt.Printf("Re-engagement failed for consultation %s: %v\n", id, err)
+		return nil
+	}
+	if reply == nil {
+		return nil
+	}
+	if err := sse.Send(StreamEvent{Type: "reengage", Data: reply.Text}); err != nil {
+		return err
+	}
+	for _, chunk := range reply.Audio {
+		if err := sse.Send(StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(chunk)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
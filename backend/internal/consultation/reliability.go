@@ -0,0 +1,108 @@
+package consultation
+
+import (
+	"math"
+	"strings"
+)
+
+// RecommendationResult is the Recommendations agent's output
+type RecommendationResult struct {
+	Text       string    `json:"text"`
+	Confidence int       `json:"confidence"`          // self-reported 0-100, -1 when the model gave none
+	FollowUp   *FollowUp `json:"follow_up,omitempty"` // nil when no follow-up visit is needed
+}
+
+// Reliability tells the doctor how far an AI-only intake can be trusted
+type Reliability struct {
+	Score           int      `json:"score"` // 0-100
+	Level           string   `json:"level"` // "high", "medium", "low"
+	FactConfidence  int      `json:"fact_confidence"`
+	ModelConfidence int      `json:"model_confidence"` // -1 when unknown
+	Notes           []string `json:"notes,omitempty"`
+}
+
+// Weight of the Analyst's per-fact confidence versus the model's own estimate
+const factWeight = 0.6
+
+func confidenceValue(confidence string) float64 {
+	switch strings.ToLower(strings.TrimSpace(confidence)) {
+	case "высокая", "high":
+		return 1.0
+	case "средняя", "medium":
+		return 0.6
+	case "низкая", "low":
+		return 0.3
+	default:
+		return 0.5
+	}
+}
+
+// assessReliability combines per-fact confidences, the model's self-reported
+// confidence and the completeness of the interview into one score
+func assessReliability(c Consultation, modelConfidence int) *Reliability {
+	total, n := 0.0, 0
+	for _, f := range c.ExtractedFacts {
+		total += confidenceValue(f.Confidence)
+		n++
+	}
+	for _, neg := range c.PertinentNegatives {
+		total += confidenceValue(neg.Confidence)
+		n++
+	}
+	factConfidence := 0.0
+	if n > 0 {
+		factConfidence = total / float64(n)
+	}
+
+	score := factConfidence
+	if modelConfidence >= 0 {
+		score = factWeight*factConfidence + (1-factWeight)*float64(modelConfidence)/100
+	}
+
+	var notes []string
+	if len(c.ExtractedFacts) < 3 {
+		notes = append(notes, "собрано мало фактов")
+		score = math.Min(score, 0.5)
+	}
+	userTurns := 0
+	for _, m := range c.History {
+		if m.Role == "user" {
+			userTurns++
+		}
+	}
+	if userTurns < 3 {
+		notes = append(notes, "короткий опрос")
+		score = math.Min(score, 0.5)
+	}
+	if len(c.PendingEpidTopics()) > 0 {
+		notes = append(notes, "эпиданамнез выяснен не полностью")
+		score -= 0.1
+	}
+	if len(c.PendingRequired()) > 0 {
+		notes = append(notes, "обязательные сведения отделения собраны не полностью")
+		score -= 0.1
+	}
+	if c.Mode == ModeMedicationReconciliation && !c.MedicationsComplete() {
+		notes = append(notes, "список препаратов заполнен не полностью")
+		score -= 0.1
+	}
+	if modelConfidence >= 0 && modelConfidence < 50 {
+		notes = append(notes, "модель сообщила о низкой уверенности")
+	}
+
+	r := &Reliability{
+		Score:           int(math.Round(math.Max(score, 0) * 100)),
+		FactConfidence:  int(math.Round(factConfidence * 100)),
+		ModelConfidence: modelConfidence,
+		Notes:           notes,
+	}
+	switch {
+	case r.Score >= 75:
+		r.Level = "high"
+	case r.Score >= 50:
+		r.Level = "medium"
+	default:
+		r.Level = "low"
+	}
+	return r
+}
@@ -13,6 +13,43 @@ import (
 type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Consultation, error)
 	Save(ctx context.Context, c *Consultation) error
+	Stats(ctx context.Context, loc Location) (*Stats, error)
+	MoodRows(ctx context.Context, from, to time.Time, loc Location) ([]MoodRow, error)
+	ResponseRows(ctx context.Context, from, to time.Time, loc Location) ([]ResponseRow, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+	Search(ctx context.Context, filter SearchFilter) ([]uuid.UUID, error)
+	PendingReviews(ctx context.Context, loc Location) ([]ReviewQueueItem, error)
+	CompletedBetween(ctx context.Context, from, to time.Time) ([]uuid.UUID, error)
+	AddLink(ctx context.Context, consultationID, linkedID uuid.UUID, relation LinkRelation) error
+	BoardEntries(ctx context.Context, since time.Time, loc Location) ([]BoardEntry, error)
+	Summaries(ctx context.Context, since time.Time) ([]Summary, error)
+	OpenConsultation(ctx context.Context, patientID uuid.UUID, since time.Time) (*Consultation, error)
+	SetTags(ctx context.Context, consultationID uuid.UUID, tags []string) error
+	AddNote(ctx context.Context, consultationID uuid.UUID, note Note) error
+	AddAbuseIncident(ctx context.Context, consultationID uuid.UUID, incident AbuseIncident) error
+	AddRedFlag(ctx context.Context, consultationID uuid.UUID, flag RedFlag) (bool, error)
+	MarkRedFlagNotified(ctx context.Context, consultationID uuid.UUID, category RedFlagCategory) error
+	AddVital(ctx context.Context, consultationID uuid.UUID, m Measurement) error
+	AddDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) error
+	UpdateDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) error
+	FindByTicket(ctx context.Context, ticket int, since time.Time) (uuid.UUID, error)
+	SetQueuedQuestions(ctx context.Context, consultationID uuid.UUID, questions []string) error
+	SetChiefComplaint(ctx context.Context, consultationID uuid.UUID, complaint ChiefComplaint) error
+	SetAssignment(ctx context.Context, consultationID uuid.UUID, a Assignment) error
+	MarkReportDispatched(ctx context.Context, consultationID uuid.UUID, at time.Time) error
+	SetAcknowledgment(ctx context.Context, consultationID uuid.UUID, a Acknowledgment) error
+	SetBridge(ctx context.Context, consultationID uuid.UUID, b Bridge) error
+	SetConfigStamp(ctx context.Context, consultationID uuid.UUID, stamp ConfigStamp) error
+	AppendMessage(ctx context.Context, consultationID uuid.UUID, m Message) error
+	SaveTurnTimings(ctx context.Context, consultationID uuid.UUID, t TurnTimings, sloMs int64, slow bool) error
+	AddAudio(ctx context.Context, consultationID uuid.UUID, segment AudioSegment) error
+	AudioSegments(ctx context.Context, consultationID uuid.UUID) ([]AudioSegment, error)
+	AddReasoning(ctx context.Context, consultationID uuid.UUID, t ReasoningTrace) error
+	ReasoningTraces(ctx context.Context, consultationID uuid.UUID) ([]ReasoningTrace, error)
+	BeginTurn(ctx context.Context, t *TurnCheckpoint) error
+	SetTurnStage(ctx context.Context, consultationID uuid.UUID, id int64, stage TurnStage, forceComplete bool) error
+	UnfinishedTurns(ctx context.Context) ([]TurnCheckpoint, error)
+	SessionChannels
 }
 
 type postgresRepo struct {
@@ -24,18 +61,57 @@ func NewRepository(db *sql.DB) Repository {
 }
 
 func (r *postgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation, error) {
-	query := `SELECT id, patient_id, history, facts, mood, is_complete, created_at, updated_at FROM consultations WHERE id = $1`
+	query := `SELECT id, patient_id, COALESCE(mode, 'standard'), COALESCE(pediatric, FALSE), child, history, facts, negatives, rule_findings, risk_screening, medications, questionnaires, epid_topics, reliability, quality, review, pacing, COALESCE(ticket, 0), visit, COALESCE(experiment, ''), COALESCE(arm, ''), COALESCE(supervisor_rounds, 0), COALESCE(supervisor_turn, 0), COALESCE(report_revision, 0), wearables, prior_conditions, fact_summary, report_recipients, booking, translation, recap, queued_questions, chief_complaint, assignment, report_dispatched_at, acknowledgment, bridge, config_versions, clarification, COALESCE(department, ''), required_fields, device, mood, is_complete, created_at, updated_at FROM consultations WHERE id = $1`
 	
 	row := r.db.QueryRowContext(ctx, query, id)
 	
 	var c Consultation
-	var historyJSON, factsJSON []byte
+	var historyJSON, factsJSON, negativesJSON, findingsJSON, screeningJSON, medicationsJSON, childJSON, questionnairesJSON, epidJSON, reliabilityJSON, qualityJSON, reviewJSON, pacingJSON, visitJSON, queuedJSON, complaintJSON, requiredJSON, deviceJSON, wearablesJSON, conditionsJSON, summaryJSON, recipientsJSON, bookingJSON, translationJSON, recapJSON, assignmentJSON, acknowledgmentJSON, bridgeJSON, configJSON, clarificationJSON []byte
+	var dispatchedAt sql.NullTime
 	
 	err := row.Scan(
 		&c.ID,
 		&c.PatientID,
+		&c.Mode,
+		&c.Pediatric,
+		&childJSON,
 		&historyJSON,
 		&factsJSON,
+		&negativesJSON,
+		&findingsJSON,
+		&screeningJSON,
+		&medicationsJSON,
+		&questionnairesJSON,
+		&epidJSON,
+		&reliabilityJSON,
+		&qualityJSON,
+		&reviewJSON,
+		&pacingJSON,
+		&c.Ticket,
+		&visitJSON,
+		&c.Experiment,
+		&c.Arm,
+		&c.SupervisorRounds,
+		&c.SupervisorTurn,
+		&c.ReportRevision,
+		&wearablesJSON,
+		&conditionsJSON,
+		&summaryJSON,
+		&recipientsJSON,
+		&bookingJSON,
+		&translationJSON,
+		&recapJSON,
+		&queuedJSON,
+		&complaintJSON,
+		&assignmentJSON,
+		&dispatchedAt,
+		&acknowledgmentJSON,
+		&bridgeJSON,
+		&configJSON,
+		&clarificationJSON,
+		&c.Department,
+		&requiredJSON,
+		&deviceJSON,
 		&c.CurrentMood,
 		&c.IsComplete,
 		&c.CreatedAt,
@@ -43,7 +119,7 @@ func (r *postgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("consultation not found")
+			return nil, ErrConsultationNotFound
 		}
 		return nil, err
 	}
@@ -58,10 +134,581 @@ func (r *postgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*Consultation
 			return nil, fmt.Errorf("failed to unmarshal facts: %w", err)
 		}
 	}
+	if len(negativesJSON) > 0 {
+		if err := json.Unmarshal(negativesJSON, &c.PertinentNegatives); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal negatives: %w", err)
+		}
+	}
+	if len(findingsJSON) > 0 {
+		if err := json.Unmarshal(findingsJSON, &c.RuleFindings); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rule findings: %w", err)
+		}
+	}
+	if len(screeningJSON) > 0 && string(screeningJSON) != "null" {
+		c.RiskScreening = &RiskScreening{}
+		if err := json.Unmarshal(screeningJSON, c.RiskScreening); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal risk screening: %w", err)
+		}
+	}
+	if len(childJSON) > 0 && string(childJSON) != "null" {
+		c.Child = &ChildInfo{}
+		if err := json.Unmarshal(childJSON, c.Child); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal child info: %w", err)
+		}
+	}
+	if len(questionnairesJSON) > 0 {
+		if err := json.Unmarshal(questionnairesJSON, &c.Questionnaires); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal questionnaires: %w", err)
+		}
+	}
+	if len(epidJSON) > 0 {
+		if err := json.Unmarshal(epidJSON, &c.EpidTopics); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal epidemiological topics: %w", err)
+		}
+	}
+	if len(queuedJSON) > 0 {
+		if err := json.Unmarshal(queuedJSON, &c.QueuedQuestions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal queued questions: %w", err)
+		}
+	}
+	if len(requiredJSON) > 0 {
+		if err := json.Unmarshal(requiredJSON, &c.RequiredFields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal required fields: %w", err)
+		}
+	}
+	if len(conditionsJSON) > 0 && string(conditionsJSON) != "null" {
+		if err := json.Unmarshal(conditionsJSON, &c.PriorConditions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal prior conditions: %w", err)
+		}
+	}
+	if len(summaryJSON) > 0 && string(summaryJSON) != "null" {
+		if err := json.Unmarshal(summaryJSON, &c.FactSummary); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fact summary: %w", err)
+		}
+	}
+	if len(recipientsJSON) > 0 && string(recipientsJSON) != "null" {
+		if err := json.Unmarshal(recipientsJSON, &c.Recipients); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal report recipients: %w", err)
+		}
+	}
+	if len(translationJSON) > 0 && string(translationJSON) != "null" {
+		if err := json.Unmarshal(translationJSON, &c.Translation); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal translation: %w", err)
+		}
+	}
+	if len(recapJSON) > 0 && string(recapJSON) != "null" {
+		if err := json.Unmarshal(recapJSON, &c.Recap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recap: %w", err)
+		}
+	}
+	if len(clarificationJSON) > 0 && string(clarificationJSON) != "null" {
+		if err := json.Unmarshal(clarificationJSON, &c.Clarification); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal clarification: %w", err)
+		}
+	}
+	if len(bookingJSON) > 0 && string(bookingJSON) != "null" {
+		if err := json.Unmarshal(bookingJSON, &c.Booking); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal booking: %w", err)
+		}
+	}
+	if len(wearablesJSON) > 0 && string(wearablesJSON) != "null" {
+		if err := json.Unmarshal(wearablesJSON, &c.Wearables); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal wearables: %w", err)
+		}
+	}
+	if len(deviceJSON) > 0 && string(deviceJSON) != "null" {
+		if err := json.Unmarshal(deviceJSON, &c.Device); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal device: %w", err)
+		}
+	}
+	if len(complaintJSON) > 0 && string(complaintJSON) != "null" {
+		c.ChiefComplaint = &ChiefComplaint{}
+		if err := json.Unmarshal(complaintJSON, c.ChiefComplaint); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chief complaint: %w", err)
+		}
+	}
+	if len(assignmentJSON) > 0 && string(assignmentJSON) != "null" {
+		if err := json.Unmarshal(assignmentJSON, &c.Assignment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal assignment: %w", err)
+		}
+	}
+	if dispatchedAt.Valid {
+		c.ReportDispatchedAt = &dispatchedAt.Time
+	}
+	if len(acknowledgmentJSON) > 0 && string(acknowledgmentJSON) != "null" {
+		if err := json.Unmarshal(acknowledgmentJSON, &c.Acknowledgment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal acknowledgment: %w", err)
+		}
+	}
+	if len(bridgeJSON) > 0 && string(bridgeJSON) != "null" {
+		if err := json.Unmarshal(bridgeJSON, &c.Bridge); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bridge: %w", err)
+		}
+	}
+	if len(configJSON) > 0 && string(configJSON) != "null" {
+		if err := json.Unmarshal(configJSON, &c.Config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config versions: %w", err)
+		}
+	}
+	if len(reliabilityJSON) > 0 && string(reliabilityJSON) != "null" {
+		c.Reliability = &Reliability{}
+		if err := json.Unmarshal(reliabilityJSON, c.Reliability); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal reliability: %w", err)
+		}
+	}
+	if len(qualityJSON) > 0 && string(qualityJSON) != "null" {
+		c.Quality = &QualityReview{}
+		if err := json.Unmarshal(qualityJSON, c.Quality); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quality review: %w", err)
+		}
+	}
+	if len(reviewJSON) > 0 && string(reviewJSON) != "null" {
+		c.Review = &Review{}
+		if err := json.Unmarshal(reviewJSON, c.Review); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal review: %w", err)
+		}
+	}
+	if len(pacingJSON) > 0 && string(pacingJSON) != "null" {
+		c.Pacing = &Pacing{}
+		if err := json.Unmarshal(pacingJSON, c.Pacing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pacing: %w", err)
+		}
+	}
+	if len(visitJSON) > 0 && string(visitJSON) != "null" {
+		c.Visit = &Visit{}
+		if err := json.Unmarshal(visitJSON, c.Visit); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal visit: %w", err)
+		}
+	}
+	if len(medicationsJSON) > 0 {
+		if err := json.Unmarshal(medicationsJSON, &c.Medications); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal medications: %w", err)
+		}
+	}
+
 
+	if c.Tags, err = r.tags(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.Notes, err = r.notes(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.SlowTurns, err = r.slowTurns(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.AbuseIncidents, err = r.abuseIncidents(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.RedFlags, err = r.redFlags(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.Vitals, err = r.vitals(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.DoctorQuestions, err = r.doctorQuestions(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	if c.Links, err = r.links(ctx, c.ID); err != nil {
+		return nil, fmt.Errorf("failed to load links: %w", err)
+	}
 	return &c, nil
 }
 
+// links loads the consultations c refers to, oldest visit first
+func (r *postgresRepo) links(ctx context.Context, id uuid.UUID) ([]LinkedConsultation, error) {
+	query := `
+		SELECT l.linked_id, l.relation, l.created_at, c.created_at, c.facts
+		FROM consultation_links l JOIN consultations c ON c.id = l.linked_id
+		WHERE l.consultation_id = $1
+		ORDER BY c.created_at`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []LinkedConsultation
+	for rows.Next() {
+		var l LinkedConsultation
+		var factsJSON []byte
+		if err := rows.Scan(&l.ID, &l.Relation, &l.LinkedAt, &l.VisitedAt, &factsJSON); err != nil {
+			return nil, err
+		}
+		var facts []MedicalFact
+		if len(factsJSON) > 0 {
+			if err := json.Unmarshal(factsJSON, &facts); err != nil {
+				return nil, err
+			}
+		}
+		l.ChiefComplaint = chiefComplaint(facts)
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (r *postgresRepo) AddLink(ctx context.Context, consultationID, linkedID uuid.UUID, relation LinkRelation) error {
+	query := `
+		INSERT INTO consultation_links (consultation_id, linked_id, relation)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (consultation_id, linked_id) DO UPDATE SET relation = $3`
+	_, err := r.db.ExecContext(ctx, query, consultationID, linkedID, relation)
+	return err
+}
+
+func (r *postgresRepo) tags(ctx context.Context, id uuid.UUID) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT tag FROM consultation_tags WHERE consultation_id = $1 ORDER BY tag`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetTags replaces all tags in one transaction
+func (r *postgresRepo) SetTags(ctx context.Context, consultationID uuid.UUID, tags []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM consultation_tags WHERE consultation_id = $1`, consultationID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO consultation_tags (consultation_id, tag) VALUES ($1, $2)`, consultationID, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) notes(ctx context.Context, id uuid.UUID) ([]Note, error) {
+	query := `
+		SELECT id, COALESCE(author_id, '00000000-0000-0000-0000-000000000000'), author, body, created_at
+		FROM consultation_notes WHERE consultation_id = $1
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ID, &n.AuthorID, &n.Author, &n.Text, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (r *postgresRepo) AddNote(ctx context.Context, consultationID uuid.UUID, note Note) error {
+	var authorID any
+	if note.AuthorID != uuid.Nil {
+		authorID = note.AuthorID
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_notes (id, consultation_id, author_id, author, body, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		note.ID, consultationID, authorID, note.Author, note.Text, note.CreatedAt)
+	return err
+}
+
+func (r *postgresRepo) abuseIncidents(ctx context.Context, id uuid.UUID) ([]AbuseIncident, error) {
+	query := `
+		SELECT message_index, trigger, response, notified, created_at
+		FROM consultation_abuse_incidents WHERE consultation_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []AbuseIncident
+	for rows.Next() {
+		var i AbuseIncident
+		if err := rows.Scan(&i.MessageIndex, &i.Trigger, &i.Response, &i.Notified, &i.At); err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, i)
+	}
+	return incidents, rows.Err()
+}
+
+func (r *postgresRepo) AddAbuseIncident(ctx context.Context, consultationID uuid.UUID, incident AbuseIncident) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_abuse_incidents (consultation_id, message_index, trigger, response, notified, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		consultationID, incident.MessageIndex, incident.Trigger, incident.Response, incident.Notified, incident.At)
+	return err
+}
+
+func (r *postgresRepo) redFlags(ctx context.Context, id uuid.UUID) ([]RedFlag, error) {
+	query := `
+		SELECT message_index, category, indicator, notified, created_at
+		FROM consultation_red_flags WHERE consultation_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []RedFlag
+	for rows.Next() {
+		var f RedFlag
+		if err := rows.Scan(&f.MessageIndex, &f.Category, &f.Indicator, &f.Notified, &f.At); err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// AddRedFlag records a category once per consultation and reports whether
+// this call did, so concurrent screens alert the doctor only once
+func (r *postgresRepo) AddRedFlag(ctx context.Context, consultationID uuid.UUID, flag RedFlag) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_red_flags (consultation_id, message_index, category, indicator, notified, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (consultation_id, category) DO NOTHING`,
+		consultationID, flag.MessageIndex, flag.Category, flag.Indicator, flag.Notified, flag.At)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *postgresRepo) MarkRedFlagNotified(ctx context.Context, consultationID uuid.UUID, category RedFlagCategory) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE consultation_red_flags SET notified = TRUE WHERE consultation_id = $1 AND category = $2`, consultationID, category)
+	return err
+}
+
+func (r *postgresRepo) vitals(ctx context.Context, id uuid.UUID) ([]Measurement, error) {
+	query := `
+		SELECT kind, value, COALESCE(diastolic, 0), device_id, measured_at
+		FROM consultation_vitals WHERE consultation_id = $1
+		ORDER BY measured_at, id`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vitals []Measurement
+	for rows.Next() {
+		var m Measurement
+		if err := rows.Scan(&m.Kind, &m.Value, &m.Diastolic, &m.DeviceID, &m.MeasuredAt); err != nil {
+			return nil, err
+		}
+		vitals = append(vitals, m)
+	}
+	return vitals, rows.Err()
+}
+
+func (r *postgresRepo) AddVital(ctx context.Context, consultationID uuid.UUID, m Measurement) error {
+	var diastolic *float64
+	if m.Kind == VitalBloodPressure {
+		diastolic = &m.Diastolic
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_vitals (consultation_id, kind, value, diastolic, device_id, measured_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		consultationID, m.Kind, m.Value, diastolic, m.DeviceID, m.MeasuredAt)
+	return err
+}
+
+func (r *postgresRepo) doctorQuestions(ctx context.Context, id uuid.UUID) ([]DoctorQuestion, error) {
+	query := `
+		SELECT id, body, author_id, author, via, created_at, asked_at, COALESCE(answer, ''), answered_at
+		FROM consultation_doctor_questions WHERE consultation_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []DoctorQuestion
+	for rows.Next() {
+		var q DoctorQuestion
+		if err := rows.Scan(&q.ID, &q.Text, &q.AuthorID, &q.Author, &q.Via, &q.CreatedAt, &q.AskedAt, &q.Answer, &q.AnsweredAt); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+func (r *postgresRepo) AddDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_doctor_questions (id, consultation_id, body, author_id, author, via, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		q.ID, consultationID, q.Text, q.AuthorID, q.Author, q.Via, q.CreatedAt)
+	return err
+}
+
+// UpdateDoctorQuestion records when the question was asked and answered
+func (r *postgresRepo) UpdateDoctorQuestion(ctx context.Context, consultationID uuid.UUID, q DoctorQuestion) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE consultation_doctor_questions SET asked_at = $3, answer = $4, answered_at = $5 WHERE id = $1 AND consultation_id = $2`,
+		q.ID, consultationID, q.AskedAt, nullIfEmpty(q.Answer), q.AnsweredAt)
+	return err
+}
+
+// FindByTicket returns the latest consultation since the given time whose
+// ticket shows as the given number, or uuid.Nil
+func (r *postgresRepo) FindByTicket(ctx context.Context, ticket int, since time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id FROM consultations WHERE ticket % 1000 = $1 AND created_at >= $2 ORDER BY created_at DESC LIMIT 1`,
+		ticket, since).Scan(&id)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, nil
+	}
+	return id, err
+}
+
+// session_channel is written only here, Save leaves it alone
+func (r *postgresRepo) SessionChannel(ctx context.Context, consultationID uuid.UUID) (int, error) {
+	var channel int
+	err := r.db.QueryRowContext(ctx, `SELECT session_channel FROM consultations WHERE id = $1`, consultationID).Scan(&channel)
+	if err == sql.ErrNoRows {
+		return 0, ErrConsultationNotFound
+	}
+	return channel, err
+}
+
+func (r *postgresRepo) AdvanceSessionChannel(ctx context.Context, consultationID uuid.UUID) (int, error) {
+	var channel int
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE consultations SET session_channel = session_channel + 1 WHERE id = $1 RETURNING session_channel`,
+		consultationID).Scan(&channel)
+	if err == sql.ErrNoRows {
+		return 0, ErrConsultationNotFound
+	}
+	return channel, err
+}
+
+// chief_complaint is written only here, Save leaves it alone. The first
+// detection wins, a late duplicate from a concurrent attempt is ignored.
+func (r *postgresRepo) SetChiefComplaint(ctx context.Context, consultationID uuid.UUID, complaint ChiefComplaint) error {
+	data, err := json.Marshal(complaint)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE consultations SET chief_complaint = $2 WHERE id = $1 AND chief_complaint IS NULL`, consultationID, data)
+	return err
+}
+
+// assignment is written only here, Save leaves it alone
+func (r *postgresRepo) SetAssignment(ctx context.Context, consultationID uuid.UUID, a Assignment) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE consultations SET assignment = $2 WHERE id = $1`, consultationID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConsultationNotFound
+	}
+	return nil
+}
+
+// report_dispatched_at is written only here, Save leaves it alone. The first
+// dispatch wins, later revisions do not restart the clock.
+func (r *postgresRepo) MarkReportDispatched(ctx context.Context, consultationID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE consultations SET report_dispatched_at = $2 WHERE id = $1 AND report_dispatched_at IS NULL`, consultationID, at)
+	return err
+}
+
+// acknowledgment is written only here, Save leaves it alone. A second
+// acknowledgment fails with ErrAlreadyAcknowledged.
+func (r *postgresRepo) SetAcknowledgment(ctx context.Context, consultationID uuid.UUID, a Acknowledgment) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE consultations SET acknowledgment = $2 WHERE id = $1 AND acknowledgment IS NULL`, consultationID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyAcknowledged
+	}
+	return nil
+}
+
+// bridge is written only here, Save leaves it alone
+func (r *postgresRepo) SetBridge(ctx context.Context, consultationID uuid.UUID, b Bridge) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE consultations SET bridge = $2 WHERE id = $1`, consultationID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConsultationNotFound
+	}
+	return nil
+}
+
+// config_versions is written only here, Save leaves it alone
+func (r *postgresRepo) SetConfigStamp(ctx context.Context, consultationID uuid.UUID, stamp ConfigStamp) error {
+	data, err := json.Marshal(stamp)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE consultations SET config_versions = $2 WHERE id = $1`, consultationID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConsultationNotFound
+	}
+	return nil
+}
+
+// AppendMessage adds one message to the history in place, so it cannot
+// overwrite a message another writer appended meanwhile
+func (r *postgresRepo) AppendMessage(ctx context.Context, consultationID uuid.UUID, m Message) error {
+	data, err := json.Marshal([]Message{m})
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE consultations SET history = COALESCE(history, '[]'::jsonb) || $2::jsonb, updated_at = NOW() WHERE id = $1`, consultationID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConsultationNotFound
+	}
+	return nil
+}
+
+// queued_questions is written only here, Save leaves it alone
+func (r *postgresRepo) SetQueuedQuestions(ctx context.Context, consultationID uuid.UUID, questions []string) error {
+	data, err := json.Marshal(questions)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE consultations SET queued_questions = $2 WHERE id = $1`, consultationID, data)
+	return err
+}
+
 func (r *postgresRepo) Save(ctx context.Context, c *Consultation) error {
 	historyJSON, err := json.Marshal(c.History)
 	if err != nil {
@@ -71,6 +718,94 @@ func (r *postgresRepo) Save(ctx context.Context, c *Consultation) error {
 	if err != nil {
 		return err
 	}
+	negativesJSON, err := json.Marshal(c.PertinentNegatives)
+	if err != nil {
+		return err
+	}
+	findingsJSON, err := json.Marshal(c.RuleFindings)
+	if err != nil {
+		return err
+	}
+	screeningJSON, err := json.Marshal(c.RiskScreening)
+	if err != nil {
+		return err
+	}
+	medicationsJSON, err := json.Marshal(c.Medications)
+	if err != nil {
+		return err
+	}
+	childJSON, err := json.Marshal(c.Child)
+	if err != nil {
+		return err
+	}
+	questionnairesJSON, err := json.Marshal(c.Questionnaires)
+	if err != nil {
+		return err
+	}
+	epidJSON, err := json.Marshal(c.EpidTopics)
+	if err != nil {
+		return err
+	}
+	reliabilityJSON, err := json.Marshal(c.Reliability)
+	if err != nil {
+		return err
+	}
+	qualityJSON, err := json.Marshal(c.Quality)
+	if err != nil {
+		return err
+	}
+	reviewJSON, err := json.Marshal(c.Review)
+	if err != nil {
+		return err
+	}
+	pacingJSON, err := json.Marshal(c.Pacing)
+	if err != nil {
+		return err
+	}
+	visitJSON, err := json.Marshal(c.Visit)
+	if err != nil {
+		return err
+	}
+	requiredJSON, err := json.Marshal(c.RequiredFields)
+	if err != nil {
+		return err
+	}
+	deviceJSON, err := json.Marshal(c.Device)
+	if err != nil {
+		return err
+	}
+	wearablesJSON, err := json.Marshal(c.Wearables)
+	if err != nil {
+		return err
+	}
+	conditionsJSON, err := json.Marshal(c.PriorConditions)
+	if err != nil {
+		return err
+	}
+	summaryJSON, err := json.Marshal(c.FactSummary)
+	if err != nil {
+		return err
+	}
+	recipientsJSON, err := json.Marshal(c.Recipients)
+	if err != nil {
+		return err
+	}
+	bookingJSON, err := json.Marshal(c.Booking)
+	if err != nil {
+		return err
+	}
+	translationJSON, err := json.Marshal(c.Translation)
+	if err != nil {
+		return err
+	}
+	recapJSON, err := json.Marshal(c.Recap)
+	if err != nil {
+		return err
+	}
+	clarificationJSON, err := json.Marshal(c.Clarification)
+	if err != nil {
+		return err
+	}
 
 	if c.CreatedAt.IsZero() {
 		c.CreatedAt = time.Now()
@@ -78,16 +813,570 @@ func (r *postgresRepo) Save(ctx context.Context, c *Consultation) error {
 	c.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO consultations (id, patient_id, history, facts, mood, is_complete, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO consultations (id, patient_id, history, facts, mood, is_complete, created_at, updated_at, negatives, rule_findings, risk_screening, mode, medications, pediatric, child, questionnaires, epid_topics, reliability, quality, review, pacing, visit, experiment, arm, supervisor_rounds, department, required_fields, device, supervisor_turn, report_revision, wearables, prior_conditions, fact_summary, report_recipients, booking, translation, recap, clarification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38)
 		ON CONFLICT (id) DO UPDATE SET
 			history = $3,
 			facts = $4,
 			mood = $5,
 			is_complete = $6,
-			updated_at = $8
+			updated_at = $8,
+			negatives = $9,
+			rule_findings = $10,
+			risk_screening = $11,
+			medications = $13,
+			child = $15,
+			questionnaires = $16,
+			epid_topics = $17,
+			reliability = $18,
+			quality = $19,
+			review = $20,
+			pacing = $21,
+			visit = $22,
+			supervisor_rounds = $25,
+			supervisor_turn = $29,
+			report_revision = $30,
+			wearables = $31,
+			prior_conditions = $32,
+			fact_summary = $33,
+			booking = $35,
+			translation = $36,
+			recap = $37,
+			clarification = $38
+		RETURNING ticket
 	`
-	_, err = r.db.ExecContext(ctx, query, 
-		c.ID, c.PatientID, historyJSON, factsJSON, c.CurrentMood, c.IsComplete, c.CreatedAt, c.UpdatedAt)
+	// The ticket comes from a sequence on insert and is returned so new consultations get it
+	return r.db.QueryRowContext(ctx, query, 
+		c.ID, c.PatientID, historyJSON, factsJSON, c.CurrentMood, c.IsComplete, c.CreatedAt, c.UpdatedAt, negativesJSON, findingsJSON, screeningJSON, c.Mode, medicationsJSON, c.Pediatric, childJSON, questionnairesJSON, epidJSON, reliabilityJSON, qualityJSON, reviewJSON, pacingJSON, visitJSON, nullIfEmpty(c.Experiment), nullIfEmpty(c.Arm), c.SupervisorRounds, nullIfEmpty(c.Department), requiredJSON, deviceJSON, c.SupervisorTurn, c.ReportRevision, wearablesJSON, conditionsJSON, summaryJSON, recipientsJSON, bookingJSON, translationJSON, recapJSON, clarificationJSON).Scan(&c.Ticket)
+}
+
+// Stats covers the consultations at loc, all of them for a zero Location
+func (r *postgresRepo) Stats(ctx context.Context, loc Location) (*Stats, error) {
+	query := `SELECT COALESCE(mood, ''), is_complete, COUNT(*) FROM consultations WHERE ` + locationClause(1) + ` GROUP BY mood, is_complete`
+
+	rows, err := r.db.QueryContext(ctx, query, loc.args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &Stats{ByMood: map[EmotionalState]int{}}
+	for rows.Next() {
+		var mood EmotionalState
+		var complete bool
+		var count int
+		if err := rows.Scan(&mood, &complete, &count); err != nil {
+			return nil, err
+		}
+		stats.Total += count
+		if complete {
+			stats.Completed += count
+		}
+		stats.ByMood[mood] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Symptom codes are stored inside the facts JSONB by the normalizer
+	codeQuery := `
+		SELECT f->'code'->>'code', COUNT(DISTINCT c.id)
+		FROM consultations c, jsonb_array_elements(COALESCE(c.facts, '[]'::jsonb)) f
+		WHERE f->'code'->>'code' IS NOT NULL AND ` + locationClause(1) + `
+		GROUP BY 1`
+	codeRows, err := r.db.QueryContext(ctx, codeQuery, loc.args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer codeRows.Close()
+
+	stats.BySymptom = map[string]int{}
+	for codeRows.Next() {
+		var code string
+		var count int
+		if err := codeRows.Scan(&code, &count); err != nil {
+			return nil, err
+		}
+		stats.BySymptom[code] = count
+	}
+	if err := codeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.qualityStats(ctx, loc, &stats.Quality); err != nil {
+		return nil, err
+	}
+	if stats.Experiments, err = r.experimentStats(ctx, loc); err != nil {
+		return nil, err
+	}
+	if err := r.latencyStats(ctx, loc, &stats.TurnLatency); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// MoodRows counts consultations created in [from, to) by day, department,
+// persona and first and last mood. The first mood is read from the history,
+// whose mood keys stay unencrypted.
+func (r *postgresRepo) MoodRows(ctx context.Context, from, to time.Time, loc Location) ([]MoodRow, error) {
+	query := `
+		SELECT to_char(created_at, 'YYYY-MM-DD'), COALESCE(department, ''), COALESCE(pacing->>'voice', ''),
+			COALESCE((
+				SELECT m->>'mood' FROM jsonb_array_elements(CASE WHEN jsonb_typeof(history) = 'array' THEN history ELSE '[]'::jsonb END) WITH ORDINALITY AS h(m, i)
+				WHERE m->>'mood' IS NOT NULL ORDER BY i LIMIT 1
+			), ''),
+			COALESCE(mood, ''), COUNT(*)
+		FROM consultations
+		WHERE created_at >= $1 AND created_at < $2 AND ` + locationClause(3) + `
+		GROUP BY 1, 2, 3, 4, 5`
+	rows, err := r.db.QueryContext(ctx, query, append([]any{from, to}, loc.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []MoodRow
+	for rows.Next() {
+		var m MoodRow
+		if err := rows.Scan(&m.Day, &m.Department, &m.Persona, &m.Initial, &m.Final, &m.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// ResponseRows lists consultations whose report was dispatched in [from, to).
+// Closure is when staff marked the visit done.
+func (r *postgresRepo) ResponseRows(ctx context.Context, from, to time.Time, loc Location) ([]ResponseRow, error) {
+	query := `
+		SELECT COALESCE(department, ''), COALESCE(acknowledgment->>'doctor', ''), report_dispatched_at,
+			(acknowledgment->>'at')::TIMESTAMPTZ,
+			CASE WHEN visit->>'state' = 'done' THEN (visit->>'updated_at')::TIMESTAMPTZ END
+		FROM consultations
+		WHERE report_dispatched_at >= $1 AND report_dispatched_at < $2 AND ` + locationClause(3)
+	rows, err := r.db.QueryContext(ctx, query, append([]any{from, to}, loc.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []ResponseRow
+	for rows.Next() {
+		var row ResponseRow
+		var acknowledged, closed sql.NullTime
+		if err := rows.Scan(&row.Department, &row.Doctor, &row.DispatchedAt, &acknowledged, &closed); err != nil {
+			return nil, err
+		}
+		if acknowledged.Valid {
+			row.Acknowledged = &acknowledged.Time
+		}
+		if closed.Valid {
+			row.Closed = &closed.Time
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+// Stages that did not run are stored as NULL
+func (r *postgresRepo) SaveTurnTimings(ctx context.Context, consultationID uuid.UUID, t TurnTimings, sloMs int64, slow bool) error {
+	optional := func(ms int64) any {
+		if ms == 0 {
+			return nil
+		}
+		return ms
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO turn_timings (consultation_id, stt_ms, llm_first_token_ms, llm_ms, tts_ms, total_ms, slo_ms, slow)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		consultationID, optional(t.STTMs), optional(t.LLMFirstTokenMs), t.LLMMs, optional(t.TTSMs), t.TotalMs, sloMs, slow)
+	return err
+}
+
+func (r *postgresRepo) AddAudio(ctx context.Context, consultationID uuid.UUID, segment AudioSegment) error {
+	query := `INSERT INTO consultation_audio (consultation_id, role, data, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.ExecContext(ctx, query, consultationID, segment.Role, segment.Data, segment.At)
 	return err
 }
+
+// AudioSegments returns the recorded audio in the order it was spoken
+func (r *postgresRepo) AudioSegments(ctx context.Context, consultationID uuid.UUID) ([]AudioSegment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT role, data, created_at FROM consultation_audio WHERE consultation_id = $1 ORDER BY created_at, id`, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []AudioSegment
+	for rows.Next() {
+		var s AudioSegment
+		if err := rows.Scan(&s.Role, &s.Data, &s.At); err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+	return segments, rows.Err()
+}
+
+func (r *postgresRepo) AddReasoning(ctx context.Context, consultationID uuid.UUID, t ReasoningTrace) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO consultation_reasoning (consultation_id, message_index, provider, text, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		consultationID, t.MessageIndex, t.Provider, t.Text, t.At)
+	return err
+}
+
+func (r *postgresRepo) ReasoningTraces(ctx context.Context, consultationID uuid.UUID) ([]ReasoningTrace, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT message_index, provider, text, created_at FROM consultation_reasoning WHERE consultation_id = $1 ORDER BY message_index, id`, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traces []ReasoningTrace
+	for rows.Next() {
+		var t ReasoningTrace
+		if err := rows.Scan(&t.MessageIndex, &t.Provider, &t.Text, &t.At); err != nil {
+			return nil, err
+		}
+		traces = append(traces, t)
+	}
+	return traces, rows.Err()
+}
+
+// BeginTurn inserts the checkpoint and sets its ID
+func (r *postgresRepo) BeginTurn(ctx context.Context, t *TurnCheckpoint) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO turn_checkpoints (consultation_id, message_index, text, stage, started_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`,
+		t.ConsultationID, t.MessageIndex, t.Text, t.Stage, t.StartedAt).Scan(&t.ID)
+}
+
+// SetTurnStage moves a turn on; the patient's text is no longer needed once the reply is saved
+func (r *postgresRepo) SetTurnStage(ctx context.Context, consultationID uuid.UUID, id int64, stage TurnStage, forceComplete bool) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE turn_checkpoints SET stage = $3, force_complete = $4, text = CASE WHEN $3 = 'started' THEN text ELSE '' END, updated_at = NOW() WHERE id = $1 AND consultation_id = $2`,
+		id, consultationID, stage, forceComplete)
+	return err
+}
+
+// UnfinishedTurns returns the turns still started or answered, oldest first
+func (r *postgresRepo) UnfinishedTurns(ctx context.Context) ([]TurnCheckpoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consultation_id, message_index, text, stage, force_complete, started_at, updated_at
+		FROM turn_checkpoints WHERE stage IN ('started', 'answered') ORDER BY started_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var turns []TurnCheckpoint
+	for rows.Next() {
+		var t TurnCheckpoint
+		if err := rows.Scan(&t.ID, &t.ConsultationID, &t.MessageIndex, &t.Text, &t.Stage, &t.ForceComplete, &t.StartedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+func (r *postgresRepo) slowTurns(ctx context.Context, id uuid.UUID) ([]SlowTurn, error) {
+	query := `
+		SELECT COALESCE(stt_ms, 0), COALESCE(llm_first_token_ms, 0), llm_ms, COALESCE(tts_ms, 0), total_ms, slo_ms, created_at
+		FROM turn_timings WHERE consultation_id = $1 AND slow
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var turns []SlowTurn
+	for rows.Next() {
+		var t SlowTurn
+		if err := rows.Scan(&t.STTMs, &t.LLMFirstTokenMs, &t.LLMMs, &t.TTSMs, &t.TotalMs, &t.SLOMs, &t.At); err != nil {
+			return nil, err
+		}
+		t.Slowest = t.TurnTimings.Slowest()
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+func (r *postgresRepo) latencyStats(ctx context.Context, loc Location, l *LatencyStats) error {
+	// percentile_cont skips NULLs, so each stage is ranked over the turns that ran it
+	percentiles := ""
+	for _, p := range []string{"0.5", "0.95"} {
+		for _, col := range []string{"stt_ms", "llm_first_token_ms", "llm_ms", "tts_ms", "total_ms"} {
+			percentiles += fmt.Sprintf(", COALESCE(percentile_cont(%s) WITHIN GROUP (ORDER BY %s), 0)", p, col)
+		}
+	}
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE slow)` + percentiles + ` FROM turn_timings WHERE created_at >= $1`
+	if !loc.IsZero() {
+		query += ` AND consultation_id IN (SELECT id FROM consultations WHERE ` + locationClause(2) + `)`
+	}
+
+	var p50, p95 [5]float64
+	args := []any{time.Now().Add(-latencyWindow)}
+	if !loc.IsZero() {
+		args = append(args, loc.args()...)
+	}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&l.Turns, &l.Slow,
+		&p50[0], &p50[1], &p50[2], &p50[3], &p50[4], &p95[0], &p95[1], &p95[2], &p95[3], &p95[4])
+	if err != nil {
+		return err
+	}
+	l.P50 = TurnTimings{STTMs: int64(p50[0]), LLMFirstTokenMs: int64(p50[1]), LLMMs: int64(p50[2]), TTSMs: int64(p50[3]), TotalMs: int64(p50[4])}
+	l.P95 = TurnTimings{STTMs: int64(p95[0]), LLMFirstTokenMs: int64(p95[1]), LLMMs: int64(p95[2]), TTSMs: int64(p95[3]), TotalMs: int64(p95[4])}
+	return nil
+}
+
+// experimentStats compares outcome metrics between the arms of each experiment.
+// A turn is one patient message.
+func (r *postgresRepo) experimentStats(ctx context.Context, loc Location) ([]ExperimentStats, error) {
+	query := `
+		SELECT experiment, arm, COUNT(*), COUNT(*) FILTER (WHERE is_complete),
+			COALESCE(AVG((SELECT COUNT(*) FROM jsonb_array_elements(COALESCE(history, '[]'::jsonb)) m WHERE m->>'role' = 'user')) FILTER (WHERE is_complete), 0),
+			COALESCE(AVG(supervisor_rounds) FILTER (WHERE is_complete), 0),
+			COUNT(*) FILTER (WHERE quality IS NOT NULL AND quality <> 'null'::jsonb),
+			COALESCE(AVG((quality->>'score')::float) FILTER (WHERE quality IS NOT NULL AND quality <> 'null'::jsonb), 0)
+		FROM consultations
+		WHERE experiment IS NOT NULL AND ` + locationClause(1) + `
+		GROUP BY experiment, arm
+		ORDER BY experiment, arm`
+	rows, err := r.db.QueryContext(ctx, query, loc.args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	experiments := []ExperimentStats{}
+	for rows.Next() {
+		var name string
+		var a ArmStats
+		if err := rows.Scan(&name, &a.Arm, &a.Consultations, &a.Completed, &a.AvgTurns, &a.AvgSupervisorRounds, &a.Reviewed, &a.AvgQualityScore); err != nil {
+			return nil, err
+		}
+		if n := len(experiments); n == 0 || experiments[n-1].Experiment != name {
+			experiments = append(experiments, ExperimentStats{Experiment: name})
+		}
+		experiments[len(experiments)-1].Arms = append(experiments[len(experiments)-1].Arms, a)
+	}
+	return experiments, rows.Err()
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// qualityStats aggregates the QA agent's reviews overall, per Communicator prompt
+// version and per week for the last 12 weeks
+func (r *postgresRepo) qualityStats(ctx context.Context, loc Location, q *QualityStats) error {
+	const aggregates = `COUNT(*), AVG((quality->>'score')::float), AVG((quality->>'coverage')::float), AVG((quality->>'empathy')::float)`
+	reviewed := `quality IS NOT NULL AND quality <> 'null'::jsonb AND ` + locationClause(1)
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+aggregates+` FROM consultations WHERE `+reviewed, loc.args()...)
+	var avgScore, avgCoverage, avgEmpathy sql.NullFloat64
+	if err := row.Scan(&q.Reviewed, &avgScore, &avgCoverage, &avgEmpathy); err != nil {
+		return err
+	}
+	q.AvgScore, q.AvgCoverage, q.AvgEmpathy = avgScore.Float64, avgCoverage.Float64, avgEmpathy.Float64
+
+	rows, err := r.db.QueryContext(ctx, `SELECT COALESCE(quality->>'prompt_version', ''), `+aggregates+`
+		FROM consultations WHERE `+reviewed+` GROUP BY 1`, loc.args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	q.ByPromptVersion = map[string]QualityAggregate{}
+	for rows.Next() {
+		var version string
+		var agg QualityAggregate
+		if err := rows.Scan(&version, &agg.Reviewed, &agg.AvgScore, &agg.AvgCoverage, &agg.AvgEmpathy); err != nil {
+			return err
+		}
+		q.ByPromptVersion[version] = agg
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	weekRows, err := r.db.QueryContext(ctx, `SELECT date_trunc('week', created_at), `+aggregates+`
+		FROM consultations WHERE `+reviewed+` AND created_at > NOW() - INTERVAL '12 weeks'
+		GROUP BY 1 ORDER BY 1`, loc.args()...)
+	if err != nil {
+		return err
+	}
+	defer weekRows.Close()
+
+	q.ByWeek = []WeeklyQuality{}
+	for weekRows.Next() {
+		var w WeeklyQuality
+		if err := weekRows.Scan(&w.Week, &w.Reviewed, &w.AvgScore, &w.AvgCoverage, &w.AvgEmpathy); err != nil {
+			return err
+		}
+		q.ByWeek = append(q.ByWeek, w)
+	}
+	return weekRows.Err()
+}
+
+// SearchFilter narrows the staff consultation listing; empty fields match all
+type SearchFilter struct {
+	Code string // normalized symptom code among the facts
+	Tag  string // staff tag
+}
+
+// Search lists consultations matching every non-empty filter field, newest first
+func (r *postgresRepo) Search(ctx context.Context, filter SearchFilter) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM consultations
+		WHERE ($1::text = '' OR facts @> jsonb_build_array(jsonb_build_object('code', jsonb_build_object('code', $1::text))))
+		  AND ($2::text = '' OR EXISTS (SELECT 1 FROM consultation_tags t WHERE t.consultation_id = consultations.id AND t.tag = $2))
+		ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, filter.Code, filter.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PendingReviews lists consultations awaiting nurse approval, oldest first
+func (r *postgresRepo) PendingReviews(ctx context.Context, loc Location) ([]ReviewQueueItem, error) {
+	query := `
+		SELECT id, patient_id, COALESCE(chief_complaint->>'text', ''), COALESCE(reliability->>'level', ''), updated_at FROM consultations
+		WHERE review->>'status' = 'pending' AND ` + locationClause(1) + `
+		ORDER BY updated_at`
+	rows, err := r.db.QueryContext(ctx, query, loc.args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ReviewQueueItem{}
+	for rows.Next() {
+		var item ReviewQueueItem
+		if err := rows.Scan(&item.ID, &item.PatientID, &item.ChiefComplaint, &item.Reliability, &item.CompletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// OpenConsultation returns the patient's latest unfinished consultation created
+// since the given time, or nil if there is none
+func (r *postgresRepo) OpenConsultation(ctx context.Context, patientID uuid.UUID, since time.Time) (*Consultation, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM consultations WHERE patient_id = $1 AND NOT is_complete AND created_at >= $2 ORDER BY created_at DESC LIMIT 1`, patientID, since).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// BoardEntries returns the waiting-room queue: consultations created since the
+// given time whose visit is not over, by ticket. Nothing identifying is selected.
+func (r *postgresRepo) BoardEntries(ctx context.Context, since time.Time, loc Location) ([]BoardEntry, error) {
+	query := `
+		SELECT ticket, is_complete, visit FROM consultations
+		WHERE created_at >= $1 AND ticket IS NOT NULL AND COALESCE(visit->>'state', '') <> 'done' AND ` + locationClause(2) + `
+		ORDER BY ticket`
+	rows, err := r.db.QueryContext(ctx, query, append([]any{since}, loc.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []BoardEntry{}
+	for rows.Next() {
+		var ticket int
+		var isComplete bool
+		var visitJSON []byte
+		if err := rows.Scan(&ticket, &isComplete, &visitJSON); err != nil {
+			return nil, err
+		}
+		var visit *Visit
+		if len(visitJSON) > 0 && string(visitJSON) != "null" {
+			visit = &Visit{}
+			if err := json.Unmarshal(visitJSON, visit); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal visit: %w", err)
+			}
+		}
+		entries = append(entries, boardEntry(ticket, isComplete, visit))
+	}
+	return entries, rows.Err()
+}
+
+// Summaries reads the consultation_summaries projection for consultations created
+// since the given time whose visit is not over, oldest first
+func (r *postgresRepo) Summaries(ctx context.Context, since time.Time) ([]Summary, error) {
+	query := `
+		SELECT id, ticket, mode, mood, is_complete, messages, review_status, visit_state, room, risk_active, risk_level, red_flag, chief_complaint, assigned_room, assigned_bed, building, floor, department, created_at, updated_at
+		FROM consultation_summaries
+		WHERE created_at >= $1 AND visit_state <> 'done'
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []Summary{}
+	for rows.Next() {
+		var s Summary
+		if err := rows.Scan(&s.ID, &s.Ticket, &s.Mode, &s.Mood, &s.IsComplete, &s.Messages, &s.ReviewStatus, &s.VisitState, &s.Room,
+			&s.RiskActive, &s.RiskLevel, &s.RedFlag, &s.ChiefComplaint, &s.AssignedRoom, &s.AssignedBed, &s.Location.Building, &s.Location.Floor, &s.Location.Department, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// CompletedBetween lists completed consultations created in [from, to), oldest first
+func (r *postgresRepo) CompletedBetween(ctx context.Context, from, to time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM consultations
+		WHERE is_complete AND created_at >= $1 AND created_at < $2
+		ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postgresRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM consultations WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
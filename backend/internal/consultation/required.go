@@ -0,0 +1,60 @@
+package consultation
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// RequiredField is one item a department wants collected before the
+// interview may end, e.g. the last meal before surgery. The Analyst records
+// the answer as a fact with Label as its category.
+type RequiredField struct {
+	ID       string `json:"id"`       // e.g. "last_meal"
+	Label    string `json:"label"`    // e.g. "Последний приём пищи"
+	Question string `json:"question"` // what the Communicator should find out
+}
+
+// ProfileSource provides the department's required fields at consultation creation
+type ProfileSource interface {
+	Required(ctx context.Context, department string) ([]RequiredField, error)
+}
+
+var departmentPattern = regexp.MustCompile(`^[a-z0-9_-]{1,40}$`)
+
+// ValidDepartment reports whether d can name a department, e.g. "surgery"
+func ValidDepartment(d string) bool {
+	return departmentPattern.MatchString(d)
+}
+
+// RequiredAnswers returns the descriptions of the facts that answer f
+func (c *Consultation) RequiredAnswers(f RequiredField) []string {
+	var answers []string
+	for _, fact := range c.ExtractedFacts {
+		if strings.EqualFold(strings.TrimSpace(fact.Category), f.Label) {
+			answers = append(answers, fact.Description)
+		}
+	}
+	return answers
+}
+
+// RequiredFieldOf returns the required field a fact answers, if any
+func (c *Consultation) RequiredFieldOf(fact MedicalFact) *RequiredField {
+	for i := range c.RequiredFields {
+		if strings.EqualFold(strings.TrimSpace(fact.Category), c.RequiredFields[i].Label) {
+			return &c.RequiredFields[i]
+		}
+	}
+	return nil
+}
+
+// PendingRequired returns the department's required fields without an answer yet
+func (c *Consultation) PendingRequired() []RequiredField {
+	var pending []RequiredField
+	for _, f := range c.RequiredFields {
+		if len(c.RequiredAnswers(f)) == 0 {
+			pending = append(pending, f)
+		}
+	}
+	return pending
+}
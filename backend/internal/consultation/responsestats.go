@@ -0,0 +1,125 @@
+package consultation
+
+import (
+	"sort"
+	"time"
+)
+
+// ResponseRow is one consultation whose report was dispatched, with when the
+// doctor acknowledged it and when the visit was closed
+type ResponseRow struct {
+	Department   string
+	Doctor       string // who acknowledged the report, "" while nobody has
+	DispatchedAt time.Time
+	Acknowledged *time.Time
+	Closed       *time.Time // the visit was marked done
+}
+
+// Durations in minutes since the report was dispatched, -1 when there is
+// nothing to measure
+type ResponseTimes struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean_minutes"`
+	Median float64 `json:"median_minutes"`
+	P90    float64 `json:"p90_minutes"`
+}
+
+// ResponseGroup shows how fast doctors of a group picked up reports and closed visits
+type ResponseGroup struct {
+	Key        string `json:"key"` // "" for the default department or unacknowledged reports
+	Dispatched int    `json:"dispatched"`
+	// Dispatched but not yet acknowledged
+	Pending         int           `json:"pending"`
+	ToAcknowledge   ResponseTimes `json:"to_acknowledge"`
+	ToClose         ResponseTimes `json:"to_close"`
+	acknowledgments []float64
+	closures        []float64
+}
+
+type ResponseAnalytics struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Overall      ResponseGroup   `json:"overall"`
+	ByDoctor     []ResponseGroup `json:"by_doctor"`
+	ByDepartment []ResponseGroup `json:"by_department"`
+}
+
+// BuildResponseAnalytics aggregates the rows overall and per doctor and department
+func BuildResponseAnalytics(rows []ResponseRow, from, to time.Time) ResponseAnalytics {
+	a := ResponseAnalytics{From: from, To: to}
+	overall := &ResponseGroup{}
+	doctors, departments := map[string]*ResponseGroup{}, map[string]*ResponseGroup{}
+	for _, r := range rows {
+		overall.add(r)
+		for _, g := range []struct {
+			groups map[string]*ResponseGroup
+			key    string
+		}{{doctors, r.Doctor}, {departments, r.Department}} {
+			if g.groups[g.key] == nil {
+				g.groups[g.key] = &ResponseGroup{Key: g.key}
+			}
+			g.groups[g.key].add(r)
+		}
+	}
+	a.Overall = overall.finish()
+	a.ByDoctor = sortedResponseGroups(doctors)
+	a.ByDepartment = sortedResponseGroups(departments)
+	return a
+}
+
+func (g *ResponseGroup) add(r ResponseRow) {
+	g.Dispatched++
+	if r.Acknowledged == nil {
+		g.Pending++
+	} else {
+		g.acknowledgments = append(g.acknowledgments, r.Acknowledged.Sub(r.DispatchedAt).Minutes())
+	}
+	if r.Closed != nil {
+		g.closures = append(g.closures, r.Closed.Sub(r.DispatchedAt).Minutes())
+	}
+}
+
+func (g *ResponseGroup) finish() ResponseGroup {
+	g.ToAcknowledge = responseTimes(g.acknowledgments)
+	g.ToClose = responseTimes(g.closures)
+	return *g
+}
+
+func responseTimes(minutes []float64) ResponseTimes {
+	t := ResponseTimes{Count: len(minutes), Mean: -1, Median: -1, P90: -1}
+	if len(minutes) == 0 {
+		return t
+	}
+	sorted := append([]float64(nil), minutes...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, m := range sorted {
+		sum += m
+	}
+	t.Mean = sum / float64(len(sorted))
+	t.Median = percentile(sorted, 0.5)
+	t.P90 = percentile(sorted, 0.9)
+	return t
+}
+
+// percentile picks the nearest rank from sorted values
+func percentile(sorted []float64, p float64) float64 {
+	i := int(float64(len(sorted))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// sortedResponseGroups orders groups by key
+func sortedResponseGroups(groups map[string]*ResponseGroup) []ResponseGroup {
+	list := make([]ResponseGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g.finish())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
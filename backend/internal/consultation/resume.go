@@ -0,0 +1,322 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// A finished turn can still be resumed for this long, enough for a client
+// to notice the dropped connection and reconnect
+const turnLogRetention = 2 * time.Minute
+
+// A turn nobody follows is cancelled after this long, so the LLM and TTS
+// stop working for a client that is not coming back
+const turnOrphanGrace = 15 * time.Second
+
+var (
+	errTurnSuperseded = errors.New("turn cancelled: a newer turn started")
+	errTurnOrphaned   = errors.New("turn cancelled: the client did not reconnect")
+)
+
+// turnLog keeps the events of one streamed turn so a client that lost the
+// connection can reconnect with Last-Event-ID and get the rest. Event IDs are
+// "<turn>:<index>".
+type turnLog struct {
+	turn int64
+	prev *turnLog // the turn it replaced, which must be over before this one runs
+
+	mu        sync.Mutex
+	events    []StreamEvent
+	done      bool
+	endedAt   time.Time
+	changed   chan struct{} // closed and replaced on every append
+	finished  chan struct{} // closed by finish
+	cancel    context.CancelCauseFunc
+	followers int
+	handedOff bool        // moved to another device, which picks up the result
+	orphaned  *time.Timer // cancels the turn once the grace period is over
+}
+
+func (l *turnLog) append(e StreamEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// finish marks the turn as over; followers stop after the last event
+func (l *turnLog) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return
+	}
+	l.done, l.endedAt = true, time.Now()
+	close(l.changed)
+	l.changed = make(chan struct{})
+	close(l.finished)
+	if l.orphaned != nil {
+		l.orphaned.Stop()
+	}
+}
+
+// attach gives the log the means to cancel the running turn
+func (l *turnLog) attach(cancel context.CancelCauseFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cancel = cancel
+	if l.followers == 0 && !l.handedOff {
+		l.orphan()
+	}
+}
+
+// join and leave count the clients following the turn; the last one to
+// leave starts the grace period
+func (l *turnLog) join() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.followers++
+	if l.orphaned != nil {
+		l.orphaned.Stop()
+		l.orphaned = nil
+	}
+}
+
+func (l *turnLog) leave(handoff bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.followers--
+	l.handedOff = l.handedOff || handoff
+	if l.followers == 0 && !l.done && !l.handedOff {
+		l.orphan()
+	}
+}
+
+// orphan starts the grace period; l.mu must be held
+func (l *turnLog) orphan() {
+	if l.cancel == nil || l.orphaned != nil {
+		return
+	}
+	l.orphaned = time.AfterFunc(turnOrphanGrace, func() { l.abort(errTurnOrphaned) })
+}
+
+// abort cancels the turn if it is still running
+func (l *turnLog) abort(cause error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil && !l.done {
+		l.cancel(cause)
+	}
+}
+
+// since returns the events after index from, whether the turn is over, and
+// a channel closed on the next change
+func (l *turnLog) since(from int) ([]StreamEvent, bool, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if from > len(l.events) {
+		from = len(l.events)
+	}
+	return l.events[from:], l.done, l.changed
+}
+
+func (l *turnLog) eventID(index int) string {
+	return fmt.Sprintf("%d:%d", l.turn, index)
+}
+
+// next returns the index to continue from after lastEventID. An ID of an
+// earlier turn, or none, means the whole turn.
+func (l *turnLog) next(lastEventID string) int {
+	turn, index, ok := strings.Cut(lastEventID, ":")
+	if !ok || turn != strconv.FormatInt(l.turn, 10) {
+		return 0
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n + 1
+}
+
+// turnLogs holds the latest streamed turn of each consultation
+type turnLogs struct {
+	mu    sync.Mutex
+	turns int64
+	logs  map[uuid.UUID]*turnLog
+}
+
+func newTurnLogs() *turnLogs {
+	return &turnLogs{logs: make(map[uuid.UUID]*turnLog)}
+}
+
+// start opens the log of a new turn, replacing the previous one. A previous
+// turn still running is cancelled: the client has moved on without it.
+func (t *turnLogs) start(id uuid.UUID) *turnLog {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, l := range t.logs {
+		l.mu.Lock()
+		expired := l.done && time.Since(l.endedAt) > turnLogRetention
+		l.mu.Unlock()
+		if expired {
+			delete(t.logs, k)
+		}
+	}
+	t.turns++
+	l := &turnLog{turn: t.turns, prev: t.logs[id], changed: make(chan struct{}), finished: make(chan struct{})}
+	if l.prev != nil {
+		l.prev.abort(errTurnSuperseded)
+	}
+	t.logs[id] = l
+	return l
+}
+
+func (t *turnLogs) get(id uuid.UUID) (*turnLog, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.logs[id]
+	if !ok {
+		return nil, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done && time.Since(l.endedAt) > turnLogRetention {
+		return nil, false
+	}
+	return l, true
+}
+
+// runTurn processes a streamed turn into its log. It is detached from the
+// request so the turn survives a dropped connection for a short grace period,
+// see turnOrphanGrace; a newer turn of the consultation and the stream
+// deadline cancel it too. Turns of one consultation run one after another.
+func (h *Handler) runTurn(reqCtx context.Context, id uuid.UUID, text string, timer *turnTimer, turn *turnLog) {
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(reqCtx))
+	defer cancel(nil)
+	if deadline, ok := reqCtx.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, deadline)
+		defer cancelDeadline()
+	}
+	turn.attach(cancel)
+	defer turn.finish()
+	defer h.recordTurn(ctx, id, timer)
+
+	if prev := turn.prev; prev != nil {
+		// Both would load and save the same consultation
+		select {
+		case <-prev.finished:
+		case <-ctx.Done():
+		}
+		turn.prev = nil
+	}
+
+	eventChan := make(chan StreamEvent)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(eventChan)
+		defer func() {
+			if rec := recover(); rec != nil {
+				fmt.Printf("Panic in stream processing: %v\n", rec)
+				errc <- errors.New("internal error")
+			}
+		}()
+		errc <- h.svc.ProcessUserAudioStream(ctx, id, text, eventChan)
+	}()
+
+	for event := range eventChan {
+		turn.append(event)
+	}
+	if err := <-errc; err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("turn timed out after %s", h.timeouts.Stream)
+		} else if cause := context.Cause(ctx); errors.Is(cause, errTurnSuperseded) || errors.Is(cause, errTurnOrphaned) {
+			fmt.Printf("Turn of consultation %s: %v\n", id, cause)
+			err = cause
+		}
+		turn.append(StreamEvent{Type: "error", Data: err.Error()})
+	}
+}
+
+// follow sends the turn's events from index from until the turn is over or
+// the client leaves, pinging while the turn is quiet
+func (h *Handler) follow(ctx context.Context, sse *sseWriter, id uuid.UUID, turn *turnLog, from int) {
+	moved, unsubscribe := h.sessions.subscribe(id)
+	defer unsubscribe()
+	handoff := false
+	turn.join()
+	defer func() { turn.leave(handoff) }()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		events, done, changed := turn.since(from)
+		for _, event := range events {
+			if err := sse.SendID(turn.eventID(from), event); err != nil {
+				// Write failed, the client is gone; the turn carries on
+				return
+			}
+			from++
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Client disconnected from stream for consultation %s, turn continues for %s\n", id, turnOrphanGrace)
+			return
+		case <-moved:
+			// The turn itself finishes in the background and shows up on the new device
+			handoff = true
+			sse.Send(StreamEvent{Type: "handoff"})
+			return
+		case <-changed:
+		case <-ticker.C:
+			if err := sse.keepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ResumeStream continues a streamed turn after a dropped connection: it
+// replays the events after Last-Event-ID (or ?last_event_id= for clients that
+// cannot set headers) and follows the turn live. An ID from an earlier turn
+// replays the current turn from the start; 204 means nothing is left to send.
+func (h *Handler) ResumeStream(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	turn, ok := h.turns.get(id)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	from := turn.next(lastID)
+	// An EventSource reconnects whenever the stream ends; 204 stops it
+	if events, done, _ := turn.since(from); done && len(events) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	h.follow(r.Context(), sse, id, turn, from)
+}
@@ -0,0 +1,103 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotPendingReview is returned when a review action targets a consultation outside the queue
+var ErrNotPendingReview = errors.New("consultation is not awaiting review")
+
+// ReviewStatus tracks the nurse sign-off some clinics require before a report is dispatched
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+)
+
+// Review is the human-in-the-loop sign-off of a completed consultation
+type Review struct {
+	Status      ReviewStatus `json:"status"`
+	FactsEdited bool         `json:"facts_edited"`
+	ReviewerID  *uuid.UUID   `json:"reviewer_id,omitempty"`
+	ReviewedAt  *time.Time   `json:"reviewed_at,omitempty"`
+}
+
+// ReviewQueueItem is a completed consultation waiting for approval
+type ReviewQueueItem struct {
+	ID             uuid.UUID `json:"id"`
+	PatientID      uuid.UUID `json:"patient_id"`
+	ChiefComplaint string    `json:"chief_complaint,omitempty"`
+	Reliability    string    `json:"reliability,omitempty"` // high, medium, low
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+func (s *service) ListPendingReviews(ctx context.Context, loc Location) ([]ReviewQueueItem, error) {
+	return s.repo.PendingReviews(ctx, loc)
+}
+
+// pendingReview loads a consultation and checks that it is still in the review queue
+func (s *service) pendingReview(ctx context.Context, consultationID uuid.UUID) (*Consultation, error) {
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Review == nil || c.Review.Status != ReviewPending {
+		return nil, ErrNotPendingReview
+	}
+	return c, nil
+}
+
+// UpdateFacts replaces the extracted facts of a consultation under review and
+// re-derives everything computed from them
+func (s *service) UpdateFacts(ctx context.Context, consultationID uuid.UUID, facts []MedicalFact) (*Consultation, error) {
+	c, err := s.pendingReview(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.normalize(&AnalysisResult{Facts: facts})
+	c.ExtractedFacts = facts
+	if c.Pediatric {
+		c.Child = childInfo(c.ExtractedFacts)
+	}
+	// The recommendations must follow the corrected facts; the old ones stay if that fails
+	if err := s.refreshRecommendations(ctx, c); err != nil {
+		fmt.Printf("Failed to refresh recommendations for consultation %s: %v\n", c.ID, err)
+	}
+	c.Review.FactsEdited = true
+
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ApproveReview signs off a consultation and dispatches its report. The approval
+// is kept even if delivery fails; the report then waits in the failed deliveries.
+func (s *service) ApproveReview(ctx context.Context, consultationID uuid.UUID, reviewerID uuid.UUID) (*Consultation, error) {
+	c, err := s.pendingReview(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.Review.Status = ReviewApproved
+	c.Review.ReviewerID = &reviewerID
+	c.Review.ReviewedAt = &now
+	c.ReportRevision = 1
+
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if err := s.dispatchReport(ctx, c); err != nil {
+		return c, fmt.Errorf("report delivery failed: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,46 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+)
+
+// refreshRecommendations regenerates the recommendations from the current
+// facts and re-derives reliability, the fact summary and rule findings, which
+// depend on them.
+// On error the old recommendations are kept but the rest is still updated.
+func (s *service) refreshRecommendations(ctx context.Context, c *Consultation) error {
+	confidence := -1
+	if c.Reliability != nil {
+		confidence = c.Reliability.ModelConfidence
+	}
+	recs, err := s.aiClient.GenerateRecommendations(ctx, c.ExtractedFacts)
+	if err == nil {
+		c.Recommendations, confidence = recs.Text, recs.Confidence
+	}
+	c.Reliability = assessReliability(*c, confidence)
+	s.summarizeFacts(ctx, c)
+	s.translateReport(ctx, c)
+	c.RuleFindings = s.rules.Evaluate(*c)
+	return err
+}
+
+// reviseReport brings a completed consultation up to date with facts found
+// after completion. A report that already went out is superseded: the doctor
+// gets the next revision through the usual delivery, retries included. A
+// report still awaiting review is only updated.
+func (s *service) reviseReport(ctx context.Context, c *Consultation) {
+	if err := s.refreshRecommendations(ctx, c); err != nil {
+		fmt.Printf("Failed to refresh recommendations for consultation %s: %v\n", c.ID, err)
+		return
+	}
+	if c.ReportRevision == 0 {
+		return
+	}
+
+	c.ReportRevision++
+	fmt.Printf("New facts after completion, sending report revision %d for consultation %s...\n", c.ReportRevision, c.ID)
+	if err := s.reportSvc.SendDoctorReport(ctx, *c); err != nil {
+		fmt.Printf("Failed to send report revision: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+package consultation
+
+import "strings"
+
+// ScreenTag opens the on-screen rendition the Communicator appends to its
+// reply in the screen text mode: "<spoken reply> [SCREEN: <short text>]"
+const ScreenTag = "[SCREEN:"
+
+// screenText reports whether replies come with a short on-screen rendition
+// for patients who hear poorly
+func (p *Pacing) screenText() bool {
+	return p != nil && p.ScreenText
+}
+
+// tailBlocks reports whether the Communicator is asked to append blocks
+// after its reply, which are then neither shown nor voiced as text
+func (p *Pacing) tailBlocks() bool {
+	return p.screenText() || p.keypad()
+}
+
+// splitTail separates the blocks appended after a complete reply (on-screen
+// text, keypad answers). A reply without them is returned as it is.
+func splitTail(reply string) (spoken, tail string) {
+	i := -1
+	for _, tag := range []string{ScreenTag, KeysTag} {
+		if j := strings.Index(reply, tag); j >= 0 && (i < 0 || j < i) {
+			i = j
+		}
+	}
+	if i < 0 {
+		return reply, ""
+	}
+	return strings.TrimSpace(reply[:i]), reply[i:]
+}
+
+// tagBlock returns the text of the block opened by tag in tail; the closing
+// bracket may be missing when the reply was cut short
+func tagBlock(tail, tag string) string {
+	i := strings.Index(tail, tag)
+	if i < 0 {
+		return ""
+	}
+	text := tail[i+len(tag):]
+	if end := strings.Index(text, "]"); end >= 0 {
+		text = text[:end]
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,194 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type RiskLevel string
+
+const (
+	RiskNone     RiskLevel = "none"
+	RiskLow      RiskLevel = "low"
+	RiskModerate RiskLevel = "moderate"
+	RiskHigh     RiskLevel = "high"
+)
+
+// RiskEscalator alerts staff about suicide/self-harm risk outside of the regular report flow
+type RiskEscalator interface {
+	EscalateRisk(ctx context.Context, c Consultation) error
+}
+
+// ScreeningAnswer is the patient's answer to one protocol question
+type ScreeningAnswer struct {
+	Step     int    `json:"step"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	Positive bool   `json:"positive"`
+}
+
+// RiskScreening tracks the suicide/self-harm screening protocol for a consultation
+type RiskScreening struct {
+	Active      bool              `json:"active"`
+	Pending     bool              `json:"pending,omitempty"` // started outside the conversation, the first question is not asked yet
+	Trigger     string            `json:"trigger"`
+	TriggeredAt time.Time         `json:"triggered_at"`
+	Step        int               `json:"step"`
+	Answers     []ScreeningAnswer `json:"answers"`
+	Level       RiskLevel         `json:"level"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// Screening questions follow the C-SSRS screener (Columbia Suicide Severity Rating Scale)
+const (
+	qWishDead = iota
+	qThoughts
+	qMethod
+	qIntent
+	qPlan
+	qBehavior
+)
+
+var screeningQuestions = []string{
+	qWishDead: "За последний месяц было ли у вас желание умереть или уснуть и не проснуться?",
+	qThoughts: "За последний месяц были ли у вас мысли о том, чтобы покончить с собой?",
+	qMethod:   "Думали ли вы о том, как именно могли бы это сделать?",
+	qIntent:   "Было ли у вас намерение действовать в соответствии с этими мыслями?",
+	qPlan:     "Начали ли вы продумывать детали или решили, как именно это сделать?",
+	qBehavior: "Делали ли вы что-нибудь, начинали делать или готовились сделать, чтобы покончить с собой, за последние три месяца?",
+}
+
+// riskTriggers are phrases that start the screening regardless of the Communicator
+var riskTriggers = []string{
+	"не хочу жить", "не хочется жить", "нет смысла жить", "жить не хочется",
+	"покончить с собой", "убить себя", "самоубий", "суицид", "свести счеты", "свести счёты",
+	"лучше бы я умер", "лучше бы меня не было", "причинить себе вред", "порезать себя", "режу себя",
+}
+
+const (
+	screeningIntro  = "Спасибо, что поделились этим со мной. Я задам вам несколько прямых вопросов — это важно для вашей безопасности. "
+	screeningUrgent = "Спасибо за честные ответы. Я уже сообщил медицинскому персоналу, к вам скоро подойдут. Вы не одни. " +
+		"Если станет хуже, звоните 112 или на телефон доверия 8-800-2000-122."
+	screeningRoutine = "Спасибо за ответы. Врач обязательно обсудит это с вами. Давайте продолжим: что ещё вас беспокоит?"
+	// Asked first when the screening was started by a questionnaire answer
+	screeningQuestionnaireIntro = "В опроснике перед визитом вы отметили мысли о том, что лучше было бы умереть или причинить себе вред. " +
+		"Я задам вам несколько прямых вопросов — это важно для вашей безопасности. "
+)
+
+func detectRiskLanguage(text string) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, t := range riskTriggers {
+		if strings.Contains(lower, t) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// nextStep applies the screener's skip logic: method/intent/plan are only asked
+// after a positive answer about suicidal thoughts
+func nextStep(step int, positive bool) int {
+	if step == qThoughts && !positive {
+		return qBehavior
+	}
+	return step + 1
+}
+
+func (rs *RiskScreening) assess() RiskLevel {
+	level := RiskLow // risk language alone warrants follow-up
+	for _, a := range rs.Answers {
+		if !a.Positive {
+			continue
+		}
+		switch a.Step {
+		case qIntent, qPlan, qBehavior:
+			return RiskHigh
+		case qMethod:
+			level = RiskModerate
+		}
+	}
+	return level
+}
+
+// screeningTurn runs the screening protocol instead of the Communicator when risk
+// language was detected. It returns false when the turn belongs to the regular interview.
+func (s *service) screeningTurn(ctx context.Context, c *Consultation, text string) (string, bool) {
+	rs := c.RiskScreening
+	if rs != nil && rs.Active && rs.Pending {
+		// The patient has not heard a question yet, so this turn is not an answer
+		rs.Pending = false
+		return screeningQuestionnaireIntro + screeningQuestions[qWishDead], true
+	}
+	if rs == nil || !rs.Active {
+		trigger, ok := detectRiskLanguage(text)
+		if !ok || rs != nil {
+			// Screen at most once per consultation; repeated language is visible in the transcript
+			return "", false
+		}
+
+		c.RiskScreening = &RiskScreening{Active: true, Trigger: trigger, TriggeredAt: time.Now(), Level: RiskLow}
+		c.CurrentMood = StateCritical
+		fmt.Printf("Risk language detected in consultation %s. Starting screening.\n", c.ID)
+
+		// Escalate immediately, staff should not wait for the screening to finish
+		if !alertSuppressed(ctx, c, "risk escalation") {
+			if err := s.escalator.EscalateRisk(ctx, *c); err != nil {
+				fmt.Printf("Failed to escalate risk: %v\n", err)
+			}
+		}
+		return screeningIntro + screeningQuestions[qWishDead], true
+	}
+
+	question := screeningQuestions[rs.Step]
+	positive, err := s.aiClient.RunScreener(ctx, question, text)
+	if err != nil {
+		// Err on the side of caution
+		fmt.Printf("Screener error: %v\n", err)
+		positive = true
+	}
+	rs.Answers = append(rs.Answers, ScreeningAnswer{Step: rs.Step, Question: question, Answer: text, Positive: positive})
+
+	rs.Step = nextStep(rs.Step, positive)
+	if rs.Step < len(screeningQuestions) {
+		return screeningQuestions[rs.Step], true
+	}
+
+	// Protocol finished
+	now := time.Now()
+	rs.Active = false
+	rs.CompletedAt = &now
+	rs.Level = rs.assess()
+	if rs.Level == RiskModerate || rs.Level == RiskHigh {
+		if !alertSuppressed(ctx, c, "risk escalation") {
+			if err := s.escalator.EscalateRisk(ctx, *c); err != nil {
+				fmt.Printf("Failed to escalate risk: %v\n", err)
+			}
+		}
+		return screeningUrgent, true
+	}
+	return screeningRoutine, true
+}
+
+// questionnaireScreening starts the screening for a positive self-harm item
+// of an imported questionnaire, as risk language does in the conversation:
+// staff are alerted at once and the protocol starts on the next turn
+func (s *service) questionnaireScreening(ctx context.Context, c *Consultation, q Questionnaire) {
+	item, answer := q.selfHarmItem()
+	if answer == 0 || c.RiskScreening != nil {
+		// Screen at most once per consultation
+		return
+	}
+
+	trigger := fmt.Sprintf("%s, пункт %d: %d из %d", q.Title, item, answer, instruments[q.Instrument].MaxItem)
+	c.RiskScreening = &RiskScreening{Active: true, Pending: true, Trigger: trigger, TriggeredAt: time.Now(), Level: RiskLow}
+	c.CurrentMood = StateCritical
+	fmt.Printf("Positive self-harm item in %s of consultation %s. Starting screening.\n", q.Instrument, c.ID)
+
+	if !alertSuppressed(ctx, c, "risk escalation") {
+		if err := s.escalator.EscalateRisk(ctx, *c); err != nil {
+			fmt.Printf("Failed to escalate risk: %v\n", err)
+		}
+	}
+}
@@ -3,94 +3,396 @@ package consultation
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/flags"
 )
 
 // AgentClient defines the interface for the AI agent interactions
 // We define it here to decouple from the specific agent implementation
 type AgentClient interface {
-	RunCommunicator(ctx context.Context, history []Message, mood EmotionalState) (string, EmotionalState, error)
-	RunCommunicatorStream(ctx context.Context, history []Message, mood EmotionalState) (<-chan string, <-chan error)
-	RunAnalyst(ctx context.Context, history []Message) ([]MedicalFact, error)
-	RunSupervisor(ctx context.Context, history []Message, facts []MedicalFact) (bool, error)
-	GenerateRecommendations(ctx context.Context, facts []MedicalFact) (string, error)
+	RunCommunicator(ctx context.Context, history []Message, mood EmotionalState, interview Interview) (string, EmotionalState, error)
+	RunCommunicatorStream(ctx context.Context, history []Message, mood EmotionalState, interview Interview) (<-chan string, <-chan error)
+	RunAnalyst(ctx context.Context, history []Message, required []RequiredField) (*AnalysisResult, error)
+	RunSupervisor(ctx context.Context, history []Message, facts []MedicalFact, negatives []PertinentNegative, pending []RequiredField) (bool, error)
+	GenerateRecommendations(ctx context.Context, facts []MedicalFact) (*RecommendationResult, error)
+	RunScreener(ctx context.Context, question string, answer string) (bool, error)
+	RunQualityReview(ctx context.Context, history []Message, facts []MedicalFact) (*QualityReview, error)
+	DetectChiefComplaint(ctx context.Context, message string) (*ChiefComplaint, error) // nil if the message names no complaint
+	// RunRedFlagScreen returns the emergency indicators in a patient message, question is the reply it answers
+	RunRedFlagScreen(ctx context.Context, question, message string) ([]RedFlag, error)
+	SummarizeWearables(ctx context.Context, signals []string, facts []MedicalFact) ([]MedicalFact, error)
+	SummarizeFacts(ctx context.Context, facts []MedicalFact, limit int) ([]MedicalFact, error)
+	// Translate returns the texts in language to, one per text; model "" uses the provider's own
+	Translate(ctx context.Context, texts []string, from, to, model string) ([]string, error)
+	// CheckRecap classifies the patient's answer to a recap of the facts
+	CheckRecap(ctx context.Context, recap, answer string, facts []MedicalFact) (*RecapCheck, error)
 }
 
 // ReportService defines the interface for sending reports
 type ReportService interface {
 	SendDoctorReport(ctx context.Context, c Consultation) error
+	// KnownDoctor reports whether the doctor registry lists a report recipient
+	KnownDoctor(id string) bool
+	// SendAssignment tells the doctor chat where a waiting patient was put
+	SendAssignment(ctx context.Context, c Consultation) error
+	// RelayToStaff passes patient messages to the nurse station during an LLM outage, see Bridge
+	RelayToStaff(ctx context.Context, c Consultation, messages []string) error
+	// RenderCertificate builds the patient's summary PDF, see Certificate
+	RenderCertificate(c Consultation, code string) ([]byte, error)
 }
 
 // TTSClient defines the interface for Text-to-Speech
 type TTSClient interface {
-	Synthesize(ctx context.Context, text string, voiceID string) ([]byte, error)
+	Synthesize(ctx context.Context, text string, opts SpeechOptions) ([]byte, error)
 }
 
 // STTClient defines the interface for Speech-to-Text
 type STTClient interface {
-	Transcribe(ctx context.Context, audioData []byte) (string, error)
+	Transcribe(ctx context.Context, audio io.Reader, hints STTHints) (Transcript, error)
+}
+
+// FeatureFlags gates risky features per consultation
+type FeatureFlags interface {
+	Enabled(flag flags.Flag, consultationID uuid.UUID) bool
+}
+
+// RuleEngine evaluates deterministic decision support rules over structured facts
+type RuleEngine interface {
+	Evaluate(c Consultation) []RuleFinding
+}
+
+// SymptomNormalizer maps free-text symptoms to a controlled vocabulary
+type SymptomNormalizer interface {
+	Normalize(text string) *Coding
+}
+
+// ResponseFilter cleans Communicator output of markup and emojis before it is
+// voiced or saved to the history
+type ResponseFilter interface {
+	ForSpeech(text string) string
+	ForTranscript(text string) string
+}
+
+// errNothingToSay is returned for text that is empty once cleaned, e.g. only emojis
+var errNothingToSay = errors.New("nothing to say")
+
+// EpidemiologyScreener decides whether the chief complaint requires the epidemiological block
+type EpidemiologyScreener interface {
+	Required(c Consultation) []EpidTopic
 }
 
 type StreamEvent struct {
-	Type string `json:"type"` // "text", "audio", "done", "error"
+	Type string `json:"type"` // "text", "screen", "keys", "audio", "fact", "stt_progress", "relay", "done", "error", "ping"
 	Data string `json:"data"`
 }
 
 type Service interface {
-	ProcessUserAudio(ctx context.Context, consultationID uuid.UUID, transcribedText string) (string, error)
+	ProcessUserAudio(ctx context.Context, consultationID uuid.UUID, transcribedText string) (*Reply, error)
 	ProcessUserAudioStream(ctx context.Context, consultationID uuid.UUID, transcribedText string, eventChan chan<- StreamEvent) error
-	CreateConsultation(ctx context.Context, patientID uuid.UUID) (*Consultation, error)
-	SynthesizeSpeech(ctx context.Context, text string) ([]byte, error)
-	TranscribeAudio(ctx context.Context, audioData []byte) (string, error)
+	CreateConsultation(ctx context.Context, patientID uuid.UUID, interview Interview, force bool) (*Consultation, error)
+	GetConsultation(ctx context.Context, consultationID uuid.UUID) (*Consultation, error)
+	SynthesizeSpeech(ctx context.Context, text string, pacing *Pacing) ([]byte, error)
+	Speak(ctx context.Context, consultationID uuid.UUID, text string, pacing *Pacing) ([]byte, error)
+	TranscribeAudio(ctx context.Context, consultationID uuid.UUID, audio io.Reader, progress func(STTProgress)) (Transcript, error)
+	Reanalyze(ctx context.Context, consultationID uuid.UUID) (*Consultation, error)
+	ImportQuestionnaire(ctx context.Context, consultationID uuid.UUID, instrument string, answers []int, completedAt time.Time) (*Questionnaire, error)
+	RecordVitals(ctx context.Context, consultationID uuid.UUID, measurements []Measurement) (*Consultation, error)
+	ImportWearables(ctx context.Context, consultationID uuid.UUID, signals *WearableSignals) (*Consultation, error)
+	AskDoctorQuestion(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author, via, text string) (*DoctorQuestion, error)
+	ConsultationByTicket(ctx context.Context, ticket string) (uuid.UUID, error)
+	ListPendingReviews(ctx context.Context, loc Location) ([]ReviewQueueItem, error)
+	UpdateFacts(ctx context.Context, consultationID uuid.UUID, facts []MedicalFact) (*Consultation, error)
+	ApproveReview(ctx context.Context, consultationID uuid.UUID, reviewerID uuid.UUID) (*Consultation, error)
+	LinkConsultation(ctx context.Context, consultationID, linkedID uuid.UUID, relation LinkRelation) (*Consultation, error)
+	SetTags(ctx context.Context, consultationID uuid.UUID, tags []string) (*Consultation, error)
+	AddNote(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author string, text string) (*Note, error)
+	InjectTurn(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author string, text string) (*Reply, error)
+	Board(ctx context.Context, loc Location) (*Board, error)
+	SetVisitState(ctx context.Context, consultationID uuid.UUID, state VisitState, room string) (*Consultation, error)
+	AssignRoom(ctx context.Context, consultationID uuid.UUID, room, bed, author string) (*Consultation, error)
+	AcknowledgeReport(ctx context.Context, consultationID uuid.UUID, doctorID uuid.UUID, doctor, via string) (*Consultation, error)
+	RelayReply(ctx context.Context, consultationID uuid.UUID, author, text string) (*Message, error)
+	EndBridge(ctx context.Context, consultationID uuid.UUID, author string) (*Consultation, error)
+	Certificate(ctx context.Context, consultationID uuid.UUID) (*Consultation, error)
+	RenderCertificate(ctx context.Context, consultationID uuid.UUID, code string) ([]byte, error)
+	SubscribeFacts(consultationID uuid.UUID) (<-chan MedicalFact, func())
+	SetVoice(ctx context.Context, consultationID uuid.UUID, voice string) (*Consultation, error)
+	SwitchToText(ctx context.Context, consultationID uuid.UUID) (*Consultation, error)
+	RecordTurn(ctx context.Context, consultationID uuid.UUID, timings TurnTimings, slo time.Duration) error
+	RecordingEnabled(consultationID uuid.UUID) bool
+	RecordAudio(ctx context.Context, consultationID uuid.UUID, role string, data []byte)
+	Recording(ctx context.Context, consultationID uuid.UUID) ([]byte, int, error)
+	ReEngage(ctx context.Context, consultationID uuid.UUID) (*Reply, error)
+	KeypadAnswer(ctx context.Context, consultationID uuid.UUID, key string) (*Reply, error)
+	RecoverTurns(ctx context.Context) error
+	Opening(ctx context.Context, c *Consultation) (string, []byte)
 }
 
 type service struct {
-	repo         Repository
-	aiClient     AgentClient
-	ttsClient    TTSClient
-	sttClient    STTClient
-	reportSvc    ReportService
+	repo          Repository
+	aiClient      AgentClient
+	ttsClient     TTSClient
+	sttClient     STTClient
+	reportSvc     ReportService
+	flags         FeatureFlags
+	rules         RuleEngine
+	normalizer    SymptomNormalizer
+	conditions    ConditionLinker
+	escalator     RiskEscalator
+	abuse         AbusePolicy
+	abuseAlerts   AbuseNotifier
+	redFlagAlerts RedFlagNotifier
+	epid          EpidemiologyScreener
+	speech        *speechCache
+	facts         *factFeed
+	experiments   Experiments
+	filter        ResponseFilter
+	questions     QuestionMode
+	profiles      ProfileSource
+	supervisor    SupervisorSchedule
+	reportSize    ReportSize
+	vocabulary    []string
+	scheduler     Scheduler
+	translation   ReportTranslation
+	inputLimits   InputLimits
+	opening       OpeningTemplates
+	configs       ConfigVersions
+	hours         *WorkingHours
+	openings      *openingCache
+	creating      sync.Mutex // serializes the open-consultation check with the insert
+	reengaging    sync.Mutex
 }
 
-func NewService(repo Repository, ai AgentClient, tts TTSClient, stt STTClient, report ReportService) Service {
+// Deps are the collaborators of the consultation service
+type Deps struct {
+	Repo          Repository
+	AI            AgentClient
+	TTS           TTSClient
+	STT           STTClient
+	Report        ReportService
+	Flags         FeatureFlags
+	Rules         RuleEngine
+	Normalizer    SymptomNormalizer
+	Conditions    ConditionLinker
+	Escalator     RiskEscalator
+	Epidemiology  EpidemiologyScreener
+	Experiments   Experiments
+	Filter        ResponseFilter
+	Questions     QuestionMode
+	Profiles      ProfileSource
+	Abuse         AbusePolicy
+	AbuseAlerts   AbuseNotifier
+	RedFlagAlerts RedFlagNotifier
+	Supervisor    SupervisorSchedule
+	ReportSize    ReportSize
+	Vocabulary    []string // domain terms passed to speech recognition
+	Scheduler     Scheduler
+	Translation   ReportTranslation
+	InputLimits   InputLimits
+	Opening       OpeningTemplates
+	Configs       ConfigVersions
+	Hours         *WorkingHours
+}
+
+func NewService(d Deps) Service {
 	return &service{
-		repo:      repo,
-		aiClient:  ai,
-		ttsClient: tts,
-		sttClient: stt,
-		reportSvc: report,
+		repo:          d.Repo,
+		aiClient:      d.AI,
+		ttsClient:     d.TTS,
+		sttClient:     d.STT,
+		reportSvc:     d.Report,
+		flags:         d.Flags,
+		rules:         d.Rules,
+		normalizer:    d.Normalizer,
+		conditions:    d.Conditions,
+		escalator:     d.Escalator,
+		abuse:         d.Abuse,
+		abuseAlerts:   d.AbuseAlerts,
+		redFlagAlerts: d.RedFlagAlerts,
+		supervisor:    d.Supervisor,
+		reportSize:    d.ReportSize,
+		vocabulary:    d.Vocabulary,
+		scheduler:     d.Scheduler,
+		translation:   d.Translation,
+		inputLimits:   d.InputLimits,
+		opening:       d.Opening,
+		configs:       d.Configs,
+		hours:         d.Hours,
+		epid:          d.Epidemiology,
+		speech:        newSpeechCache(),
+		facts:         newFactFeed(),
+		openings:      newOpeningCache(),
+		experiments:   d.Experiments,
+		filter:        d.Filter,
+		questions:     d.Questions,
+		profiles:      d.Profiles,
 	}
 }
 
-func (s *service) TranscribeAudio(ctx context.Context, audioData []byte) (string, error) {
-	return s.sttClient.Transcribe(ctx, audioData)
+// SynthesizeSpeech voices text at the consultation's pace; nil pacing means normal speed
+func (s *service) SynthesizeSpeech(ctx context.Context, text string, pacing *Pacing) ([]byte, error) {
+	text = s.filter.ForSpeech(text)
+	if text == "" {
+		return nil, errNothingToSay
+	}
+	defer turnTimerFrom(ctx).addTTS(time.Now())
+	// The client uses its default voice
+	return s.ttsClient.Synthesize(ctx, text, pacing.Speech())
 }
 
-func (s *service) SynthesizeSpeech(ctx context.Context, text string) ([]byte, error) {
-	// Use a default voice ID or load from config/env if needed
-	// For now, we'll let the client use its default or pass empty
-	return s.ttsClient.Synthesize(ctx, text, "")
-}
+// CreateConsultation starts a new interview. Unless force is set, a patient
+// with an open consultation gets a *DuplicateError instead of a second one.
+func (s *service) CreateConsultation(ctx context.Context, patientID uuid.UUID, interview Interview, force bool) (*Consultation, error) {
+	s.creating.Lock()
+	defer s.creating.Unlock()
+	if !force {
+		existing, err := s.openConsultation(ctx, patientID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, &DuplicateError{Existing: existing}
+		}
+	}
 
-func (s *service) CreateConsultation(ctx context.Context, patientID uuid.UUID) (*Consultation, error) {
 	c := &Consultation{
 		ID:          uuid.New(),
 		PatientID:   patientID,
+		Mode:        interview.Mode,
+		Pediatric:   interview.Pediatric,
+		Pacing:      interview.Pacing,
+		Department:  interview.Department,
+		Device:      interview.Device,
+		Recipients:  interview.Recipients,
 		History:     []Message{},
 		CurrentMood: StateNeutral,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	// A kiosk standing in a department's waiting area routes its patients there
+	if c.Department == "" && c.Device != nil {
+		c.Department = c.Device.Department
+	}
+	if err := s.checkRecipients(c.Recipients); err != nil {
+		return nil, err
+	}
+	if s.experiments != nil {
+		c.Experiment, c.Arm = s.experiments.Assign(c.ID)
+	}
+	if c.Department != "" {
+		required, err := s.profiles.Required(ctx, c.Department)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load department profile: %w", err)
+		}
+		c.RequiredFields = required
+	}
+	s.addOpening(c)
 	if err := s.repo.Save(ctx, c); err != nil {
 		return nil, err
 	}
+	s.stampConfig(ctx, c)
 	return c, nil
 }
 
+func (s *service) GetConsultation(ctx context.Context, consultationID uuid.UUID) (*Consultation, error) {
+	return s.repo.GetByID(ctx, consultationID)
+}
+
+// ImportQuestionnaire scores a questionnaire and attaches it to the consultation.
+// A repeated import of the same instrument replaces the earlier one. A
+// positive self-harm item starts the risk screening.
+func (s *service) ImportQuestionnaire(ctx context.Context, consultationID uuid.UUID, instrument string, answers []int, completedAt time.Time) (*Questionnaire, error) {
+	q, err := ScoreQuestionnaire(instrument, answers, completedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := false
+	for i := range c.Questionnaires {
+		if c.Questionnaires[i].Instrument == q.Instrument {
+			c.Questionnaires[i] = *q
+			replaced = true
+		}
+	}
+	if !replaced {
+		c.Questionnaires = append(c.Questionnaires, *q)
+	}
+	c.UpdatedAt = time.Now()
+	s.questionnaireScreening(ctx, c, *q)
+
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// sendEvent delivers an event unless the request context is cancelled first,
+// so producers never block on a consumer that has gone away
+func sendEvent(ctx context.Context, eventChan chan<- StreamEvent, event StreamEvent) bool {
+	select {
+	case eventChan <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Reanalyze rebuilds the semantic memory from the full history, e.g. after a prompt change
+func (s *service) Reanalyze(ctx context.Context, consultationID uuid.UUID) (*Consultation, error) {
+	consultation, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := s.aiClient.RunAnalyst(ctx, consultation.History, consultation.RequiredFields)
+	if err != nil {
+		return nil, fmt.Errorf("analyst failed: %w", err)
+	}
+	s.normalize(analysis)
+	consultation.ExtractedFacts = analysis.Facts
+	consultation.PertinentNegatives = analysis.Negatives
+	consultation.Medications = nil
+	consultation.AddMedications(analysis.Medications)
+	consultation.PriorConditions = nil
+	consultation.AddPriorConditions(analysis.PriorConditions)
+	if consultation.Pediatric {
+		consultation.Child = childInfo(consultation.ExtractedFacts)
+	}
+	consultation.RuleFindings = s.rules.Evaluate(*consultation)
+
+	if consultation.IsComplete {
+		recs, err := s.aiClient.GenerateRecommendations(ctx, consultation.ExtractedFacts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recommendations: %w", err)
+		}
+		consultation.Recommendations = recs.Text
+		consultation.Reliability = assessReliability(*consultation, recs.Confidence)
+		s.summarizeFacts(ctx, consultation)
+		s.translateReport(ctx, consultation)
+		// Re-run the rules so conflicts with the new recommendations are flagged
+		consultation.RuleFindings = s.rules.Evaluate(*consultation)
+	}
+
+	if err := s.repo.Save(ctx, consultation); err != nil {
+		return nil, err
+	}
+	return consultation, nil
+}
+
 func (s *service) ProcessUserAudioStream(ctx context.Context, consultationID uuid.UUID, text string, eventChan chan<- StreamEvent) error {
 	// 1. Load Context
 	consultation, err := s.repo.GetByID(ctx, consultationID)
@@ -98,47 +400,149 @@ func (s *service) ProcessUserAudioStream(ctx context.Context, consultationID uui
 		return err
 	}
 
+	if err := s.checkInput(consultation, text); err != nil {
+		return err
+	}
+
+	// Voice commands are answered locally and stay out of the History
+	if reply, ok, err := s.commandTurn(ctx, consultation, text); ok {
+		if err != nil {
+			return err
+		}
+		return s.streamCommand(ctx, consultation.ID, reply, eventChan)
+	}
+
+	// During an LLM outage the message goes to the nurse station instead
+	if response, ok, err := s.bridgeTurn(ctx, consultation, text); ok {
+		if err != nil {
+			return err
+		}
+		if !sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: response}) {
+			return ctx.Err()
+		}
+		if !consultation.Pacing.textOnly() {
+			if audio, err := s.Speak(ctx, consultation.ID, response, consultation.Pacing); err == nil {
+				sendEvent(ctx, eventChan, StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(audio)})
+			}
+		}
+		sendEvent(ctx, eventChan, StreamEvent{Type: "done", Data: ""})
+		return nil
+	}
+
+	// Misheard words are confirmed before the turn enters the History
+	text, question, err := s.clarify(ctx, consultation, text)
+	if err != nil {
+		return err
+	}
+	if question != "" {
+		if !sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: question}) {
+			return ctx.Err()
+		}
+		if !consultation.Pacing.textOnly() {
+			if audio, err := s.Speak(ctx, consultation.ID, question, consultation.Pacing); err == nil {
+				sendEvent(ctx, eventChan, StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(audio)})
+			}
+		}
+		sendEvent(ctx, eventChan, StreamEvent{Type: "done", Data: ""})
+		return nil
+	}
+
+	turn := s.beginTurn(ctx, consultation, text)
+	defer turn.abandon()
+
 	// 2. Update Episodic Memory (User Input)
 	consultation.History = append(consultation.History, Message{
-		Role: "user", Content: text, Timestamp: time.Now(),
+		Role: "user", Content: text, Timestamp: time.Now(), InjectedBy: injectedBy(ctx), Key: keyPressed(ctx),
 	})
+	s.detectComplaint(consultation, text)
+	s.screenRedFlags(consultation, text)
+	s.answerDoctorQuestion(ctx, consultation)
+
+	// Risk screening takes over the dialogue until its protocol is finished, abuse gets the policy's response
+	if response, ok := s.localTurn(ctx, consultation, text); ok {
+		if !sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: response}) {
+			return ctx.Err()
+		}
+		if !consultation.Pacing.textOnly() {
+			if audio, err := s.Speak(ctx, consultation.ID, response, consultation.Pacing); err == nil {
+				sendEvent(ctx, eventChan, StreamEvent{Type: "audio", Data: base64.StdEncoding.EncodeToString(audio)})
+			}
+		}
+		sendEvent(ctx, eventChan, StreamEvent{Type: "done", Data: ""})
+		return s.saveLocalTurn(ctx, turn, consultation, response)
+	}
+
+	// Facts of the previous turn may still arrive from the background Analyst
+	facts, unsubscribe := s.facts.subscribe(consultation.ID)
+	defer unsubscribe()
 
 	// 3. Run Communicator Stream
-	tokenChan, errChan := s.aiClient.RunCommunicatorStream(ctx, consultation.History, consultation.CurrentMood)
+	timer := turnTimerFrom(ctx)
+	timer.startLLM()
+	llmCtx, served := withServedBy(ctx)
+	interview := s.interview(consultation)
+	tokenChan, errChan := s.aiClient.RunCommunicatorStream(llmCtx, consultation.History, consultation.CurrentMood, interview)
 
 	var fullResponseBuilder strings.Builder
 	var currentSentenceBuilder strings.Builder
 	var moodStrBuilder strings.Builder
+	// Once a question is out, the rest is held back until it is clear whether it asks another one
+	var heldBuilder strings.Builder
+	questionSent := false
 	inMoodBlock := false
 	moodFound := false
 	
+	// Paced consultations can't stream raw tokens: sentences are rewritten before the patient sees them
+	paced := consultation.Pacing != nil && consultation.Pacing.MaxSentenceWords > 0
+	// Blocks closing the reply (on-screen rendition, keypad answers) are sent as
+	// their own events and not voiced
+	var tailBuilder strings.Builder
+	inTail := false
+	s.speech.reset(consultation.ID)
+
 	// Helper to process sentence audio
 	processAudio := func(text string) {
 		if len(strings.TrimSpace(text)) == 0 {
 			return
 		}
-		audio, err := s.SynthesizeSpeech(context.Background(), text)
+		if paced {
+			text = s.filter.ForTranscript(consultation.Pacing.Apply(text))
+			fullResponseBuilder.WriteString(text + " ")
+			sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: text + " "})
+		}
+		if consultation.Pacing.textOnly() {
+			return
+		}
+		audio, err := s.SynthesizeSpeech(ctx, text, consultation.Pacing)
 		if err == nil {
+			s.speech.add(consultation.ID, audio)
+			s.RecordAudio(ctx, consultation.ID, "assistant", audio)
 			b64 := base64.StdEncoding.EncodeToString(audio)
-			eventChan <- StreamEvent{Type: "audio", Data: b64}
+			sendEvent(ctx, eventChan, StreamEvent{Type: "audio", Data: b64})
 		}
 	}
 
 	for {
 		select {
+		case <-ctx.Done():
+			// Client went away: drop the turn without saving or running background agents
+			return ctx.Err()
 		case err := <-errChan:
 			if err != nil {
 				return err
 			}
 			// If err is nil (closed), we are done
 			goto Done
+		case fact := <-facts:
+			sendEvent(ctx, eventChan, factEvent(fact))
 		case token, ok := <-tokenChan:
 			if !ok {
 				goto Done
 			}
+			timer.gotToken()
 
-			// Handle Mood Parsing [MOOD: ...]
-			if !moodFound {
+			// Handle Mood Parsing [MOOD: ...]; with tools the mood comes from set_mood
+			if !moodFound && !interview.Tools {
 				if strings.Contains(token, "[") {
 					inMoodBlock = true
 				}
@@ -168,10 +572,35 @@ func (s *service) ProcessUserAudioStream(ctx context.Context, consultationID uui
 				}
 			}
 
+			if consultation.Pacing.tailBlocks() {
+				if inTail {
+					tailBuilder.WriteString(token)
+					continue
+				}
+				if i := strings.Index(token, "["); i >= 0 {
+					inTail = true
+					tailBuilder.WriteString(token[i:])
+					if token = token[:i]; token == "" {
+						continue
+					}
+				}
+			}
+
 			// Content
-			fullResponseBuilder.WriteString(token)
+			if questionSent {
+				heldBuilder.WriteString(token)
+				continue
+			}
+			if s.questions != QuestionsOff && strings.Contains(token, "?") {
+				questionSent = true
+			}
 			currentSentenceBuilder.WriteString(token)
-			eventChan <- StreamEvent{Type: "text", Data: token}
+			if !paced {
+				fullResponseBuilder.WriteString(token)
+				if !sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: token}) {
+					return ctx.Err()
+				}
+			}
 
 			// Check for sentence end
 			if strings.ContainsAny(token, ".?!") {
@@ -186,157 +615,341 @@ func (s *service) ProcessUserAudioStream(ctx context.Context, consultationID uui
 	}
 
 Done:
+	timer.endLLM()
+	// Held text is dropped if it asks further questions, otherwise it goes out now
+	cut := questionsIn(heldBuilder.String())
+	if held := heldBuilder.String(); held != "" && len(cut) == 0 {
+		if !paced {
+			fullResponseBuilder.WriteString(held)
+			sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: held})
+		}
+		currentSentenceBuilder.WriteString(held)
+	}
+
 	// Process remaining audio
 	remaining := currentSentenceBuilder.String()
 	if len(remaining) > 0 {
 		processAudio(remaining)
 	}
 
-	eventChan <- StreamEvent{Type: "done", Data: ""}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	screen := s.filter.ForTranscript(tagBlock(tailBuilder.String(), ScreenTag))
+	if screen != "" {
+		sendEvent(ctx, eventChan, StreamEvent{Type: "screen", Data: screen})
+	}
+	keys := parseKeys(tagBlock(tailBuilder.String(), KeysTag))
+	if len(keys) > 0 {
+		data, _ := json.Marshal(keys)
+		sendEvent(ctx, eventChan, StreamEvent{Type: "keys", Data: string(data)})
+	}
+	tools := s.applyTools(ctx, consultation, served)
+	if tools.mood != "" {
+		consultation.CurrentMood, moodFound = tools.mood, true
+	}
+	// The farewell is followed by the read-back of the facts instead of ending the interview
+	recapping := false
+	ends := tools.ends(fullResponseBuilder.String())
+	if ends {
+		var recap string
+		if recap, recapping = s.startRecap(consultation); recapping {
+			if !paced {
+				fullResponseBuilder.WriteString(" " + recap)
+				sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: " " + recap})
+			}
+			processAudio(recap)
+		}
+	}
+	// Repeated holding messages hand the patient over to the nurse station
+	if notice, ok := s.startBridge(ctx, consultation, served.provider()); ok {
+		if !paced {
+			fullResponseBuilder.WriteString(" " + notice)
+			sendEvent(ctx, eventChan, StreamEvent{Type: "text", Data: " " + notice})
+		}
+		processAudio(notice)
+	}
+	sendEvent(ctx, eventChan, StreamEvent{Type: "done", Data: ""})
 
 	// Post-processing (Save history, Background agents)
-	response := fullResponseBuilder.String()
+	s.advanceQuestions(ctx, consultation, cut)
+	response := s.filter.ForTranscript(strings.TrimSpace(fullResponseBuilder.String()))
+	// The mood is recorded only when the reply judged it
+	var mood EmotionalState
+	if moodFound {
+		mood = consultation.CurrentMood
+	}
 	consultation.History = append(consultation.History, Message{
-		Role: "assistant", Content: response, Timestamp: time.Now(),
+		Role: "assistant", Content: response, Timestamp: time.Now(), InjectedBy: injectedBy(ctx), Provider: served.provider(), Screen: screen, Keys: keys, Mood: mood, ToolCalls: tools.calls,
 	})
+	s.keepReasoning(ctx, consultation, served)
+	s.doctorQuestionAsked(ctx, consultation)
 	
+	forceComplete := ends && !recapping
 	if err := s.repo.Save(ctx, consultation); err != nil {
 		fmt.Printf("Failed to save consultation: %v\n", err)
-	}
-
-	// Check for completion phrases
-	forceComplete := false
-	lowerResp := strings.ToLower(response)
-	if strings.Contains(lowerResp, "врач скоро подойдет") || 
-	   strings.Contains(lowerResp, "до свидания") || 
-	   strings.Contains(lowerResp, "всего доброго") ||
-	   strings.Contains(lowerResp, "ждите врача") {
-		forceComplete = true
+	} else {
+		turn.answer(forceComplete)
 	}
 
 	// Background agents
-	go func(c Consultation) {
-		bgCtx := context.Background()
-		newFacts, err := s.aiClient.RunAnalyst(bgCtx, c.History)
-		if err == nil && len(newFacts) > 0 {
-			c.ExtractedFacts = append(c.ExtractedFacts, newFacts...)
-		}
-
-		if !c.IsComplete {
-			isComplete := false
-			var err error
-
-			if forceComplete {
-				isComplete = true
-			} else {
-				isComplete, err = s.aiClient.RunSupervisor(bgCtx, c.History, c.ExtractedFacts)
-			}
-
-			if err == nil && isComplete {
-				recs, err := s.aiClient.GenerateRecommendations(bgCtx, c.ExtractedFacts)
-				if err == nil {
-					c.Recommendations = recs
-				}
-				c.IsComplete = true
-				
-				// Delay report sending to allow the voice response to finish playing on the client
-				if forceComplete {
-					fmt.Println("Waiting before sending report to allow voice response to complete...")
-					time.Sleep(10 * time.Second)
-				}
-
-				s.reportSvc.SendDoctorReport(bgCtx, c)
-			}
-		}
-		_ = s.repo.Save(bgCtx, &c)
-	}(*consultation)
+	go s.runTurnAgents(turn, *consultation, forceComplete)
 
 	return nil
 }
 
 // ProcessUserAudio acts as the Central Executive
-func (s *service) ProcessUserAudio(ctx context.Context, consultationID uuid.UUID, text string) (string, error) {
+func (s *service) ProcessUserAudio(ctx context.Context, consultationID uuid.UUID, text string) (*Reply, error) {
 	// 1. Load Context (Working Memory)
 	consultation, err := s.repo.GetByID(ctx, consultationID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	if err := s.checkInput(consultation, text); err != nil {
+		return nil, err
+	}
+
+	// Voice commands are answered locally and stay out of the History
+	if reply, ok, err := s.commandTurn(ctx, consultation, text); ok {
+		return reply, err
+	}
+
+	// During an LLM outage the message goes to the nurse station instead
+	if response, ok, err := s.bridgeTurn(ctx, consultation, text); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &Reply{Text: response, Pacing: consultation.Pacing}, nil
+	}
+
+	// Misheard words are confirmed before the turn enters the History
+	text, question, err := s.clarify(ctx, consultation, text)
+	if err != nil {
+		return nil, err
+	}
+	if question != "" {
+		return &Reply{Text: question, Pacing: consultation.Pacing}, nil
+	}
+
+	turn := s.beginTurn(ctx, consultation, text)
+	defer turn.abandon()
+
 	// 2. Update Episodic Memory (User Input)
 	consultation.History = append(consultation.History, Message{
-		Role: "user", Content: text, Timestamp: time.Now(),
+		Role: "user", Content: text, Timestamp: time.Now(), InjectedBy: injectedBy(ctx), Key: keyPressed(ctx),
 	})
+	s.detectComplaint(consultation, text)
+	s.screenRedFlags(consultation, text)
+	s.answerDoctorQuestion(ctx, consultation)
+
+	// Risk screening takes over the dialogue until its protocol is finished, abuse gets the policy's response
+	if response, ok := s.localTurn(ctx, consultation, text); ok {
+		if err := s.saveLocalTurn(ctx, turn, consultation, response); err != nil {
+			return nil, err
+		}
+		return &Reply{Text: response, Pacing: consultation.Pacing, Keys: consultation.screeningKeys()}, nil
+	}
 
 	// 3. Run Communicator Agent (Synchronous - Fast Path)
-	response, newMood, err := s.aiClient.RunCommunicator(ctx, consultation.History, consultation.CurrentMood)
+	timer := turnTimerFrom(ctx)
+	timer.startLLM()
+	llmCtx, served := withServedBy(ctx)
+	response, newMood, err := s.aiClient.RunCommunicator(llmCtx, consultation.History, consultation.CurrentMood, s.interview(consultation))
 	if err != nil {
-		return "", fmt.Errorf("communicator failed: %w", err)
+		return nil, fmt.Errorf("communicator failed: %w", err)
+	}
+	timer.endLLM()
+	response, tail := splitTail(response)
+	screen := s.filter.ForTranscript(tagBlock(tail, ScreenTag))
+	keys := parseKeys(tagBlock(tail, KeysTag))
+	response, cut := s.limitQuestions(response)
+	s.advanceQuestions(ctx, consultation, cut)
+	response = s.filter.ForTranscript(consultation.Pacing.Apply(response))
+	tools := s.applyTools(ctx, consultation, served)
+	if tools.mood != "" {
+		newMood = tools.mood
 	}
 
-	// Check for completion phrases to force finish the consultation
+	// Check for the end of the interview to force finish the consultation
 	// This ensures that if the AI says "Doctor is coming", we definitely send the report.
-	forceComplete := false
-	lowerResp := strings.ToLower(response)
-	if strings.Contains(lowerResp, "врач скоро подойдет") || 
-	   strings.Contains(lowerResp, "до свидания") || 
-	   strings.Contains(lowerResp, "всего доброго") ||
-	   strings.Contains(lowerResp, "ждите врача") {
-		forceComplete = true
-		fmt.Println("Detected completion phrase in assistant response. Forcing completion.")
+	forceComplete := tools.ends(response)
+	if forceComplete {
+		fmt.Println("Assistant ended the interview. Forcing completion.")
+	}
+	// Unless the facts are read back first
+	if forceComplete {
+		if recap, ok := s.startRecap(consultation); ok {
+			response += " " + recap
+			forceComplete = false
+		}
+	}
+	// Repeated holding messages hand the patient over to the nurse station
+	if notice, ok := s.startBridge(ctx, consultation, served.provider()); ok {
+		response += " " + notice
 	}
 	
 	// Update Episodic Memory (AI Response) & Emotional State
 	consultation.History = append(consultation.History, Message{
-		Role: "assistant", Content: response, Timestamp: time.Now(),
+		Role: "assistant", Content: response, Timestamp: time.Now(), InjectedBy: injectedBy(ctx), Provider: served.provider(), Screen: screen, Keys: keys, Mood: newMood, ToolCalls: tools.calls,
 	})
+	s.keepReasoning(ctx, consultation, served)
+	s.doctorQuestionAsked(ctx, consultation)
 	consultation.CurrentMood = newMood
 
 	// 4. Save State immediately
 	if err := s.repo.Save(ctx, consultation); err != nil {
-		return "", err
+		return nil, err
 	}
+	turn.answer(forceComplete)
 
 	// 5. Run Analyst & Supervisor Agents (Asynchronous - Background Processing)
-	go func(c Consultation) {
-		// Create a detached context for background work
-		bgCtx := context.Background()
-
-		// Analyst: Extract Facts
-		newFacts, err := s.aiClient.RunAnalyst(bgCtx, c.History)
-		if err == nil && len(newFacts) > 0 {
-			c.ExtractedFacts = append(c.ExtractedFacts, newFacts...)
-		}
+	go s.runTurnAgents(turn, *consultation, forceComplete)
 
-		// Supervisor: Check if we are done
-		// Only run supervisor if the consultation is not already marked as complete
-		if !c.IsComplete {
-			isComplete := false
-			var err error
+	return &Reply{Text: response, Pacing: consultation.Pacing, Screen: screen, Keys: keys}, nil
+}
 
-			if forceComplete {
-				isComplete = true
-				fmt.Println("Forcing completion based on assistant response.")
-			} else {
-				isComplete, err = s.aiClient.RunSupervisor(bgCtx, c.History, c.ExtractedFacts)
+// saveLocalTurn records a reply made without the Communicator, such as a
+// screening question. Background agents still run so facts keep accumulating,
+// but the supervisor waits until a screening protocol is finished.
+func (s *service) saveLocalTurn(ctx context.Context, turn *turnCheckpoint, c *Consultation, response string) error {
+	c.History = append(c.History, Message{
+		Role: "assistant", Content: response, Timestamp: time.Now(), InjectedBy: injectedBy(ctx), Keys: c.screeningKeys(),
+	})
+	if err := s.repo.Save(ctx, c); err != nil {
+		return err
+	}
+	// An answered recap ends the interview as the Communicator's farewell would
+	forceComplete := c.Recap.Answered() && isCompletionPhrase(response)
+	turn.answer(forceComplete)
+	go s.runTurnAgents(turn, *c, forceComplete)
+	return nil
+}
+
+// normalize attaches controlled vocabulary codes to the Analyst's free text
+func (s *service) normalize(analysis *AnalysisResult) {
+	for i := range analysis.Facts {
+		analysis.Facts[i].Code = s.normalizer.Normalize(analysis.Facts[i].Description)
+	}
+	for i := range analysis.Negatives {
+		analysis.Negatives[i].Code = s.normalizer.Normalize(analysis.Negatives[i].Symptom + " " + analysis.Negatives[i].Context)
+	}
+	for i := range analysis.PriorConditions {
+		analysis.PriorConditions[i].Code = s.conditions.Normalize(analysis.PriorConditions[i].Name)
+	}
+}
+
+// isCompletionPhrase detects the assistant's farewell, which signals the end of the interview
+func isCompletionPhrase(response string) bool {
+	lowerResp := strings.ToLower(response)
+	return strings.Contains(lowerResp, "врач скоро подойдет") ||
+		strings.Contains(lowerResp, "до свидания") ||
+		strings.Contains(lowerResp, "всего доброго") ||
+		strings.Contains(lowerResp, "ждите врача")
+}
+
+// runBackgroundAgents runs the Analyst & Supervisor agents on a snapshot of the consultation
+func (s *service) runBackgroundAgents(c Consultation, forceComplete bool) {
+	// Create a detached context for background work
+	bgCtx := context.Background()
+	wasComplete := c.IsComplete
+	injector := c.lastTurnInjectedBy()
+
+	// Analyst: Extract Facts and pertinent negatives
+	analysis, err := s.aiClient.RunAnalyst(bgCtx, c.History, c.RequiredFields)
+	newFacts := err == nil && (len(analysis.Facts) > 0 || len(analysis.Negatives) > 0)
+	if err == nil {
+		s.normalize(analysis)
+		for i := range analysis.Facts {
+			analysis.Facts[i].InjectedBy = injector
+		}
+		c.ExtractedFacts = append(c.ExtractedFacts, analysis.Facts...)
+		c.AddNegatives(analysis.Negatives)
+		c.AddMedications(analysis.Medications)
+		c.AddPriorConditions(analysis.PriorConditions)
+		s.facts.publish(c.ID, analysis.Facts)
+	}
+	if c.Pediatric {
+		c.Child = childInfo(c.ExtractedFacts)
+	}
+	if len(c.EpidTopics) == 0 {
+		c.EpidTopics = s.epid.Required(c)
+	}
+	c.RuleFindings = s.rules.Evaluate(c)
+
+	// Supervisor: Check if we are done
+	// Only run supervisor if the consultation is not already marked as complete
+	// and no risk screening is in progress
+	screening := c.RiskScreening != nil && c.RiskScreening.Active
+	recapping := c.Recap != nil && c.Recap.Active
+	if !c.IsComplete && !screening && !recapping {
+		isComplete := false
+		var err error
+
+		if gaps := c.coverageGaps(); forceComplete && s.supervisor.Veto && len(gaps) > 0 {
+			// Said goodbye too early; the Communicator is told to go on next turn
+			fmt.Printf("Completion of consultation %s vetoed, missing: %s\n", c.ID, strings.Join(gaps, "; "))
+			_ = s.repo.Save(bgCtx, &c)
+			return
+		} else if forceComplete {
+			isComplete = true
+			fmt.Println("Forcing completion based on assistant response.")
+		} else if !s.supervisor.due(&c, newFacts) {
+			// Saves a call; the interview goes on either way
+			_ = s.repo.Save(bgCtx, &c)
+			return
+		} else {
+			isComplete, err = s.aiClient.RunSupervisor(bgCtx, c.History, c.ExtractedFacts, c.PertinentNegatives, c.PendingRequired())
+			c.SupervisorRounds++
+			if err == nil {
+				c.SupervisorTurn = patientTurns(&c)
+			}
+			// Medication reconciliation is only done once every entry is fully described
+			if c.Mode == ModeMedicationReconciliation && !c.MedicationsComplete() {
+				isComplete = false
+			}
+			// The epidemiological block must be covered once it was required
+			if len(c.PendingEpidTopics()) > 0 {
+				isComplete = false
+			}
+			// So must the department's required information
+			if len(c.PendingRequired()) > 0 {
+				isComplete = false
 			}
+		}
 
+		if err != nil {
+			fmt.Printf("Supervisor error: %v\n", err)
+		}
+		if err == nil && isComplete {
+			fmt.Println("Supervisor decided consultation is complete. Generating recommendations...")
+			
+			// Generate Recommendations
+			recs, err := s.aiClient.GenerateRecommendations(bgCtx, c.ExtractedFacts)
+			modelConfidence := -1
 			if err != nil {
-				fmt.Printf("Supervisor error: %v\n", err)
+				fmt.Printf("Failed to generate recommendations: %v\n", err)
+				c.Recommendations = "Не удалось сгенерировать рекомендации."
+			} else {
+				c.Recommendations = recs.Text
+				modelConfidence = recs.Confidence
+				// Booked before the report is written so the doctor sees the slot
+				s.bookFollowUp(bgCtx, &c, recs.FollowUp)
 			}
-			if err == nil && isComplete {
-				fmt.Println("Supervisor decided consultation is complete. Generating recommendations...")
-				
-				// Generate Recommendations
-				recs, err := s.aiClient.GenerateRecommendations(bgCtx, c.ExtractedFacts)
-				if err != nil {
-					fmt.Printf("Failed to generate recommendations: %v\n", err)
-					c.Recommendations = "Не удалось сгенерировать рекомендации."
-				} else {
-					c.Recommendations = recs
-				}
-
-				c.IsComplete = true
-				
+			c.Reliability = assessReliability(c, modelConfidence)
+			s.summarizeFacts(bgCtx, &c)
+			s.translateReport(bgCtx, &c)
+			// Re-run the rules so conflicts with the recommendations make it into the report
+			c.RuleFindings = s.rules.Evaluate(c)
+			s.stampConfig(bgCtx, &c)
+
+			c.IsComplete = true
+
+			if s.flags.Enabled(flags.NurseReview, c.ID) {
+				// The clinic signs off every report; it is sent on approval
+				c.Review = &Review{Status: ReviewPending}
+				fmt.Println("Consultation queued for nurse review.")
+			} else {
 				// Delay report sending to allow the voice response to finish playing on the client
 				// This is a simple heuristic. Ideally, the client should acknowledge playback.
 				if forceComplete {
@@ -345,19 +958,31 @@ func (s *service) ProcessUserAudio(ctx context.Context, consultationID uuid.UUID
 				}
 
 				// Trigger Report Generation
-				if err := s.reportSvc.SendDoctorReport(bgCtx, c); err != nil {
+				c.ReportRevision = 1
+				if injector != "" {
+					fmt.Printf("Support console: report of consultation %s completed by a turn of %s is not sent\n", c.ID, injector)
+				} else if err := s.dispatchReport(bgCtx, &c); err != nil {
 					fmt.Printf("Failed to send report: %v\n", err)
 				} else {
 					fmt.Println("Report sent successfully.")
 				}
+			}
+
+			// QA: score the interview for monitoring, once the report is out of the critical path
+			review, err := s.aiClient.RunQualityReview(bgCtx, c.History, c.ExtractedFacts)
+			if err != nil {
+				fmt.Printf("Quality review failed: %v\n", err)
 			} else {
-				fmt.Println("Supervisor decided consultation is NOT complete yet.")
+				c.Quality = review
 			}
+		} else {
+			fmt.Println("Supervisor decided consultation is NOT complete yet.")
 		}
+	} else if wasComplete && newFacts && injector == "" {
+		// Facts that came in after the report was written
+		s.reviseReport(bgCtx, &c)
+	}
 
-		// Save updated cognitive state
-		_ = s.repo.Save(bgCtx, &c)
-	}(*consultation)
-
-	return response, nil
+	// Save updated cognitive state
+	_ = s.repo.Save(bgCtx, &c)
 }
@@ -0,0 +1,159 @@
+package consultation
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// SessionChannels tracks which device currently owns a consultation's session.
+// The channel starts at 0 and is advanced by every handoff.
+type SessionChannels interface {
+	SessionChannel(ctx context.Context, consultationID uuid.UUID) (int, error)
+	AdvanceSessionChannel(ctx context.Context, consultationID uuid.UUID) (int, error)
+}
+
+// SessionSigner issues short-lived tokens bound to a single consultation, so
+// kiosks can reach patient-facing resources without a user login. A token is
+// also bound to a channel, so handing the session to another device revokes it.
+type SessionSigner struct {
+	secret   []byte
+	ttl      time.Duration
+	channels SessionChannels
+
+	mu           sync.Mutex
+	handoffs     map[string]pendingHandoff
+	certificates map[string]pendingCertificate
+	streams      map[uuid.UUID]map[chan struct{}]struct{}
+	chats        map[int64]telegramChat
+}
+
+func NewSessionSigner(secret []byte, ttl time.Duration, channels SessionChannels) *SessionSigner {
+	return &SessionSigner{
+		secret:       secret,
+		ttl:          ttl,
+		channels:     channels,
+		handoffs:     make(map[string]pendingHandoff),
+		certificates: make(map[string]pendingCertificate),
+		streams:      make(map[uuid.UUID]map[chan struct{}]struct{}),
+		chats:        make(map[int64]telegramChat),
+	}
+}
+
+var (
+	errInvalidSession = errors.New("invalid session token")
+	errSessionMoved   = errors.New("session was moved to another device")
+)
+
+// Sign returns a token for a new consultation (channel 0) and its expiry time
+func (s *SessionSigner) Sign(consultationID uuid.UUID) (string, time.Time) {
+	return s.signChannel(consultationID, 0)
+}
+
+// signChannel returns a token of the form "<expiry>.<signature>" for channel 0
+// and "<expiry>.<channel>.<signature>" after a handoff
+func (s *SessionSigner) signChannel(consultationID uuid.UUID, channel int) (string, time.Time) {
+	expires := time.Now().Add(s.ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	if channel == 0 {
+		return exp + "." + s.signature(consultationID, exp, 0), expires
+	}
+	return exp + "." + strconv.Itoa(channel) + "." + s.signature(consultationID, exp, channel), expires
+}
+
+// Verify checks that token was issued for consultationID, has not expired and
+// belongs to the device that currently owns the session
+func (s *SessionSigner) Verify(ctx context.Context, token string, consultationID uuid.UUID) error {
+	parts := strings.Split(token, ".")
+	exp, sig, channel := parts[0], parts[len(parts)-1], 0
+	switch len(parts) {
+	case 2:
+	case 3:
+		var err error
+		if channel, err = strconv.Atoi(parts[1]); err != nil || channel <= 0 {
+			return errInvalidSession
+		}
+	default:
+		return errInvalidSession
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.signature(consultationID, exp, channel))) {
+		return errInvalidSession
+	}
+
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return errInvalidSession
+	}
+	if time.Now().After(time.Unix(unix, 0)) {
+		return fmt.Errorf("session token expired")
+	}
+
+	current, err := s.channels.SessionChannel(ctx, consultationID)
+	if err != nil {
+		return err
+	}
+	if channel != current {
+		return errSessionMoved
+	}
+	return nil
+}
+
+// signature covers the channel only after a handoff, so channel 0 tokens keep
+// the original format
+func (s *SessionSigner) signature(consultationID uuid.UUID, exp string, channel int) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(consultationID[:])
+	mac.Write([]byte(exp))
+	if channel > 0 {
+		mac.Write([]byte("." + strconv.Itoa(channel)))
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sessionToken reads the token from the "token" query parameter or the X-Session-Token header
+func sessionToken(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	return r.Header.Get("X-Session-Token")
+}
+
+// checkChannel guards the turn endpoints, which predate session tokens: they
+// stay open until the first handoff, after which only the new device may talk
+func (s *SessionSigner) checkChannel(r *http.Request, consultationID uuid.UUID) error {
+	current, err := s.channels.SessionChannel(r.Context(), consultationID)
+	if err != nil || current == 0 {
+		return err
+	}
+	return s.Verify(r.Context(), sessionToken(r), consultationID)
+}
+
+// requireSession guards /consultation/{id}/... routes. The token may come as a
+// "token" query parameter (signed URL, works for <audio src> and EventSource)
+// or in the X-Session-Token header.
+func (s *SessionSigner) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.Verify(r.Context(), sessionToken(r), id); err != nil {
+			http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
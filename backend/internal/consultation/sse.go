@@ -0,0 +1,70 @@
+package consultation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Proxies tend to cut connections idle for 30-60s, so a quiet stream gets a
+// ping well before that
+const pingInterval = 15 * time.Second
+
+// sseWriter writes server-sent events to the response. It must only be used
+// from the handler goroutine: writing to a ResponseWriter after the handler
+// has returned panics, so producers hand events over through a channel.
+type sseWriter struct {
+	w         http.ResponseWriter
+	flusher   http.Flusher
+	lastWrite time.Time
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	// Stops nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	return &sseWriter{w: w, flusher: flusher, lastWrite: time.Now()}, true
+}
+
+// Send writes a single event. An error means the client is gone.
+func (s *sseWriter) Send(event StreamEvent) error {
+	return s.SendID("", event)
+}
+
+// SendID writes an event with an ID the client echoes back in Last-Event-ID
+// when it reconnects
+func (s *sseWriter) SendID(id string, event StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	s.lastWrite = time.Now()
+	return nil
+}
+
+// keepAlive sends a ping if nothing was written for pingInterval
+func (s *sseWriter) keepAlive() error {
+	if time.Since(s.lastWrite) < pingInterval {
+		return nil
+	}
+	return s.Send(StreamEvent{Type: "ping"})
+}
@@ -0,0 +1,78 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// STTHints steer speech recognition for one consultation
+type STTHints struct {
+	Language string // ISO 639-1 code; empty lets Whisper detect the language
+	Prompt   string // terms to expect, passed to Whisper as its initial prompt
+}
+
+const defaultSTTLanguage = "ru"
+
+// Whisper uses only the last 224 tokens of the prompt; Cyrillic takes about
+// one token per two or three characters
+const maxSTTPromptRunes = 500
+
+// Kiosk languages Whisper knows; others are left to its detection
+var whisperLanguages = map[string]bool{"ru": true, "en": true, "kk": true, "uz": true, "tg": true}
+
+// sttHints builds the hints from the consultation's kiosk language and the
+// drugs and diagnoses already named in it, followed by the clinic vocabulary.
+// An unknown consultation gets the defaults.
+func (s *service) sttHints(ctx context.Context, consultationID uuid.UUID) STTHints {
+	hints := STTHints{Language: defaultSTTLanguage}
+	var terms []string
+	if consultationID != uuid.Nil {
+		c, err := s.repo.GetByID(ctx, consultationID)
+		if err != nil {
+			fmt.Printf("STT hints for consultation %s: %v\n", consultationID, err)
+		} else {
+			if c.Device != nil && c.Device.Language != "" {
+				hints.Language = ""
+				if whisperLanguages[c.Device.Language] {
+					hints.Language = c.Device.Language
+				}
+			}
+			for _, m := range c.Medications {
+				terms = append(terms, m.Name)
+			}
+			for _, p := range c.PriorConditions {
+				terms = append(terms, p.Name)
+			}
+		}
+	}
+	hints.Prompt = sttPrompt(append(terms, s.vocabulary...))
+	return hints
+}
+
+// sttPrompt lists distinct terms in order until the prompt is full
+func sttPrompt(terms []string) string {
+	var kept []string
+	seen := map[string]bool{}
+	length := 0
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		n := len([]rune(t)) + 2
+		if length+n > maxSTTPromptRunes {
+			break
+		}
+		seen[key] = true
+		kept = append(kept, t)
+		length += n
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, ", ") + "."
+}
@@ -0,0 +1,78 @@
+package consultation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Summary is a row of the consultation_summaries view: the state of a
+// consultation without its history and facts, for frequently polled dashboards
+type Summary struct {
+	ID             uuid.UUID      `json:"id"`
+	Ticket         int            `json:"ticket"`
+	Mode           InterviewMode  `json:"mode"`
+	Mood           EmotionalState `json:"mood"`
+	IsComplete     bool           `json:"is_complete"`
+	Messages       int            `json:"messages"`
+	ReviewStatus   ReviewStatus   `json:"review_status,omitempty"`
+	VisitState     VisitState     `json:"visit_state,omitempty"`
+	Room           string         `json:"room,omitempty"`
+	RiskActive     bool           `json:"risk_active"`
+	RiskLevel      RiskLevel      `json:"risk_level,omitempty"`
+	RedFlag        bool           `json:"red_flag"`                  // a rule with red triage fired or the red-flag screen found an emergency
+	ChiefComplaint string         `json:"chief_complaint,omitempty"` // empty until detected
+	AssignedRoom   string         `json:"assigned_room,omitempty"`
+	AssignedBed    string         `json:"assigned_bed,omitempty"`
+	Location       Location       `json:"location"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Alert reasons, in the order staff should read them
+const (
+	AlertRiskScreening = "risk_screening"
+	AlertCriticalMood  = "critical_mood"
+	AlertRedFlag       = "red_flag"
+)
+
+// AlertReasons lists why the consultation needs a nurse right away; empty if it does not
+func (s Summary) AlertReasons() []string {
+	var reasons []string
+	if s.RiskActive || s.RiskLevel == RiskModerate || s.RiskLevel == RiskHigh {
+		reasons = append(reasons, AlertRiskScreening)
+	}
+	if s.Mood == StateCritical {
+		reasons = append(reasons, AlertCriticalMood)
+	}
+	if s.RedFlag {
+		reasons = append(reasons, AlertRedFlag)
+	}
+	return reasons
+}
+
+// AlertReasons is Summary.AlertReasons for the full consultation
+func (c *Consultation) AlertReasons() []string {
+	s := Summary{Mood: c.CurrentMood}
+	if rs := c.RiskScreening; rs != nil {
+		s.RiskActive, s.RiskLevel = rs.Active, rs.Level
+	}
+	for _, f := range c.RuleFindings {
+		if f.Triage == "red" {
+			s.RedFlag = true
+		}
+	}
+	if len(c.RedFlags) > 0 {
+		s.RedFlag = true
+	}
+	return s.AlertReasons()
+}
+
+// BoardEntry returns the line the waiting-room board shows for this consultation
+func (s Summary) BoardEntry() BoardEntry {
+	var visit *Visit
+	if s.VisitState != "" {
+		visit = &Visit{State: s.VisitState, Room: s.Room}
+	}
+	return boardEntry(s.Ticket, s.IsComplete, visit)
+}
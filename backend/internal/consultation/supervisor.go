@@ -0,0 +1,54 @@
+package consultation
+
+import "fmt"
+
+// SupervisorSchedule spaces out Supervisor runs, which otherwise cost a call
+// on every turn. Turns are counted in patient messages; 0 turns off a limit.
+type SupervisorSchedule struct {
+	// Messages in the history before the first run; the agent won't decide on fewer
+	MinHistory int
+	// Run at most every this many turns, or sooner when the Analyst found new facts
+	Every int
+	// Skip this many turns after the Supervisor decided the interview is not complete, even with new facts
+	Cooldown int
+	// Keep the interview going when the Communicator says goodbye with coverage gaps, see coverageGaps
+	Veto bool
+}
+
+var DefaultSupervisorSchedule = SupervisorSchedule{
+	MinHistory: 4,
+	Every:      3,
+	Cooldown:   1,
+	Veto:       true,
+}
+
+// due reports whether the Supervisor should judge the consultation this
+// turn. The first decision is made as soon as the history is long enough.
+func (p SupervisorSchedule) due(c *Consultation, newFacts bool) bool {
+	if len(c.History) < p.MinHistory {
+		return false
+	}
+	if c.SupervisorTurn == 0 {
+		return true
+	}
+	since := patientTurns(c) - c.SupervisorTurn
+	if since <= p.Cooldown {
+		fmt.Printf("Supervisor cooling down for consultation %s (%d turns since it declined)\n", c.ID, since)
+		return false
+	}
+	if newFacts || p.Every <= 1 || since >= p.Every {
+		return true
+	}
+	fmt.Printf("Supervisor skipped for consultation %s: no new facts\n", c.ID)
+	return false
+}
+
+func patientTurns(c *Consultation) int {
+	n := 0
+	for _, m := range c.History {
+		if m.Role == "user" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,153 @@
+package consultation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// A patient can continue the consultation in the clinic's Telegram bot. The
+// handoff link opens the bot with "/start h_<code>"; the chat claims the code
+// like any new device and its messages become turns, with the reply text
+// streamed back into the chat. Replies in the chat are not voiced.
+
+// ErrNoTelegramChat is returned for messages from a chat no consultation was
+// handed off to, or whose consultation has moved on to another device
+var ErrNoTelegramChat = errors.New("no consultation in this telegram chat")
+
+// Handoff codes in the bot link are prefixed so they are not taken for
+// certificate codes, which come in the same "/start" command
+const telegramHandoffPrefix = "h_"
+
+// telegramChat is the consultation a chat continues and the session channel
+// it got; a later handoff advances the channel and unbinds the chat
+type telegramChat struct {
+	consultationID uuid.UUID
+	channel        int
+}
+
+// TelegramHandoffCode returns the handoff code of a "/start" payload, if it is one
+func TelegramHandoffCode(payload string) (string, bool) {
+	return strings.CutPrefix(strings.TrimSpace(payload), telegramHandoffPrefix)
+}
+
+func (s *SessionSigner) bindChat(chatID int64, consultationID uuid.UUID, channel int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chats[chatID] = telegramChat{consultationID: consultationID, channel: channel}
+}
+
+func (s *SessionSigner) unbindChat(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chats, chatID)
+}
+
+// chatSession returns the consultation of a chat while the chat still owns its session
+func (s *SessionSigner) chatSession(ctx context.Context, chatID int64) (uuid.UUID, error) {
+	s.mu.Lock()
+	chat, ok := s.chats[chatID]
+	s.mu.Unlock()
+	if !ok {
+		return uuid.Nil, ErrNoTelegramChat
+	}
+	channel, err := s.channels.SessionChannel(ctx, chat.consultationID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if channel != chat.channel {
+		s.unbindChat(chatID)
+		return uuid.Nil, ErrNoTelegramChat
+	}
+	return chat.consultationID, nil
+}
+
+// ClaimTelegramChat moves the consultation of a handoff code to a Telegram
+// chat and switches it to text replies. It returns the assistant's last
+// reply, for the chat to pick up where the kiosk left off.
+func (h *Handler) ClaimTelegramChat(ctx context.Context, code string, chatID int64) (string, error) {
+	id, _, _, err := h.sessions.claimHandoff(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	channel, err := h.sessions.channels.SessionChannel(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	c, err := h.svc.SwitchToText(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to switch to text replies: %w", err)
+	}
+	h.sessions.bindChat(chatID, id, channel)
+	fmt.Printf("Consultation %s continues in Telegram chat %d\n", id, chatID)
+	last, _ := c.lastAssistantMessage()
+	return last, nil
+}
+
+// TelegramTurn runs a message from a Telegram chat as a turn of its
+// consultation and returns the reply text as it is generated; the channel is
+// closed when the turn is over. The turn runs like a streamed one from the
+// kiosk, with the stream deadline, and ctx only bounds the delivery: a chat
+// that stops reading leaves the turn to the orphan grace period.
+func (h *Handler) TelegramTurn(ctx context.Context, chatID int64, text string) (<-chan string, error) {
+	id, err := h.sessions.chatSession(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := h.svc.GetConsultation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsComplete {
+		h.sessions.unbindChat(chatID)
+		return nil, ErrConsultationComplete
+	}
+
+	turnCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeouts.Stream)
+	turnCtx, timer := withTurnTimer(turnCtx)
+	turn := h.turns.start(id)
+	turn.append(StreamEvent{Type: "user_text", Data: text})
+	turn.join()
+	go func() {
+		defer cancel()
+		h.runTurn(turnCtx, id, text, timer, turn)
+	}()
+
+	chunks := make(chan string)
+	go func() {
+		defer close(chunks)
+		defer turn.leave(false)
+		for from := 0; ; {
+			events, done, changed := turn.since(from)
+			for _, event := range events {
+				from++
+				chunk := event.Data
+				switch {
+				case event.Type == "text":
+				case event.Type == "error" && event.Data != errTurnSuperseded.Error():
+					fmt.Printf("Telegram turn of consultation %s failed: %s\n", id, event.Data)
+					chunk = "\n\nНе получилось ответить на сообщение, попробуйте отправить его ещё раз."
+				default:
+					continue
+				}
+				select {
+				case chunks <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if done {
+				return
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
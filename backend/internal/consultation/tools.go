@@ -0,0 +1,125 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Backend tools the Communicator may call with its reply when the
+// communicator_tools flag is on. They replace the "[MOOD: ...]" prefix and
+// the farewell phrase, which stay as the fallback for providers without
+// function calling.
+const (
+	ToolSetMood         = "set_mood"
+	ToolRecordVital     = "record_vital_sign"
+	ToolFlagEmergency   = "flag_emergency"
+	ToolEndConsultation = "end_consultation"
+)
+
+// ToolCall is one call the Communicator made, decoded by the agent client
+type ToolCall struct {
+	Tool   string         `json:"tool"`
+	Mood   EmotionalState `json:"mood,omitempty"`   // set_mood
+	Vital  *Measurement   `json:"vital,omitempty"`  // record_vital_sign, as the patient reported it
+	Reason string         `json:"reason,omitempty"` // flag_emergency
+}
+
+// RecordToolCalls is called by the agent client after a provider that was
+// offered the tools answered, with the calls it made, possibly none. Outside
+// a Communicator call it does nothing.
+func RecordToolCalls(ctx context.Context, calls []ToolCall) {
+	s, _ := ctx.Value(providerKey{}).(*servedBy)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = true
+	s.calls = append(s.calls, calls...)
+}
+
+func (s *servedBy) toolCalls() (calls []ToolCall, offered bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.tools
+}
+
+// toolTurn is what the Communicator's tool calls did in one turn
+type toolTurn struct {
+	offered bool // the reply came from a provider with the tools
+	mood    EmotionalState
+	end     bool
+	calls   []ToolCall
+}
+
+// ends reports whether the reply ends the interview: by end_consultation
+// when the Communicator had the tools, by the farewell phrase otherwise
+func (t toolTurn) ends(response string) bool {
+	if t.offered {
+		return t.end
+	}
+	return isCompletionPhrase(response)
+}
+
+// applyTools carries out the Communicator's tool calls on c before the turn
+// is saved. A call that cannot be carried out is logged and skipped.
+func (s *service) applyTools(ctx context.Context, c *Consultation, served *servedBy) toolTurn {
+	calls, offered := served.toolCalls()
+	t := toolTurn{offered: offered, calls: calls}
+	emergency := false
+	for _, call := range calls {
+		switch call.Tool {
+		case ToolSetMood:
+			t.mood = call.Mood
+		case ToolRecordVital:
+			if err := s.recordReportedVital(ctx, c, call.Vital); err != nil {
+				fmt.Printf("Communicator tool %s for consultation %s: %v\n", call.Tool, c.ID, err)
+			}
+		case ToolFlagEmergency:
+			s.flagEmergency(ctx, c, call.Reason)
+			emergency = true
+		case ToolEndConsultation:
+			t.end = true
+		}
+	}
+	// An emergency outweighs a calmer mood set in the same reply
+	if emergency {
+		t.mood = StateCritical
+	}
+	return t
+}
+
+// recordReportedVital stores a measurement the patient named, such as the
+// blood pressure taken at home, next to the device readings
+func (s *service) recordReportedVital(ctx context.Context, c *Consultation, m *Measurement) error {
+	if m == nil {
+		return fmt.Errorf("%w: no measurement", ErrInvalidVitals)
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	m.MeasuredAt = time.Now()
+	if err := s.repo.AddVital(ctx, c.ID, *m); err != nil {
+		return err
+	}
+	c.Vitals = append(c.Vitals, *m)
+	c.ExtractedFacts = append(c.ExtractedFacts, MedicalFact{
+		Category: VitalsCategory, Description: m.Label() + " (со слов пациента)", Confidence: "Medium",
+	})
+	return nil
+}
+
+// flagEmergency marks the patient critical, which puts the consultation on
+// the nurse station's alerts and sends its report to the on-call chain after
+// hours, and leaves the reason in the staff notes
+func (s *service) flagEmergency(ctx context.Context, c *Consultation, reason string) {
+	c.CurrentMood = StateCritical
+	fmt.Printf("Communicator flagged an emergency in consultation %s: %s\n", c.ID, reason)
+	note := Note{ID: uuid.New(), Author: "Communicator", Text: "Экстренная ситуация по оценке ассистента: " + reason, CreatedAt: time.Now()}
+	if err := s.repo.AddNote(ctx, c.ID, note); err != nil {
+		fmt.Printf("Failed to note the emergency in consultation %s: %v\n", c.ID, err)
+	}
+}
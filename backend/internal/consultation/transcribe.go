@@ -0,0 +1,233 @@
+package consultation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Long monologues are split into overlapping chunks that are transcribed in
+// parallel, so a minute of speech takes about as long as a short answer
+const (
+	longAudio       = 30 * time.Second
+	sttChunk        = 20 * time.Second
+	sttChunkOverlap = 2 * time.Second
+	sttParallelism  = 3
+
+	// How many words the overlap can repeat at a chunk boundary
+	maxOverlapWords = 12
+)
+
+// STTProgress is the "stt_progress" stream event of a chunked transcription
+type STTProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TranscribeAudio transcribes an upload. Only PCM WAV can be cut without
+// decoding, so other formats go to STT in one request without buffering.
+// progress, if not nil, is called once chunking starts and after every chunk.
+// consultationID may be uuid.Nil when the upload does not name it before the audio.
+// Audio without recognizable speech gives ErrSTTEmpty.
+func (s *service) TranscribeAudio(ctx context.Context, consultationID uuid.UUID, audio io.Reader, progress func(STTProgress)) (Transcript, error) {
+	t, err := s.transcribe(ctx, consultationID, audio, progress)
+	if err == nil && strings.TrimSpace(t.Text) == "" {
+		return Transcript{}, ErrSTTEmpty
+	}
+	return t, err
+}
+
+func (s *service) transcribe(ctx context.Context, consultationID uuid.UUID, audio io.Reader, progress func(STTProgress)) (Transcript, error) {
+	hints := s.sttHints(ctx, consultationID)
+	br := bufio.NewReader(audio)
+	if head, _ := br.Peek(12); len(head) < 12 || string(head[0:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return s.sttClient.Transcribe(ctx, br, hints)
+	}
+
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return Transcript{}, err
+	}
+	w, err := parseWAV(data)
+	if err != nil || w.duration() <= longAudio {
+		return s.sttClient.Transcribe(ctx, bytes.NewReader(data), hints)
+	}
+	text, err := s.transcribeChunks(ctx, w.split(sttChunk, sttChunkOverlap), hints, progress)
+	// The confidences would not survive stitching; long monologues are not clarified
+	return Transcript{Text: text}, err
+}
+
+func (s *service) transcribeChunks(ctx context.Context, chunks [][]byte, hints STTHints, progress func(STTProgress)) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	done := 0
+	report := func() {
+		if progress != nil {
+			progress(STTProgress{Done: done, Total: len(chunks)})
+		}
+	}
+	report()
+
+	texts := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, sttParallelism)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk []byte) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			var t Transcript
+			t, errs[i] = s.sttClient.Transcribe(ctx, bytes.NewReader(chunk), hints)
+			texts[i] = t.Text
+			if errs[i] != nil {
+				cancel()
+				return
+			}
+			mu.Lock()
+			done++
+			report()
+			mu.Unlock()
+		}(i, chunk)
+	}
+	// progress is never called after we return
+	wg.Wait()
+
+	// Report the failure that caused the cancellation, not the cancellations
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return stitch(texts), nil
+}
+
+// stitch joins chunk transcripts, dropping the words the overlap repeats at
+// the start of the next chunk
+func stitch(texts []string) string {
+	var words []string
+	for _, text := range texts {
+		next := strings.Fields(text)
+		words = append(words, next[overlapWords(words, next):]...)
+	}
+	return strings.Join(words, " ")
+}
+
+// overlapWords returns the length of the longest tail of prev that next starts with
+func overlapWords(prev, next []string) int {
+	for k := min(maxOverlapWords, len(prev), len(next)); k > 0; k-- {
+		match := true
+		for i := 0; i < k; i++ {
+			if normalizeWord(prev[len(prev)-k+i]) != normalizeWord(next[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k
+		}
+	}
+	return 0
+}
+
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return unicode.IsPunct(r)
+	}))
+}
+
+// wav is a PCM WAV file: the fmt chunk and the sample data
+type wav struct {
+	format []byte // fmt chunk body
+	data   []byte
+}
+
+func parseWAV(b []byte) (*wav, error) {
+	var w wav
+	for off := 12; off+8 <= len(b); {
+		id := string(b[off : off+4])
+		size := int(binary.LittleEndian.Uint32(b[off+4 : off+8]))
+		body := b[off+8:]
+		if size > len(body) {
+			// Streaming recorders leave the data size unset
+			size = len(body)
+		}
+		switch id {
+		case "fmt ":
+			w.format = body[:size]
+		case "data":
+			w.data = body[:size]
+		}
+		off += 8 + size + size%2
+	}
+	if len(w.format) < 16 || w.data == nil {
+		return nil, errors.New("invalid WAV file")
+	}
+	if binary.LittleEndian.Uint16(w.format[0:2]) != 1 || w.blockAlign() == 0 || w.byteRate() == 0 {
+		return nil, errors.New("WAV is not PCM")
+	}
+	return &w, nil
+}
+
+func (w *wav) byteRate() int   { return int(binary.LittleEndian.Uint32(w.format[8:12])) }
+func (w *wav) blockAlign() int { return int(binary.LittleEndian.Uint16(w.format[12:14])) }
+
+func (w *wav) duration() time.Duration {
+	return time.Duration(len(w.data)) * time.Second / time.Duration(w.byteRate())
+}
+
+// split cuts the samples into standalone WAV files of length chunk, each
+// starting overlap before the end of the previous one
+func (w *wav) split(chunk, overlap time.Duration) [][]byte {
+	size := w.bytes(chunk)
+	step := size - w.bytes(overlap)
+	var chunks [][]byte
+	for start := 0; start < len(w.data); start += step {
+		end := min(start+size, len(w.data))
+		chunks = append(chunks, w.file(w.data[start:end]))
+		if end == len(w.data) {
+			break
+		}
+	}
+	return chunks
+}
+
+// bytes converts a duration to a sample-aligned byte count
+func (w *wav) bytes(d time.Duration) int {
+	n := int(d.Seconds() * float64(w.byteRate()))
+	return n - n%w.blockAlign()
+}
+
+func (w *wav) file(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+len(w.format)+8+len(data)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(w.format)))
+	buf.Write(w.format)
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
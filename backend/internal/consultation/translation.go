@@ -0,0 +1,99 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReportTranslation configures the translation pass for doctors who do not
+// read the language the interview was held in
+type ReportTranslation struct {
+	DoctorLanguage string // ISO 639-1 code; "" turns the pass off
+	Model          string // "" uses each provider's own model
+}
+
+var DefaultReportTranslation = ReportTranslation{DoctorLanguage: "ru"}
+
+// Translation is the doctor-language copy of the report's free text. The
+// report prints each translation under its original.
+type Translation struct {
+	From            string           `json:"from"`
+	To              string           `json:"to"`
+	Facts           []TranslatedText `json:"facts"` // fact descriptions, from the full list and the summary
+	Recommendations string           `json:"recommendations,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+type TranslatedText struct {
+	Original string `json:"original"`
+	Text     string `json:"text"`
+}
+
+// Of returns the translation of a fact description, "" if there is none
+func (t *Translation) Of(original string) string {
+	if t == nil {
+		return ""
+	}
+	for _, f := range t.Facts {
+		if f.Original == original {
+			return f.Text
+		}
+	}
+	return ""
+}
+
+// InterviewLanguage is the kiosk's language, "" when unknown
+func (c *Consultation) InterviewLanguage() string {
+	if c.Device == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(c.Device.Language))
+}
+
+// translateReport refreshes the translation after the recommendations and
+// the fact summary. Without a known interview language, or when it is the
+// doctor's, there is nothing to translate; when the pass fails the report
+// goes out in the original only.
+func (s *service) translateReport(ctx context.Context, c *Consultation) {
+	c.Translation = nil
+	from, to := c.InterviewLanguage(), strings.ToLower(s.translation.DoctorLanguage)
+	if from == "" || to == "" || from == to {
+		return
+	}
+
+	var texts []string
+	seen := map[string]bool{}
+	for _, facts := range [][]MedicalFact{c.ExtractedFacts, c.FactSummary} {
+		for _, f := range facts {
+			if f.Description != "" && !seen[f.Description] {
+				seen[f.Description] = true
+				texts = append(texts, f.Description)
+			}
+		}
+	}
+	if c.Recommendations != "" {
+		texts = append(texts, c.Recommendations)
+	}
+	if len(texts) == 0 {
+		return
+	}
+
+	translated, err := s.aiClient.Translate(ctx, texts, from, to, s.translation.Model)
+	if err != nil {
+		fmt.Printf("Failed to translate the report of consultation %s from %s to %s: %v\n", c.ID, from, to, err)
+		return
+	}
+
+	t := &Translation{From: from, To: to, CreatedAt: time.Now()}
+	n := len(texts)
+	if c.Recommendations != "" {
+		n--
+		t.Recommendations = translated[n]
+	}
+	for i := 0; i < n; i++ {
+		t.Facts = append(t.Facts, TranslatedText{Original: texts[i], Text: translated[i]})
+	}
+	c.Translation = t
+}
@@ -0,0 +1,135 @@
+package consultation
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Limits holds the maximum accepted request body sizes in bytes
+type Limits struct {
+	JSON      int64
+	Audio     int64
+	Wearables int64
+}
+
+var DefaultLimits = Limits{
+	JSON:      1 << 20,   // 1MB
+	Audio:     10 << 20,  // 10MB
+	Wearables: 200 << 20, // 200MB, Apple Health exports are large
+}
+
+type requestError struct {
+	status int
+	msg    string
+	err    error
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// writeRequestError maps upload errors to HTTP responses. Hitting the body limit
+// surfaces as *http.MaxBytesError anywhere down the read path, including STT.
+func writeRequestError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	writeServiceError(w, err.Error(), err)
+}
+
+// writeServiceError responds with the status of the typed error anywhere
+// down the chain, see ErrorStatus
+func writeServiceError(w http.ResponseWriter, msg string, err error) {
+	WriteError(w, msg, err)
+}
+
+// transcribeUpload walks the multipart body part by part instead of buffering it
+// with ParseMultipartForm, streaming the "audio" part straight into STT.
+// Clients should send consultation_id before audio so bad IDs are rejected
+// before transcription starts. progress reports chunked transcription of long audio.
+func (h *Handler) transcribeUpload(r *http.Request, progress func(STTProgress)) (uuid.UUID, Transcript, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return uuid.Nil, Transcript{}, &requestError{http.StatusBadRequest, "Invalid multipart request", nil}
+	}
+
+	var id uuid.UUID
+	var transcript Transcript
+	var recorded *bytes.Buffer
+	idSeen, audioSeen := false, false
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return uuid.Nil, Transcript{}, err
+		}
+
+		switch part.FormName() {
+		case "consultation_id":
+			raw, err := io.ReadAll(io.LimitReader(part, 64))
+			if err != nil {
+				part.Close()
+				return uuid.Nil, Transcript{}, err
+			}
+			id, err = uuid.Parse(strings.TrimSpace(string(raw)))
+			if err != nil {
+				part.Close()
+				return uuid.Nil, Transcript{}, &requestError{http.StatusBadRequest, "Invalid consultation ID", nil}
+			}
+			if err := h.sessions.checkChannel(r, id); err != nil {
+				part.Close()
+				return uuid.Nil, Transcript{}, &requestError{http.StatusForbidden, "Forbidden: " + err.Error(), nil}
+			}
+			idSeen = true
+			h.activity.touch(id)
+		case "audio":
+			// The upload is kept only if the consultation records audio, which
+			// is known when the ID came first
+			var audio io.Reader = part
+			if idSeen && h.svc.RecordingEnabled(id) {
+				recorded = &bytes.Buffer{}
+				audio = io.TeeReader(part, recorded)
+			}
+			transcript, err = h.svc.TranscribeAudio(r.Context(), id, audio, progress)
+			if errors.Is(err, ErrSTTEmpty) {
+				// Silence is answered with an empty reply, not an error
+				transcript, err = Transcript{}, nil
+			}
+			if err != nil {
+				part.Close()
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					return uuid.Nil, Transcript{}, err
+				}
+				return uuid.Nil, Transcript{}, &requestError{http.StatusInternalServerError, "Transcription failed: " + err.Error(), err}
+			}
+			audioSeen = true
+		}
+		part.Close()
+	}
+
+	if !idSeen {
+		return uuid.Nil, Transcript{}, &requestError{http.StatusBadRequest, "Missing consultation_id", nil}
+	}
+	if !audioSeen {
+		return uuid.Nil, Transcript{}, &requestError{http.StatusBadRequest, "Error retrieving audio file", nil}
+	}
+	if recorded != nil && transcript.Text != "" {
+		h.svc.RecordAudio(r.Context(), id, "user", recorded.Bytes())
+	}
+	return id, transcript, nil
+}
@@ -0,0 +1,246 @@
+package consultation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVitals = errors.New("invalid measurement")
+	ErrUnknownTicket = errors.New("no consultation with this ticket")
+)
+
+// VitalKind is a measurement a waiting-room device can send
+type VitalKind string
+
+const (
+	VitalBloodPressure VitalKind = "blood_pressure" // Value is systolic, Diastolic is set
+	VitalPulse         VitalKind = "pulse"
+	VitalTemperature   VitalKind = "temperature"
+	VitalSpO2          VitalKind = "spo2"
+	VitalRespRate      VitalKind = "resp_rate"
+	VitalHeight        VitalKind = "height"
+	VitalWeight        VitalKind = "weight"
+)
+
+// VitalDiastolic names the diastolic pressure in rules; it is stored with blood_pressure
+const VitalDiastolic VitalKind = "diastolic"
+
+// VitalsCategory is the fact category of device measurements
+const VitalsCategory = "Объективные данные"
+
+// vitalSpec is how a kind is shown and the values a working device can report
+type vitalSpec struct {
+	label    string
+	unit     string
+	min, max float64
+}
+
+var vitalSpecs = map[VitalKind]vitalSpec{
+	VitalBloodPressure: {"АД", "мм рт. ст.", 40, 300},
+	VitalPulse:         {"ЧСС", "уд/мин", 20, 300},
+	VitalTemperature:   {"Температура", "°C", 30, 45},
+	VitalSpO2:          {"SpO2", "%", 50, 100},
+	VitalRespRate:      {"ЧДД", "в мин", 4, 80},
+	VitalHeight:        {"Рост", "см", 30, 250},
+	VitalWeight:        {"Вес", "кг", 0.5, 400},
+}
+
+// Measurement is one reading from a connected device
+type Measurement struct {
+	Kind       VitalKind  `json:"kind"`
+	Value      float64    `json:"value"`
+	Diastolic  float64    `json:"diastolic,omitempty"`
+	DeviceID   *uuid.UUID `json:"device_id,omitempty"` // registered device that sent it
+	MeasuredAt time.Time  `json:"measured_at"`
+}
+
+// Validate rejects unknown kinds and values no working device reports
+func (m Measurement) Validate() error {
+	spec, ok := vitalSpecs[m.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidVitals, m.Kind)
+	}
+	if m.Value < spec.min || m.Value > spec.max {
+		return fmt.Errorf("%w: %s %g out of range", ErrInvalidVitals, m.Kind, m.Value)
+	}
+	if m.Kind == VitalBloodPressure {
+		if m.Diastolic < 20 || m.Diastolic >= m.Value {
+			return fmt.Errorf("%w: diastolic %g does not fit systolic %g", ErrInvalidVitals, m.Diastolic, m.Value)
+		}
+	} else if m.Diastolic != 0 {
+		return fmt.Errorf("%w: diastolic is only for blood_pressure", ErrInvalidVitals)
+	}
+	return nil
+}
+
+// Label is the reading as the doctor reads it, e.g. "АД 150/95 мм рт. ст."
+func (m Measurement) Label() string {
+	return m.Name() + " " + m.Reading()
+}
+
+// Name is the measured parameter, e.g. "АД"
+func (m Measurement) Name() string {
+	return vitalSpecs[m.Kind].label
+}
+
+// Reading is the value with its unit, e.g. "150/95 мм рт. ст."
+func (m Measurement) Reading() string {
+	value := formatVital(m.Value)
+	if m.Kind == VitalBloodPressure {
+		value += "/" + formatVital(m.Diastolic)
+	}
+	return value + " " + vitalSpecs[m.Kind].unit
+}
+
+func formatVital(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+}
+
+// LatestVital returns the most recent value of a kind; VitalDiastolic reads
+// the diastolic pressure
+func (c Consultation) LatestVital(kind VitalKind) (float64, bool) {
+	source := kind
+	if kind == VitalDiastolic {
+		source = VitalBloodPressure
+	}
+	var latest *Measurement
+	for i, m := range c.Vitals {
+		if m.Kind == source && (latest == nil || !m.MeasuredAt.Before(latest.MeasuredAt)) {
+			latest = &c.Vitals[i]
+		}
+	}
+	if latest == nil {
+		return 0, false
+	}
+	if kind == VitalDiastolic {
+		return latest.Diastolic, true
+	}
+	return latest.Value, true
+}
+
+// RecordVitals stores device measurements for a consultation and adds them to
+// the facts, so rules see them and red flags reach the nurse station.
+func (s *service) RecordVitals(ctx context.Context, consultationID uuid.UUID, measurements []Measurement) (*Consultation, error) {
+	if len(measurements) == 0 {
+		return nil, fmt.Errorf("%w: no measurements", ErrInvalidVitals)
+	}
+	device, _ := DeviceFromContext(ctx)
+	now := time.Now()
+	for i := range measurements {
+		if err := measurements[i].Validate(); err != nil {
+			return nil, err
+		}
+		if measurements[i].MeasuredAt.IsZero() || measurements[i].MeasuredAt.After(now) {
+			measurements[i].MeasuredAt = now
+		}
+		if device != nil {
+			measurements[i].DeviceID = &device.ID
+		}
+	}
+
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range measurements {
+		if err := s.repo.AddVital(ctx, c.ID, m); err != nil {
+			return nil, err
+		}
+		c.Vitals = append(c.Vitals, m)
+		c.ExtractedFacts = append(c.ExtractedFacts, MedicalFact{
+			Category: VitalsCategory, Description: m.Label() + " (измерено прибором)", Confidence: "High",
+		})
+	}
+	c.RuleFindings = s.rules.Evaluate(*c)
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ConsultationByTicket finds today's consultation with the ticket shown to the patient
+func (s *service) ConsultationByTicket(ctx context.Context, ticket string) (uuid.UUID, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(ticket))
+	if err != nil || n < 0 || n > 999 {
+		return uuid.Nil, fmt.Errorf("%w: %q", ErrUnknownTicket, ticket)
+	}
+	id, err := s.repo.FindByTicket(ctx, n, time.Now().Add(-boardWindow))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("%w: %q", ErrUnknownTicket, ticket)
+	}
+	return id, nil
+}
+
+// VitalsRequest is a batch of readings for one consultation, named by its ID
+// or by the ticket the patient shows at the device
+type VitalsRequest struct {
+	ConsultationID *uuid.UUID    `json:"consultation_id,omitempty"`
+	Ticket         string        `json:"ticket,omitempty"`
+	Measurements   []Measurement `json:"measurements"`
+}
+
+type VitalsResponse struct {
+	ConsultationID uuid.UUID     `json:"consultation_id"`
+	Vitals         []Measurement `json:"vitals"`
+	RuleFindings   []RuleFinding `json:"rule_findings"`
+}
+
+// RecordVitals takes readings from a registered waiting-room device. Only
+// requests with a device key are accepted.
+func (h *Handler) RecordVitals(w http.ResponseWriter, r *http.Request) {
+	if _, ok := DeviceFromContext(r.Context()); !ok {
+		http.Error(w, "Device key required", http.StatusUnauthorized)
+		return
+	}
+
+	var req VitalsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var id uuid.UUID
+	switch {
+	case req.ConsultationID != nil:
+		id = *req.ConsultationID
+	case req.Ticket != "":
+		var err error
+		if id, err = h.svc.ConsultationByTicket(r.Context(), req.Ticket); err != nil {
+			if errors.Is(err, ErrUnknownTicket) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeServiceError(w, "Failed to find consultation", err)
+			return
+		}
+	default:
+		http.Error(w, "consultation_id or ticket is required", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.RecordVitals(r.Context(), id, req.Measurements)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVitals) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeServiceError(w, "Failed to record measurements", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(VitalsResponse{ConsultationID: c.ID, Vitals: c.Vitals, RuleFindings: c.RuleFindings})
+}
@@ -0,0 +1,416 @@
+package consultation
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidWearables = errors.New("invalid wearable data")
+
+// Wearable export formats
+const (
+	WearableAppleHealth = "apple_health" // export.xml, or the export.zip the Health app shares
+	WearableGoogleFit   = "google_fit"   // Fitness API dataset or aggregate response
+)
+
+// WearablesCategory is the fact category of signals from the patient's devices
+const WearablesCategory = "Носимые устройства"
+
+// Only the recent past matters for the visit, and exports span years
+const wearableWindow = 30 * 24 * time.Hour
+
+// WearableSignals is what an export says about the last 30 days. Values
+// that were not in the export are left zero.
+type WearableSignals struct {
+	Source     string      `json:"source"`
+	From       time.Time   `json:"from"`
+	To         time.Time   `json:"to"`
+	HeartRate  *Range      `json:"heart_rate,omitempty"` // bpm
+	Resting    *Trend      `json:"resting_heart_rate,omitempty"`
+	SpO2Min    float64     `json:"spo2_min,omitempty"`    // %
+	DailySteps int         `json:"daily_steps,omitempty"` // average over days with data
+	Falls      []time.Time `json:"falls,omitempty"`
+	ImportedAt time.Time   `json:"imported_at"`
+}
+
+type Range struct {
+	Avg     float64 `json:"avg"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+// Trend compares the first and the last week of the window
+type Trend struct {
+	FirstWeek float64 `json:"first_week"`
+	LastWeek  float64 `json:"last_week"`
+}
+
+// Lines describes the signals for the agent and as fallback facts
+func (s WearableSignals) Lines() []string {
+	var lines []string
+	if hr := s.HeartRate; hr != nil {
+		lines = append(lines, fmt.Sprintf("Пульс: в среднем %.0f, от %.0f до %.0f уд/мин (измерений: %d)", hr.Avg, hr.Min, hr.Max, hr.Samples))
+	}
+	if t := s.Resting; t != nil {
+		lines = append(lines, fmt.Sprintf("Пульс покоя: %.0f уд/мин в первую неделю, %.0f в последнюю", t.FirstWeek, t.LastWeek))
+	}
+	if s.SpO2Min > 0 {
+		lines = append(lines, fmt.Sprintf("Минимальная сатурация: %.0f %%", s.SpO2Min))
+	}
+	if s.DailySteps > 0 {
+		lines = append(lines, fmt.Sprintf("Шагов в день в среднем: %d", s.DailySteps))
+	}
+	if len(s.Falls) > 0 {
+		dates := make([]string, len(s.Falls))
+		for i, f := range s.Falls {
+			dates[i] = f.Format("02.01.2006")
+		}
+		lines = append(lines, fmt.Sprintf("Зарегистрированные падения: %d (%s)", len(s.Falls), strings.Join(dates, ", ")))
+	}
+	return lines
+}
+
+type wearableSample struct {
+	kind  string // heart_rate, resting_heart_rate, spo2, steps, fall
+	value float64
+	at    time.Time
+}
+
+// ParseWearables reads an export in the given format, or guesses it from the
+// content when format is empty
+func ParseWearables(format string, data []byte, now time.Time) (*WearableSignals, error) {
+	if format == "" {
+		switch {
+		case bytes.HasPrefix(data, []byte("PK")), bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")):
+			format = WearableAppleHealth
+		default:
+			format = WearableGoogleFit
+		}
+	}
+
+	var samples []wearableSample
+	var err error
+	switch format {
+	case WearableAppleHealth:
+		samples, err = parseAppleHealth(data)
+	case WearableGoogleFit:
+		samples, err = parseGoogleFit(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidWearables, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := summarizeSamples(samples, now)
+	s.Source = format
+	if s.HeartRate == nil && s.SpO2Min == 0 && s.DailySteps == 0 && len(s.Falls) == 0 {
+		return nil, fmt.Errorf("%w: no heart rate, oxygen, step or fall data in the last 30 days", ErrInvalidWearables)
+	}
+	return s, nil
+}
+
+var appleHealthKinds = map[string]string{
+	"HKQuantityTypeIdentifierHeartRate":           "heart_rate",
+	"HKQuantityTypeIdentifierRestingHeartRate":    "resting_heart_rate",
+	"HKQuantityTypeIdentifierOxygenSaturation":    "spo2",
+	"HKQuantityTypeIdentifierStepCount":           "steps",
+	"HKQuantityTypeIdentifierNumberOfTimesFallen": "fall",
+}
+
+// parseAppleHealth walks the export token by token, since export.xml often
+// runs to hundreds of megabytes of records
+func parseAppleHealth(data []byte) ([]wearableSample, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte("PK")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWearables, err)
+		}
+		var export *zip.File
+		for _, f := range zr.File {
+			if path.Base(f.Name) == "export.xml" {
+				export = f
+			}
+		}
+		if export == nil {
+			return nil, fmt.Errorf("%w: export.xml not found in archive", ErrInvalidWearables)
+		}
+		rc, err := export.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWearables, err)
+		}
+		defer rc.Close()
+		r = rc
+	}
+
+	var samples []wearableSample
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWearables, err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok || el.Name.Local != "Record" {
+			continue
+		}
+		var typ, value, start string
+		for _, a := range el.Attr {
+			switch a.Name.Local {
+			case "type":
+				typ = a.Value
+			case "value":
+				value = a.Value
+			case "startDate":
+				start = a.Value
+			}
+		}
+		kind, ok := appleHealthKinds[typ]
+		if !ok {
+			continue
+		}
+		at, err := time.Parse("2006-01-02 15:04:05 -0700", start)
+		if err != nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		if kind == "spo2" && v <= 1 {
+			v *= 100 // HealthKit stores a fraction
+		}
+		samples = append(samples, wearableSample{kind: kind, value: v, at: at})
+	}
+	return samples, nil
+}
+
+var googleFitKinds = map[string]string{
+	"com.google.heart_rate.bpm":        "heart_rate",
+	"com.google.heart_rate.summary":    "heart_rate",
+	"com.google.oxygen_saturation":     "spo2",
+	"com.google.step_count.delta":      "steps",
+	"com.google.step_count.cumulative": "steps",
+}
+
+type googleFitPoint struct {
+	DataTypeName   string `json:"dataTypeName"`
+	StartTimeNanos string `json:"startTimeNanos"`
+	Value          []struct {
+		FpVal  *float64 `json:"fpVal"`
+		IntVal *int64   `json:"intVal"`
+	} `json:"value"`
+}
+
+// parseGoogleFit accepts a dataset ({"point": [...]}) or an aggregate
+// response ({"bucket": [{"dataset": [...]}]})
+func parseGoogleFit(data []byte) ([]wearableSample, error) {
+	var body struct {
+		Point  []googleFitPoint `json:"point"`
+		Bucket []struct {
+			Dataset []struct {
+				Point []googleFitPoint `json:"point"`
+			} `json:"dataset"`
+		} `json:"bucket"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWearables, err)
+	}
+	points := body.Point
+	for _, b := range body.Bucket {
+		for _, d := range b.Dataset {
+			points = append(points, d.Point...)
+		}
+	}
+
+	var samples []wearableSample
+	for _, p := range points {
+		kind, ok := googleFitKinds[p.DataTypeName]
+		if !ok || len(p.Value) == 0 {
+			continue
+		}
+		nanos, err := strconv.ParseInt(p.StartTimeNanos, 10, 64)
+		if err != nil {
+			continue
+		}
+		var v float64
+		switch {
+		case p.Value[0].FpVal != nil:
+			v = *p.Value[0].FpVal
+		case p.Value[0].IntVal != nil:
+			v = float64(*p.Value[0].IntVal)
+		default:
+			continue
+		}
+		samples = append(samples, wearableSample{kind: kind, value: v, at: time.Unix(0, nanos)})
+	}
+	return samples, nil
+}
+
+func summarizeSamples(samples []wearableSample, now time.Time) *WearableSignals {
+	s := &WearableSignals{From: now.Add(-wearableWindow), To: now, ImportedAt: now}
+	var hr, resting []wearableSample
+	steps := make(map[string]float64)
+	for _, sm := range samples {
+		if sm.at.Before(s.From) || sm.at.After(now) {
+			continue
+		}
+		switch sm.kind {
+		case "heart_rate":
+			hr = append(hr, sm)
+		case "resting_heart_rate":
+			resting = append(resting, sm)
+		case "spo2":
+			if s.SpO2Min == 0 || sm.value < s.SpO2Min {
+				s.SpO2Min = sm.value
+			}
+		case "steps":
+			steps[sm.at.Format("2006-01-02")] += sm.value
+		case "fall":
+			s.Falls = append(s.Falls, sm.at)
+		}
+	}
+
+	if len(hr) > 0 {
+		r := &Range{Min: math.Inf(1), Max: math.Inf(-1), Samples: len(hr)}
+		sum := 0.0
+		for _, sm := range hr {
+			sum += sm.value
+			r.Min = math.Min(r.Min, sm.value)
+			r.Max = math.Max(r.Max, sm.value)
+		}
+		r.Avg = sum / float64(len(hr))
+		s.HeartRate = r
+	}
+	if len(resting) > 0 {
+		sort.Slice(resting, func(i, j int) bool { return resting[i].at.Before(resting[j].at) })
+		first, last := resting[0].at, resting[len(resting)-1].at
+		if last.Sub(first) >= 14*24*time.Hour {
+			s.Resting = &Trend{
+				FirstWeek: meanWithin(resting, first, first.Add(7*24*time.Hour)),
+				LastWeek:  meanWithin(resting, last.Add(-7*24*time.Hour), last.Add(time.Second)),
+			}
+		}
+	}
+	if len(steps) > 0 {
+		total := 0.0
+		for _, v := range steps {
+			total += v
+		}
+		s.DailySteps = int(total / float64(len(steps)))
+	}
+	sort.Slice(s.Falls, func(i, j int) bool { return s.Falls[i].Before(s.Falls[j]) })
+	return s
+}
+
+func meanWithin(samples []wearableSample, from, to time.Time) float64 {
+	sum, n := 0.0, 0
+	for _, sm := range samples {
+		if !sm.at.Before(from) && sm.at.Before(to) {
+			sum += sm.value
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// ImportWearables attaches the signals to the consultation. An agent picks
+// what matters for the complaint and phrases it as facts for the doctor; if
+// it fails every signal is added as is. A new import replaces the previous one.
+func (s *service) ImportWearables(ctx context.Context, consultationID uuid.UUID, signals *WearableSignals) (*Consultation, error) {
+	c, err := s.repo.GetByID(ctx, consultationID)
+	if err != nil {
+		return nil, err
+	}
+
+	facts, err := s.aiClient.SummarizeWearables(ctx, signals.Lines(), c.ExtractedFacts)
+	if err != nil || len(facts) == 0 {
+		fmt.Printf("Wearable summary failed for consultation %s, adding raw signals: %v\n", c.ID, err)
+		facts = nil
+		for _, line := range signals.Lines() {
+			facts = append(facts, MedicalFact{Description: line, Confidence: "Medium"})
+		}
+	}
+
+	s.normalize(&AnalysisResult{Facts: facts})
+	kept := c.ExtractedFacts[:0:0]
+	for _, f := range c.ExtractedFacts {
+		if f.Category != WearablesCategory {
+			kept = append(kept, f)
+		}
+	}
+	for _, f := range facts {
+		f.Category = WearablesCategory
+		kept = append(kept, f)
+	}
+	c.ExtractedFacts = kept
+	c.Wearables = signals
+	c.RuleFindings = s.rules.Evaluate(*c)
+
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ImportWearables accepts an Apple Health export (export.xml or export.zip)
+// or a Google Fit API response as the request body. ?format= names the
+// format; without it the content decides.
+func (h *Handler) ImportWearables(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(chi.URLParam(r, "id"))
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Export too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	signals, err := ParseWearables(r.URL.Query().Get("format"), data, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.svc.ImportWearables(r.Context(), id, signals)
+	if err != nil {
+		writeServiceError(w, "Failed to import wearable data", err)
+		return
+	}
+
+	var facts []MedicalFact
+	for _, f := range c.ExtractedFacts {
+		if f.Category == WearablesCategory {
+			facts = append(facts, f)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(WearablesResponse{Signals: *c.Wearables, Facts: facts})
+}
+
+type WearablesResponse struct {
+	Signals WearableSignals `json:"signals"`
+	Facts   []MedicalFact   `json:"facts"` // what the doctor will see
+}
@@ -0,0 +1,130 @@
+package devices
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type RegisterDeviceResponse struct {
+	Device *Device `json:"device"`
+	Key    string  `json:"key"` // shown only once, sent by the kiosk in X-Device-Key
+}
+
+func (h *Handler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.svc.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list devices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(devices)
+}
+
+func (h *Handler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	var d Device
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.svc.Register(r.Context(), &d)
+	if err != nil {
+		if errors.Is(err, ErrInvalidDevice) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to register device: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RegisterDeviceResponse{Device: &d, Key: key})
+}
+
+// UpdateDevice replaces the kiosk's configuration. Consultations already
+// started keep the placement they were stamped with.
+func (h *Handler) UpdateDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid device ID", http.StatusBadRequest)
+		return
+	}
+
+	var d Device
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	d.ID = id
+
+	if err := h.svc.Update(r.Context(), &d); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "Device not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidDevice) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to update device: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(d)
+}
+
+func (h *Handler) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid device ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete device: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes mounts kiosk management. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermManageDevices))
+		r.Get("/devices", h.ListDevices)
+		r.Post("/devices", h.RegisterDevice)
+		r.Put("/devices/{id}", h.UpdateDevice)
+		r.Delete("/devices/{id}", h.DeleteDevice)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/devices", Summary: "Registered kiosks (manage_devices)", Tags: tags,
+			Response: []Device{}},
+		{Method: http.MethodPost, Path: "/admin/devices", Summary: "Register a kiosk; its device key is shown only once (manage_devices)", Tags: tags,
+			Request: Device{}, Response: RegisterDeviceResponse{}},
+		{Method: http.MethodPut, Path: "/admin/devices/{id}", Summary: "Change the location and settings of a kiosk (manage_devices)", Tags: tags,
+			Request: Device{}, Response: Device{}},
+		{Method: http.MethodDelete, Path: "/admin/devices/{id}", Summary: "Remove a kiosk and revoke its key (manage_devices)", Tags: tags},
+	}
+}
@@ -0,0 +1,35 @@
+package devices
+
+import (
+	"net/http"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// KeyHeader carries the kiosk's device key
+const KeyHeader = "X-Device-Key"
+
+// Identify attaches the kiosk named by the device key to the request. Requests
+// without a key go to fallback, e.g. kiosk-role token checks, or straight
+// through when fallback is nil. A wrong key is always rejected.
+func Identify(svc *Service, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withoutKey := next
+		if fallback != nil {
+			withoutKey = fallback(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(KeyHeader)
+			if key == "" {
+				withoutKey.ServeHTTP(w, r)
+				return
+			}
+			d, err := svc.Authenticate(r.Context(), key)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(consultation.WithDevice(r.Context(), &d.Device)))
+		})
+	}
+}
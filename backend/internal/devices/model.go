@@ -0,0 +1,62 @@
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+var (
+	ErrNotFound      = errors.New("device not found")
+	ErrInvalidDevice = errors.New("invalid device")
+)
+
+var languages = []string{"ru", "en", "kk", "uz", "ky", "tg"}
+
+// Device is a registered kiosk and its configuration
+type Device struct {
+	consultation.Device
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate trims the settings and checks them against what the kiosk and
+// the TTS service support. A persona alias is replaced by its speaker.
+func (d *Device) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	d.Location = strings.TrimSpace(d.Location)
+	d.Room = strings.TrimSpace(d.Room)
+	d.Building = strings.TrimSpace(d.Building)
+	d.Floor = strings.TrimSpace(d.Floor)
+	d.Department = strings.TrimSpace(d.Department)
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidDevice)
+	}
+	if d.Persona != "" {
+		speaker, err := consultation.ResolveVoice(d.Persona)
+		if err != nil {
+			return fmt.Errorf("%w: unknown persona %q", ErrInvalidDevice, d.Persona)
+		}
+		d.Persona = speaker
+	}
+	if d.Language != "" && !validLanguage(d.Language) {
+		return fmt.Errorf("%w: unsupported language %q", ErrInvalidDevice, d.Language)
+	}
+	if d.Volume != 0 {
+		if err := (&consultation.Pacing{Volume: d.Volume}).Validate(); err != nil {
+			return fmt.Errorf("%w: volume out of range", ErrInvalidDevice)
+		}
+	}
+	return nil
+}
+
+func validLanguage(lang string) bool {
+	for _, l := range languages {
+		if lang == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package devices
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, d *Device, keyHash string) error
+	List(ctx context.Context) ([]Device, error)
+	Update(ctx context.Context, d *Device) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByKeyHash(ctx context.Context, keyHash string) (*Device, error)
+}
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+const deviceColumns = `id, name, location, room, building, floor, department, persona, language, volume, created_at`
+
+func scanDevice(row interface{ Scan(...any) error }) (*Device, error) {
+	var d Device
+	err := row.Scan(&d.ID, &d.Name, &d.Location, &d.Room, &d.Building, &d.Floor, &d.Department, &d.Persona, &d.Language, &d.Volume, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *postgresRepo) Create(ctx context.Context, d *Device, keyHash string) error {
+	query := `INSERT INTO devices (id, name, location, room, building, floor, department, persona, language, volume, key_hash, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := r.db.ExecContext(ctx, query, d.ID, d.Name, d.Location, d.Room, d.Building, d.Floor, d.Department, d.Persona, d.Language, d.Volume, keyHash, d.CreatedAt)
+	return err
+}
+
+func (r *postgresRepo) List(ctx context.Context) ([]Device, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+deviceColumns+` FROM devices ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []Device{}
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *d)
+	}
+	return devices, rows.Err()
+}
+
+// Update replaces the configuration; the key stays the same
+func (r *postgresRepo) Update(ctx context.Context, d *Device) error {
+	query := `UPDATE devices SET name = $2, location = $3, room = $4, building = $5, floor = $6, department = $7, persona = $8, language = $9, volume = $10 WHERE id = $1 RETURNING created_at`
+	err := r.db.QueryRowContext(ctx, query, d.ID, d.Name, d.Location, d.Room, d.Building, d.Floor, d.Department, d.Persona, d.Language, d.Volume).Scan(&d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *postgresRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM devices WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresRepo) GetByKeyHash(ctx context.Context, keyHash string) (*Device, error) {
+	d, err := scanDevice(r.db.QueryRowContext(ctx, `SELECT `+deviceColumns+` FROM devices WHERE key_hash = $1`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
@@ -0,0 +1,69 @@
+package devices
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Register stores a new kiosk and returns its device key. Only a hash of the
+// key is persisted, so it cannot be shown again.
+func (s *Service) Register(ctx context.Context, d *Device) (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(raw)
+
+	d.ID = uuid.New()
+	d.CreatedAt = time.Now()
+	if err := s.repo.Create(ctx, d, hashKey(key)); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]Device, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Update(ctx context.Context, d *Device) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, d)
+}
+
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Authenticate resolves a device key to its kiosk
+func (s *Service) Authenticate(ctx context.Context, key string) (*Device, error) {
+	if key == "" {
+		return nil, fmt.Errorf("missing device key")
+	}
+	return s.repo.GetByKeyHash(ctx, hashKey(key))
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
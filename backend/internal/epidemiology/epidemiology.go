@@ -0,0 +1,96 @@
+package epidemiology
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+// Triggers select the chief complaints that require the screening block
+type Triggers struct {
+	Codes    []string `json:"codes"`    // controlled vocabulary codes of facts
+	Keywords []string `json:"keywords"` // keywords in fact descriptions
+}
+
+// Config is the screening block definition
+type Config struct {
+	Triggers Triggers                 `json:"triggers"`
+	Topics   []consultation.EpidTopic `json:"topics"`
+}
+
+// Parse reads a screening config written in YAML
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yamlconf.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid screening config: %w", err)
+	}
+	for _, t := range cfg.Topics {
+		if t.ID == "" || t.Category == "" || t.Question == "" {
+			return nil, fmt.Errorf("screening topic %q: id, category and question are required", t.ID)
+		}
+	}
+	return &cfg, nil
+}
+
+// Default returns the built-in screening block
+func Default() *Config {
+	cfg, err := Parse(defaultConfig)
+	if err != nil {
+		panic(fmt.Sprintf("built-in screening config: %v", err))
+	}
+	return cfg
+}
+
+// Load reads the config from path, falling back to the built-in block when path is empty
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+type Screener struct {
+	cfg *Config
+}
+
+func NewScreener(cfg *Config) *Screener {
+	return &Screener{cfg: cfg}
+}
+
+// Required returns the topics the interview must cover, or nil when no fact matches a trigger
+func (s *Screener) Required(c consultation.Consultation) []consultation.EpidTopic {
+	for _, f := range c.ExtractedFacts {
+		if s.triggeredBy(f) {
+			return s.cfg.Topics
+		}
+	}
+	return nil
+}
+
+func (s *Screener) triggeredBy(f consultation.MedicalFact) bool {
+	if f.Code != nil {
+		for _, code := range s.cfg.Triggers.Codes {
+			if f.Code.Code == code {
+				return true
+			}
+		}
+	}
+	desc := strings.ToLower(f.Description)
+	for _, k := range s.cfg.Triggers.Keywords {
+		if strings.Contains(desc, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
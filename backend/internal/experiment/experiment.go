@@ -0,0 +1,144 @@
+// Package experiment splits consultations between Communicator variants
+// (model, temperature, extra prompt instructions) so they can be compared on
+// outcome metrics in the admin stats.
+package experiment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+// Arm is one variant. Empty fields keep the Communicator defaults, so a
+// control arm only needs a name and a weight.
+type Arm struct {
+	Name        string  `json:"name"`
+	Weight      int     `json:"weight"`
+	Model       string  `json:"model,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+	Prompt      string  `json:"prompt,omitempty"` // appended to the Communicator system prompt
+}
+
+// Experiment enrolls Percent of consultations and splits them between its arms by weight
+type Experiment struct {
+	Name    string `json:"name"`
+	Percent int    `json:"percent"`
+	Arms    []Arm  `json:"arms"`
+}
+
+type file struct {
+	Experiments []Experiment `json:"experiments"`
+}
+
+// Parse reads an experiments file written in YAML
+func Parse(data []byte) ([]Experiment, error) {
+	var f file
+	if err := yamlconf.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid experiments file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range f.Experiments {
+		if e.Name == "" {
+			return nil, fmt.Errorf("experiment: name is required")
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("experiment %s: duplicate name", e.Name)
+		}
+		seen[e.Name] = true
+		if e.Percent < 0 || e.Percent > 100 {
+			return nil, fmt.Errorf("experiment %s: percent must be 0-100", e.Name)
+		}
+		if len(e.Arms) < 2 {
+			return nil, fmt.Errorf("experiment %s: at least two arms are required", e.Name)
+		}
+		arms := make(map[string]bool)
+		for _, a := range e.Arms {
+			if a.Name == "" || a.Weight <= 0 {
+				return nil, fmt.Errorf("experiment %s: every arm needs a name and a positive weight", e.Name)
+			}
+			if arms[a.Name] {
+				return nil, fmt.Errorf("experiment %s: duplicate arm %s", e.Name, a.Name)
+			}
+			arms[a.Name] = true
+		}
+	}
+	return f.Experiments, nil
+}
+
+// Load reads experiments from path; an empty path means no experiments
+func Load(path string) ([]Experiment, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Splitter assigns consultations to arms
+type Splitter struct {
+	experiments []Experiment
+}
+
+func NewSplitter(experiments []Experiment) *Splitter {
+	return &Splitter{experiments: experiments}
+}
+
+// Assign puts a consultation into at most one experiment: the first one that
+// enrolls it. Assignment is a hash of the consultation ID, so it is stable and
+// needs no shared state.
+func (s *Splitter) Assign(consultationID uuid.UUID) (experiment, arm string) {
+	for _, e := range s.experiments {
+		if bucket(e.Name, "enroll", consultationID, 100) >= e.Percent {
+			continue
+		}
+		total := 0
+		for _, a := range e.Arms {
+			total += a.Weight
+		}
+		n := bucket(e.Name, "arm", consultationID, total)
+		for _, a := range e.Arms {
+			if n < a.Weight {
+				return e.Name, a.Name
+			}
+			n -= a.Weight
+		}
+	}
+	return "", ""
+}
+
+// Variant returns the Communicator settings of an arm. Consultations of an
+// experiment that was removed from the file fall back to the defaults.
+func (s *Splitter) Variant(experiment, arm string) (consultation.Variant, bool) {
+	for _, e := range s.experiments {
+		if e.Name != experiment {
+			continue
+		}
+		for _, a := range e.Arms {
+			if a.Name == arm {
+				return consultation.Variant{Model: a.Model, Temperature: a.Temperature, Prompt: a.Prompt}, true
+			}
+		}
+	}
+	return consultation.Variant{}, false
+}
+
+// Experiments returns the configured experiments, for diagnostics
+func (s *Splitter) Experiments() []Experiment {
+	return s.experiments
+}
+
+func bucket(name, salt string, id uuid.UUID, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + "/" + salt))
+	h.Write(id[:])
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,202 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Flag names a risky feature that can be rolled out gradually
+type Flag string
+
+const (
+	NurseReview        Flag = "nurse_review"        // reports wait for nurse approval
+	SessionRecording   Flag = "session_recording"   // patient and assistant audio is kept for review
+	FactRecap          Flag = "fact_recap"          // key facts are read back for confirmation before completion
+	PatientCertificate Flag = "patient_certificate" // the patient can download a summary of the completed interview
+	CommunicatorTools  Flag = "communicator_tools"  // the Communicator acts through tool calls instead of text markers
+)
+
+// Rule enables a flag for a tenant (empty = all tenants) for a percentage of consultations
+type Rule struct {
+	Flag    Flag   `json:"flag"`
+	Tenant  string `json:"tenant,omitempty"`
+	Percent int    `json:"percent"` // 0 = off, 100 = on
+}
+
+// Store loads persisted rules (e.g. from the database)
+type Store interface {
+	LoadRules(ctx context.Context) ([]Rule, error)
+}
+
+type Service struct {
+	tenant   string
+	store    Store
+	envRules []Rule
+
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// NewService creates the flag evaluator for this deployment's tenant.
+// Rules from the environment take precedence over stored ones.
+func NewService(tenant string, store Store, envRules []Rule) *Service {
+	s := &Service{tenant: tenant, store: store, envRules: envRules}
+	s.rules = merge(nil, envRules)
+	return s
+}
+
+// Refresh reloads rules from the store
+func (s *Service) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	stored, err := s.store.LoadRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.rules = merge(stored, s.envRules)
+	s.mu.Unlock()
+	return nil
+}
+
+// StartRefresh keeps the rules in sync with the store until ctx is cancelled
+func (s *Service) StartRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					log.Printf("Feature flag refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Enabled evaluates a flag for a consultation of this deployment's tenant
+func (s *Service) Enabled(flag Flag, consultationID uuid.UUID) bool {
+	return s.EnabledFor(flag, s.tenant, consultationID)
+}
+
+// EnabledFor evaluates a flag for a specific tenant. A tenant-specific rule
+// overrides the global one; partial rollouts bucket consultations by ID so a
+// consultation sees the same value for its whole lifetime.
+func (s *Service) EnabledFor(flag Flag, tenant string, consultationID uuid.UUID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	percent, tenantPercent := 0, -1
+	for _, r := range s.rules {
+		if r.Flag != flag {
+			continue
+		}
+		if r.Tenant == "" {
+			percent = r.Percent
+		} else if r.Tenant == tenant {
+			tenantPercent = r.Percent
+		}
+	}
+	if tenantPercent >= 0 {
+		percent = tenantPercent
+	}
+
+	switch {
+	case percent <= 0:
+		return false
+	case percent >= 100:
+		return true
+	default:
+		return bucket(flag, consultationID) < percent
+	}
+}
+
+// Rules returns the effective rules, for diagnostics
+func (s *Service) Rules() []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Rule(nil), s.rules...)
+}
+
+func bucket(flag Flag, id uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write(id[:])
+	return int(h.Sum32() % 100)
+}
+
+// merge overlays rules on base, replacing entries with the same flag and tenant
+func merge(base, overlay []Rule) []Rule {
+	byKey := map[string]Rule{}
+	for _, r := range base {
+		byKey[r.Tenant+"/"+string(r.Flag)] = r
+	}
+	for _, r := range overlay {
+		byKey[r.Tenant+"/"+string(r.Flag)] = r
+	}
+
+	rules := make([]Rule, 0, len(byKey))
+	for _, r := range byKey {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Flag != rules[j].Flag {
+			return rules[i].Flag < rules[j].Flag
+		}
+		return rules[i].Tenant < rules[j].Tenant
+	})
+	return rules
+}
+
+// ParseEnv parses rules in the form "flag=on,flag=25,tenant/flag=off".
+// Values are on/off/true/false or a rollout percentage.
+func ParseEnv(value string) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag %q: expected name=value", item)
+		}
+
+		var r Rule
+		if tenant, name, hasTenant := strings.Cut(key, "/"); hasTenant {
+			r.Tenant, r.Flag = strings.TrimSpace(tenant), Flag(strings.TrimSpace(name))
+		} else {
+			r.Flag = Flag(strings.TrimSpace(key))
+		}
+
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "on", "true":
+			r.Percent = 100
+		case "off", "false":
+			r.Percent = 0
+		default:
+			p, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(val), "%"))
+			if err != nil || p < 0 || p > 100 {
+				return nil, fmt.Errorf("invalid feature flag value %q for %s", val, key)
+			}
+			r.Percent = p
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
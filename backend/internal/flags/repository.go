@@ -0,0 +1,34 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) LoadRules(ctx context.Context) ([]Rule, error) {
+	query := `SELECT flag, tenant, percent FROM feature_flags`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.Flag, &r.Tenant, &r.Percent); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
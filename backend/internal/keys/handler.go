@@ -0,0 +1,77 @@
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ErasePatient destroys the patient's data key. It cannot be undone.
+func (h *Handler) ErasePatient(w http.ResponseWriter, r *http.Request) {
+	patientID, err := uuid.Parse(chi.URLParam(r, "patientID"))
+	if err != nil {
+		http.Error(w, "Invalid patient ID", http.StatusBadRequest)
+		return
+	}
+
+	erasedBy := ""
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		erasedBy = u.Name
+	}
+	e, err := h.svc.Erase(r.Context(), patientID, erasedBy)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "No data key for this patient", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erase failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(e)
+}
+
+func (h *Handler) ListErasures(w http.ResponseWriter, r *http.Request) {
+	erasures, err := h.svc.Erasures(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list erasures: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(erasures)
+}
+
+// RegisterRoutes mounts cryptographic erasure. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermPurge))
+		r.Get("/erasures", h.ListErasures)
+		r.Post("/patients/{patientID}/erase", h.ErasePatient)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// They are only mounted when ENCRYPTION_MASTER_KEY is set.
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/erasures", Summary: "Patients whose data keys were destroyed (purge, only with encryption on)", Tags: tags,
+			Response: []Erasure{}},
+		{Method: http.MethodPost, Path: "/admin/patients/{patientID}/erase", Summary: "Destroy the patient's data key; it cannot be undone (purge, only with encryption on)", Tags: tags,
+			Response: Erasure{}},
+	}
+}
@@ -0,0 +1,60 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MasterKey wraps and unwraps the per-patient data keys. In production it is
+// backed by a KMS; LocalMasterKey holds the key in process memory.
+type MasterKey interface {
+	ID() string // identifies the master key a data key was wrapped with
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+type localMasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalMasterKey uses a 32-byte key, e.g. from ENCRYPTION_MASTER_KEY
+func NewLocalMasterKey(key []byte) (MasterKey, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &localMasterKey{id: "local:" + hex.EncodeToString(sum[:6]), aead: aead}, nil
+}
+
+func (k *localMasterKey) ID() string {
+	return k.id
+}
+
+func (k *localMasterKey) Wrap(dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.aead.Seal(nonce, nonce, dataKey, []byte(k.id)), nil
+}
+
+func (k *localMasterKey) Unwrap(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < k.aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	nonce, sealed := wrapped[:k.aead.NonceSize()], wrapped[k.aead.NonceSize():]
+	return k.aead.Open(nil, nonce, sealed, []byte(k.id))
+}
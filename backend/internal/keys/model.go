@@ -0,0 +1,27 @@
+package keys
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = errors.New("patient key not found")
+
+// PatientKey is a patient's data key as stored: wrapped with the master key
+type PatientKey struct {
+	ID          uuid.UUID
+	PatientID   uuid.UUID
+	Wrapped     []byte
+	MasterKeyID string
+	CreatedAt   time.Time
+}
+
+// Erasure records that a patient's key was destroyed, and by whom
+type Erasure struct {
+	PatientID uuid.UUID `json:"patient_id"`
+	KeyID     uuid.UUID `json:"key_id"`
+	ErasedBy  string    `json:"erased_by"`
+	ErasedAt  time.Time `json:"erased_at"`
+}
@@ -0,0 +1,96 @@
+package keys
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, k *PatientKey) error
+	GetByPatient(ctx context.Context, patientID uuid.UUID) (*PatientKey, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*PatientKey, error)
+	Erase(ctx context.Context, patientID uuid.UUID, erasedBy string) (*Erasure, error)
+	Erasures(ctx context.Context) ([]Erasure, error)
+}
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+const keyColumns = `id, patient_id, wrapped_key, master_key_id, created_at`
+
+func scanKey(row interface{ Scan(...any) error }) (*PatientKey, error) {
+	var k PatientKey
+	err := row.Scan(&k.ID, &k.PatientID, &k.Wrapped, &k.MasterKeyID, &k.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Create stores the key unless the patient already has one; the caller
+// reads the patient's key back either way
+func (r *postgresRepo) Create(ctx context.Context, k *PatientKey) error {
+	query := `INSERT INTO patient_keys (id, patient_id, wrapped_key, master_key_id, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (patient_id) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, k.ID, k.PatientID, k.Wrapped, k.MasterKeyID, k.CreatedAt)
+	return err
+}
+
+func (r *postgresRepo) GetByPatient(ctx context.Context, patientID uuid.UUID) (*PatientKey, error) {
+	return scanKey(r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM patient_keys WHERE patient_id = $1`, patientID))
+}
+
+func (r *postgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*PatientKey, error) {
+	return scanKey(r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM patient_keys WHERE id = $1`, id))
+}
+
+// Erase deletes the patient's key and records the erasure in one transaction
+func (r *postgresRepo) Erase(ctx context.Context, patientID uuid.UUID, erasedBy string) (*Erasure, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	e := Erasure{PatientID: patientID, ErasedBy: erasedBy}
+	err = tx.QueryRowContext(ctx, `DELETE FROM patient_keys WHERE patient_id = $1 RETURNING id`, patientID).Scan(&e.KeyID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	query := `INSERT INTO patient_key_erasures (patient_id, key_id, erased_by) VALUES ($1, $2, $3) RETURNING erased_at`
+	if err := tx.QueryRowContext(ctx, query, e.PatientID, e.KeyID, e.ErasedBy).Scan(&e.ErasedAt); err != nil {
+		return nil, err
+	}
+	return &e, tx.Commit()
+}
+
+// Erasures lists past erasures, newest first
+func (r *postgresRepo) Erasures(ctx context.Context) ([]Erasure, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT patient_id, key_id, erased_by, erased_at FROM patient_key_erasures ORDER BY erased_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	erasures := []Erasure{}
+	for rows.Next() {
+		var e Erasure
+		if err := rows.Scan(&e.PatientID, &e.KeyID, &e.ErasedBy, &e.ErasedAt); err != nil {
+			return nil, err
+		}
+		erasures = append(erasures, e)
+	}
+	return erasures, rows.Err()
+}
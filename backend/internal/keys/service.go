@@ -0,0 +1,151 @@
+package keys
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+
+	"github.com/google/uuid"
+)
+
+// Unwrapped keys are cached briefly to spare the master key (a KMS call in
+// production). Another instance forgets an erased key within this time.
+const cacheTTL = time.Minute
+
+type cachedKey struct {
+	patientID uuid.UUID
+	key       []byte
+	loadedAt  time.Time
+}
+
+// Service implements consultation.Keyring with envelope encryption: each
+// patient gets a random data key, stored wrapped with the master key
+type Service struct {
+	repo   Repository
+	master MasterKey
+
+	mu      sync.Mutex
+	byID    map[uuid.UUID]cachedKey
+	current map[uuid.UUID]uuid.UUID // patient -> key ID
+}
+
+func NewService(repo Repository, master MasterKey) *Service {
+	return &Service{
+		repo:    repo,
+		master:  master,
+		byID:    make(map[uuid.UUID]cachedKey),
+		current: make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+// PatientKey returns the patient's data key, creating it on first use
+func (s *Service) PatientKey(ctx context.Context, patientID uuid.UUID) (uuid.UUID, []byte, error) {
+	s.mu.Lock()
+	if id, ok := s.current[patientID]; ok {
+		if k, ok := s.fresh(id); ok {
+			s.mu.Unlock()
+			return id, k.key, nil
+		}
+	}
+	s.mu.Unlock()
+
+	stored, err := s.repo.GetByPatient(ctx, patientID)
+	if errors.Is(err, ErrNotFound) {
+		stored, err = s.create(ctx, patientID)
+	}
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	key, err := s.unwrap(stored)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return stored.ID, key, nil
+}
+
+// Key returns a data key by ID. A key that no longer exists was erased.
+func (s *Service) Key(ctx context.Context, keyID uuid.UUID) ([]byte, error) {
+	s.mu.Lock()
+	k, ok := s.fresh(keyID)
+	s.mu.Unlock()
+	if ok {
+		return k.key, nil
+	}
+
+	stored, err := s.repo.GetByID(ctx, keyID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, consultation.ErrErased
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.unwrap(stored)
+}
+
+// Erase destroys the patient's data key. Everything encrypted with it, in
+// the database, the event log and backups, can no longer be read.
+func (s *Service) Erase(ctx context.Context, patientID uuid.UUID, erasedBy string) (*Erasure, error) {
+	e, err := s.repo.Erase(ctx, patientID, erasedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.byID, e.KeyID)
+	delete(s.current, patientID)
+	s.mu.Unlock()
+
+	fmt.Printf("Erased data key %s of patient %s (requested by %s)\n", e.KeyID, patientID, erasedBy)
+	return e, nil
+}
+
+func (s *Service) Erasures(ctx context.Context) ([]Erasure, error) {
+	return s.repo.Erasures(ctx)
+}
+
+func (s *Service) create(ctx context.Context, patientID uuid.UUID) (*PatientKey, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	wrapped, err := s.master.Wrap(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	k := &PatientKey{ID: uuid.New(), PatientID: patientID, Wrapped: wrapped, MasterKeyID: s.master.ID(), CreatedAt: time.Now()}
+	if err := s.repo.Create(ctx, k); err != nil {
+		return nil, err
+	}
+	// A concurrent request may have created the patient's key first
+	return s.repo.GetByPatient(ctx, patientID)
+}
+
+func (s *Service) unwrap(stored *PatientKey) ([]byte, error) {
+	if stored.MasterKeyID != s.master.ID() {
+		return nil, fmt.Errorf("data key %s is wrapped with master key %s, not %s", stored.ID, stored.MasterKeyID, s.master.ID())
+	}
+	key, err := s.master.Unwrap(stored.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key %s: %w", stored.ID, err)
+	}
+
+	s.mu.Lock()
+	s.byID[stored.ID] = cachedKey{patientID: stored.PatientID, key: key, loadedAt: time.Now()}
+	s.current[stored.PatientID] = stored.ID
+	s.mu.Unlock()
+	return key, nil
+}
+
+// fresh must be called with s.mu held
+func (s *Service) fresh(keyID uuid.UUID) (cachedKey, bool) {
+	k, ok := s.byID[keyID]
+	if !ok || time.Since(k.loadedAt) > cacheTTL {
+		return cachedKey{}, false
+	}
+	return k, true
+}
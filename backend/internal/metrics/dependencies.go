@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DependencyState is the health of an external dependency judged from the
+// calls made to it recently
+type DependencyState string
+
+const (
+	StateHealthy  DependencyState = "healthy"
+	StateDegraded DependencyState = "degraded"
+	StateDown     DependencyState = "down"
+)
+
+var dependencyStates = []DependencyState{StateHealthy, StateDegraded, StateDown}
+
+const (
+	// State and rates are judged over the calls of the last few minutes
+	healthWindow = 5 * time.Minute
+	maxSamples   = 1000
+	// A dependency is down after this many failures in a row...
+	downAfter = 5
+	// ...and degraded from this error rate, given enough calls to judge
+	degradedErrorRate = 0.1
+	minCalls          = 5
+
+	recheckTimeout = 2 * time.Second
+)
+
+// DependencyStatus is one dependency's entry in /readyz
+type DependencyStatus struct {
+	Name      string          `json:"name"`
+	State     DependencyState `json:"state"`
+	Required  bool            `json:"required"`
+	Calls     int             `json:"calls"` // in the last 5 minutes
+	ErrorRate float64         `json:"error_rate"`
+	P95Ms     int64           `json:"p95_ms"`
+}
+
+// Dependencies tracks error rates and latencies of the external services the
+// server calls. It observes real traffic rather than probing, so a dependency
+// nobody called recently is reported healthy.
+type Dependencies struct {
+	mu   sync.Mutex
+	deps map[string]*dependency
+}
+
+type dependency struct {
+	required bool
+	slow     time.Duration // p95 above this means degraded
+	recheck  func(ctx context.Context) error
+	samples  []sample
+	failing  int // failures in a row
+	calls    int64
+	errors   int64
+}
+
+type sample struct {
+	at     time.Time
+	d      time.Duration
+	failed bool
+}
+
+func NewDependencies() *Dependencies {
+	return &Dependencies{deps: map[string]*dependency{}}
+}
+
+// Register adds a dependency. Only required dependencies make /readyz fail
+// when down; slow is the p95 latency above which it counts as degraded.
+// recheck, if set, is called by /readyz while the dependency is down: a pod
+// taken out of rotation gets no traffic that could show the recovery.
+func (h *Dependencies) Register(name string, required bool, slow time.Duration, recheck func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deps[name] = &dependency{required: required, slow: slow, recheck: recheck}
+}
+
+// Observe records one call; calls to unregistered dependencies are ignored
+func (h *Dependencies) Observe(name string, d time.Duration, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dep, ok := h.deps[name]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	dep.samples = append(dep.prune(now), sample{at: now, d: d, failed: failed})
+	if len(dep.samples) > maxSamples {
+		dep.samples = dep.samples[len(dep.samples)-maxSamples:]
+	}
+	dep.calls++
+	if failed {
+		dep.errors++
+		dep.failing++
+	} else {
+		dep.failing = 0
+	}
+}
+
+// prune drops samples that fell out of the window
+func (d *dependency) prune(now time.Time) []sample {
+	i := sort.Search(len(d.samples), func(i int) bool { return now.Sub(d.samples[i].at) < healthWindow })
+	return d.samples[i:]
+}
+
+func (d *dependency) status(name string, now time.Time) DependencyStatus {
+	d.samples = d.prune(now)
+	s := DependencyStatus{Name: name, State: StateHealthy, Required: d.required, Calls: len(d.samples)}
+	if len(d.samples) == 0 {
+		return s
+	}
+	failed := 0
+	latencies := make([]float64, len(d.samples))
+	for i, smp := range d.samples {
+		if smp.failed {
+			failed++
+		}
+		latencies[i] = smp.d.Seconds()
+	}
+	sort.Float64s(latencies)
+	s.ErrorRate = float64(failed) / float64(len(d.samples))
+	p95 := time.Duration(quantile(latencies, 0.95) * float64(time.Second))
+	s.P95Ms = p95.Milliseconds()
+
+	switch {
+	case d.failing >= downAfter:
+		s.State = StateDown
+	case len(d.samples) >= minCalls && s.ErrorRate >= degradedErrorRate:
+		s.State = StateDegraded
+	case d.slow > 0 && p95 > d.slow:
+		s.State = StateDegraded
+	}
+	return s
+}
+
+// Statuses returns every registered dependency by name
+func (h *Dependencies) Statuses() []DependencyStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	list := make([]DependencyStatus, 0, len(h.deps))
+	for _, name := range sortedKeys(h.deps) {
+		list = append(list, h.deps[name].status(name, now))
+	}
+	return list
+}
+
+func (h *Dependencies) Collect(out io.Writer) {
+	statuses := h.Statuses()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(out, "# HELP dependency_requests_total Calls to external dependencies.\n# TYPE dependency_requests_total counter\n")
+	for _, s := range statuses {
+		fmt.Fprintf(out, "dependency_requests_total{dependency=%q} %d\n", s.Name, h.deps[s.Name].calls)
+	}
+	fmt.Fprintf(out, "# HELP dependency_errors_total Failed calls to external dependencies.\n# TYPE dependency_errors_total counter\n")
+	for _, s := range statuses {
+		fmt.Fprintf(out, "dependency_errors_total{dependency=%q} %d\n", s.Name, h.deps[s.Name].errors)
+	}
+	fmt.Fprintf(out, "# HELP dependency_error_ratio Share of failed calls over the last 5 minutes.\n# TYPE dependency_error_ratio gauge\n")
+	for _, s := range statuses {
+		fmt.Fprintf(out, "dependency_error_ratio{dependency=%q} %g\n", s.Name, s.ErrorRate)
+	}
+	fmt.Fprintf(out, "# HELP dependency_latency_p95_seconds 95th percentile call latency over the last 5 minutes.\n# TYPE dependency_latency_p95_seconds gauge\n")
+	for _, s := range statuses {
+		fmt.Fprintf(out, "dependency_latency_p95_seconds{dependency=%q} %g\n", s.Name, float64(s.P95Ms)/1000)
+	}
+	fmt.Fprintf(out, "# HELP dependency_state Current state of the dependency, 1 for the state it is in.\n# TYPE dependency_state gauge\n")
+	for _, s := range statuses {
+		for _, state := range dependencyStates {
+			v := 0
+			if s.State == state {
+				v = 1
+			}
+			fmt.Fprintf(out, "dependency_state{dependency=%q,state=%q} %d\n", s.Name, state, v)
+		}
+	}
+}
+
+// ReadyResponse is served by /readyz
+type ReadyResponse struct {
+	Ready        bool               `json:"ready"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// ReadyHandler reports the dependencies' states, with 503 while a required one is down
+func (h *Dependencies) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.recheckDown(r.Context())
+		resp := ReadyResponse{Ready: true, Dependencies: h.Statuses()}
+		for _, s := range resp.Dependencies {
+			if s.Required && s.State == StateDown {
+				resp.Ready = false
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !resp.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (h *Dependencies) recheckDown(ctx context.Context) {
+	h.mu.Lock()
+	checks := map[string]func(context.Context) error{}
+	for name, dep := range h.deps {
+		if dep.recheck != nil && dep.failing >= downAfter {
+			checks[name] = dep.recheck
+		}
+	}
+	h.mu.Unlock()
+
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, recheckTimeout)
+		start := time.Now()
+		err := check(checkCtx)
+		cancel()
+		h.Observe(name, time.Since(start), err != nil)
+	}
+}
+
+// Transport observes HTTP calls to the named dependency. Transport errors,
+// 5xx and 429 responses count as failures; calls the caller cancelled are
+// not counted at all.
+func (h *Dependencies) Transport(name string) http.RoundTripper {
+	return h.Wrap(name, http.DefaultTransport)
+}
+
+// Wrap observes calls to the named dependency made through next
+func (h *Dependencies) Wrap(name string, next http.RoundTripper) http.RoundTripper {
+	return &observedTransport{name: name, deps: h, next: next}
+}
+
+type observedTransport struct {
+	name string
+	deps *Dependencies
+	next http.RoundTripper
+}
+
+func (t *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if errors.Is(err, context.Canceled) {
+		return resp, err
+	}
+	failed := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	t.deps.Observe(t.name, time.Since(start), failed)
+	return resp, err
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Percentiles are computed over the latest calls of each series, so they
+// follow the current data rather than the whole uptime
+const windowSize = 1000
+
+var quantiles = []float64{0.5, 0.95, 0.99}
+
+// Collector writes its series in the Prometheus text format
+type Collector interface {
+	Collect(w io.Writer)
+}
+
+// Handler serves the collectors at /metrics
+func Handler(collectors ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, c := range collectors {
+			c.Collect(w)
+		}
+	})
+}
+
+// Summary tracks durations per label value, e.g. per query
+type Summary struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	series map[string]*window
+}
+
+func NewSummary(name, help, label string) *Summary {
+	return &Summary{name: name, help: help, label: label, series: map[string]*window{}}
+}
+
+func (s *Summary) Observe(value string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.series[value]
+	if !ok {
+		w = &window{}
+		s.series[value] = w
+	}
+	w.add(d.Seconds())
+}
+
+func (s *Summary) Collect(out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s summary\n", s.name, s.help, s.name)
+	for _, value := range sortedKeys(s.series) {
+		w := s.series[value]
+		sorted := append([]float64(nil), w.samples...)
+		sort.Float64s(sorted)
+		for _, q := range quantiles {
+			fmt.Fprintf(out, "%s{%s=%q,quantile=\"%g\"} %g\n", s.name, s.label, value, q, quantile(sorted, q))
+		}
+		fmt.Fprintf(out, "%s_sum{%s=%q} %g\n", s.name, s.label, value, w.sum)
+		fmt.Fprintf(out, "%s_count{%s=%q} %d\n", s.name, s.label, value, w.count)
+	}
+}
+
+// Counter counts events per label value
+type Counter struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func NewCounter(name, help, label string) *Counter {
+	return &Counter{name: name, help: help, label: label, counts: map[string]int64{}}
+}
+
+func (c *Counter) Inc(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[value]++
+}
+
+func (c *Counter) Collect(out io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, value := range sortedKeys(c.counts) {
+		fmt.Fprintf(out, "%s{%s=%q} %d\n", c.name, c.label, value, c.counts[value])
+	}
+}
+
+// window keeps the latest samples for percentiles and totals over all of them
+type window struct {
+	samples []float64
+	next    int
+	sum     float64
+	count   int64
+}
+
+func (w *window) add(v float64) {
+	if len(w.samples) < windowSize {
+		w.samples = append(w.samples, v)
+	} else {
+		w.samples[w.next] = v
+		w.next = (w.next + 1) % windowSize
+	}
+	w.sum += v
+	w.count++
+}
+
+// quantile picks the nearest sample below q from sorted samples
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// QueryTimings are the durations of repository calls and the count of slow
+// ones. Every call also counts towards the health of the "postgres" dependency.
+type QueryTimings struct {
+	durations *Summary
+	slow      *Counter
+	deps      *Dependencies
+}
+
+func NewQueryTimings(deps *Dependencies) *QueryTimings {
+	return &QueryTimings{
+		durations: NewSummary("repository_query_duration_seconds", "Duration of repository calls, quantiles over the latest calls.", "query"),
+		slow:      NewCounter("repository_slow_queries_total", "Repository calls slower than the slow-query threshold.", "query"),
+		deps:      deps,
+	}
+}
+
+// ObserveQuery implements consultation.QueryMetrics
+func (t *QueryTimings) ObserveQuery(query string, d time.Duration, slow bool, err error) {
+	t.durations.Observe(query, d)
+	if slow {
+		t.slow.Inc(query)
+	}
+	t.deps.Observe("postgres", d, databaseFailure(err))
+}
+
+func (t *QueryTimings) Collectors() []Collector {
+	return []Collector{t.durations, t.slow}
+}
+
+// databaseFailure tells errors of the database itself from the repository's
+// own, such as a missing consultation or a record that fails to decode
+func databaseFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Connection, insufficient resources, operator intervention, system error
+		switch pqErr.Code.Class() {
+		case "08", "53", "57", "58":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package ontology
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+//go:embed snomed_subset.csv
+var defaultCodes []byte
+
+//go:embed conditions.csv
+var defaultConditions []byte
+
+//go:embed vocabulary.txt
+var defaultVocabulary []byte
+
+// Concept is one entry of the controlled vocabulary
+type Concept struct {
+	System   string   `json:"system"`
+	Code     string   `json:"code"`
+	Display  string   `json:"display"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// Parse reads a code list in CSV form: system,code,display,synonyms
+// where synonyms are "|"-separated lowercase stems matched against free text.
+func Parse(r io.Reader) ([]Concept, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid code list: %w", err)
+	}
+	if len(records) > 0 && records[0][0] == "system" {
+		records = records[1:] // header
+	}
+
+	concepts := make([]Concept, 0, len(records))
+	for i, rec := range records {
+		c := Concept{
+			System:  strings.TrimSpace(rec[0]),
+			Code:    strings.TrimSpace(rec[1]),
+			Display: strings.TrimSpace(rec[2]),
+		}
+		for _, syn := range strings.Split(rec[3], "|") {
+			if syn = strings.ToLower(strings.TrimSpace(syn)); syn != "" {
+				c.Synonyms = append(c.Synonyms, syn)
+			}
+		}
+		if c.System == "" || c.Code == "" || len(c.Synonyms) == 0 {
+			return nil, fmt.Errorf("code list row %d: system, code and synonyms are required", i+1)
+		}
+		concepts = append(concepts, c)
+	}
+	return concepts, nil
+}
+
+// Default returns the built-in SNOMED CT subset
+func Default() []Concept {
+	concepts, err := Parse(bytes.NewReader(defaultCodes))
+	if err != nil {
+		panic(fmt.Sprintf("built-in code list: %v", err))
+	}
+	return concepts
+}
+
+// Load reads a code list from path, falling back to the built-in subset when path is empty
+func Load(path string) ([]Concept, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// DefaultConditions returns the built-in SNOMED CT subset of diagnoses
+func DefaultConditions() []Concept {
+	concepts, err := Parse(bytes.NewReader(defaultConditions))
+	if err != nil {
+		panic(fmt.Sprintf("built-in diagnosis list: %v", err))
+	}
+	return concepts
+}
+
+// LoadConditions reads a diagnosis list from path, falling back to the built-in one when path is empty
+func LoadConditions(path string) ([]Concept, error) {
+	if path == "" {
+		return DefaultConditions(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ParseVocabulary reads speech recognition terms, one per line, skipping
+// blank lines and "#" comments
+func ParseVocabulary(r io.Reader) ([]string, error) {
+	var terms []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid vocabulary: %w", err)
+	}
+	return terms, nil
+}
+
+// DefaultVocabulary returns the built-in drug names and clinic terms
+func DefaultVocabulary() []string {
+	terms, err := ParseVocabulary(bytes.NewReader(defaultVocabulary))
+	if err != nil {
+		panic(fmt.Sprintf("built-in vocabulary: %v", err))
+	}
+	return terms
+}
+
+// LoadVocabulary reads terms from path, falling back to the built-in ones when path is empty
+func LoadVocabulary(path string) ([]string, error) {
+	if path == "" {
+		return DefaultVocabulary(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseVocabulary(f)
+}
+
+// Normalizer maps free-text symptom descriptions to the controlled vocabulary.
+// The same matching links diagnoses against the diagnosis list.
+type Normalizer struct {
+	concepts []Concept
+}
+
+func NewNormalizer(concepts []Concept) *Normalizer {
+	return &Normalizer{concepts: concepts}
+}
+
+// Normalize returns the concept whose longest synonym occurs in text, or nil.
+// Preferring the longest match keeps "боль в груди" from losing to a shorter stem.
+func (n *Normalizer) Normalize(text string) *consultation.Coding {
+	text = strings.ToLower(text)
+
+	var best *Concept
+	bestLen := 0
+	for i := range n.concepts {
+		for _, syn := range n.concepts[i].Synonyms {
+			if len(syn) > bestLen && strings.Contains(text, syn) {
+				best, bestLen = &n.concepts[i], len(syn)
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &consultation.Coding{System: best.System, Code: best.Code, Display: best.Display}
+}
@@ -0,0 +1,5 @@
+// Package openapi builds the OpenAPI 3 contract of the HTTP API from the
+// handlers' request/response types and serves it together with Swagger UI.
+package openapi
+
+//go:generate go run ../../cmd/openapi -o ../../api/openapi.json
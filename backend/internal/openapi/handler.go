@@ -0,0 +1,53 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// The Swagger UI assets are embedded in the binary, so the docs work on a
+// clinic network without internet access. The page loads them from "docs/"
+// next to itself.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Medical AI Agent API</title>
+  <link rel="stylesheet" href="docs/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="docs/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>`
+
+// SpecHandler serves the document as JSON.
+func SpecHandler(doc *Document) http.HandlerFunc {
+	body, _ := json.MarshalIndent(doc, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// DocsHandler serves the Swagger UI page. It expects the spec to be
+// reachable at "openapi.json" relative to its own path.
+func DocsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(swaggerUIPage))
+	}
+}
+
+// AssetsHandler serves the embedded Swagger UI files. Mount it at "docs/*"
+// next to DocsHandler; prefix is the path the file names follow, e.g. "/api/docs/".
+func AssetsHandler(prefix string) http.Handler {
+	return http.StripPrefix(prefix, http.FileServerFS(swaggerFiles.FS))
+}
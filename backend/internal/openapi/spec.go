@@ -0,0 +1,233 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Route describes a single HTTP operation exposed by a handler package.
+// Request and Response hold zero values of the wire types; their schemas are
+// derived via reflection so the spec always follows the Go structs.
+type Route struct {
+	Method       string
+	Path         string
+	Summary      string
+	Tags         []string
+	RequestType  string // "application/json" by default
+	Request      any
+	ResponseType string // "application/json" by default
+	Response     any
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+const (
+	Title   = "Medical AI Agent API"
+	Version = "1.0.0"
+)
+
+// Build assembles an OpenAPI 3 document from the given routes.
+func Build(routes []Route) *Document {
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: Title, Version: Version},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+
+	for _, rt := range routes {
+		op := &Operation{
+			Summary:    rt.Summary,
+			Tags:       rt.Tags,
+			Parameters: pathParameters(rt.Path),
+			Responses:  map[string]Response{},
+		}
+
+		if rt.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					orDefault(rt.RequestType): {Schema: doc.schemaFor(reflect.TypeOf(rt.Request))},
+				},
+			}
+		}
+
+		ok := Response{Description: "OK"}
+		if rt.Response != nil {
+			ok.Content = map[string]MediaType{
+				orDefault(rt.ResponseType): {Schema: doc.schemaFor(reflect.TypeOf(rt.Response))},
+			}
+		} else if rt.ResponseType != "" {
+			ok.Content = map[string]MediaType{
+				rt.ResponseType: {Schema: &Schema{Type: "string", Format: "binary"}},
+			}
+		}
+		op.Responses["200"] = ok
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content: map[string]MediaType{
+				"text/plain": {Schema: &Schema{Type: "string"}},
+			},
+		}
+
+		item, exists := doc.Paths[rt.Path]
+		if !exists {
+			item = PathItem{}
+			doc.Paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	return doc
+}
+
+// pathParameters declares the {name} segments of a chi-style path
+func pathParameters(path string) []Parameter {
+	var params []Parameter
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	return params
+}
+
+func orDefault(contentType string) string {
+	if contentType == "" {
+		return "application/json"
+	}
+	return contentType
+}
+
+// schemaFor returns an inline schema for basic types and a $ref for named
+// structs, registering the latter under components.
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.PkgPath() == "time" && t.Name() == "Time":
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.PkgPath() == "github.com/google/uuid" && t.Name() == "UUID":
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		if _, ok := d.Components.Schemas[t.Name()]; !ok {
+			// Reserve the name first to stop recursion on self-referencing types
+			d.Components.Schemas[t.Name()] = &Schema{}
+			d.Components.Schemas[t.Name()] = d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fs := d.schemaFor(f.Type)
+		if format := f.Tag.Get("format"); format != "" {
+			fs = &Schema{Type: "string", Format: format}
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
@@ -0,0 +1,211 @@
+// Package fakespeech serves canned audio and transcripts in place of the
+// Python speech sidecar, so the whole pipeline can run in CI without models.
+//
+// The fixture directory may contain:
+//   - name.wav with name.txt: an upload with exactly these bytes is
+//     transcribed as the text in name.txt
+//   - script.txt: transcripts, one per line, returned in order for any other
+//     upload; once it runs out the upload is treated as silence
+//   - speech.wav: returned for every synthesis request. Without it a silent
+//     WAV roughly as long as the text would take to say is generated.
+package fakespeech
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	sampleRate = 24000 // the sidecar's default
+	perRune    = 60 * time.Millisecond
+	minSpeech  = 300 * time.Millisecond
+	maxSpeech  = 10 * time.Second
+)
+
+// Call is one request the server answered, for tests to check what the
+// backend said and heard
+type Call struct {
+	Endpoint string `json:"endpoint"` // generate or transcribe
+	Text     string `json:"text"`     // synthesized or returned text
+	Speaker  string `json:"speaker,omitempty"`
+	Language string `json:"language,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+}
+
+type Server struct {
+	transcripts map[[32]byte]string // by audio hash
+	script      []string
+	speech      []byte // nil generates silence
+
+	mu    sync.Mutex
+	next  int
+	calls []Call
+	mux   *http.ServeMux
+}
+
+// New loads the fixtures from dir
+func New(dir string) (*Server, error) {
+	s := &Server{transcripts: map[[32]byte]string{}, mux: http.NewServeMux()}
+
+	wavs, err := filepath.Glob(filepath.Join(dir, "*.wav"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range wavs {
+		if filepath.Base(path) == "speech.wav" {
+			continue
+		}
+		text, err := os.ReadFile(strings.TrimSuffix(path, ".wav") + ".txt")
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		audio, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s.transcripts[sha256.Sum256(audio)] = strings.TrimSpace(string(text))
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, "script.txt"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(script), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.script = append(s.script, line)
+		}
+	}
+
+	s.speech, err = os.ReadFile(filepath.Join(dir, "speech.wav"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	s.mux.HandleFunc("POST /generate", s.generate)
+	s.mux.HandleFunc("POST /transcribe", s.transcribe)
+	s.mux.HandleFunc("GET /calls", s.listCalls)
+	s.mux.HandleFunc("POST /reset", s.reset)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) record(c Call) {
+	s.mu.Lock()
+	s.calls = append(s.calls, c)
+	s.mu.Unlock()
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text    string `json:"text"`
+		Speaker string `json:"speaker"`
+		PauseMs int    `json:"pause_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+	s.record(Call{Endpoint: "generate", Text: req.Text, Speaker: req.Speaker})
+
+	audio := s.speech
+	if audio == nil {
+		d := time.Duration(utf8.RuneCountInString(req.Text)) * perRune
+		d = min(max(d, minSpeech), maxSpeech) + time.Duration(req.PauseMs)*time.Millisecond
+		audio = silence(d)
+	}
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Write(audio)
+}
+
+func (s *Server) transcribe(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	text, ok := s.transcripts[sha256.Sum256(audio)]
+	if !ok {
+		s.mu.Lock()
+		if s.next < len(s.script) {
+			text = s.script[s.next]
+			s.next++
+		}
+		s.mu.Unlock()
+	}
+	language := r.FormValue("language")
+	s.record(Call{Endpoint: "transcribe", Text: text, Language: language, Prompt: r.FormValue("prompt")})
+
+	if language == "" {
+		language = "ru"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"text": text, "language": language})
+}
+
+func (s *Server) listCalls(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	calls := append([]Call{}, s.calls...)
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calls)
+}
+
+// reset starts the script over and forgets the calls, between test cases
+func (s *Server) reset(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.next, s.calls = 0, nil
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// silence is a mono 16-bit PCM WAV of the given length
+func silence(d time.Duration) []byte {
+	samples := int(d.Seconds() * sampleRate)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+samples*2))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16), uint16(1), uint16(1), // PCM, mono
+		uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16),
+	} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(samples*2))
+	b.Write(make([]byte, samples*2))
+	return b.Bytes()
+}
+
+// String describes the loaded fixtures for the startup log
+func (s *Server) String() string {
+	audio := "generated silence"
+	if s.speech != nil {
+		audio = "speech.wav"
+	}
+	return fmt.Sprintf("%d audio fixtures, %d script lines, %s for synthesis", len(s.transcripts), len(s.script), audio)
+}
@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+)
+
+// Client sends mail through an SMTP relay
+type Client struct {
+	addr string // host:port
+	from string
+	auth smtp.Auth
+}
+
+// NewClient returns a client for the relay at addr. Without a user the relay
+// is used without authentication, e.g. a local MTA.
+func NewClient(addr, from, user, password string) *Client {
+	c := &Client{addr: addr, from: from}
+	if user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		c.auth = smtp.PlainAuth("", user, password, host)
+	}
+	return c
+}
+
+// SendDocument mails a file as an attachment with a short text body
+func (c *Client) SendDocument(to, subject, body string, fileData []byte, fileName string) error {
+	var msg bytes.Buffer
+	writer := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", c.from, to, mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return err
+	}
+	part.Write([]byte(body))
+
+	part, err = writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.TypeByExtension(filepath.Ext(fileName))},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", fileName)},
+	})
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(fileData)
+	// Lines of base64 must not exceed 76 characters
+	for len(encoded) > 76 {
+		part.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	part.Write([]byte(encoded + "\r\n"))
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(c.addr, c.auth, c.from, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("smtp error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate from disk and picks up renewals (e.g. by certbot)
+// without a restart by checking the files' modification time.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// certCheckInterval limits how often we stat the files during handshakes
+const certCheckInterval = time.Minute
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) reload() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat certificate: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) > certCheckInterval {
+		r.checked = time.Now()
+		if info, err := os.Stat(r.certFile); err == nil && info.ModTime().After(r.modTime) {
+			if err := r.reload(); err != nil {
+				// Keep serving the old certificate rather than failing handshakes
+				fmt.Printf("Certificate reload failed: %v\n", err)
+			} else {
+				fmt.Println("TLS certificate reloaded.")
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// TLSConfig returns a hardened TLS configuration. HTTP/2 is negotiated
+// automatically by net/http when serving with it.
+func TLSConfig(certs *CertReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
@@ -0,0 +1,100 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Dependency is an external service the server needs at startup.
+// Required dependencies abort startup when they never become available,
+// optional ones only produce a warning.
+type Dependency struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// Backoff controls how long we keep retrying a dependency
+type Backoff struct {
+	Initial        time.Duration
+	Max            time.Duration
+	Attempts       int
+	AttemptTimeout time.Duration
+}
+
+var DefaultBackoff = Backoff{
+	Initial:        500 * time.Millisecond,
+	Max:            10 * time.Second,
+	Attempts:       10,
+	AttemptTimeout: 5 * time.Second,
+}
+
+// Result reports the outcome for a single dependency
+type Result struct {
+	Name      string
+	Required  bool
+	Available bool
+	Err       error
+}
+
+// WaitFor checks all dependencies concurrently, retrying each with exponential
+// backoff. It returns an error if any required dependency is still unavailable
+// after the last attempt.
+func WaitFor(ctx context.Context, deps []Dependency, b Backoff) ([]Result, error) {
+	results := make([]Result, len(deps))
+
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			err := retry(ctx, dep, b)
+			results[i] = Result{Name: dep.Name, Required: dep.Required, Available: err == nil, Err: err}
+		}(i, dep)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		switch {
+		case r.Available:
+			log.Printf("Dependency %s is available.", r.Name)
+		case r.Required:
+			return results, fmt.Errorf("required dependency %s is unavailable after %d attempts: %w", r.Name, b.Attempts, r.Err)
+		default:
+			log.Printf("Warning: optional dependency %s is unavailable: %v. Related features will fail.", r.Name, r.Err)
+		}
+	}
+	return results, nil
+}
+
+func retry(ctx context.Context, dep Dependency, b Backoff) error {
+	delay := b.Initial
+	var err error
+	for attempt := 1; attempt <= b.Attempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, b.AttemptTimeout)
+		err = dep.Check(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == b.Attempts {
+			break
+		}
+
+		log.Printf("Waiting for %s... (%d/%d): %v", dep.Name, attempt, b.Attempts, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		delay *= 2
+		if delay > b.Max {
+			delay = b.Max
+		}
+	}
+	return err
+}
@@ -15,11 +15,13 @@ type Client struct {
 	httpClient *http.Client
 }
 
-func NewClient(token string) *Client {
+// transport may be nil for the default one
+func NewClient(token string, timeout time.Duration, transport http.RoundTripper) *Client {
 	return &Client{
 		Token: token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second, // Increased timeout for file uploads
+			Timeout:   timeout,
+			Transport: transport,
 		},
 	}
 }
@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Telegram allows about one edit per second in a chat and 4096 characters
+// per message
+const (
+	DefaultEditInterval = time.Second
+	maxMessageLength    = 4096
+	streamPlaceholder   = "…"
+)
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// apiError is a response with ok=false. RetryAfter is set when Telegram
+// rate limited the call.
+type apiError struct {
+	Status      int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("telegram api returned status: %d, description: %s", e.Status, e.Description)
+}
+
+func (c *Client) call(ctx context.Context, method string, body any, result any) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", c.Token, method)
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var r apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram api returned status: %s", resp.Status)
+	}
+	if !r.OK {
+		return &apiError{Status: resp.StatusCode, Description: r.Description, RetryAfter: time.Duration(r.Parameters.RetryAfter) * time.Second}
+	}
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
+
+// SendText sends a message and returns its ID, so it can be edited later
+func (c *Client) SendText(ctx context.Context, chatID int64, text string) (int64, error) {
+	var msg struct {
+		MessageID int64 `json:"message_id"`
+	}
+	err := c.call(ctx, "sendMessage", sendMessageReq{ChatID: chatID, Text: text}, &msg)
+	return msg.MessageID, err
+}
+
+type editMessageReq struct {
+	ChatID    int64  `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+	Text      string `json:"text"`
+}
+
+// EditMessageText replaces the text of a sent message. Setting the text it
+// already has is not an error.
+func (c *Client) EditMessageText(ctx context.Context, chatID, messageID int64, text string) error {
+	err := c.call(ctx, "editMessageText", editMessageReq{ChatID: chatID, MessageID: messageID, Text: text}, nil)
+	if e, ok := err.(*apiError); ok && strings.Contains(e.Description, "message is not modified") {
+		return nil
+	}
+	return err
+}
+
+// StreamReply shows a reply as it is generated: it sends a placeholder and
+// edits it with the text received so far, at most once per interval. Text
+// beyond the message limit continues in a new message. The final text is
+// always written once chunks is closed.
+func (c *Client) StreamReply(ctx context.Context, chatID int64, chunks <-chan string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultEditInterval
+	}
+	messageID, err := c.SendText(ctx, chatID, streamPlaceholder)
+	if err != nil {
+		return err
+	}
+	s := &replyStream{c: c, chatID: chatID, messageID: messageID}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return s.finish(ctx)
+			}
+			s.text += chunk
+		case <-ticker.C:
+			err := s.update(ctx)
+			if e, ok := err.(*apiError); ok && e.RetryAfter > 0 {
+				// Rate limited: the text is written on a later tick
+				err = nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replyStream is the message being edited and the text for it
+type replyStream struct {
+	c         *Client
+	chatID    int64
+	messageID int64
+	text      string // received, not yet moved to a finished message
+	shown     string // what the message currently says
+}
+
+// update edits the message to the received text, first moving text that
+// no longer fits into finished messages
+func (s *replyStream) update(ctx context.Context) error {
+	for len([]rune(s.text)) > maxMessageLength {
+		head, rest := splitMessage(s.text)
+		if err := s.c.EditMessageText(ctx, s.chatID, s.messageID, head); err != nil {
+			return err
+		}
+		messageID, err := s.c.SendText(ctx, s.chatID, streamPlaceholder)
+		if err != nil {
+			return err
+		}
+		s.messageID, s.text, s.shown = messageID, rest, streamPlaceholder
+	}
+	if s.text == s.shown || strings.TrimSpace(s.text) == "" {
+		return nil
+	}
+	if err := s.c.EditMessageText(ctx, s.chatID, s.messageID, s.text); err != nil {
+		return err
+	}
+	s.shown = s.text
+	return nil
+}
+
+// finish writes the final text, waiting out a rate limit once
+func (s *replyStream) finish(ctx context.Context) error {
+	err := s.update(ctx)
+	if e, ok := err.(*apiError); ok && e.RetryAfter > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.RetryAfter):
+		}
+		err = s.update(ctx)
+	}
+	return err
+}
+
+// splitMessage cuts text at the last line break or space that fits in one message
+func splitMessage(text string) (string, string) {
+	runes := []rune(text)
+	head := string(runes[:maxMessageLength])
+	if i := strings.LastIndexAny(head, "\n "); i > maxMessageLength/2 {
+		head = head[:i]
+	}
+	return head, strings.TrimLeft(text[len(head):], "\n ")
+}
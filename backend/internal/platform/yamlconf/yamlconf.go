@@ -0,0 +1,82 @@
+// Package yamlconf reads the YAML configuration files: rules, screening
+// blocks, experiments, text normalization, the abuse policy and agent models.
+package yamlconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errEmpty = errors.New("empty document")
+
+// Unmarshal decodes a YAML document into v. The structs of the config
+// packages carry json tags, which also serve the admin API, so the document
+// is converted to JSON and decoded with them; a file in the older JSON
+// syntax is valid YAML and reads the same.
+func Unmarshal(data []byte, v any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return errEmpty
+	}
+	value, err := jsonValue(doc.Content[0])
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// jsonValue turns a YAML node into a value encoding/json can write. Mapping
+// keys become strings and dates stay strings, as they would be in JSON.
+func jsonValue(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			item, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key.Value] = item
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, c := range n.Content {
+			item, err := jsonValue(c)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case yaml.AliasNode:
+		return jsonValue(n.Alias)
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool", "!!int", "!!float":
+			var v any
+			if err := n.Decode(&v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+		return n.Value, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
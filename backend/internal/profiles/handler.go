@@ -0,0 +1,107 @@
+package profiles
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"medical-ai-agent/internal/auth"
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	store   Store
+	history History
+}
+
+// NewHandler takes the history that versions every change; nil keeps none
+func NewHandler(store Store, history History) *Handler {
+	return &Handler{store: store, history: history}
+}
+
+type PutProfileRequest struct {
+	Fields []consultation.RequiredField `json:"fields"`
+}
+
+func (h *Handler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.store.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(profiles)
+}
+
+// PutProfile creates or replaces a department's profile. Consultations
+// already running keep the fields they started with.
+func (h *Handler) PutProfile(w http.ResponseWriter, r *http.Request) {
+	var req PutProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	p := &Profile{Department: chi.URLParam(r, "department"), Fields: req.Fields, UpdatedAt: time.Now()}
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		p.UpdatedBy = u.Name
+	}
+	if err := p.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.Put(r.Context(), p); err != nil {
+		http.Error(w, "Failed to save profile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.record(r.Context(), p.UpdatedBy, "profile "+p.Department+" saved")
+
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	department := chi.URLParam(r, "department")
+	err := h.store.Delete(r.Context(), department)
+	if err == ErrNotFound {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete profile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	author := ""
+	if u, ok := auth.UserFromContext(r.Context()); ok {
+		author = u.Name
+	}
+	h.record(r.Context(), author, "profile "+department+" deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes mounts profile management. Authenticate must already be applied.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.With(auth.Require(auth.PermViewStats)).Get("/profiles", h.ListProfiles)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Require(auth.PermManageProfiles))
+		r.Put("/profiles/{department}", h.PutProfile)
+		r.Delete("/profiles/{department}", h.DeleteProfile)
+	})
+}
+
+// Routes describes the endpoints registered by RegisterRoutes for the OpenAPI spec.
+// Keep it in sync with RegisterRoutes and regenerate api/openapi.json (go generate ./...).
+func Routes() []openapi.Route {
+	tags := []string{"admin"}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/admin/profiles", Summary: "Required-information profiles of the departments (view_stats)", Tags: tags,
+			Response: []Profile{}},
+		{Method: http.MethodPut, Path: "/admin/profiles/{department}", Summary: "Set the fields the interview must cover for a department (manage_profiles)", Tags: tags,
+			Request: PutProfileRequest{}, Response: Profile{}},
+		{Method: http.MethodDelete, Path: "/admin/profiles/{department}", Summary: "Remove the profile of a department (manage_profiles)", Tags: tags},
+	}
+}
@@ -0,0 +1,53 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// A profile with more fields would make the interview drag on
+const maxFields = 20
+
+var ErrNotFound = errors.New("profile not found")
+
+// Profile is the information a department wants collected in every interview
+type Profile struct {
+	Department string                       `json:"department"`
+	Fields     []consultation.RequiredField `json:"fields"`
+	UpdatedBy  string                       `json:"updated_by"`
+	UpdatedAt  time.Time                    `json:"updated_at"`
+}
+
+// Validate trims the fields and checks that ids and labels are set and unique
+func (p *Profile) Validate() error {
+	if !consultation.ValidDepartment(p.Department) {
+		return fmt.Errorf("invalid department %q", p.Department)
+	}
+	if len(p.Fields) == 0 {
+		return fmt.Errorf("profile has no fields")
+	}
+	if len(p.Fields) > maxFields {
+		return fmt.Errorf("profile has more than %d fields", maxFields)
+	}
+
+	ids, labels := map[string]bool{}, map[string]bool{}
+	for i := range p.Fields {
+		f := &p.Fields[i]
+		f.ID = strings.TrimSpace(f.ID)
+		f.Label = strings.TrimSpace(f.Label)
+		f.Question = strings.TrimSpace(f.Question)
+		if f.ID == "" || f.Label == "" || f.Question == "" {
+			return fmt.Errorf("field %d needs id, label and question", i+1)
+		}
+		label := strings.ToLower(f.Label)
+		if ids[f.ID] || labels[label] {
+			return fmt.Errorf("duplicate field %q", f.ID)
+		}
+		ids[f.ID], labels[label] = true, true
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package profiles
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+type Store interface {
+	List(ctx context.Context) ([]Profile, error)
+	Get(ctx context.Context, department string) (*Profile, error)
+	Put(ctx context.Context, p *Profile) error
+	Delete(ctx context.Context, department string) error
+	// ReplaceAll swaps every profile for the given ones, e.g. on a rollback
+	ReplaceAll(ctx context.Context, profiles []Profile) error
+	// Required implements consultation.ProfileSource; a department without
+	// a profile has no required fields
+	Required(ctx context.Context, department string) ([]consultation.RequiredField, error)
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) List(ctx context.Context) ([]Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT department, fields, updated_by, updated_at FROM requirement_profiles ORDER BY department`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		var p Profile
+		var fieldsJSON []byte
+		if err := rows.Scan(&p.Department, &fieldsJSON, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fieldsJSON, &p.Fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of %s: %w", p.Department, err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+func (s *postgresStore) Get(ctx context.Context, department string) (*Profile, error) {
+	query := `SELECT department, fields, updated_by, updated_at FROM requirement_profiles WHERE department = $1`
+
+	var p Profile
+	var fieldsJSON []byte
+	err := s.db.QueryRowContext(ctx, query, department).Scan(&p.Department, &fieldsJSON, &p.UpdatedBy, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(fieldsJSON, &p.Fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+	return &p, nil
+}
+
+func (s *postgresStore) Put(ctx context.Context, p *Profile) error {
+	fieldsJSON, err := json.Marshal(p.Fields)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO requirement_profiles (department, fields, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (department) DO UPDATE SET
+			fields = EXCLUDED.fields,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`
+	_, err = s.db.ExecContext(ctx, query, p.Department, fieldsJSON, p.UpdatedBy, p.UpdatedAt)
+	return err
+}
+
+func (s *postgresStore) Delete(ctx context.Context, department string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM requirement_profiles WHERE department = $1`, department)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *postgresStore) ReplaceAll(ctx context.Context, profiles []Profile) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM requirement_profiles`); err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		fieldsJSON, err := json.Marshal(p.Fields)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO requirement_profiles (department, fields, updated_by, updated_at) VALUES ($1, $2, $3, $4)`,
+			p.Department, fieldsJSON, p.UpdatedBy, p.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *postgresStore) Required(ctx context.Context, department string) ([]consultation.RequiredField, error) {
+	p, err := s.Get(ctx, department)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Fields, nil
+}
@@ -0,0 +1,71 @@
+package profiles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"medical-ai-agent/internal/configversions"
+)
+
+// History records the profiles as a checklist version after every change
+type History interface {
+	Record(ctx context.Context, kind configversions.Kind, content any, author, note string) (*configversions.Version, error)
+}
+
+// Versions applies checklist versions, see configversions.Applier. The
+// content is the full list of profiles.
+type Versions struct {
+	store Store
+}
+
+func NewVersions(store Store) *Versions {
+	return &Versions{store: store}
+}
+
+func (v *Versions) Validate(content json.RawMessage) error {
+	_, err := parseVersion(content)
+	return err
+}
+
+// Apply replaces the profiles; consultations already running keep the
+// fields they started with
+func (v *Versions) Apply(ctx context.Context, content json.RawMessage) error {
+	profiles, err := parseVersion(content)
+	if err != nil {
+		return err
+	}
+	return v.store.ReplaceAll(ctx, profiles)
+}
+
+func parseVersion(content json.RawMessage) ([]Profile, error) {
+	var profiles []Profile
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		return nil, fmt.Errorf("checklists must be a list of profiles: %w", err)
+	}
+	seen := make(map[string]bool, len(profiles))
+	for i := range profiles {
+		if err := profiles[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[profiles[i].Department] {
+			return nil, fmt.Errorf("duplicate profile %q", profiles[i].Department)
+		}
+		seen[profiles[i].Department] = true
+	}
+	return profiles, nil
+}
+
+// record snapshots the profiles after a change by author
+func (h *Handler) record(ctx context.Context, author, note string) {
+	if h.history == nil {
+		return
+	}
+	profiles, err := h.store.List(ctx)
+	if err == nil {
+		_, err = h.history.Record(ctx, configversions.KindChecklists, profiles, author, note)
+	}
+	if err != nil {
+		fmt.Printf("Failed to record a checklist version: %v\n", err)
+	}
+}
@@ -0,0 +1,41 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// NotifyAbuse asks staff to come to a patient who keeps abusing the assistant.
+// It goes to the crisis chat, which staff on the floor watch.
+func (s *Service) NotifyAbuse(ctx context.Context, c consultation.Consultation) error {
+	var b strings.Builder
+	b.WriteString("Пациент продолжает оскорблять ассистента, нужен сотрудник\n")
+	fmt.Fprintf(&b, "Консультация: %s\n", c.ID)
+	fmt.Fprintf(&b, "Пациент: %s\n", c.PatientID)
+	if d := c.Device; d != nil {
+		place := []string{d.Name}
+		for _, p := range []string{d.Location, d.Room} {
+			if p != "" {
+				place = append(place, p)
+			}
+		}
+		fmt.Fprintf(&b, "Место: %s\n", strings.Join(place, ", "))
+	}
+	fmt.Fprintf(&b, "Эпизодов: %d", len(c.AbuseIncidents))
+
+	fmt.Printf("Sending abuse alert for consultation %s to chat %d...\n", c.ID, s.crisisChatID)
+	return s.tgClient.SendMessage(s.crisisChatID, b.String())
+}
+
+func abuseSummary(incidents []consultation.AbuseIncident) string {
+	line := fmt.Sprintf("Оскорбления в адрес ассистента: %d", len(incidents))
+	for _, i := range incidents {
+		if i.Response == consultation.AbuseStaffCalled {
+			return line + " (вызван сотрудник)"
+		}
+	}
+	return line
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// SendAssignment tells the doctor chat which room, and bed, a waiting patient
+// was put in, so the doctor who got the report knows where to find them
+func (s *Service) SendAssignment(ctx context.Context, c consultation.Consultation) error {
+	if c.Assignment == nil {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Талон %s: %s\n", consultation.FormatTicket(c.Ticket), assignmentLine(*c.Assignment))
+	if c.ChiefComplaint != nil && c.ChiefComplaint.Text != "" {
+		fmt.Fprintf(&b, "Жалоба: %s\n", c.ChiefComplaint.Text)
+	}
+	fmt.Fprintf(&b, "Консультация: %s\n", c.ID)
+	fmt.Fprintf(&b, "Назначил: %s", c.Assignment.AssignedBy)
+
+	chatID := s.doctorChat(c)
+	fmt.Printf("Sending assignment for consultation %s to chat %d...\n", c.ID, chatID)
+	return s.tgClient.SendMessage(chatID, b.String())
+}
+
+func assignmentLine(a consultation.Assignment) string {
+	if a.Bed != "" {
+		return fmt.Sprintf("кабинет %s, место %s", a.Room, a.Bed)
+	}
+	return "кабинет " + a.Room
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg" // logo decoders
+	_ "image/png"
+	"regexp"
+	"strings"
+	"time"
+
+	"medical-ai-agent/internal/branding"
+
+	"github.com/signintech/gopdf"
+)
+
+// BrandingSource gives the tenant's branding in effect
+type BrandingSource interface {
+	Current() branding.Branding
+}
+
+func (s *Service) branding() branding.Branding {
+	if s.brand == nil {
+		return branding.Branding{}
+	}
+	return s.brand.Current()
+}
+
+// brandedTelegram heads every message with the tenant's display name and
+// puts it in the names of the files, so staff sharing a chat across clinics
+// can tell them apart
+type brandedTelegram struct {
+	next  TelegramClient
+	brand BrandingSource
+}
+
+func (t brandedTelegram) SendMessage(chatID int64, text string) error {
+	if name := t.brand.Current().DisplayName; name != "" {
+		text = name + "\n" + text
+	}
+	return t.next.SendMessage(chatID, text)
+}
+
+func (t brandedTelegram) SendDocument(chatID int64, fileData []byte, fileName string) error {
+	if name := fileSafeName(t.brand.Current().DisplayName); name != "" {
+		fileName = name + "_" + fileName
+	}
+	return t.next.SendDocument(chatID, fileData, fileName)
+}
+
+// StreamReply answers a patient in their own chat, which needs no header
+func (t brandedTelegram) StreamReply(ctx context.Context, chatID int64, chunks <-chan string, interval time.Duration) error {
+	return t.next.StreamReply(ctx, chatID, chunks, interval)
+}
+
+var fileUnsafeRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// fileSafeName turns a display name into a part of a file name
+func fileSafeName(name string) string {
+	return strings.Trim(fileUnsafeRe.ReplaceAllString(name, "_"), "_")
+}
+
+// Logo size in the PDF, in points
+const (
+	logoHeight   = 40.0
+	logoMaxWidth = 120.0
+)
+
+// writeBrandHeader puts the logo at the top right of the page and the header
+// text at the top left, beside it, then moves below both
+func (p *paginatedPDF) writeBrandHeader(b branding.Branding) error {
+	top, bottom := p.GetY(), p.GetY()
+	if len(b.Logo) > 0 {
+		h, err := p.logo(b.Logo, top)
+		if err != nil {
+			// A broken logo must not hold up the report
+			fmt.Printf("Report logo skipped: %v\n", err)
+		} else {
+			bottom = top + h + 10
+		}
+	}
+	if b.Header != "" {
+		if err := p.SetFont("DejaVu", "", 9); err != nil {
+			return err
+		}
+		for _, text := range strings.Split(b.Header, "\n") {
+			lines, _ := p.SplitText(text, 500-logoMaxWidth-20)
+			for _, l := range lines {
+				p.Cell(nil, l)
+				p.Br(11)
+			}
+		}
+		p.Br(8)
+	}
+	if p.GetY() < bottom {
+		p.SetY(bottom)
+	}
+	return nil
+}
+
+// logo draws the image right-aligned to the text at y and returns its height
+func (p *paginatedPDF) logo(data []byte, y float64) (float64, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return 0, fmt.Errorf("empty image")
+	}
+	h := logoHeight
+	w := float64(cfg.Width) * h / float64(cfg.Height)
+	if w > logoMaxWidth {
+		w, h = logoMaxWidth, float64(cfg.Height)*logoMaxWidth/float64(cfg.Width)
+	}
+	holder, err := gopdf.ImageHolderByBytes(data)
+	if err != nil {
+		return 0, err
+	}
+	x := p.GetX() + 500 - w
+	if err := p.ImageByHolder(holder, x, y, &gopdf.Rect{W: w, H: h}); err != nil {
+		return 0, err
+	}
+	return h, nil
+}
+
+// writeBrandFooter writes the tenant's footer at the bottom left of every
+// page, beside the page number
+func (p *paginatedPDF) writeBrandFooter(footer string) error {
+	if footer == "" {
+		return nil
+	}
+	if err := p.SetFont("DejaVu", "", 8); err != nil {
+		return err
+	}
+	lines, _ := p.SplitText(strings.ReplaceAll(footer, "\n", " "), 420)
+	if len(lines) > 2 {
+		lines = lines[:2]
+	}
+	for i := 1; i <= p.GetNumberOfPages(); i++ {
+		if err := p.SetPage(i); err != nil {
+			return err
+		}
+		for n, l := range lines {
+			p.SetX(p.MarginLeft())
+			p.SetY(pageBottom + 20 + float64(n)*10)
+			if err := p.Cell(nil, l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// logoURL embeds the logo in the HTML report
+func logoURL(b branding.Branding) template.URL {
+	if len(b.Logo) == 0 {
+		return ""
+	}
+	return template.URL("data:" + b.LogoType + ";base64," + base64.StdEncoding.EncodeToString(b.Logo))
+}
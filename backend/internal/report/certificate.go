@@ -0,0 +1,141 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+
+	"github.com/signintech/gopdf"
+)
+
+// maxCertificateFacts keeps the certificate to one page; the doctor's report
+// has the full list
+const maxCertificateFacts = 10
+
+// RenderCertificate builds the patient's one-page summary of a completed
+// interview: what they reported and what happens next. It leaves out
+// everything meant for the doctor, such as risk flags, rule findings and
+// recommendations. code is the signed code staff check the certificate by.
+func (s *Service) RenderCertificate(c consultation.Consultation, code string) ([]byte, error) {
+	fmt.Printf("Generating patient certificate for consultation %s...\n", c.ID)
+	pdf := &paginatedPDF{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	pdf.AddPage()
+	if err := pdf.loadFont(); err != nil {
+		return nil, err
+	}
+
+	brand := s.branding()
+	if err := pdf.writeBrandHeader(brand); err != nil {
+		return nil, err
+	}
+
+	paragraph := func(text string, size float64) error {
+		if err := pdf.SetFont("DejaVu", "", size); err != nil {
+			return err
+		}
+		lines, _ := pdf.SplitText(text, 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(size + 3)
+		}
+		return nil
+	}
+
+	if err := paragraph("Справка о прохождении предварительного опроса", 18); err != nil {
+		return nil, err
+	}
+	pdf.Br(10)
+	if err := paragraph(fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006 15:04")), 12); err != nil {
+		return nil, err
+	}
+	if err := paragraph(fmt.Sprintf("Талон: %s", consultation.FormatTicket(c.Ticket)), 12); err != nil {
+		return nil, err
+	}
+	if c.Device != nil && c.Device.Place() != "" {
+		if err := paragraph("Место: "+c.Device.Place(), 12); err != nil {
+			return nil, err
+		}
+	}
+	pdf.Br(10)
+
+	if err := paragraph("Что вы рассказали", 14); err != nil {
+		return nil, err
+	}
+	if c.ChiefComplaint != nil && c.ChiefComplaint.Text != "" {
+		if err := paragraph("Причина обращения: "+c.ChiefComplaint.Text, 11); err != nil {
+			return nil, err
+		}
+	}
+	facts := c.ExtractedFacts
+	if len(c.FactSummary) > 0 {
+		facts = c.FactSummary
+	}
+	if len(facts) > maxCertificateFacts {
+		facts = facts[:maxCertificateFacts]
+	}
+	for _, f := range facts {
+		if err := paragraph("• "+f.Description, 11); err != nil {
+			return nil, err
+		}
+	}
+	pdf.Br(10)
+
+	if err := paragraph("Что дальше", 14); err != nil {
+		return nil, err
+	}
+	for _, line := range nextSteps(c) {
+		if err := paragraph(line, 11); err != nil {
+			return nil, err
+		}
+	}
+	pdf.Br(10)
+
+	if err := paragraph("Это не медицинское заключение и не диагноз. Ответы записаны ИИ-ассистентом и будут проверены врачом. Если вам стало хуже, сразу скажите об этом медсестре.", 10); err != nil {
+		return nil, err
+	}
+	pdf.Br(10)
+	if err := paragraph(fmt.Sprintf("Консультация: %s", c.ID), 9); err != nil {
+		return nil, err
+	}
+	if err := paragraph(fmt.Sprintf("Код подлинности: %s", code), 9); err != nil {
+		return nil, err
+	}
+	if err := pdf.writeBrandFooter(brand.Footer); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// nextSteps tells the patient where to go and what was booked for them
+func nextSteps(c consultation.Consultation) []string {
+	var steps []string
+	if c.Assignment != nil {
+		steps = append(steps, c.Assignment.Announcement())
+	} else {
+		steps = append(steps, "Врач ознакомится с вашими ответами до приёма. Следите за номером талона на табло, вас пригласят.")
+	}
+
+	if b := c.Booking; b != nil {
+		switch {
+		case b.Status == consultation.BookingBooked && b.Slot != nil:
+			line := fmt.Sprintf("Вы записаны на повторный приём: %s, %s", b.Specialty, b.Slot.Format("02.01.2006 15:04"))
+			if b.Location != "" {
+				line += ", " + b.Location
+			}
+			steps = append(steps, line+".")
+		case b.Status == consultation.BookingRequested:
+			steps = append(steps, fmt.Sprintf("Запрос на повторный приём (%s) передан в регистратуру, с вами свяжутся.", b.Specialty))
+		default:
+			steps = append(steps, fmt.Sprintf("Запишитесь, пожалуйста, на повторный приём: %s.", b.Specialty))
+		}
+	}
+	return steps
+}
@@ -0,0 +1,319 @@
+package report
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/platform/telegram"
+)
+
+// DoctorCommands carries out the staff chat commands: passing a question to a
+// running interview, acknowledging a report and answering relayed messages
+type DoctorCommands interface {
+	ConsultationByTicket(ctx context.Context, ticket string) (uuid.UUID, error)
+	AskDoctorQuestion(ctx context.Context, consultationID uuid.UUID, authorID uuid.UUID, author, via, text string) (*consultation.DoctorQuestion, error)
+	AcknowledgeReport(ctx context.Context, consultationID uuid.UUID, doctorID uuid.UUID, doctor, via string) (*consultation.Consultation, error)
+	RelayReply(ctx context.Context, consultationID uuid.UUID, author, text string) (*consultation.Message, error)
+	EndBridge(ctx context.Context, consultationID uuid.UUID, author string) (*consultation.Consultation, error)
+}
+
+// PatientCertificates hands out certificates for the codes in the bot links
+// kiosks show to patients
+type PatientCertificates interface {
+	CertificateByCode(ctx context.Context, code string) ([]byte, string, error)
+}
+
+// PatientChats continues consultations handed off to the patient bot's chat
+type PatientChats interface {
+	ClaimTelegramChat(ctx context.Context, code string, chatID int64) (string, error)
+	TelegramTurn(ctx context.Context, chatID int64, text string) (<-chan string, error)
+}
+
+// How long a patient's reply may take to stream into the chat
+const patientReplyTimeout = 5 * time.Minute
+
+// telegramUpdate is the part of a Telegram update the bot reads
+type telegramUpdate struct {
+	Message *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		From struct {
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Username  string `json:"username"`
+		} `json:"from"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+const (
+	askUsage    = "Формат: /ask <талон или ID консультации> <вопрос>, например /ask 042 принимал ли он сегодня инсулин"
+	ackUsage    = "Формат: /ack <талон или ID консультации>, например /ack 042"
+	replyUsage  = "Формат: /reply <талон или ID консультации> <ответ>, например /reply 042 медсестра сейчас подойдёт"
+	resumeUsage = "Формат: /resume <талон или ID консультации>, например /resume 042"
+)
+
+// CommandHandler receives Telegram webhook updates. Only the doctor and nurse
+// station chats are served, including those of the location routes, and Telegram must send the secret given to
+// setWebhook in X-Telegram-Bot-Api-Secret-Token. The exceptions are patient
+// chats: "/start <code>", sent by any chat that opens a certificate link, is
+// answered with the patient's certificate, and "/start h_<code>" from a
+// handoff link moves the consultation into the chat, whose messages are then
+// answered by the assistant with the reply streamed in. "/ask 042 <question>" has
+// the assistant put the question to the patient with ticket 042, "/ack 042"
+// marks the report of ticket 042 as read; both are for the doctor chat.
+// While ticket 042 is relayed to staff, "/reply 042 <text>" answers the
+// patient and "/resume 042" hands them back to the assistant.
+func (s *Service) CommandHandler(commands DoctorCommands, certificates PatientCertificates, chats PatientChats, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var update telegramUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+		// Telegram retries anything but 200, so ignored updates are acknowledged too
+		w.WriteHeader(http.StatusOK)
+
+		msg := update.Message
+		if msg == nil {
+			return
+		}
+		if code, ok := strings.CutPrefix(strings.TrimSpace(msg.Text), "/start "); ok {
+			if handoff, ok := consultation.TelegramHandoffCode(code); ok {
+				s.claimChat(r.Context(), chats, msg.Chat.ID, handoff)
+				return
+			}
+			s.sendCertificate(r.Context(), certificates, msg.Chat.ID, code)
+			return
+		}
+		known, doctorChat := s.staffChat(msg.Chat.ID)
+		if !known {
+			if text := strings.TrimSpace(msg.Text); text != "" && !strings.HasPrefix(text, "/") {
+				s.answerPatient(chats, msg.Chat.ID, text)
+			}
+			return
+		}
+		command, args, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+		command, _, _ = strings.Cut(command, "@") // "/ask@clinic_bot" in group chats
+		switch command {
+		case "/reply", "/resume":
+		case "/ask", "/ack":
+			if !doctorChat {
+				return
+			}
+		default:
+			return
+		}
+
+		author := strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+		if msg.From.Username != "" {
+			author += " (@" + msg.From.Username + ")"
+		}
+		author = strings.TrimSpace(author)
+		var reply string
+		switch command {
+		case "/ask":
+			reply = s.ask(r.Context(), commands, author, args)
+		case "/ack":
+			reply = s.ack(r.Context(), commands, author, args)
+		case "/reply":
+			reply = s.relayReply(r.Context(), commands, author, args)
+		case "/resume":
+			reply = s.resume(r.Context(), commands, author, args)
+		}
+		if err := s.tgClient.SendMessage(msg.Chat.ID, reply); err != nil {
+			fmt.Printf("Failed to answer %s in chat %d: %v\n", command, msg.Chat.ID, err)
+		}
+	})
+}
+
+// ask runs "/ask" and returns the reply for the chat
+func (s *Service) ask(ctx context.Context, commands DoctorCommands, author, args string) string {
+	ref, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if ref == "" || strings.TrimSpace(text) == "" {
+		return askUsage
+	}
+
+	id, reply := findConsultation(ctx, commands, ref)
+	if reply != "" {
+		return reply
+	}
+
+	q, err := commands.AskDoctorQuestion(ctx, id, uuid.Nil, author, consultation.QuestionViaTelegram, text)
+	switch {
+	case errors.Is(err, consultation.ErrInvalidQuestion):
+		return "Вопрос должен быть не длиннее 500 символов"
+	case errors.Is(err, consultation.ErrConsultationComplete):
+		return "Опрос уже завершён, вопрос не передан"
+	case err != nil:
+		return "Не удалось передать вопрос: " + err.Error()
+	}
+	return fmt.Sprintf("Ассистент задаст вопрос следующей репликой: «%s»", q.Text)
+}
+
+// ack runs "/ack" and returns the reply for the chat
+func (s *Service) ack(ctx context.Context, commands DoctorCommands, author, args string) string {
+	ref := strings.TrimSpace(args)
+	if ref == "" || strings.Contains(ref, " ") {
+		return ackUsage
+	}
+
+	id, reply := findConsultation(ctx, commands, ref)
+	if reply != "" {
+		return reply
+	}
+
+	_, err := commands.AcknowledgeReport(ctx, id, uuid.Nil, author, consultation.QuestionViaTelegram)
+	switch {
+	case errors.Is(err, consultation.ErrReportNotDispatched):
+		return "Отчёт по этой консультации ещё не отправлен"
+	case errors.Is(err, consultation.ErrAlreadyAcknowledged):
+		return "Отчёт уже отмечен как прочитанный"
+	case err != nil:
+		return "Не удалось отметить отчёт: " + err.Error()
+	}
+	return fmt.Sprintf("Отчёт по консультации %s отмечен как прочитанный", ref)
+}
+
+// relayReply runs "/reply" and returns the reply for the chat
+func (s *Service) relayReply(ctx context.Context, commands DoctorCommands, author, args string) string {
+	ref, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if ref == "" || strings.TrimSpace(text) == "" {
+		return replyUsage
+	}
+
+	id, reply := findConsultation(ctx, commands, ref)
+	if reply != "" {
+		return reply
+	}
+
+	_, err := commands.RelayReply(ctx, id, author, text)
+	switch {
+	case errors.Is(err, consultation.ErrInvalidRelayReply):
+		return "Ответ должен быть не длиннее 500 символов"
+	case errors.Is(err, consultation.ErrConsultationComplete):
+		return "Опрос уже завершён, ответ не передан"
+	case errors.Is(err, consultation.ErrNoBridge):
+		return "Пациент уже общается с ассистентом, ответ не передан"
+	case err != nil:
+		return "Не удалось передать ответ: " + err.Error()
+	}
+	return fmt.Sprintf("Ответ передан пациенту с талоном %s", ref)
+}
+
+// resume runs "/resume" and returns the reply for the chat
+func (s *Service) resume(ctx context.Context, commands DoctorCommands, author, args string) string {
+	ref := strings.TrimSpace(args)
+	if ref == "" || strings.Contains(ref, " ") {
+		return resumeUsage
+	}
+
+	id, reply := findConsultation(ctx, commands, ref)
+	if reply != "" {
+		return reply
+	}
+
+	_, err := commands.EndBridge(ctx, id, author)
+	switch {
+	case errors.Is(err, consultation.ErrNoBridge):
+		return "Пациент уже общается с ассистентом"
+	case err != nil:
+		return "Не удалось вернуть пациента ассистенту: " + err.Error()
+	}
+	return fmt.Sprintf("Следующее сообщение пациента с талоном %s получит ассистент", ref)
+}
+
+// findConsultation resolves a ticket or consultation ID, or returns the reply
+// explaining why it could not
+func findConsultation(ctx context.Context, commands DoctorCommands, ref string) (uuid.UUID, string) {
+	id, err := uuid.Parse(ref)
+	if err == nil {
+		return id, ""
+	}
+	if id, err = commands.ConsultationByTicket(ctx, ref); err != nil {
+		if errors.Is(err, consultation.ErrUnknownTicket) {
+			return uuid.Nil, fmt.Sprintf("Консультация с талоном %s не найдена", ref)
+		}
+		return uuid.Nil, "Не удалось найти консультацию: " + err.Error()
+	}
+	return id, ""
+}
+
+// sendCertificate answers "/start <code>" with the certificate, in whatever
+// chat the patient opened the bot link
+func (s *Service) sendCertificate(ctx context.Context, certificates PatientCertificates, chatID int64, code string) {
+	data, fileName, err := certificates.CertificateByCode(ctx, code)
+	if err != nil {
+		fmt.Printf("No certificate for code %q from chat %d: %v\n", code, chatID, err)
+		if err := s.tgClient.SendMessage(chatID, "Ссылка устарела или неверна. Попросите новую на экране киоска."); err != nil {
+			fmt.Printf("Failed to answer /start in chat %d: %v\n", chatID, err)
+		}
+		return
+	}
+	fmt.Printf("Sending patient certificate to Telegram chat %d...\n", chatID)
+	if err := s.tgClient.SendDocument(chatID, data, fileName); err != nil {
+		fmt.Printf("Failed to send the certificate to chat %d: %v\n", chatID, err)
+	}
+}
+
+// claimChat answers "/start h_<code>": the consultation moves from the kiosk
+// into the chat
+func (s *Service) claimChat(ctx context.Context, chats PatientChats, chatID int64, code string) {
+	reply := "Продолжим здесь, отвечайте сообщениями."
+	last, err := chats.ClaimTelegramChat(ctx, code, chatID)
+	switch {
+	case err != nil:
+		fmt.Printf("No handoff for code %q from chat %d: %v\n", code, chatID, err)
+		reply = "Ссылка устарела или неверна. Попросите новую на экране киоска."
+	case last != "":
+		reply += "\n\n" + last
+	}
+	if err := s.tgClient.SendMessage(chatID, reply); err != nil {
+		fmt.Printf("Failed to answer /start in chat %d: %v\n", chatID, err)
+	}
+}
+
+// answerPatient runs a message from a patient chat as a turn and streams the
+// reply into the chat. Chats without a consultation are ignored.
+func (s *Service) answerPatient(chats PatientChats, chatID int64, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), patientReplyTimeout)
+	chunks, err := chats.TelegramTurn(ctx, chatID, text)
+	if err != nil {
+		cancel()
+		reply := "Не получилось ответить на сообщение, попробуйте отправить его ещё раз."
+		switch {
+		case errors.Is(err, consultation.ErrNoTelegramChat):
+			return
+		case errors.Is(err, consultation.ErrConsultationComplete):
+			reply = "Опрос уже завершён, спасибо за ответы."
+		default:
+			fmt.Printf("Failed to answer patient chat %d: %v\n", chatID, err)
+		}
+		if err := s.tgClient.SendMessage(chatID, reply); err != nil {
+			fmt.Printf("Failed to answer patient chat %d: %v\n", chatID, err)
+		}
+		return
+	}
+	go func() {
+		defer cancel()
+		if err := s.tgClient.StreamReply(ctx, chatID, chunks, telegram.DefaultEditInterval); err != nil {
+			fmt.Printf("Failed to stream the reply to patient chat %d: %v\n", chatID, err)
+		}
+	}()
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// StoredDelivery is a failed delivery as it is kept in the database; the
+// consultation is loaded again on restore
+type StoredDelivery struct {
+	ConsultationID uuid.UUID
+	Error          string
+	FailedAt       time.Time
+	Recipients     []string
+}
+
+// DeliveryStore keeps the failed deliveries across restarts
+type DeliveryStore interface {
+	SaveFailed(ctx context.Context, d StoredDelivery) error
+	DeleteFailed(ctx context.Context, consultationID uuid.UUID) error
+	ListFailed(ctx context.Context) ([]StoredDelivery, error)
+}
+
+// ConsultationSource loads the consultations of restored deliveries
+type ConsultationSource interface {
+	GetConsultation(ctx context.Context, consultationID uuid.UUID) (*consultation.Consultation, error)
+}
+
+type postgresDeliveryStore struct {
+	db *sql.DB
+}
+
+func NewPostgresDeliveryStore(db *sql.DB) DeliveryStore {
+	return &postgresDeliveryStore{db: db}
+}
+
+func (s *postgresDeliveryStore) SaveFailed(ctx context.Context, d StoredDelivery) error {
+	recipients, err := json.Marshal(d.Recipients)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO report_failed_deliveries (consultation_id, error, recipients, failed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (consultation_id) DO UPDATE SET
+			error = EXCLUDED.error, recipients = EXCLUDED.recipients, failed_at = EXCLUDED.failed_at`,
+		d.ConsultationID, d.Error, recipients, d.FailedAt)
+	return err
+}
+
+func (s *postgresDeliveryStore) DeleteFailed(ctx context.Context, consultationID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM report_failed_deliveries WHERE consultation_id = $1`, consultationID)
+	return err
+}
+
+func (s *postgresDeliveryStore) ListFailed(ctx context.Context) ([]StoredDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT consultation_id, error, recipients, failed_at
+		FROM report_failed_deliveries ORDER BY failed_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []StoredDelivery
+	for rows.Next() {
+		var d StoredDelivery
+		var recipients []byte
+		if err := rows.Scan(&d.ConsultationID, &d.Error, &recipients, &d.FailedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(recipients, &d.Recipients); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recipients of %s: %w", d.ConsultationID, err)
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// RestoreFailedDeliveries loads the deliveries that failed before a restart,
+// so they show up for the station and the admin retry again. Deliveries of
+// consultations that are gone are dropped.
+func (s *Service) RestoreFailedDeliveries(ctx context.Context, consultations ConsultationSource) error {
+	if s.deliveries == nil {
+		return nil
+	}
+	stored, err := s.deliveries.ListFailed(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load failed deliveries: %w", err)
+	}
+
+	restored := make(map[uuid.UUID]FailedDelivery, len(stored))
+	for _, d := range stored {
+		c, err := consultations.GetConsultation(ctx, d.ConsultationID)
+		if errors.Is(err, consultation.ErrConsultationNotFound) || errors.Is(err, consultation.ErrErased) {
+			fmt.Printf("Dropping the failed delivery of consultation %s: %v\n", d.ConsultationID, err)
+			if err := s.deliveries.DeleteFailed(ctx, d.ConsultationID); err != nil {
+				fmt.Printf("Failed to drop the failed delivery of consultation %s: %v\n", d.ConsultationID, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load consultation %s of a failed delivery: %w", d.ConsultationID, err)
+		}
+		restored[d.ConsultationID] = FailedDelivery{Consultation: *c, Error: d.Error, FailedAt: d.FailedAt, Recipients: d.Recipients}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, f := range restored {
+		if _, ok := s.failed[id]; !ok {
+			s.failed[id] = f
+		}
+	}
+	fmt.Printf("Restored %d failed report deliveries\n", len(restored))
+	return nil
+}
+
+// recordDelivery keeps the outcome of a delivery in the store. A store error
+// only costs the retry after a restart, so it is logged.
+func (s *Service) recordDelivery(ctx context.Context, c consultation.Consultation, f *FailedDelivery) {
+	if s.deliveries == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	if f != nil {
+		err = s.deliveries.SaveFailed(ctx, StoredDelivery{ConsultationID: c.ID, Error: f.Error, FailedAt: f.FailedAt, Recipients: f.Recipients})
+	} else {
+		err = s.deliveries.DeleteFailed(ctx, c.ID)
+	}
+	if err != nil {
+		fmt.Printf("Failed to record the report delivery of consultation %s: %v\n", c.ID, err)
+	}
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// factGroup is the facts of one category
+type factGroup struct {
+	Category string
+	Facts    []consultation.MedicalFact
+}
+
+// groupFacts groups facts by category, categories in the order they first
+// came up in the interview
+func groupFacts(facts []consultation.MedicalFact) []factGroup {
+	var groups []factGroup
+	index := map[string]int{}
+	for _, f := range facts {
+		category := strings.TrimSpace(f.Category)
+		if category == "" {
+			category = "Прочее"
+		}
+		key := strings.ToLower(category)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, factGroup{Category: category})
+		}
+		groups[i].Facts = append(groups[i].Facts, f)
+	}
+	return groups
+}
+
+func factLine(f consultation.MedicalFact) string {
+	line := fmt.Sprintf("%s (Уверенность: %s)", f.Description, f.Confidence)
+	if f.InjectedBy != "" {
+		line = "[тест] " + line
+	}
+	return line
+}
+
+// translatedLine labels a translation with the doctor's language, e.g. "RU: ..."
+func translatedLine(t *consultation.Translation, text string) string {
+	return fmt.Sprintf("%s: %s", strings.ToUpper(t.To), text)
+}
+
+// summaryNotice explains that the doctor is reading a summary, not every fact
+func summaryNotice(summary, total int) string {
+	return fmt.Sprintf("Сводка ИИ: %d фактов вместо %d собранных. Полный список — во внутренней версии отчёта.", summary, total)
+}
@@ -0,0 +1,276 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// htmlSection is one heading of the report with its lines, or a table,
+// optionally followed by subheadings
+type htmlSection struct {
+	Title  string
+	Lines  []string
+	Table  [][]string
+	Groups []htmlSection
+}
+
+// htmlFactGroups turns facts into one subheading per category, each fact
+// followed by its translation if the report has one
+func htmlFactGroups(facts []consultation.MedicalFact, t *consultation.Translation) []htmlSection {
+	var groups []htmlSection
+	for _, g := range groupFacts(facts) {
+		sec := htmlSection{Title: g.Category}
+		for _, f := range g.Facts {
+			line := factLine(f)
+			if tr := t.Of(f.Description); tr != "" {
+				line += " — " + translatedLine(t, tr)
+			}
+			sec.Lines = append(sec.Lines, line)
+		}
+		groups = append(groups, sec)
+	}
+	return groups
+}
+
+type htmlReport struct {
+	Logo     template.URL
+	Brand    string // the tenant's header text
+	Footer   string
+	Preview  bool
+	Revision string
+	Header   []string
+	Sections []htmlSection
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Медицинский отчет (AI Agent)</title>
+<style>
+body { font-family: "DejaVu Sans", sans-serif; max-width: 50em; margin: 2em auto; line-height: 1.4; }
+.brand { display: flex; justify-content: space-between; white-space: pre-line; font-size: .9em; }
+.brand img { max-height: 4em; max-width: 12em; }
+footer { margin-top: 2em; border-top: 1px solid #999; font-size: .8em; white-space: pre-line; }
+.preview { background: #fff3cd; border: 1px solid #e0c36c; padding: .5em 1em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #999; padding: .2em .5em; text-align: left; }
+</style>
+</head>
+<body>
+{{if or .Logo .Brand}}<div class="brand"><div>{{.Brand}}</div>{{with .Logo}}<img src="{{.}}" alt="">{{end}}</div>{{end}}
+<h1>Медицинский отчет (AI Agent)</h1>
+{{if .Preview}}<p class="preview">Предварительный отчёт: опрос ещё идёт, врачу отчёт не отправлялся.</p>{{end}}
+{{with .Revision}}<p class="preview">{{.}}</p>{{end}}
+{{range .Header}}<p>{{.}}</p>
+{{end}}
+{{range .Sections}}<h2>{{.Title}}</h2>
+{{if .Table}}<table>
+{{range $i, $row := .Table}}<tr>{{range $row}}{{if eq $i 0}}<th>{{.}}</th>{{else}}<td>{{.}}</td>{{end}}{{end}}</tr>
+{{end}}</table>
+{{end}}{{if .Lines}}<ul>
+{{range .Lines}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}{{range .Groups}}<h3>{{.Title}}</h3>
+<ul>
+{{range .Lines}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}{{end}}
+{{with .Footer}}<footer>{{.}}</footer>{{end}}
+</body>
+</html>
+`))
+
+// RenderReportHTML builds the doctor's report as a web page, with the same
+// content as the PDF sent to Telegram. Incomplete consultations are marked as
+// a preview.
+func (s *Service) RenderReportHTML(c consultation.Consultation) ([]byte, error) {
+	brand := s.branding()
+	r := htmlReport{Logo: logoURL(brand), Brand: brand.Header, Footer: brand.Footer, Preview: !c.IsComplete}
+	if c.ReportRevision > 1 {
+		r.Revision = revisionNotice(c.ReportRevision)
+	}
+
+	if rel := c.Reliability; rel != nil {
+		line := fmt.Sprintf("Надёжность AI-анамнеза: %d/100 (%s). Уверенность по фактам: %d/100", rel.Score, translateReliability(rel.Level), rel.FactConfidence)
+		if len(rel.Notes) > 0 {
+			line += ". Ограничения: " + strings.Join(rel.Notes, ", ")
+		}
+		r.Header = append(r.Header, line)
+	}
+	r.Header = append(r.Header,
+		"Отчёт составлен ИИ по опросу пациента без осмотра. Все сведения требуют проверки врачом.",
+		fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006 15:04")),
+		fmt.Sprintf("ID Пациента: %s", c.PatientID),
+	)
+	if c.Device != nil {
+		place := c.Device.Place()
+		if place == "" {
+			place = "не указано"
+		}
+		r.Header = append(r.Header, fmt.Sprintf("Местонахождение пациента: %s (киоск %s)", place, c.Device.Name))
+	}
+	if c.ChiefComplaint != nil {
+		r.Header = append(r.Header, fmt.Sprintf("Основная жалоба: %s", c.ChiefComplaint.Text))
+	}
+	r.Header = append(r.Header, fmt.Sprintf("Эмоциональное состояние: %s", translateMood(c.CurrentMood)))
+	if c.Pediatric {
+		r.Header = append(r.Header, fmt.Sprintf("Педиатрическая консультация. Возраст ребёнка: %s, вес: %s", formatChildAge(c.Child), formatChildWeight(c.Child)))
+	}
+	r.Header = append(r.Header, redFlagLines(c.RedFlags)...)
+	if len(c.AbuseIncidents) > 0 {
+		r.Header = append(r.Header, abuseSummary(c.AbuseIncidents))
+	}
+	if line := injectedSummary(c); line != "" {
+		r.Header = append(r.Header, line)
+	}
+
+	if rs := c.RiskScreening; rs != nil {
+		sec := htmlSection{Title: "Скрининг суицидального риска"}
+		header := fmt.Sprintf("Уровень риска: %s. Триггер: «%s» (%s)", translateRiskLevel(rs.Level), rs.Trigger, rs.TriggeredAt.Format("15:04"))
+		if rs.Active {
+			header += ". Скрининг не завершён"
+		}
+		sec.Lines = append(sec.Lines, header)
+		for _, a := range rs.Answers {
+			answer := "Нет"
+			if a.Positive {
+				answer = "Да"
+			}
+			sec.Lines = append(sec.Lines, fmt.Sprintf("%s — %s («%s»)", a.Question, answer, a.Answer))
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if len(c.Vitals) > 0 {
+		r.Sections = append(r.Sections, htmlSection{Title: "Показатели (измерены приборами)", Table: vitalsTable(c.Vitals)})
+	}
+
+	// The page has no size limit, so a summary is shown in addition to the full list
+	facts := c.GeneralFacts()
+	if len(c.FactSummary) > 0 {
+		r.Sections = append(r.Sections, htmlSection{
+			Title:  "Собранные факты (сводка)",
+			Lines:  []string{summaryNotice(len(c.FactSummary), len(facts))},
+			Groups: htmlFactGroups(c.FactSummary, c.Translation),
+		})
+	}
+	factsSection := htmlSection{Title: "Собранные факты", Groups: htmlFactGroups(facts, c.Translation)}
+	if len(facts) == 0 {
+		factsSection.Lines = []string{"Факты не выявлены."}
+	}
+	r.Sections = append(r.Sections, factsSection)
+
+	if len(c.DoctorQuestions) > 0 {
+		r.Sections = append(r.Sections, htmlSection{Title: "Вопросы врача во время опроса", Lines: doctorQuestionLines(c.DoctorQuestions)})
+	}
+
+	if len(c.Questionnaires) > 0 {
+		sec := htmlSection{Title: "Опросники до визита"}
+		for _, q := range c.Questionnaires {
+			sec.Lines = append(sec.Lines, fmt.Sprintf("%s (заполнен %s)", q.Summary(), q.CompletedAt.Format("02.01.2006")))
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if c.Mode == consultation.ModeMedicationReconciliation || len(c.Medications) > 0 {
+		sec := htmlSection{Title: "Принимаемые препараты"}
+		if len(c.Medications) == 0 {
+			sec.Lines = []string{"Пациент не принимает препаратов."}
+		} else {
+			sec.Table = [][]string{{"Препарат", "Доза", "Схема приёма", "Соблюдение"}}
+			for _, m := range c.Medications {
+				sec.Table = append(sec.Table, []string{orDash(m.Name), orDash(m.Dose), orDash(m.Schedule), orDash(m.Adherence)})
+			}
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if len(c.PriorConditions) > 0 {
+		sec := htmlSection{Title: "Перенесённые и хронические заболевания"}
+		for _, p := range c.PriorConditions {
+			sec.Lines = append(sec.Lines, p.Describe())
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if len(c.EpidTopics) > 0 {
+		sec := htmlSection{Title: "Эпидемиологический анамнез"}
+		for _, t := range c.EpidTopics {
+			var answers []string
+			for _, f := range c.ExtractedFacts {
+				if topic := epidTopicOf(c, f); topic != nil && topic.ID == t.ID {
+					answers = append(answers, f.Description)
+				}
+			}
+			answer := "не выяснено"
+			if len(answers) > 0 {
+				answer = strings.Join(answers, "; ")
+			}
+			sec.Lines = append(sec.Lines, fmt.Sprintf("%s: %s", strings.TrimPrefix(t.Category, "Эпиданамнез: "), answer))
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if len(c.RequiredFields) > 0 {
+		sec := htmlSection{Title: fmt.Sprintf("Обязательные сведения (отделение %s)", c.Department)}
+		for _, f := range c.RequiredFields {
+			answer := strings.Join(c.RequiredAnswers(f), "; ")
+			if answer == "" {
+				answer = "НЕ ВЫЯСНЕНО"
+			}
+			sec.Lines = append(sec.Lines, fmt.Sprintf("%s: %s", f.Label, answer))
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	negatives := htmlSection{Title: "Отрицаемые симптомы"}
+	for _, n := range c.PertinentNegatives {
+		line := n.Symptom
+		if n.Context != "" {
+			line += ": " + n.Context
+		}
+		negatives.Lines = append(negatives.Lines, fmt.Sprintf("%s (Уверенность: %s)", line, n.Confidence))
+	}
+	if len(negatives.Lines) == 0 {
+		negatives.Lines = []string{"Не уточнялись."}
+	}
+	r.Sections = append(r.Sections, negatives)
+
+	if len(c.RuleFindings) > 0 {
+		sec := htmlSection{Title: "Находки по правилам (детерминированные)"}
+		for _, f := range c.RuleFindings {
+			line := fmt.Sprintf("[%s] %s (Триаж: %s). %s", f.RuleID, f.Title, translateTriage(f.Triage), f.Recommendation)
+			if f.Conflict {
+				line = fmt.Sprintf("[%s] КОНФЛИКТ С РЕКОМЕНДАЦИЯМИ: %s. %s", f.RuleID, f.Title, f.Recommendation)
+			}
+			sec.Lines = append(sec.Lines, line)
+		}
+		r.Sections = append(r.Sections, sec)
+	}
+
+	if c.Recommendations != "" {
+		r.Sections = append(r.Sections, htmlSection{Title: "Рекомендации и Анализ", Lines: []string{c.Recommendations}})
+	}
+	if c.Translation != nil && c.Translation.Recommendations != "" {
+		title := fmt.Sprintf("Перевод рекомендаций (%s → %s)", c.Translation.From, c.Translation.To)
+		r.Sections = append(r.Sections, htmlSection{Title: title, Lines: []string{c.Translation.Recommendations}})
+	}
+	if c.Booking != nil {
+		r.Sections = append(r.Sections, htmlSection{Title: "Повторный приём", Lines: []string{c.Booking.ReportLine()}})
+	}
+	if c.Recap.Answered() {
+		r.Sections = append(r.Sections, htmlSection{Title: "Подтверждение фактов", Lines: []string{c.Recap.ReportLine()}})
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
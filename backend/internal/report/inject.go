@@ -0,0 +1,30 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// injectedSummary warns that the interview contains synthetic turns from the
+// support console, "" if there are none
+func injectedSummary(c consultation.Consultation) string {
+	var turns int
+	var authors []string
+	seen := map[string]bool{}
+	for _, m := range c.History {
+		if m.Role != "user" || m.InjectedBy == "" {
+			continue
+		}
+		turns++
+		if !seen[m.InjectedBy] {
+			seen[m.InjectedBy] = true
+			authors = append(authors, m.InjectedBy)
+		}
+	}
+	if turns == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Тестовые реплики через консоль поддержки: %d (%s). Это не слова пациента; факты из них помечены «[тест]».", turns, strings.Join(authors, ", "))
+}
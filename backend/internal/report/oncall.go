@@ -0,0 +1,41 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// onCall is the chain critical alerts go down instead of the day roster,
+// nil during working hours or without one
+func (s *Service) onCall() []int64 {
+	if s.hours == nil || len(s.hours.OnCallChatIDs) == 0 || s.hours.Open(time.Now()) {
+		return nil
+	}
+	return s.hours.OnCallChatIDs
+}
+
+// reportOnCall is the chain the consultation's report goes down: after hours,
+// when it needs a doctor right away; nil otherwise
+func (s *Service) reportOnCall(c consultation.Consultation) []int64 {
+	if len(c.AlertReasons()) == 0 {
+		return nil
+	}
+	return s.onCall()
+}
+
+// sendOnCall tries the chats of the chain in order until one accepts
+func sendOnCall(chain []int64, send func(chatID int64) error) error {
+	var errs []error
+	for _, chatID := range chain {
+		err := send(chatID)
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("On-call chat %d failed, trying the next one: %v\n", chatID, err)
+		errs = append(errs, fmt.Errorf("chat %d: %w", chatID, err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/signintech/gopdf"
+)
+
+// Vertical limits of the report body on an A4 page, in points
+const (
+	pageTop    = 20.0
+	pageBottom = 800.0
+)
+
+// paginatedPDF starts a new page whenever a line break runs past the bottom
+// of the current one, so long reports are no longer cut off after page one
+type paginatedPDF struct {
+	gopdf.GoPdf
+}
+
+// loadFont registers DejaVu, which supports Cyrillic, trying the common
+// paths of Alpine Linux
+func (p *paginatedPDF) loadFont() error {
+	fontPaths := []string{
+		"/usr/share/fonts/ttf-dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	}
+
+	var fontErr error
+	for _, path := range fontPaths {
+		if fontErr = p.AddTTFFont("DejaVu", path); fontErr == nil {
+			fmt.Printf("Successfully loaded font from: %s\n", path)
+			return nil
+		}
+	}
+	fmt.Printf("Error loading font from all paths. Last error: %v\n", fontErr)
+	return fmt.Errorf("failed to load font for PDF. Please ensure ttf-dejavu is installed. Last error: %w", fontErr)
+}
+
+// Br moves to the next line, on a new page if the current one is full
+func (p *paginatedPDF) Br(h float64) {
+	p.GoPdf.Br(h)
+	if p.GetY() > pageBottom {
+		p.newPage()
+	}
+}
+
+// ensure moves to a new page unless height more points fit on the current one
+func (p *paginatedPDF) ensure(height float64) {
+	if p.GetY()+height > pageBottom && p.GetY() > pageTop {
+		p.newPage()
+	}
+}
+
+func (p *paginatedPDF) newPage() {
+	p.AddPage()
+	p.SetX(p.MarginLeft())
+	p.SetY(pageTop)
+}
+
+// numberPages writes "Стр. 1 из 3" at the bottom of every page of a multi-page
+// report. The font must be set before the call.
+func (p *paginatedPDF) numberPages() error {
+	total := p.GetNumberOfPages()
+	if total < 2 {
+		return nil
+	}
+	for i := 1; i <= total; i++ {
+		if err := p.SetPage(i); err != nil {
+			return err
+		}
+		p.SetX(500)
+		p.SetY(pageBottom + 20)
+		if err := p.Cell(nil, fmt.Sprintf("Стр. %d из %d", i, total)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package report
+
+import (
+	"fmt"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// doctorQuestionLines describes the questions doctors had the assistant ask,
+// with the patient's answers
+func doctorQuestionLines(questions []consultation.DoctorQuestion) []string {
+	var lines []string
+	for _, q := range questions {
+		lines = append(lines, fmt.Sprintf("%s (%s, %s): %s", q.Text, q.Author, q.CreatedAt.Format("15:04"), doctorAnswer(q)))
+	}
+	return lines
+}
+
+func doctorAnswer(q consultation.DoctorQuestion) string {
+	switch {
+	case q.AnsweredAt != nil:
+		return fmt.Sprintf("«%s»", q.Answer)
+	case q.AskedAt != nil:
+		return "пациент не ответил"
+	default:
+		return "вопрос не был задан"
+	}
+}
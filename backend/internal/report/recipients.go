@@ -0,0 +1,121 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Doctor is a registered recipient of reports outside the doctor chat, e.g. a
+// referring GP. Reports go to the Telegram chat if one is set, otherwise by email.
+type Doctor struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	TelegramChatID int64  `json:"telegram_chat_id,omitempty"`
+	Email          string `json:"email,omitempty"`
+}
+
+// Mailer sends reports to recipients without Telegram
+type Mailer interface {
+	SendDocument(to, subject, body string, fileData []byte, fileName string) error
+}
+
+// Anonymizer prepares the copy for recipients with the "anonymized" redaction
+type Anonymizer interface {
+	Anonymize(c consultation.Consultation) consultation.Consultation
+}
+
+// LoadDoctors reads the doctor registry from a JSON list. Without a path the
+// registry is empty and consultations cannot name recipients.
+func LoadDoctors(path string) ([]Doctor, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doctors []Doctor
+	if err := json.Unmarshal(data, &doctors); err != nil {
+		return nil, fmt.Errorf("invalid doctor registry: %w", err)
+	}
+	ids := map[string]bool{}
+	for i := range doctors {
+		d := &doctors[i]
+		d.ID = strings.TrimSpace(d.ID)
+		d.Email = strings.TrimSpace(d.Email)
+		if d.ID == "" {
+			return nil, fmt.Errorf("doctor %d has no id", i+1)
+		}
+		if ids[d.ID] {
+			return nil, fmt.Errorf("doctor %q is listed twice", d.ID)
+		}
+		ids[d.ID] = true
+		if d.TelegramChatID == 0 && d.Email == "" {
+			return nil, fmt.Errorf("doctor %q needs telegram_chat_id or email", d.ID)
+		}
+	}
+	return doctors, nil
+}
+
+// KnownDoctor implements consultation.ReportService
+func (s *Service) KnownDoctor(id string) bool {
+	_, ok := s.doctors[id]
+	return ok
+}
+
+// sendToRecipients sends the report to the consultation's named recipients,
+// each with its redaction. With only set, the others already have it. The
+// recipients that failed are returned for a retry.
+func (s *Service) sendToRecipients(ctx context.Context, c consultation.Consultation, only []string) ([]string, error) {
+	var failed []string
+	var errs []error
+	for _, r := range c.Recipients {
+		if only != nil && !slices.Contains(only, r.DoctorID) {
+			continue
+		}
+		if err := s.sendToRecipient(c, r); err != nil {
+			fmt.Printf("Error sending report to %s: %v\n", r.DoctorID, err)
+			failed = append(failed, r.DoctorID)
+			errs = append(errs, fmt.Errorf("recipient %s: %w", r.DoctorID, err))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+func (s *Service) sendToRecipient(c consultation.Consultation, r consultation.ReportRecipient) error {
+	d, ok := s.doctors[r.DoctorID]
+	if !ok {
+		// The registry changed since the consultation was created
+		return fmt.Errorf("doctor is no longer in the registry")
+	}
+	if r.Redaction == consultation.RedactionAnonymized {
+		c = s.anon.Anonymize(c)
+	}
+	data, err := s.RenderReport(c, false)
+	if err != nil {
+		return err
+	}
+	fileName := reportFileName(c)
+
+	if d.TelegramChatID != 0 {
+		fmt.Printf("Sending PDF document to %s in Telegram chat %d...\n", d.ID, d.TelegramChatID)
+		return s.tgClient.SendDocument(d.TelegramChatID, data, fileName)
+	}
+	if s.mailer == nil {
+		return fmt.Errorf("email is not configured")
+	}
+	fmt.Printf("Sending PDF document to %s by email...\n", d.ID)
+	body := "Отчёт AI-ассистента по опросу пациента во вложении. Все сведения требуют проверки врачом."
+	subject := "Медицинский отчёт (AI Agent)"
+	if name := s.branding().DisplayName; name != "" {
+		subject = "Медицинский отчёт: " + name
+	}
+	return s.mailer.SendDocument(d.Email, subject, body, data, fileName)
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// NotifyRedFlag sends an immediate text alert to the doctor chat, after hours
+// to the on-call chain. Like EscalateRisk it bypasses the PDF report.
+func (s *Service) NotifyRedFlag(ctx context.Context, c consultation.Consultation, flag consultation.RedFlag) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠️ СРОЧНО: %s\n", translateRedFlag(flag.Category))
+	if c.Ticket != 0 {
+		fmt.Fprintf(&b, "Талон: %s\n", consultation.FormatTicket(c.Ticket))
+	}
+	fmt.Fprintf(&b, "Консультация: %s\n", c.ID)
+	fmt.Fprintf(&b, "Пациент: %s\n", c.PatientID)
+	if d := c.Device; d != nil {
+		fmt.Fprintf(&b, "Место: %s (киоск %s)\n", d.Place(), d.Name)
+	}
+	fmt.Fprintf(&b, "Признак: %s\n", flag.Indicator)
+	b.WriteString("Подойдите к пациенту немедленно.")
+
+	if chain := s.onCall(); chain != nil {
+		fmt.Printf("Sending red flag alert for consultation %s to the on-call chain...\n", c.ID)
+		return sendOnCall(chain, func(chatID int64) error {
+			return s.tgClient.SendMessage(chatID, b.String())
+		})
+	}
+	chatID := s.doctorChat(c)
+	fmt.Printf("Sending red flag alert for consultation %s to chat %d...\n", c.ID, chatID)
+	return s.tgClient.SendMessage(chatID, b.String())
+}
+
+func translateRedFlag(category consultation.RedFlagCategory) string {
+	switch category {
+	case consultation.RedFlagCardiac:
+		return "признаки острого коронарного синдрома"
+	case consultation.RedFlagStroke:
+		return "признаки инсульта"
+	case consultation.RedFlagSuicidal:
+		return "суицидальные мысли"
+	default:
+		return string(category)
+	}
+}
+
+// redFlagLines lists the red flags for the report, one per line
+func redFlagLines(flags []consultation.RedFlag) []string {
+	lines := make([]string, len(flags))
+	for i, f := range flags {
+		line := fmt.Sprintf("Экстренный признак (%s): %s — %s", f.At.Format("15:04"), translateRedFlag(f.Category), f.Indicator)
+		if f.Notified {
+			line += ", врач оповещён"
+		}
+		lines[i] = line
+	}
+	return lines
+}
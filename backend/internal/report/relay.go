@@ -0,0 +1,29 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// RelayToStaff passes patient messages to the nurse station chat while the
+// assistant is unavailable. Staff answer with /reply and hand the patient
+// back with /resume.
+func (s *Service) RelayToStaff(ctx context.Context, c consultation.Consultation, messages []string) error {
+	ticket := consultation.FormatTicket(c.Ticket)
+
+	var b strings.Builder
+	if !c.Bridge.Active() {
+		fmt.Fprintf(&b, "Талон %s: ассистент недоступен, сообщения пациента идут сюда\n", ticket)
+	}
+	for _, m := range messages {
+		fmt.Fprintf(&b, "Талон %s: «%s»\n", ticket, m)
+	}
+	fmt.Fprintf(&b, "Ответить: /reply %s <текст>, вернуть ассистенту: /resume %s", ticket, ticket)
+
+	chatID := s.nurseChat(c)
+	fmt.Printf("Relaying messages of consultation %s to chat %d...\n", c.ID, chatID)
+	return s.tgClient.SendMessage(chatID, b.String())
+}
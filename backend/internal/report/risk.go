@@ -0,0 +1,59 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// EscalateRisk sends an immediate text alert to the crisis chat, after hours
+// to the on-call chain. It bypasses the PDF report and its delivery tracking
+// so nothing delays it.
+func (s *Service) EscalateRisk(ctx context.Context, c consultation.Consultation) error {
+	rs := c.RiskScreening
+	if rs == nil {
+		return nil
+	}
+
+	var b strings.Builder
+	if rs.Active {
+		b.WriteString("⚠️ СРОЧНО: риск суицида/самоповреждения\n")
+	} else {
+		fmt.Fprintf(&b, "⚠️ СРОЧНО: скрининг завершён, риск %s\n", translateRiskLevel(rs.Level))
+	}
+	fmt.Fprintf(&b, "Консультация: %s\n", c.ID)
+	fmt.Fprintf(&b, "Пациент: %s\n", c.PatientID)
+	fmt.Fprintf(&b, "Триггер: «%s»\n", rs.Trigger)
+	for _, a := range rs.Answers {
+		if a.Positive {
+			fmt.Fprintf(&b, "Да: %s\n", a.Question)
+		}
+	}
+	b.WriteString("Подойдите к пациенту немедленно.")
+
+	if chain := s.onCall(); chain != nil {
+		fmt.Printf("Sending risk alert for consultation %s to the on-call chain...\n", c.ID)
+		return sendOnCall(chain, func(chatID int64) error {
+			return s.tgClient.SendMessage(chatID, b.String())
+		})
+	}
+	fmt.Printf("Sending risk alert for consultation %s to chat %d...\n", c.ID, s.crisisChatID)
+	return s.tgClient.SendMessage(s.crisisChatID, b.String())
+}
+
+func translateRiskLevel(level consultation.RiskLevel) string {
+	switch level {
+	case consultation.RiskHigh:
+		return "высокий"
+	case consultation.RiskModerate:
+		return "умеренный"
+	case consultation.RiskLow:
+		return "низкий"
+	case consultation.RiskNone:
+		return "нет"
+	default:
+		return string(level)
+	}
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Route sends the reports, assignments and relayed messages of one waiting
+// area to its own chats. Location fields left empty match any value; a chat
+// left out falls back to the default one.
+type Route struct {
+	consultation.Location
+	DoctorChatID int64 `json:"doctor_chat_id,omitempty"`
+	NurseChatID  int64 `json:"nurse_chat_id,omitempty"`
+}
+
+// LoadRoutes reads the routes from a JSON list, the first matching route
+// wins. Without a path everything goes to the default chats.
+func LoadRoutes(path string) ([]Route, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("invalid location routes: %w", err)
+	}
+	for i, r := range routes {
+		if r.DoctorChatID == 0 && r.NurseChatID == 0 {
+			return nil, fmt.Errorf("route %d needs doctor_chat_id or nurse_chat_id", i+1)
+		}
+	}
+	return routes, nil
+}
+
+// doctorChat is the chat the consultation's reports and assignments go to
+func (s *Service) doctorChat(c consultation.Consultation) int64 {
+	loc := c.Location()
+	for _, r := range s.routes {
+		if r.DoctorChatID != 0 && r.Matches(loc) {
+			return r.DoctorChatID
+		}
+	}
+	return s.doctorChatID
+}
+
+// nurseChat is the chat the consultation's messages are relayed to
+func (s *Service) nurseChat(c consultation.Consultation) int64 {
+	loc := c.Location()
+	for _, r := range s.routes {
+		if r.NurseChatID != 0 && r.Matches(loc) {
+			return r.NurseChatID
+		}
+	}
+	return s.nurseChatID
+}
+
+// staffChat reports whether commands are taken from the chat, and whether it
+// is a doctor chat
+func (s *Service) staffChat(chatID int64) (known, doctor bool) {
+	if chatID == s.doctorChatID {
+		return true, true
+	}
+	for _, r := range s.routes {
+		if chatID == r.DoctorChatID {
+			return true, true
+		}
+	}
+	// The on-call doctors get critical reports after hours
+	if s.hours != nil {
+		for _, id := range s.hours.OnCallChatIDs {
+			if chatID == id {
+				return true, true
+			}
+		}
+	}
+	if chatID == s.nurseChatID {
+		return true, false
+	}
+	for _, r := range s.routes {
+		if chatID == r.NurseChatID {
+			return true, false
+		}
+	}
+	return false, false
+}
@@ -3,90 +3,523 @@ package report
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"medical-ai-agent/internal/consultation"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/signintech/gopdf"
 )
 
 type TelegramClient interface {
 	SendMessage(chatID int64, text string) error
 	SendDocument(chatID int64, fileData []byte, fileName string) error
+	StreamReply(ctx context.Context, chatID int64, chunks <-chan string, interval time.Duration) error
+}
+
+// FailedDelivery is a report that could not be delivered to the doctor
+type FailedDelivery struct {
+	Consultation consultation.Consultation `json:"consultation"`
+	Error        string                    `json:"error"`
+	FailedAt     time.Time                 `json:"failed_at"`
+	// Named recipients still waiting for the report; empty when the doctor
+	// chat failed and a retry sends it to everyone
+	Recipients []string `json:"recipients,omitempty"`
 }
 
 type Service struct {
 	tgClient     TelegramClient
 	doctorChatID int64
+	crisisChatID int64
+	nurseChatID  int64
+	routes       []Route
+	doctors      map[string]Doctor
+	mailer       Mailer
+	anon         Anonymizer
+	hours        *consultation.WorkingHours
+	brand        BrandingSource
+
+	mu         sync.Mutex
+	failed     map[uuid.UUID]FailedDelivery
+	deliveries DeliveryStore
 }
 
-func NewService(tg TelegramClient, doctorChatID int64) *Service {
+// NewService takes the doctor registry for named recipients; mailer may be
+// nil when no recipient has only an email. Patient messages relayed during
+// an LLM outage go to the nurse station chat. Routes override the doctor and
+// nurse chats per waiting area. Outside of working hours critical alerts go
+// to the on-call chain of hours instead; nil hours means always open.
+// brand gives the tenant's logo and texts for the reports and its name for
+// Telegram; nil leaves them unbranded. Failed deliveries are kept in
+// deliveries, see RestoreFailedDeliveries; nil keeps them in memory only.
+func NewService(tg TelegramClient, doctorChatID int64, crisisChatID int64, nurseChatID int64, routes []Route, doctors []Doctor, mailer Mailer, anon Anonymizer, hours *consultation.WorkingHours, brand BrandingSource, deliveries DeliveryStore) *Service {
+	if brand != nil {
+		tg = brandedTelegram{next: tg, brand: brand}
+	}
+	registry := make(map[string]Doctor, len(doctors))
+	for _, d := range doctors {
+		registry[d.ID] = d
+	}
 	return &Service{
 		tgClient:     tg,
 		doctorChatID: doctorChatID,
+		crisisChatID: crisisChatID,
+		nurseChatID:  nurseChatID,
+		routes:       routes,
+		doctors:      registry,
+		mailer:       mailer,
+		anon:         anon,
+		hours:        hours,
+		brand:        brand,
+		failed:       make(map[uuid.UUID]FailedDelivery),
+		deliveries:   deliveries,
 	}
 }
 
+// SendDoctorReport generates and sends the report to the doctor chat and the
+// consultation's named recipients, remembering failures so they can be retried
 func (s *Service) SendDoctorReport(ctx context.Context, c consultation.Consultation) error {
+	return s.deliver(ctx, c, nil)
+}
+
+// deliver sends the report to the doctor chat and then to the recipients, or
+// only to the pending recipients when the doctor chat already has it
+func (s *Service) deliver(ctx context.Context, c consultation.Consultation, pending []string) error {
+	var err error
+	var failed []string
+	if pending == nil {
+		err = s.sendDoctorReport(ctx, c)
+	}
+	if err == nil {
+		failed, err = s.sendToRecipients(ctx, c, pending)
+	}
+
+	var f *FailedDelivery
+	s.mu.Lock()
+	if err != nil {
+		f = &FailedDelivery{Consultation: c, Error: err.Error(), FailedAt: time.Now(), Recipients: failed}
+		s.failed[c.ID] = *f
+	} else {
+		delete(s.failed, c.ID)
+	}
+	s.mu.Unlock()
+	s.recordDelivery(ctx, c, f)
+	return err
+}
+
+// FailedDeliveries returns the reports that are still waiting for a successful delivery
+func (s *Service) FailedDeliveries() []FailedDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]FailedDelivery, 0, len(s.failed))
+	for _, f := range s.failed {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FailedAt.Before(list[j].FailedAt) })
+	return list
+}
+
+// ErrNoFailedDelivery is returned by RetryDelivery for a consultation whose
+// report is not waiting for a retry
+var ErrNoFailedDelivery = errors.New("no failed delivery for consultation")
+
+// RetryDelivery resends a previously failed report, to the recipients that did not get it
+func (s *Service) RetryDelivery(ctx context.Context, consultationID uuid.UUID) error {
+	s.mu.Lock()
+	f, ok := s.failed[consultationID]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w %s", ErrNoFailedDelivery, consultationID)
+	}
+	return s.deliver(ctx, f.Consultation, f.Recipients)
+}
+
+func (s *Service) sendDoctorReport(ctx context.Context, c consultation.Consultation) error {
+	data, err := s.RenderReport(c, false)
+	if err != nil {
+		return err
+	}
+
+	if chain := s.reportOnCall(c); chain != nil {
+		fmt.Printf("Sending PDF document of critical consultation %s to the on-call chain...\n", c.ID)
+		return sendOnCall(chain, func(chatID int64) error {
+			return s.tgClient.SendDocument(chatID, data, reportFileName(c))
+		})
+	}
+
+	chatID := s.doctorChat(c)
+	fmt.Printf("Sending PDF document to Telegram chat %d...\n", chatID)
+	if err := s.tgClient.SendDocument(chatID, data, reportFileName(c)); err != nil {
+		fmt.Printf("Error sending Telegram document: %v\n", err)
+		return err
+	}
+	fmt.Println("PDF report sent successfully.")
+	return nil
+}
+
+func reportFileName(c consultation.Consultation) string {
+	if c.ReportRevision > 1 {
+		return fmt.Sprintf("report_%s_rev%d.pdf", c.ID.String(), c.ReportRevision)
+	}
+	return fmt.Sprintf("report_%s.pdf", c.ID.String())
+}
+
+// RenderReport builds the doctor's PDF. The internal version, for staff only,
+// adds the consultation's tags and notes; the one sent to Telegram never does.
+func (s *Service) RenderReport(c consultation.Consultation, internal bool) ([]byte, error) {
 	fmt.Printf("Generating PDF report for consultation %s...\n", c.ID)
-	pdf := gopdf.GoPdf{}
+	pdf := &paginatedPDF{}
 	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
 	pdf.AddPage()
-
-	// Load Font (DejaVuSans supports Cyrillic)
-	// Try multiple common paths for Alpine Linux
-	fontPaths := []string{
-		"/usr/share/fonts/ttf-dejavu/DejaVuSans.ttf",
-		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
-		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
-	}
-
-	var fontErr error
-	fontLoaded := false
-	for _, path := range fontPaths {
-		if err := pdf.AddTTFFont("DejaVu", path); err == nil {
-			fmt.Printf("Successfully loaded font from: %s\n", path)
-			fontLoaded = true
-			break
-		} else {
-			fontErr = err
-		}
+	if err := pdf.loadFont(); err != nil {
+		return nil, err
 	}
 
-	if !fontLoaded {
-		fmt.Printf("Error loading font from all paths. Last error: %v\n", fontErr)
-		return fmt.Errorf("failed to load font for PDF. Please ensure ttf-dejavu is installed. Last error: %w", fontErr)
+	brand := s.branding()
+	if err := pdf.writeBrandHeader(brand); err != nil {
+		return nil, err
 	}
-
 	if err := pdf.SetFont("DejaVu", "", 20); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Header
 	pdf.Cell(nil, "Медицинский отчет (AI Agent)")
 	pdf.Br(30)
+	if !c.IsComplete {
+		if err := pdf.SetFont("DejaVu", "", 13); err != nil { return nil, err }
+		pdf.Cell(nil, "Предварительный отчёт: опрос ещё идёт")
+		pdf.Br(20)
+	}
+	if c.ReportRevision > 1 {
+		if err := pdf.SetFont("DejaVu", "", 13); err != nil { return nil, err }
+		pdf.Cell(nil, revisionNotice(c.ReportRevision))
+		pdf.Br(20)
+	}
+
+	// Reliability and disclaimer go right under the header so they are read first
+	if err := pdf.SetFont("DejaVu", "", 13); err != nil { return nil, err }
+	if r := c.Reliability; r != nil {
+		pdf.Cell(nil, fmt.Sprintf("Надёжность AI-анамнеза: %d/100 (%s)", r.Score, translateReliability(r.Level)))
+		pdf.Br(16)
+		if err := pdf.SetFont("DejaVu", "", 10); err != nil { return nil, err }
+		details := fmt.Sprintf("Уверенность по фактам: %d/100", r.FactConfidence)
+		if r.ModelConfidence >= 0 {
+			details += fmt.Sprintf(", самооценка модели: %d/100", r.ModelConfidence)
+		}
+		if len(r.Notes) > 0 {
+			details += ". Ограничения: " + strings.Join(r.Notes, ", ")
+		}
+		lines, _ := pdf.SplitText(details, 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+	}
+	if err := pdf.SetFont("DejaVu", "", 10); err != nil { return nil, err }
+	lines, _ := pdf.SplitText("Отчёт составлен ИИ по опросу пациента без осмотра. Все сведения требуют проверки врачом.", 500)
+	for _, l := range lines {
+		pdf.Cell(nil, l)
+		pdf.Br(12)
+	}
+	pdf.Br(15)
 
 	// Patient Info
-	if err := pdf.SetFont("DejaVu", "", 12); err != nil { return err }
+	if err := pdf.SetFont("DejaVu", "", 12); err != nil { return nil, err }
 	pdf.Cell(nil, fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006 15:04")))
 	pdf.Br(15)
 	pdf.Cell(nil, fmt.Sprintf("ID Пациента: %s", c.PatientID))
 	pdf.Br(15)
+	if c.Device != nil {
+		place := c.Device.Place()
+		if place == "" {
+			place = "не указано"
+		}
+		pdf.Cell(nil, fmt.Sprintf("Местонахождение пациента: %s (киоск %s)", place, c.Device.Name))
+		pdf.Br(15)
+	}
+	if c.ChiefComplaint != nil {
+		pdf.Cell(nil, fmt.Sprintf("Основная жалоба: %s", c.ChiefComplaint.Text))
+		pdf.Br(15)
+	}
 	pdf.Cell(nil, fmt.Sprintf("Эмоциональное состояние: %s", translateMood(c.CurrentMood)))
-	pdf.Br(25)
+	pdf.Br(15)
+	if c.Pediatric {
+		pdf.Cell(nil, "Педиатрическая консультация: ответы даны родителем/законным представителем")
+		pdf.Br(15)
+		pdf.Cell(nil, fmt.Sprintf("Возраст ребёнка: %s, вес: %s", formatChildAge(c.Child), formatChildWeight(c.Child)))
+		pdf.Br(15)
+	}
+	for _, l := range c.Links {
+		text := fmt.Sprintf("Связанный визит: %s (ID %s)", l.Describe(c.CreatedAt), l.ID)
+		lines, _ := pdf.SplitText(text, 500)
+		for _, line := range lines {
+			pdf.Cell(nil, line)
+			pdf.Br(12)
+		}
+		pdf.Br(3)
+	}
+	pdf.Br(10)
+
+	if line := injectedSummary(c); line != "" {
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		lines, _ := pdf.SplitText(line, 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+		pdf.Br(10)
+	}
+
+	// Risk screening goes first so it can't be missed
+	if rs := c.RiskScreening; rs != nil {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Скрининг суицидального риска:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		header := fmt.Sprintf("Уровень риска: %s. Триггер: «%s» (%s)", translateRiskLevel(rs.Level), rs.Trigger, rs.TriggeredAt.Format("15:04"))
+		if rs.Active {
+			header += ". Скрининг не завершён"
+		}
+		lines, _ := pdf.SplitText(header, 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+		pdf.Br(5)
+		for _, a := range rs.Answers {
+			answer := "Нет"
+			if a.Positive {
+				answer = "Да"
+			}
+			line := fmt.Sprintf("- %s — %s («%s»)", a.Question, answer, a.Answer)
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	if len(c.RedFlags) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, l := range redFlagLines(c.RedFlags) {
+			pdf.Cell(nil, l)
+			pdf.Br(15)
+		}
+		pdf.Br(5)
+	}
+
+	if len(c.AbuseIncidents) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		pdf.Cell(nil, abuseSummary(c.AbuseIncidents))
+		pdf.Br(20)
+	}
+
+	// Vitals from waiting-room devices
+	if len(c.Vitals) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Показатели (измерены приборами):")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 10); err != nil { return nil, err }
+		drawTable(pdf, []float64{150, 200, 150}, vitalsTable(c.Vitals))
+		pdf.Br(20)
+	}
 
-	// Facts
-	if err := pdf.SetFont("DejaVu", "", 14); err != nil { return err }
+	// Facts, grouped by category; epidemiology, the department checklist and
+	// vitals have their own sections. Long lists are replaced by the summary
+	// except in the internal version.
+	facts := c.GeneralFacts()
+	pdf.ensure(60)
+	if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
 	pdf.Cell(nil, "Собранные факты:")
 	pdf.Br(15)
 
-	if err := pdf.SetFont("DejaVu", "", 11); err != nil { return err }
-	if len(c.ExtractedFacts) == 0 {
+	if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+	if len(facts) == 0 {
 		pdf.Cell(nil, "- Факты не выявлены.")
 		pdf.Br(15)
 	}
-	for _, fact := range c.ExtractedFacts {
-		line := fmt.Sprintf("- [%s] %s (Уверенность: %s)", fact.Category, fact.Description, fact.Confidence)
+	if len(c.FactSummary) > 0 && !internal {
+		pdf.Cell(nil, summaryNotice(len(c.FactSummary), len(facts)))
+		pdf.Br(18)
+		facts = c.FactSummary
+	}
+	for _, group := range groupFacts(facts) {
+		pdf.ensure(40)
+		if err := pdf.SetFont("DejaVu", "", 12); err != nil { return nil, err }
+		pdf.Cell(nil, group.Category)
+		pdf.Br(15)
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, fact := range group.Facts {
+			lines, _ := pdf.SplitText("- "+factLine(fact), 500)
+			// The translation goes right under the original
+			if tr := c.Translation.Of(fact.Description); tr != "" {
+				translated, _ := pdf.SplitText("  "+translatedLine(c.Translation, tr), 490)
+				lines = append(lines, translated...)
+			}
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(3)
+		}
+		pdf.Br(5)
+	}
+	pdf.Br(15)
+
+	// Questions the doctor asked during the interview
+	if len(c.DoctorQuestions) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Вопросы врача во время опроса:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, line := range doctorQuestionLines(c.DoctorQuestions) {
+			lines, _ := pdf.SplitText("- "+line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	// Pre-visit questionnaires
+	if len(c.Questionnaires) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Опросники до визита:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, q := range c.Questionnaires {
+			line := fmt.Sprintf("- %s (заполнен %s)", q.Summary(), q.CompletedAt.Format("02.01.2006"))
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	// Medications
+	if c.Mode == consultation.ModeMedicationReconciliation || len(c.Medications) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Принимаемые препараты:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 10); err != nil { return nil, err }
+		if len(c.Medications) == 0 {
+			pdf.Cell(nil, "- Пациент не принимает препаратов.")
+			pdf.Br(15)
+		} else {
+			rows := [][]string{{"Препарат", "Доза", "Схема приёма", "Соблюдение"}}
+			for _, m := range c.Medications {
+				rows = append(rows, []string{orDash(m.Name), orDash(m.Dose), orDash(m.Schedule), orDash(m.Adherence)})
+			}
+			drawTable(pdf, []float64{140, 90, 135, 135}, rows)
+		}
+		pdf.Br(20)
+	}
+
+	// Prior diagnoses
+	if len(c.PriorConditions) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Перенесённые и хронические заболевания:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, p := range c.PriorConditions {
+			lines, _ := pdf.SplitText("- "+p.Describe(), 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	// Epidemiological history
+	if len(c.EpidTopics) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Эпидемиологический анамнез:")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, t := range c.EpidTopics {
+			answer := "не выяснено"
+			var answers []string
+			for _, f := range c.ExtractedFacts {
+				if topic := epidTopicOf(c, f); topic != nil && topic.ID == t.ID {
+					answers = append(answers, f.Description)
+				}
+			}
+			if len(answers) > 0 {
+				answer = strings.Join(answers, "; ")
+			}
+			line := fmt.Sprintf("- %s: %s", strings.TrimPrefix(t.Category, "Эпиданамнез: "), answer)
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	// Department's required information
+	if len(c.RequiredFields) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, fmt.Sprintf("Обязательные сведения (отделение %s):", c.Department))
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, f := range c.RequiredFields {
+			mark, answer := "[x]", strings.Join(c.RequiredAnswers(f), "; ")
+			if answer == "" {
+				mark, answer = "[ ]", "НЕ ВЫЯСНЕНО"
+			}
+			line := fmt.Sprintf("%s %s: %s", mark, f.Label, answer)
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
+	// Pertinent negatives
+	if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+	pdf.Cell(nil, "Отрицаемые симптомы:")
+	pdf.Br(15)
+
+	if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+	if len(c.PertinentNegatives) == 0 {
+		pdf.Cell(nil, "- Не уточнялись.")
+		pdf.Br(15)
+	}
+	for _, n := range c.PertinentNegatives {
+		line := fmt.Sprintf("- %s", n.Symptom)
+		if n.Context != "" {
+			line += fmt.Sprintf(": %s", n.Context)
+		}
+		line += fmt.Sprintf(" (Уверенность: %s)", n.Confidence)
 		lines, _ := pdf.SplitText(line, 500)
 		for _, l := range lines {
 			pdf.Cell(nil, l)
@@ -96,12 +529,34 @@ func (s *Service) SendDoctorReport(ctx context.Context, c consultation.Consultat
 	}
 	pdf.Br(15)
 
+	// Rule-based findings
+	if len(c.RuleFindings) > 0 {
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Находки по правилам (детерминированные):")
+		pdf.Br(15)
+
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		for _, f := range c.RuleFindings {
+			line := fmt.Sprintf("- [%s] %s (Триаж: %s). %s", f.RuleID, f.Title, translateTriage(f.Triage), f.Recommendation)
+			if f.Conflict {
+				line = fmt.Sprintf("- [%s] КОНФЛИКТ С РЕКОМЕНДАЦИЯМИ: %s. %s", f.RuleID, f.Title, f.Recommendation)
+			}
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+		pdf.Br(15)
+	}
+
 	// Recommendations
 	if c.Recommendations != "" {
-		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return err }
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
 		pdf.Cell(nil, "Рекомендации и Анализ:")
 		pdf.Br(15)
-		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return err }
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
 		
 		lines, _ := pdf.SplitText(c.Recommendations, 500)
 		for _, l := range lines {
@@ -110,24 +565,95 @@ func (s *Service) SendDoctorReport(ctx context.Context, c consultation.Consultat
 		}
 	}
 
+	if c.Translation != nil && c.Translation.Recommendations != "" {
+		pdf.Br(10)
+		if err := pdf.SetFont("DejaVu", "", 12); err != nil { return nil, err }
+		pdf.Cell(nil, fmt.Sprintf("Перевод рекомендаций (%s → %s):", c.Translation.From, c.Translation.To))
+		pdf.Br(15)
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		lines, _ := pdf.SplitText(c.Translation.Recommendations, 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+	}
+
+	// Follow-up visit booked after the interview
+	if c.Booking != nil {
+		pdf.Br(5)
+		lines, _ := pdf.SplitText(c.Booking.ReportLine(), 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+	}
+
+	// Whether the patient agreed with the read-back of the facts
+	if c.Recap.Answered() {
+		pdf.Br(5)
+		lines, _ := pdf.SplitText(c.Recap.ReportLine(), 500)
+		for _, l := range lines {
+			pdf.Cell(nil, l)
+			pdf.Br(12)
+		}
+	}
+
+	// Staff tags and notes, internal version only
+	if internal && (len(c.Tags) > 0 || len(c.Notes) > 0) {
+		pdf.Br(15)
+		if err := pdf.SetFont("DejaVu", "", 14); err != nil { return nil, err }
+		pdf.Cell(nil, "Служебные пометки (не для пациента):")
+		pdf.Br(15)
+		if err := pdf.SetFont("DejaVu", "", 11); err != nil { return nil, err }
+		if len(c.Tags) > 0 {
+			pdf.Cell(nil, "Теги: "+strings.Join(c.Tags, ", "))
+			pdf.Br(15)
+		}
+		for _, n := range c.Notes {
+			line := fmt.Sprintf("%s, %s: %s", n.Author, n.CreatedAt.Format("02.01.2006 15:04"), n.Text)
+			lines, _ := pdf.SplitText(line, 500)
+			for _, l := range lines {
+				pdf.Cell(nil, l)
+				pdf.Br(12)
+			}
+			pdf.Br(5)
+		}
+	}
+
 	// Footer
-	pdf.SetY(270)
-	if err := pdf.SetFont("DejaVu", "", 9); err != nil { return err }
+	if err := pdf.SetFont("DejaVu", "", 9); err != nil { return nil, err }
+	// Configuration the consultation ran on, to trace the report later
+	if c.Config != nil {
+		pdf.Br(15)
+		line := "Конфигурация: " + c.Config.String()
+		if c.Config.Tenant != "" {
+			line += " (" + c.Config.Tenant + ")"
+		}
+		pdf.Cell(nil, line)
+	}
+	if err := pdf.numberPages(); err != nil { return nil, err }
+	if err := pdf.writeBrandFooter(brand.Footer); err != nil { return nil, err }
 
 	// Write to buffer
 	var buf bytes.Buffer
 	if _, err := pdf.WriteTo(&buf); err != nil {
-		return fmt.Errorf("failed to write PDF: %w", err)
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
 	}
+	return buf.Bytes(), nil
+}
 
-	fileName := fmt.Sprintf("report_%s.pdf", c.ID.String())
-	fmt.Printf("Sending PDF document to Telegram chat %d...\n", s.doctorChatID)
-	if err := s.tgClient.SendDocument(s.doctorChatID, buf.Bytes(), fileName); err != nil {
-		fmt.Printf("Error sending Telegram document: %v\n", err)
-		return err
+// vitalsTable lists the measurements with a header row
+func vitalsTable(vitals []consultation.Measurement) [][]string {
+	rows := [][]string{{"Показатель", "Значение", "Время"}}
+	for _, m := range vitals {
+		rows = append(rows, []string{m.Name(), m.Reading(), m.MeasuredAt.Format("15:04")})
 	}
-	fmt.Println("PDF report sent successfully.")
-	return nil
+	return rows
+}
+
+// revisionNotice heads a report that replaces one already sent
+func revisionNotice(revision int) string {
+	return fmt.Sprintf("Редакция %d: заменяет ранее отправленный отчёт", revision)
 }
 
 func translateMood(mood consultation.EmotionalState) string {
@@ -144,3 +670,63 @@ func translateMood(mood consultation.EmotionalState) string {
 		return string(mood)
 	}
 }
+
+func formatChildAge(child *consultation.ChildInfo) string {
+	if child == nil || child.AgeMonths == 0 {
+		return "не указан"
+	}
+	if child.AgeMonths < 24 {
+		return fmt.Sprintf("%d мес.", child.AgeMonths)
+	}
+	return fmt.Sprintf("%d г. %d мес.", child.AgeMonths/12, child.AgeMonths%12)
+}
+
+func formatChildWeight(child *consultation.ChildInfo) string {
+	if child == nil || child.WeightKg == 0 {
+		return "не указан"
+	}
+	return fmt.Sprintf("%.1f кг", child.WeightKg)
+}
+
+// epidTopicOf returns the epidemiological topic a fact answers, if any
+func epidTopicOf(c consultation.Consultation, f consultation.MedicalFact) *consultation.EpidTopic {
+	for i := range c.EpidTopics {
+		if strings.EqualFold(c.EpidTopics[i].Category, f.Category) {
+			return &c.EpidTopics[i]
+		}
+	}
+	return nil
+}
+
+func translateReliability(level string) string {
+	switch level {
+	case "high":
+		return "высокая"
+	case "medium":
+		return "средняя"
+	case "low":
+		return "низкая"
+	default:
+		return level
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func translateTriage(triage string) string {
+	switch triage {
+	case "red":
+		return "красный"
+	case "yellow":
+		return "жёлтый"
+	case "green":
+		return "зелёный"
+	default:
+		return triage
+	}
+}
@@ -0,0 +1,55 @@
+package report
+
+// drawTable renders rows (the first one being the header) as a bordered table
+// starting at the current position, wrapping long cells onto several lines.
+// A row that does not fit on the page moves to the next one.
+func drawTable(pdf *paginatedPDF, widths []float64, rows [][]string) {
+	const lineHeight, padding = 12.0, 3.0
+
+	x0 := pdf.GetX()
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	pdf.Line(x0, pdf.GetY(), x0+total, pdf.GetY())
+
+	for _, row := range rows {
+		cells := make([][]string, len(row))
+		maxLines := 1
+		for i, text := range row {
+			lines, err := pdf.SplitText(text, widths[i]-2*padding)
+			if err != nil || len(lines) == 0 {
+				lines = []string{text}
+			}
+			cells[i] = lines
+			maxLines = max(maxLines, len(lines))
+		}
+		height := float64(maxLines)*lineHeight + 2*padding
+		if pdf.GetY()+height > pageBottom {
+			pdf.newPage()
+			pdf.Line(x0, pdf.GetY(), x0+total, pdf.GetY())
+		}
+		y := pdf.GetY()
+
+		x := x0
+		for i, lines := range cells {
+			for j, l := range lines {
+				pdf.SetX(x + padding)
+				pdf.SetY(y + padding + float64(j)*lineHeight)
+				pdf.Cell(nil, l)
+			}
+			x += widths[i]
+		}
+
+		x = x0
+		pdf.Line(x, y, x, y+height)
+		for _, w := range widths {
+			x += w
+			pdf.Line(x, y, x, y+height)
+		}
+		pdf.Line(x0, y+height, x0+total, y+height)
+
+		pdf.SetX(x0)
+		pdf.SetY(y + height)
+	}
+}
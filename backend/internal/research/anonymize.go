@@ -0,0 +1,300 @@
+package research
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Dates of one patient are shifted by the same offset within ±maxShiftDays,
+// so intervals between visits survive while real calendar dates do not
+const maxShiftDays = 180
+
+// Anonymizer turns consultations into research-safe records. Pseudonyms and date
+// shifts are keyed by a secret salt: stable across exports with the same salt,
+// irreversible without it.
+type Anonymizer struct {
+	salt []byte
+}
+
+func NewAnonymizer(salt []byte) *Anonymizer {
+	return &Anonymizer{salt: salt}
+}
+
+func (a *Anonymizer) digest(id uuid.UUID) []byte {
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write(id[:])
+	return mac.Sum(nil)
+}
+
+// Pseudonym maps an identifier to a stable random-looking UUID
+func (a *Anonymizer) Pseudonym(id uuid.UUID) uuid.UUID {
+	var p uuid.UUID
+	copy(p[:], a.digest(id))
+	p[6] = (p[6] & 0x0f) | 0x40 // version 4
+	p[8] = (p[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return p
+}
+
+// shift returns the patient's date offset
+func (a *Anonymizer) shift(patientID uuid.UUID) time.Duration {
+	n := binary.BigEndian.Uint32(a.digest(patientID)[28:])
+	days := int(n%(2*maxShiftDays+1)) - maxShiftDays
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Anonymize returns a copy of c with identifiers pseudonymized, dates shifted and
+// free text redacted. c itself is not modified.
+func (a *Anonymizer) Anonymize(c consultation.Consultation) consultation.Consultation {
+	offset := a.shift(c.PatientID)
+	shift := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		return t.Add(offset)
+	}
+	shiftPtr := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		shifted := shift(*t)
+		return &shifted
+	}
+
+	out := c
+	out.ID = a.Pseudonym(c.ID)
+	out.PatientID = a.Pseudonym(c.PatientID)
+	out.CreatedAt = shift(c.CreatedAt)
+	out.UpdatedAt = shift(c.UpdatedAt)
+	out.Recommendations = RedactText(c.Recommendations)
+
+	out.History = make([]consultation.Message, len(c.History))
+	for i, m := range c.History {
+		m.Content = RedactText(m.Content)
+		m.Timestamp = shift(m.Timestamp)
+		// Synthetic turns stay recognizable without naming the staff member
+		if m.InjectedBy != "" {
+			m.InjectedBy = "support"
+		}
+		if m.RequestedBy != "" {
+			m.RequestedBy = "doctor"
+		}
+		if m.RelayedBy != "" {
+			m.RelayedBy = "staff"
+		}
+		out.History[i] = m
+	}
+
+	out.ExtractedFacts = make([]consultation.MedicalFact, len(c.ExtractedFacts))
+	for i, f := range c.ExtractedFacts {
+		f.Description = RedactText(f.Description)
+		if f.InjectedBy != "" {
+			f.InjectedBy = "support"
+		}
+		out.ExtractedFacts[i] = f
+	}
+	if c.FactSummary != nil {
+		out.FactSummary = make([]consultation.MedicalFact, len(c.FactSummary))
+		for i, f := range c.FactSummary {
+			f.Description = RedactText(f.Description)
+			out.FactSummary[i] = f
+		}
+	}
+
+	if c.Translation != nil {
+		// Redacted like the originals, so the report still finds them
+		t := *c.Translation
+		t.Facts = make([]consultation.TranslatedText, len(c.Translation.Facts))
+		for i, f := range c.Translation.Facts {
+			t.Facts[i] = consultation.TranslatedText{Original: RedactText(f.Original), Text: RedactText(f.Text)}
+		}
+		t.Recommendations = RedactText(t.Recommendations)
+		t.CreatedAt = shift(t.CreatedAt)
+		out.Translation = &t
+	}
+
+	out.PertinentNegatives = make([]consultation.PertinentNegative, len(c.PertinentNegatives))
+	for i, n := range c.PertinentNegatives {
+		n.Context = RedactText(n.Context)
+		out.PertinentNegatives[i] = n
+	}
+
+	// Readings are kept for research, shifted like every other time; the device is dropped like the kiosk
+	out.Vitals = make([]consultation.Measurement, len(c.Vitals))
+	for i, m := range c.Vitals {
+		m.MeasuredAt = shift(m.MeasuredAt)
+		m.DeviceID = nil
+		out.Vitals[i] = m
+	}
+
+	out.PriorConditions = make([]consultation.PriorCondition, len(c.PriorConditions))
+	for i, p := range c.PriorConditions {
+		p.Since = RedactText(p.Since)
+		p.Treatment = RedactText(p.Treatment)
+		out.PriorConditions[i] = p
+	}
+
+	out.DoctorQuestions = make([]consultation.DoctorQuestion, len(c.DoctorQuestions))
+	for i, q := range c.DoctorQuestions {
+		q.Text = RedactText(q.Text)
+		q.Answer = RedactText(q.Answer)
+		q.AuthorID = a.Pseudonym(q.AuthorID)
+		q.Author = "doctor"
+		q.CreatedAt = shift(q.CreatedAt)
+		q.AskedAt = shiftPtr(q.AskedAt)
+		q.AnsweredAt = shiftPtr(q.AnsweredAt)
+		out.DoctorQuestions[i] = q
+	}
+
+	out.Questionnaires = make([]consultation.Questionnaire, len(c.Questionnaires))
+	for i, q := range c.Questionnaires {
+		q.CompletedAt = shift(q.CompletedAt)
+		q.ImportedAt = shift(q.ImportedAt)
+		out.Questionnaires[i] = q
+	}
+
+	if c.RiskScreening != nil {
+		rs := *c.RiskScreening
+		rs.Trigger = RedactText(rs.Trigger)
+		rs.TriggeredAt = shift(rs.TriggeredAt)
+		rs.CompletedAt = shiftPtr(rs.CompletedAt)
+		rs.Answers = make([]consultation.ScreeningAnswer, len(c.RiskScreening.Answers))
+		for i, ans := range c.RiskScreening.Answers {
+			ans.Answer = RedactText(ans.Answer)
+			rs.Answers[i] = ans
+		}
+		out.RiskScreening = &rs
+	}
+
+	if c.Quality != nil {
+		q := *c.Quality
+		q.ReviewedAt = shift(q.ReviewedAt)
+		q.UnansweredQuestions = make([]string, len(c.Quality.UnansweredQuestions))
+		for i, question := range c.Quality.UnansweredQuestions {
+			q.UnansweredQuestions[i] = RedactText(question)
+		}
+		q.Comment = RedactText(q.Comment)
+		out.Quality = &q
+	}
+
+	// Ticket numbers can be matched against the waiting room
+	out.Ticket = 0
+	// So can the kiosk's room and the time it was used
+	out.Device = nil
+	// And the room and bed the patient was put in
+	out.Assignment = nil
+	// Report recipients name staff outside the clinic
+	out.Recipients = nil
+	out.ReportDispatchedAt = shiftPtr(c.ReportDispatchedAt)
+	if c.Acknowledgment != nil {
+		ack := *c.Acknowledgment
+		ack.DoctorID = a.Pseudonym(ack.DoctorID)
+		ack.Doctor = "doctor"
+		ack.At = shift(ack.At)
+		out.Acknowledgment = &ack
+	}
+	if c.Bridge != nil {
+		b := *c.Bridge
+		b.StartedAt = shift(b.StartedAt)
+		b.EndedAt = shiftPtr(b.EndedAt)
+		if b.EndedBy != "" {
+			b.EndedBy = "staff"
+		}
+		out.Bridge = &b
+	}
+	if c.Recap != nil {
+		// The read-back and the corrections are the patient's own words
+		r := *c.Recap
+		r.Text = RedactText(r.Text)
+		r.Corrections = make([]string, len(c.Recap.Corrections))
+		for i, t := range c.Recap.Corrections {
+			r.Corrections[i] = RedactText(t)
+		}
+		r.AskedAt = shift(r.AskedAt)
+		if r.FinishedAt != nil {
+			f := shift(*r.FinishedAt)
+			r.FinishedAt = &f
+		}
+		out.Recap = &r
+	}
+	if c.Booking != nil {
+		// The scheduling system's reference leads back to the patient
+		b := *c.Booking
+		b.Reference = ""
+		if b.Slot != nil {
+			slot := shift(*b.Slot)
+			b.Slot = &slot
+		}
+		b.UpdatedAt = shift(b.UpdatedAt)
+		out.Booking = &b
+	}
+	if c.Visit != nil {
+		v := *c.Visit
+		v.UpdatedAt = shift(v.UpdatedAt)
+		out.Visit = &v
+	}
+
+	out.Links = make([]consultation.LinkedConsultation, len(c.Links))
+	for i, l := range c.Links {
+		l.ID = a.Pseudonym(l.ID)
+		l.VisitedAt = shift(l.VisitedAt)
+		l.LinkedAt = shift(l.LinkedAt)
+		l.ChiefComplaint = RedactText(l.ChiefComplaint)
+		out.Links[i] = l
+	}
+
+	if c.ChiefComplaint != nil {
+		cc := *c.ChiefComplaint
+		cc.Text = RedactText(cc.Text)
+		cc.DetectedAt = shift(cc.DetectedAt)
+		out.ChiefComplaint = &cc
+	}
+
+	out.SlowTurns = make([]consultation.SlowTurn, len(c.SlowTurns))
+	for i, t := range c.SlowTurns {
+		t.At = shift(t.At)
+		out.SlowTurns[i] = t
+	}
+
+	out.AbuseIncidents = make([]consultation.AbuseIncident, len(c.AbuseIncidents))
+	for i, inc := range c.AbuseIncidents {
+		inc.At = shift(inc.At)
+		out.AbuseIncidents[i] = inc
+	}
+
+	out.RedFlags = make([]consultation.RedFlag, len(c.RedFlags))
+	for i, f := range c.RedFlags {
+		f.Indicator = RedactText(f.Indicator)
+		f.At = shift(f.At)
+		out.RedFlags[i] = f
+	}
+
+	if c.Wearables != nil {
+		ws := *c.Wearables
+		ws.From = shift(ws.From)
+		ws.To = shift(ws.To)
+		ws.ImportedAt = shift(ws.ImportedAt)
+		ws.Falls = make([]time.Time, len(c.Wearables.Falls))
+		for i, f := range c.Wearables.Falls {
+			ws.Falls[i] = shift(f)
+		}
+		out.Wearables = &ws
+	}
+
+	if c.Review != nil {
+		r := *c.Review
+		if r.ReviewerID != nil {
+			reviewer := a.Pseudonym(*r.ReviewerID)
+			r.ReviewerID = &reviewer
+		}
+		r.ReviewedAt = shiftPtr(r.ReviewedAt)
+		out.Review = &r
+	}
+
+	return out
+}
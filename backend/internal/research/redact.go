@@ -0,0 +1,42 @@
+package research
+
+import "regexp"
+
+// entity is a class of identifier recognized in free text and the placeholder replacing it
+type entity struct {
+	label string
+	re    *regexp.Regexp
+}
+
+const (
+	capWord = `[А-ЯЁ][а-яё]+`
+	initial = `[А-ЯЁ]\.`
+)
+
+// Rule-based NER tuned for Russian intake dialogues. Order matters: more specific
+// entities run first so e.g. a phone number is not half-eaten as a document number.
+var entities = []entity{
+	{"[EMAIL]", regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+	{"[ТЕЛЕФОН]", regexp.MustCompile(`(\+7|8)[\s(-]*\d{3}[\s)-]*\d{3}[\s-]*\d{2}[\s-]*\d{2}`)},
+	{"[ДАТА]", regexp.MustCompile(`\d{1,2}[./]\d{1,2}[./]\d{2,4}`)},
+	{"[ДАТА]", regexp.MustCompile(`(?i)\d{1,2}\s+(января|февраля|марта|апреля|мая|июня|июля|августа|сентября|октября|ноября|декабря)(\s+\d{4}(\s*(г\.|года))?)?`)},
+	{"[НОМЕР]", regexp.MustCompile(`\d{3}-\d{3}-\d{3}\s?\d{2}`)}, // СНИЛС
+	{"[НОМЕР]", regexp.MustCompile(`\d[\d ]{5,}\d`)},             // passport, policy and other long numbers
+	{"[АДРЕС]", regexp.MustCompile(`(?i)(ул\.|улиц[аеыу]|проспект[аеу]?|пр-т|переул[оке]+|бульвар[аеу]?|шоссе)\s*[^,.\n]+(,\s*(д\.|дом)\s*\d+[0-9a-zа-яё/]*)?(,\s*(кв\.|квартира)\s*\d+)?`)},
+	// Names: full names with a patronymic and surnames with initials
+	{"[ИМЯ]", regexp.MustCompile(`(?:` + capWord + `\s+)?` + capWord + `\s+` + capWord + `(?:ович|евич|ич|овна|евна|ична|инична)`)},
+	{"[ИМЯ]", regexp.MustCompile(capWord + `\s+` + initial + `\s?` + initial + `|` + initial + `\s?` + initial + `\s?` + capWord)},
+}
+
+// introduction catches names given after phrases like "меня зовут"; only the name is replaced
+var introduction = regexp.MustCompile(`((?i:меня зовут|зовут|фамилия|имя|доктор[аеуом]*|врач[аеуом]*|dr\.?))(\s+)(` + capWord + `(?:\s+` + capWord + `){0,2})`)
+
+// RedactText replaces personal identifiers in free text with typed placeholders
+func RedactText(text string) string {
+	// Introductions first: the whole name after the cue, including a bare surname
+	text = introduction.ReplaceAllString(text, "${1}${2}[ИМЯ]")
+	for _, e := range entities {
+		text = e.re.ReplaceAllString(text, e.label)
+	}
+	return text
+}
@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+// Triage levels a rule can assign
+const (
+	TriageRed    = "red"
+	TriageYellow = "yellow"
+	TriageGreen  = "green"
+)
+
+//go:embed default.yaml
+var defaultRules []byte
+
+// Condition is a single check over the structured facts. Exactly one field is set.
+type Condition struct {
+	Symptom        []string `json:"symptom,omitempty"`          // any keyword found in a fact
+	Denied         []string `json:"denied,omitempty"`           // any keyword found in a pertinent negative
+	AgeOver        int      `json:"age_over,omitempty"`         // patient age in years is known and greater
+	AgeUnder       int      `json:"age_under,omitempty"`        // patient age in years is known and smaller
+	AgeMonthsUnder int      `json:"age_months_under,omitempty"` // patient age in months is known and smaller
+	Pediatric      *bool    `json:"pediatric,omitempty"`        // consultation is (or is not) pediatric
+	Allergy        []string `json:"allergy,omitempty"`          // any keyword found in an allergy fact
+	Recommended    []string `json:"recommended,omitempty"`      // any keyword found in the LLM recommendations
+	Vital          *Vital   `json:"vital,omitempty"`            // latest device measurement out of bounds
+}
+
+// Vital checks the latest measurement of a kind against a bound. Set above,
+// below or both; a rule holds if the value is beyond either.
+type Vital struct {
+	Kind  consultation.VitalKind `json:"kind"` // e.g. "spo2"; "diastolic" for the lower pressure
+	Above *float64               `json:"above,omitempty"`
+	Below *float64               `json:"below,omitempty"`
+}
+
+func (v Vital) holds(c consultation.Consultation) bool {
+	value, ok := c.LatestVital(v.Kind)
+	if !ok {
+		return false
+	}
+	return (v.Above != nil && value > *v.Above) || (v.Below != nil && value < *v.Below)
+}
+
+// Rule fires when all of its conditions hold. Conflict rules check the LLM
+// recommendations against allergies and conditions and need no triage level.
+type Rule struct {
+	ID        string      `json:"id"`
+	Title     string      `json:"title"`
+	When      []Condition `json:"when"`
+	Triage    string      `json:"triage,omitempty"`
+	Recommend string      `json:"recommend"`
+	Conflict  bool        `json:"conflict,omitempty"`
+}
+
+type file struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Parse reads a rule file written in YAML; files in the older JSON syntax read the same
+func Parse(data []byte) ([]Rule, error) {
+	var f file
+	if err := yamlconf.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid rule file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range f.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %q: id is required", r.Title)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("rule %s: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+		if len(r.When) == 0 {
+			return nil, fmt.Errorf("rule %s: at least one condition is required", r.ID)
+		}
+		for _, c := range r.When {
+			if c.Vital != nil && (c.Vital.Kind == "" || (c.Vital.Above == nil && c.Vital.Below == nil)) {
+				return nil, fmt.Errorf("rule %s: vital needs a kind and a bound", r.ID)
+			}
+		}
+		switch r.Triage {
+		case TriageRed, TriageYellow, TriageGreen:
+		case "":
+			if !r.Conflict {
+				return nil, fmt.Errorf("rule %s: triage is required", r.ID)
+			}
+		default:
+			return nil, fmt.Errorf("rule %s: unknown triage %q", r.ID, r.Triage)
+		}
+	}
+	return f.Rules, nil
+}
+
+// Default returns the built-in rule set
+func Default() []Rule {
+	rs, err := Parse(defaultRules)
+	if err != nil {
+		panic(fmt.Sprintf("built-in rules: %v", err))
+	}
+	return rs
+}
+
+// Load reads rules from path, falling back to the built-in set when path is empty
+func Load(path string) ([]Rule, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Engine evaluates rules deterministically over the Analyst's structured output
+type Engine struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+func NewEngine(rules []Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Rules returns the loaded rule set
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rules
+}
+
+// Validate checks a rule set version before it is stored, see configversions
+func (e *Engine) Validate(content json.RawMessage) error {
+	_, err := Parse(content)
+	return err
+}
+
+// Apply puts a rule set version into effect; consultations completing from
+// now on are evaluated against it
+func (e *Engine) Apply(ctx context.Context, content json.RawMessage) error {
+	rs, err := Parse(content)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.rules = rs
+	e.mu.Unlock()
+	return nil
+}
+
+// Evaluate returns a finding for every rule whose conditions all hold
+func (e *Engine) Evaluate(c consultation.Consultation) []consultation.RuleFinding {
+	var findings []consultation.RuleFinding
+	for _, r := range e.Rules() {
+		fired := true
+		for _, cond := range r.When {
+			if !cond.holds(c) {
+				fired = false
+				break
+			}
+		}
+		if fired {
+			findings = append(findings, consultation.RuleFinding{
+				RuleID:         r.ID,
+				Title:          r.Title,
+				Triage:         r.Triage,
+				Recommendation: r.Recommend,
+				Conflict:       r.Conflict,
+			})
+		}
+	}
+	return findings
+}
+
+func (c Condition) holds(cons consultation.Consultation) bool {
+	months, ageKnown := consultation.PatientAgeMonths(cons.ExtractedFacts)
+
+	switch {
+	case c.Pediatric != nil:
+		return cons.Pediatric == *c.Pediatric
+	case len(c.Symptom) > 0:
+		for _, f := range cons.ExtractedFacts {
+			if containsAny(f.Description, c.Symptom) {
+				return true
+			}
+		}
+		return false
+	case len(c.Denied) > 0:
+		for _, n := range cons.PertinentNegatives {
+			if containsAny(n.Symptom+" "+n.Context, c.Denied) {
+				return true
+			}
+		}
+		return false
+	case len(c.Allergy) > 0:
+		for _, f := range cons.ExtractedFacts {
+			if isAllergy(f) && containsAny(f.Description, c.Allergy) {
+				return true
+			}
+		}
+		return false
+	case len(c.Recommended) > 0:
+		return containsAny(cons.Recommendations, c.Recommended)
+	case c.Vital != nil:
+		return c.Vital.holds(cons)
+	case c.AgeOver > 0:
+		return ageKnown && months/12 > c.AgeOver
+	case c.AgeUnder > 0:
+		return ageKnown && months/12 < c.AgeUnder
+	case c.AgeMonthsUnder > 0:
+		return ageKnown && months < c.AgeMonthsUnder
+	}
+	return false
+}
+
+func containsAny(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, k := range keywords {
+		if strings.Contains(text, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllergy(f consultation.MedicalFact) bool {
+	return strings.EqualFold(f.Category, "Аллергия") || strings.Contains(strings.ToLower(f.Description), "аллерг")
+}
@@ -0,0 +1,94 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// Client books follow-up visits through the clinic's scheduling API. The URL
+// may also be a plain webhook: a response without a slot counts as a request
+// the registry handles later.
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// transport may be nil for the default one
+func NewClient(url, token string, timeout time.Duration, transport http.RoundTripper) *Client {
+	return &Client{
+		url:   url,
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+	}
+}
+
+type bookReq struct {
+	ConsultationID string `json:"consultation_id"`
+	PatientID      string `json:"patient_id"`
+	Specialty      string `json:"specialty"`
+	WithinDays     int    `json:"within_days,omitempty"`
+	Department     string `json:"department,omitempty"`
+}
+
+type bookResp struct {
+	Slot      *time.Time `json:"slot"`
+	Location  string     `json:"location"`
+	Reference string     `json:"reference"`
+}
+
+// Book implements consultation.Scheduler
+func (c *Client) Book(ctx context.Context, cons consultation.Consultation, f consultation.FollowUp) (*consultation.Appointment, error) {
+	jsonBody, err := json.Marshal(bookReq{
+		ConsultationID: cons.ID.String(),
+		PatientID:      cons.PatientID.String(),
+		Specialty:      f.Specialty,
+		WithinDays:     f.WithinDays,
+		Department:     cons.Department,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call scheduling api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("scheduling api returned status: %s, body: %s", resp.Status, string(body))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var out bookResp
+	if err := json.Unmarshal(body, &out); err != nil {
+		// Webhooks answer with whatever they like
+		return nil, nil
+	}
+	if out.Slot == nil {
+		return nil, nil
+	}
+	return &consultation.Appointment{Slot: *out.Slot, Location: out.Location, Reference: out.Reference}, nil
+}
@@ -0,0 +1,71 @@
+package station
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+)
+
+// ConsultationReader loads a single consultation
+type ConsultationReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*consultation.Consultation, error)
+}
+
+// ReportRenderer builds the doctor's report without sending it
+type ReportRenderer interface {
+	RenderReport(c consultation.Consultation, internal bool) ([]byte, error)
+	RenderReportHTML(c consultation.Consultation) ([]byte, error)
+}
+
+// GetReportPreview renders the report as it stands now, so a nurse can check
+// an interview in progress. Nothing is sent to the doctor. HTML by default,
+// ?format=pdf (or Accept: application/pdf) for the PDF.
+func (h *Handler) GetReportPreview(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consultation ID", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "application/pdf") {
+		format = "pdf"
+	}
+	if format != "" && format != "pdf" && format != "html" {
+		http.Error(w, "format must be html or pdf", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.consultations.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Consultation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if format == "pdf" {
+		data, err := h.render.RenderReport(*c, false)
+		if err != nil {
+			http.Error(w, "Failed to render report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="preview_%s.pdf"`, c.ID))
+		w.Write(data)
+		return
+	}
+
+	data, err := h.render.RenderReportHTML(*c)
+	if err != nil {
+		http.Error(w, "Failed to render report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(data)
+}
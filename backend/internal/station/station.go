@@ -0,0 +1,258 @@
+// Package station serves the nurse station dashboard: one endpoint that the
+// dashboard polls every few seconds instead of assembling its view from the
+// admin API, and a preview of the report for a consultation in progress.
+package station
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"medical-ai-agent/internal/consultation"
+	"medical-ai-agent/internal/openapi"
+	"medical-ai-agent/internal/report"
+)
+
+// The dashboard covers one shift
+const window = 12 * time.Hour
+
+// SummaryStore reads the consultation summary projection
+type SummaryStore interface {
+	Summaries(ctx context.Context, since time.Time) ([]consultation.Summary, error)
+}
+
+// DeliveryTracker exposes reports that failed to reach the doctor
+type DeliveryTracker interface {
+	FailedDeliveries() []report.FailedDelivery
+}
+
+type Handler struct {
+	store         SummaryStore
+	consultations ConsultationReader
+	reports       DeliveryTracker
+	render        ReportRenderer
+	hours         *consultation.WorkingHours
+}
+
+// NewHandler takes the clinic's working hours for the queue status; nil is always open
+func NewHandler(store SummaryStore, consultations ConsultationReader, reports DeliveryTracker, render ReportRenderer, hours *consultation.WorkingHours) *Handler {
+	return &Handler{store: store, consultations: consultations, reports: reports, render: render, hours: hours}
+}
+
+// ActiveConsultation is an interview in progress
+type ActiveConsultation struct {
+	ConsultationID uuid.UUID                   `json:"consultation_id"`
+	Ticket         string                      `json:"ticket"`
+	Mode           consultation.InterviewMode  `json:"mode"`
+	Mood           consultation.EmotionalState `json:"mood"`
+	ChiefComplaint string                      `json:"chief_complaint,omitempty"` // known from the first messages
+	Messages       int                         `json:"messages"`
+	Location       consultation.Location       `json:"location"`
+	StartedAt      time.Time                   `json:"started_at"`
+	LastActivity   time.Time                   `json:"last_activity"`
+}
+
+// Alert is a patient who needs a nurse right away
+type Alert struct {
+	ConsultationID uuid.UUID             `json:"consultation_id"`
+	Ticket         string                `json:"ticket"`
+	Reasons        []string              `json:"reasons"` // "risk_screening", "critical_mood", "red_flag"
+	ChiefComplaint string                `json:"chief_complaint,omitempty"`
+	Location       consultation.Location `json:"location"`
+	Since          time.Time             `json:"since"`
+}
+
+// WaitingPatient is a patient whose interview is over and who is not with the
+// doctor yet, with the room staff assigned
+type WaitingPatient struct {
+	ConsultationID uuid.UUID             `json:"consultation_id"`
+	Ticket         string                `json:"ticket"`
+	ChiefComplaint string                `json:"chief_complaint,omitempty"`
+	Room           string                `json:"room,omitempty"` // empty until assigned
+	Bed            string                `json:"bed,omitempty"`
+	Location       consultation.Location `json:"location"`
+	Since          time.Time             `json:"since"`
+}
+
+// Report statuses that need someone at the station to act
+const (
+	ReportPendingReview  = "pending_review"
+	ReportDeliveryFailed = "delivery_failed"
+)
+
+type UnacknowledgedReport struct {
+	ConsultationID uuid.UUID `json:"consultation_id"`
+	Ticket         string    `json:"ticket"`
+	Status         string    `json:"status"`
+	Since          time.Time `json:"since"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// QueueStats counts the waiting-room board states
+type QueueStats struct {
+	Interview          int `json:"interview"`
+	Waiting            int `json:"waiting"`
+	Called             int `json:"called"`
+	BeingSeen          int `json:"being_seen"`
+	LongestWaitMinutes int `json:"longest_wait_minutes"`
+	// Whether the clinic is within working hours
+	Clinic consultation.ClinicStatus `json:"clinic"`
+}
+
+type Overview struct {
+	Active                []ActiveConsultation   `json:"active"`
+	Alerts                []Alert                `json:"alerts"`
+	Waiting               []WaitingPatient       `json:"waiting"`
+	UnacknowledgedReports []UnacknowledgedReport `json:"unacknowledged_reports"`
+	Queue                 QueueStats             `json:"queue"`
+}
+
+// Build assembles the overview from the projection rows and the failed deliveries
+func Build(summaries []consultation.Summary, failed []report.FailedDelivery, now time.Time) Overview {
+	o := Overview{
+		Active:                []ActiveConsultation{},
+		Alerts:                []Alert{},
+		Waiting:               []WaitingPatient{},
+		UnacknowledgedReports: []UnacknowledgedReport{},
+	}
+
+	for _, s := range summaries {
+		ticket := consultation.FormatTicket(s.Ticket)
+
+		if !s.IsComplete {
+			o.Active = append(o.Active, ActiveConsultation{
+				ConsultationID: s.ID,
+				Ticket:         ticket,
+				Mode:           s.Mode,
+				Mood:           s.Mood,
+				ChiefComplaint: s.ChiefComplaint,
+				Messages:       s.Messages,
+				Location:       s.Location,
+				StartedAt:      s.CreatedAt,
+				LastActivity:   s.UpdatedAt,
+			})
+		}
+		if reasons := s.AlertReasons(); len(reasons) > 0 {
+			o.Alerts = append(o.Alerts, Alert{ConsultationID: s.ID, Ticket: ticket, Reasons: reasons, ChiefComplaint: s.ChiefComplaint, Location: s.Location, Since: s.UpdatedAt})
+		}
+		if s.ReviewStatus == consultation.ReviewPending {
+			o.UnacknowledgedReports = append(o.UnacknowledgedReports, UnacknowledgedReport{
+				ConsultationID: s.ID,
+				Ticket:         ticket,
+				Status:         ReportPendingReview,
+				Since:          s.UpdatedAt,
+			})
+		}
+
+		state := s.BoardEntry().State
+		if state == consultation.BoardWaiting || state == consultation.BoardCalled {
+			o.Waiting = append(o.Waiting, WaitingPatient{
+				ConsultationID: s.ID,
+				Ticket:         ticket,
+				ChiefComplaint: s.ChiefComplaint,
+				Room:           s.AssignedRoom,
+				Bed:            s.AssignedBed,
+				Location:       s.Location,
+				Since:          s.UpdatedAt,
+			})
+		}
+
+		switch state {
+		case consultation.BoardInterview:
+			o.Queue.Interview++
+		case consultation.BoardWaiting:
+			o.Queue.Waiting++
+			// Completion is the last update before the patient is called
+			if wait := int(now.Sub(s.UpdatedAt).Minutes()); wait > o.Queue.LongestWaitMinutes {
+				o.Queue.LongestWaitMinutes = wait
+			}
+		case consultation.BoardCalled:
+			o.Queue.Called++
+		case consultation.BoardBeingSeen:
+			o.Queue.BeingSeen++
+		}
+	}
+
+	for _, f := range failed {
+		o.UnacknowledgedReports = append(o.UnacknowledgedReports, UnacknowledgedReport{
+			ConsultationID: f.Consultation.ID,
+			Ticket:         consultation.FormatTicket(f.Consultation.Ticket),
+			Status:         ReportDeliveryFailed,
+			Since:          f.FailedAt,
+			Error:          f.Error,
+		})
+	}
+	return o
+}
+
+// AtLocation keeps the consultations of one waiting area; a zero filter keeps all
+func AtLocation(summaries []consultation.Summary, failed []report.FailedDelivery, loc consultation.Location) ([]consultation.Summary, []report.FailedDelivery) {
+	if loc.IsZero() {
+		return summaries, failed
+	}
+	var keptSummaries []consultation.Summary
+	for _, s := range summaries {
+		if loc.Matches(s.Location) {
+			keptSummaries = append(keptSummaries, s)
+		}
+	}
+	var keptFailed []report.FailedDelivery
+	for _, f := range failed {
+		if loc.Matches(f.Consultation.Location()) {
+			keptFailed = append(keptFailed, f)
+		}
+	}
+	return keptSummaries, keptFailed
+}
+
+// GetOverview returns the dashboard data, for one waiting area with ?building,
+// ?floor and ?department. The response carries an ETag, so a poll with an
+// unchanged overview costs a 304 and no body.
+func (h *Handler) GetOverview(w http.ResponseWriter, r *http.Request) {
+	summaries, err := h.store.Summaries(r.Context(), time.Now().Add(-window))
+	if err != nil {
+		http.Error(w, "Failed to load overview: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summaries, failed := AtLocation(summaries, h.reports.FailedDeliveries(), consultation.LocationFromQuery(r.URL.Query()))
+	o := Build(summaries, failed, time.Now())
+	o.Queue.Clinic = h.hours.Status(time.Now())
+	data, err := json.Marshal(o)
+	if err != nil {
+		http.Error(w, "Failed to encode overview", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/station/overview", h.GetOverview)
+	r.Get("/consultation/{id}/report/preview", h.GetReportPreview)
+}
+
+// Routes describes the station endpoints for the OpenAPI spec
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/api/station/overview", Summary: "Nurse station dashboard: active consultations, alerts, waiting patients with their rooms, unacknowledged reports and queue stats with the clinic's open status (staff login, supports If-None-Match, ?building, ?floor and ?department for one waiting area)", Tags: []string{"station"},
+			Response: Overview{}},
+		{Method: http.MethodGet, Path: "/api/consultation/{id}/report/preview", Summary: "Render the current report without sending it, also for incomplete consultations (staff login, ?format=pdf for PDF)", Tags: []string{"station"},
+			ResponseType: "text/html"},
+	}
+}
@@ -0,0 +1,379 @@
+package textnorm
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var months = []string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"}
+
+var (
+	// number matches integers and decimals with either separator, e.g. "37,5"
+	number = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	// ratio matches readings like blood pressure "120/80"
+	ratio = regexp.MustCompile(`(\d+)/(\d+)`)
+	// date matches "12.03.2024"
+	date = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4})\b`)
+	// dayMonth matches "12 марта"
+	dayMonth = regexp.MustCompile(`\b(\d{1,2})\s+(` + strings.Join(months, "|") + `)`)
+	// year matches "2024 года", "2024 г." and "2024 году"
+	year = regexp.MustCompile(`\b(\d{4})\s?(года|году|г\.)`)
+	// clock matches "8:05" and "21:30"
+	clock = regexp.MustCompile(`\b([01]?\d|2[0-3]):([0-5]\d)\b`)
+	// counted matches an integer followed by a word it has to agree with; the
+	// leading group keeps the fraction of "37,5 раз" out
+	counted = regexp.MustCompile(`(^|[^\d.,])(\d+)(\s+)(\p{L}+)`)
+)
+
+// unit is a dosage or measurement unit with its forms for 1, 2-4 and 5+
+type unit struct {
+	forms    [3]string
+	feminine bool
+}
+
+var (
+	milligram  = unit{[3]string{"миллиграмм", "миллиграмма", "миллиграммов"}, false}
+	unitDose   = unit{[3]string{"единица", "единицы", "единиц"}, true}
+	tablet     = unit{[3]string{"таблетка", "таблетки", "таблеток"}, true}
+	degree     = unit{[3]string{"градус", "градуса", "градусов"}, false}
+	mercury    = unit{[3]string{"миллиметр ртутного столба", "миллиметра ртутного столба", "миллиметров ртутного столба"}, false}
+	unitsTable = map[string]unit{
+		"мг":         milligram,
+		"мкг":        {[3]string{"микрограмм", "микрограмма", "микрограммов"}, false},
+		"г":          {[3]string{"грамм", "грамма", "граммов"}, false},
+		"кг":         {[3]string{"килограмм", "килограмма", "килограммов"}, false},
+		"мл":         {[3]string{"миллилитр", "миллилитра", "миллилитров"}, false},
+		"л":          {[3]string{"литр", "литра", "литров"}, false},
+		"ЕД":         unitDose,
+		"ед":         unitDose,
+		"МЕ":         {[3]string{"международная единица", "международные единицы", "международных единиц"}, true},
+		"табл":       tablet,
+		"таб":        tablet,
+		"капс":       {[3]string{"капсула", "капсулы", "капсул"}, true},
+		"кап":        {[3]string{"капля", "капли", "капель"}, true},
+		"ммоль/л":    {[3]string{"миллимоль на литр", "миллимоля на литр", "миллимолей на литр"}, false},
+		"мм рт. ст.": mercury,
+		"мм рт.ст.":  mercury,
+		"мм":         {[3]string{"миллиметр", "миллиметра", "миллиметров"}, false},
+		"см":         {[3]string{"сантиметр", "сантиметра", "сантиметров"}, false},
+		"уд/мин":     {[3]string{"удар в минуту", "удара в минуту", "ударов в минуту"}, false},
+		"ч":          {[3]string{"час", "часа", "часов"}, false},
+		"мин":        {[3]string{"минута", "минуты", "минут"}, true},
+		"°C":         degree,
+		"°С":         degree,
+		"°":          degree,
+		"%":          {[3]string{"процент", "процента", "процентов"}, false},
+	}
+	// quantity matches a number with a unit; the optional period belongs to
+	// abbreviations like "табл."
+	quantity = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s?(` + unitAlternation() + `)(\.?)`)
+)
+
+// Feminine nouns that numbers agree with ("две таблетки", "одну неделю")
+var feminineStems = []string{"таблет", "капсул", "капл", "капел", "ампул", "ложк", "ложе", "доз", "единиц", "недел", "минут", "секунд", "ночь", "ноч"}
+
+// Longest first, so "мм рт. ст." wins over "мм"
+func unitAlternation() string {
+	keys := make([]string, 0, len(unitsTable))
+	for k := range unitsTable {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for i, k := range keys {
+		keys[i] = regexp.QuoteMeta(k)
+	}
+	return strings.Join(keys, "|")
+}
+
+// expandNumbers verbalizes numbers for TTS: dates and times first, since the
+// plain number pattern would read their separators as decimals, then
+// quantities with units and counted nouns with agreement, then the rest
+func expandNumbers(text string) string {
+	text = date.ReplaceAllStringFunc(text, func(s string) string {
+		m := date.FindStringSubmatch(s)
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		y, _ := strconv.Atoi(m[3])
+		if day < 1 || day > 31 || month < 1 || month > 12 {
+			return s
+		}
+		return ordinalGenitive(day) + " " + months[month-1] + " " + ordinalGenitive(y) + " года"
+	})
+	text = dayMonth.ReplaceAllStringFunc(text, func(s string) string {
+		m := dayMonth.FindStringSubmatch(s)
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return s
+		}
+		return ordinalGenitive(day) + " " + m[2]
+	})
+	text = year.ReplaceAllStringFunc(text, func(s string) string {
+		m := year.FindStringSubmatch(s)
+		y, _ := strconv.Atoi(m[1])
+		if m[2] == "году" {
+			return ordinalPrepositional(y) + " году"
+		}
+		return ordinalGenitive(y) + " года"
+	})
+	text = clock.ReplaceAllStringFunc(text, func(s string) string {
+		m := clock.FindStringSubmatch(s)
+		h, _ := strconv.Atoi(m[1])
+		if m[2] == "00" {
+			return numberWords(int64(h), false) + " " + [3]string{"час", "часа", "часов"}[plural(h)]
+		}
+		return numberWords(int64(h), false) + " " + spellFeminine(m[2])
+	})
+	text = ratio.ReplaceAllString(text, "$1 на $2")
+	text = expandQuantities(text)
+	text = counted.ReplaceAllStringFunc(text, func(s string) string {
+		m := counted.FindStringSubmatch(s)
+		return m[1] + spellAgreeing(m[2], m[4]) + m[3] + m[4]
+	})
+	return number.ReplaceAllStringFunc(text, func(s string) string {
+		whole, frac, found := strings.Cut(strings.ReplaceAll(s, ",", "."), ".")
+		out := spell(whole, false)
+		if found {
+			out += " и " + spell(frac, false)
+		}
+		return out
+	})
+}
+
+// expandQuantities turns "500 мг" into "пятьсот миллиграммов" and "38,7°C"
+// into "тридцать восемь и семь градуса"
+func expandQuantities(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range quantity.FindAllStringSubmatchIndex(text, -1) {
+		digits, key, dot := text[loc[2]:loc[3]], text[loc[4]:loc[5]], text[loc[6]:loc[7]]
+		rest := text[loc[1]:]
+		// "5 мгновений" is not milligrams
+		if r := []rune(key); unicode.IsLetter(r[len(r)-1]) && dot == "" && startsWithWordChar(rest) {
+			continue
+		}
+
+		u := unitsTable[key]
+		whole, frac, isDecimal := strings.Cut(strings.ReplaceAll(digits, ",", "."), ".")
+		out := spell(whole, u.feminine)
+		form := u.forms[1] // a decimal takes the genitive singular
+		switch {
+		case whole == "1" && frac == "5":
+			out = "полтора"
+			if u.feminine {
+				out = "полторы"
+			}
+		case isDecimal:
+			out += " и " + spell(frac, u.feminine)
+		}
+		if n, err := strconv.Atoi(whole); err == nil && !isDecimal {
+			form = u.forms[plural(n)]
+		}
+		out += " " + form
+		// The abbreviation's period may also have ended the sentence
+		if dot != "" && endsSentence(rest) {
+			out += "."
+		}
+
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(out)
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// spellAgreeing spells an integer in the gender of the noun after it
+func spellAgreeing(digits, noun string) string {
+	lower := strings.ToLower(noun)
+	for _, stem := range feminineStems {
+		if !strings.HasPrefix(lower, stem) {
+			continue
+		}
+		out := spell(digits, true)
+		// Accusative "одну неделю", "одну таблетку"
+		if strings.HasSuffix(lower, "у") || strings.HasSuffix(lower, "ю") {
+			if out == "одна" || strings.HasSuffix(out, " одна") {
+				out = strings.TrimSuffix(out, "одна") + "одну"
+			}
+		}
+		return out
+	}
+	return spell(digits, false)
+}
+
+func startsWithWordChar(s string) bool {
+	for _, r := range s {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return false
+}
+
+// endsSentence reports whether s is empty or continues with a capitalized word
+func endsSentence(s string) bool {
+	t := strings.TrimLeft(s, " \t\n")
+	if t == "" {
+		return true
+	}
+	r := []rune(t)[0]
+	return t != s && unicode.IsUpper(r)
+}
+
+// spellFeminine reads minutes: "05" is "ноль пять", "21" is "двадцать одна"
+func spellFeminine(digits string) string {
+	return spell(digits, true)
+}
+
+func spell(digits string, feminine bool) string {
+	// Leading zeros are read digit by digit, like in "0,05"
+	var zeros []string
+	for len(digits) > 1 && digits[0] == '0' {
+		zeros = append(zeros, "ноль")
+		digits = digits[1:]
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n >= 1_000_000_000_000 {
+		// Too long to be a quantity (phone numbers, IDs): read digit by digit
+		var words []string
+		for _, d := range digits {
+			words = append(words, units[d-'0'])
+		}
+		return strings.Join(append(zeros, words...), " ")
+	}
+	return strings.Join(append(zeros, numberWords(n, feminine)), " ")
+}
+
+var (
+	units    = []string{"ноль", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	unitsFem = []string{"", "одна", "две"}
+	teens    = []string{"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать", "пятнадцать", "шестнадцать", "семнадцать", "восемнадцать", "девятнадцать"}
+	tens     = []string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят", "шестьдесят", "семьдесят", "восемьдесят", "девяносто"}
+	hundreds = []string{"", "сто", "двести", "триста", "четыреста", "пятьсот", "шестьсот", "семьсот", "восемьсот", "девятьсот"}
+
+	// Genitive ordinals, for dates: "двенадцатого марта"
+	ordUnits     = []string{"", "первого", "второго", "третьего", "четвёртого", "пятого", "шестого", "седьмого", "восьмого", "девятого"}
+	ordTeens     = []string{"десятого", "одиннадцатого", "двенадцатого", "тринадцатого", "четырнадцатого", "пятнадцатого", "шестнадцатого", "семнадцатого", "восемнадцатого", "девятнадцатого"}
+	ordTens      = []string{"", "", "двадцатого", "тридцатого", "сорокового", "пятидесятого", "шестидесятого", "семидесятого", "восьмидесятого", "девяностого"}
+	ordHundreds  = []string{"", "сотого", "двухсотого", "трёхсотого", "четырёхсотого", "пятисотого", "шестисотого", "семисотого", "восьмисотого", "девятисотого"}
+	ordThousands = []string{"", "тысячного", "двухтысячного", "трёхтысячного", "четырёхтысячного", "пятитысячного", "шеститысячного", "семитысячного", "восьмитысячного", "девятитысячного"}
+)
+
+// scales holds the forms for 1, 2-4 and 5+ of each power of a thousand
+var scales = []struct {
+	value    int64
+	forms    [3]string
+	feminine bool
+}{
+	{1_000_000_000, [3]string{"миллиард", "миллиарда", "миллиардов"}, false},
+	{1_000_000, [3]string{"миллион", "миллиона", "миллионов"}, false},
+	{1_000, [3]string{"тысяча", "тысячи", "тысяч"}, true},
+}
+
+// numberWords spells n in the nominative; feminine is the gender of the
+// counted noun ("одна таблетка", "две таблетки")
+func numberWords(n int64, feminine bool) string {
+	if n == 0 {
+		return units[0]
+	}
+	var words []string
+	for _, sc := range scales {
+		if group := n / sc.value; group > 0 {
+			// "тысяча", not "одна тысяча"
+			if group != 1 || !sc.feminine {
+				words = append(words, triple(int(group), sc.feminine)...)
+			}
+			words = append(words, sc.forms[plural(int(group))])
+			n %= sc.value
+		}
+	}
+	words = append(words, triple(int(n), feminine)...)
+	return strings.Join(words, " ")
+}
+
+// triple spells 1-999; feminine is for thousands ("одна тысяча", "две тысячи")
+func triple(n int, feminine bool) []string {
+	var words []string
+	if n >= 100 {
+		words = append(words, hundreds[n/100])
+		n %= 100
+	}
+	switch {
+	case n >= 10 && n < 20:
+		return append(words, teens[n-10])
+	case n >= 20:
+		words = append(words, tens[n/10])
+		n %= 10
+	}
+	if n > 0 {
+		if feminine && n <= 2 {
+			words = append(words, unitsFem[n])
+		} else {
+			words = append(words, units[n])
+		}
+	}
+	return words
+}
+
+// ordinalGenitive spells 1-9999 as a genitive ordinal. Only the last word is
+// ordinal: "две тысячи двадцать четвёртого".
+func ordinalGenitive(n int) string {
+	if n <= 0 || n > 9999 {
+		return numberWords(int64(n), false)
+	}
+	th, rest := n/1000, n%1000
+	if rest == 0 {
+		return ordThousands[th]
+	}
+	var words []string
+	if th > 0 {
+		words = append(words, numberWords(int64(th*1000), false))
+	}
+	h, r := rest/100, rest%100
+	if r == 0 {
+		return strings.Join(append(words, ordHundreds[h]), " ")
+	}
+	if h > 0 {
+		words = append(words, hundreds[h])
+	}
+	switch {
+	case r < 10:
+		words = append(words, ordUnits[r])
+	case r < 20:
+		words = append(words, ordTeens[r-10])
+	case r%10 == 0:
+		words = append(words, ordTens[r/10])
+	default:
+		words = append(words, tens[r/10], ordUnits[r%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// ordinalPrepositional turns the genitive ordinal into the prepositional one:
+// "в две тысячи двадцать четвёртом году"
+func ordinalPrepositional(n int) string {
+	s := ordinalGenitive(n)
+	if strings.HasSuffix(s, "его") {
+		return strings.TrimSuffix(s, "его") + "ем"
+	}
+	return strings.TrimSuffix(s, "ого") + "ом"
+}
+
+// plural picks the Russian form index for 1, 2-4 and 5+ (11-14 take the last)
+func plural(n int) int {
+	switch {
+	case n%100 >= 11 && n%100 <= 14:
+		return 2
+	case n%10 == 1:
+		return 0
+	case n%10 >= 2 && n%10 <= 4:
+		return 1
+	}
+	return 2
+}
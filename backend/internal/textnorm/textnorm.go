@@ -0,0 +1,218 @@
+// Package textnorm cleans Communicator replies: markdown and emojis would be
+// read aloud literally by TTS, and Silero skips digits and abbreviations.
+package textnorm
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"medical-ai-agent/internal/platform/yamlconf"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+// Rules selects the clean-up steps of one stage
+type Rules struct {
+	StripMarkup         bool `json:"strip_markup"`
+	RemoveEmoji         bool `json:"remove_emoji"`
+	ExpandNumbers       bool `json:"expand_numbers"` // numbers, dates, times and dosages in words
+	ExpandAbbreviations bool `json:"expand_abbreviations"`
+}
+
+// Config holds the rules for speech and for the saved transcript
+type Config struct {
+	Speech        Rules             `json:"speech"`
+	Transcript    Rules             `json:"transcript"`
+	Abbreviations map[string]string `json:"abbreviations"`
+}
+
+// Parse reads a config written in YAML
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yamlconf.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid text normalization config: %w", err)
+	}
+	for abbr := range cfg.Abbreviations {
+		if strings.TrimSpace(abbr) == "" {
+			return nil, fmt.Errorf("text normalization: empty abbreviation")
+		}
+	}
+	return &cfg, nil
+}
+
+// Default returns the built-in rules
+func Default() *Config {
+	cfg, err := Parse(defaultConfig)
+	if err != nil {
+		panic(fmt.Sprintf("built-in text normalization config: %v", err))
+	}
+	return cfg
+}
+
+// Load reads the config from path, falling back to the built-in rules when path is empty
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+type abbreviation struct {
+	re           *regexp.Regexp
+	replacement  string
+	endsSentence bool // the key ends with a period that may also end the sentence
+}
+
+type Normalizer struct {
+	cfg           *Config
+	abbreviations []abbreviation
+}
+
+func NewNormalizer(cfg *Config) *Normalizer {
+	n := &Normalizer{cfg: cfg}
+
+	// Longest first, so "°C" wins over "°"
+	keys := make([]string, 0, len(cfg.Abbreviations))
+	for k := range cfg.Abbreviations {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		n.abbreviations = append(n.abbreviations, abbreviation{
+			re:           abbreviationPattern(k),
+			replacement:  cfg.Abbreviations[k],
+			endsSentence: strings.HasSuffix(k, "."),
+		})
+	}
+	return n
+}
+
+// ForSpeech prepares a reply for TTS
+func (n *Normalizer) ForSpeech(text string) string {
+	return n.apply(n.cfg.Speech, text)
+}
+
+// ForTranscript prepares a reply for the consultation history
+func (n *Normalizer) ForTranscript(text string) string {
+	return n.apply(n.cfg.Transcript, text)
+}
+
+func (n *Normalizer) apply(r Rules, text string) string {
+	if r.StripMarkup {
+		text = stripMarkup(text)
+	}
+	if r.RemoveEmoji {
+		text = emoji.ReplaceAllString(text, "")
+	}
+	// Numbers go first so units after them are inflected; abbreviations
+	// then cover the units that stand alone
+	if r.ExpandNumbers {
+		text = expandNumbers(text)
+	}
+	if r.ExpandAbbreviations {
+		for _, a := range n.abbreviations {
+			text = a.expand(text)
+		}
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	// Removed emojis and markup leave gaps before punctuation
+	return spaceBeforePunct.ReplaceAllString(text, "$1")
+}
+
+// expand replaces every occurrence. An abbreviation ending a sentence also
+// carried its period, so the period is put back.
+func (a abbreviation) expand(text string) string {
+	return a.re.ReplaceAllStringFunc(text, func(m string) string {
+		g := a.re.FindStringSubmatch(m)
+		out := g[1] + a.replacement
+		if a.endsSentence && (g[2] == "" || strings.TrimSpace(g[2]) != "" && unicode.IsUpper([]rune(strings.TrimSpace(g[2]))[0])) {
+			out += "."
+		}
+		return out + g[2]
+	})
+}
+
+// abbreviationPattern matches k as a whole word. Go's \b only knows ASCII
+// letters, so the boundaries are explicit groups that are put back.
+func abbreviationPattern(k string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(k)
+	first, last := []rune(k)[0], []rune(k)[len([]rune(k))-1]
+	before, after := "()", "()"
+	if unicode.IsLetter(first) {
+		before = `(^|[^\p{L}\p{N}])`
+	}
+	switch {
+	case unicode.IsLetter(last):
+		after = `($|[^\p{L}\p{N}])`
+	case last == '.':
+		// Capture the next word's first letter to tell a sentence end
+		after = `($|\s+\p{L}|[^\p{L}\p{N}\s])`
+	}
+	return regexp.MustCompile(before + pattern + after)
+}
+
+var (
+	spaceBeforePunct = regexp.MustCompile(` +([.,!?:;])`)
+
+	emoji = regexp.MustCompile(`[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{FE0F}\x{200D}\x{20E3}]`)
+
+	codeFence  = regexp.MustCompile("```[a-z]*")
+	heading    = regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+`)
+	listMarker = regexp.MustCompile(`(?m)^[ \t]*(?:[-*+•]|\d+[.)])[ \t]+`)
+	quote      = regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`)
+	link       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	// RE2 has no backreferences, so every marker pair gets its own pattern
+	emphasis = []*regexp.Regexp{
+		regexp.MustCompile(`\*\*(.+?)\*\*`),
+		regexp.MustCompile(`__(.+?)__`),
+		regexp.MustCompile(`~~(.+?)~~`),
+		regexp.MustCompile(`\*(\S[^*\n]*?)\*`),
+		regexp.MustCompile("`([^`\n]+)`"),
+	}
+	strayMark = regexp.MustCompile("\\*+|`+|~~")
+)
+
+// stripMarkup removes markdown and joins lines, ending unpunctuated lines
+// (list items, headings) with a period so TTS pauses between them
+func stripMarkup(text string) string {
+	text = codeFence.ReplaceAllString(text, "")
+	text = heading.ReplaceAllString(text, "")
+	text = listMarker.ReplaceAllString(text, "")
+	text = quote.ReplaceAllString(text, "")
+	text = link.ReplaceAllString(text, "$1")
+	for _, re := range emphasis {
+		text = re.ReplaceAllString(text, "$1")
+	}
+	text = strayMark.ReplaceAllString(text, "")
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(lines) > 0 {
+			prev := lines[len(lines)-1]
+			if !strings.ContainsRune(".!?:;,", []rune(prev)[len([]rune(prev))-1]) {
+				lines[len(lines)-1] = prev + "."
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, " ")
+}
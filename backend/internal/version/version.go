@@ -0,0 +1,89 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"medical-ai-agent/internal/openapi"
+)
+
+// Set at build time:
+//
+//	go build -ldflags "-X medical-ai-agent/internal/version.Commit=$(git rev-parse HEAD) -X medical-ai-agent/internal/version.BuildTime=$(date -u +%FT%TZ)"
+var (
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Providers names the backends in use for each AI capability
+type Providers struct {
+	LLM string `json:"llm"` // providers in failover order, e.g. "deepseek (deepseek), local (ollama)"
+	TTS string `json:"tts"`
+	STT string `json:"stt"`
+}
+
+type Migrations struct {
+	Version uint   `json:"version"`
+	Dirty   bool   `json:"dirty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Info struct {
+	Commit         string            `json:"commit"`
+	BuildTime      string            `json:"build_time"`
+	GoVersion      string            `json:"go_version"`
+	Providers      Providers         `json:"providers"`
+	PromptVersions map[string]string `json:"prompt_versions"`
+	Migrations     Migrations        `json:"migrations"`
+
+	promptVersions func() map[string]string
+}
+
+// NewInfo fills in build metadata. Without ldflags the VCS data embedded
+// by the Go toolchain is used. promptVersions is read on every request, so
+// reloaded prompts show up.
+func NewInfo(providers Providers, promptVersions func() map[string]string, migrations Migrations) Info {
+	info := Info{
+		Commit:         Commit,
+		BuildTime:      BuildTime,
+		GoVersion:      runtime.Version(),
+		Providers:      providers,
+		Migrations:     migrations,
+		promptVersions: promptVersions,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
+
+func Handler(info Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := info
+		if info.promptVersions != nil {
+			current.PromptVersions = info.promptVersions()
+		}
+		json.NewEncoder(w).Encode(current)
+	}
+}
+
+// Routes describes the version endpoint for the OpenAPI spec
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/api/version", Summary: "Build and runtime information", Tags: []string{"system"},
+			Response: Info{}},
+	}
+}
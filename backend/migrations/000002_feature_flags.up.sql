@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS feature_flags (
+    flag TEXT NOT NULL,
+    tenant TEXT NOT NULL DEFAULT '',
+    percent INTEGER NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (flag, tenant)
+);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_consultations_facts ON consultations USING GIN (facts jsonb_path_ops);
@@ -0,0 +1,2 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS medications;
+ALTER TABLE consultations DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,2 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'standard';
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS medications JSONB;
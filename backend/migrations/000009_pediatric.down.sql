@@ -0,0 +1,2 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS child;
+ALTER TABLE consultations DROP COLUMN IF EXISTS pediatric;
@@ -0,0 +1,2 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS pediatric BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS child JSONB;
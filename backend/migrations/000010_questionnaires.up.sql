@@ -0,0 +1 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS questionnaires JSONB;
@@ -0,0 +1 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS epid_topics JSONB;
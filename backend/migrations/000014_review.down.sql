@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_consultations_review_pending;
+ALTER TABLE consultations DROP COLUMN IF EXISTS review;
@@ -0,0 +1,2 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS review JSONB;
+CREATE INDEX IF NOT EXISTS idx_consultations_review_pending ON consultations ((review->>'status')) WHERE review IS NOT NULL;
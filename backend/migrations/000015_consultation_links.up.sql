@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS consultation_links (
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    linked_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    relation TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consultation_id, linked_id)
+);
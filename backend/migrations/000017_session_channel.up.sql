@@ -0,0 +1 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS session_channel INTEGER NOT NULL DEFAULT 0;
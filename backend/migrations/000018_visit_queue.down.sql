@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_consultations_created_at;
+ALTER TABLE consultations DROP COLUMN IF EXISTS visit;
+ALTER TABLE consultations DROP COLUMN IF EXISTS ticket;
+DROP SEQUENCE IF EXISTS consultation_ticket_seq;
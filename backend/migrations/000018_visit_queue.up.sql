@@ -0,0 +1,4 @@
+CREATE SEQUENCE IF NOT EXISTS consultation_ticket_seq;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS ticket INTEGER DEFAULT nextval('consultation_ticket_seq');
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS visit JSONB;
+CREATE INDEX IF NOT EXISTS idx_consultations_created_at ON consultations (created_at);
@@ -0,0 +1 @@
+DROP VIEW IF EXISTS consultation_summaries;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_consultations_experiment;
+ALTER TABLE consultations DROP COLUMN IF EXISTS supervisor_rounds;
+ALTER TABLE consultations DROP COLUMN IF EXISTS arm;
+ALTER TABLE consultations DROP COLUMN IF EXISTS experiment;
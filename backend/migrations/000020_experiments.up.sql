@@ -0,0 +1,4 @@
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS experiment TEXT;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS arm TEXT;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS supervisor_rounds INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_consultations_experiment ON consultations (experiment, arm) WHERE experiment IS NOT NULL;
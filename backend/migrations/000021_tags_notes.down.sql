@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS consultation_notes;
+DROP TABLE IF EXISTS consultation_tags;
@@ -0,0 +1,21 @@
+-- Staff-only annotations. Kept out of the consultations row so that agent
+-- writes of a stale snapshot cannot drop them.
+CREATE TABLE IF NOT EXISTS consultation_tags (
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consultation_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_tags_tag ON consultation_tags(tag);
+
+CREATE TABLE IF NOT EXISTS consultation_notes (
+    id UUID PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    author_id UUID,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_notes_consultation ON consultation_notes(consultation_id, created_at);
@@ -0,0 +1,17 @@
+-- Stage timings of every Communicator turn. Slow turns (over the SLO) are
+-- attached to the consultation as diagnostics.
+CREATE TABLE IF NOT EXISTS turn_timings (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    stt_ms INTEGER,
+    llm_first_token_ms INTEGER,
+    llm_ms INTEGER NOT NULL,
+    tts_ms INTEGER,
+    total_ms INTEGER NOT NULL,
+    slo_ms INTEGER NOT NULL,
+    slow BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_turn_timings_consultation ON turn_timings(consultation_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_turn_timings_created ON turn_timings(created_at);
@@ -0,0 +1,21 @@
+DROP VIEW IF EXISTS consultation_summaries;
+
+CREATE VIEW consultation_summaries AS
+SELECT
+    id,
+    COALESCE(ticket, 0) AS ticket,
+    COALESCE(mode, 'standard') AS mode,
+    COALESCE(mood, '') AS mood,
+    COALESCE(is_complete, FALSE) AS is_complete,
+    CASE WHEN jsonb_typeof(history) = 'array' THEN jsonb_array_length(history) ELSE 0 END AS messages,
+    COALESCE(review->>'status', '') AS review_status,
+    COALESCE(visit->>'state', '') AS visit_state,
+    COALESCE(visit->>'room', '') AS room,
+    COALESCE((risk_screening->>'active')::BOOLEAN, FALSE) AS risk_active,
+    COALESCE(risk_screening->>'level', '') AS risk_level,
+    COALESCE(rule_findings @> '[{"triage": "red"}]', FALSE) AS red_flag,
+    created_at,
+    updated_at
+FROM consultations;
+
+ALTER TABLE consultations DROP COLUMN IF EXISTS chief_complaint;
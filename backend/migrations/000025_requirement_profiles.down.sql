@@ -0,0 +1,4 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS required_fields;
+ALTER TABLE consultations DROP COLUMN IF EXISTS department;
+
+DROP TABLE IF EXISTS requirement_profiles;
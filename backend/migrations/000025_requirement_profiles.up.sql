@@ -0,0 +1,11 @@
+-- Per-department lists of information the interview must collect
+CREATE TABLE IF NOT EXISTS requirement_profiles (
+    department TEXT PRIMARY KEY,
+    fields JSONB NOT NULL DEFAULT '[]',
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A consultation keeps the fields that applied when it was created
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS department TEXT;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS required_fields JSONB;
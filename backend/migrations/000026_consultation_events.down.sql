@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS consultation_events;
+DROP FUNCTION IF EXISTS consultation_events_immutable();
@@ -0,0 +1,22 @@
+-- Append-only change log for EVENT_SOURCING deployments. No foreign key:
+-- the record outlives the consultations row, e.g. after a purge.
+CREATE TABLE IF NOT EXISTS consultation_events (
+    seq BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_events_consultation ON consultation_events(consultation_id, seq);
+
+CREATE OR REPLACE FUNCTION consultation_events_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'consultation_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS consultation_events_immutable ON consultation_events;
+CREATE TRIGGER consultation_events_immutable
+    BEFORE UPDATE OR DELETE ON consultation_events
+    FOR EACH ROW EXECUTE FUNCTION consultation_events_immutable();
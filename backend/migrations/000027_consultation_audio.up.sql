@@ -0,0 +1,10 @@
+-- Session audio kept for review when the session_recording flag is on
+CREATE TABLE IF NOT EXISTS consultation_audio (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_audio_consultation ON consultation_audio(consultation_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS device;
+
+DROP TABLE IF EXISTS devices;
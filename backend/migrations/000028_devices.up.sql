@@ -0,0 +1,15 @@
+-- Registered kiosks; only a hash of the device key is stored
+CREATE TABLE IF NOT EXISTS devices (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    room TEXT NOT NULL DEFAULT '',
+    persona TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    volume DOUBLE PRECISION NOT NULL DEFAULT 0,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The kiosk a consultation was started on, copied at creation
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS device JSONB;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS patient_key_erasures;
+DROP TABLE IF EXISTS patient_keys;
@@ -0,0 +1,18 @@
+-- Per-patient data keys, wrapped with the master key. Deleting a key makes
+-- the patient's encrypted content unreadable everywhere it was copied.
+CREATE TABLE IF NOT EXISTS patient_keys (
+    id UUID PRIMARY KEY,
+    patient_id UUID NOT NULL UNIQUE,
+    wrapped_key BYTEA NOT NULL,
+    master_key_id TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Audit trail of erasures; holds no patient content
+CREATE TABLE IF NOT EXISTS patient_key_erasures (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id UUID NOT NULL,
+    key_id UUID NOT NULL,
+    erased_by TEXT NOT NULL DEFAULT '',
+    erased_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
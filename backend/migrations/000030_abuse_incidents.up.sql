@@ -0,0 +1,13 @@
+-- Abuse aimed at the assistant. Kept out of the consultations row so that
+-- agent writes of a stale snapshot cannot drop an incident.
+CREATE TABLE IF NOT EXISTS consultation_abuse_incidents (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    message_index INTEGER NOT NULL,
+    trigger TEXT NOT NULL,
+    response TEXT NOT NULL,
+    notified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_abuse_incidents_consultation ON consultation_abuse_incidents(consultation_id, created_at);
@@ -0,0 +1,2 @@
+-- Patient turn of the last Supervisor decision, used to space out its runs
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS supervisor_turn INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Revision of the doctor's report last dispatched; facts found after
+-- completion send the next one
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS report_revision INTEGER NOT NULL DEFAULT 0;
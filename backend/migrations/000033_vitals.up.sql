@@ -0,0 +1,13 @@
+-- Readings from waiting-room devices (BP cuff, thermometer, pulse oximeter).
+-- Kept out of the consultations row so agent writes cannot drop them.
+CREATE TABLE IF NOT EXISTS consultation_vitals (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    diastolic DOUBLE PRECISION,
+    device_id UUID,
+    measured_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_vitals_consultation ON consultation_vitals(consultation_id, measured_at);
@@ -0,0 +1,2 @@
+-- Summary of the patient's wearable export, see consultation.WearableSignals
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS wearables JSONB;
@@ -0,0 +1,2 @@
+-- Past and chronic diagnoses the patient mentioned, see consultation.PriorCondition
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS prior_conditions JSONB;
@@ -0,0 +1,16 @@
+-- Questions a doctor asked the assistant to put to the patient mid-interview.
+-- Kept out of the consultations row so turn saves cannot drop them.
+CREATE TABLE IF NOT EXISTS consultation_doctor_questions (
+    id UUID PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    author_id UUID NOT NULL,
+    author TEXT NOT NULL,
+    via TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    asked_at TIMESTAMP WITH TIME ZONE,
+    answer TEXT,
+    answered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_doctor_questions_consultation ON consultation_doctor_questions(consultation_id, created_at);
@@ -0,0 +1,2 @@
+-- AI summary of the facts for long reports, see consultation.ReportSize
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS fact_summary JSONB;
@@ -0,0 +1 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS report_recipients;
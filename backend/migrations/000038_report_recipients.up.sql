@@ -0,0 +1,2 @@
+-- Doctors who get the report besides the doctor on duty, see consultation.ReportRecipient
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS report_recipients JSONB;
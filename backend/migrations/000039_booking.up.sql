@@ -0,0 +1,2 @@
+-- Follow-up visit booked after completion, see consultation.Booking
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS booking JSONB;
@@ -0,0 +1,2 @@
+-- Report text in the doctor's language, see consultation.Translation
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS translation JSONB;
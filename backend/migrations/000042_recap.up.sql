@@ -0,0 +1,2 @@
+-- Read-back of the facts before completion, see consultation.Recap
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS recap JSONB;
@@ -0,0 +1,12 @@
+-- Thinking of reasoning models behind Communicator replies, kept for audit
+-- when LLM_REASONING_AUDIT is on; never shown to the patient
+CREATE TABLE IF NOT EXISTS consultation_reasoning (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    message_index INTEGER NOT NULL,
+    provider TEXT NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_reasoning_consultation ON consultation_reasoning(consultation_id, message_index);
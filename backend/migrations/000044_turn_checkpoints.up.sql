@@ -0,0 +1,14 @@
+-- One row per patient turn, so a restart can tell turns that died before
+-- the reply was saved from ones whose background agents did not finish
+CREATE TABLE IF NOT EXISTS turn_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    message_index INTEGER NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    stage TEXT NOT NULL,
+    force_complete BOOLEAN NOT NULL DEFAULT FALSE,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_turn_checkpoints_unfinished ON turn_checkpoints(started_at) WHERE stage IN ('started', 'answered');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_consultations_report_dispatched_at;
+ALTER TABLE consultations DROP COLUMN IF EXISTS acknowledgment;
+ALTER TABLE consultations DROP COLUMN IF EXISTS report_dispatched_at;
@@ -0,0 +1,5 @@
+-- Report dispatch and the doctor's acknowledgment, see consultation.Acknowledgment
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS report_dispatched_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS acknowledgment JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_consultations_report_dispatched_at ON consultations(report_dispatched_at) WHERE report_dispatched_at IS NOT NULL;
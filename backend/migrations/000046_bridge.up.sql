@@ -0,0 +1,2 @@
+-- Relay of patient messages to the nurse station during LLM outages, see consultation.Bridge
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS bridge JSONB;
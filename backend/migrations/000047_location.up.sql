@@ -0,0 +1,29 @@
+-- Waiting area a kiosk serves, see consultation.Location
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS building TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS floor TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS department TEXT NOT NULL DEFAULT '';
+
+-- New columns can only be appended to a view
+CREATE OR REPLACE VIEW consultation_summaries AS
+SELECT
+    id,
+    COALESCE(ticket, 0) AS ticket,
+    COALESCE(mode, 'standard') AS mode,
+    COALESCE(mood, '') AS mood,
+    COALESCE(is_complete, FALSE) AS is_complete,
+    CASE WHEN jsonb_typeof(history) = 'array' THEN jsonb_array_length(history) ELSE 0 END AS messages,
+    COALESCE(review->>'status', '') AS review_status,
+    COALESCE(visit->>'state', '') AS visit_state,
+    COALESCE(visit->>'room', '') AS room,
+    COALESCE((risk_screening->>'active')::BOOLEAN, FALSE) AS risk_active,
+    COALESCE(risk_screening->>'level', '') AS risk_level,
+    COALESCE(rule_findings @> '[{"triage": "red"}]', FALSE) AS red_flag,
+    created_at,
+    updated_at,
+    COALESCE(chief_complaint->>'text', '') AS chief_complaint,
+    COALESCE(assignment->>'room', '') AS assigned_room,
+    COALESCE(assignment->>'bed', '') AS assigned_bed,
+    COALESCE(device->>'building', '') AS building,
+    COALESCE(device->>'floor', '') AS floor,
+    COALESCE(department, '') AS department
+FROM consultations;
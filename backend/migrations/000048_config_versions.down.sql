@@ -0,0 +1,2 @@
+ALTER TABLE consultations DROP COLUMN IF EXISTS config_versions;
+DROP TABLE IF EXISTS config_versions;
@@ -0,0 +1,16 @@
+-- Versions of prompts, rules and checklists per tenant, see configversions.Version
+CREATE TABLE IF NOT EXISTS config_versions (
+    tenant TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    content JSONB NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    rollback_of INTEGER,
+    note TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant, kind, version)
+);
+
+-- The versions a consultation ran on, see consultation.ConfigStamp
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS config_versions JSONB;
@@ -0,0 +1,2 @@
+-- Spoken turn held back until the patient confirms it, see consultation.Clarification
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS clarification JSONB;
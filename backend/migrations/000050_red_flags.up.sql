@@ -0,0 +1,41 @@
+-- Emergencies found by the red-flag screen. Kept out of the consultations row
+-- so that agent writes of a stale snapshot cannot drop one.
+CREATE TABLE IF NOT EXISTS consultation_red_flags (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id UUID NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    message_index INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    indicator TEXT NOT NULL,
+    notified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- One alert per emergency: overlapping screens race on the insert
+    UNIQUE (consultation_id, category)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultation_red_flags_consultation ON consultation_red_flags(consultation_id, created_at);
+
+-- red_flag also covers the screen's findings
+CREATE OR REPLACE VIEW consultation_summaries AS
+SELECT
+    id,
+    COALESCE(ticket, 0) AS ticket,
+    COALESCE(mode, 'standard') AS mode,
+    COALESCE(mood, '') AS mood,
+    COALESCE(is_complete, FALSE) AS is_complete,
+    CASE WHEN jsonb_typeof(history) = 'array' THEN jsonb_array_length(history) ELSE 0 END AS messages,
+    COALESCE(review->>'status', '') AS review_status,
+    COALESCE(visit->>'state', '') AS visit_state,
+    COALESCE(visit->>'room', '') AS room,
+    COALESCE((risk_screening->>'active')::BOOLEAN, FALSE) AS risk_active,
+    COALESCE(risk_screening->>'level', '') AS risk_level,
+    COALESCE(rule_findings @> '[{"triage": "red"}]', FALSE)
+        OR EXISTS (SELECT 1 FROM consultation_red_flags f WHERE f.consultation_id = consultations.id) AS red_flag,
+    created_at,
+    updated_at,
+    COALESCE(chief_complaint->>'text', '') AS chief_complaint,
+    COALESCE(assignment->>'room', '') AS assigned_room,
+    COALESCE(assignment->>'bed', '') AS assigned_bed,
+    COALESCE(device->>'building', '') AS building,
+    COALESCE(device->>'floor', '') AS floor,
+    COALESCE(department, '') AS department
+FROM consultations;
@@ -0,0 +1,11 @@
+-- Logo and report texts per tenant, see branding.Branding
+CREATE TABLE IF NOT EXISTS tenant_branding (
+    tenant TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL DEFAULT '',
+    header TEXT NOT NULL DEFAULT '',
+    footer TEXT NOT NULL DEFAULT '',
+    logo BYTEA,
+    logo_type TEXT NOT NULL DEFAULT '',
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
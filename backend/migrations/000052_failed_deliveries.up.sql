@@ -0,0 +1,9 @@
+-- Reports waiting for a retry after a failed delivery, see report.FailedDelivery.
+-- recipients lists the named recipients still without the report; empty means
+-- the doctor chat failed and a retry sends it to everyone.
+CREATE TABLE IF NOT EXISTS report_failed_deliveries (
+    consultation_id UUID PRIMARY KEY REFERENCES consultations(id) ON DELETE CASCADE,
+    error TEXT NOT NULL DEFAULT '',
+    recipients JSONB NOT NULL DEFAULT '[]',
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
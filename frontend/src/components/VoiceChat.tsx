@@ -1,13 +1,19 @@
 import React, { useState, useEffect, useRef } from 'react';
 
+// Kiosk token for backends running with REQUIRE_KIOSK_AUTH=true
+const kioskToken = import.meta.env.VITE_KIOSK_TOKEN as string | undefined;
+const authHeaders: Record<string, string> = kioskToken ? { Authorization: `Bearer ${kioskToken}` } : {};
+
 const VoiceChat: React.FC = () => {
   const [isListening, setIsListening] = useState(false);
   const [isHandsFree, setIsHandsFree] = useState(true); // Default to true as requested
-  const [messages, setMessages] = useState<{role: string, text: string}[]>([]);
+  // screen is the short large-print version of a reply for hearing-impaired patients (?pacing=hearing)
+  const [messages, setMessages] = useState<{role: string, text: string, screen?: string}[]>([]);
   
   const mediaRecorderRef = useRef<MediaRecorder | null>(null);
   const chunksRef = useRef<Blob[]>([]);
   const consultationIdRef = useRef<string | null>(null);
+  const sessionTokenRef = useRef<string | null>(null);
   const isProcessingRef = useRef(false);
   const audioContextRef = useRef<AudioContext | null>(null);
   const silenceTimerRef = useRef<any>(null);
@@ -26,6 +32,18 @@ const VoiceChat: React.FC = () => {
   }, [isHandsFree]);
 
   const [isSpeaking, setIsSpeaking] = useState(false); // Visual feedback for VAD
+  // The patient asked to continue in text ("напишите"), replies are no longer voiced
+  const [isTextMode, setIsTextMode] = useState(false);
+  const [draft, setDraft] = useState('');
+  // Handoff to the patient's phone: the code shown here, and whether this device gave the session away
+  const [handoff, setHandoff] = useState<{code: string, url: string} | null>(null);
+  const [isMovedAway, setIsMovedAway] = useState(false);
+  // Queue number shown on the waiting-room board
+  const [ticket, setTicket] = useState<string | null>(null);
+  const [sttProgress, setSttProgress] = useState<{done: number, total: number} | null>(null);
+
+  const sessionHeaders = (): Record<string, string> =>
+    sessionTokenRef.current ? { ...authHeaders, 'X-Session-Token': sessionTokenRef.current } : authHeaders;
 
 
   useEffect(() => {
@@ -49,19 +67,99 @@ const VoiceChat: React.FC = () => {
   };
 
   const createConsultation = async () => {
+    const code = new URLSearchParams(window.location.search).get('handoff');
+    if (code) {
+      await claimHandoff(code);
+      return;
+    }
     try {
-      const res = await fetch('/api/consultation', {
+      // Interview mode can be preset per kiosk, e.g. ?mode=medication_reconciliation, ?pediatric=1, ?pacing=elderly or ?pacing=hearing
+      const params = new URLSearchParams(window.location.search);
+      const mode = params.get('mode') || undefined;
+      const pediatric = params.get('pediatric') === '1';
+      const pacing = params.get('pacing') || undefined;
+      const create = (options: {resume?: boolean, force?: boolean}) => fetch('/api/consultation', {
         method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ patient_id: "550e8400-e29b-41d4-a716-446655440000" }), // Demo Patient ID
+        headers: { 'Content-Type': 'application/json', ...authHeaders },
+        body: JSON.stringify({ patient_id: "550e8400-e29b-41d4-a716-446655440000", mode, pediatric, pacing, ...options }), // Demo Patient ID
       });
+      let res = await create({});
+      if (res.status === 409) {
+        // The patient already has an open consultation: continue it or start over
+        const open = await res.json();
+        const resume = window.confirm(`У вас есть незавершённая консультация${open.ticket ? ` (номер ${open.ticket})` : ''}. Продолжить её?`);
+        res = await create(resume ? { resume: true } : { force: true });
+      }
       const data = await res.json();
       consultationIdRef.current = data.consultation_id;
+      sessionTokenRef.current = data.session_token;
+      setTicket(data.ticket || null);
+      if (data.resumed) {
+        await loadTranscript(data.consultation_id);
+      } else if (data.opening) {
+        // The assistant introduces itself and says it is not a doctor
+        setMessages([{ role: 'assistant', text: data.opening }]);
+        if (data.opening_audio) {
+          await playBase64Audio(data.opening_audio);
+        }
+      }
     } catch (error) {
       console.error("Failed to create consultation", error);
     }
   };
 
+  // Continues a consultation started on another device (e.g. a kiosk) from the code in ?handoff=
+  const claimHandoff = async (code: string) => {
+    try {
+      const res = await fetch('/api/consultation/handoff', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', ...authHeaders },
+        body: JSON.stringify({ code }),
+      });
+      if (!res.ok) throw new Error(await res.text());
+      const data = await res.json();
+      consultationIdRef.current = data.consultation_id;
+      sessionTokenRef.current = data.session_token;
+      setTicket(data.ticket || null);
+      window.history.replaceState(null, '', window.location.pathname);
+      await loadTranscript(data.consultation_id);
+    } catch (error) {
+      console.error("Failed to claim handoff", error);
+    }
+  };
+
+  // Shows the conversation so far when continuing an existing consultation
+  const loadTranscript = async (id: string) => {
+    const transcript = await fetch(`/api/consultation/${id}/transcript`, { headers: sessionHeaders() });
+    const t = await transcript.json();
+    setMessages(t.messages
+      .filter((m: {role: string}) => m.role === 'user' || m.role === 'assistant')
+      .map((m: {role: string, content: string, screen?: string}) => ({ role: m.role, text: m.content, screen: m.screen })));
+  };
+
+  const startHandoff = async () => {
+    if (!consultationIdRef.current) return;
+    try {
+      const res = await fetch(`/api/consultation/${consultationIdRef.current}/handoff`, {
+        method: 'POST',
+        headers: sessionHeaders(),
+      });
+      const data = await res.json();
+      setHandoff({ code: data.code, url: window.location.origin + data.path });
+    } catch (error) {
+      console.error("Failed to start handoff", error);
+    }
+  };
+
+  // The consultation continues on another device; this one stops talking
+  const onMovedAway = () => {
+    setIsMovedAway(true);
+    setHandoff(null);
+    isManualStop.current = true;
+    isProcessingRef.current = false;
+    stopListening();
+  };
+
   const startListening = async () => {
       try {
         initAudioContext();
@@ -230,7 +328,10 @@ const VoiceChat: React.FC = () => {
   };
 
   const handleStreamEvent = (event: any) => {
-      if (event.type === 'user_text') {
+      if (event.type === 'stt_progress') {
+           setSttProgress(JSON.parse(event.data));
+      } else if (event.type === 'user_text') {
+           setSttProgress(null);
            setMessages((prev: {role: string, text: string}[]) => [...prev, { role: 'user', text: event.data }]);
       } else if (event.type === 'text') {
            setMessages((prev: {role: string, text: string}[]) => {
@@ -241,6 +342,19 @@ const VoiceChat: React.FC = () => {
                    return [...prev, { role: 'assistant', text: event.data }];
                }
            });
+      } else if (event.type === 'screen') {
+           setMessages((prev: {role: string, text: string, screen?: string}[]) => {
+               const last = prev[prev.length - 1];
+               if (last && last.role === 'assistant') {
+                   return [...prev.slice(0, -1), { ...last, screen: event.data }];
+               }
+               return [...prev, { role: 'assistant', text: '', screen: event.data }];
+           });
+      } else if (event.type === 'command') {
+           if (event.data === 'text_only') {
+               setIsTextMode(true);
+               setIsHandsFree(false);
+           }
       } else if (event.type === 'audio') {
            audioQueueRef.current.push(event.data);
            if (!isPlayingRef.current) {
@@ -255,8 +369,11 @@ const VoiceChat: React.FC = () => {
                    setTimeout(() => startListening(), 200);
                }
            }
+      } else if (event.type === 'handoff') {
+           onMovedAway();
       } else if (event.type === 'error') {
            console.error("Stream error:", event.data);
+           setSttProgress(null);
            isProcessingRef.current = false;
       }
   };
@@ -273,14 +390,20 @@ const VoiceChat: React.FC = () => {
     }
     
     const formData = new FormData();
-    formData.append('audio', audioBlob);
+    // consultation_id goes first so the backend can validate it before streaming audio to STT
     formData.append('consultation_id', consultationIdRef.current);
+    formData.append('audio', audioBlob);
 
     try {
         const response = await fetch('/api/consultation/audio/stream', {
             method: 'POST',
+            headers: sessionHeaders(),
             body: formData,
         });
+        if (response.status === 403) {
+            onMovedAway();
+            return;
+        }
 
         const reader = response.body?.getReader();
         if (!reader) {
@@ -321,6 +444,29 @@ const VoiceChat: React.FC = () => {
     }
   };
 
+  const sendText = async (e: React.FormEvent) => {
+    e.preventDefault();
+    const text = draft.trim();
+    if (!text || !consultationIdRef.current) return;
+    setDraft('');
+    setMessages((prev: {role: string, text: string}[]) => [...prev, { role: 'user', text }]);
+    try {
+      const res = await fetch('/api/consultation/chat', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', ...sessionHeaders() },
+        body: JSON.stringify({ consultation_id: consultationIdRef.current, text }),
+      });
+      if (res.status === 403) {
+        onMovedAway();
+        return;
+      }
+      const data = await res.json();
+      setMessages((prev: {role: string, text: string, screen?: string}[]) => [...prev, { role: 'assistant', text: data.response, screen: data.screen }]);
+    } catch (error) {
+      console.error("Failed to send text", error);
+    }
+  };
+
   const playBase64Audio = async (base64String: string, onEnd?: () => void) => {
       try {
         if (!audioContextRef.current) initAudioContext();
@@ -372,6 +518,12 @@ const VoiceChat: React.FC = () => {
             <p className="text-indigo-100 text-sm">Ваш персональный помощник здоровья</p>
           </div>
           <div className="flex items-center gap-4">
+             {ticket && (
+                <div className="text-right">
+                   <p className="text-indigo-100 text-xs">Ваш номер</p>
+                   <p className="text-2xl font-bold font-mono">{ticket}</p>
+                </div>
+             )}
              <div className="flex items-center gap-2 bg-indigo-700 px-3 py-1 rounded-full text-xs cursor-pointer" onClick={() => setIsHandsFree(!isHandsFree)}>
                 <div className={`w-2 h-2 rounded-full ${isHandsFree ? 'bg-green-400' : 'bg-gray-400'}`}></div>
                 {isHandsFree ? 'Hands-Free' : 'Push-to-Talk'}
@@ -404,14 +556,53 @@ const VoiceChat: React.FC = () => {
                 <p className="text-xs opacity-70 mb-1 font-medium uppercase tracking-wider">
                   {m.role === 'user' ? 'Вы' : 'Ассистент'}
                 </p>
-                <p className="leading-relaxed">{m.text}</p>
+                {m.screen ? (
+                  <>
+                    <p className="text-3xl font-semibold leading-snug">{m.screen}</p>
+                    <p className="mt-2 text-sm leading-relaxed opacity-70">{m.text}</p>
+                  </>
+                ) : (
+                  <p className="leading-relaxed">{m.text}</p>
+                )}
               </div>
             </div>
           ))}
+          {sttProgress && (
+            <div className="flex justify-end">
+              <div className="w-48 p-3 rounded-2xl bg-indigo-100 text-indigo-700 text-sm">
+                <p>Распознаю речь… {sttProgress.done}/{sttProgress.total}</p>
+                <div className="h-1 mt-2 bg-indigo-200 rounded-full">
+                  <div className="h-1 bg-indigo-600 rounded-full transition-all" style={{ width: `${100 * sttProgress.done / sttProgress.total}%` }}></div>
+                </div>
+              </div>
+            </div>
+          )}
         </div>
         
         {/* Controls */}
         <div className="p-6 bg-white border-t border-gray-100">
+          {handoff && (
+            <div className="mb-4 p-4 rounded-xl bg-indigo-50 text-center">
+              <p className="text-sm text-gray-600">Откройте ссылку на телефоне или введите код:</p>
+              <p className="text-3xl font-mono font-bold tracking-widest text-indigo-700 my-2">{handoff.code}</p>
+              <p className="text-xs text-gray-500 break-all">{handoff.url}</p>
+            </div>
+          )}
+          {isMovedAway ? (
+            <p className="text-center text-gray-600">Консультация продолжается на другом устройстве.</p>
+          ) : isTextMode ? (
+            <form onSubmit={sendText} className="flex gap-3">
+              <input
+                value={draft}
+                onChange={(e) => setDraft(e.target.value)}
+                placeholder="Напишите ответ..."
+                className="flex-1 px-4 py-3 rounded-xl border border-gray-200 focus:outline-none focus:border-indigo-500"
+              />
+              <button type="submit" className="px-6 py-3 rounded-xl bg-indigo-600 hover:bg-indigo-700 text-white font-bold">
+                Отправить
+              </button>
+            </form>
+          ) : (<>
           <button 
             onClick={toggleRecording}
             className={`w-full py-4 rounded-xl font-bold text-lg shadow-lg transition-all transform hover:scale-[1.02] active:scale-[0.98] flex items-center justify-center gap-3 ${
@@ -439,6 +630,12 @@ const VoiceChat: React.FC = () => {
                 ? "Режим Hands-Free включен. Ассистент будет слушать вас автоматически после своего ответа." 
                 : "Нажмите кнопку, чтобы записать ответ."}
           </p>
+          </>)}
+          {!isMovedAway && !handoff && (
+            <button onClick={startHandoff} className="w-full mt-3 text-sm text-indigo-600 hover:underline">
+              Продолжить на телефоне
+            </button>
+          )}
         </div>
       </div>
     </div>
@@ -1,5 +1,5 @@
 import torch
-from fastapi import FastAPI, HTTPException, UploadFile, File
+from fastapi import FastAPI, HTTPException, UploadFile, File, Form
 from pydantic import BaseModel
 import io
 import uvicorn
@@ -8,6 +8,7 @@ import soundfile as sf
 import numpy as np
 from faster_whisper import WhisperModel
 import os
+import subprocess
 import tempfile
 
 app = FastAPI()
@@ -36,6 +37,17 @@ class TTSRequest(BaseModel):
     text: str
     speaker: str = "kseniya" # Options: aidar, baya, kseniya, xenia, eugene
     sample_rate: int = 24000
+    rate: float = 1.0  # tempo, e.g. 0.85 for elderly patients; pitch is preserved
+    pause_ms: int = 0  # silence appended after the phrase
+    volume: float = 1.0  # gain, e.g. 1.5 when the patient asks to speak louder
+
+def change_tempo(wav: bytes, rate: float) -> bytes:
+    # ffmpeg's atempo keeps the pitch, unlike resampling; it accepts 0.5-2.0
+    rate = min(max(rate, 0.5), 2.0)
+    result = subprocess.run(
+        ["ffmpeg", "-loglevel", "error", "-i", "pipe:0", "-filter:a", f"atempo={rate}", "-f", "wav", "pipe:1"],
+        input=wav, capture_output=True, check=True)
+    return result.stdout
 
 @app.post("/generate")
 async def generate_audio(req: TTSRequest):
@@ -51,21 +63,29 @@ async def generate_audio(req: TTSRequest):
         # Convert tensor to wav bytes using soundfile directly
         # audio is a 1D tensor
         audio_np = audio.numpy()
+        if req.volume != 1.0:
+            audio_np = np.clip(audio_np * req.volume, -1.0, 1.0)
+        if req.pause_ms > 0:
+            silence = np.zeros(int(req.sample_rate * req.pause_ms / 1000), dtype=audio_np.dtype)
+            audio_np = np.concatenate([audio_np, silence])
         
         buffer = io.BytesIO()
         # Use PCM_16 to reduce file size (2 bytes per sample instead of 4 for float32)
         # This helps with network latency and "glitching"
         sf.write(buffer, audio_np, req.sample_rate, format='WAV', subtype='PCM_16')
         buffer.seek(0)
+        wav = buffer.read()
+        if abs(req.rate - 1.0) > 0.01:
+            wav = change_tempo(wav, req.rate)
         
-        return Response(content=buffer.read(), media_type="audio/wav")
+        return Response(content=wav, media_type="audio/wav")
         
     except Exception as e:
         print(f"Error generating audio: {e}")
         raise HTTPException(status_code=500, detail=str(e))
 
 @app.post("/transcribe")
-async def transcribe_audio(file: UploadFile = File(...)):
+async def transcribe_audio(file: UploadFile = File(...), language: str = Form("ru"), prompt: str = Form("")):
     try:
         # Save uploaded file to temp file because Whisper needs a file path
         with tempfile.NamedTemporaryFile(delete=False, suffix=".wav") as tmp:
@@ -73,16 +93,28 @@ async def transcribe_audio(file: UploadFile = File(...)):
             tmp.write(content)
             tmp_path = tmp.name
 
-        segments, info = stt_model.transcribe(tmp_path, beam_size=5, language="ru")
+        # An empty language lets Whisper detect it; the prompt lists drug names
+        # and clinic terms so they are spelled right
+        segments, info = stt_model.transcribe(
+            tmp_path,
+            beam_size=5,
+            language=language or None,
+            initial_prompt=prompt or None,
+            word_timestamps=True,
+        )
         
         text = ""
+        # Word probabilities let the backend ask the patient to confirm doubtful words
+        words = []
         for segment in segments:
             text += segment.text + " "
+            for word in segment.words or []:
+                words.append({"word": word.word.strip(), "confidence": word.probability})
             
         # Cleanup
         os.remove(tmp_path)
         
-        return {"text": text.strip(), "language": info.language}
+        return {"text": text.strip(), "language": info.language, "words": words}
 
     except Exception as e:
         print(f"Error transcribing audio: {e}")